@@ -1,6 +1,9 @@
 resource "aws_api_gateway_rest_api" "api" {
   name        = "${var.domain_name}-opentofu-registry"
   description = "API Gateway for the OpenTofu Registry"
+
+  // the lambda returns compressed (base64 encoded) bodies when the client accepts them
+  binary_media_types = ["*/*"]
 }
 
 resource "aws_api_gateway_resource" "github" {
@@ -51,6 +54,13 @@ resource "aws_api_gateway_resource" "providers_resource" {
   path_part   = "providers"
 }
 
+// a static path takes precedence over the {namespace} parameter next to it
+resource "aws_api_gateway_resource" "provider_search_resource" {
+  rest_api_id = aws_api_gateway_rest_api.api.id
+  parent_id   = aws_api_gateway_resource.providers_resource.id
+  path_part   = "search"
+}
+
 resource "aws_api_gateway_resource" "namespace_resource" {
   rest_api_id = aws_api_gateway_rest_api.api.id
   parent_id   = aws_api_gateway_resource.providers_resource.id
@@ -69,6 +79,24 @@ resource "aws_api_gateway_resource" "provider_versions_resource" {
   path_part   = "versions"
 }
 
+resource "aws_api_gateway_resource" "provider_latest_resource" {
+  rest_api_id = aws_api_gateway_rest_api.api.id
+  parent_id   = aws_api_gateway_resource.provider_type_resource.id
+  path_part   = "latest"
+}
+
+resource "aws_api_gateway_resource" "provider_downloads_resource" {
+  rest_api_id = aws_api_gateway_rest_api.api.id
+  parent_id   = aws_api_gateway_resource.provider_type_resource.id
+  path_part   = "downloads"
+}
+
+resource "aws_api_gateway_resource" "provider_downloads_summary_resource" {
+  rest_api_id = aws_api_gateway_rest_api.api.id
+  parent_id   = aws_api_gateway_resource.provider_downloads_resource.id
+  path_part   = "summary"
+}
+
 resource "aws_api_gateway_resource" "provider_version_resource" {
   rest_api_id = aws_api_gateway_rest_api.api.id
   parent_id   = aws_api_gateway_resource.provider_type_resource.id
@@ -99,6 +127,13 @@ resource "aws_api_gateway_resource" "modules_resource" {
   path_part   = "modules"
 }
 
+// a static path takes precedence over the {namespace} parameter next to it
+resource "aws_api_gateway_resource" "module_search_resource" {
+  rest_api_id = aws_api_gateway_rest_api.api.id
+  parent_id   = aws_api_gateway_resource.modules_resource.id
+  path_part   = "search"
+}
+
 resource "aws_api_gateway_resource" "modules_namespace_resource" {
   rest_api_id = aws_api_gateway_rest_api.api.id
   parent_id   = aws_api_gateway_resource.modules_resource.id
@@ -135,6 +170,45 @@ resource "aws_api_gateway_resource" "module_versions_resource" {
   path_part   = "versions"
 }
 
+resource "aws_api_gateway_resource" "v2_resource" {
+  rest_api_id = aws_api_gateway_rest_api.api.id
+  parent_id   = aws_api_gateway_rest_api.api.root_resource_id
+  path_part   = "v2"
+}
+
+// the v2 API is routed inside the lambda, so we proxy everything below /v2 to it
+resource "aws_api_gateway_resource" "v2_proxy_resource" {
+  rest_api_id = aws_api_gateway_rest_api.api.id
+  parent_id   = aws_api_gateway_resource.v2_resource.id
+  path_part   = "{proxy+}"
+}
+
+resource "aws_api_gateway_resource" "admin_resource" {
+  rest_api_id = aws_api_gateway_rest_api.api.id
+  parent_id   = aws_api_gateway_rest_api.api.root_resource_id
+  path_part   = "admin"
+}
+
+// the admin API is routed inside the lambda, so we proxy everything below /admin to it
+resource "aws_api_gateway_resource" "admin_proxy_resource" {
+  rest_api_id = aws_api_gateway_rest_api.api.id
+  parent_id   = aws_api_gateway_resource.admin_resource.id
+  path_part   = "{proxy+}"
+}
+
+resource "aws_api_gateway_resource" "tfc_api_resource" {
+  rest_api_id = aws_api_gateway_rest_api.api.id
+  parent_id   = aws_api_gateway_rest_api.api.root_resource_id
+  path_part   = "api"
+}
+
+// the Terraform Cloud compatible API is routed inside the lambda, so we proxy everything below /api to it
+resource "aws_api_gateway_resource" "tfc_api_proxy_resource" {
+  rest_api_id = aws_api_gateway_rest_api.api.id
+  parent_id   = aws_api_gateway_resource.tfc_api_resource.id
+  path_part   = "{proxy+}"
+}
+
 resource "aws_api_gateway_method" "provider_download_method" {
   rest_api_id   = aws_api_gateway_rest_api.api.id
   resource_id   = aws_api_gateway_resource.provider_arch_resource.id
@@ -142,11 +216,13 @@ resource "aws_api_gateway_method" "provider_download_method" {
   authorization = "NONE"
 
   request_parameters = {
-    "method.request.path.namespace" = true,
-    "method.request.path.type"      = true,
-    "method.request.path.version"   = true,
-    "method.request.path.os"        = true,
-    "method.request.path.arch"      = true,
+    "method.request.path.namespace"       = true,
+    "method.request.path.type"            = true,
+    "method.request.path.version"         = true,
+    "method.request.path.os"              = true,
+    "method.request.path.arch"            = true,
+    "method.request.header.Authorization" = false,
+    "method.request.header.If-None-Match" = false,
   }
 }
 
@@ -165,6 +241,10 @@ resource "aws_api_gateway_integration" "provider_download_integration" {
     "method.request.path.version",
     "method.request.path.os",
     "method.request.path.arch",
+    // private providers and tokens passed through to GitHub get responses of their own
+    "method.request.header.Authorization",
+    // the revalidations get a 304, which must not be served to the other clients
+    "method.request.header.If-None-Match",
   ]
 }
 
@@ -175,8 +255,11 @@ resource "aws_api_gateway_method" "provider_list_versions_method" {
   authorization = "NONE"
 
   request_parameters = {
-    "method.request.path.namespace" = true,
-    "method.request.path.type"      = true,
+    "method.request.path.namespace"       = true,
+    "method.request.path.type"            = true,
+    "method.request.header.Authorization" = false,
+    "method.request.header.Origin"        = false,
+    "method.request.header.If-None-Match" = false,
   }
 }
 
@@ -192,6 +275,103 @@ resource "aws_api_gateway_integration" "provider_list_versions_integration" {
   cache_key_parameters = [
     "method.request.path.namespace",
     "method.request.path.type",
+    "method.request.header.Authorization",
+    // the CORS headers depend on the origin of the request
+    "method.request.header.Origin",
+    // the revalidations get a 304, which must not be served to the other clients
+    "method.request.header.If-None-Match",
+  ]
+}
+
+resource "aws_api_gateway_method" "provider_downloads_summary_method" {
+  rest_api_id   = aws_api_gateway_rest_api.api.id
+  resource_id   = aws_api_gateway_resource.provider_downloads_summary_resource.id
+  http_method   = "GET"
+  authorization = "NONE"
+
+  request_parameters = {
+    "method.request.path.namespace"       = true,
+    "method.request.path.type"            = true,
+    "method.request.header.Authorization" = false,
+  }
+}
+
+resource "aws_api_gateway_integration" "provider_downloads_summary_integration" {
+  rest_api_id = aws_api_gateway_rest_api.api.id
+  resource_id = aws_api_gateway_resource.provider_downloads_summary_resource.id
+  http_method = aws_api_gateway_method.provider_downloads_summary_method.http_method
+
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = aws_lambda_function.api_function.invoke_arn
+
+  cache_key_parameters = [
+    "method.request.path.namespace",
+    "method.request.path.type",
+    "method.request.header.Authorization",
+  ]
+}
+
+resource "aws_api_gateway_method" "provider_latest_method" {
+  rest_api_id   = aws_api_gateway_rest_api.api.id
+  resource_id   = aws_api_gateway_resource.provider_latest_resource.id
+  http_method   = "GET"
+  authorization = "NONE"
+
+  request_parameters = {
+    "method.request.path.namespace"       = true,
+    "method.request.path.type"            = true,
+    "method.request.header.Authorization" = false,
+    "method.request.header.If-None-Match" = false,
+  }
+}
+
+resource "aws_api_gateway_integration" "provider_latest_integration" {
+  rest_api_id = aws_api_gateway_rest_api.api.id
+  resource_id = aws_api_gateway_resource.provider_latest_resource.id
+  http_method = aws_api_gateway_method.provider_latest_method.http_method
+
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = aws_lambda_function.api_function.invoke_arn
+
+  cache_key_parameters = [
+    "method.request.path.namespace",
+    "method.request.path.type",
+    "method.request.header.Authorization",
+    // the revalidations get a 304, which must not be served to the other clients
+    "method.request.header.If-None-Match",
+  ]
+}
+
+resource "aws_api_gateway_method" "module_latest_method" {
+  rest_api_id   = aws_api_gateway_rest_api.api.id
+  resource_id   = aws_api_gateway_resource.modules_system_resource.id
+  http_method   = "GET"
+  authorization = "NONE"
+
+  request_parameters = {
+    "method.request.path.namespace"       = true,
+    "method.request.path.name"            = true,
+    "method.request.path.system"          = true,
+    "method.request.header.Authorization" = false,
+  }
+}
+
+resource "aws_api_gateway_integration" "module_latest_integration" {
+  rest_api_id = aws_api_gateway_rest_api.api.id
+  resource_id = aws_api_gateway_resource.modules_system_resource.id
+  http_method = aws_api_gateway_method.module_latest_method.http_method
+
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = aws_lambda_function.api_function.invoke_arn
+
+  cache_key_parameters = [
+    "method.request.path.namespace",
+    "method.request.path.name",
+    "method.request.path.system",
+    "method.request.header.Authorization",
   ]
 }
 
@@ -202,10 +382,11 @@ resource "aws_api_gateway_method" "module_download_method" {
   authorization = "NONE"
 
   request_parameters = {
-    "method.request.path.namespace" = true,
-    "method.request.path.name"      = true,
-    "method.request.path.system"    = true,
-    "method.request.path.version"   = true,
+    "method.request.path.namespace"       = true,
+    "method.request.path.name"            = true,
+    "method.request.path.system"          = true,
+    "method.request.path.version"         = true,
+    "method.request.header.Authorization" = false,
   }
 }
 
@@ -223,6 +404,7 @@ resource "aws_api_gateway_integration" "module_download_integration" {
     "method.request.path.name",
     "method.request.path.system",
     "method.request.path.version",
+    "method.request.header.Authorization",
   ]
 }
 
@@ -233,9 +415,11 @@ resource "aws_api_gateway_method" "module_list_versions_method" {
   authorization = "NONE"
 
   request_parameters = {
-    "method.request.path.namespace" = true,
-    "method.request.path.name"      = true,
-    "method.request.path.system"    = true,
+    "method.request.path.namespace"       = true,
+    "method.request.path.name"            = true,
+    "method.request.path.system"          = true,
+    "method.request.header.Authorization" = false,
+    "method.request.header.Origin"        = false,
   }
 }
 
@@ -252,9 +436,85 @@ resource "aws_api_gateway_integration" "module_list_versions_integration" {
     "method.request.path.namespace",
     "method.request.path.name",
     "method.request.path.system",
+    "method.request.header.Authorization",
+    // the CORS headers depend on the origin of the request
+    "method.request.header.Origin",
   ]
 }
 
+resource "aws_api_gateway_method" "module_version_metadata_method" {
+  rest_api_id   = aws_api_gateway_rest_api.api.id
+  resource_id   = aws_api_gateway_resource.module_version_resource.id
+  http_method   = "GET"
+  authorization = "NONE"
+
+  request_parameters = {
+    "method.request.path.namespace"       = true,
+    "method.request.path.name"            = true,
+    "method.request.path.system"          = true,
+    "method.request.path.version"         = true,
+    "method.request.header.Authorization" = false,
+    "method.request.header.Origin"        = false,
+    "method.request.header.If-None-Match" = false,
+  }
+}
+
+resource "aws_api_gateway_integration" "module_version_metadata_integration" {
+  rest_api_id = aws_api_gateway_rest_api.api.id
+  resource_id = aws_api_gateway_resource.module_version_resource.id
+  http_method = aws_api_gateway_method.module_version_metadata_method.http_method
+
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = aws_lambda_function.api_function.invoke_arn
+
+  cache_key_parameters = [
+    "method.request.path.namespace",
+    "method.request.path.name",
+    "method.request.path.system",
+    "method.request.path.version",
+    "method.request.header.Authorization",
+    // the CORS headers depend on the origin of the request
+    "method.request.header.Origin",
+    // the revalidations get a 304, which must not be served to the other clients
+    "method.request.header.If-None-Match",
+  ]
+}
+
+resource "aws_api_gateway_method" "provider_search_method" {
+  rest_api_id   = aws_api_gateway_rest_api.api.id
+  resource_id   = aws_api_gateway_resource.provider_search_resource.id
+  http_method   = "GET"
+  authorization = "NONE"
+}
+
+resource "aws_api_gateway_integration" "provider_search_integration" {
+  rest_api_id = aws_api_gateway_rest_api.api.id
+  resource_id = aws_api_gateway_resource.provider_search_resource.id
+  http_method = aws_api_gateway_method.provider_search_method.http_method
+
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = aws_lambda_function.api_function.invoke_arn
+}
+
+resource "aws_api_gateway_method" "module_search_method" {
+  rest_api_id   = aws_api_gateway_rest_api.api.id
+  resource_id   = aws_api_gateway_resource.module_search_resource.id
+  http_method   = "GET"
+  authorization = "NONE"
+}
+
+resource "aws_api_gateway_integration" "module_search_integration" {
+  rest_api_id = aws_api_gateway_rest_api.api.id
+  resource_id = aws_api_gateway_resource.module_search_resource.id
+  http_method = aws_api_gateway_method.module_search_method.http_method
+
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = aws_lambda_function.api_function.invoke_arn
+}
+
 resource "aws_api_gateway_method" "metadata_method" {
   rest_api_id   = aws_api_gateway_rest_api.api.id
   resource_id   = aws_api_gateway_resource.terraform_json.id
@@ -272,6 +532,101 @@ resource "aws_api_gateway_integration" "metadata_integration" {
   uri                     = aws_lambda_function.api_function.invoke_arn
 }
 
+// the CORS preflight requests of the v1 endpoints called by browser-based frontends are answered by the lambda, the
+// proxied APIs pass them on whatever the method
+locals {
+  cors_preflight_resources = length(var.cors_allowed_origins) == 0 ? {} : {
+    provider_versions = aws_api_gateway_resource.provider_versions_resource.id
+    module_versions   = aws_api_gateway_resource.module_versions_resource.id
+    module_version    = aws_api_gateway_resource.module_version_resource.id
+    provider_search   = aws_api_gateway_resource.provider_search_resource.id
+    module_search     = aws_api_gateway_resource.module_search_resource.id
+    terraform_json    = aws_api_gateway_resource.terraform_json.id
+  }
+}
+
+resource "aws_api_gateway_method" "cors_preflight_method" {
+  for_each      = local.cors_preflight_resources
+  rest_api_id   = aws_api_gateway_rest_api.api.id
+  resource_id   = each.value
+  http_method   = "OPTIONS"
+  authorization = "NONE"
+}
+
+resource "aws_api_gateway_integration" "cors_preflight_integration" {
+  for_each    = local.cors_preflight_resources
+  rest_api_id = aws_api_gateway_rest_api.api.id
+  resource_id = each.value
+  http_method = aws_api_gateway_method.cors_preflight_method[each.key].http_method
+
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = aws_lambda_function.api_function.invoke_arn
+}
+
+resource "aws_api_gateway_method" "v2_proxy_method" {
+  rest_api_id   = aws_api_gateway_rest_api.api.id
+  resource_id   = aws_api_gateway_resource.v2_proxy_resource.id
+  http_method   = "ANY"
+  authorization = "NONE"
+
+  request_parameters = {
+    "method.request.path.proxy" = true,
+  }
+}
+
+resource "aws_api_gateway_integration" "v2_proxy_integration" {
+  rest_api_id = aws_api_gateway_rest_api.api.id
+  resource_id = aws_api_gateway_resource.v2_proxy_resource.id
+  http_method = aws_api_gateway_method.v2_proxy_method.http_method
+
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = aws_lambda_function.api_function.invoke_arn
+}
+
+resource "aws_api_gateway_method" "admin_proxy_method" {
+  rest_api_id   = aws_api_gateway_rest_api.api.id
+  resource_id   = aws_api_gateway_resource.admin_proxy_resource.id
+  http_method   = "ANY"
+  authorization = "NONE"
+
+  request_parameters = {
+    "method.request.path.proxy" = true,
+  }
+}
+
+resource "aws_api_gateway_integration" "admin_proxy_integration" {
+  rest_api_id = aws_api_gateway_rest_api.api.id
+  resource_id = aws_api_gateway_resource.admin_proxy_resource.id
+  http_method = aws_api_gateway_method.admin_proxy_method.http_method
+
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = aws_lambda_function.api_function.invoke_arn
+}
+
+resource "aws_api_gateway_method" "tfc_api_proxy_method" {
+  rest_api_id   = aws_api_gateway_rest_api.api.id
+  resource_id   = aws_api_gateway_resource.tfc_api_proxy_resource.id
+  http_method   = "GET"
+  authorization = "NONE"
+
+  request_parameters = {
+    "method.request.path.proxy" = true,
+  }
+}
+
+resource "aws_api_gateway_integration" "tfc_api_proxy_integration" {
+  rest_api_id = aws_api_gateway_rest_api.api.id
+  resource_id = aws_api_gateway_resource.tfc_api_proxy_resource.id
+  http_method = aws_api_gateway_method.tfc_api_proxy_method.http_method
+
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = aws_lambda_function.api_function.invoke_arn
+}
+
 resource "aws_api_gateway_method" "github_rest_method" {
   rest_api_id   = aws_api_gateway_rest_api.api.id
   resource_id   = aws_api_gateway_resource.github_rest_proxy.id
@@ -340,15 +695,45 @@ resource "aws_api_gateway_deployment" "deployment" {
     aws_api_gateway_method.provider_list_versions_method,
     aws_api_gateway_integration.provider_list_versions_integration,
 
+    aws_api_gateway_method.provider_downloads_summary_method,
+    aws_api_gateway_integration.provider_downloads_summary_integration,
+
+    aws_api_gateway_method.provider_latest_method,
+    aws_api_gateway_integration.provider_latest_integration,
+
+    aws_api_gateway_method.module_latest_method,
+    aws_api_gateway_integration.module_latest_integration,
+
     aws_api_gateway_method.module_download_method,
     aws_api_gateway_integration.module_download_integration,
 
     aws_api_gateway_method.module_list_versions_method,
     aws_api_gateway_integration.module_list_versions_integration,
 
+    aws_api_gateway_method.module_version_metadata_method,
+    aws_api_gateway_integration.module_version_metadata_integration,
+
+    aws_api_gateway_method.provider_search_method,
+    aws_api_gateway_integration.provider_search_integration,
+
+    aws_api_gateway_method.module_search_method,
+    aws_api_gateway_integration.module_search_integration,
+
     aws_api_gateway_method.metadata_method,
     aws_api_gateway_integration.metadata_integration,
 
+    aws_api_gateway_method.cors_preflight_method,
+    aws_api_gateway_integration.cors_preflight_integration,
+
+    aws_api_gateway_method.v2_proxy_method,
+    aws_api_gateway_integration.v2_proxy_integration,
+
+    aws_api_gateway_method.admin_proxy_method,
+    aws_api_gateway_integration.admin_proxy_integration,
+
+    aws_api_gateway_method.tfc_api_proxy_method,
+    aws_api_gateway_integration.tfc_api_proxy_integration,
+
     aws_api_gateway_method.github_rest_method,
     aws_api_gateway_integration.github_rest_integration,
 
@@ -367,6 +752,19 @@ resource "aws_api_gateway_deployment" "deployment" {
   }
 }
 
+# The errors API Gateway answers itself, e.g. when the Lambda times out, carry the request ID like the responses of the
+# registry, which adopts the API Gateway request ID.
+resource "aws_api_gateway_gateway_response" "request_id" {
+  for_each = toset(["DEFAULT_4XX", "DEFAULT_5XX"])
+
+  rest_api_id   = aws_api_gateway_rest_api.api.id
+  response_type = each.key
+
+  response_parameters = {
+    "gatewayresponse.header.X-Request-Id" = "context.requestId"
+  }
+}
+
 resource "aws_cloudwatch_log_group" "apigw_log_group" {
   name              = "/aws/lambda/${replace(var.domain_name, ".", "-")}-apigw"
   retention_in_days = 7
@@ -426,6 +824,42 @@ resource "aws_api_gateway_method_settings" "provider_list_versions_method_settin
   }
 }
 
+resource "aws_api_gateway_method_settings" "provider_latest_method_settings" {
+  rest_api_id = aws_api_gateway_rest_api.api.id
+  stage_name  = aws_api_gateway_stage.stage.stage_name
+
+  # This encodes `/` as `~1` to provide the correct path for the method
+  method_path = "~1v1~1providers~1{namespace}~1{type}~1latest/GET"
+
+  settings {
+    metrics_enabled    = true
+    logging_level      = "INFO"
+    data_trace_enabled = true
+    caching_enabled    = true
+    // 60 minutes, like the versions listing the latest version is computed from
+    cache_ttl_in_seconds                    = (60 * 60)
+    require_authorization_for_cache_control = false
+  }
+}
+
+resource "aws_api_gateway_method_settings" "module_latest_method_settings" {
+  rest_api_id = aws_api_gateway_rest_api.api.id
+  stage_name  = aws_api_gateway_stage.stage.stage_name
+
+  # This encodes `/` as `~1` to provide the correct path for the method
+  method_path = "~1v1~1modules~1{namespace}~1{name}~1{system}/GET"
+
+  settings {
+    metrics_enabled    = true
+    logging_level      = "INFO"
+    data_trace_enabled = true
+    caching_enabled    = true
+    // 60 minutes, like the versions listing the latest version is computed from
+    cache_ttl_in_seconds                    = (60 * 60)
+    require_authorization_for_cache_control = false
+  }
+}
+
 resource "aws_api_gateway_method_settings" "module_download_method_settings" {
   rest_api_id = aws_api_gateway_rest_api.api.id
   stage_name  = aws_api_gateway_stage.stage.stage_name
@@ -462,6 +896,24 @@ resource "aws_api_gateway_method_settings" "module_list_versions_method_settings
   }
 }
 
+resource "aws_api_gateway_method_settings" "module_version_metadata_method_settings" {
+  rest_api_id = aws_api_gateway_rest_api.api.id
+  stage_name  = aws_api_gateway_stage.stage.stage_name
+
+  # This encodes `/` as `~1` to provide the correct path for the method
+  method_path = "~1v1~1modules~1{namespace}~1{name}~1{system}~1{version}/GET"
+
+  settings {
+    metrics_enabled    = true
+    logging_level      = "INFO"
+    data_trace_enabled = true
+    caching_enabled    = true
+    // parsing the module archive is slow, and releases do not change
+    cache_ttl_in_seconds                    = (60 * 60)
+    require_authorization_for_cache_control = false
+  }
+}
+
 resource "aws_api_gateway_method_settings" "well_known_method_settings" {
   rest_api_id = aws_api_gateway_rest_api.api.id
   stage_name  = aws_api_gateway_stage.stage.stage_name
@@ -8,4 +8,223 @@ resource "aws_dynamodb_table" "provider_versions" {
     name = "provider"
     type = "S"
   }
-}
\ No newline at end of file
+
+  // lists the providers of a namespace, see `/v2/namespaces/{namespace}`
+  attribute {
+    name = "namespace"
+    type = "S"
+  }
+
+  global_secondary_index {
+    name            = "namespace-index"
+    hash_key        = "namespace"
+    projection_type = "KEYS_ONLY"
+  }
+
+  // lists the keys of the providers in order under a single partition, for the provider search to query instead of
+  // scanning the table
+  attribute {
+    name = "search_partition"
+    type = "S"
+  }
+
+  global_secondary_index {
+    name            = "search-index"
+    hash_key        = "search_partition"
+    range_key       = "provider"
+    projection_type = "KEYS_ONLY"
+  }
+}
+// the module versions fetched from GitHub, keyed by `<owner>/<repository>`
+resource "aws_dynamodb_table" "module_versions" {
+  name         = "${var.domain_name}-module-versions"
+  billing_mode = "PAY_PER_REQUEST"
+
+  hash_key = "repository"
+
+  attribute {
+    name = "repository"
+    type = "S"
+  }
+}
+
+resource "aws_dynamodb_table" "deprecations" {
+  name         = "${var.domain_name}-deprecations"
+  billing_mode = "PAY_PER_REQUEST"
+
+  hash_key = "address"
+
+  attribute {
+    name = "address"
+    type = "S"
+  }
+}
+
+resource "aws_dynamodb_table" "provider_aliases" {
+  name         = "${var.domain_name}-provider-aliases"
+  billing_mode = "PAY_PER_REQUEST"
+
+  hash_key = "from"
+
+  attribute {
+    name = "from"
+    type = "S"
+  }
+}
+
+// repositories mapped by hand to providers and modules, managed through the admin API
+resource "aws_dynamodb_table" "repository_mappings" {
+  name         = "${var.domain_name}-repository-mappings"
+  billing_mode = "PAY_PER_REQUEST"
+
+  hash_key = "address"
+
+  attribute {
+    name = "address"
+    type = "S"
+  }
+}
+
+// provider namespace redirects managed through the admin API, applied on top of var.provider_namespace_redirects
+resource "aws_dynamodb_table" "provider_redirects" {
+  name         = "${var.domain_name}-provider-redirects"
+  billing_mode = "PAY_PER_REQUEST"
+
+  hash_key = "from"
+
+  attribute {
+    name = "from"
+    type = "S"
+  }
+}
+
+// the token buckets rate limiting the clients of the API, keyed by `ip:<source IP>`, `key:<API key ID>` or
+// `token:<SHA-256 of the read token>`, deleted once full again
+resource "aws_dynamodb_table" "rate_limits" {
+  name         = "${var.domain_name}-rate-limits"
+  billing_mode = "PAY_PER_REQUEST"
+
+  hash_key = "client"
+
+  attribute {
+    name = "client"
+    type = "S"
+  }
+
+  ttl {
+    attribute_name = "expires_at"
+    enabled        = true
+  }
+}
+
+resource "aws_dynamodb_table" "quarantine" {
+  name         = "${var.domain_name}-quarantine"
+  billing_mode = "PAY_PER_REQUEST"
+
+  hash_key = "provider"
+
+  attribute {
+    name = "provider"
+    type = "S"
+  }
+}
+
+// the GPG keys registered through `/admin/v1/gpg-keys/{namespace}`, keyed by namespace, `<tenant>/<namespace>` for
+// tenants, and key ID
+resource "aws_dynamodb_table" "gpg_keys" {
+  name         = "${var.domain_name}-gpg-keys"
+  billing_mode = "PAY_PER_REQUEST"
+
+  hash_key  = "owner"
+  range_key = "key_id"
+
+  attribute {
+    name = "owner"
+    type = "S"
+  }
+
+  attribute {
+    name = "key_id"
+    type = "S"
+  }
+}
+
+// the downloads served, counted by `providers/<namespace>/<type>` or `modules/<namespace>/<name>/<system>`, prefixed
+// with the tenant for tenants, and by `<version>/<os>_<arch>`, or `<version>` for modules, and in daily rollups by
+// `<kind>#<yyyy-mm-dd>` and address, deleted once past the longest ranking period
+resource "aws_dynamodb_table" "download_stats" {
+  name         = "${var.domain_name}-download-stats"
+  billing_mode = "PAY_PER_REQUEST"
+
+  hash_key  = "subject"
+  range_key = "entry"
+
+  attribute {
+    name = "subject"
+    type = "S"
+  }
+
+  attribute {
+    name = "entry"
+    type = "S"
+  }
+
+  ttl {
+    attribute_name = "expires_at"
+    enabled        = true
+  }
+}
+
+// the ETags of the GitHub release listings of the providers' repositories, keyed by `<owner>/<repo>`, prefixed with the
+// tenant for tenants
+resource "aws_dynamodb_table" "release_etags" {
+  name         = "${var.domain_name}-release-etags"
+  billing_mode = "PAY_PER_REQUEST"
+
+  hash_key = "repository"
+
+  attribute {
+    name = "repository"
+    type = "S"
+  }
+}
+
+resource "aws_dynamodb_table" "tenant_provider_versions" {
+  for_each = var.tenants
+
+  name         = "${var.domain_name}-${each.value.name}-provider-versions"
+  billing_mode = "PAY_PER_REQUEST"
+
+  hash_key = "provider"
+
+  attribute {
+    name = "provider"
+    type = "S"
+  }
+
+  // lists the providers of a namespace, see `/v2/namespaces/{namespace}`
+  attribute {
+    name = "namespace"
+    type = "S"
+  }
+
+  global_secondary_index {
+    name            = "namespace-index"
+    hash_key        = "namespace"
+    projection_type = "KEYS_ONLY"
+  }
+
+  // lists the keys of the providers in order under a single partition, for the provider search to query instead of
+  // scanning the table
+  attribute {
+    name = "search_partition"
+    type = "S"
+  }
+
+  global_secondary_index {
+    name            = "search-index"
+    hash_key        = "search_partition"
+    range_key       = "provider"
+    projection_type = "KEYS_ONLY"
+  }
+}
@@ -19,9 +19,10 @@ data "aws_iam_policy_document" "github_api_token_secrets_iam_policy" {
       "secretsmanager:GetSecretValue",
     ]
 
-    resources = [
+    resources = concat([
       aws_secretsmanager_secret.github_api_token.arn,
-    ]
+      aws_secretsmanager_secret.admin_api_token.arn,
+    ], aws_secretsmanager_secret.oci_registry_credentials[*].arn, aws_secretsmanager_secret.gitlab_token[*].arn, aws_secretsmanager_secret.namespace_github_tokens[*].arn, aws_secretsmanager_secret.github_token_pool[*].arn, aws_secretsmanager_secret.github_app_private_key[*].arn, aws_secretsmanager_secret.release_webhook_secret[*].arn, aws_secretsmanager_secret.private_providers[*].arn, aws_secretsmanager_secret.asset_mirror_cloudfront_private_key[*].arn)
   }
 }
 
@@ -99,9 +100,20 @@ data "aws_iam_policy_document" "dynamodb_policy" {
       "dynamodb:BatchWriteItem"
     ]
 
-    resources = [
-      aws_dynamodb_table.provider_versions.arn
-    ]
+    resources = concat([
+      aws_dynamodb_table.provider_versions.arn,
+      "${aws_dynamodb_table.provider_versions.arn}/index/*",
+      aws_dynamodb_table.module_versions.arn,
+      aws_dynamodb_table.deprecations.arn,
+      aws_dynamodb_table.provider_aliases.arn,
+      aws_dynamodb_table.repository_mappings.arn,
+      aws_dynamodb_table.provider_redirects.arn,
+      aws_dynamodb_table.rate_limits.arn,
+      aws_dynamodb_table.quarantine.arn,
+      aws_dynamodb_table.gpg_keys.arn,
+      aws_dynamodb_table.download_stats.arn,
+      aws_dynamodb_table.release_etags.arn
+    ], [for table in aws_dynamodb_table.tenant_provider_versions : table.arn], [for table in aws_dynamodb_table.tenant_provider_versions : "${table.arn}/index/*"])
   }
 }
 
@@ -141,4 +153,144 @@ resource "aws_iam_role_policy_attachment" "lambda_populate_provider_versions_pol
   policy_arn = aws_iam_policy.lambda_populate_provider_versions_policy.arn
 }
 
+// allow the populate_provider_versions lambda to enqueue refreshes, and the worker lambda to consume them
+data "aws_iam_policy_document" "refresh_queue_policy" {
+  count = var.refresh_schedule == "" ? 0 : 1
+
+  statement {
+    effect = "Allow"
+    actions = [
+      "sqs:SendMessage",
+      "sqs:ReceiveMessage",
+      "sqs:DeleteMessage",
+      "sqs:GetQueueAttributes"
+    ]
+
+    resources = [
+      aws_sqs_queue.refresh[0].arn
+    ]
+  }
+}
+
+resource "aws_iam_policy" "lambda_refresh_queue_policy" {
+  count = var.refresh_schedule == "" ? 0 : 1
+
+  name        = "${var.domain_name}-RegistryLambdaRefreshQueuePolicy"
+  description = "Policy for the registry lambdas to send and receive the provider refreshes"
+  policy      = data.aws_iam_policy_document.refresh_queue_policy[0].json
+}
+
+resource "aws_iam_role_policy_attachment" "lambda_refresh_queue_policy_attachment" {
+  count = var.refresh_schedule == "" ? 0 : 1
+
+  role       = aws_iam_role.lambda.id
+  policy_arn = aws_iam_policy.lambda_refresh_queue_policy[0].arn
+}
+
+// allow the api_function lambda to pre-sign downloads from the asset mirror bucket, and the
+// populate_provider_versions lambda to copy the release assets into it and to tell missing objects
+// apart from forbidden ones
+data "aws_iam_policy_document" "asset_mirror_policy" {
+  count = var.asset_mirror_bucket != "" ? 1 : 0
+
+  statement {
+    effect = "Allow"
+    actions = concat([
+      "s3:GetObject"
+    ], var.asset_mirror_populate ? ["s3:PutObject"] : [])
+
+    resources = [
+      "arn:aws:s3:::${var.asset_mirror_bucket}/providers/*",
+      "arn:aws:s3:::${var.asset_mirror_bucket}/objects/*"
+    ]
+  }
 
+  dynamic "statement" {
+    for_each = var.asset_mirror_populate ? [1] : []
+    content {
+      effect    = "Allow"
+      actions   = ["s3:ListBucket"]
+      resources = ["arn:aws:s3:::${var.asset_mirror_bucket}"]
+    }
+  }
+}
+
+resource "aws_iam_policy" "lambda_asset_mirror_policy" {
+  count = var.asset_mirror_bucket != "" ? 1 : 0
+
+  name        = "${var.domain_name}-RegistryLambdaAssetMirrorPolicy"
+  description = "Policy for the registry lambda to pre-sign downloads from the asset mirror bucket"
+  policy      = data.aws_iam_policy_document.asset_mirror_policy[0].json
+}
+
+resource "aws_iam_role_policy_attachment" "lambda_asset_mirror_policy_attachment" {
+  count = var.asset_mirror_bucket != "" ? 1 : 0
+
+  role       = aws_iam_role.lambda.id
+  policy_arn = aws_iam_policy.lambda_asset_mirror_policy[0].arn
+}
+
+// allow the populate_provider_versions lambda to keep and replay the ingestion snapshots
+data "aws_iam_policy_document" "ingestion_snapshot_policy" {
+  count = var.ingestion_snapshot_bucket != "" ? 1 : 0
+
+  statement {
+    effect = "Allow"
+    actions = [
+      "s3:GetObject",
+      "s3:PutObject"
+    ]
+
+    resources = [
+      "arn:aws:s3:::${var.ingestion_snapshot_bucket}/snapshots/*"
+    ]
+  }
+}
+
+resource "aws_iam_policy" "lambda_ingestion_snapshot_policy" {
+  count = var.ingestion_snapshot_bucket != "" ? 1 : 0
+
+  name        = "${var.domain_name}-RegistryLambdaIngestionSnapshotPolicy"
+  description = "Policy for the registry lambda to keep and replay the ingestion snapshots"
+  policy      = data.aws_iam_policy_document.ingestion_snapshot_policy[0].json
+}
+
+resource "aws_iam_role_policy_attachment" "lambda_ingestion_snapshot_policy_attachment" {
+  count = var.ingestion_snapshot_bucket != "" ? 1 : 0
+
+  role       = aws_iam_role.lambda.id
+  policy_arn = aws_iam_policy.lambda_ingestion_snapshot_policy[0].arn
+}
+
+// allow the lambdas to keep and serve the large versions listings and cached versions
+data "aws_iam_policy_document" "large_listings_policy" {
+  count = var.large_listings_bucket != "" ? 1 : 0
+
+  statement {
+    effect = "Allow"
+    actions = [
+      "s3:GetObject",
+      "s3:PutObject"
+    ]
+
+    resources = [
+      "arn:aws:s3:::${var.large_listings_bucket}/listings/*",
+      "arn:aws:s3:::${var.large_listings_bucket}/cache/*"
+    ]
+  }
+}
+
+resource "aws_iam_policy" "lambda_large_listings_policy" {
+  count = var.large_listings_bucket != "" ? 1 : 0
+
+  name        = "${var.domain_name}-RegistryLambdaLargeListingsPolicy"
+  description = "Policy for the registry lambda to keep and serve the large versions listings"
+  policy      = data.aws_iam_policy_document.large_listings_policy[0].json
+}
+
+resource "aws_iam_role_policy_attachment" "lambda_large_listings_policy_attachment" {
+  count = var.large_listings_bucket != "" ? 1 : 0
+
+  role       = aws_iam_role.lambda.id
+  policy_arn = aws_iam_policy.lambda_large_listings_policy[0].arn
+}
@@ -1,3 +1,13 @@
+locals {
+  registry_tenants = jsonencode({
+    for hostname, tenant in var.tenants : hostname => {
+      name                         = tenant.name
+      provider_versions_table_name = aws_dynamodb_table.tenant_provider_versions[hostname].name
+      provider_namespace_redirects = tenant.provider_namespace_redirects
+    }
+  })
+}
+
 resource "null_resource" "api_function_binary" {
   provisioner "local-exec" {
     command     = "GOOS=linux GOARCH=amd64 CGO_ENABLED=0 GOFLAGS=-trimpath go build -mod=readonly -tags lambda.norpc -ldflags='-s -w' -o ../api_function_bootstrap/bootstrap ./lambda/api"
@@ -20,6 +30,32 @@ resource "null_resource" "populate_provider_versions_binary" {
   }
 }
 
+resource "null_resource" "populate_provider_versions_worker_binary" {
+  count = var.refresh_schedule == "" ? 0 : 1
+
+  provisioner "local-exec" {
+    command     = "GOOS=linux GOARCH=amd64 CGO_ENABLED=0 GOFLAGS=-trimpath go build -mod=readonly -tags lambda.norpc -ldflags='-s -w' -o ../populate_provider_versions_worker_bootstrap/bootstrap ./lambda/populate_provider_versions_worker"
+    working_dir = "./src"
+  }
+
+  triggers = {
+    always_run = timestamp()
+  }
+}
+
+resource "null_resource" "release_webhook_binary" {
+  count = var.release_webhook_secret == "" ? 0 : 1
+
+  provisioner "local-exec" {
+    command     = "GOOS=linux GOARCH=amd64 CGO_ENABLED=0 GOFLAGS=-trimpath go build -mod=readonly -tags lambda.norpc -ldflags='-s -w' -o ../release_webhook_bootstrap/bootstrap ./lambda/release_webhook"
+    working_dir = "./src"
+  }
+
+  triggers = {
+    always_run = timestamp()
+  }
+}
+
 data "archive_file" "api_function_archive" {
   depends_on = [null_resource.api_function_binary]
 
@@ -36,6 +72,24 @@ data "archive_file" "populate_provider_versions_archive" {
   output_path = "populate_provider_versions_bootstrap.zip"
 }
 
+data "archive_file" "populate_provider_versions_worker_archive" {
+  count      = var.refresh_schedule == "" ? 0 : 1
+  depends_on = [null_resource.populate_provider_versions_worker_binary]
+
+  type        = "zip"
+  source_file = "./populate_provider_versions_worker_bootstrap/bootstrap"
+  output_path = "populate_provider_versions_worker_bootstrap.zip"
+}
+
+data "archive_file" "release_webhook_archive" {
+  count      = var.release_webhook_secret == "" ? 0 : 1
+  depends_on = [null_resource.release_webhook_binary]
+
+  type        = "zip"
+  source_file = "./release_webhook_bootstrap/bootstrap"
+  output_path = "release_webhook_bootstrap.zip"
+}
+
 // create the lambda function from zip file
 resource "aws_lambda_function" "api_function" {
   function_name = "${replace(var.domain_name, ".", "-")}-registry-handler"
@@ -59,10 +113,67 @@ resource "aws_lambda_function" "api_function" {
   environment {
     variables = {
       GITHUB_TOKEN_SECRET_ASM_NAME             = aws_secretsmanager_secret.github_api_token.name
+      ADMIN_API_TOKEN_SECRET_ASM_NAME          = aws_secretsmanager_secret.admin_api_token.name
       PROVIDER_NAMESPACE_REDIRECTS             = jsonencode(var.provider_namespace_redirects)
+      PROVIDER_REDIRECTS_TABLE_NAME            = aws_dynamodb_table.provider_redirects.name
+      PROVIDER_REDIRECTS_REFRESH_INTERVAL      = var.provider_redirects_refresh_interval
+      MODULE_SOURCE_REWRITES                   = jsonencode(var.module_source_rewrites)
+      MODULE_ARCHIVE_SOURCES                   = var.module_archive_sources
+      MODULE_STABLE_ARCHIVES                   = var.module_stable_archives
+      MODULE_REPOSITORIES                      = jsonencode(var.module_repositories)
       PROVIDER_VERSIONS_TABLE_NAME             = aws_dynamodb_table.provider_versions.name
+      MODULE_VERSIONS_TABLE_NAME               = aws_dynamodb_table.module_versions.name
+      CACHE_SCHEMA_WRITE_VERSION               = var.cache_schema_write_version
+      FAULT_INJECTION                          = jsonencode(var.fault_injection)
+      DEPRECATIONS_TABLE_NAME                  = aws_dynamodb_table.deprecations.name
+      PROVIDER_ALIASES_TABLE_NAME              = aws_dynamodb_table.provider_aliases.name
+      REPOSITORY_MAPPINGS_TABLE_NAME           = aws_dynamodb_table.repository_mappings.name
+      QUARANTINE_TABLE_NAME                    = aws_dynamodb_table.quarantine.name
+      GPG_KEYS_TABLE_NAME                      = aws_dynamodb_table.gpg_keys.name
+      DOWNLOAD_STATS_TABLE_NAME                = aws_dynamodb_table.download_stats.name
+      RATE_LIMIT_TABLE_NAME                    = aws_dynamodb_table.rate_limits.name
+      RATE_LIMIT_REQUESTS_PER_MINUTE           = var.rate_limit_requests_per_minute
+      RATE_LIMIT_BURST                         = var.rate_limit_burst
       POPULATE_PROVIDER_VERSIONS_FUNCTION_NAME = aws_lambda_function.populate_provider_versions_function.function_name
       GITHUB_API_GW_URL                        = var.domain_name
+      REGISTRY_HOSTNAMES                       = join(",", concat([var.domain_name], var.additional_hostnames))
+      CORS_ALLOWED_ORIGINS                     = join(",", var.cors_allowed_origins)
+      SERVICE_DISCOVERY_PROVIDERS_V2           = var.service_discovery_providers_v2
+      SERVICE_DISCOVERY_LOGIN                  = jsonencode(var.service_discovery_login)
+      SERVICE_DISCOVERY_SERVICES               = jsonencode(var.service_discovery_services)
+      REGISTRY_TENANTS                         = local.registry_tenants
+      OCI_PROVIDERS                            = jsonencode(var.oci_providers)
+      GITLAB_NAMESPACES                        = jsonencode(var.gitlab_namespaces)
+      GITLAB_URL                               = var.gitlab_url
+      ASSET_NAME_PATTERNS                      = jsonencode(var.asset_name_patterns)
+      PROVIDER_REPOSITORIES                    = jsonencode(var.provider_repositories)
+      PLATFORM_ALLOWLIST                       = jsonencode(var.platform_allowlist)
+      ASSET_MIRROR_BUCKET                      = var.asset_mirror_bucket
+      ASSET_MIRROR_URL_TTL                     = var.asset_mirror_url_ttl
+      ASSET_MIRROR_CLOUDFRONT_URL              = var.asset_mirror_cloudfront_url
+      ASSET_MIRROR_CLOUDFRONT_KEY_PAIR_ID      = var.asset_mirror_cloudfront_key_pair_id
+      ASSET_MIRROR_CLOUDFRONT_SECRET_ASM_NAME  = join("", aws_secretsmanager_secret.asset_mirror_cloudfront_private_key[*].name)
+      REGIONAL_MIRRORS                         = jsonencode(var.regional_mirrors)
+      V1_VERSIONS_PAGE_SIZE                    = var.v1_versions_page_size
+      LARGE_LISTINGS_BUCKET                    = var.large_listings_bucket
+      LARGE_LISTINGS_URL                       = var.large_listings_url
+      PROVIDER_CACHE_TTL                       = var.provider_cache_ttl
+      NOT_FOUND_CACHE_TTL                      = var.not_found_cache_ttl
+      MODULE_CACHE_TTL                         = var.module_cache_ttl
+      RESPONSE_MAX_AGE                         = var.response_max_age
+      METRICS_NAMESPACE                        = var.metrics_namespace
+      SERVE_STALE                              = var.serve_stale
+      REFRESH_QUEUE_URL                        = join("", aws_sqs_queue.refresh[*].url)
+      GITHUB_TOKEN_PASSTHROUGH                 = var.github_token_passthrough
+      MAINTENANCE_MODE                         = var.maintenance_mode
+      OCI_REGISTRY_CREDENTIALS_SECRET_ASM_NAME = join("", aws_secretsmanager_secret.oci_registry_credentials[*].name)
+      GITLAB_TOKEN_SECRET_ASM_NAME             = join("", aws_secretsmanager_secret.gitlab_token[*].name)
+      NAMESPACE_GITHUB_TOKENS_SECRET_ASM_NAME  = join("", aws_secretsmanager_secret.namespace_github_tokens[*].name)
+      GITHUB_TOKEN_POOL_SECRET_ASM_NAME        = join("", aws_secretsmanager_secret.github_token_pool[*].name)
+      GITHUB_APP_PRIVATE_KEY_SECRET_ASM_NAME   = join("", aws_secretsmanager_secret.github_app_private_key[*].name)
+      GITHUB_APP_ID                            = var.github_app_id
+      GITHUB_APP_INSTALLATION_ID               = var.github_app_installation_id
+      PRIVATE_PROVIDERS_SECRET_ASM_NAME        = join("", aws_secretsmanager_secret.private_providers[*].name)
     }
   }
 }
@@ -95,8 +206,46 @@ resource "aws_lambda_function" "populate_provider_versions_function" {
   environment {
     variables = {
       PROVIDER_VERSIONS_TABLE_NAME = aws_dynamodb_table.provider_versions.name
+      CACHE_SCHEMA_WRITE_VERSION   = var.cache_schema_write_version
+      FAULT_INJECTION              = jsonencode(var.fault_injection)
       GITHUB_TOKEN_SECRET_ASM_NAME = aws_secretsmanager_secret.github_api_token.name
       GITHUB_API_GW_URL            = var.domain_name
+      REGISTRY_TENANTS             = local.registry_tenants
+      OCI_PROVIDERS                = jsonencode(var.oci_providers)
+      GITLAB_NAMESPACES            = jsonencode(var.gitlab_namespaces)
+      GITLAB_URL                   = var.gitlab_url
+      INGESTION_QUOTAS             = jsonencode(var.ingestion_quotas)
+      ASSET_NAME_PATTERNS          = jsonencode(var.asset_name_patterns)
+      PROVIDER_REPOSITORIES        = jsonencode(var.provider_repositories)
+      PLATFORM_ALLOWLIST           = jsonencode(var.platform_allowlist)
+      QUARANTINE_TABLE_NAME        = aws_dynamodb_table.quarantine.name
+      CHECKSUM_RECONCILE_VERSIONS  = var.checksum_reconcile_versions
+      REFRESH_QUARANTINE_FAILURES  = var.refresh_quarantine_failures
+      REFRESH_WORKERS              = var.refresh_workers
+      PROVIDER_CACHE_TTL           = var.provider_cache_ttl
+      NOT_FOUND_CACHE_TTL          = var.not_found_cache_ttl
+      MODULE_CACHE_TTL             = var.module_cache_ttl
+      MODULE_VERSIONS_TABLE_NAME   = aws_dynamodb_table.module_versions.name
+      INGESTION_SNAPSHOT_BUCKET    = var.ingestion_snapshot_bucket
+      LARGE_LISTINGS_BUCKET        = var.large_listings_bucket
+      LARGE_LISTING_THRESHOLD      = var.large_listing_threshold
+      ASSET_MIRROR_BUCKET          = var.asset_mirror_bucket
+      ASSET_MIRROR_POPULATE        = var.asset_mirror_populate
+      MODULE_STABLE_ARCHIVES       = var.module_stable_archives
+      MAINTENANCE_MODE             = var.maintenance_mode
+      DRIFT_AUTO_HEAL              = var.drift_auto_heal
+      RELEASE_ETAGS_TABLE_NAME     = aws_dynamodb_table.release_etags.name
+      REFRESH_QUEUE_URL            = join("", aws_sqs_queue.refresh[*].url)
+      METRICS_NAMESPACE            = var.metrics_namespace
+
+      REPOSITORY_MAPPINGS_TABLE_NAME           = aws_dynamodb_table.repository_mappings.name
+      OCI_REGISTRY_CREDENTIALS_SECRET_ASM_NAME = join("", aws_secretsmanager_secret.oci_registry_credentials[*].name)
+      GITLAB_TOKEN_SECRET_ASM_NAME             = join("", aws_secretsmanager_secret.gitlab_token[*].name)
+      NAMESPACE_GITHUB_TOKENS_SECRET_ASM_NAME  = join("", aws_secretsmanager_secret.namespace_github_tokens[*].name)
+      GITHUB_TOKEN_POOL_SECRET_ASM_NAME        = join("", aws_secretsmanager_secret.github_token_pool[*].name)
+      GITHUB_APP_PRIVATE_KEY_SECRET_ASM_NAME   = join("", aws_secretsmanager_secret.github_app_private_key[*].name)
+      GITHUB_APP_ID                            = var.github_app_id
+      GITHUB_APP_INSTALLATION_ID               = var.github_app_installation_id
     }
   }
 }
@@ -116,3 +265,156 @@ resource "aws_cloudwatch_log_group" "log_group" {
   name              = "/aws/lambda/${aws_lambda_function.api_function.function_name}"
   retention_in_days = 7
 }
+
+// periodically check a sample of the cached providers for drift from GitHub
+resource "aws_cloudwatch_event_rule" "drift_check" {
+  count               = var.drift_check_schedule == "" ? 0 : 1
+  name                = "${replace(var.domain_name, ".", "-")}-drift-check"
+  description         = "Compares a sample of the cached providers with their releases on GitHub"
+  schedule_expression = var.drift_check_schedule
+}
+
+resource "aws_cloudwatch_event_target" "drift_check" {
+  count = var.drift_check_schedule == "" ? 0 : 1
+  rule  = aws_cloudwatch_event_rule.drift_check[0].name
+  arn   = aws_lambda_function.populate_provider_versions_function.arn
+  input = jsonencode({ drift = true, sample = var.drift_check_sample })
+}
+
+resource "aws_lambda_permission" "drift_check_invoke_lambda_permission" {
+  count         = var.drift_check_schedule == "" ? 0 : 1
+  statement_id  = "AllowEventBridgeInvoke"
+  action        = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.populate_provider_versions_function.function_name
+  principal     = "events.amazonaws.com"
+  source_arn    = aws_cloudwatch_event_rule.drift_check[0].arn
+}
+
+// periodically check the asset mirror objects of a sample of the cached providers
+resource "aws_cloudwatch_event_rule" "asset_mirror_reconcile" {
+  count               = var.asset_mirror_reconcile_schedule == "" ? 0 : 1
+  name                = "${replace(var.domain_name, ".", "-")}-asset-mirror-reconcile"
+  description         = "Repairs the missing and corrupted asset mirror objects of a sample of the cached providers"
+  schedule_expression = var.asset_mirror_reconcile_schedule
+}
+
+resource "aws_cloudwatch_event_target" "asset_mirror_reconcile" {
+  count = var.asset_mirror_reconcile_schedule == "" ? 0 : 1
+  rule  = aws_cloudwatch_event_rule.asset_mirror_reconcile[0].name
+  arn   = aws_lambda_function.populate_provider_versions_function.arn
+  input = jsonencode({ mirror_reconcile = true, sample = var.asset_mirror_reconcile_sample })
+}
+
+resource "aws_lambda_permission" "asset_mirror_reconcile_invoke_lambda_permission" {
+  count         = var.asset_mirror_reconcile_schedule == "" ? 0 : 1
+  statement_id  = "AllowEventBridgeInvokeAssetMirrorReconcile"
+  action        = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.populate_provider_versions_function.function_name
+  principal     = "events.amazonaws.com"
+  source_arn    = aws_cloudwatch_event_rule.asset_mirror_reconcile[0].arn
+}
+
+// periodically enqueue a refresh of every cached provider, see sqs.tf
+resource "aws_cloudwatch_event_rule" "refresh" {
+  count               = var.refresh_schedule == "" ? 0 : 1
+  name                = "${replace(var.domain_name, ".", "-")}-provider-refresh"
+  description         = "Enqueues a refresh of every cached provider"
+  schedule_expression = var.refresh_schedule
+}
+
+resource "aws_cloudwatch_event_target" "refresh" {
+  count = var.refresh_schedule == "" ? 0 : 1
+  rule  = aws_cloudwatch_event_rule.refresh[0].name
+  arn   = aws_lambda_function.populate_provider_versions_function.arn
+  input = jsonencode({ enqueue = true })
+}
+
+resource "aws_lambda_permission" "refresh_invoke_lambda_permission" {
+  count         = var.refresh_schedule == "" ? 0 : 1
+  statement_id  = "AllowEventBridgeInvokeRefresh"
+  action        = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.populate_provider_versions_function.function_name
+  principal     = "events.amazonaws.com"
+  source_arn    = aws_cloudwatch_event_rule.refresh[0].arn
+}
+
+// refresh the providers of the refresh queue one by one
+resource "aws_lambda_function" "populate_provider_versions_worker_function" {
+  count         = var.refresh_schedule == "" ? 0 : 1
+  function_name = "${replace(var.domain_name, ".", "-")}-populate-provider-versions-worker"
+  description   = "A lambda to refresh the providers of the refresh queue"
+  role          = aws_iam_role.lambda.arn
+  handler       = "populate-provider-versions-worker"
+  memory_size   = 128
+  timeout       = 10 * 60
+
+  filename         = data.archive_file.populate_provider_versions_worker_archive[0].output_path
+  source_code_hash = data.archive_file.populate_provider_versions_worker_archive[0].output_base64sha256
+
+  runtime = "provided.al2"
+
+  tracing_config {
+    mode = "Active"
+  }
+
+  // the worker refreshes providers exactly like the populate lambda
+  environment {
+    variables = aws_lambda_function.populate_provider_versions_function.environment[0].variables
+  }
+}
+
+// refresh the cache as soon as GitHub notifies of a release, see the release_webhook_url output
+resource "aws_lambda_function" "release_webhook_function" {
+  count         = var.release_webhook_secret == "" ? 0 : 1
+  function_name = "${replace(var.domain_name, ".", "-")}-release-webhook"
+  description   = "A lambda to refresh the cache on GitHub release webhooks"
+  role          = aws_iam_role.lambda.arn
+  handler       = "release-webhook"
+  memory_size   = 128
+  timeout       = 10
+
+  filename         = data.archive_file.release_webhook_archive[0].output_path
+  source_code_hash = data.archive_file.release_webhook_archive[0].output_base64sha256
+
+  runtime = "provided.al2"
+
+  tracing_config {
+    mode = "Active"
+  }
+
+  environment {
+    variables = {
+      RELEASE_WEBHOOK_SECRET_ASM_NAME          = aws_secretsmanager_secret.release_webhook_secret[0].name
+      GITHUB_TOKEN_SECRET_ASM_NAME             = aws_secretsmanager_secret.github_api_token.name
+      PROVIDER_VERSIONS_TABLE_NAME             = aws_dynamodb_table.provider_versions.name
+      MODULE_VERSIONS_TABLE_NAME               = aws_dynamodb_table.module_versions.name
+      REPOSITORY_MAPPINGS_TABLE_NAME           = aws_dynamodb_table.repository_mappings.name
+      REGISTRY_TENANTS                         = local.registry_tenants
+      LARGE_LISTINGS_BUCKET                    = var.large_listings_bucket
+      MODULE_REPOSITORIES                      = jsonencode(var.module_repositories)
+      PROVIDER_REPOSITORIES                    = jsonencode(var.provider_repositories)
+      POPULATE_PROVIDER_VERSIONS_FUNCTION_NAME = aws_lambda_function.populate_provider_versions_function.function_name
+      REFRESH_QUEUE_URL                        = join("", aws_sqs_queue.refresh[*].url)
+      GITHUB_API_GW_URL                        = var.domain_name
+      MAINTENANCE_MODE                         = var.maintenance_mode
+      METRICS_NAMESPACE                        = var.metrics_namespace
+      NAMESPACE_GITHUB_TOKENS_SECRET_ASM_NAME  = join("", aws_secretsmanager_secret.namespace_github_tokens[*].name)
+      GITHUB_TOKEN_POOL_SECRET_ASM_NAME        = join("", aws_secretsmanager_secret.github_token_pool[*].name)
+      GITHUB_APP_PRIVATE_KEY_SECRET_ASM_NAME   = join("", aws_secretsmanager_secret.github_app_private_key[*].name)
+      GITHUB_APP_ID                            = var.github_app_id
+      GITHUB_APP_INSTALLATION_ID               = var.github_app_installation_id
+    }
+  }
+}
+
+// the webhooks are authenticated by their signature
+resource "aws_lambda_function_url" "release_webhook" {
+  count              = var.release_webhook_secret == "" ? 0 : 1
+  function_name      = aws_lambda_function.release_webhook_function[0].function_name
+  authorization_type = "NONE"
+}
+
+output "release_webhook_url" {
+  description = "Payload URL of the GitHub release webhooks, empty unless release_webhook_secret is set."
+  value       = join("", aws_lambda_function_url.release_webhook[*].function_url)
+}
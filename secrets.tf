@@ -6,3 +6,100 @@ resource "aws_secretsmanager_secret_version" "github_api_token" {
   secret_id     = aws_secretsmanager_secret.github_api_token.id
   secret_string = var.github_api_token
 }
+
+resource "aws_secretsmanager_secret" "github_app_private_key" {
+  count = var.github_app_id == "" ? 0 : 1
+  name  = "${var.domain_name}-github_app_private_key"
+}
+
+resource "aws_secretsmanager_secret_version" "github_app_private_key" {
+  count         = var.github_app_id == "" ? 0 : 1
+  secret_id     = aws_secretsmanager_secret.github_app_private_key[0].id
+  secret_string = var.github_app_private_key
+}
+
+resource "aws_secretsmanager_secret" "asset_mirror_cloudfront_private_key" {
+  count = var.asset_mirror_cloudfront_url == "" ? 0 : 1
+  name  = "${var.domain_name}-asset_mirror_cloudfront_private_key"
+}
+
+resource "aws_secretsmanager_secret_version" "asset_mirror_cloudfront_private_key" {
+  count         = var.asset_mirror_cloudfront_url == "" ? 0 : 1
+  secret_id     = aws_secretsmanager_secret.asset_mirror_cloudfront_private_key[0].id
+  secret_string = var.asset_mirror_cloudfront_private_key
+}
+
+resource "aws_secretsmanager_secret" "github_token_pool" {
+  count = length(var.github_token_pool) == 0 ? 0 : 1
+  name  = "${var.domain_name}-github_token_pool"
+}
+
+resource "aws_secretsmanager_secret_version" "github_token_pool" {
+  count         = length(var.github_token_pool) == 0 ? 0 : 1
+  secret_id     = aws_secretsmanager_secret.github_token_pool[0].id
+  secret_string = jsonencode(var.github_token_pool)
+}
+
+resource "aws_secretsmanager_secret" "admin_api_token" {
+  name = "${var.domain_name}-admin_api_token"
+}
+
+resource "aws_secretsmanager_secret_version" "admin_api_token" {
+  secret_id     = aws_secretsmanager_secret.admin_api_token.id
+  secret_string = var.admin_api_token
+}
+
+resource "aws_secretsmanager_secret" "oci_registry_credentials" {
+  count = var.oci_registry_credentials == "" ? 0 : 1
+  name  = "${var.domain_name}-oci_registry_credentials"
+}
+
+resource "aws_secretsmanager_secret_version" "oci_registry_credentials" {
+  count         = var.oci_registry_credentials == "" ? 0 : 1
+  secret_id     = aws_secretsmanager_secret.oci_registry_credentials[0].id
+  secret_string = var.oci_registry_credentials
+}
+
+resource "aws_secretsmanager_secret" "gitlab_token" {
+  count = var.gitlab_token == "" ? 0 : 1
+  name  = "${var.domain_name}-gitlab_token"
+}
+
+resource "aws_secretsmanager_secret_version" "gitlab_token" {
+  count         = var.gitlab_token == "" ? 0 : 1
+  secret_id     = aws_secretsmanager_secret.gitlab_token[0].id
+  secret_string = var.gitlab_token
+}
+
+resource "aws_secretsmanager_secret" "namespace_github_tokens" {
+  count = length(var.namespace_github_tokens) == 0 ? 0 : 1
+  name  = "${var.domain_name}-namespace_github_tokens"
+}
+
+resource "aws_secretsmanager_secret_version" "namespace_github_tokens" {
+  count         = length(var.namespace_github_tokens) == 0 ? 0 : 1
+  secret_id     = aws_secretsmanager_secret.namespace_github_tokens[0].id
+  secret_string = jsonencode(var.namespace_github_tokens)
+}
+
+resource "aws_secretsmanager_secret" "private_providers" {
+  count = length(var.private_providers) == 0 ? 0 : 1
+  name  = "${var.domain_name}-private_providers"
+}
+
+resource "aws_secretsmanager_secret_version" "private_providers" {
+  count         = length(var.private_providers) == 0 ? 0 : 1
+  secret_id     = aws_secretsmanager_secret.private_providers[0].id
+  secret_string = jsonencode(var.private_providers)
+}
+
+resource "aws_secretsmanager_secret" "release_webhook_secret" {
+  count = var.release_webhook_secret == "" ? 0 : 1
+  name  = "${var.domain_name}-release_webhook_secret"
+}
+
+resource "aws_secretsmanager_secret_version" "release_webhook_secret" {
+  count         = var.release_webhook_secret == "" ? 0 : 1
+  secret_id     = aws_secretsmanager_secret.release_webhook_secret[0].id
+  secret_string = var.release_webhook_secret
+}
@@ -0,0 +1,37 @@
+// the providers to refresh, one message each, sent by the populate lambda on the refresh schedule
+resource "aws_sqs_queue" "refresh" {
+  count = var.refresh_schedule == "" ? 0 : 1
+  name  = "${replace(var.domain_name, ".", "-")}-provider-refresh"
+
+  // the worker must be done with a message before it is delivered again
+  visibility_timeout_seconds = 6 * aws_lambda_function.populate_provider_versions_worker_function[0].timeout
+  message_retention_seconds  = 24 * 60 * 60
+
+  redrive_policy = jsonencode({
+    deadLetterTargetArn = aws_sqs_queue.refresh_dead_letter[0].arn
+    maxReceiveCount     = var.refresh_max_receive_count
+  })
+}
+
+// the refreshes which failed refresh_max_receive_count times, kept for investigation
+resource "aws_sqs_queue" "refresh_dead_letter" {
+  count = var.refresh_schedule == "" ? 0 : 1
+  name  = "${replace(var.domain_name, ".", "-")}-provider-refresh-dead-letter"
+
+  message_retention_seconds = 14 * 24 * 60 * 60
+}
+
+resource "aws_lambda_event_source_mapping" "refresh" {
+  count            = var.refresh_schedule == "" ? 0 : 1
+  event_source_arn = aws_sqs_queue.refresh[0].arn
+  function_name    = aws_lambda_function.populate_provider_versions_worker_function[0].arn
+  // the messages of a batch are refreshed in parallel, see REFRESH_WORKERS
+  batch_size = var.refresh_workers
+
+  function_response_types = ["ReportBatchItemFailures"]
+
+  // bounds the GitHub calls made at the same time
+  scaling_config {
+    maximum_concurrency = var.refresh_concurrency
+  }
+}
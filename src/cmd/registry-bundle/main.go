@@ -0,0 +1,78 @@
+// registry-bundle assembles an offline bundle of providers for air-gapped environments.
+//
+//	registry-bundle -provider 'hashicorp/aws:~> 5.0' -provider hashicorp/random -platform linux_amd64 -out bundle.zip
+//
+// The bundle is a zip archive laid out as a provider mirror, see the bundle package for its contents.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/opentofu/registry/internal/bundle"
+	"github.com/opentofu/registry/internal/platform"
+)
+
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+func main() {
+	var providerFlags, platformFlags stringList
+	registry := flag.String("registry", "registry.opentofu.org", "hostname of the registry to download the providers from")
+	out := flag.String("out", "bundle.zip", "path of the bundle to write")
+	flag.Var(&providerFlags, "provider", "provider to bundle, as <namespace>/<type>[:<constraints>], can be repeated")
+	flag.Var(&platformFlags, "platform", "platform to bundle, as <os>_<arch>, can be repeated")
+	flag.Parse()
+
+	if err := run(*registry, *out, providerFlags, platformFlags); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(registry, out string, providerFlags, platformFlags []string) error {
+	if len(providerFlags) == 0 || len(platformFlags) == 0 {
+		return fmt.Errorf("at least one -provider and one -platform are required")
+	}
+
+	requirements := make([]bundle.Requirement, len(providerFlags))
+	for i, value := range providerFlags {
+		requirement, err := bundle.ParseRequirement(value)
+		if err != nil {
+			return err
+		}
+		requirements[i] = requirement
+	}
+
+	platforms := make([]platform.Platform, len(platformFlags))
+	for i, value := range platformFlags {
+		p, err := bundle.ParsePlatform(value)
+		if err != nil {
+			return err
+		}
+		platforms[i] = p
+	}
+
+	f, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+
+	if err := bundle.NewBuilder(registry).Build(context.Background(), f, requirements, platforms); err != nil {
+		f.Close()
+		os.Remove(out)
+		return err
+	}
+	return f.Close()
+}
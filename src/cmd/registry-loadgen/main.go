@@ -0,0 +1,127 @@
+// registry-loadgen replays a traffic mix against a deployed registry and reports the latency and error distributions.
+//
+//	registry-loadgen -target https://registry.example.com -mix versions -provider hashicorp/aws -provider hashicorp/random -workers 20 -duration 1m
+//
+// See the loadgen package for the mixes.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/opentofu/registry/internal/loadgen"
+)
+
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+type options struct {
+	target        string
+	mix           string
+	providers     []string
+	coldProviders []string
+	workers       int
+	rate          float64
+	duration      time.Duration
+	timeout       time.Duration
+	seed          int64
+	json          bool
+}
+
+func main() {
+	var opts options
+	var providerFlags, coldProviderFlags stringList
+	flag.StringVar(&opts.target, "target", "", "URL of the registry to load, e.g. https://registry.example.com")
+	flag.StringVar(&opts.mix, "mix", "versions", "traffic mix to replay: versions, downloads or cold")
+	flag.Var(&providerFlags, "provider", "provider to request, as <namespace>/<type>, can be repeated")
+	flag.Var(&coldProviderFlags, "cold-provider", "provider to request once, for the cold mix, as <namespace>/<type>, can be repeated")
+	flag.IntVar(&opts.workers, "workers", 10, "number of requests in flight at once") //nolint:gomnd // Default concurrency.
+	flag.Float64Var(&opts.rate, "rate", 0, "maximum number of requests started per second, 0 for unlimited")
+	flag.DurationVar(&opts.duration, "duration", time.Minute, "how long to send requests for")
+	flag.DurationVar(&opts.timeout, "timeout", 30*time.Second, "timeout of each request") //nolint:gomnd // Default timeout.
+	flag.Int64Var(&opts.seed, "seed", time.Now().UnixNano(), "seed of the random choice of requests, to replay the same requests")
+	flag.BoolVar(&opts.json, "json", false, "print the report as JSON")
+	flag.Parse()
+	opts.providers, opts.coldProviders = providerFlags, coldProviderFlags
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := run(ctx, opts); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, opts options) error {
+	if opts.target == "" {
+		return fmt.Errorf("-target is required")
+	}
+	if len(opts.providers) == 0 {
+		return fmt.Errorf("at least one -provider is required")
+	}
+
+	mix, err := loadgen.ParseMix(opts.mix)
+	if err != nil {
+		return err
+	}
+	providers, err := parseProviders(opts.providers)
+	if err != nil {
+		return err
+	}
+	coldProviders, err := parseProviders(opts.coldProviders)
+	if err != nil {
+		return err
+	}
+
+	runner := &loadgen.Runner{
+		BaseURL:       strings.TrimSuffix(opts.target, "/"),
+		HTTPClient:    &http.Client{Timeout: opts.timeout, Transport: &http.Transport{MaxIdleConnsPerHost: opts.workers * mix.DownloadBurst}},
+		Mix:           mix,
+		Providers:     providers,
+		ColdProviders: coldProviders,
+		Workers:       opts.workers,
+		Rate:          opts.rate,
+		Duration:      opts.duration,
+		Seed:          opts.seed,
+	}
+	fmt.Fprintf(os.Stderr, "replaying the %s mix against %s for %s (seed %d)\n", mix.Name, runner.BaseURL, opts.duration, opts.seed)
+	report, err := runner.Run(ctx)
+	if err != nil {
+		return err
+	}
+
+	if opts.json {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+	return report.Write(os.Stdout)
+}
+
+func parseProviders(values []string) ([]loadgen.Provider, error) {
+	providers := make([]loadgen.Provider, len(values))
+	for i, value := range values {
+		p, err := loadgen.ParseProvider(value)
+		if err != nil {
+			return nil, err
+		}
+		providers[i] = p
+	}
+	return providers, nil
+}
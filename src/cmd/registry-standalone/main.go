@@ -0,0 +1,120 @@
+// registry-standalone runs the registry as a single process without any AWS services, for labs and small on-prem
+// environments. It serves the provider versions and download endpoints for a fixed list of providers, which it keeps
+// up to date from their GitHub releases.
+//
+//	GITHUB_TOKEN=... registry-standalone -provider hashicorp/aws -provider hashicorp/random -data registry.db
+//
+// The provider listings are kept in a bbolt database file, and refreshed in the background every -refresh-interval.
+// Providers whose repositories are not named `terraform-provider-<type>` are configured with PROVIDER_REPOSITORIES, as
+// for the Lambdas.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/aws/aws-xray-sdk-go/strategy/ctxmissing"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/opentofu/registry/internal/github"
+	"github.com/opentofu/registry/internal/localstore"
+	"github.com/opentofu/registry/internal/providers"
+	"github.com/opentofu/registry/internal/scheduler"
+	"github.com/opentofu/registry/internal/selfhosted"
+	"golang.org/x/exp/slog"
+)
+
+const readHeaderTimeout = 10 * time.Second
+
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+func main() {
+	var providerFlags stringList
+	listen := flag.String("listen", ":8080", "address to serve the registry on")
+	data := flag.String("data", "registry.db", "path of the database file holding the provider listings")
+	refreshInterval := flag.Duration("refresh-interval", time.Hour, "how often to check GitHub for new releases")
+	flag.Var(&providerFlags, "provider", "provider to serve, as <namespace>/<type>, can be repeated")
+	flag.Parse()
+
+	slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stdout, nil)))
+
+	if err := run(*listen, *data, *refreshInterval, providerFlags); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(listen, data string, refreshInterval time.Duration, providerAddresses []string) error {
+	if len(providerAddresses) == 0 {
+		return fmt.Errorf("at least one -provider is required")
+	}
+	for _, address := range providerAddresses {
+		if _, _, err := selfhosted.SplitAddress(address); err != nil {
+			return err
+		}
+	}
+
+	token := os.Getenv("GITHUB_TOKEN")
+	if token == "" {
+		return fmt.Errorf("GITHUB_TOKEN environment variable not set")
+	}
+
+	// the GitHub helpers are traced for the Lambdas, there is no X-Ray segment to attach to here
+	if err := xray.Configure(xray.Config{ContextMissingStrategy: ctxmissing.NewDefaultIgnoreErrorStrategy()}); err != nil {
+		return fmt.Errorf("could not configure X-Ray: %w", err)
+	}
+
+	repoNames, err := providers.ParseRepoNames(os.Getenv("PROVIDER_REPOSITORIES"))
+	if err != nil {
+		return err
+	}
+
+	cache, err := localstore.OpenProviderCache(data)
+	if err != nil {
+		return err
+	}
+	defer cache.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	refresher := selfhosted.Refresher{
+		Cache:               cache,
+		ManagedGithubClient: github.NewDirectGithubClient(token),
+		RawGithubv4Client:   github.NewDirectGithubv4Client(token),
+		RepoNames:           repoNames,
+	}
+	go scheduler.New(refreshInterval, refresher.Jobs(providerAddresses)...).Run(ctx)
+
+	server := &http.Server{
+		Addr:              listen,
+		Handler:           selfhosted.NewServer(cache, providerAddresses),
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	slog.Info("Serving registry", "address", listen, "providers", len(providerAddresses))
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+// registryd serves the registry API over HTTP, as an alternative to deploying it on Lambda behind API Gateway. It runs
+// the same handlers, configured through the same environment variables, and refreshes the provider cache in-process
+// instead of invoking the populate_provider_versions Lambda.
+//
+//	registryd -listen :8080
+//
+// Flags can also be given as environment variables, such as REGISTRYD_LISTEN for -listen.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/aws/aws-xray-sdk-go/strategy/ctxmissing"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/opentofu/registry/internal/api"
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/health"
+	"github.com/opentofu/registry/internal/metrics"
+	"github.com/opentofu/registry/internal/populate"
+	"golang.org/x/exp/slog"
+)
+
+// startupCheckTimeout bounds how long we wait for the dependencies to answer before giving up on starting.
+const startupCheckTimeout = 10 * time.Second
+
+type options struct {
+	listen         string
+	trustedProxies string
+	readTimeout    time.Duration
+	writeTimeout   time.Duration
+
+	readinessInterval time.Duration
+	readinessGrace    time.Duration
+	readinessTimeout  time.Duration
+
+	shutdownDelay   time.Duration
+	shutdownTimeout time.Duration
+}
+
+func main() {
+	var opts options
+	flag.StringVar(&opts.listen, "listen", ":8080", "address to serve the registry on")
+	flag.StringVar(&opts.trustedProxies, "trusted-proxies", "", "comma separated addresses or CIDR ranges of the proxies whose X-Forwarded-For is trusted")
+	flag.DurationVar(&opts.readTimeout, "read-timeout", 30*time.Second, "maximum duration for reading a request")                                                             //nolint:gomnd // API Gateway's integration timeout, rounded up
+	flag.DurationVar(&opts.writeTimeout, "write-timeout", 30*time.Second, "maximum duration for writing a response")                                                          //nolint:gomnd // API Gateway's integration timeout, rounded up
+	flag.DurationVar(&opts.readinessInterval, "readiness-interval", 10*time.Second, "how often /readyz re-runs the dependency checks")                                        //nolint:gomnd // a few checks per probe period
+	flag.DurationVar(&opts.readinessGrace, "readiness-grace", 30*time.Second, "how long a dependency check may fail before /readyz reports not ready")                        //nolint:gomnd // rides out short outages
+	flag.DurationVar(&opts.readinessTimeout, "readiness-timeout", 5*time.Second, "timeout of each dependency check")                                                          //nolint:gomnd // GitHub can take a few seconds to answer
+	flag.DurationVar(&opts.shutdownDelay, "shutdown-delay", 5*time.Second, "how long to keep serving after /readyz starts failing on shutdown, for load balancers to notice") //nolint:gomnd // a couple of probe periods of most load balancers
+	flag.DurationVar(&opts.shutdownTimeout, "shutdown-timeout", 20*time.Second, "how long to wait for in-flight requests and refreshes to finish on shutdown")                //nolint:gomnd // fits in the default termination grace period of Kubernetes and ECS, with the delay
+	flag.Parse()
+
+	slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stdout, nil)))
+
+	if err := flagsFromEnv(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if err := run(opts); err != nil {
+		slog.Error("Registry server failed", "error", err)
+		os.Exit(1)
+	}
+}
+
+// flagsFromEnv sets the flags which were not given on the command line from their REGISTRYD_ environment variables.
+func flagsFromEnv() error {
+	given := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+
+	var err error
+	flag.VisitAll(func(f *flag.Flag) {
+		name := "REGISTRYD_" + strings.ToUpper(strings.ReplaceAll(f.Name, "-", "_"))
+		value, ok := os.LookupEnv(name)
+		if given[f.Name] || !ok || err != nil {
+			return
+		}
+		if setErr := f.Value.Set(value); setErr != nil {
+			err = fmt.Errorf("invalid %s: %w", name, setErr)
+		}
+	})
+	return err
+}
+
+func run(opts options) error {
+	trustedProxies, err := api.ParseTrustedProxies(opts.trustedProxies)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Startup happens in dependency order: the configuration and the clients built from it, then the cache, which
+	// has to be reachable before we accept any traffic.
+	cfg, err := api.NewConfigBuilder().BuildConfig(ctx, "registryd.buildconfig")
+	if err != nil {
+		return fmt.Errorf("could not build config: %w", err)
+	}
+
+	// requests get their own X-Ray segments, but the in-process refreshes run outside of any, so missing segments are
+	// not errors
+	if err = xray.Configure(xray.Config{ContextMissingStrategy: ctxmissing.NewDefaultIgnoreErrorStrategy()}); err != nil {
+		return fmt.Errorf("could not configure X-Ray: %w", err)
+	}
+
+	startupCtx, cancel := context.WithTimeout(ctx, startupCheckTimeout)
+	err = checkCache(startupCtx, cfg)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("provider cache is not reachable: %w", err)
+	}
+
+	populator := populate.NewInProcess(cfg)
+	cfg.PopulateProviderVersions = populator.Populate
+	cfg.PopulateModuleVersions = populator.PopulateModule
+
+	checker := health.NewChecker(opts.readinessInterval, opts.readinessGrace, opts.readinessTimeout,
+		health.Check{Name: "config", Run: func(ctx context.Context) error { return checkConfig(cfg) }},
+		health.Check{Name: "storage", Run: func(ctx context.Context) error { return checkCache(ctx, cfg) }},
+		health.Check{Name: "github", Run: func(ctx context.Context) error { return checkGithubToken(ctx, cfg) }},
+	)
+
+	mux := http.NewServeMux()
+	mux.Handle("/livez", checker.LivezHandler())
+	mux.Handle("/readyz", checker.ReadyzHandler())
+	mux.Handle("/", xray.Handler(xray.NewFixedSegmentNamer("registryd"), api.NewHTTPHandler(api.Router(*cfg), trustedProxies)))
+
+	server := &http.Server{
+		Addr:              opts.listen,
+		Handler:           mux,
+		ReadHeaderTimeout: opts.readTimeout,
+		ReadTimeout:       opts.readTimeout,
+		WriteTimeout:      opts.writeTimeout,
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		slog.Info("Serving registry", "address", opts.listen)
+		serveErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err = <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+	// a second signal stops the process straight away
+	stop()
+
+	shutdown(opts, server, checker, populator)
+	return nil
+}
+
+// shutdown stops the server without dropping requests: it first fails the readiness probe and keeps serving for a
+// while so load balancers stop sending traffic, then stops accepting connections and waits for the in-flight requests
+// and provider refreshes, and finally flushes the metrics.
+func shutdown(opts options, server *http.Server, checker *health.Checker, populator *populate.InProcess) {
+	slog.Info("Shutting down", "delay", opts.shutdownDelay.String(), "timeout", opts.shutdownTimeout.String())
+	checker.ShutDown()
+	time.Sleep(opts.shutdownDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), opts.shutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		slog.Error("In-flight requests did not finish in time", "error", err)
+		server.Close()
+	}
+	if err := populator.Drain(ctx); err != nil {
+		slog.Error("Provider refreshes did not finish in time", "error", err)
+	}
+
+	// metrics are scraped rather than pushed, log them so what was recorded since the last scrape is not lost
+	slog.Info("Final metrics", "metrics", metrics.Default().Snapshot())
+	slog.Info("Shut down")
+}
+
+// checkConfig makes sure the clients the handlers rely on have been built.
+func checkConfig(cfg *config.Config) error {
+	if cfg.ProviderVersionCache == nil || cfg.ManagedGithubClient == nil || cfg.RawGithubv4Client == nil {
+		return fmt.Errorf("configuration is incomplete")
+	}
+	return nil
+}
+
+// checkCache makes sure the provider cache can be read. On startup, this stops a misconfigured table or missing
+// permissions from failing every request instead.
+func checkCache(ctx context.Context, cfg *config.Config) error {
+	_, err := cfg.ProviderVersionCache.GetItem(ctx, "registryd/health-check")
+	return err
+}
+
+// checkGithubToken makes sure GitHub accepts our token. Looking up the rate limit does not count against it.
+func checkGithubToken(ctx context.Context, cfg *config.Config) error {
+	_, _, err := cfg.ManagedGithubClient.RateLimits(ctx)
+	return err
+}
@@ -0,0 +1,89 @@
+package access
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// ACLs mark providers as private and list the tokens allowed to read them, keyed by lowercase `<namespace>` for all
+// the providers of a namespace or `<namespace>/<type>` for a single provider. The providers of neither are public.
+type ACLs map[string][]string
+
+// Parse parses the private providers ACLs, a JSON object mapping namespaces and provider addresses to the tokens
+// allowed to read them, e.g. `{"example": ["token1"], "other/internal": ["token2"]}`.
+func Parse(value string) (ACLs, error) {
+	acls := make(ACLs)
+	if value == "" {
+		return acls, nil
+	}
+
+	var settings map[string][]string
+	if err := json.Unmarshal([]byte(value), &settings); err != nil {
+		// the value holds secrets, so do not wrap the error which may quote it
+		return nil, errors.New("could not parse private providers: invalid JSON object")
+	}
+
+	for address, tokens := range settings {
+		if parts := strings.Split(address, "/"); len(parts) > 2 || parts[0] == "" || (len(parts) == 2 && parts[1] == "") {
+			return nil, errors.New("could not parse private providers: addresses must be <namespace> or <namespace>/<type>")
+		}
+		if len(tokens) == 0 {
+			return nil, errors.New("could not parse private providers: every address needs at least one token")
+		}
+		for _, token := range tokens {
+			if token == "" {
+				return nil, errors.New("could not parse private providers: tokens must not be empty")
+			}
+		}
+		key := strings.ToLower(address)
+		acls[key] = append(acls[key], tokens...)
+	}
+	return acls, nil
+}
+
+// Private returns true if the provider is only served to the tokens allowed to read it.
+func (a ACLs) Private(namespace, providerType string) bool {
+	return len(a.tokens(namespace, providerType)) > 0
+}
+
+// CanRead returns true if the token can read the provider: it is public, or the token is allowed for the provider or
+// for its whole namespace.
+func (a ACLs) CanRead(namespace, providerType, token string) bool {
+	allowed := a.tokens(namespace, providerType)
+	if len(allowed) == 0 {
+		return true
+	}
+	return contains(allowed, token)
+}
+
+// Known returns true if the token is allowed to read any private provider.
+func (a ACLs) Known(token string) bool {
+	for _, allowed := range a {
+		if contains(allowed, token) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a ACLs) tokens(namespace, providerType string) []string {
+	namespace = strings.ToLower(namespace)
+	tokens := a[namespace]
+	return append(tokens[:len(tokens):len(tokens)], a[namespace+"/"+strings.ToLower(providerType)]...)
+}
+
+func contains(allowed []string, token string) bool {
+	if token == "" {
+		return false
+	}
+	found := false
+	// compare against every token so that the time taken does not tell which one matched
+	for _, t := range allowed {
+		if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+			found = true
+		}
+	}
+	return found
+}
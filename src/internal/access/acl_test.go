@@ -0,0 +1,57 @@
+package access
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := map[string]bool{
+		``: true,
+		`{"example": ["a"], "Other/Internal": ["b", "c"]}`: true,
+		`{"a/b/c": ["a"]}`: false,
+		`{"/b": ["a"]}`:    false,
+		`{"a/": ["a"]}`:    false,
+		`{"a": []}`:        false,
+		`{"a": [""]}`:      false,
+		`["a"]`:            false,
+	}
+	for value, valid := range tests {
+		if _, err := Parse(value); (err == nil) != valid {
+			t.Errorf("Parse(%q) error = %v, want valid %v", value, err, valid)
+		}
+	}
+}
+
+func TestCanRead(t *testing.T) {
+	acls, err := Parse(`{"example": ["namespace"], "other/internal": ["provider"], "Example/Secret": ["provider"]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		namespace, providerType, token string
+		want                           bool
+	}{
+		{"public", "aws", "", true},
+		{"other", "public", "", true},
+		{"example", "aws", "", false},
+		{"example", "aws", "namespace", true},
+		{"EXAMPLE", "aws", "namespace", true},
+		{"example", "aws", "provider", false},
+		{"example", "secret", "provider", true},
+		{"example", "secret", "namespace", true},
+		{"other", "internal", "provider", true},
+		{"other", "internal", "namespace", false},
+		{"other", "internal", "", false},
+	}
+	for _, tt := range tests {
+		if got := acls.CanRead(tt.namespace, tt.providerType, tt.token); got != tt.want {
+			t.Errorf("CanRead(%q, %q, %q) = %v, want %v", tt.namespace, tt.providerType, tt.token, got, tt.want)
+		}
+	}
+
+	if !acls.Private("example", "aws") || acls.Private("public", "aws") {
+		t.Error("Private() does not match the ACLs")
+	}
+	if !acls.Known("provider") || acls.Known("unknown") || acls.Known("") {
+		t.Error("Known() does not match the ACLs")
+	}
+}
@@ -0,0 +1,57 @@
+// Package aliases holds the explicit rename records for providers that have moved to a new address. Unlike namespace
+// redirects, aliases are surfaced to users so that they can update their configuration.
+package aliases
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Alias records that the provider at From has moved to To.
+type Alias struct {
+	From   string    `json:"from" dynamodbav:"from"` // The old address, in the form "namespace/type".
+	To     string    `json:"to" dynamodbav:"to"`     // The new address, in the form "namespace/type".
+	Reason string    `json:"reason,omitempty" dynamodbav:"reason"`
+	Since  time.Time `json:"since" dynamodbav:"since"`
+}
+
+// Address returns the address under which a provider alias is stored.
+func Address(namespace, providerType string) string {
+	return fmt.Sprintf("%s/%s", namespace, providerType)
+}
+
+// Validate checks that the alias points from one valid provider address to another.
+func (a Alias) Validate() error {
+	if err := validateAddress(a.From); err != nil {
+		return fmt.Errorf("invalid from address: %w", err)
+	}
+	if err := validateAddress(a.To); err != nil {
+		return fmt.Errorf("invalid to address: %w", err)
+	}
+	if strings.EqualFold(a.From, a.To) {
+		return fmt.Errorf("an alias cannot point to itself")
+	}
+	return nil
+}
+
+func validateAddress(address string) error {
+	parts := strings.Split(address, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" { //nolint:gomnd // namespace and type
+		return fmt.Errorf("%q is not in the form namespace/type", address)
+	}
+	return nil
+}
+
+// Warning renders the alias as a human-readable warning.
+func (a Alias) Warning() string {
+	warning := fmt.Sprintf("This provider has moved to %s", a.To)
+	if !a.Since.IsZero() {
+		warning += fmt.Sprintf(" (since %s)", a.Since.Format("2006-01-02"))
+	}
+	warning += fmt.Sprintf(". Please update your configuration to use %s.", a.To)
+	if a.Reason != "" {
+		warning += fmt.Sprintf(" Reason: %s", a.Reason)
+	}
+	return warning
+}
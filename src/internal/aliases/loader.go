@@ -0,0 +1,70 @@
+package aliases
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/slog"
+)
+
+// DefaultRefreshInterval is how long the loaded aliases are used before they are loaded again.
+const DefaultRefreshInterval = 5 * time.Minute
+
+// lister is the part of the Handler the Loader uses, replaced in tests.
+type lister interface {
+	List(ctx context.Context) ([]Alias, error)
+}
+
+// Loader caches the aliases of the table, so that they are not scanned on every request. Changes made on other
+// instances are picked up once the cached aliases are older than the refresh interval.
+type Loader struct {
+	interval time.Duration
+	lister   lister
+	now      func() time.Time
+
+	mu       sync.Mutex
+	aliases  []Alias
+	loaded   bool
+	loadedAt time.Time
+}
+
+func NewLoader(store *Handler, interval time.Duration) *Loader {
+	if interval <= 0 {
+		interval = DefaultRefreshInterval
+	}
+	return &Loader{interval: interval, lister: store, now: time.Now}
+}
+
+// List returns all the recorded aliases, loading them again if they are older than the refresh interval. If loading
+// fails, the aliases loaded last keep being used until the next attempt, one refresh interval later; the error is only
+// returned if they were never loaded.
+func (l *Loader) List(ctx context.Context) ([]Alias, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if l.loaded && now.Sub(l.loadedAt) < l.interval {
+		return l.aliases, nil
+	}
+
+	found, err := l.lister.List(ctx)
+	if err != nil {
+		if !l.loaded {
+			return nil, err
+		}
+		slog.Error("Could not load provider aliases, using the ones loaded last", "error", err)
+		l.loadedAt = now
+		return l.aliases, nil
+	}
+	l.aliases, l.loaded, l.loadedAt = found, true, now
+	return l.aliases, nil
+}
+
+// Invalidate makes the next call to List load the aliases again, so that the changes made through this instance are
+// served right away.
+func (l *Loader) Invalidate() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.loadedAt = time.Time{}
+}
@@ -0,0 +1,67 @@
+package aliases
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type fakeLister struct {
+	aliases []Alias
+	err     error
+	calls   int
+}
+
+func (f *fakeLister) List(context.Context) ([]Alias, error) {
+	f.calls++
+	return f.aliases, f.err
+}
+
+func TestLoader(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := &fakeLister{err: errors.New("throttled")}
+	loader := &Loader{interval: time.Minute, lister: store, now: func() time.Time { return now }}
+	ctx := context.Background()
+
+	// without aliases loaded before, failures are returned
+	if _, err := loader.List(ctx); err == nil {
+		t.Fatal("expected the error of the first load")
+	}
+
+	store.err = nil
+	store.aliases = []Alias{{From: "old/aws", To: "new/aws"}}
+	if got, err := loader.List(ctx); err != nil || len(got) != 1 || store.calls != 2 {
+		t.Fatalf("List() = %v, %v after %d loads", got, err, store.calls)
+	}
+
+	// cached until the refresh interval has passed
+	store.aliases = append(store.aliases, Alias{From: "old/random", To: "new/random"})
+	now = now.Add(30 * time.Second)
+	if got, _ := loader.List(ctx); len(got) != 1 || store.calls != 2 {
+		t.Errorf("List() = %v after %d loads, expected the cached aliases", got, store.calls)
+	}
+
+	now = now.Add(time.Minute)
+	if got, _ := loader.List(ctx); len(got) != 2 || store.calls != 3 {
+		t.Errorf("List() = %v after %d loads, expected the aliases loaded again", got, store.calls)
+	}
+
+	// failures keep the aliases loaded last
+	store.err = errors.New("throttled")
+	now = now.Add(time.Minute)
+	if got, err := loader.List(ctx); err != nil || len(got) != 2 || store.calls != 4 {
+		t.Errorf("List() = %v, %v after %d loads, expected the aliases loaded last", got, err, store.calls)
+	}
+	_, _ = loader.List(ctx)
+	if store.calls != 4 {
+		t.Errorf("failed load retried before the refresh interval")
+	}
+
+	store.err = nil
+	store.aliases = nil
+	loader.Invalidate()
+	if got, _ := loader.List(ctx); len(got) != 0 || store.calls != 5 {
+		t.Errorf("List() = %v after %d loads, expected the aliases loaded again after Invalidate", got, store.calls)
+	}
+}
@@ -0,0 +1,103 @@
+package aliases
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"golang.org/x/exp/slog"
+)
+
+type Handler struct {
+	TableName *string
+	Client    *dynamodb.Client
+}
+
+func NewHandler(awsConfig aws.Config, tableName string) *Handler {
+	ddbClient := dynamodb.NewFromConfig(awsConfig)
+
+	return &Handler{
+		TableName: aws.String(tableName),
+		Client:    ddbClient,
+	}
+}
+
+// Get returns the alias for the given provider address, or nil if the provider has not moved.
+func (h *Handler) Get(ctx context.Context, from string) (*Alias, error) {
+	result, err := h.Client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: h.TableName,
+		Key: map[string]types.AttributeValue{
+			"from": &types.AttributeValueMemberS{Value: from},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get alias: %w", err)
+	}
+
+	if len(result.Item) == 0 {
+		return nil, nil //nolint:nilnil // This is not an error, it just means the provider has not moved.
+	}
+
+	var alias Alias
+	if err := attributevalue.UnmarshalMap(result.Item, &alias); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal alias: %w", err)
+	}
+	return &alias, nil
+}
+
+// List returns all the recorded aliases.
+func (h *Handler) List(ctx context.Context) ([]Alias, error) {
+	var aliases []Alias
+
+	paginator := dynamodb.NewScanPaginator(h.Client, &dynamodb.ScanInput{TableName: h.TableName})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan aliases: %w", err)
+		}
+
+		var pageAliases []Alias
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &pageAliases); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal aliases: %w", err)
+		}
+		aliases = append(aliases, pageAliases...)
+	}
+
+	return aliases, nil
+}
+
+// Put creates or replaces the alias for alias.From.
+func (h *Handler) Put(ctx context.Context, alias Alias) error {
+	marshalledItem, err := attributevalue.MarshalMap(alias)
+	if err != nil {
+		return fmt.Errorf("failed to marshal alias: %w", err)
+	}
+
+	slog.Info("Storing alias", "from", alias.From, "to", alias.To)
+	_, err = h.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		Item:      marshalledItem,
+		TableName: h.TableName,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store alias: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the alias for the given provider address.
+func (h *Handler) Delete(ctx context.Context, from string) error {
+	slog.Info("Deleting alias", "from", from)
+	_, err := h.Client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: h.TableName,
+		Key: map[string]types.AttributeValue{
+			"from": &types.AttributeValueMemberS{Value: from},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete alias: %w", err)
+	}
+	return nil
+}
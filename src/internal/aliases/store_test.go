@@ -0,0 +1,108 @@
+package aliases
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/opentofu/registry/internal/awstest"
+)
+
+// testTable serves the calls of a Handler from memory. Scans return one item per page, so that List has to follow
+// the pages.
+type testTable struct {
+	mu    sync.Mutex
+	items map[string]map[string]json.RawMessage
+}
+
+func newTestHandler(t *testing.T) *Handler {
+	table := &testTable{items: make(map[string]map[string]json.RawMessage)}
+	client := awstest.DynamoDBClient(t, table)
+	return &Handler{TableName: aws.String("aliases"), Client: client}
+}
+
+func (table *testTable) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	table.mu.Lock()
+	defer table.mu.Unlock()
+
+	var input struct {
+		Key               map[string]struct{ S string }
+		Item              map[string]json.RawMessage
+		ExclusiveStartKey map[string]struct{ S string }
+	}
+	_ = json.NewDecoder(r.Body).Decode(&input)
+
+	w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+	switch strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "DynamoDB_20120810.") {
+	case "GetItem":
+		_ = json.NewEncoder(w).Encode(map[string]any{"Item": table.items[input.Key["from"].S]})
+	case "PutItem":
+		var from struct{ S string }
+		_ = json.Unmarshal(input.Item["from"], &from)
+		table.items[from.S] = input.Item
+		_, _ = w.Write([]byte(`{}`))
+	case "DeleteItem":
+		delete(table.items, input.Key["from"].S)
+		_, _ = w.Write([]byte(`{}`))
+	case "Scan":
+		keys := make([]string, 0, len(table.items))
+		for key := range table.items {
+			if key > input.ExclusiveStartKey["from"].S {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		page := map[string]any{"Items": []any{}}
+		if len(keys) > 0 {
+			page["Items"] = []any{table.items[keys[0]]}
+			if len(keys) > 1 {
+				page["LastEvaluatedKey"] = map[string]any{"from": map[string]string{"S": keys[0]}}
+			}
+		}
+		_ = json.NewEncoder(w).Encode(page)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"__type":"com.amazonaws.dynamodb.v20120810#ValidationException"}`))
+	}
+}
+
+func TestHandler(t *testing.T) {
+	handler := newTestHandler(t)
+	ctx := context.Background()
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, alias := range []Alias{
+		{From: "old/aws", To: "new/aws", Reason: "renamed", Since: since},
+		{From: "old/random", To: "new/random", Since: since},
+	} {
+		if err := handler.Put(ctx, alias); err != nil {
+			t.Fatalf("Put() error = %v", err)
+		}
+	}
+
+	alias, err := handler.Get(ctx, "old/aws")
+	if err != nil || alias == nil || alias.To != "new/aws" || alias.Reason != "renamed" || !alias.Since.Equal(since) {
+		t.Errorf("Get() = %+v, %v", alias, err)
+	}
+	if alias, err := handler.Get(ctx, "old/unknown"); err != nil || alias != nil {
+		t.Errorf("Get() of an unknown address = %+v, %v, want nil", alias, err)
+	}
+
+	found, err := handler.List(ctx)
+	if err != nil || len(found) != 2 || found[0].From != "old/aws" || found[1].From != "old/random" {
+		t.Errorf("List() = %+v, %v, want the aliases of every page", found, err)
+	}
+
+	if err := handler.Delete(ctx, "old/aws"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if found, err := handler.List(ctx); err != nil || len(found) != 1 {
+		t.Errorf("List() after Delete() = %+v, %v", found, err)
+	}
+}
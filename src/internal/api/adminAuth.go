@@ -0,0 +1,34 @@
+package api
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/opentofu/registry/internal/config"
+	"golang.org/x/exp/slog"
+)
+
+// isAdminRequest checks that the request carries the admin API token as a bearer token.
+func isAdminRequest(config config.Config, req events.APIGatewayProxyRequest) bool {
+	if config.AdminAPIToken == "" {
+		slog.Warn("Admin API token is not configured, rejecting admin request")
+		return false
+	}
+
+	token := bearerToken(req)
+	if token == "" {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(token), []byte(config.AdminAPIToken)) == 1
+}
+
+// bearerToken returns the bearer token of the request, empty if it has none.
+func bearerToken(req events.APIGatewayProxyRequest) string {
+	token, ok := strings.CutPrefix(getHeader(req, "Authorization"), "Bearer ")
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
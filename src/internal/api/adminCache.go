@@ -0,0 +1,206 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/logging"
+	"github.com/opentofu/registry/internal/modules/modulecache"
+	"github.com/opentofu/registry/internal/providers/types"
+)
+
+// ProviderCacheItemResponse is the cached item of a provider as it is stored, with the versions decoded.
+type ProviderCacheItemResponse struct {
+	Key           string                `json:"key"`
+	LastUpdated   time.Time             `json:"last_updated"`
+	Stale         bool                  `json:"stale"`
+	SchemaVersion int                   `json:"schema_version"`
+	Revision      int64                 `json:"revision"`
+	Metadata      CacheMetadataResponse `json:"metadata"`
+	Versions      types.VersionList     `json:"versions"`
+}
+
+// CacheMetadataResponse is the metadata of a cached provider, see types.ProviderMetadata.
+type CacheMetadataResponse struct {
+	Archived         bool   `json:"archived"`
+	Incomplete       bool   `json:"incomplete"`
+	License          string `json:"license,omitempty"`
+	NotFound         bool   `json:"not_found,omitempty"`
+	RefreshFailures  int    `json:"refresh_failures,omitempty"`
+	LastRefreshError string `json:"last_refresh_error,omitempty"`
+	Listing          string `json:"listing,omitempty"`
+}
+
+// ModuleCacheItemResponse is the cached item of the repository of a module, with the tags of its versions.
+type ModuleCacheItemResponse struct {
+	Key         string               `json:"key"`
+	LastUpdated time.Time            `json:"last_updated"`
+	Stale       bool                 `json:"stale"`
+	Versions    []ModuleCacheVersion `json:"versions"`
+}
+
+type ModuleCacheVersion struct {
+	Version    string `json:"version"`
+	Tag        string `json:"tag"`
+	Prerelease bool   `json:"prerelease,omitempty"`
+}
+
+// adminProviderCache inspects and fixes the cached item of a provider, under its effective namespace. GET returns the
+// item as it is stored, and DELETE deletes it, so that the next request fetches the provider from GitHub again.
+func adminProviderCache(config config.Config) LambdaFunc {
+	return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		params := getListProvidersPathParams(req)
+		ctx = params.AnnotateLogger(ctx)
+
+		if !isAdminRequest(config, req) {
+			return UnauthorizedResponse, nil
+		}
+
+		key := fmt.Sprintf("%s/%s", config.EffectiveProviderNamespace(params.Namespace), params.Type)
+		switch req.HTTPMethod {
+		case http.MethodGet:
+			item, err := config.ProviderVersionCache.GetItem(ctx, key)
+			if err != nil {
+				logging.FromContext(ctx).Error("Error getting document from cache", "error", err)
+				return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+			}
+			if item == nil {
+				return NotFoundResponse, nil
+			}
+			metadata := item.Metadata
+			return jsonResponse(ProviderCacheItemResponse{
+				Key:           key,
+				LastUpdated:   item.LastUpdated,
+				Stale:         item.IsStale(config.CacheTTL),
+				SchemaVersion: item.SchemaVersion,
+				Revision:      item.Revision,
+				Metadata: CacheMetadataResponse{
+					Archived:         metadata.Archived,
+					Incomplete:       metadata.Incomplete,
+					License:          metadata.License,
+					NotFound:         metadata.NotFound,
+					RefreshFailures:  metadata.RefreshFailures,
+					LastRefreshError: metadata.LastRefreshError,
+					Listing:          metadata.Listing,
+				},
+				Versions: append(types.VersionList{}, item.Versions...),
+			})
+		case http.MethodDelete:
+			logging.FromContext(ctx).Info("Deleting cached provider", "key", key)
+			if err := config.ProviderVersionCache.Delete(ctx, key); err != nil {
+				logging.FromContext(ctx).Error("Error deleting document from cache", "error", err)
+				return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+			}
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusNoContent}, nil
+		default:
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusMethodNotAllowed}, nil
+		}
+	}
+}
+
+// adminRefreshProvider starts refreshing the cached versions of a provider right away, even if they are not stale.
+// The refresh runs like the ones triggered by stale items, so the response does not wait for it.
+func adminRefreshProvider(config config.Config) LambdaFunc {
+	return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		params := getListProvidersPathParams(req)
+		ctx = params.AnnotateLogger(ctx)
+
+		if !isAdminRequest(config, req) {
+			return UnauthorizedResponse, nil
+		}
+
+		logging.FromContext(ctx).Info("Forcing a refresh of the provider")
+		if err := startProviderRefresh(ctx, config, config.EffectiveProviderNamespace(params.Namespace), params.Type, true); err != nil {
+			logging.FromContext(ctx).Error("Error triggering refresh", "error", err)
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusAccepted}, nil
+	}
+}
+
+// adminModuleCache inspects and fixes the cached versions of the repository of a module, like adminProviderCache. The
+// modules released from the same repository share the item.
+func adminModuleCache(config config.Config) LambdaFunc {
+	return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		params := getListModuleVersionsPathParams(req)
+		ctx = params.AnnotateLogger(ctx)
+
+		if !isAdminRequest(config, req) {
+			return UnauthorizedResponse, nil
+		}
+		if config.ModuleVersionCache == nil {
+			return NotFoundResponse, nil
+		}
+
+		repo, err := config.ModuleRepository(ctx, params.Namespace, params.Name, params.System)
+		if err != nil {
+			logging.FromContext(ctx).Error("Error looking up the repository of the module", "error", err)
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+		key := modulecache.Key(repo)
+
+		switch req.HTTPMethod {
+		case http.MethodGet:
+			item, err := config.ModuleVersionCache.GetItem(ctx, key)
+			if err != nil {
+				logging.FromContext(ctx).Error("Error getting module versions from cache", "error", err)
+				return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+			}
+			if item == nil {
+				return NotFoundResponse, nil
+			}
+			versions := make([]ModuleCacheVersion, len(item.Versions))
+			for i, v := range item.Versions {
+				versions[i] = ModuleCacheVersion{Version: v.Version, Tag: v.Tag, Prerelease: v.Prerelease}
+			}
+			return jsonResponse(ModuleCacheItemResponse{
+				Key:         key,
+				LastUpdated: item.LastUpdated,
+				Stale:       item.IsStale(config.ModuleCacheTTL),
+				Versions:    versions,
+			})
+		case http.MethodDelete:
+			logging.FromContext(ctx).Info("Deleting cached module versions", "key", key)
+			if err := config.ModuleVersionCache.Delete(ctx, key); err != nil {
+				logging.FromContext(ctx).Error("Error deleting module versions from cache", "error", err)
+				return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+			}
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusNoContent}, nil
+		default:
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusMethodNotAllowed}, nil
+		}
+	}
+}
+
+// adminRefreshModule starts refreshing the cached versions of the repository of a module right away, like
+// adminRefreshProvider.
+func adminRefreshModule(config config.Config) LambdaFunc {
+	return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		params := getListModuleVersionsPathParams(req)
+		ctx = params.AnnotateLogger(ctx)
+
+		if !isAdminRequest(config, req) {
+			return UnauthorizedResponse, nil
+		}
+		if config.ModuleVersionCache == nil {
+			return NotFoundResponse, nil
+		}
+
+		repo, err := config.ModuleRepository(ctx, params.Namespace, params.Name, params.System)
+		if err != nil {
+			logging.FromContext(ctx).Error("Error looking up the repository of the module", "error", err)
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+
+		logging.FromContext(ctx).Info("Forcing a refresh of the module", "key", modulecache.Key(repo))
+		if err := startModuleRefresh(ctx, config, repo, true); err != nil {
+			logging.FromContext(ctx).Error("Error triggering refresh", "error", err)
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusAccepted}, nil
+	}
+}
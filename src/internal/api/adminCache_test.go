@@ -0,0 +1,97 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/providers/providercache"
+	"github.com/opentofu/registry/internal/providers/types"
+)
+
+// itemsProviderCache is a provider cache which can only get and delete items.
+type itemsProviderCache struct {
+	providercache.Cache
+	items map[string]*types.CacheItem
+}
+
+func (c itemsProviderCache) GetItem(_ context.Context, key string) (*types.CacheItem, error) {
+	return c.items[key], nil
+}
+
+func (c itemsProviderCache) Delete(_ context.Context, key string) error {
+	delete(c.items, key)
+	return nil
+}
+
+func adminRequest(method string, params map[string]string) events.APIGatewayProxyRequest {
+	return events.APIGatewayProxyRequest{
+		HTTPMethod:     method,
+		PathParameters: params,
+		Headers:        map[string]string{"Authorization": "Bearer secret"},
+	}
+}
+
+func TestAdminProviderCache(t *testing.T) {
+	cache := itemsProviderCache{items: map[string]*types.CacheItem{
+		"example/cached": {
+			Provider:    "example/cached",
+			Versions:    types.VersionList{{Version: "1.0.0", Protocols: []string{"5.0"}}},
+			LastUpdated: time.Now(),
+			Metadata:    types.ProviderMetadata{License: "MPL-2.0"},
+			Revision:    3,
+		},
+	}}
+	cfg := config.Config{AdminAPIToken: "secret", ProviderVersionCache: cache}
+	params := map[string]string{"namespace": "example", "type": "cached"}
+
+	unauthorized := adminRequest(http.MethodGet, params)
+	unauthorized.Headers = nil
+	if response, _ := adminProviderCache(cfg)(context.Background(), unauthorized); response.StatusCode != http.StatusUnauthorized {
+		t.Errorf("status without token = %d, want %d", response.StatusCode, http.StatusUnauthorized)
+	}
+
+	response, err := adminProviderCache(cfg)(context.Background(), adminRequest(http.MethodGet, params))
+	if err != nil || response.StatusCode != http.StatusOK {
+		t.Fatalf("GET = %d, %v", response.StatusCode, err)
+	}
+	var item ProviderCacheItemResponse
+	if err := json.Unmarshal([]byte(response.Body), &item); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if item.Key != "example/cached" || item.Revision != 3 || item.Stale || item.Metadata.License != "MPL-2.0" || len(item.Versions) != 1 {
+		t.Errorf("unexpected item %+v", item)
+	}
+
+	response, err = adminProviderCache(cfg)(context.Background(), adminRequest(http.MethodDelete, params))
+	if err != nil || response.StatusCode != http.StatusNoContent {
+		t.Fatalf("DELETE = %d, %v", response.StatusCode, err)
+	}
+	if response, _ := adminProviderCache(cfg)(context.Background(), adminRequest(http.MethodGet, params)); response.StatusCode != http.StatusNotFound {
+		t.Errorf("GET after DELETE = %d, want %d", response.StatusCode, http.StatusNotFound)
+	}
+}
+
+func TestAdminRefreshProvider(t *testing.T) {
+	var refreshed string
+	var forced bool
+	cfg := config.Config{
+		AdminAPIToken: "secret",
+		PopulateProviderVersions: func(_ context.Context, namespace, providerType, _ string, force bool) error {
+			refreshed, forced = namespace+"/"+providerType, force
+			return nil
+		},
+	}
+
+	response, err := adminRefreshProvider(cfg)(context.Background(), adminRequest(http.MethodPost, map[string]string{"namespace": "example", "type": "cached"}))
+	if err != nil || response.StatusCode != http.StatusAccepted {
+		t.Fatalf("POST = %d, %v", response.StatusCode, err)
+	}
+	if refreshed != "example/cached" || !forced {
+		t.Errorf("refreshed %q with force %v, want a forced refresh of example/cached", refreshed, forced)
+	}
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/deprecations"
+	"github.com/opentofu/registry/internal/logging"
+)
+
+type DeprecationsRequest struct {
+	Deprecations []deprecations.Deprecation `json:"deprecations"`
+}
+
+type DeprecationsResponse struct {
+	Address      string                     `json:"address"`
+	Deprecations []deprecations.Deprecation `json:"deprecations"`
+}
+
+func adminProviderDeprecations(config config.Config) LambdaFunc {
+	return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		params := getListProvidersPathParams(req)
+		ctx = params.AnnotateLogger(ctx)
+		return handleDeprecations(ctx, config, req, deprecations.ProviderAddress(params.Namespace, params.Type))
+	}
+}
+
+func adminModuleDeprecations(config config.Config) LambdaFunc {
+	return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		params := getListModuleVersionsPathParams(req)
+		ctx = params.AnnotateLogger(ctx)
+		return handleDeprecations(ctx, config, req, deprecations.ModuleAddress(params.Namespace, params.Name, params.System))
+	}
+}
+
+func handleDeprecations(ctx context.Context, config config.Config, req events.APIGatewayProxyRequest, address string) (events.APIGatewayProxyResponse, error) {
+	if !isAdminRequest(config, req) {
+		return UnauthorizedResponse, nil
+	}
+
+	switch req.HTTPMethod {
+	case http.MethodGet:
+		found, err := config.DeprecationsStore.Get(ctx, address)
+		if err != nil {
+			logging.FromContext(ctx).Error("Error getting deprecations", "error", err)
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+		if found == nil {
+			return NotFoundResponse, nil
+		}
+		return deprecationsResponse(address, found)
+	case http.MethodPut:
+		var body DeprecationsRequest
+		if err := json.Unmarshal([]byte(req.Body), &body); err != nil {
+			return badRequestResponse(fmt.Sprintf("invalid request body: %s", err)), nil
+		}
+		for i := range body.Deprecations {
+			if err := body.Deprecations[i].Validate(); err != nil {
+				return badRequestResponse(err.Error()), nil
+			}
+			if body.Deprecations[i].CreatedAt.IsZero() {
+				body.Deprecations[i].CreatedAt = time.Now()
+			}
+		}
+		if err := config.DeprecationsStore.Put(ctx, address, body.Deprecations); err != nil {
+			logging.FromContext(ctx).Error("Error storing deprecations", "error", err)
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+		return deprecationsResponse(address, body.Deprecations)
+	case http.MethodDelete:
+		if err := config.DeprecationsStore.Delete(ctx, address); err != nil {
+			logging.FromContext(ctx).Error("Error deleting deprecations", "error", err)
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusNoContent}, nil
+	default:
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusMethodNotAllowed}, nil
+	}
+}
+
+func deprecationsResponse(address string, found []deprecations.Deprecation) (events.APIGatewayProxyResponse, error) {
+	resBody, err := json.Marshal(DeprecationsResponse{Address: address, Deprecations: found})
+	if err != nil {
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+	}
+	return events.APIGatewayProxyResponse{StatusCode: http.StatusOK, Body: string(resBody)}, nil
+}
+
+// lookupDeprecationWarnings returns the deprecation warnings for the given address that apply to any of the versions.
+// Failures are logged and ignored, as deprecations should never prevent serving the versions themselves.
+func lookupDeprecationWarnings(ctx context.Context, config config.Config, address string, versions []string) []string {
+	if config.DeprecationsStore == nil {
+		return nil
+	}
+	found, err := config.DeprecationsStore.Get(ctx, address)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error getting deprecations", "address", address, "error", err)
+		return nil
+	}
+	return deprecations.Warnings(found, versions)
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/keys"
+	"github.com/opentofu/registry/internal/logging"
+	"github.com/opentofu/registry/internal/providers"
+)
+
+type KeysRequest struct {
+	ASCIIArmor     string `json:"ascii_armor"`
+	TrustSignature string `json:"trust_signature"`
+	Source         string `json:"source"`
+	SourceURL      string `json:"source_url"`
+	// ExpiresAt overrides the expiry of the key, e.g. to stop serving the old key some time after a rotation.
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type KeysResponse struct {
+	Namespace string     `json:"namespace"`
+	Keys      []keys.Key `json:"keys"`
+}
+
+// adminNamespaceKeys manages the GPG keys registered for a namespace, served with its providers next to the keys of
+// the repository. GET lists the keys, revoked and expired ones included, POST registers a key, and DELETE with a
+// `key_id` query parameter revokes one. Rotating a key is registering the new key, and letting the old one expire or
+// revoking it once the versions signed with it are not downloaded anymore.
+func adminNamespaceKeys(config config.Config) LambdaFunc {
+	return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		namespace := req.PathParameters["namespace"]
+		ctx = logging.With(ctx, "namespace", namespace)
+
+		if !isAdminRequest(config, req) {
+			return UnauthorizedResponse, nil
+		}
+		if config.KeyStore == nil {
+			return NotFoundResponse, nil
+		}
+
+		owner := keys.Owner(config.TenantName, config.EffectiveProviderNamespace(namespace))
+		switch req.HTTPMethod {
+		case http.MethodGet:
+			stored, err := config.KeyStore.Get(ctx, owner)
+			if err != nil {
+				logging.FromContext(ctx).Error("Error getting keys", "error", err)
+				return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+			}
+			return keysResponse(owner, stored)
+		case http.MethodPost:
+			var body KeysRequest
+			if err := json.Unmarshal([]byte(req.Body), &body); err != nil {
+				return badRequestResponse(fmt.Sprintf("invalid request body: %s", err)), nil
+			}
+			publicKey, err := providers.ParseArmoredKey([]byte(body.ASCIIArmor))
+			if err != nil {
+				return badRequestResponse(fmt.Sprintf("invalid key: %s", err)), nil
+			}
+
+			if body.ExpiresAt != nil {
+				publicKey.ExpiresAt = body.ExpiresAt
+			}
+			if publicKey.Expired(time.Now()) {
+				return badRequestResponse("the key has expired"), nil
+			}
+
+			key := keys.Key{
+				Owner:          owner,
+				KeyID:          publicKey.KeyID,
+				ASCIIArmor:     publicKey.ASCIIArmor,
+				TrustSignature: body.TrustSignature,
+				Source:         body.Source,
+				SourceURL:      body.SourceURL,
+				ExpiresAt:      publicKey.ExpiresAt,
+				CreatedAt:      time.Now(),
+			}
+			if err := config.KeyStore.Put(ctx, key); err != nil {
+				logging.FromContext(ctx).Error("Error storing key", "error", err)
+				return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+			}
+			resBody, err := json.Marshal(key)
+			if err != nil {
+				return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+			}
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusCreated, Body: string(resBody)}, nil
+		case http.MethodDelete:
+			keyID := req.QueryStringParameters["key_id"]
+			if keyID == "" {
+				return badRequestResponse("the key_id query parameter is required"), nil
+			}
+			if err := config.KeyStore.Revoke(ctx, owner, keyID); err != nil {
+				if errors.Is(err, keys.ErrNotFound) {
+					return NotFoundResponse, nil
+				}
+				logging.FromContext(ctx).Error("Error revoking key", "error", err)
+				return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+			}
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusNoContent}, nil
+		default:
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusMethodNotAllowed}, nil
+		}
+	}
+}
+
+func keysResponse(owner string, stored []keys.Key) (events.APIGatewayProxyResponse, error) {
+	if stored == nil {
+		stored = []keys.Key{}
+	}
+	resBody, err := json.Marshal(KeysResponse{Namespace: owner, Keys: stored})
+	if err != nil {
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+	}
+	return events.APIGatewayProxyResponse{StatusCode: http.StatusOK, Body: string(resBody)}, nil
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/logging"
+	"github.com/opentofu/registry/internal/redirects"
+)
+
+type ProviderRedirectRequest struct {
+	To string `json:"to"`
+}
+
+type ListProviderRedirectsResponse struct {
+	// Redirects are the redirects managed through the admin API.
+	Redirects []redirects.Redirect `json:"redirects"`
+	// Static are the redirects configured with the deployment, which the managed ones override.
+	Static map[string]string `json:"static"`
+}
+
+// adminListProviderRedirects lists the provider namespace redirects, both the managed and the static ones.
+func adminListProviderRedirects(config config.Config) LambdaFunc {
+	return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		if !isAdminRequest(config, req) {
+			return UnauthorizedResponse, nil
+		}
+		if config.DynamicProviderRedirects == nil {
+			return NotFoundResponse, nil
+		}
+
+		found, err := config.DynamicProviderRedirects.Store.List(ctx)
+		if err != nil {
+			logging.FromContext(ctx).Error("Error listing provider redirects", "error", err)
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+
+		static := config.ProviderRedirects
+		if static == nil {
+			static = map[string]string{}
+		}
+		resBody, err := json.Marshal(ListProviderRedirectsResponse{Redirects: append([]redirects.Redirect{}, found...), Static: static})
+		if err != nil {
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusOK, Body: string(resBody)}, nil
+	}
+}
+
+// adminProviderRedirect manages the redirect of a namespace. The redirects which would make a chain of redirects loop
+// are refused. The changes are applied right away on this instance, and within the refresh interval on the others.
+func adminProviderRedirect(config config.Config) LambdaFunc {
+	return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		namespace := req.PathParameters["namespace"]
+		ctx = logging.With(ctx, "namespace", namespace)
+
+		if !isAdminRequest(config, req) {
+			return UnauthorizedResponse, nil
+		}
+		if config.DynamicProviderRedirects == nil {
+			return NotFoundResponse, nil
+		}
+
+		store := config.DynamicProviderRedirects.Store
+		from := strings.ToLower(namespace)
+		switch req.HTTPMethod {
+		case http.MethodGet:
+			redirect, err := store.Get(ctx, from)
+			if err != nil {
+				logging.FromContext(ctx).Error("Error getting provider redirect", "error", err)
+				return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+			}
+			if redirect == nil {
+				return NotFoundResponse, nil
+			}
+			return providerRedirectResponse(*redirect)
+		case http.MethodPut:
+			var body ProviderRedirectRequest
+			if err := json.Unmarshal([]byte(req.Body), &body); err != nil {
+				return badRequestResponse(fmt.Sprintf("invalid request body: %s", err)), nil
+			}
+
+			redirect := redirects.New(namespace, body.To)
+			if err := redirect.Validate(); err != nil {
+				return badRequestResponse(err.Error()), nil
+			}
+
+			stored, err := store.List(ctx)
+			if err != nil {
+				logging.FromContext(ctx).Error("Error listing provider redirects", "error", err)
+				return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+			}
+			if err := config.CheckProviderRedirect(stored, redirect); err != nil {
+				return badRequestResponse(err.Error()), nil
+			}
+
+			if err := store.Put(ctx, redirect); err != nil {
+				logging.FromContext(ctx).Error("Error storing provider redirect", "error", err)
+				return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+			}
+			config.DynamicProviderRedirects.Invalidate()
+			return providerRedirectResponse(redirect)
+		case http.MethodDelete:
+			if err := store.Delete(ctx, from); err != nil {
+				logging.FromContext(ctx).Error("Error deleting provider redirect", "error", err)
+				return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+			}
+			config.DynamicProviderRedirects.Invalidate()
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusNoContent}, nil
+		default:
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusMethodNotAllowed}, nil
+		}
+	}
+}
+
+func providerRedirectResponse(redirect redirects.Redirect) (events.APIGatewayProxyResponse, error) {
+	resBody, err := json.Marshal(redirect)
+	if err != nil {
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+	}
+	return events.APIGatewayProxyResponse{StatusCode: http.StatusOK, Body: string(resBody)}, nil
+}
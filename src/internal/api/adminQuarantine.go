@@ -0,0 +1,192 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/logging"
+	"github.com/opentofu/registry/internal/platform"
+	"github.com/opentofu/registry/internal/providers/providercache"
+	"github.com/opentofu/registry/internal/providers/types"
+	"github.com/opentofu/registry/internal/quarantine"
+)
+
+type QuarantineResponse struct {
+	Provider    string                  `json:"provider"`
+	Quarantines []quarantine.Quarantine `json:"quarantines"`
+}
+
+type QuarantineListResponse struct {
+	Providers []QuarantineResponse `json:"providers"`
+}
+
+// adminListQuarantines lists the quarantines of every provider of the registry the request was made to, the quarantined
+// versions as well as the quarantined refreshes.
+func adminListQuarantines(config config.Config) LambdaFunc {
+	return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		if !isAdminRequest(config, req) {
+			return UnauthorizedResponse, nil
+		}
+
+		items, err := config.QuarantineStore.List(ctx)
+		if err != nil {
+			logging.FromContext(ctx).Error("Error listing quarantines", "error", err)
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+
+		// the tenants share the table, their providers are told apart by their address
+		response := QuarantineListResponse{Providers: []QuarantineResponse{}}
+		for _, item := range items {
+			if quarantine.Tenant(item.Provider) == config.TenantName {
+				response.Providers = append(response.Providers, QuarantineResponse{Provider: item.Provider, Quarantines: item.Quarantines})
+			}
+		}
+
+		resBody, err := json.Marshal(response)
+		if err != nil {
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusOK, Body: string(resBody)}, nil
+	}
+}
+
+// adminProviderQuarantine manages the quarantined versions of a provider. PUT quarantines a version by hand, and
+// DELETE with a `version` query parameter resolves a quarantine: the checksums published for the version are accepted
+// into the cache, and the version is served again. Resolving the `*` version resumes the refreshes of the provider,
+// with its count of failed refreshes reset.
+func adminProviderQuarantine(config config.Config) LambdaFunc {
+	return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		params := getListProvidersPathParams(req)
+		ctx = params.AnnotateLogger(ctx)
+
+		if !isAdminRequest(config, req) {
+			return UnauthorizedResponse, nil
+		}
+
+		effectiveNamespace := config.EffectiveProviderNamespace(params.Namespace)
+		address := quarantine.Address(config.TenantName, effectiveNamespace, params.Type)
+		quarantines, err := config.QuarantineStore.Get(ctx, address)
+		if err != nil {
+			logging.FromContext(ctx).Error("Error getting quarantines", "error", err)
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+
+		switch req.HTTPMethod {
+		case http.MethodGet:
+			if quarantines == nil {
+				return NotFoundResponse, nil
+			}
+			return quarantineResponse(address, quarantines)
+		case http.MethodPut:
+			var body quarantine.Quarantine
+			if err := json.Unmarshal([]byte(req.Body), &body); err != nil {
+				return badRequestResponse(fmt.Sprintf("invalid request body: %s", err)), nil
+			}
+			if err := body.Validate(); err != nil {
+				return badRequestResponse(err.Error()), nil
+			}
+			if body.DetectedAt.IsZero() {
+				body.DetectedAt = time.Now()
+			}
+			quarantines = append(withoutVersion(quarantines, body.Version), body)
+			if err := config.QuarantineStore.Put(ctx, address, quarantines); err != nil {
+				logging.FromContext(ctx).Error("Error storing quarantines", "error", err)
+				return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+			}
+			return quarantineResponse(address, quarantines)
+		case http.MethodDelete:
+			return resolveQuarantine(ctx, config, req, effectiveNamespace, params.Type, quarantines)
+		default:
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusMethodNotAllowed}, nil
+		}
+	}
+}
+
+func resolveQuarantine(ctx context.Context, config config.Config, req events.APIGatewayProxyRequest, effectiveNamespace, providerType string, quarantines []quarantine.Quarantine) (events.APIGatewayProxyResponse, error) {
+	version := req.QueryStringParameters["version"]
+	if version == "" {
+		return badRequestResponse("the version query parameter is required"), nil
+	}
+	q := quarantine.Find(quarantines, version)
+	if q == nil {
+		return NotFoundResponse, nil
+	}
+
+	key := fmt.Sprintf("%s/%s", effectiveNamespace, providerType)
+	if len(q.Mismatches) > 0 || version == quarantine.AllVersions {
+		// the item is changed as it is written back, so that the versions appended concurrently by a refresh are kept
+		err := config.ProviderVersionCache.UpdateVersions(ctx, key, func(item *types.CacheItem) error {
+			// accept the published checksums, so that the version is served with the assets as they are now
+			if len(q.Mismatches) > 0 {
+				item.Versions = quarantine.Accept(item.Versions, *q)
+			}
+			// give the refreshes a fresh start, otherwise the next failure would quarantine them again
+			if version == quarantine.AllVersions {
+				item.Metadata.RefreshFailures = 0
+				item.Metadata.LastRefreshError = ""
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, providercache.ErrNotFound) {
+			logging.FromContext(ctx).Error("Error storing document", "error", err)
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+	}
+
+	logging.FromContext(ctx).Info("Resolving quarantine", "version", version)
+	address := quarantine.Address(config.TenantName, effectiveNamespace, providerType)
+	if err := config.QuarantineStore.Put(ctx, address, withoutVersion(quarantines, version)); err != nil {
+		logging.FromContext(ctx).Error("Error storing quarantines", "error", err)
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+	}
+	return events.APIGatewayProxyResponse{StatusCode: http.StatusNoContent}, nil
+}
+
+func withoutVersion(quarantines []quarantine.Quarantine, version string) []quarantine.Quarantine {
+	remaining := make([]quarantine.Quarantine, 0, len(quarantines))
+	for _, q := range quarantines {
+		if q.Version != version {
+			remaining = append(remaining, q)
+		}
+	}
+	return remaining
+}
+
+func quarantineResponse(address string, quarantines []quarantine.Quarantine) (events.APIGatewayProxyResponse, error) {
+	resBody, err := json.Marshal(QuarantineResponse{Provider: address, Quarantines: quarantines})
+	if err != nil {
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+	}
+	return events.APIGatewayProxyResponse{StatusCode: http.StatusOK, Body: string(resBody)}, nil
+}
+
+// lookupQuarantines returns the quarantined versions of a provider. Failures are logged and ignored, like for
+// deprecations, so that an unavailable quarantine table does not take the downloads down with it.
+func lookupQuarantines(ctx context.Context, config config.Config, effectiveNamespace, providerType string) []quarantine.Quarantine {
+	if config.QuarantineStore == nil {
+		return nil
+	}
+	quarantines, err := config.QuarantineStore.Get(ctx, quarantine.Address(config.TenantName, effectiveNamespace, providerType))
+	if err != nil {
+		logging.FromContext(ctx).Error("Error getting quarantines", "error", err)
+		return nil
+	}
+	return quarantines
+}
+
+// versionQuarantinedResponse returns the error document of a quarantined version requested without a platform, such as
+// its checksums.
+func versionQuarantinedResponse(q *quarantine.Quarantine) events.APIGatewayProxyResponse {
+	return errorResponse(http.StatusNotFound, ErrCodeVersionQuarantined, fmt.Sprintf("version %s is quarantined: %s", q.Version, q.Reason))
+}
+
+func quarantinedResponse(q *quarantine.Quarantine, requested platform.Platform) events.APIGatewayProxyResponse {
+	message := fmt.Sprintf("version %s is quarantined: %s", q.Version, q.Reason)
+	return platformResponse(ErrCodeVersionQuarantined, message, PlatformAvailability{Requested: requested, Reason: types.PlatformQuarantined})
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/logging"
+	"github.com/opentofu/registry/internal/repomappings"
+)
+
+type RepositoryMappingRequest struct {
+	Owner        string `json:"owner"`
+	Repository   string `json:"repository"`
+	TagPrefix    string `json:"tag_prefix,omitempty"`
+	Subdirectory string `json:"subdirectory,omitempty"`
+}
+
+type ListRepositoryMappingsResponse struct {
+	Mappings []repomappings.Mapping `json:"mappings"`
+}
+
+// adminListRepositoryMappings lists the repositories mapped to providers and modules.
+func adminListRepositoryMappings(config config.Config) LambdaFunc {
+	return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		if !isAdminRequest(config, req) {
+			return UnauthorizedResponse, nil
+		}
+		if config.RepositoryMappings == nil {
+			return NotFoundResponse, nil
+		}
+
+		found, err := config.RepositoryMappings.List(ctx)
+		if err != nil {
+			logging.FromContext(ctx).Error("Error listing repository mappings", "error", err)
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+
+		resBody, err := json.Marshal(ListRepositoryMappingsResponse{Mappings: append([]repomappings.Mapping{}, found...)})
+		if err != nil {
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusOK, Body: string(resBody)}, nil
+	}
+}
+
+// adminProviderRepositoryMapping manages the repository mapped to a provider, under its effective namespace like the
+// cached versions it is used for.
+func adminProviderRepositoryMapping(config config.Config) LambdaFunc {
+	return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		params := getListProvidersPathParams(req)
+		ctx = params.AnnotateLogger(ctx)
+		address := repomappings.ProviderAddress(config.EffectiveProviderNamespace(params.Namespace), params.Type)
+		return handleRepositoryMapping(ctx, config, req, address)
+	}
+}
+
+func adminModuleRepositoryMapping(config config.Config) LambdaFunc {
+	return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		params := getListModuleVersionsPathParams(req)
+		ctx = params.AnnotateLogger(ctx)
+		return handleRepositoryMapping(ctx, config, req, repomappings.ModuleAddress(params.Namespace, params.Name, params.System))
+	}
+}
+
+func handleRepositoryMapping(ctx context.Context, config config.Config, req events.APIGatewayProxyRequest, address string) (events.APIGatewayProxyResponse, error) {
+	if !isAdminRequest(config, req) {
+		return UnauthorizedResponse, nil
+	}
+	if config.RepositoryMappings == nil {
+		return NotFoundResponse, nil
+	}
+
+	switch req.HTTPMethod {
+	case http.MethodGet:
+		mapping, err := config.RepositoryMappings.Get(ctx, address)
+		if err != nil {
+			logging.FromContext(ctx).Error("Error getting repository mapping", "error", err)
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+		if mapping == nil {
+			return NotFoundResponse, nil
+		}
+		return repositoryMappingResponse(*mapping)
+	case http.MethodPut:
+		var body RepositoryMappingRequest
+		if err := json.Unmarshal([]byte(req.Body), &body); err != nil {
+			return badRequestResponse(fmt.Sprintf("invalid request body: %s", err)), nil
+		}
+
+		mapping := repomappings.Mapping{Address: address, Owner: body.Owner, Repository: body.Repository, TagPrefix: body.TagPrefix, Subdirectory: body.Subdirectory}
+		if err := mapping.Validate(); err != nil {
+			return badRequestResponse(err.Error()), nil
+		}
+
+		if err := config.RepositoryMappings.Put(ctx, mapping); err != nil {
+			logging.FromContext(ctx).Error("Error storing repository mapping", "error", err)
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+		return repositoryMappingResponse(mapping)
+	case http.MethodDelete:
+		if err := config.RepositoryMappings.Delete(ctx, address); err != nil {
+			logging.FromContext(ctx).Error("Error deleting repository mapping", "error", err)
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusNoContent}, nil
+	default:
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusMethodNotAllowed}, nil
+	}
+}
+
+func repositoryMappingResponse(mapping repomappings.Mapping) (events.APIGatewayProxyResponse, error) {
+	resBody, err := json.Marshal(mapping)
+	if err != nil {
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+	}
+	return events.APIGatewayProxyResponse{StatusCode: http.StatusOK, Body: string(resBody)}, nil
+}
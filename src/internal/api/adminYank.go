@@ -0,0 +1,102 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/logging"
+	"github.com/opentofu/registry/internal/providers/providercache"
+	"github.com/opentofu/registry/internal/providers/types"
+)
+
+type YankRequest struct {
+	Reason string `json:"reason"`
+}
+
+type YankResponse struct {
+	Provider string      `json:"provider"`
+	Version  string      `json:"version"`
+	Yanked   *types.Yank `json:"yanked"`
+}
+
+// adminYankProviderVersion yanks a cached provider version, see types.CacheVersion.Yanked. PUT yanks the version with
+// the reason of the request, which the failed downloads of the version are told, and DELETE serves it again. Only the
+// cached versions can be yanked, and the yank is lost with the cached item, see adminProviderCache.
+func adminYankProviderVersion(config config.Config) LambdaFunc {
+	return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		params := getListProvidersPathParams(req)
+		ctx = params.AnnotateLogger(ctx)
+		version := req.PathParameters["version"]
+		logger := logging.FromContext(ctx).With("version", version)
+
+		if !isAdminRequest(config, req) {
+			return UnauthorizedResponse, nil
+		}
+
+		var yank *types.Yank
+		switch req.HTTPMethod {
+		case http.MethodPut:
+			var body YankRequest
+			if err := json.Unmarshal([]byte(req.Body), &body); err != nil {
+				return badRequestResponse(fmt.Sprintf("invalid request body: %s", err)), nil
+			}
+			if body.Reason == "" {
+				return badRequestResponse("reason is required"), nil
+			}
+			yank = &types.Yank{Reason: body.Reason, YankedAt: time.Now()}
+		case http.MethodDelete:
+		default:
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusMethodNotAllowed}, nil
+		}
+
+		if yank != nil {
+			logger.Warn("Yanking provider version", "reason", yank.Reason)
+		} else {
+			logger.Info("Serving yanked provider version again")
+		}
+
+		// the item is changed as it is written back, so that the versions appended concurrently by a refresh are kept
+		key := fmt.Sprintf("%s/%s", config.EffectiveProviderNamespace(params.Namespace), params.Type)
+		err := config.ProviderVersionCache.UpdateVersions(ctx, key, func(item *types.CacheItem) error {
+			if findCacheVersion(item, version) == nil {
+				return errVersionNotCached
+			}
+			versions := append(types.VersionList{}, item.Versions...)
+			for i := range versions {
+				if versions[i].Version == version {
+					versions[i].Yanked = yank
+				}
+			}
+			item.Versions = versions
+			// the stored listing has the versions as they were, so it is rendered by the API until the next refresh
+			item.Metadata.Listing = ""
+			return nil
+		})
+		if errors.Is(err, providercache.ErrNotFound) || errors.Is(err, errVersionNotCached) {
+			return versionNotFoundResponse(version), nil
+		}
+		if err != nil {
+			logger.Error("Error storing document", "error", err)
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+
+		if yank == nil {
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusNoContent}, nil
+		}
+		return jsonResponse(YankResponse{Provider: key, Version: version, Yanked: yank})
+	}
+}
+
+// errVersionNotCached is returned by the update of adminYankProviderVersion when the version is not in the item.
+var errVersionNotCached = errors.New("version is not cached")
+
+func yankedResponse(v *types.CacheVersion) events.APIGatewayProxyResponse {
+	message := fmt.Sprintf("version %s was yanked and can no longer be downloaded: %s", v.Version, v.Yanked.Reason)
+	return errorResponse(http.StatusNotFound, ErrCodeVersionYanked, message)
+}
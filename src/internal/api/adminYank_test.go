@@ -0,0 +1,109 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/providers/providercache"
+	"github.com/opentofu/registry/internal/providers/types"
+)
+
+// storingProviderCache is a provider cache which can get, delete and store items.
+type storingProviderCache struct {
+	itemsProviderCache
+}
+
+func (c storingProviderCache) Store(_ context.Context, key string, versions types.VersionList, metadata types.ProviderMetadata) error {
+	c.items[key] = &types.CacheItem{Provider: key, Versions: versions, Metadata: metadata, LastUpdated: time.Now()}
+	return nil
+}
+
+func (c storingProviderCache) UpdateVersions(_ context.Context, key string, update func(item *types.CacheItem) error) error {
+	item, ok := c.items[key]
+	if !ok {
+		return providercache.ErrNotFound
+	}
+	updated := *item
+	if err := update(&updated); err != nil {
+		return err
+	}
+	c.items[key] = &updated
+	return nil
+}
+
+func TestAdminYankProviderVersion(t *testing.T) {
+	lastUpdated := time.Now().Add(-time.Hour)
+	cache := storingProviderCache{itemsProviderCache{items: map[string]*types.CacheItem{
+		"example/cached": {
+			Provider:    "example/cached",
+			Versions:    types.VersionList{{Version: "1.1.0"}, {Version: "1.0.0"}},
+			LastUpdated: lastUpdated,
+			Metadata:    types.ProviderMetadata{Listing: "listings/example/cached.json"},
+		},
+	}}}
+	cfg := config.Config{AdminAPIToken: "secret", ProviderVersionCache: cache}
+	params := map[string]string{"namespace": "example", "type": "cached", "version": "1.1.0"}
+
+	yank := adminRequest(http.MethodPut, params)
+	yank.Body = `{"reason":"CVE-2023-1234"}`
+	response, err := adminYankProviderVersion(cfg)(context.Background(), yank)
+	if err != nil || response.StatusCode != http.StatusOK {
+		t.Fatalf("PUT = %d, %v", response.StatusCode, err)
+	}
+	var yanked YankResponse
+	if err := json.Unmarshal([]byte(response.Body), &yanked); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if yanked.Version != "1.1.0" || yanked.Yanked == nil || yanked.Yanked.Reason != "CVE-2023-1234" {
+		t.Errorf("unexpected response %+v", yanked)
+	}
+
+	document := cache.items["example/cached"]
+	if document.Versions[0].Yanked == nil || document.Versions[1].Yanked != nil {
+		t.Errorf("expected only 1.1.0 to be yanked, got %+v", document.Versions)
+	}
+	if document.Metadata.Listing != "" {
+		t.Errorf("expected the stored listing to be dropped, got %q", document.Metadata.Listing)
+	}
+	if !document.LastUpdated.Equal(lastUpdated) {
+		t.Errorf("LastUpdated = %v, want it kept at %v", document.LastUpdated, lastUpdated)
+	}
+
+	download := DownloadHandlerPathParams{Namespace: "example", Type: "cached", Version: "1.1.0", OS: "linux", Architecture: "amd64"}
+	response, err = processDocumentForProviderDownload(context.Background(), cfg, events.APIGatewayProxyRequest{}, document, "example", download)
+	if err != nil || response.StatusCode != http.StatusNotFound {
+		t.Fatalf("download = %d, %v, want %d", response.StatusCode, err, http.StatusNotFound)
+	}
+	var errDocument ErrorDocument
+	if err := json.Unmarshal([]byte(response.Body), &errDocument); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if errDocument.Errors[0].Code != ErrCodeVersionYanked {
+		t.Errorf("code = %q, want %q", errDocument.Errors[0].Code, ErrCodeVersionYanked)
+	}
+
+	response, err = adminYankProviderVersion(cfg)(context.Background(), adminRequest(http.MethodDelete, params))
+	if err != nil || response.StatusCode != http.StatusNoContent {
+		t.Fatalf("DELETE = %d, %v", response.StatusCode, err)
+	}
+	if v := cache.items["example/cached"].Versions[0]; v.Yanked != nil {
+		t.Errorf("expected 1.1.0 to be served again, got %+v", v)
+	}
+
+	missing := adminRequest(http.MethodPut, map[string]string{"namespace": "example", "type": "cached", "version": "2.0.0"})
+	missing.Body = `{"reason":"CVE-2023-1234"}`
+	if response, _ := adminYankProviderVersion(cfg)(context.Background(), missing); response.StatusCode != http.StatusNotFound {
+		t.Errorf("PUT of an uncached version = %d, want %d", response.StatusCode, http.StatusNotFound)
+	}
+
+	uncached := adminRequest(http.MethodPut, map[string]string{"namespace": "example", "type": "uncached", "version": "1.0.0"})
+	uncached.Body = `{"reason":"CVE-2023-1234"}`
+	if response, _ := adminYankProviderVersion(cfg)(context.Background(), uncached); response.StatusCode != http.StatusNotFound {
+		t.Errorf("PUT of an uncached provider = %d, want %d", response.StatusCode, http.StatusNotFound)
+	}
+}
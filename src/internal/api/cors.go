@@ -0,0 +1,100 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/metrics"
+)
+
+// corsMaxAge is how long, in seconds, browsers may reuse the answer to a preflight request.
+const corsMaxAge = "3600"
+
+// corsAllowedHeaders are the request headers the frontends may send.
+const corsAllowedHeaders = "Accept, Authorization, Content-Type, If-Modified-Since, If-None-Match"
+
+// corsExposedHeaders are the response headers, beyond the ones always readable, the frontends may read.
+const corsExposedHeaders = "Allow, ETag, Location, Retry-After, X-Terraform-Get, " + requestIDHeader + ", " + effectiveAddressHeader
+
+// withCORS lets the browser-based frontends of config.CORSAllowedOrigins call every route of the API: it answers the
+// preflight requests of the routes, and adds the CORS headers to every response. Without allowed origins, the requests
+// are passed on as they are.
+func withCORS(config config.Config, next LambdaFunc) LambdaFunc {
+	if len(config.CORSAllowedOrigins) == 0 {
+		return next
+	}
+
+	return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		origin := getHeader(req, "Origin")
+		if req.HTTPMethod == http.MethodOptions && origin != "" && getHeader(req, "Access-Control-Request-Method") != "" {
+			// the preflight requests of unknown paths get the usual not found error
+			if matched, _ := matchRoute(RouteHandlers(config), req.Path); matched != nil {
+				response := preflightResponse(config, origin, getHeader(req, "Access-Control-Request-Method"), matched)
+				metrics.Inc(metrics.HTTPRequests, metrics.Labels{"route": matched.Pattern, "status": strconv.Itoa(response.StatusCode)})
+				return response, nil
+			}
+		}
+
+		response, err := next(ctx, req)
+		return withCORSHeaders(config, origin, response), err
+	}
+}
+
+// preflightResponse answers the preflight request of a route. The CORS headers are left out if the origin is not
+// allowed or the route does not accept the method, so that the browser does not make the request.
+func preflightResponse(config config.Config, origin, method string, route *Route) events.APIGatewayProxyResponse {
+	response := events.APIGatewayProxyResponse{
+		StatusCode: http.StatusNoContent,
+		Headers:    map[string]string{"Allow": route.allowHeader(), "Vary": "Origin"},
+	}
+
+	allowedOrigin, ok := corsAllowedOrigin(config.CORSAllowedOrigins, origin)
+	if !ok || !route.Allows(method) {
+		return response
+	}
+	response.Headers["Access-Control-Allow-Origin"] = allowedOrigin
+	response.Headers["Access-Control-Allow-Methods"] = route.allowHeader()
+	response.Headers["Access-Control-Allow-Headers"] = corsAllowedHeaders
+	response.Headers["Access-Control-Max-Age"] = corsMaxAge
+	return response
+}
+
+// withCORSHeaders adds the CORS headers for a request from the origin to its response. Responses vary by origin, so
+// that the caches do not serve the headers for one origin to another.
+func withCORSHeaders(config config.Config, origin string, response events.APIGatewayProxyResponse) events.APIGatewayProxyResponse {
+	// the headers of the shared responses must not be modified, so set the headers on a copy
+	headers := make(map[string]string, len(response.Headers)+3) //nolint:gomnd // Vary and the two CORS headers.
+	for name, value := range response.Headers {
+		headers[name] = value
+	}
+	if vary := headers["Vary"]; vary != "" {
+		headers["Vary"] = vary + ", Origin"
+	} else {
+		headers["Vary"] = "Origin"
+	}
+
+	if allowedOrigin, ok := corsAllowedOrigin(config.CORSAllowedOrigins, origin); ok && origin != "" {
+		headers["Access-Control-Allow-Origin"] = allowedOrigin
+		headers["Access-Control-Expose-Headers"] = corsExposedHeaders
+	}
+	response.Headers = headers
+	return response
+}
+
+// corsAllowedOrigin returns the value of the Access-Control-Allow-Origin header for a request from the origin, if it
+// is allowed: `*` if any origin is, the origin itself otherwise.
+func corsAllowedOrigin(allowed []string, origin string) (string, bool) {
+	for _, a := range allowed {
+		if a == "*" {
+			return "*", true
+		}
+		if strings.EqualFold(a, origin) {
+			return origin, true
+		}
+	}
+	return "", false
+}
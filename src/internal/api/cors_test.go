@@ -0,0 +1,74 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/opentofu/registry/internal/config"
+)
+
+func TestCORSPreflight(t *testing.T) {
+	router := Router(config.Config{CORSAllowedOrigins: []string{"https://ui.example.com"}})
+
+	tests := []struct {
+		name       string
+		origin     string
+		method     string
+		wantOrigin string
+	}{
+		{name: "allowed", origin: "https://ui.example.com", method: http.MethodGet, wantOrigin: "https://ui.example.com"},
+		{name: "other origin", origin: "https://evil.example.com", method: http.MethodGet},
+		{name: "method not allowed", origin: "https://ui.example.com", method: http.MethodDelete},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := events.APIGatewayProxyRequest{
+				HTTPMethod: http.MethodOptions,
+				Path:       "/v1/providers/hashicorp/aws/versions",
+				Headers:    map[string]string{"Origin": tt.origin, "Access-Control-Request-Method": tt.method},
+			}
+			response, err := router(context.Background(), req)
+			if err != nil || response.StatusCode != http.StatusNoContent {
+				t.Fatalf("Router() = %d, %v", response.StatusCode, err)
+			}
+			if got := response.Headers["Access-Control-Allow-Origin"]; got != tt.wantOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+			if tt.wantOrigin != "" && response.Headers["Access-Control-Allow-Methods"] != "GET, HEAD" {
+				t.Errorf("Access-Control-Allow-Methods = %q", response.Headers["Access-Control-Allow-Methods"])
+			}
+		})
+	}
+}
+
+func TestCORSHeaders(t *testing.T) {
+	router := Router(config.Config{CORSAllowedOrigins: []string{"*"}})
+
+	for path, wantStatus := range map[string]int{"/.well-known/terraform.json": http.StatusOK, "/unknown": http.StatusNotFound} {
+		req := events.APIGatewayProxyRequest{HTTPMethod: http.MethodGet, Path: path, Headers: map[string]string{"Origin": "https://ui.example.com"}}
+		response, err := router(context.Background(), req)
+		if err != nil || response.StatusCode != wantStatus {
+			t.Fatalf("Router() for %s = %d, %v", path, response.StatusCode, err)
+		}
+		if response.Headers["Access-Control-Allow-Origin"] != "*" || response.Headers["Access-Control-Expose-Headers"] == "" {
+			t.Errorf("missing CORS headers for %s: %v", path, response.Headers)
+		}
+	}
+}
+
+func TestCORSDisabled(t *testing.T) {
+	req := events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodOptions,
+		Path:       "/v1/providers/hashicorp/aws/versions",
+		Headers:    map[string]string{"Origin": "https://ui.example.com", "Access-Control-Request-Method": http.MethodGet},
+	}
+	response, err := Router(config.Config{})(context.Background(), req)
+	if err != nil || response.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatalf("Router() = %d, %v", response.StatusCode, err)
+	}
+	if _, ok := response.Headers["Access-Control-Allow-Origin"]; ok {
+		t.Errorf("CORS headers returned without allowed origins")
+	}
+}
@@ -0,0 +1,266 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/downloads"
+	"github.com/opentofu/registry/internal/logging"
+	"github.com/opentofu/registry/internal/version"
+)
+
+type DownloadSummaryResponse struct {
+	Namespace string `json:"namespace"`
+	Type      string `json:"type"`
+	Downloads int64  `json:"downloads"`
+	// LastDownloadedAt is absent when the provider was never downloaded.
+	LastDownloadedAt *time.Time               `json:"last_downloaded_at,omitempty"`
+	Versions         []VersionDownloadSummary `json:"versions"`
+}
+
+type VersionDownloadSummary struct {
+	Version   string           `json:"version"`
+	Downloads int64            `json:"downloads"`
+	Platforms map[string]int64 `json:"platforms"` // The downloads per `<os>_<arch>` platform.
+}
+
+// providerDownloadSummary returns the number of downloads of a provider, per version and platform. Only the downloads
+// served since the download statistics are recorded are counted.
+func providerDownloadSummary(config config.Config) LambdaFunc {
+	return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		namespace, providerType := req.PathParameters["namespace"], req.PathParameters["type"]
+		ctx = logging.With(ctx, "namespace", namespace, "type", providerType)
+
+		if config.DownloadStats == nil {
+			logging.FromContext(ctx).Info("Download statistics are not recorded, returning 404")
+			return NotFoundResponse, nil
+		}
+
+		effectiveNamespace := config.EffectiveProviderNamespace(namespace)
+		counts, err := config.DownloadStats.Get(ctx, downloads.ProviderSubject(config.TenantName, effectiveNamespace, providerType))
+		if err != nil {
+			logging.FromContext(ctx).Error("Error getting download counts", "error", err)
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+
+		response := summarizeDownloads(counts)
+		response.Namespace = namespace
+		response.Type = providerType
+		return jsonResponse(response)
+	}
+}
+
+// summarizeDownloads sums up the download counts of a provider per version, newest version first.
+func summarizeDownloads(counts []downloads.Count) DownloadSummaryResponse {
+	response := DownloadSummaryResponse{Versions: []VersionDownloadSummary{}}
+	byVersion := make(map[string]*VersionDownloadSummary)
+	for _, c := range counts {
+		summary, ok := byVersion[c.Version]
+		if !ok {
+			summary = &VersionDownloadSummary{Version: c.Version, Platforms: make(map[string]int64)}
+			byVersion[c.Version] = summary
+		}
+		summary.Downloads += c.Downloads
+		if c.Platform != "" {
+			summary.Platforms[c.Platform] += c.Downloads
+		}
+
+		response.Downloads += c.Downloads
+		if response.LastDownloadedAt == nil || c.LastDownloadedAt.After(*response.LastDownloadedAt) {
+			lastDownloadedAt := c.LastDownloadedAt
+			response.LastDownloadedAt = &lastDownloadedAt
+		}
+	}
+
+	for _, summary := range byVersion {
+		response.Versions = append(response.Versions, *summary)
+	}
+	sort.Slice(response.Versions, func(i, j int) bool {
+		return version.Compare(response.Versions[i].Version, response.Versions[j].Version) > 0
+	})
+	return response
+}
+
+// topPeriods are the periods the most downloaded providers and modules can be ranked over, by `period` query
+// parameter, in days.
+var topPeriods = map[string]int{"day": 1, "week": 7, "month": downloads.MaxTopDays} //nolint:gochecknoglobals // This should be treated as a constant.
+
+const (
+	// defaultTopPeriod is the period of the rankings when the request does not ask for one.
+	defaultTopPeriod = "week"
+	// defaultTopLimit and maxTopLimit are the default and largest numbers of providers or modules ranked.
+	defaultTopLimit = 10
+	maxTopLimit     = 100
+)
+
+type TopProvidersResponse struct {
+	Period    string        `json:"period"`
+	Providers []TopProvider `json:"providers"`
+}
+
+type TopProvider struct {
+	Namespace string `json:"namespace"`
+	Type      string `json:"type"`
+	Downloads int64  `json:"downloads"`
+}
+
+type TopModulesResponse struct {
+	Period  string      `json:"period"`
+	Modules []TopModule `json:"modules"`
+}
+
+type TopModule struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	System    string `json:"system"`
+	Downloads int64  `json:"downloads"`
+}
+
+// topListing is what the client asked the most downloaded providers or modules for, parsed from the query
+// parameters.
+type topListing struct {
+	period string
+	days   int
+	limit  int
+}
+
+func parseTopListing(req events.APIGatewayProxyRequest) (topListing, error) {
+	listing := topListing{period: defaultTopPeriod, limit: defaultTopLimit}
+	if period := req.QueryStringParameters["period"]; period != "" {
+		listing.period = period
+	}
+	days, ok := topPeriods[listing.period]
+	if !ok {
+		return listing, fmt.Errorf("invalid period, expected day, week or month")
+	}
+	listing.days = days
+
+	if value := req.QueryStringParameters["limit"]; value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxTopLimit {
+			return listing, fmt.Errorf("invalid limit, expected a number between 1 and %d", maxTopLimit)
+		}
+		listing.limit = limit
+	}
+	return listing, nil
+}
+
+// topProviders returns the most downloaded providers over the `period` of the request, `day`, `week` or `month`, up
+// to `limit` of them. Only the downloads served since the download statistics are recorded are counted.
+func topProviders(config config.Config) LambdaFunc {
+	return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		if config.DownloadStats == nil {
+			logging.FromContext(ctx).Info("Download statistics are not recorded, returning 404")
+			return NotFoundResponse, nil
+		}
+		listing, err := parseTopListing(req)
+		if err != nil {
+			return badRequestResponse(err.Error()), nil
+		}
+
+		totals, err := config.DownloadStats.Top(ctx, config.TenantName, downloads.KindProviders, listing.days)
+		if err != nil {
+			logging.FromContext(ctx).Error("Error getting download totals", "error", err)
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+
+		response := TopProvidersResponse{Period: listing.period, Providers: []TopProvider{}}
+		for _, total := range totals {
+			if len(response.Providers) == listing.limit {
+				break
+			}
+			// the private providers the request cannot read are not ranked
+			if !canReadAddress(config, total.Address) {
+				continue
+			}
+			namespace, providerType, _ := strings.Cut(total.Address, "/")
+			response.Providers = append(response.Providers, TopProvider{Namespace: namespace, Type: providerType, Downloads: total.Downloads})
+		}
+
+		return negotiatedResponse(req, response, func() JSONAPIDocument {
+			return topProvidersDocument(response)
+		})
+	}
+}
+
+// topModules returns the most downloaded modules, see topProviders.
+func topModules(config config.Config) LambdaFunc {
+	return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		if config.DownloadStats == nil {
+			logging.FromContext(ctx).Info("Download statistics are not recorded, returning 404")
+			return NotFoundResponse, nil
+		}
+		listing, err := parseTopListing(req)
+		if err != nil {
+			return badRequestResponse(err.Error()), nil
+		}
+
+		totals, err := config.DownloadStats.Top(ctx, config.TenantName, downloads.KindModules, listing.days)
+		if err != nil {
+			logging.FromContext(ctx).Error("Error getting download totals", "error", err)
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+
+		response := TopModulesResponse{Period: listing.period, Modules: []TopModule{}}
+		for _, total := range totals {
+			if len(response.Modules) == listing.limit {
+				break
+			}
+			parts := strings.SplitN(total.Address, "/", 3)
+			if len(parts) != 3 {
+				continue
+			}
+			response.Modules = append(response.Modules, TopModule{Namespace: parts[0], Name: parts[1], System: parts[2], Downloads: total.Downloads})
+		}
+
+		return negotiatedResponse(req, response, func() JSONAPIDocument {
+			return topModulesDocument(response)
+		})
+	}
+}
+
+// recordProviderDownloads wraps the provider download handler to count the downloads it serves. A failure to record a
+// download is logged and does not fail the download.
+func recordProviderDownloads(config config.Config, handler LambdaFunc) LambdaFunc {
+	return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		response, err := handler(ctx, req)
+		// the clients revalidating the download they cached download the archive all the same
+		served := response.StatusCode == http.StatusOK || response.StatusCode == http.StatusNotModified
+		if err != nil || !served || config.DownloadStats == nil {
+			return response, err
+		}
+
+		params := getDownloadPathParams(req)
+		subject := downloads.ProviderSubject(config.TenantName, config.EffectiveProviderNamespace(params.Namespace), params.Type)
+		platform := fmt.Sprintf("%s_%s", params.OS, params.Architecture)
+		if recordErr := config.DownloadStats.Record(ctx, subject, params.Version, platform); recordErr != nil {
+			logging.FromContext(ctx).Error("Could not record download", "error", recordErr)
+		}
+		return response, nil
+	}
+}
+
+// recordModuleDownloads wraps the module download handler to count the downloads it serves, see
+// recordProviderDownloads.
+func recordModuleDownloads(config config.Config, handler LambdaFunc) LambdaFunc {
+	return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		response, err := handler(ctx, req)
+		if err != nil || response.StatusCode != http.StatusNoContent || config.DownloadStats == nil {
+			return response, err
+		}
+
+		params := getDownloadModuleHandlerPathParams(req)
+		subject := downloads.ModuleSubject(config.TenantName, params.Namespace, params.Name, params.System)
+		if recordErr := config.DownloadStats.Record(ctx, subject, params.Version, ""); recordErr != nil {
+			logging.FromContext(ctx).Error("Could not record download", "error", recordErr)
+		}
+		return response, nil
+	}
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/opentofu/registry/internal/access"
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/downloads"
+)
+
+func TestSummarizeDownloads(t *testing.T) {
+	last := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	summary := summarizeDownloads([]downloads.Count{
+		{Version: "1.2.0", Platform: "linux_amd64", Downloads: 5, LastDownloadedAt: last.Add(-time.Hour)},
+		{Version: "1.10.0", Platform: "linux_amd64", Downloads: 3, LastDownloadedAt: last},
+		{Version: "1.10.0", Platform: "darwin_arm64", Downloads: 2, LastDownloadedAt: last.Add(-time.Minute)},
+	})
+
+	if summary.Downloads != 10 {
+		t.Errorf("Downloads = %d, want 10", summary.Downloads)
+	}
+	if summary.LastDownloadedAt == nil || !summary.LastDownloadedAt.Equal(last) {
+		t.Errorf("LastDownloadedAt = %v, want %v", summary.LastDownloadedAt, last)
+	}
+	if len(summary.Versions) != 2 || summary.Versions[0].Version != "1.10.0" || summary.Versions[1].Version != "1.2.0" {
+		t.Fatalf("Versions = %+v, want 1.10.0 then 1.2.0", summary.Versions)
+	}
+	if v := summary.Versions[0]; v.Downloads != 5 || v.Platforms["linux_amd64"] != 3 || v.Platforms["darwin_arm64"] != 2 {
+		t.Errorf("Versions[0] = %+v", v)
+	}
+
+	if empty := summarizeDownloads(nil); empty.Downloads != 0 || empty.LastDownloadedAt != nil || empty.Versions == nil {
+		t.Errorf("summarizeDownloads(nil) = %+v", empty)
+	}
+}
+
+// topDownloadStats serves the totals of each kind, recording the periods it was asked for.
+type topDownloadStats struct {
+	downloads.Store
+	totals map[string][]downloads.Total
+	days   []int
+}
+
+func (s *topDownloadStats) Top(_ context.Context, _, kind string, days int) ([]downloads.Total, error) {
+	s.days = append(s.days, days)
+	return s.totals[kind], nil
+}
+
+func TestTopProviders(t *testing.T) {
+	stats := &topDownloadStats{totals: map[string][]downloads.Total{
+		downloads.KindProviders: {
+			{Address: "example/secret", Downloads: 30},
+			{Address: "example/popular", Downloads: 20},
+			{Address: "example/rising", Downloads: 10},
+			{Address: "example/niche", Downloads: 1},
+		},
+	}}
+	cfg := config.Config{DownloadStats: stats, PrivateProviders: access.ACLs{"example/secret": {"read-token"}}}
+
+	response, err := topProviders(cfg)(context.Background(), events.APIGatewayProxyRequest{
+		QueryStringParameters: map[string]string{"period": "month", "limit": "2"},
+	})
+	if err != nil || response.StatusCode != http.StatusOK {
+		t.Fatalf("topProviders() = %d, %v", response.StatusCode, err)
+	}
+	var top TopProvidersResponse
+	if err := json.Unmarshal([]byte(response.Body), &top); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []TopProvider{{Namespace: "example", Type: "popular", Downloads: 20}, {Namespace: "example", Type: "rising", Downloads: 10}}
+	if top.Period != "month" || !reflect.DeepEqual(top.Providers, want) {
+		t.Errorf("unexpected response %+v, want the two most downloaded public providers", top)
+	}
+	if !reflect.DeepEqual(stats.days, []int{downloads.MaxTopDays}) {
+		t.Errorf("downloads summed over %v days, want %d", stats.days, downloads.MaxTopDays)
+	}
+
+	response, err = topProviders(cfg)(context.Background(), events.APIGatewayProxyRequest{
+		Headers: map[string]string{"Accept": jsonAPIContentType},
+	})
+	if err != nil || response.Headers["Content-Type"] != jsonAPIContentType {
+		t.Fatalf("topProviders() = %v, %v, want a JSON:API document", response.Headers, err)
+	}
+	var document struct {
+		Data []JSONAPIResource `json:"data"`
+		Meta map[string]any    `json:"meta"`
+	}
+	if err := json.Unmarshal([]byte(response.Body), &document); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(document.Data) != 3 || document.Data[0].ID != "example/popular" || document.Meta["period"] != defaultTopPeriod {
+		t.Errorf("unexpected document %+v", document)
+	}
+}
+
+func TestTopModules(t *testing.T) {
+	stats := &topDownloadStats{totals: map[string][]downloads.Total{
+		downloads.KindModules: {{Address: "example/vpc/aws", Downloads: 5}, {Address: "example/network/google", Downloads: 2}},
+	}}
+
+	response, err := topModules(config.Config{DownloadStats: stats})(context.Background(), events.APIGatewayProxyRequest{
+		QueryStringParameters: map[string]string{"period": "day"},
+	})
+	if err != nil || response.StatusCode != http.StatusOK {
+		t.Fatalf("topModules() = %d, %v", response.StatusCode, err)
+	}
+	var top TopModulesResponse
+	if err := json.Unmarshal([]byte(response.Body), &top); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []TopModule{{Namespace: "example", Name: "vpc", System: "aws", Downloads: 5}, {Namespace: "example", Name: "network", System: "google", Downloads: 2}}
+	if !reflect.DeepEqual(top.Modules, want) || !reflect.DeepEqual(stats.days, []int{1}) {
+		t.Errorf("unexpected response %+v over %v days", top, stats.days)
+	}
+}
+
+func TestTopDownloadsErrors(t *testing.T) {
+	stats := &topDownloadStats{}
+	tests := []struct {
+		name   string
+		config config.Config
+		query  map[string]string
+		status int
+	}{
+		{name: "not recorded", status: http.StatusNotFound},
+		{name: "unknown period", config: config.Config{DownloadStats: stats}, query: map[string]string{"period": "year"}, status: http.StatusBadRequest},
+		{name: "limit too large", config: config.Config{DownloadStats: stats}, query: map[string]string{"limit": "101"}, status: http.StatusBadRequest},
+		{name: "invalid limit", config: config.Config{DownloadStats: stats}, query: map[string]string{"limit": "ten"}, status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := events.APIGatewayProxyRequest{QueryStringParameters: tt.query}
+			for name, handler := range map[string]LambdaFunc{"providers": topProviders(tt.config), "modules": topModules(tt.config)} {
+				if response, _ := handler(context.Background(), req); response.StatusCode != tt.status {
+					t.Errorf("%s: status = %d, want %d", name, response.StatusCode, tt.status)
+				}
+			}
+		})
+	}
+	if len(stats.days) != 0 {
+		t.Errorf("expected no totals to be read, got %v", stats.days)
+	}
+}
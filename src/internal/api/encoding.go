@@ -0,0 +1,140 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/klauspost/compress/zstd"
+	"golang.org/x/exp/slog"
+)
+
+// minCompressibleSize is the smallest response body we bother compressing.
+const minCompressibleSize = 1024
+
+// supportedEncodings lists the content encodings we can produce, in order of preference when the client accepts
+// several of them with the same weight.
+//
+//nolint:gochecknoglobals // This should be treated as a constant.
+var supportedEncodings = []string{"zstd", "br", "gzip"}
+
+// negotiateEncoding picks the best supported content encoding for the given Accept-Encoding header, returning an
+// empty string if the response should not be encoded.
+func negotiateEncoding(acceptEncoding string) string {
+	weights := make(map[string]float64)
+	wildcard := -1.0
+
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		weight := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			weight = parsed
+		}
+
+		if name == "*" {
+			wildcard = weight
+			continue
+		}
+		weights[name] = weight
+	}
+
+	best, bestWeight := "", 0.0
+	for _, encoding := range supportedEncodings {
+		weight, ok := weights[encoding]
+		if !ok {
+			weight = wildcard
+		}
+		if weight > bestWeight {
+			best, bestWeight = encoding, weight
+		}
+	}
+	return best
+}
+
+func compressBody(encoding string, body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	switch encoding {
+	case "zstd":
+		encoder, err := zstd.NewWriter(nil)
+		if err != nil {
+			return nil, err
+		}
+		defer encoder.Close()
+		return encoder.EncodeAll(body, nil), nil
+	case "br":
+		writer := brotli.NewWriterLevel(&buf, brotli.DefaultCompression)
+		if _, err := writer.Write(body); err != nil {
+			return nil, err
+		}
+		if err := writer.Close(); err != nil {
+			return nil, err
+		}
+	case "gzip":
+		writer := gzip.NewWriter(&buf)
+		if _, err := writer.Write(body); err != nil {
+			return nil, err
+		}
+		if err := writer.Close(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported encoding %q", encoding)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// encodeResponse compresses the response body with the best encoding the client accepts. The encoded body is
+// returned base64 encoded, which API Gateway decodes before sending it to the client.
+func encodeResponse(req events.APIGatewayProxyRequest, response events.APIGatewayProxyResponse) events.APIGatewayProxyResponse {
+	if response.IsBase64Encoded || len(response.Body) < minCompressibleSize {
+		return response
+	}
+	if _, ok := response.Headers["Content-Encoding"]; ok {
+		return response
+	}
+
+	// the headers of the shared responses must not be modified, so set the headers on a copy
+	headers := make(map[string]string, len(response.Headers)+2) //nolint:gomnd // Vary and Content-Encoding.
+	for name, value := range response.Headers {
+		headers[name] = value
+	}
+	if vary := headers["Vary"]; vary != "" {
+		headers["Vary"] = vary + ", Accept-Encoding"
+	} else {
+		headers["Vary"] = "Accept-Encoding"
+	}
+	response.Headers = headers
+
+	encoding := negotiateEncoding(getHeader(req, "Accept-Encoding"))
+	if encoding == "" {
+		return response
+	}
+
+	compressed, err := compressBody(encoding, []byte(response.Body))
+	if err != nil {
+		// compression is an optimisation, so just fall back to the plain body
+		slog.Error("Error compressing response", "encoding", encoding, "error", err)
+		return response
+	}
+
+	response.Headers["Content-Encoding"] = encoding
+	response.Body = base64.StdEncoding.EncodeToString(compressed)
+	response.IsBase64Encoded = true
+	return response
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := []struct {
+		acceptEncoding string
+		want           string
+	}{
+		{acceptEncoding: "", want: ""},
+		{acceptEncoding: "identity", want: ""},
+		{acceptEncoding: "gzip", want: "gzip"},
+		{acceptEncoding: "gzip, deflate, br", want: "br"},
+		{acceptEncoding: "gzip, br, zstd", want: "zstd"},
+		{acceptEncoding: "GZIP", want: "gzip"},
+		{acceptEncoding: "zstd;q=0.5, gzip;q=0.8", want: "gzip"},
+		{acceptEncoding: "br;q=0, gzip", want: "gzip"},
+		{acceptEncoding: "gzip;q=0", want: ""},
+		{acceptEncoding: "gzip;q=invalid", want: ""},
+		{acceptEncoding: "*", want: "zstd"},
+		{acceptEncoding: "*;q=0.5, gzip", want: "gzip"},
+		{acceptEncoding: "*, zstd;q=0", want: "br"},
+		{acceptEncoding: "*;q=0", want: ""},
+	}
+	for _, tt := range tests {
+		if got := negotiateEncoding(tt.acceptEncoding); got != tt.want {
+			t.Errorf("negotiateEncoding(%q) = %q, want %q", tt.acceptEncoding, got, tt.want)
+		}
+	}
+}
+
+func TestEncodeResponse(t *testing.T) {
+	body := strings.Repeat(`{"version":"1.0.0"}`, minCompressibleSize)
+	decoders := map[string]func(io.Reader) (io.Reader, error){
+		"gzip": func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		"br":   func(r io.Reader) (io.Reader, error) { return brotli.NewReader(r), nil },
+		"zstd": func(r io.Reader) (io.Reader, error) { return zstd.NewReader(r) },
+	}
+	for encoding, decoder := range decoders {
+		t.Run(encoding, func(t *testing.T) {
+			req := events.APIGatewayProxyRequest{Headers: map[string]string{"Accept-Encoding": encoding}}
+			shared := map[string]string{"Content-Type": "application/json", "Vary": "Accept"}
+			response := encodeResponse(req, events.APIGatewayProxyResponse{StatusCode: http.StatusOK, Headers: shared, Body: body})
+
+			if !response.IsBase64Encoded || response.Headers["Content-Encoding"] != encoding {
+				t.Fatalf("expected a %s encoded body, got headers %v", encoding, response.Headers)
+			}
+			if vary := response.Headers["Vary"]; vary != "Accept, Accept-Encoding" {
+				t.Errorf("Vary = %q, want %q", vary, "Accept, Accept-Encoding")
+			}
+			if _, ok := shared["Content-Encoding"]; ok || shared["Vary"] != "Accept" {
+				t.Errorf("expected the headers of the response not to be modified, got %v", shared)
+			}
+
+			compressed, err := base64.StdEncoding.DecodeString(response.Body)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			reader, err := decoder(bytes.NewReader(compressed))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			decoded, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(decoded) != body {
+				t.Errorf("expected the decoded body to round-trip, got %d bytes", len(decoded))
+			}
+		})
+	}
+
+	t.Run("small body", func(t *testing.T) {
+		req := events.APIGatewayProxyRequest{Headers: map[string]string{"Accept-Encoding": "gzip"}}
+		small := strings.Repeat("a", minCompressibleSize-1)
+		response := encodeResponse(req, events.APIGatewayProxyResponse{StatusCode: http.StatusOK, Body: small})
+		if response.IsBase64Encoded || response.Body != small || response.Headers["Vary"] != "" {
+			t.Errorf("expected a body below the threshold to be sent as is, got %+v", response)
+		}
+	})
+
+	t.Run("not accepted", func(t *testing.T) {
+		req := events.APIGatewayProxyRequest{Headers: map[string]string{"Accept-Encoding": "gzip;q=0"}}
+		response := encodeResponse(req, events.APIGatewayProxyResponse{StatusCode: http.StatusOK, Body: body})
+		if response.IsBase64Encoded || response.Body != body {
+			t.Errorf("expected the body to be sent as is, got headers %v", response.Headers)
+		}
+		if response.Headers["Vary"] != "Accept-Encoding" {
+			t.Errorf("Vary = %q, want %q", response.Headers["Vary"], "Accept-Encoding")
+		}
+	})
+
+	t.Run("already encoded", func(t *testing.T) {
+		req := events.APIGatewayProxyRequest{Headers: map[string]string{"Accept-Encoding": "gzip"}}
+		response := encodeResponse(req, events.APIGatewayProxyResponse{StatusCode: http.StatusOK, Headers: map[string]string{"Content-Encoding": "br"}, Body: body})
+		if response.IsBase64Encoded || response.Body != body {
+			t.Errorf("expected an encoded body not to be encoded again, got headers %v", response.Headers)
+		}
+	})
+}
@@ -0,0 +1,354 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/logging"
+	"github.com/opentofu/registry/internal/modules"
+	"github.com/opentofu/registry/internal/providers/types"
+	"github.com/opentofu/registry/internal/quarantine"
+)
+
+const (
+	// maxGraphQLDepth limits how deeply fields can be nested, provider { versions { platforms { os } } } is 4 deep.
+	maxGraphQLDepth = 5
+	// maxGraphQLComplexity limits the cost of a query, the sum of the costs of its fields.
+	maxGraphQLComplexity = 200
+	// graphQLLookupCost is the cost of the top-level fields, which each read the provider cache, possibly triggering
+	// a refresh, or call GitHub. The other fields cost 1.
+	graphQLLookupCost = 20
+)
+
+type GraphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// graphqlProvider is the source object for the Provider GraphQL type.
+type graphqlProvider struct {
+	Namespace   string            `json:"namespace"`
+	Type        string            `json:"type"`
+	LastUpdated string            `json:"lastUpdated"`
+	Warnings    []string          `json:"warnings"`
+	License     string            `json:"license"`
+	Versions    types.VersionList `json:"-"`
+}
+
+// graphqlModule is the source object for the Module GraphQL type.
+type graphqlModule struct {
+	Namespace string            `json:"namespace"`
+	Name      string            `json:"name"`
+	System    string            `json:"system"`
+	Versions  []modules.Version `json:"versions"`
+}
+
+func graphqlHandler(config config.Config) LambdaFunc {
+	return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		var body GraphQLRequest
+		switch req.HTTPMethod {
+		case http.MethodGet:
+			body.Query = req.QueryStringParameters["query"]
+			body.OperationName = req.QueryStringParameters["operationName"]
+			if variables := req.QueryStringParameters["variables"]; variables != "" {
+				if err := json.Unmarshal([]byte(variables), &body.Variables); err != nil {
+					return badRequestResponse(fmt.Sprintf("invalid variables: %s", err)), nil
+				}
+			}
+		case http.MethodPost:
+			if err := json.Unmarshal([]byte(req.Body), &body); err != nil {
+				return badRequestResponse(fmt.Sprintf("invalid request body: %s", err)), nil
+			}
+		default:
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusMethodNotAllowed}, nil
+		}
+
+		if body.Query == "" {
+			return badRequestResponse("query is required"), nil
+		}
+		if depth, complexity := graphQLQueryCost(body.Query); depth > maxGraphQLDepth {
+			return badRequestResponse(fmt.Sprintf("query is nested more than %d levels deep", maxGraphQLDepth)), nil
+		} else if complexity > maxGraphQLComplexity {
+			return badRequestResponse(fmt.Sprintf("query complexity exceeds the limit of %d", maxGraphQLComplexity)), nil
+		}
+
+		schema, err := newGraphQLSchema(config)
+		if err != nil {
+			logging.FromContext(ctx).Error("Error building GraphQL schema", "error", err)
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+
+		result := graphql.Do(graphql.Params{
+			Schema:         schema,
+			RequestString:  body.Query,
+			VariableValues: body.Variables,
+			OperationName:  body.OperationName,
+			Context:        ctx,
+		})
+		if result.HasErrors() {
+			logging.FromContext(ctx).Warn("GraphQL query returned errors", "errors", len(result.Errors))
+		}
+
+		resBody, err := json.Marshal(result)
+		if err != nil {
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+		return events.APIGatewayProxyResponse{
+			StatusCode: http.StatusOK,
+			Headers:    map[string]string{"Content-Type": "application/json"},
+			Body:       string(resBody),
+		}, nil
+	}
+}
+
+// newGraphQLSchema builds the read-only schema over the registry metadata. There is deliberately no mutation type.
+func newGraphQLSchema(config config.Config) (graphql.Schema, error) {
+	platformType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Platform",
+		Fields: graphql.Fields{
+			"os":                  &graphql.Field{Type: graphql.String, Resolve: downloadDetailsField(func(d types.CacheVersionDownloadDetails) string { return d.Platform.OS })},
+			"arch":                &graphql.Field{Type: graphql.String, Resolve: downloadDetailsField(func(d types.CacheVersionDownloadDetails) string { return d.Platform.Arch })},
+			"filename":            &graphql.Field{Type: graphql.String, Resolve: downloadDetailsField(func(d types.CacheVersionDownloadDetails) string { return d.Filename })},
+			"downloadUrl":         &graphql.Field{Type: graphql.String, Resolve: downloadDetailsField(func(d types.CacheVersionDownloadDetails) string { return d.DownloadURL })},
+			"shasum":              &graphql.Field{Type: graphql.String, Resolve: downloadDetailsField(func(d types.CacheVersionDownloadDetails) string { return d.SHASum })},
+			"shasumsUrl":          &graphql.Field{Type: graphql.String, Resolve: downloadDetailsField(func(d types.CacheVersionDownloadDetails) string { return d.SHASumsURL })},
+			"shasumsSignatureUrl": &graphql.Field{Type: graphql.String, Resolve: downloadDetailsField(func(d types.CacheVersionDownloadDetails) string { return d.SHASumsSignatureURL })},
+		},
+	})
+
+	providerVersionType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "ProviderVersion",
+		Fields: graphql.Fields{
+			"version":   &graphql.Field{Type: graphql.String, Resolve: cacheVersionField(func(v types.CacheVersion) interface{} { return v.Version })},
+			"protocols": &graphql.Field{Type: graphql.NewList(graphql.String), Resolve: cacheVersionField(func(v types.CacheVersion) interface{} { return v.Protocols })},
+			"platforms": &graphql.Field{Type: graphql.NewList(platformType), Resolve: cacheVersionField(func(v types.CacheVersion) interface{} { return v.DownloadDetails })},
+		},
+	})
+
+	providerType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Provider",
+		Fields: graphql.Fields{
+			"namespace":   &graphql.Field{Type: graphql.String},
+			"type":        &graphql.Field{Type: graphql.String},
+			"lastUpdated": &graphql.Field{Type: graphql.String},
+			"warnings":    &graphql.Field{Type: graphql.NewList(graphql.String)},
+			"license":     &graphql.Field{Type: graphql.String, Description: "SPDX identifier of the license of the repository, empty if unknown."},
+			"versions": &graphql.Field{
+				Type: graphql.NewList(providerVersionType),
+				Args: graphql.FieldConfigArgument{
+					"version": &graphql.ArgumentConfig{Type: graphql.String, Description: "Only return the given version."},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					provider, ok := p.Source.(*graphqlProvider)
+					if !ok {
+						return nil, fmt.Errorf("unexpected source type %T", p.Source)
+					}
+					version, ok := p.Args["version"].(string)
+					if !ok || version == "" {
+						return []types.CacheVersion(provider.Versions), nil
+					}
+					for _, v := range provider.Versions {
+						if v.Version == version {
+							return []types.CacheVersion{v}, nil
+						}
+					}
+					return []types.CacheVersion{}, nil
+				},
+			},
+		},
+	})
+
+	moduleVersionType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "ModuleVersion",
+		Fields: graphql.Fields{
+			"version": &graphql.Field{Type: graphql.String},
+		},
+	})
+
+	moduleType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Module",
+		Fields: graphql.Fields{
+			"namespace": &graphql.Field{Type: graphql.String},
+			"name":      &graphql.Field{Type: graphql.String},
+			"system":    &graphql.Field{Type: graphql.String},
+			"versions":  &graphql.Field{Type: graphql.NewList(moduleVersionType)},
+		},
+	})
+
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"provider": &graphql.Field{
+				Type: providerType,
+				Args: graphql.FieldConfigArgument{
+					"namespace": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"type":      &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return resolveGraphQLProvider(p.Context, config, stringArg(p, "namespace"), stringArg(p, "type"))
+				},
+			},
+			"module": &graphql.Field{
+				Type: moduleType,
+				Args: graphql.FieldConfigArgument{
+					"namespace": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"name":      &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"system":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return resolveGraphQLModule(p.Context, config, stringArg(p, "namespace"), stringArg(p, "name"), stringArg(p, "system"))
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: queryType})
+}
+
+// graphQLQueryCost returns the depth and the complexity of the operations of a query, with its fragments expanded.
+// The walk stops as soon as either limit is exceeded, so that nested fragments cannot make it expand exponentially.
+// Introspection fields are left out, they are answered from the schema. Queries which do not parse are left to
+// graphql.Do to report.
+func graphQLQueryCost(query string) (depth, complexity int) {
+	document, err := parser.Parse(parser.ParseParams{Source: query})
+	if err != nil {
+		return 0, 0
+	}
+	fragments := make(map[string]*ast.FragmentDefinition)
+	for _, definition := range document.Definitions {
+		if fragment, ok := definition.(*ast.FragmentDefinition); ok {
+			fragments[fragment.Name.Value] = fragment
+		}
+	}
+
+	// spread holds the fragments being expanded, so that cyclic ones, which graphql.Do rejects, are not walked forever
+	spread := make(map[string]bool)
+	var walk func(set *ast.SelectionSet, level int)
+	walk = func(set *ast.SelectionSet, level int) {
+		if set == nil {
+			return
+		}
+		for _, selection := range set.Selections {
+			if depth > maxGraphQLDepth || complexity > maxGraphQLComplexity {
+				return
+			}
+			switch selection := selection.(type) {
+			case *ast.Field:
+				if strings.HasPrefix(selection.Name.Value, "__") {
+					continue
+				}
+				if level > depth {
+					depth = level
+				}
+				if level == 1 {
+					complexity += graphQLLookupCost
+				} else {
+					complexity++
+				}
+				walk(selection.SelectionSet, level+1)
+			case *ast.InlineFragment:
+				walk(selection.SelectionSet, level)
+			case *ast.FragmentSpread:
+				fragment, ok := fragments[selection.Name.Value]
+				if !ok || spread[selection.Name.Value] {
+					continue
+				}
+				spread[selection.Name.Value] = true
+				walk(fragment.SelectionSet, level)
+				delete(spread, selection.Name.Value)
+			}
+		}
+	}
+	for _, definition := range document.Definitions {
+		if operation, ok := definition.(*ast.OperationDefinition); ok {
+			walk(operation.SelectionSet, 1)
+		}
+	}
+	return depth, complexity
+}
+
+func stringArg(p graphql.ResolveParams, name string) string {
+	value, _ := p.Args[name].(string)
+	return value
+}
+
+func cacheVersionField(get func(types.CacheVersion) interface{}) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		version, ok := p.Source.(types.CacheVersion)
+		if !ok {
+			return nil, fmt.Errorf("unexpected source type %T", p.Source)
+		}
+		return get(version), nil
+	}
+}
+
+func downloadDetailsField(get func(types.CacheVersionDownloadDetails) string) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		details, ok := p.Source.(types.CacheVersionDownloadDetails)
+		if !ok {
+			return nil, fmt.Errorf("unexpected source type %T", p.Source)
+		}
+		return get(details), nil
+	}
+}
+
+// resolveGraphQLProvider serves the provider from the cache only. If it is not cached yet, a refresh is triggered and
+// null is returned, so GraphQL queries never fan out into GitHub calls.
+func resolveGraphQLProvider(ctx context.Context, config config.Config, namespace, providerType string) (interface{}, error) {
+	if !config.CanReadProvider(namespace, providerType) {
+		return nil, nil //nolint:nilnil // private providers the request cannot read are null, as if they did not exist
+	}
+
+	effectiveNamespace := config.EffectiveProviderNamespace(namespace)
+
+	document, err := config.ProviderVersionCache.GetItem(ctx, fmt.Sprintf("%s/%s", effectiveNamespace, providerType))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read provider from cache: %w", err)
+	}
+	if document == nil || document.IsStale(config.CacheTTL) {
+		if triggerErr := triggerPopulateProviderVersions(ctx, config, effectiveNamespace, providerType); triggerErr != nil {
+			logging.FromContext(ctx).Error("Error triggering lambda", "error", triggerErr)
+		}
+	}
+	if document == nil || document.Metadata.NotFound {
+		return nil, nil //nolint:nilnil // null is the GraphQL response for a provider that is not cached
+	}
+
+	return &graphqlProvider{
+		Namespace:   namespace,
+		Type:        providerType,
+		LastUpdated: document.LastUpdated.UTC().Format(time.RFC3339),
+		Warnings:    providerWarnings(ctx, config, namespace, providerType, types.VersionExtensions{}.ToVersions(document.Versions), document.Metadata),
+		License:     document.Metadata.License,
+		Versions:    quarantine.Exclude(document.Versions.WithoutYanked(), lookupQuarantines(ctx, config, effectiveNamespace, providerType)),
+	}, nil
+}
+
+// resolveGraphQLModule serves the module like the REST listing, from the module version cache when there is one, so
+// that it is refreshed and served in maintenance mode the same way.
+func resolveGraphQLModule(ctx context.Context, config config.Config, namespace, name, system string) (interface{}, error) {
+	repo, err := config.ModuleRepository(ctx, namespace, name, system)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up module repository: %w", err)
+	}
+
+	versions, exists, err := getModuleVersions(ctx, config, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get module versions: %w", err)
+	}
+	if !exists {
+		return nil, nil //nolint:nilnil // null is the GraphQL response for a module that does not exist
+	}
+
+	return &graphqlModule{Namespace: namespace, Name: name, System: system, Versions: versions}, nil
+}
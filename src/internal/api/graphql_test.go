@@ -0,0 +1,185 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/modules"
+	"github.com/opentofu/registry/internal/modules/modulecache"
+	"github.com/opentofu/registry/internal/providers/types"
+	"github.com/opentofu/registry/internal/warnings"
+)
+
+func TestGraphQLQueryCost(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		wantDepth      int
+		wantComplexity int
+	}{
+		{
+			name:           "provider platforms",
+			query:          `{ provider(namespace: "example", type: "test") { namespace versions { version platforms { os arch } } } }`,
+			wantDepth:      4,
+			wantComplexity: graphQLLookupCost + 6,
+		},
+		{
+			name:           "aliased lookups",
+			query:          `{ a: provider(namespace: "example", type: "a") { type } b: provider(namespace: "example", type: "b") { type } }`,
+			wantDepth:      2,
+			wantComplexity: 2*graphQLLookupCost + 2,
+		},
+		{
+			name:           "fragments",
+			query:          `query { provider(namespace: "example", type: "test") { ...fields ... on Provider { license } } } fragment fields on Provider { versions { version } }`,
+			wantDepth:      3,
+			wantComplexity: graphQLLookupCost + 3,
+		},
+		{
+			name:           "introspection",
+			query:          `{ __schema { types { name fields { name type { name ofType { name ofType { name } } } } } } }`,
+			wantDepth:      0,
+			wantComplexity: 0,
+		},
+		{
+			name:           "cyclic fragments",
+			query:          `{ provider(namespace: "example", type: "test") { ...a } } fragment a on Provider { type ...b } fragment b on Provider { ...a }`,
+			wantDepth:      2,
+			wantComplexity: graphQLLookupCost + 1,
+		},
+		{
+			name:      "syntax error",
+			query:     `{ provider(`,
+			wantDepth: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			depth, complexity := graphQLQueryCost(tt.query)
+			if depth != tt.wantDepth || complexity != tt.wantComplexity {
+				t.Errorf("graphQLQueryCost() = %d, %d, want %d, %d", depth, complexity, tt.wantDepth, tt.wantComplexity)
+			}
+		})
+	}
+}
+
+func TestGraphQLHandlerLimits(t *testing.T) {
+	var refreshes int
+	cfg := config.Config{
+		ProviderVersionCache: itemsProviderCache{items: map[string]*types.CacheItem{
+			"example/test": {Provider: "example/test", Versions: types.VersionList{{Version: "1.0.0"}}, LastUpdated: time.Now()},
+		}},
+		PopulateProviderVersions: func(context.Context, string, string, string, bool) error {
+			refreshes++
+			return nil
+		},
+	}
+
+	var lookups []string
+	for i := 0; i < maxGraphQLComplexity/graphQLLookupCost+1; i++ {
+		lookups = append(lookups, fmt.Sprintf(`p%d: provider(namespace: "example", type: "missing%d") { type }`, i, i))
+	}
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+	}{
+		{"within limits", `{ provider(namespace: "example", type: "test") { versions { version } } }`, http.StatusOK},
+		{"too deep", `{ provider(namespace: "example", type: "test") { versions { platforms { os { a { b } } } } } }`, http.StatusBadRequest},
+		{"too complex", "{ " + strings.Join(lookups, " ") + " }", http.StatusBadRequest},
+		{"deep fragments", `{ provider(namespace: "example", type: "test") { ...v } } fragment v on Provider { versions { platforms { a: os { b: os { c: os } } } } }`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(GraphQLRequest{Query: tt.query})
+			req := events.APIGatewayProxyRequest{HTTPMethod: http.MethodPost, Body: string(body)}
+			response, err := graphqlHandler(cfg)(context.Background(), req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if response.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", response.StatusCode, tt.wantStatus, response.Body)
+			}
+		})
+	}
+	if refreshes != 0 {
+		t.Errorf("expected the rejected queries not to trigger refreshes, got %d", refreshes)
+	}
+}
+
+func TestGraphQLProvider(t *testing.T) {
+	cfg := config.Config{
+		ProviderVersionCache: itemsProviderCache{items: map[string]*types.CacheItem{
+			"example/test": {
+				Provider: "example/test",
+				Versions: types.VersionList{
+					{Version: "1.1.0", Yanked: &types.Yank{Reason: "CVE-2023-1234"}},
+					{Version: "1.0.0"},
+					{Version: "0.9.0"},
+				},
+				LastUpdated: time.Now(),
+			},
+		}},
+		QuarantineStore: testQuarantineStore(t, "0.9.0"),
+	}
+	body, _ := json.Marshal(GraphQLRequest{Query: `{ provider(namespace: "example", type: "test") { warnings versions { version } } }`})
+	response, err := graphqlHandler(cfg)(context.Background(), events.APIGatewayProxyRequest{HTTPMethod: http.MethodPost, Body: string(body)})
+	if err != nil || response.StatusCode != http.StatusOK {
+		t.Fatalf("got %d, %v", response.StatusCode, err)
+	}
+	var result struct {
+		Data struct {
+			Provider struct {
+				Warnings []string
+				Versions []struct{ Version string }
+			}
+		}
+	}
+	if err := json.Unmarshal([]byte(response.Body), &result); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var versions []string
+	for _, v := range result.Data.Provider.Versions {
+		versions = append(versions, v.Version)
+	}
+	if !reflect.DeepEqual(versions, []string{"1.0.0"}) {
+		t.Errorf("versions = %v, want the yanked and quarantined versions left out", versions)
+	}
+	// the warnings are the ones of the REST listing
+	if want := []string{warnings.Quarantined("0.9.0", "checksum mismatch")}; !reflect.DeepEqual(result.Data.Provider.Warnings, want) {
+		t.Errorf("warnings = %v, want %v", result.Data.Provider.Warnings, want)
+	}
+}
+
+// itemsModuleCache is a module cache which can only get items.
+type itemsModuleCache struct {
+	modulecache.Cache
+	items map[string]*modulecache.CacheItem
+}
+
+func (c itemsModuleCache) GetItem(_ context.Context, key string) (*modulecache.CacheItem, error) {
+	return c.items[key], nil
+}
+
+func TestGraphQLModule(t *testing.T) {
+	// there is no GitHub client, the module can only be served from the cache
+	cfg := config.Config{ModuleVersionCache: itemsModuleCache{items: map[string]*modulecache.CacheItem{
+		"example/terraform-aws-vpc": {Repository: "example/terraform-aws-vpc", Versions: []modules.Version{{Version: "1.0.0"}}, LastUpdated: time.Now()},
+	}}}
+	body, _ := json.Marshal(GraphQLRequest{Query: `{ module(namespace: "example", name: "vpc", system: "aws") { name versions { version } } }`})
+	response, err := graphqlHandler(cfg)(context.Background(), events.APIGatewayProxyRequest{HTTPMethod: http.MethodPost, Body: string(body)})
+	if err != nil || response.StatusCode != http.StatusOK {
+		t.Fatalf("got %d, %v", response.StatusCode, err)
+	}
+	if want := `{"data":{"module":{"name":"vpc","versions":[{"version":"1.0.0"}]}}}`; response.Body != want {
+		t.Errorf("body = %s, want %s", response.Body, want)
+	}
+}
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/providers/types"
+)
+
+// getHeader returns the value of the given request header. API Gateway passes headers through with the casing the
+// client used, so the lookup has to be case-insensitive.
+func getHeader(req events.APIGatewayProxyRequest, name string) string {
+	if value, ok := req.Headers[name]; ok {
+		return value
+	}
+	for key, value := range req.Headers {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	return ""
+}
+
+// notModifiedSince returns true if the client sent an If-Modified-Since header that is not older than lastModified.
+// HTTP dates only have a resolution of one second, so lastModified is truncated before comparing.
+func notModifiedSince(req events.APIGatewayProxyRequest, lastModified time.Time) bool {
+	header := getHeader(req, "If-Modified-Since")
+	if header == "" || lastModified.IsZero() {
+		return false
+	}
+
+	since, err := http.ParseTime(header)
+	if err != nil {
+		return false
+	}
+
+	return !lastModified.Truncate(time.Second).After(since)
+}
+
+// notModified returns true if the client already has the representation with the ETag, or last modified at
+// lastModified. If-None-Match takes precedence over If-Modified-Since, as in RFC 9110.
+func notModified(req events.APIGatewayProxyRequest, etag string, lastModified time.Time) bool {
+	if getHeader(req, "If-None-Match") != "" {
+		return noneMatch(req, etag)
+	}
+	return notModifiedSince(req, lastModified)
+}
+
+// withLastModified sets the Last-Modified header on the response.
+func withLastModified(response events.APIGatewayProxyResponse, lastModified time.Time) events.APIGatewayProxyResponse {
+	if lastModified.IsZero() {
+		return response
+	}
+	if response.Headers == nil {
+		response.Headers = make(map[string]string)
+	}
+	response.Headers["Last-Modified"] = lastModified.UTC().Format(http.TimeFormat)
+	return response
+}
+
+// defaultResponseMaxAge is how long clients and CDNs may reuse the responses rendered from cached documents, unless
+// configured otherwise. New versions take up to that long to show up for the clients which fetched the listing.
+const defaultResponseMaxAge = 5 * time.Minute
+
+// responseMaxAge returns how long clients and CDNs may reuse the responses rendered from cached documents.
+func responseMaxAge(config config.Config) time.Duration {
+	if config.ResponseMaxAge > 0 {
+		return config.ResponseMaxAge
+	}
+	return defaultResponseMaxAge
+}
+
+// documentETag returns the weak ETag of the responses rendered from a cached document, which changes whenever the
+// document is updated. The variant tells apart the representations of a single URL rendered from the same document.
+// The inputs are what else the responses are rendered from, which changes without the document being updated: their
+// warnings, which carry the deprecations and quarantines, and the signing keys of the downloads.
+func documentETag(document *types.CacheItem, variant string, inputs ...string) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%d/%d/%s", document.LastUpdated.UnixNano(), len(document.Versions), variant)
+	for _, input := range inputs {
+		// the length keeps the inputs apart, so that moving text from one to the next changes the ETag
+		fmt.Fprintf(hash, "/%d:%s", len(input), input)
+	}
+	return fmt.Sprintf(`W/"%s"`, hex.EncodeToString(hash.Sum(nil)[:8]))
+}
+
+// keyIDs returns the IDs of the signing keys, the part of them the ETag of the downloads changes with.
+func keyIDs(keys []types.GPGPublicKey) []string {
+	ids := make([]string, len(keys))
+	for i, key := range keys {
+		ids[i] = key.KeyID
+	}
+	return ids
+}
+
+// noneMatch returns true if the client sent an If-None-Match header listing the ETag, or `*`. ETags are compared
+// weakly, as the registry only sends weak ones.
+func noneMatch(req events.APIGatewayProxyRequest, etag string) bool {
+	header := getHeader(req, "If-None-Match")
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// withCacheHeaders sets the ETag of the response and lets clients and CDNs reuse it for maxAge. Only successful
+// responses are cached, the others are returned as they are.
+func withCacheHeaders(response events.APIGatewayProxyResponse, etag string, maxAge time.Duration) events.APIGatewayProxyResponse {
+	if response.StatusCode != http.StatusOK && response.StatusCode != http.StatusNotModified {
+		return response
+	}
+
+	// the headers of the shared responses must not be modified, so set the headers on a copy
+	headers := make(map[string]string, len(response.Headers)+2) //nolint:gomnd // ETag and Cache-Control.
+	for name, value := range response.Headers {
+		headers[name] = value
+	}
+	headers["ETag"] = etag
+	headers["Cache-Control"] = fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds()))
+	response.Headers = headers
+	return response
+}
+
+// selfURL builds an absolute URL to the given path on this registry, preferring the hostname the request came in on.
+func selfURL(config config.Config, req events.APIGatewayProxyRequest, path string) string {
+	return config.PublicURL(getHeader(req, "Host"), path)
+}
+
+// effectiveAddressHeader names the provider a request was served for, when namespace redirects apply to it.
+const effectiveAddressHeader = "X-Registry-Effective-Address"
+
+// withEffectiveAddress sets the effective address header on the responses for providers whose namespace is
+// redirected, so that clients can tell where the versions they got come from.
+func withEffectiveAddress(config config.Config, params map[string]string, response events.APIGatewayProxyResponse) events.APIGatewayProxyResponse {
+	namespace, providerType := params["namespace"], params["type"]
+	if namespace == "" || providerType == "" {
+		return response
+	}
+	effective := config.EffectiveProviderNamespace(namespace)
+	if effective == namespace {
+		return response
+	}
+
+	// the headers of the shared responses must not be modified, so set the header on a copy
+	headers := make(map[string]string, len(response.Headers)+1)
+	for name, value := range response.Headers {
+		headers[name] = value
+	}
+	headers[effectiveAddressHeader] = effective + "/" + providerType
+	response.Headers = headers
+	return response
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/opentofu/registry/internal/awstest"
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/providers/types"
+	"github.com/opentofu/registry/internal/quarantine"
+)
+
+func TestNotModifiedSince(t *testing.T) {
+	lastModified := time.Date(2023, 9, 1, 12, 0, 0, 500000000, time.UTC)
+	tests := []struct {
+		name         string
+		header       string
+		lastModified time.Time
+		want         bool
+	}{
+		{name: "no header", lastModified: lastModified},
+		{name: "same second", header: "Fri, 01 Sep 2023 12:00:00 GMT", lastModified: lastModified, want: true},
+		{name: "later", header: "Fri, 01 Sep 2023 13:00:00 GMT", lastModified: lastModified, want: true},
+		{name: "earlier", header: "Fri, 01 Sep 2023 11:59:59 GMT", lastModified: lastModified},
+		{name: "invalid date", header: "yesterday", lastModified: lastModified},
+		{name: "unknown modification time", header: "Fri, 01 Sep 2023 12:00:00 GMT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := events.APIGatewayProxyRequest{Headers: map[string]string{"if-modified-since": tt.header}}
+			if got := notModifiedSince(req, tt.lastModified); got != tt.want {
+				t.Errorf("notModifiedSince() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithLastModified(t *testing.T) {
+	lastModified := time.Date(2023, 9, 1, 14, 0, 0, 0, time.FixedZone("CEST", 2*60*60))
+	response := withLastModified(events.APIGatewayProxyResponse{StatusCode: http.StatusOK}, lastModified)
+	if got := response.Headers["Last-Modified"]; got != "Fri, 01 Sep 2023 12:00:00 GMT" {
+		t.Errorf("Last-Modified = %q, want the time in GMT", got)
+	}
+
+	response = withLastModified(events.APIGatewayProxyResponse{StatusCode: http.StatusOK}, time.Time{})
+	if _, ok := response.Headers["Last-Modified"]; ok {
+		t.Errorf("expected no Last-Modified header without a modification time, got %v", response.Headers)
+	}
+}
+
+func TestProviderVersionsLastModified(t *testing.T) {
+	lastUpdated := time.Now().Add(-time.Minute).Truncate(time.Second)
+	cfg := config.Config{ProviderVersionCache: itemsProviderCache{items: map[string]*types.CacheItem{
+		"example/test": {Provider: "example/test", Versions: types.VersionList{{Version: "1.0.0"}}, LastUpdated: lastUpdated},
+	}}}
+	get := func(ifModifiedSince time.Time) events.APIGatewayProxyResponse {
+		req := events.APIGatewayProxyRequest{
+			HTTPMethod: http.MethodGet,
+			Path:       "/v1/providers/example/test/versions",
+			Headers:    map[string]string{},
+		}
+		if !ifModifiedSince.IsZero() {
+			req.Headers["If-Modified-Since"] = ifModifiedSince.UTC().Format(http.TimeFormat)
+		}
+		response, err := Router(cfg)(context.Background(), req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return response
+	}
+
+	response := get(time.Time{})
+	if response.StatusCode != http.StatusOK || response.Headers["Last-Modified"] != lastUpdated.UTC().Format(http.TimeFormat) {
+		t.Errorf("got %d with headers %v, want %d with the last update as Last-Modified", response.StatusCode, response.Headers, http.StatusOK)
+	}
+	if response := get(lastUpdated); response.StatusCode != http.StatusNotModified || response.Body != "" {
+		t.Errorf("status = %d with body %q, want %d without a body", response.StatusCode, response.Body, http.StatusNotModified)
+	}
+	if response := get(lastUpdated.Add(-time.Minute)); response.StatusCode != http.StatusOK {
+		t.Errorf("status = %d for a listing modified since, want %d", response.StatusCode, http.StatusOK)
+	}
+}
+
+func TestProviderVersionsETagQuarantine(t *testing.T) {
+	quarantined := false
+	quarantineClient := awstest.DynamoDBClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+		if !quarantined {
+			_, _ = w.Write([]byte(`{}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"Item":{"provider":{"S":"example/test"},"quarantines":{"L":[{"M":{"version":{"S":"1.0.0"},"reason":{"S":"checksum mismatch"}}}]}}}`))
+	}))
+	cfg := config.Config{
+		ProviderVersionCache: itemsProviderCache{items: map[string]*types.CacheItem{
+			"example/test": {Provider: "example/test", Versions: types.VersionList{{Version: "1.0.0"}}, LastUpdated: time.Now().Add(-time.Minute)},
+		}},
+		QuarantineStore: &quarantine.Handler{TableName: aws.String("quarantines"), Client: quarantineClient},
+	}
+	get := func(etag string) events.APIGatewayProxyResponse {
+		req := events.APIGatewayProxyRequest{
+			HTTPMethod: http.MethodGet,
+			Path:       "/v1/providers/example/test/versions",
+			Headers:    map[string]string{"If-None-Match": etag},
+		}
+		response, err := Router(cfg)(context.Background(), req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return response
+	}
+
+	etag := get("").Headers["ETag"]
+	if response := get(etag); response.StatusCode != http.StatusNotModified {
+		t.Fatalf("status = %d, want %d", response.StatusCode, http.StatusNotModified)
+	}
+	quarantined = true
+	response := get(etag)
+	if response.StatusCode != http.StatusOK || response.Headers["ETag"] == etag {
+		t.Errorf("got %d with ETag %s after a quarantine, want %d with a new ETag", response.StatusCode, response.Headers["ETag"], http.StatusOK)
+	}
+}
@@ -0,0 +1,302 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/opentofu/registry/internal/providers/types"
+	"golang.org/x/exp/slog"
+)
+
+// The v2 endpoints return plain JSON by default and the JSON:API format (https://jsonapi.org) when the client asks
+// for it in the Accept header. The resource types and ids follow the upstream registry's v2 API, so that client
+// libraries written against it can parse our responses too.
+
+const jsonAPIContentType = "application/vnd.api+json"
+
+type JSONAPIDocument struct {
+	Data     any               `json:"data"`
+	Included []JSONAPIResource `json:"included,omitempty"`
+	Links    map[string]string `json:"links,omitempty"`
+	Meta     map[string]any    `json:"meta,omitempty"`
+}
+
+type JSONAPIResource struct {
+	ID            string                         `json:"id"`
+	Type          string                         `json:"type"`
+	Attributes    any                            `json:"attributes"`
+	Relationships map[string]JSONAPIRelationship `json:"relationships,omitempty"`
+	Links         map[string]string              `json:"links,omitempty"`
+}
+
+type JSONAPIRelationship struct {
+	Data []JSONAPIIdentifier `json:"data"`
+}
+
+type JSONAPIIdentifier struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+}
+
+func (r JSONAPIResource) identifier() JSONAPIIdentifier {
+	return JSONAPIIdentifier{ID: r.ID, Type: r.Type}
+}
+
+type JSONAPIProviderVersionAttributes struct {
+	Version      string     `json:"version"`
+	Protocols    []string   `json:"protocols"`
+	PublishedAt  *time.Time `json:"published-at,omitempty"`
+	ChangelogURL string     `json:"changelog-url,omitempty"`
+}
+
+type JSONAPIProviderPlatformAttributes struct {
+	OS   string `json:"os"`
+	Arch string `json:"arch"`
+}
+
+type JSONAPIProviderAttributes struct {
+	Namespace string   `json:"namespace"`
+	Name      string   `json:"name"`
+	Warnings  []string `json:"warnings,omitempty"`
+	License   string   `json:"license,omitempty"`
+	// Prereleases are listed apart from the provider-versions relationship, see ListProviderVersionsResponse.
+	Prereleases []types.Version `json:"prereleases,omitempty"`
+}
+
+type JSONAPIChecksumsAttributes struct {
+	Version             string                      `json:"version"`
+	SHASumsURL          string                      `json:"shasums-url"`
+	SHASumsSignatureURL string                      `json:"shasums-signature-url,omitempty"`
+	Signed              bool                        `json:"signed"`
+	Platforms           map[string]PlatformChecksum `json:"platforms"`
+}
+
+type JSONAPIAliasAttributes struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Reason string `json:"reason,omitempty"`
+	Since  string `json:"since"`
+}
+
+// acceptsJSONAPI returns true if the client listed the JSON:API media type in its Accept header.
+func acceptsJSONAPI(req events.APIGatewayProxyRequest) bool {
+	for _, accepted := range strings.Split(getHeader(req, "Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err == nil && mediaType == jsonAPIContentType {
+			return true
+		}
+	}
+	return false
+}
+
+// negotiatedResponse returns the JSON:API document if the client asked for it and the plain response otherwise. The
+// document is only built when it is needed.
+func negotiatedResponse(req events.APIGatewayProxyRequest, plain any, document func() JSONAPIDocument) (events.APIGatewayProxyResponse, error) {
+	var response events.APIGatewayProxyResponse
+	var err error
+	if acceptsJSONAPI(req) {
+		response, err = jsonAPIResponse(document())
+	} else {
+		response, err = jsonResponse(plain)
+	}
+	if err != nil {
+		return response, err
+	}
+
+	// the same URL serves two representations, caches have to keep them apart
+	response.Headers["Vary"] = "Accept"
+	return response, nil
+}
+
+func jsonResponse(body any) (events.APIGatewayProxyResponse, error) {
+	resBody, err := json.Marshal(body)
+	if err != nil {
+		slog.Error("Error marshalling response", "error", err)
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+	}
+	return events.APIGatewayProxyResponse{
+		StatusCode: http.StatusOK,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(resBody),
+	}, nil
+}
+
+func jsonAPIResponse(document JSONAPIDocument) (events.APIGatewayProxyResponse, error) {
+	resBody, err := json.Marshal(document)
+	if err != nil {
+		slog.Error("Error marshalling response", "error", err)
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+	}
+	return events.APIGatewayProxyResponse{
+		StatusCode: http.StatusOK,
+		Headers:    map[string]string{"Content-Type": jsonAPIContentType},
+		Body:       string(resBody),
+	}, nil
+}
+
+// providerVersionResources converts the versions of a provider into provider-versions resources and the
+// provider-platforms resources they relate to.
+func providerVersionResources(namespace, providerType string, versions []types.Version) ([]JSONAPIResource, []JSONAPIResource) {
+	resources := make([]JSONAPIResource, 0, len(versions))
+	var included []JSONAPIResource
+	for _, v := range versions {
+		id := fmt.Sprintf("%s/%s/%s", namespace, providerType, v.Version)
+
+		platforms := make([]JSONAPIIdentifier, 0, len(v.Platforms))
+		for _, p := range v.Platforms {
+			platformResource := JSONAPIResource{
+				ID:         fmt.Sprintf("%s/%s_%s", id, p.OS, p.Arch),
+				Type:       "provider-platforms",
+				Attributes: JSONAPIProviderPlatformAttributes{OS: p.OS, Arch: p.Arch},
+			}
+			platforms = append(platforms, platformResource.identifier())
+			included = append(included, platformResource)
+		}
+
+		resources = append(resources, JSONAPIResource{
+			ID:            id,
+			Type:          "provider-versions",
+			Attributes:    JSONAPIProviderVersionAttributes{Version: v.Version, Protocols: v.Protocols, PublishedAt: v.PublishedAt, ChangelogURL: v.ChangelogURL},
+			Relationships: map[string]JSONAPIRelationship{"platforms": {Data: platforms}},
+		})
+	}
+	return resources, included
+}
+
+func providerVersionsDocument(namespace, providerType string, response ListProviderVersionsResponse) JSONAPIDocument {
+	data, included := providerVersionResources(namespace, providerType, response.Versions)
+	document := JSONAPIDocument{Data: data, Included: included}
+	if response.Next != "" {
+		document.Links = map[string]string{"next": response.Next}
+	}
+	meta := make(map[string]any)
+	if len(response.Warnings) > 0 {
+		meta["warnings"] = response.Warnings
+	}
+	if response.License != "" {
+		meta["license"] = response.License
+	}
+	if len(response.Prereleases) > 0 {
+		meta["prereleases"] = response.Prereleases
+	}
+	if len(meta) > 0 {
+		document.Meta = meta
+	}
+	return document
+}
+
+func batchProviderVersionsDocument(response BatchProviderVersionsResponse, order []string) JSONAPIDocument {
+	data := make([]JSONAPIResource, 0, len(response.Providers))
+	var included []JSONAPIResource
+	for _, address := range order {
+		entry, ok := response.Providers[address]
+		if !ok {
+			continue
+		}
+		namespace, providerType, _ := strings.Cut(address, "/")
+
+		versions, versionsIncluded := providerVersionResources(namespace, providerType, entry.Versions)
+		identifiers := make([]JSONAPIIdentifier, len(versions))
+		for i, v := range versions {
+			identifiers[i] = v.identifier()
+		}
+		included = append(included, versions...)
+		included = append(included, versionsIncluded...)
+
+		data = append(data, JSONAPIResource{
+			ID:            address,
+			Type:          "providers",
+			Attributes:    JSONAPIProviderAttributes{Namespace: namespace, Name: providerType, Warnings: entry.Warnings, License: entry.License, Prereleases: entry.Prereleases},
+			Relationships: map[string]JSONAPIRelationship{"provider-versions": {Data: identifiers}},
+		})
+	}
+
+	document := JSONAPIDocument{Data: data, Included: included}
+	if len(response.Missing) > 0 {
+		document.Meta = map[string]any{"missing": response.Missing}
+	}
+	return document
+}
+
+func watchProviderVersionsDocument(namespace, providerType string, response WatchProviderVersionsResponse) JSONAPIDocument {
+	data, included := providerVersionResources(namespace, providerType, response.Versions)
+	meta := map[string]any{"cursor": response.Cursor, "changed": response.Changed}
+	if len(response.Prereleases) > 0 {
+		meta["prereleases"] = response.Prereleases
+	}
+	return JSONAPIDocument{
+		Data:     data,
+		Included: included,
+		Links:    map[string]string{"next": response.Next},
+		Meta:     meta,
+	}
+}
+
+func checksumsDocument(namespace, providerType string, response ProviderChecksumsResponse) JSONAPIDocument {
+	return JSONAPIDocument{Data: JSONAPIResource{
+		ID:   fmt.Sprintf("%s/%s/%s", namespace, providerType, response.Version),
+		Type: "provider-checksums",
+		Attributes: JSONAPIChecksumsAttributes{
+			Version:             response.Version,
+			SHASumsURL:          response.SHASumsURL,
+			SHASumsSignatureURL: response.SHASumsSignatureURL,
+			Signed:              response.Signed,
+			Platforms:           response.Platforms,
+		},
+	}}
+}
+
+func providerAliasesDocument(response ListProviderAliasesResponse) JSONAPIDocument {
+	data := make([]JSONAPIResource, 0, len(response.Aliases))
+	for _, a := range response.Aliases {
+		data = append(data, JSONAPIResource{
+			ID:   a.From,
+			Type: "provider-aliases",
+			Attributes: JSONAPIAliasAttributes{
+				From:   a.From,
+				To:     a.To,
+				Reason: a.Reason,
+				Since:  a.Since.UTC().Format("2006-01-02T15:04:05Z"),
+			},
+			Links: map[string]string{"versions": a.VersionsURL},
+		})
+	}
+	return JSONAPIDocument{Data: data}
+}
+
+func namespaceStatsDocument(response NamespaceStatsResponse) JSONAPIDocument {
+	return JSONAPIDocument{Data: JSONAPIResource{
+		ID:         response.Namespace,
+		Type:       "namespace-stats",
+		Attributes: response,
+	}}
+}
+
+func topProvidersDocument(response TopProvidersResponse) JSONAPIDocument {
+	data := make([]JSONAPIResource, 0, len(response.Providers))
+	for _, p := range response.Providers {
+		data = append(data, JSONAPIResource{
+			ID:         fmt.Sprintf("%s/%s", p.Namespace, p.Type),
+			Type:       "provider-downloads",
+			Attributes: p,
+		})
+	}
+	return JSONAPIDocument{Data: data, Meta: map[string]any{"period": response.Period}}
+}
+
+func topModulesDocument(response TopModulesResponse) JSONAPIDocument {
+	data := make([]JSONAPIResource, 0, len(response.Modules))
+	for _, m := range response.Modules {
+		data = append(data, JSONAPIResource{
+			ID:         fmt.Sprintf("%s/%s/%s", m.Namespace, m.Name, m.System),
+			Type:       "module-downloads",
+			Attributes: m,
+		})
+	}
+	return JSONAPIDocument{Data: data, Meta: map[string]any{"period": response.Period}}
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/platform"
+	"github.com/opentofu/registry/internal/providers/types"
+)
+
+func TestAcceptsJSONAPI(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   bool
+	}{
+		{accept: ""},
+		{accept: "application/json"},
+		{accept: "*/*"},
+		{accept: "application/vnd.api+json", want: true},
+		{accept: "application/json, application/vnd.api+json", want: true},
+		{accept: `application/vnd.api+json; ext="https://jsonapi.org/ext/atomic"`, want: true},
+		{accept: "application/vnd.api+json+other"},
+	}
+	for _, tt := range tests {
+		req := events.APIGatewayProxyRequest{Headers: map[string]string{"accept": tt.accept}}
+		if got := acceptsJSONAPI(req); got != tt.want {
+			t.Errorf("acceptsJSONAPI(%q) = %v, want %v", tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestNegotiatedResponse(t *testing.T) {
+	var built int
+	document := func() JSONAPIDocument {
+		built++
+		return JSONAPIDocument{Data: []JSONAPIResource{}}
+	}
+
+	response, err := negotiatedResponse(events.APIGatewayProxyRequest{}, map[string]string{"plain": "yes"}, document)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if response.Headers["Content-Type"] != "application/json" || response.Body != `{"plain":"yes"}` || built != 0 {
+		t.Errorf("expected the plain response without building the document, got %+v", response)
+	}
+	if response.Headers["Vary"] != "Accept" {
+		t.Errorf("Vary = %q, want Accept", response.Headers["Vary"])
+	}
+
+	req := events.APIGatewayProxyRequest{Headers: map[string]string{"Accept": jsonAPIContentType}}
+	response, err = negotiatedResponse(req, map[string]string{"plain": "yes"}, document)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if response.Headers["Content-Type"] != jsonAPIContentType || response.Body != `{"data":[]}` || built != 1 {
+		t.Errorf("expected the JSON:API document, got %+v", response)
+	}
+	if response.Headers["Vary"] != "Accept" {
+		t.Errorf("Vary = %q, want Accept", response.Headers["Vary"])
+	}
+}
+
+func TestProviderVersionsDocument(t *testing.T) {
+	publishedAt := time.Date(2023, 9, 1, 12, 0, 0, 0, time.UTC)
+	document := providerVersionsDocument("example", "test", ListProviderVersionsResponse{
+		Versions: []types.Version{
+			{Version: "1.0.0", Protocols: []string{"5.0"}, Platforms: []platform.Platform{{OS: "linux", Arch: "amd64"}}, PublishedAt: &publishedAt},
+		},
+		Warnings: []string{"deprecated"},
+		Next:     "https://registry.example.com/v2/providers/example/test/versions?cursor=abc",
+		License:  "MPL-2.0",
+	})
+
+	want := JSONAPIDocument{
+		Data: []JSONAPIResource{{
+			ID:         "example/test/1.0.0",
+			Type:       "provider-versions",
+			Attributes: JSONAPIProviderVersionAttributes{Version: "1.0.0", Protocols: []string{"5.0"}, PublishedAt: &publishedAt},
+			Relationships: map[string]JSONAPIRelationship{
+				"platforms": {Data: []JSONAPIIdentifier{{ID: "example/test/1.0.0/linux_amd64", Type: "provider-platforms"}}},
+			},
+		}},
+		Included: []JSONAPIResource{{
+			ID:         "example/test/1.0.0/linux_amd64",
+			Type:       "provider-platforms",
+			Attributes: JSONAPIProviderPlatformAttributes{OS: "linux", Arch: "amd64"},
+		}},
+		Links: map[string]string{"next": "https://registry.example.com/v2/providers/example/test/versions?cursor=abc"},
+		Meta:  map[string]any{"warnings": []string{"deprecated"}, "license": "MPL-2.0"},
+	}
+	if !reflect.DeepEqual(document, want) {
+		t.Errorf("providerVersionsDocument() = %+v, want %+v", document, want)
+	}
+
+	if document := providerVersionsDocument("example", "test", ListProviderVersionsResponse{}); document.Links != nil || document.Meta != nil {
+		t.Errorf("expected no links or meta for a complete listing without warnings, got %+v", document)
+	}
+}
+
+func TestProviderVersionsJSONAPI(t *testing.T) {
+	cfg := config.Config{ProviderVersionCache: itemsProviderCache{items: map[string]*types.CacheItem{
+		"example/test": {Provider: "example/test", Versions: types.VersionList{{Version: "1.0.0"}}, LastUpdated: time.Now()},
+	}}}
+	get := func(path, accept string) events.APIGatewayProxyResponse {
+		req := events.APIGatewayProxyRequest{HTTPMethod: http.MethodGet, Path: path, Headers: map[string]string{"Accept": accept}}
+		response, err := Router(cfg)(context.Background(), req)
+		if err != nil {
+			t.Fatalf("Router() error = %v", err)
+		}
+		return response
+	}
+
+	response := get("/v2/providers/example/test/versions", jsonAPIContentType)
+	var document struct {
+		Data []JSONAPIIdentifier `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(response.Body), &document); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if response.Headers["Content-Type"] != jsonAPIContentType || len(document.Data) != 1 || document.Data[0].ID != "example/test/1.0.0" {
+		t.Errorf("expected a JSON:API document, got %s with headers %v", response.Body, response.Headers)
+	}
+	if response.Headers["ETag"] == get("/v2/providers/example/test/versions", "").Headers["ETag"] {
+		t.Errorf("expected the representations to have different ETags, got %s for both", response.Headers["ETag"])
+	}
+
+	var listing ListProviderVersionsResponse
+	response = get("/v1/providers/example/test/versions", jsonAPIContentType)
+	if err := json.Unmarshal([]byte(response.Body), &listing); err != nil || len(listing.Versions) != 1 || response.Headers["Content-Type"] == jsonAPIContentType {
+		t.Errorf("expected the v1 listing to stay plain JSON, got %s with headers %v", response.Body, response.Headers)
+	}
+}
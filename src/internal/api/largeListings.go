@@ -0,0 +1,37 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/logging"
+	"github.com/opentofu/registry/internal/providers/types"
+)
+
+// largeListingResponse serves the v1 listing of a provider with many versions from the listings bucket, where the
+// populate lambda stored it, by redirecting the client to the CDN in front of the bucket or by returning its content.
+// Only the listing without query parameters is stored, so the other requests, and the listings with warnings, are
+// rendered as usual. It returns false if the listing has to be rendered.
+func largeListingResponse(ctx context.Context, config config.Config, listing versionsListing, document *types.CacheItem, warnings []string) (events.APIGatewayProxyResponse, bool) {
+	key := document.Metadata.Listing
+	if key == "" || config.LargeListings == nil || len(warnings) > 0 || !listing.plain() {
+		return events.APIGatewayProxyResponse{}, false
+	}
+
+	if config.LargeListingsURL != "" {
+		logging.FromContext(ctx).Info("Redirecting to large versions listing", "key", key)
+		return events.APIGatewayProxyResponse{
+			StatusCode: http.StatusFound,
+			Headers:    map[string]string{"Location": config.LargeListingsURL + "/" + key},
+		}, true
+	}
+
+	body, err := config.LargeListings.Get(ctx, key)
+	if err != nil || body == nil {
+		logging.FromContext(ctx).Error("Failed to read large versions listing, rendering it instead", "key", key, "error", err)
+		return events.APIGatewayProxyResponse{}, false
+	}
+	return events.APIGatewayProxyResponse{StatusCode: http.StatusOK, Body: string(body)}, true
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/listings"
+	"github.com/opentofu/registry/internal/providers/types"
+)
+
+func TestLargeListingResponse(t *testing.T) {
+	cfg := config.Config{
+		LargeListings:    listings.NewStore(aws.Config{Region: "eu-west-1"}, "registry-listings"),
+		LargeListingsURL: "https://listings.example.com",
+	}
+	document := &types.CacheItem{Metadata: types.ProviderMetadata{Listing: "listings/providers/hashicorp/aws/versions.json"}}
+
+	response, ok := largeListingResponse(context.Background(), cfg, versionsListing{}, document, nil)
+	if !ok || response.StatusCode != http.StatusFound || response.Headers["Location"] != "https://listings.example.com/listings/providers/hashicorp/aws/versions.json" {
+		t.Errorf("expected a redirect to the stored listing, got %v %+v", ok, response)
+	}
+
+	if _, ok := largeListingResponse(context.Background(), cfg, versionsListing{extensions: types.VersionExtensions{Timestamps: true}}, document, nil); ok {
+		t.Errorf("expected a listing with extensions to be rendered")
+	}
+	if _, ok := largeListingResponse(context.Background(), cfg, versionsListing{}, document, []string{"deprecated"}); ok {
+		t.Errorf("expected a listing with warnings to be rendered")
+	}
+	if _, ok := largeListingResponse(context.Background(), cfg, versionsListing{}, &types.CacheItem{}, nil); ok {
+		t.Errorf("expected a listing without a stored object to be rendered")
+	}
+}
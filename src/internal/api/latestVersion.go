@@ -0,0 +1,182 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/deprecations"
+	"github.com/opentofu/registry/internal/logging"
+	"github.com/opentofu/registry/internal/modules"
+	"github.com/opentofu/registry/internal/providers/types"
+	"github.com/opentofu/registry/internal/version"
+)
+
+// LatestProviderVersionResponse is the highest version of a provider, with its extended metadata.
+type LatestProviderVersionResponse struct {
+	ID        string `json:"id"` // The `<namespace>/<type>` address of the provider.
+	Namespace string `json:"namespace"`
+	Type      string `json:"type"`
+	types.Version
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// LatestModuleVersionResponse is the highest version of a module.
+type LatestModuleVersionResponse struct {
+	ID          string   `json:"id"` // The `<namespace>/<name>/<system>/<version>` address of the module version.
+	Namespace   string   `json:"namespace"`
+	Name        string   `json:"name"`
+	Provider    string   `json:"provider"`
+	Version     string   `json:"version"`
+	DownloadURL string   `json:"download_url"`
+	Warnings    []string `json:"warnings,omitempty"`
+}
+
+// highestVersion returns the index of the highest of the versions which are valid and not skipped, -1 if there is
+// none.
+func highestVersion(versions []string, skip func(string) bool) int {
+	highest := -1
+	for i, v := range versions {
+		if !version.Valid(v) || skip(v) {
+			continue
+		}
+		if highest == -1 || version.Compare(v, versions[highest]) > 0 {
+			highest = i
+		}
+	}
+	return highest
+}
+
+// latestProviderVersion returns the highest version of a provider which is not a prerelease, so that tools keeping
+// providers up to date do not have to fetch and sort the whole listing. Quarantined versions are skipped, as they
+// cannot be downloaded.
+func latestProviderVersion(config config.Config) LambdaFunc {
+	return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		params := getListProvidersPathParams(req)
+		ctx = params.AnnotateLogger(ctx)
+		effectiveNamespace := config.EffectiveProviderNamespace(params.Namespace)
+		extensions := types.VersionExtensions{Timestamps: true, Changelog: true}
+
+		// For now, we will ignore errors from the cache and just fetch from GH instead
+		document, _ := listVersionsFromCache(ctx, config, effectiveNamespace, params.Type)
+		if knownMissing(config, document) {
+			logging.FromContext(ctx).Info("Provider is known not to exist")
+			return NotFoundResponse, nil
+		}
+
+		var versionList []types.Version
+		metadata := types.ProviderMetadata{}
+		cached := document != nil && len(document.Versions) > 0
+		if cached {
+			versionList, metadata = extensions.ToVersions(document.Versions), document.Metadata
+		} else {
+			if config.MaintenanceMode {
+				return maintenanceResponse(), nil
+			}
+
+			cacheVersions, repoExists, err := listVersionsFromRepository(ctx, config, effectiveNamespace, params.Type)
+			if !repoExists {
+				if err != nil {
+					logging.FromContext(ctx).Error("Error checking if repo exists", "error", err)
+					return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+				}
+				logging.FromContext(ctx).Info("Repo does not exist")
+				rememberMissing(ctx, config, effectiveNamespace, params.Type)
+				return NotFoundResponse, nil
+			}
+			if err != nil {
+				logging.FromContext(ctx).Error("Error fetching versions from github", "error", err)
+				return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+			}
+			if err := triggerPopulateProviderVersions(ctx, config, effectiveNamespace, params.Type); err != nil {
+				logging.FromContext(ctx).Error("Error triggering lambda", "error", err)
+			}
+			versionList = extensions.ToVersions(cacheVersions)
+		}
+
+		quarantined := make(map[string]bool)
+		for _, q := range lookupQuarantines(ctx, config, effectiveNamespace, params.Type) {
+			quarantined[q.Version] = true
+		}
+		versionNumbers := make([]string, len(versionList))
+		for i, v := range versionList {
+			versionNumbers[i] = v.Version
+		}
+		highest := highestVersion(versionNumbers, func(v string) bool { return quarantined[v] })
+		if highest == -1 {
+			logging.FromContext(ctx).Info("Provider has no stable version")
+			return errorResponse(http.StatusNotFound, ErrCodeVersionNotFound, "the provider has no stable version"), nil
+		}
+
+		latest := versionList[highest]
+		warn := providerWarnings(ctx, config, params.Namespace, params.Type, []types.Version{latest}, metadata)
+		cacheable := func(response events.APIGatewayProxyResponse) events.APIGatewayProxyResponse { return response }
+		if cached {
+			// the quarantines decide which version is the latest, so it is part of the ETag along with its warnings
+			etag, lastUpdated := documentETag(document, "latest", append(warn, latest.Version)...), document.LastUpdated
+			cacheable = func(response events.APIGatewayProxyResponse) events.APIGatewayProxyResponse {
+				return withCacheHeaders(withLastModified(response, lastUpdated), etag, responseMaxAge(config))
+			}
+			if notModified(req, etag, lastUpdated) {
+				logging.FromContext(ctx).Info("Document not modified since the client fetched it, returning 304")
+				return cacheable(events.APIGatewayProxyResponse{StatusCode: http.StatusNotModified}), nil
+			}
+		}
+
+		response, err := jsonResponse(LatestProviderVersionResponse{
+			ID:        fmt.Sprintf("%s/%s", params.Namespace, params.Type),
+			Namespace: params.Namespace,
+			Type:      params.Type,
+			Version:   latest,
+			Warnings:  warn,
+		})
+		return cacheable(response), err
+	}
+}
+
+// latestModuleVersion returns the highest version of a module which is not a prerelease, like the module endpoint of
+// the public registry protocol.
+func latestModuleVersion(config config.Config) LambdaFunc {
+	return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		params := getListModuleVersionsPathParams(req)
+		ctx = params.AnnotateLogger(ctx)
+		repo, err := config.ModuleRepository(ctx, params.Namespace, params.Name, params.System)
+		if err != nil {
+			logging.FromContext(ctx).Error("Error looking up the repository of the module", "error", err)
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+
+		versions, exists, err := getModuleVersions(ctx, config, repo)
+		if err != nil {
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+		if !exists {
+			return NotFoundResponse, nil
+		}
+
+		versions = modules.WithoutPrereleases(versions)
+		versionNumbers := make([]string, len(versions))
+		for i, v := range versions {
+			versionNumbers[i] = v.Version
+		}
+		highest := highestVersion(versionNumbers, func(string) bool { return false })
+		if highest == -1 {
+			logging.FromContext(ctx).Info("Module has no stable version")
+			return errorResponse(http.StatusNotFound, ErrCodeVersionNotFound, "the module has no stable version"), nil
+		}
+
+		latest := versionNumbers[highest]
+		address := deprecations.ModuleAddress(params.Namespace, params.Name, params.System)
+		return jsonResponse(LatestModuleVersionResponse{
+			ID:          fmt.Sprintf("%s/%s/%s/%s", params.Namespace, params.Name, params.System, latest),
+			Namespace:   params.Namespace,
+			Name:        params.Name,
+			Provider:    params.System,
+			Version:     latest,
+			DownloadURL: selfURL(config, req, fmt.Sprintf("/v1/modules/%s/%s/%s/%s/download", params.Namespace, params.Name, params.System, latest)),
+			Warnings:    lookupDeprecationWarnings(ctx, config, address, []string{latest}),
+		})
+	}
+}
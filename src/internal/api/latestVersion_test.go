@@ -0,0 +1,17 @@
+package api
+
+import "testing"
+
+func TestHighestVersion(t *testing.T) {
+	versions := []string{"1.9.0", "1.10.0", "not-a-version", "2.0.0", "1.2.3"}
+
+	if got := highestVersion(versions, func(string) bool { return false }); got != 3 {
+		t.Errorf("highestVersion() = %d, want 3", got)
+	}
+	if got := highestVersion(versions, func(v string) bool { return v == "2.0.0" }); got != 1 {
+		t.Errorf("highestVersion() skipping 2.0.0 = %d, want 1", got)
+	}
+	if got := highestVersion([]string{"not-a-version"}, func(string) bool { return false }); got != -1 {
+		t.Errorf("highestVersion() without valid versions = %d, want -1", got)
+	}
+}
@@ -0,0 +1,29 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"golang.org/x/exp/slog"
+)
+
+// maintenanceRetryAfter is how long clients are asked to wait before retrying the requests refused in maintenance
+// mode.
+const maintenanceRetryAfter = 5 * time.Minute
+
+// maintenanceResponse refuses a request which cannot be served in maintenance mode, either because it writes or
+// because what it reads is not cached.
+func maintenanceResponse() events.APIGatewayProxyResponse {
+	slog.Info("Registry is in maintenance mode, returning 503")
+	response := errorResponse(http.StatusServiceUnavailable, ErrCodeMaintenance, "the registry is in maintenance, only cached providers are served")
+	response.Headers = map[string]string{"Retry-After": strconv.Itoa(int(maintenanceRetryAfter.Seconds()))}
+	return response
+}
+
+// isWriteRequest returns true if the request changes the state of the registry, which only the admin endpoints do.
+func isWriteRequest(req events.APIGatewayProxyRequest) bool {
+	return strings.HasPrefix(req.Path, "/admin/") && req.HTTPMethod != http.MethodGet && req.HTTPMethod != http.MethodHead
+}
@@ -0,0 +1,29 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/opentofu/registry/internal/logging"
+	"github.com/opentofu/registry/internal/metrics"
+)
+
+// metricsHandler exposes the metrics of this process in the Prometheus text format. Each Lambda instance only sees
+// its own requests, so this is only useful for the long-lived server, Lambda metrics go to CloudWatch instead.
+func metricsHandler() LambdaFunc {
+	return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		var body strings.Builder
+		if err := metrics.Default().WritePrometheus(&body); err != nil {
+			logging.FromContext(ctx).Error("Error writing metrics", "error", err)
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+
+		return events.APIGatewayProxyResponse{
+			StatusCode: http.StatusOK,
+			Headers:    map[string]string{"Content-Type": metrics.PrometheusContentType},
+			Body:       body.String(),
+		}, nil
+	}
+}
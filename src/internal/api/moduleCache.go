@@ -0,0 +1,92 @@
+package api
+
+import (
+	"context"
+
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/logging"
+	"github.com/opentofu/registry/internal/modules"
+	"github.com/opentofu/registry/internal/modules/modulecache"
+)
+
+// getModuleVersions returns the versions of the modules of a repository, and false if the repository does not exist.
+// Fresh cached versions are served without calling GitHub, and stale ones are only served if GitHub fails, so that
+// modules survive GitHub outages and rate limits. With config.ServeStale, stale ones are served straight away while a
+// refresh runs in the background instead.
+func getModuleVersions(ctx context.Context, config config.Config, repo modules.Repository) ([]modules.Version, bool, error) {
+	if config.ModuleVersionCache == nil {
+		return fetchModuleVersions(ctx, config, repo)
+	}
+
+	key := modulecache.Key(repo)
+	item, err := config.ModuleVersionCache.GetItem(ctx, key)
+	if err != nil {
+		// GitHub can still answer without the cache
+		logging.FromContext(ctx).Error("Error getting module versions from cache", "error", err)
+		item = nil
+	}
+	// in maintenance mode nothing is refreshed, the cached versions are served however old they are
+	if item != nil && (!item.IsStale(config.ModuleCacheTTL) || config.MaintenanceMode) {
+		return item.Versions, true, nil
+	}
+	if item != nil && config.ServeStale {
+		logging.FromContext(ctx).Info("Module versions are stale, returning cached versions and triggering refresh", "last_updated", item.LastUpdated)
+		if err := triggerModuleRefresh(ctx, config, repo); err != nil {
+			logging.FromContext(ctx).Error("Error triggering module refresh", "error", err)
+		}
+		return item.Versions, true, nil
+	}
+
+	versions, exists, err := fetchModuleVersions(ctx, config, repo)
+	if err != nil {
+		if item != nil {
+			logging.FromContext(ctx).Warn("Error fetching module versions, serving stale cached versions", "error", err)
+			return item.Versions, true, nil
+		}
+		return nil, false, err
+	}
+	if !exists || config.MaintenanceMode {
+		return versions, exists, nil
+	}
+
+	if item != nil {
+		versions = modules.KeepArchives(versions, item.Versions)
+	}
+	// a failure to cache the versions should not prevent them from being served
+	if err := config.ModuleVersionCache.Store(ctx, key, versions); err != nil {
+		logging.FromContext(ctx).Error("Error storing module versions in cache", "error", err)
+	}
+	return versions, true, nil
+}
+
+func fetchModuleVersions(ctx context.Context, config config.Config, repo modules.Repository) ([]modules.Version, bool, error) {
+	host := config.RepositoryHost(repo)
+	exists, err := host.RepositoryExists(ctx, repo.Owner, repo.Name)
+	if err != nil || !exists {
+		return nil, false, err
+	}
+
+	versions, err := modules.GetVersions(ctx, host, repo.Owner, repo.Name, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	return versions, true, nil
+}
+
+// cachedModuleVersion returns a cached module version, or nil if it is not cached. Tags do not change once released,
+// so the version is used however old the cached versions are.
+func cachedModuleVersion(ctx context.Context, config config.Config, repo modules.Repository, version string) *modules.Version {
+	if config.ModuleVersionCache == nil {
+		return nil
+	}
+
+	item, err := config.ModuleVersionCache.GetItem(ctx, modulecache.Key(repo))
+	if err != nil {
+		logging.FromContext(ctx).Error("Error getting module versions from cache", "error", err)
+		return nil
+	}
+	if item == nil {
+		return nil
+	}
+	return item.Version(version)
+}
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/logging"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/opentofu/registry/internal/modules"
+	"github.com/opentofu/registry/internal/vcs"
+)
+
+type DownloadModuleHandlerPathParams struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	System    string `json:"system"`
+	Version   string `json:"version"`
+}
+
+func (p DownloadModuleHandlerPathParams) AnnotateLogger(ctx context.Context) context.Context {
+	logger := logging.FromContext(ctx).
+		With("namespace", p.Namespace).
+		With("name", p.Name).
+		With("system", p.System).
+		With("version", p.Version)
+	return logging.WithLogger(ctx, logger)
+}
+
+func downloadModuleVersion(config config.Config) LambdaFunc {
+	return recordModuleDownloads(config, func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		params := getDownloadModuleHandlerPathParams(req)
+		ctx = params.AnnotateLogger(ctx)
+		repo, err := config.ModuleRepository(ctx, params.Namespace, params.Name, params.System)
+		if err != nil {
+			logging.FromContext(ctx).Error("Error looking up the repository of the module", "error", err)
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+
+		releaseTag, response, err := findModuleReleaseTag(ctx, config, repo, params.Version)
+		if releaseTag == "" {
+			return response, err
+		}
+
+		source := repo.Source(releaseTag)
+		if config.ModuleArchiveSources {
+			source = repo.Archive(releaseTag)
+		}
+		if config.ModuleStableArchives {
+			if stable := stableArchiveSource(ctx, config, repo, params.Version); stable != "" {
+				source = stable
+			}
+		}
+		if rewritten := modules.RewriteSource(config.ModuleSourceRewrites, source); rewritten != source {
+			logging.FromContext(ctx).Info("Rewrote module source", "source", source, "rewritten", rewritten)
+			source = rewritten
+		}
+
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusNoContent, Body: "", Headers: map[string]string{
+			"X-Terraform-Get": source,
+		}}, nil
+	})
+}
+
+func getDownloadModuleHandlerPathParams(req events.APIGatewayProxyRequest) DownloadModuleHandlerPathParams {
+	return DownloadModuleHandlerPathParams{
+		Namespace: req.PathParameters["namespace"],
+		Name:      req.PathParameters["name"],
+		System:    req.PathParameters["system"],
+		Version:   req.PathParameters["version"],
+	}
+}
+
+// stableArchiveSource returns the module source of a byte-stable archive of the cached module version, see
+// config.Config.ModuleStableArchives: the tarball attached to its release, or else the archive of the tree of its tag
+// built by the module refreshes. An empty source is returned if the version has neither yet, and a refresh is started
+// to build its archive.
+func stableArchiveSource(ctx context.Context, config config.Config, repo modules.Repository, version string) string {
+	v := cachedModuleVersion(ctx, config, repo, version)
+	if v == nil {
+		return ""
+	}
+
+	if v.TarballAsset != "" {
+		return withSubdirectory(v.TarballAsset, repo.Subdirectory)
+	}
+	if v.Archive != "" {
+		archiveURL, err := config.AssetMirror.PresignObject(ctx, v.Archive)
+		if err != nil {
+			logging.FromContext(ctx).Error("Error presigning the module archive", "archive", v.Archive, "error", err)
+			return ""
+		}
+		// the URL of the object has no extension, the `archive` parameter tells the clients it is a gzipped tarball,
+		// and is removed from the URL before it is downloaded, so that the signature still matches
+		source := withSubdirectory(archiveURL, repo.Subdirectory)
+		if strings.Contains(source, "?") {
+			return source + "&archive=tar.gz"
+		}
+		return source + "?archive=tar.gz"
+	}
+
+	logging.FromContext(ctx).Info("Module version has no stable archive yet, triggering refresh to build it", "tag", v.Tag)
+	if err := triggerModuleRefresh(ctx, config, repo); err != nil {
+		logging.FromContext(ctx).Error("Error triggering module refresh", "error", err)
+	}
+	return ""
+}
+
+// withSubdirectory returns the module source of the subdirectory of the archive at archiveURL, which goes before the
+// query string of the URL.
+func withSubdirectory(archiveURL, subdirectory string) string {
+	if subdirectory == "" {
+		return archiveURL
+	}
+	base, query, found := strings.Cut(archiveURL, "?")
+	source := base + "//" + subdirectory
+	if found {
+		source += "?" + query
+	}
+	return source
+}
+
+// findModuleReleaseTag returns the tag of the release of the module version, or an empty tag and the response to
+// return if the repository or the release does not exist.
+func findModuleReleaseTag(ctx context.Context, config config.Config, repo modules.Repository, version string) (string, events.APIGatewayProxyResponse, error) {
+	// the versions in the module cache do not need GitHub to be served
+	if v := cachedModuleVersion(ctx, config, repo, version); v != nil {
+		return v.Tag, events.APIGatewayProxyResponse{}, nil
+	}
+
+	// check if the repo exists
+	exists, err := config.RepositoryHost(repo).RepositoryExists(ctx, repo.Owner, repo.Name)
+	if err != nil {
+		return "", events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+	}
+	if !exists {
+		return "", NotFoundResponse, nil
+	}
+
+	releaseTag, err := getReleaseTag(ctx, config, repo, version)
+	if err != nil {
+		return "", events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+	}
+	if releaseTag == "" {
+		logging.FromContext(ctx).Info("Release not found in repo")
+		return "", errorResponse(http.StatusNotFound, ErrCodeReleaseNotFound, "no GitHub release found for the version"), nil
+	}
+	return releaseTag, events.APIGatewayProxyResponse{}, nil
+}
+
+// getReleaseTag returns the tag of the published release of the version, see version.Tags, or an empty string if the
+// repository has no such release.
+func getReleaseTag(ctx context.Context, config config.Config, repo modules.Repository, version string) (string, error) {
+	release, err := vcs.FindVersionRelease(ctx, config.RepositoryHost(repo), repo.Owner, repo.Name, version)
+	if err != nil || release == nil {
+		return "", err
+	}
+	return release.TagName, nil
+}
@@ -0,0 +1,38 @@
+package api
+
+import "testing"
+
+func TestWithSubdirectory(t *testing.T) {
+	tests := []struct {
+		name         string
+		archiveURL   string
+		subdirectory string
+		want         string
+	}{
+		{
+			name:       "no subdirectory",
+			archiveURL: "https://github.com/example/vpc/releases/download/v1.0.0/vpc.tar.gz",
+			want:       "https://github.com/example/vpc/releases/download/v1.0.0/vpc.tar.gz",
+		},
+		{
+			name:         "asset",
+			archiveURL:   "https://github.com/example/vpc/releases/download/v1.0.0/vpc.tar.gz",
+			subdirectory: "modules/vpc",
+			want:         "https://github.com/example/vpc/releases/download/v1.0.0/vpc.tar.gz//modules/vpc",
+		},
+		{
+			name:         "presigned",
+			archiveURL:   "https://mirror.s3.eu-west-1.amazonaws.com/objects/sha256/abc?X-Amz-Signature=def",
+			subdirectory: "modules/vpc",
+			want:         "https://mirror.s3.eu-west-1.amazonaws.com/objects/sha256/abc//modules/vpc?X-Amz-Signature=def",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := withSubdirectory(tt.archiveURL, tt.subdirectory); got != tt.want {
+				t.Errorf("withSubdirectory() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
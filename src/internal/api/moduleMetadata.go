@@ -0,0 +1,83 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/github"
+	"github.com/opentofu/registry/internal/logging"
+	"github.com/opentofu/registry/internal/modules"
+)
+
+// ModuleVersionMetadataResponse describes a version of a module, its inputs and outputs and those of its submodules
+// and examples, for the registry UI to document it.
+type ModuleVersionMetadataResponse struct {
+	ID        string `json:"id"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Provider  string `json:"provider"`
+	Version   string `json:"version"`
+	modules.Metadata
+}
+
+// getModuleVersionMetadata downloads the archive of the release of a module version and parses its configuration,
+// see modules.ParseArchive. Releases are not expected to change, so the ETag of the responses only depends on the
+// archive they are parsed from, and a client which has it does not get it downloaded again.
+func getModuleVersionMetadata(config config.Config) LambdaFunc {
+	return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		params := getDownloadModuleHandlerPathParams(req)
+		ctx = params.AnnotateLogger(ctx)
+		repo, err := config.ModuleRepository(ctx, params.Namespace, params.Name, params.System)
+		if err != nil {
+			logging.FromContext(ctx).Error("Error looking up the repository of the module", "error", err)
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+
+		releaseTag, response, err := findModuleReleaseTag(ctx, config, repo, params.Version)
+		if releaseTag == "" {
+			return response, err
+		}
+
+		archiveURL := repo.ArchiveURL(releaseTag)
+		hash := sha256.Sum256([]byte(archiveURL + "//" + repo.Subdirectory))
+		etag := fmt.Sprintf(`W/"%s"`, hex.EncodeToString(hash[:8]))
+		if noneMatch(req, etag) {
+			logging.FromContext(ctx).Info("Module version not modified since the client fetched it, returning 304")
+			return withCacheHeaders(events.APIGatewayProxyResponse{StatusCode: http.StatusNotModified}, etag, responseMaxAge(config)), nil
+		}
+
+		archive, err := github.DownloadAssetContents(ctx, archiveURL)
+		if err != nil {
+			logging.FromContext(ctx).Error("Error downloading the module archive", "url", archiveURL, "error", err)
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+		defer archive.Close()
+
+		metadata, err := modules.ParseArchive(archive, repo.Subdirectory)
+		if err != nil {
+			logging.FromContext(ctx).Error("Error parsing the module archive", "url", archiveURL, "error", err)
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+		metadata.Root.Name = params.Name
+
+		resBody, err := json.Marshal(ModuleVersionMetadataResponse{
+			ID:        fmt.Sprintf("%s/%s/%s/%s", params.Namespace, params.Name, params.System, params.Version),
+			Namespace: params.Namespace,
+			Name:      params.Name,
+			Provider:  params.System,
+			Version:   params.Version,
+			Metadata:  *metadata,
+		})
+		if err != nil {
+			logging.FromContext(ctx).Error("Error marshalling response", "error", err)
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+		return withCacheHeaders(events.APIGatewayProxyResponse{StatusCode: http.StatusOK, Body: string(resBody)}, etag, responseMaxAge(config)), nil
+	}
+}
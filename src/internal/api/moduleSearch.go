@@ -0,0 +1,103 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/logging"
+	"github.com/opentofu/registry/internal/modules"
+	"github.com/opentofu/registry/internal/modules/modulecache"
+)
+
+type SearchModulesResponse struct {
+	Meta    SearchMeta             `json:"meta"`
+	Modules []SearchModuleResponse `json:"modules"`
+}
+
+type SearchModuleResponse struct {
+	ID          string `json:"id"` // The `<namespace>/<name>/<system>` address of the module.
+	Namespace   string `json:"namespace"`
+	Name        string `json:"name"`
+	Provider    string `json:"provider"`
+	VersionsURL string `json:"versions_url"`
+}
+
+// searchModules searches the namespaces, names and systems of the known modules: the modules whose versions are
+// cached, the ones configured with MODULE_REPOSITORIES, and the ones listed in known_modules.txt. The `namespace` and
+// `provider` query parameters narrow the search down to the modules of a namespace or for a system.
+func searchModules(config config.Config) LambdaFunc {
+	return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		search, err := parseSearchQuery(req)
+		if err != nil {
+			return badRequestResponse(err.Error()), nil
+		}
+		namespace := strings.ToLower(req.QueryStringParameters["namespace"])
+		system := strings.ToLower(req.QueryStringParameters["provider"])
+		ctx = logging.With(ctx, "query", search.query, "namespace", namespace, "provider", system)
+
+		addresses, err := knownModuleAddresses(ctx, config)
+		if err != nil {
+			logging.FromContext(ctx).Error("Error listing the known modules", "error", err)
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+
+		matching := addresses[:0]
+		for _, address := range addresses {
+			parts := strings.Split(address, "/")
+			if !strings.Contains(address, search.query) || (namespace != "" && parts[0] != namespace) || (system != "" && parts[2] != system) {
+				continue
+			}
+			matching = append(matching, address)
+		}
+
+		meta, page := searchWindow(matching, search)
+		response := SearchModulesResponse{Meta: meta, Modules: []SearchModuleResponse{}}
+		for _, address := range page {
+			parts := strings.Split(address, "/")
+			response.Modules = append(response.Modules, SearchModuleResponse{
+				ID:          address,
+				Namespace:   parts[0],
+				Name:        parts[1],
+				Provider:    parts[2],
+				VersionsURL: selfURL(config, req, fmt.Sprintf("/v1/modules/%s/versions", address)),
+			})
+		}
+		return jsonResponse(response)
+	}
+}
+
+// knownModuleAddresses returns the sorted, lower case addresses of the known modules, see searchModules. The cached
+// repositories which do not follow the naming convention cannot be told the address of, they are only found when
+// configured.
+func knownModuleAddresses(ctx context.Context, config config.Config) ([]string, error) {
+	known := make(map[string]bool)
+	for _, address := range modules.KnownModules() {
+		known[address] = true
+	}
+	for address := range config.ModuleRepositories {
+		known[address] = true
+	}
+	if config.ModuleVersionCache != nil {
+		keys, err := config.ModuleVersionCache.Keys(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range keys {
+			if address, ok := modulecache.ModuleAddress(key); ok {
+				known[address] = true
+			}
+		}
+	}
+
+	addresses := make([]string, 0, len(known))
+	for address := range known {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+	return addresses, nil
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/modules"
+	"github.com/opentofu/registry/internal/modules/modulecache"
+)
+
+// keysModuleCache is a module cache which only has keys.
+type keysModuleCache struct {
+	modulecache.Cache
+	keys []string
+}
+
+func (c keysModuleCache) Keys(context.Context) ([]string, error) {
+	return c.keys, nil
+}
+
+func TestSearchModules(t *testing.T) {
+	cfg := config.Config{
+		ModuleRepositories: map[string]modules.Repository{"example/network/aws": {Owner: "example", Name: "infrastructure"}},
+		ModuleVersionCache: keysModuleCache{keys: []string{
+			"example/infrastructure",
+			"example/terraform-aws-vpc",
+			"example/terraform-google-vpc",
+			"other/terraform-aws-vpc",
+		}},
+	}
+
+	tests := []struct {
+		params map[string]string
+		want   []string
+	}{
+		{params: map[string]string{"q": "vpc"}, want: []string{"example/vpc/aws", "example/vpc/google", "other/vpc/aws"}},
+		{params: map[string]string{"q": "aws", "namespace": "Example"}, want: []string{"example/network/aws", "example/vpc/aws"}},
+		{params: map[string]string{"q": "example", "provider": "google"}, want: []string{"example/vpc/google"}},
+	}
+	for _, tt := range tests {
+		response, err := searchModules(cfg)(context.Background(), events.APIGatewayProxyRequest{QueryStringParameters: tt.params})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var body SearchModulesResponse
+		if err := json.Unmarshal([]byte(response.Body), &body); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got := make([]string, len(body.Modules))
+		for i, m := range body.Modules {
+			got[i] = m.ID
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("searchModules(%v) = %v, want %v", tt.params, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("searchModules(%v) = %v, want %v", tt.params, got, tt.want)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/deprecations"
+	"github.com/opentofu/registry/internal/logging"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/opentofu/registry/internal/modules"
+)
+
+type ListModuleVersionsPathParams struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	System    string `json:"system"`
+}
+
+func (p ListModuleVersionsPathParams) AnnotateLogger(ctx context.Context) context.Context {
+	logger := logging.FromContext(ctx).
+		With("namespace", p.Namespace).
+		With("name", p.Name).
+		With("system", p.System)
+	return logging.WithLogger(ctx, logger)
+}
+
+func getListModuleVersionsPathParams(req events.APIGatewayProxyRequest) ListModuleVersionsPathParams {
+	return ListModuleVersionsPathParams{
+		Namespace: req.PathParameters["namespace"],
+		Name:      req.PathParameters["name"],
+		System:    req.PathParameters["system"],
+	}
+}
+
+type ListModuleVersionsResponse struct {
+	Modules  []ModulesResponse `json:"modules"`
+	Warnings []string          `json:"warnings,omitempty"`
+}
+
+type ModulesResponse struct {
+	Versions []modules.Version `json:"versions"`
+	// Prereleases are only listed with `include=prereleases`, apart from the versions so that clients of the protocol
+	// never pick one.
+	Prereleases []modules.Version `json:"prereleases,omitempty"`
+}
+
+func listModuleVersions(config config.Config) LambdaFunc {
+	return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		params := getListModuleVersionsPathParams(req)
+		ctx = params.AnnotateLogger(ctx)
+		repo, err := config.ModuleRepository(ctx, params.Namespace, params.Name, params.System)
+		if err != nil {
+			logging.FromContext(ctx).Error("Error looking up the repository of the module", "error", err)
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+
+		// only prereleases apply to modules, which have no other metadata to include
+		extensions, err := parseVersionExtensions(req)
+		if err != nil {
+			return badRequestResponse(err.Error()), nil
+		}
+
+		versions, exists, err := getModuleVersions(ctx, config, repo)
+		if err != nil {
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+		if !exists {
+			return NotFoundResponse, nil
+		}
+		var prereleases []modules.Version
+		if extensions.Prereleases {
+			prereleases = modules.Prereleases(versions)
+		}
+		versions = modules.WithoutPrereleases(versions)
+
+		versionNumbers := make([]string, 0, len(versions)+len(prereleases))
+		for _, v := range append(versions, prereleases...) {
+			versionNumbers = append(versionNumbers, v.Version)
+		}
+		address := deprecations.ModuleAddress(params.Namespace, params.Name, params.System)
+
+		response := ListModuleVersionsResponse{
+			Modules: []ModulesResponse{
+				{
+					Versions:    versions,
+					Prereleases: prereleases,
+				},
+			},
+			Warnings: lookupDeprecationWarnings(ctx, config, address, versionNumbers),
+		}
+
+		resBody, err := json.Marshal(response)
+		if err != nil {
+			logging.FromContext(ctx).Error("Error marshalling response", "error", err)
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusOK, Body: string(resBody)}, nil
+	}
+}
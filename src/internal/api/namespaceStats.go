@@ -0,0 +1,124 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/github"
+	"github.com/opentofu/registry/internal/logging"
+	"github.com/opentofu/registry/internal/modules"
+	"github.com/opentofu/registry/internal/providers/types"
+)
+
+type NamespaceStatsResponse struct {
+	Namespace string `json:"namespace"`
+	Providers int    `json:"providers"`
+	// Modules is absent when the repositories of the namespace could not be listed. ModulesComplete is false when
+	// the namespace has too many repositories to list them all, and Modules is a lower bound.
+	Modules         *int       `json:"modules,omitempty"`
+	ModulesComplete bool       `json:"modules_complete"`
+	Versions        int        `json:"versions"`
+	LastPublishedAt *time.Time `json:"last_published_at,omitempty"`
+	// Verified is true if the namespace has GPG keys registered, so that the signatures of its providers are checked.
+	Verified bool `json:"verified"`
+}
+
+// namespaceStats returns statistics about a namespace. The provider figures come from the provider cache, and only
+// cover the providers which have been requested at least once. Modules are not cached, they are counted from the
+// repositories of the namespace on GitHub.
+func namespaceStats(config config.Config) LambdaFunc {
+	return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		requested := req.PathParameters["namespace"]
+		ctx = logging.With(ctx, "namespace", requested)
+
+		namespace := config.EffectiveProviderNamespace(requested)
+		keys, err := config.ProviderVersionCache.ListNamespace(ctx, namespace)
+		if err != nil {
+			logging.FromContext(ctx).Error("Error listing namespace from cache", "error", err)
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+
+		// the private providers the request cannot read are not counted
+		readable := keys[:0]
+		for _, key := range keys {
+			if canReadAddress(config, key) {
+				readable = append(readable, key)
+			}
+		}
+		keys = readable
+
+		documents := make(map[string]*types.CacheItem)
+		if len(keys) > 0 {
+			documents, err = config.ProviderVersionCache.GetItems(ctx, keys)
+			if err != nil {
+				logging.FromContext(ctx).Error("Error getting documents from cache", "error", err)
+				return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+			}
+		}
+
+		response := providerStats(documents)
+		response.Namespace = requested
+
+		gpgKeys, err := config.KeysForNamespace(ctx, namespace)
+		if err != nil {
+			logging.FromContext(ctx).Error("Error getting keys for namespace", "error", err)
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+		response.Verified = len(gpgKeys) > 0
+
+		// a failure to count the modules should not prevent the provider figures from being served, and the modules
+		// are only counted for the namespaces on GitHub
+		if _, onGitLab := config.GitLabGroup(requested); !onGitLab {
+			countModules(ctx, config, requested, &response)
+		}
+
+		return negotiatedResponse(req, response, func() JSONAPIDocument {
+			return namespaceStatsDocument(response)
+		})
+	}
+}
+
+// countModules counts the module repositories of the namespace on GitHub. Failures are only logged, leaving the
+// count out of the response.
+func countModules(ctx context.Context, config config.Config, namespace string, response *NamespaceStatsResponse) {
+	names, complete, err := github.FetchRepositoryNames(ctx, config.Githubv4Client(namespace), namespace)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error listing repositories of namespace", "error", err)
+		return
+	}
+	count := 0
+	for _, name := range names {
+		if modules.IsModuleRepo(name) {
+			count++
+		}
+	}
+	response.Modules = &count
+	response.ModulesComplete = complete
+}
+
+// providerStats sums up the cached provider documents of a namespace.
+func providerStats(documents map[string]*types.CacheItem) NamespaceStatsResponse {
+	var response NamespaceStatsResponse
+	var lastPublishedAt time.Time
+	for _, document := range documents {
+		// the providers known not to exist are cached too
+		if document.Metadata.NotFound {
+			continue
+		}
+		response.Providers++
+		response.Versions += len(document.Versions)
+		for _, v := range document.Versions {
+			if v.PublishedAt.After(lastPublishedAt) {
+				lastPublishedAt = v.PublishedAt
+			}
+		}
+	}
+	// versions cached before their publication time was recorded have none
+	if !lastPublishedAt.IsZero() {
+		response.LastPublishedAt = &lastPublishedAt
+	}
+	return response
+}
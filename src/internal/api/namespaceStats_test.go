@@ -0,0 +1,31 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/opentofu/registry/internal/providers/types"
+)
+
+func TestProviderStats(t *testing.T) {
+	published := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	stats := providerStats(map[string]*types.CacheItem{
+		"example/foo": {Versions: types.VersionList{
+			{Version: "1.0.0", PublishedAt: published.Add(-time.Hour)},
+			{Version: "1.1.0", PublishedAt: published},
+		}},
+		// cached before the publication times were recorded
+		"example/bar": {Versions: types.VersionList{{Version: "0.1.0"}}},
+	})
+
+	if stats.Providers != 2 || stats.Versions != 3 {
+		t.Errorf("providerStats() = %+v", stats)
+	}
+	if stats.LastPublishedAt == nil || !stats.LastPublishedAt.Equal(published) {
+		t.Errorf("LastPublishedAt = %v, want %v", stats.LastPublishedAt, published)
+	}
+
+	if empty := providerStats(nil); empty.Providers != 0 || empty.LastPublishedAt != nil {
+		t.Errorf("providerStats(nil) = %+v", empty)
+	}
+}
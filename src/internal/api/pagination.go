@@ -0,0 +1,142 @@
+package api
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/providers/types"
+	"github.com/opentofu/registry/internal/version"
+)
+
+const (
+	// defaultVersionsPageSize keeps v2 responses far below the 10MB API Gateway payload limit, even for providers
+	// built for dozens of platforms.
+	defaultVersionsPageSize = 200
+	// maxVersionsPageSize is the largest page a client can ask for with the `limit` query parameter.
+	maxVersionsPageSize = 1000
+)
+
+// versionsListingOptions are the parts of the versions listing that differ between the API versions.
+type versionsListingOptions struct {
+	// filters enables the `protocol` and `platform` query parameters.
+	filters bool
+	// pageSize is the number of versions per page unless the client asks for another `limit`, 0 disables pagination.
+	pageSize int
+	// jsonAPI enables the negotiation of the JSON:API format through the Accept header.
+	jsonAPI bool
+}
+
+// versionsListing is what the client asked for, parsed from the query parameters.
+type versionsListing struct {
+	filter     types.VersionFilter
+	extensions types.VersionExtensions
+	limit      int
+	after      string
+}
+
+func parseVersionsListing(req events.APIGatewayProxyRequest, options versionsListingOptions) (versionsListing, error) {
+	var listing versionsListing
+
+	if options.filters {
+		filter, err := parseVersionFilter(req)
+		if err != nil {
+			return listing, err
+		}
+		listing.filter = filter
+	}
+
+	extensions, err := parseVersionExtensions(req)
+	if err != nil {
+		return listing, err
+	}
+	listing.extensions = extensions
+
+	if options.pageSize == 0 {
+		return listing, nil
+	}
+
+	listing.limit = options.pageSize
+	if value := req.QueryStringParameters["limit"]; value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxVersionsPageSize {
+			return listing, fmt.Errorf("invalid limit, expected a number between 1 and %d", maxVersionsPageSize)
+		}
+		listing.limit = limit
+	}
+
+	if cursor := req.QueryStringParameters["cursor"]; cursor != "" {
+		after, err := base64.RawURLEncoding.DecodeString(cursor)
+		if err != nil || !version.Valid(string(after)) {
+			return listing, fmt.Errorf("invalid cursor")
+		}
+		listing.after = string(after)
+	}
+
+	return listing, nil
+}
+
+// plain returns true if the client asked for the listing without any query parameter changing it.
+func (l versionsListing) plain() bool {
+	return l.filter.IsEmpty() && l.extensions == (types.VersionExtensions{}) && l.limit == 0 && l.after == ""
+}
+
+// apply filters the versions and cuts out the requested page. Pages are ordered from the newest version to the oldest,
+// the cursor is the last version of the previous page. If there are more versions, the URL of the next page is
+// returned as well.
+func (l versionsListing) apply(config config.Config, req events.APIGatewayProxyRequest, versions []types.Version) ([]types.Version, string) {
+	versions = l.filter.Apply(versions)
+	if l.limit == 0 {
+		return versions, ""
+	}
+
+	sorted := make([]types.Version, len(versions))
+	copy(sorted, versions)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return version.Compare(sorted[i].Version, sorted[j].Version) > 0
+	})
+
+	start := 0
+	if l.after != "" {
+		start = sort.Search(len(sorted), func(i int) bool {
+			return version.Compare(sorted[i].Version, l.after) < 0
+		})
+	}
+
+	end := start + l.limit
+	if end >= len(sorted) {
+		return sorted[start:], ""
+	}
+
+	page := sorted[start:end]
+	return page, nextPageURL(config, req, page[len(page)-1].Version)
+}
+
+// prereleases filters the prereleases like the versions. They are all listed on the first page, as there are few of
+// them.
+func (l versionsListing) prereleases(prereleases []types.Version) []types.Version {
+	if l.after != "" {
+		return nil
+	}
+	return l.filter.Apply(prereleases)
+}
+
+// nextPageURL builds the URL of the page after the given version, keeping the other query parameters.
+func nextPageURL(config config.Config, req events.APIGatewayProxyRequest, after string) string {
+	query := url.Values{}
+	for name, values := range req.MultiValueQueryStringParameters {
+		query[name] = values
+	}
+	for name, value := range req.QueryStringParameters {
+		if _, ok := query[name]; !ok {
+			query.Set(name, value)
+		}
+	}
+	query.Set("cursor", base64.RawURLEncoding.EncodeToString([]byte(after)))
+
+	return fmt.Sprintf("%s?%s", selfURL(config, req, req.Path), query.Encode())
+}
@@ -0,0 +1,154 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/providers/types"
+)
+
+func TestParseVersionsListing(t *testing.T) {
+	paged := versionsListingOptions{pageSize: defaultVersionsPageSize}
+	tests := []struct {
+		name      string
+		query     map[string]string
+		options   versionsListingOptions
+		wantLimit int
+		wantAfter string
+		wantErr   bool
+	}{
+		{name: "pagination disabled", query: map[string]string{"limit": "5"}},
+		{name: "default page size", options: paged, wantLimit: defaultVersionsPageSize},
+		{name: "limit", query: map[string]string{"limit": "5"}, options: paged, wantLimit: 5},
+		{name: "largest limit", query: map[string]string{"limit": "1000"}, options: paged, wantLimit: maxVersionsPageSize},
+		{name: "limit too large", query: map[string]string{"limit": "1001"}, options: paged, wantErr: true},
+		{name: "zero limit", query: map[string]string{"limit": "0"}, options: paged, wantErr: true},
+		{name: "invalid limit", query: map[string]string{"limit": "all"}, options: paged, wantErr: true},
+		{
+			name:      "cursor",
+			query:     map[string]string{"cursor": base64.RawURLEncoding.EncodeToString([]byte("1.2.0"))},
+			options:   paged,
+			wantLimit: defaultVersionsPageSize,
+			wantAfter: "1.2.0",
+		},
+		{name: "cursor which is not a version", query: map[string]string{"cursor": base64.RawURLEncoding.EncodeToString([]byte("latest"))}, options: paged, wantErr: true},
+		{name: "cursor which is not base64", query: map[string]string{"cursor": "1.2.0!"}, options: paged, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listing, err := parseVersionsListing(events.APIGatewayProxyRequest{QueryStringParameters: tt.query}, tt.options)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseVersionsListing() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (listing.limit != tt.wantLimit || listing.after != tt.wantAfter) {
+				t.Errorf("limit, after = %d, %q, want %d, %q", listing.limit, listing.after, tt.wantLimit, tt.wantAfter)
+			}
+		})
+	}
+}
+
+func TestVersionsListingPages(t *testing.T) {
+	versions := []types.Version{{Version: "1.0.0"}, {Version: "1.10.0"}, {Version: "1.2.0"}, {Version: "2.0.0"}, {Version: "1.9.0"}}
+	req := events.APIGatewayProxyRequest{
+		Path:                  "/v2/providers/example/test/versions",
+		Headers:               map[string]string{"Host": "registry.example.com"},
+		QueryStringParameters: map[string]string{"limit": "2", "include": "timestamps"},
+	}
+
+	var pages [][]string
+	for i := 0; i < 5; i++ {
+		listing, err := parseVersionsListing(req, versionsListingOptions{pageSize: defaultVersionsPageSize})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		page, next := listing.apply(config.Config{}, req, versions)
+		var numbers []string
+		for _, v := range page {
+			numbers = append(numbers, v.Version)
+		}
+		pages = append(pages, numbers)
+		if next == "" {
+			break
+		}
+
+		u, err := url.Parse(next)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if u.Host != "registry.example.com" || u.Path != req.Path || u.Query().Get("include") != "timestamps" {
+			t.Errorf("expected the next page URL to keep the query parameters, got %s", next)
+		}
+		req.QueryStringParameters = map[string]string{}
+		for name := range u.Query() {
+			req.QueryStringParameters[name] = u.Query().Get(name)
+		}
+	}
+
+	want := [][]string{{"2.0.0", "1.10.0"}, {"1.9.0", "1.2.0"}, {"1.0.0"}}
+	if !reflect.DeepEqual(pages, want) {
+		t.Errorf("pages = %v, want %v", pages, want)
+	}
+	if versions[0].Version != "1.0.0" {
+		t.Errorf("expected the listing not to be sorted in place, got %v", versions)
+	}
+}
+
+func TestProviderVersionsPrereleases(t *testing.T) {
+	cfg := config.Config{ProviderVersionCache: itemsProviderCache{items: map[string]*types.CacheItem{
+		"example/test": {
+			Provider:    "example/test",
+			Versions:    types.VersionList{{Version: "2.0.0-rc1"}, {Version: "1.1.0", Prerelease: true}, {Version: "1.0.0"}, {Version: "0.9.0"}},
+			LastUpdated: time.Now().Add(-time.Minute),
+		},
+	}}}
+	list := func(path string, query map[string]string) ListProviderVersionsResponse {
+		t.Helper()
+		req := events.APIGatewayProxyRequest{HTTPMethod: http.MethodGet, Path: path, QueryStringParameters: query, Headers: map[string]string{}}
+		response, err := Router(cfg)(context.Background(), req)
+		if err != nil || response.StatusCode != http.StatusOK {
+			t.Fatalf("GET %s = %d, %v", path, response.StatusCode, err)
+		}
+		var listing ListProviderVersionsResponse
+		if err := json.Unmarshal([]byte(response.Body), &listing); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return listing
+	}
+	numbers := func(versions []types.Version) []string {
+		var numbers []string
+		for _, v := range versions {
+			numbers = append(numbers, v.Version)
+		}
+		return numbers
+	}
+
+	listing := list("/v1/providers/example/test/versions", nil)
+	if got := numbers(listing.Versions); !reflect.DeepEqual(got, []string{"1.0.0", "0.9.0"}) || listing.Prereleases != nil {
+		t.Errorf("versions, prereleases = %v, %v, want the prereleases left out", got, numbers(listing.Prereleases))
+	}
+
+	listing = list("/v1/providers/example/test/versions", map[string]string{"include": "prereleases"})
+	if got := numbers(listing.Versions); !reflect.DeepEqual(got, []string{"1.0.0", "0.9.0"}) {
+		t.Errorf("versions = %v, want the prereleases kept out of them", got)
+	}
+	if got := numbers(listing.Prereleases); !reflect.DeepEqual(got, []string{"2.0.0-rc1", "1.1.0"}) {
+		t.Errorf("prereleases = %v, want them listed apart", got)
+	}
+
+	first := list("/v2/providers/example/test/versions", map[string]string{"include": "prereleases", "limit": "1"})
+	if len(first.Versions) != 1 || len(first.Prereleases) != 2 || first.Next == "" {
+		t.Fatalf("first page = %+v, want a version and all prereleases", first)
+	}
+	second := list("/v2/providers/example/test/versions", map[string]string{"include": "prereleases", "limit": "1", "cursor": base64.RawURLEncoding.EncodeToString([]byte("1.0.0"))})
+	if got := numbers(second.Versions); !reflect.DeepEqual(got, []string{"0.9.0"}) || second.Prereleases != nil {
+		t.Errorf("second page = %v with prereleases %v, want the prereleases on the first page only", got, numbers(second.Prereleases))
+	}
+}
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/opentofu/registry/internal/config"
+)
+
+// clientGithubToken returns the token a request brings for the GitHub calls made on its behalf, empty if the registry
+// should use its own. Tokens are only taken in passthrough mode, and never from admin requests, whose bearer token is
+// the admin API token, nor from the requests bringing the token of a private provider.
+func clientGithubToken(config config.Config, req events.APIGatewayProxyRequest) string {
+	if !config.GithubTokenPassthrough || strings.HasPrefix(req.Path, "/admin/") {
+		return ""
+	}
+
+	token := bearerToken(req)
+	// the tokens of private providers are the registry's own, not GitHub tokens
+	if token == "" || config.PrivateProviders.Known(token) {
+		return ""
+	}
+	if config.AdminAPIToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(config.AdminAPIToken)) == 1 {
+		return ""
+	}
+	return token
+}
+
+// withPrivateCaching marks a response built with a client's token, or serving a private provider, as private to that
+// client, so that neither shared caches nor the client's store it.
+func withPrivateCaching(response events.APIGatewayProxyResponse) events.APIGatewayProxyResponse {
+	// the headers of the shared responses must not be modified, so set the header on a copy
+	headers := make(map[string]string, len(response.Headers)+1)
+	for name, value := range response.Headers {
+		headers[name] = value
+	}
+	headers["Cache-Control"] = "private, no-store"
+	response.Headers = headers
+	return response
+}
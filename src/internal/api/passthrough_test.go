@@ -0,0 +1,38 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/opentofu/registry/internal/access"
+	"github.com/opentofu/registry/internal/config"
+)
+
+func TestClientGithubToken(t *testing.T) {
+	enabled := config.Config{GithubTokenPassthrough: true, AdminAPIToken: "admin", PrivateProviders: access.ACLs{"example": {"private"}}}
+	tests := []struct {
+		name   string
+		config config.Config
+		path   string
+		header string
+		want   string
+	}{
+		{name: "client token", config: enabled, path: "/v1/modules/a/b/c/versions", header: "Bearer ghp_client", want: "ghp_client"},
+		{name: "disabled", config: config.Config{}, path: "/v1/modules/a/b/c/versions", header: "Bearer ghp_client"},
+		{name: "no token", config: enabled, path: "/v1/modules/a/b/c/versions"},
+		{name: "not a bearer token", config: enabled, path: "/v1/modules/a/b/c/versions", header: "Basic dXNlcg=="},
+		{name: "empty bearer token", config: enabled, path: "/v1/modules/a/b/c/versions", header: "Bearer  "},
+		{name: "admin token", config: enabled, path: "/v1/modules/a/b/c/versions", header: "Bearer admin"},
+		{name: "private provider token", config: enabled, path: "/v1/providers/example/aws/versions", header: "Bearer private"},
+		{name: "admin endpoint", config: enabled, path: "/admin/v1/provider-aliases/a/b", header: "Bearer ghp_client"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := events.APIGatewayProxyRequest{Path: tt.path, Headers: map[string]string{"authorization": tt.header}}
+			if got := clientGithubToken(tt.config, req); got != tt.want {
+				t.Errorf("clientGithubToken() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/opentofu/registry/internal/version"
+)
+
+//nolint:gochecknoglobals // This should be treated as a constant.
+var (
+	// namePattern matches namespaces, provider types, module names and systems, organizations and registries.
+	namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,63}$`)
+	// platformPattern matches operating systems and architectures.
+	platformPattern = regexp.MustCompile(`^[a-z0-9_]{1,32}$`)
+)
+
+// validatePathParams checks the path parameters taken from a request path before any handler sees them. They end up
+// in cache keys, GitHub API calls and download URLs, so anything which cannot be a valid name is rejected up front.
+func validatePathParams(params map[string]string) error {
+	for name, value := range params {
+		var valid bool
+		switch name {
+		case "version":
+			_, valid = version.FromTag(value)
+		case "os", "arch":
+			valid = platformPattern.MatchString(value)
+		default:
+			valid = namePattern.MatchString(value)
+		}
+		if !valid {
+			return fmt.Errorf("invalid %s", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/opentofu/registry/internal/config"
+)
+
+func TestValidatePathParams(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  map[string]string
+		wantErr string
+	}{
+		{name: "provider download", params: map[string]string{"namespace": "hashicorp", "type": "aws", "version": "5.0.0", "os": "linux", "arch": "amd64"}},
+		{name: "module", params: map[string]string{"namespace": "terraform-aws-modules", "name": "vpc", "system": "aws", "version": "v5.1.2"}},
+		{name: "traversal", params: map[string]string{"namespace": "..", "type": "aws"}, wantErr: "invalid namespace"},
+		{name: "version", params: map[string]string{"version": "latest"}, wantErr: "invalid version"},
+		{name: "os", params: map[string]string{"os": "Linux"}, wantErr: "invalid os"},
+		{name: "long name", params: map[string]string{"type": strings.Repeat("a", 65)}, wantErr: "invalid type"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validatePathParams(tt.params)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func FuzzMatchRoute(f *testing.F) {
+	for _, seed := range []string{
+		"/v1/providers/hashicorp/aws/5.0.0/download/linux/amd64",
+		"/v1/modules/a/b/c/versions",
+		"/v1/providers/%2e%2e/aws/versions",
+		"/admin/v1/quarantine/providers/a/b",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, path string) {
+		_, params := matchRoute(RouteHandlers(config.Config{}), path)
+		if validatePathParams(params) != nil {
+			return
+		}
+		for name, value := range params {
+			if strings.ContainsAny(value, "/\\%?#") || value == "." || value == ".." {
+				t.Errorf("accepted unsafe %s %q for path %q", name, value, path)
+			}
+		}
+	})
+}
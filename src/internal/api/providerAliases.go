@@ -0,0 +1,149 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/opentofu/registry/internal/aliases"
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/logging"
+)
+
+type ListProviderAliasesResponse struct {
+	Aliases []ProviderAliasResponse `json:"aliases"`
+}
+
+type ProviderAliasResponse struct {
+	aliases.Alias
+	VersionsURL string `json:"versions_url"` // Where to list the versions of the new address.
+}
+
+type ProviderAliasRequest struct {
+	To     string    `json:"to"`
+	Reason string    `json:"reason,omitempty"`
+	Since  time.Time `json:"since,omitempty"`
+}
+
+func listProviderAliases(config config.Config) LambdaFunc {
+	return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		found, err := config.ProviderAliases.List(ctx)
+		if err != nil {
+			logging.FromContext(ctx).Error("Error listing provider aliases", "error", err)
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+
+		// Allow filtering down to the aliases of a single old address, and leave out the ones involving private
+		// providers the request cannot read
+		from := req.QueryStringParameters["from"]
+		var filtered []aliases.Alias
+		for _, a := range found {
+			if (from == "" || a.From == from) && canReadAddress(config, a.From) && canReadAddress(config, a.To) {
+				filtered = append(filtered, a)
+			}
+		}
+		found = filtered
+
+		response := ListProviderAliasesResponse{Aliases: make([]ProviderAliasResponse, 0, len(found))}
+		for _, a := range found {
+			response.Aliases = append(response.Aliases, ProviderAliasResponse{
+				Alias:       a,
+				VersionsURL: selfURL(config, req, fmt.Sprintf("/v1/providers/%s/versions", a.To)),
+			})
+		}
+
+		return negotiatedResponse(req, response, func() JSONAPIDocument {
+			return providerAliasesDocument(response)
+		})
+	}
+}
+
+// canReadAddress returns true if the request can read the provider at the `<namespace>/<type>` address.
+func canReadAddress(config config.Config, address string) bool {
+	namespace, providerType, _ := strings.Cut(address, "/")
+	return config.CanReadProvider(namespace, providerType)
+}
+
+func adminProviderAlias(config config.Config) LambdaFunc {
+	return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		params := getListProvidersPathParams(req)
+		ctx = params.AnnotateLogger(ctx)
+
+		if !isAdminRequest(config, req) {
+			return UnauthorizedResponse, nil
+		}
+
+		from := aliases.Address(params.Namespace, params.Type)
+
+		switch req.HTTPMethod {
+		case http.MethodGet:
+			alias, err := config.ProviderAliasesStore.Get(ctx, from)
+			if err != nil {
+				logging.FromContext(ctx).Error("Error getting provider alias", "error", err)
+				return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+			}
+			if alias == nil {
+				return NotFoundResponse, nil
+			}
+			return aliasResponse(*alias)
+		case http.MethodPut:
+			var body ProviderAliasRequest
+			if err := json.Unmarshal([]byte(req.Body), &body); err != nil {
+				return badRequestResponse(fmt.Sprintf("invalid request body: %s", err)), nil
+			}
+
+			alias := aliases.Alias{From: from, To: body.To, Reason: body.Reason, Since: body.Since}
+			if alias.Since.IsZero() {
+				alias.Since = time.Now()
+			}
+			if err := alias.Validate(); err != nil {
+				return badRequestResponse(err.Error()), nil
+			}
+
+			if err := config.ProviderAliasesStore.Put(ctx, alias); err != nil {
+				logging.FromContext(ctx).Error("Error storing provider alias", "error", err)
+				return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+			}
+			config.ProviderAliases.Invalidate()
+			return aliasResponse(alias)
+		case http.MethodDelete:
+			if err := config.ProviderAliasesStore.Delete(ctx, from); err != nil {
+				logging.FromContext(ctx).Error("Error deleting provider alias", "error", err)
+				return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+			}
+			config.ProviderAliases.Invalidate()
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusNoContent}, nil
+		default:
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusMethodNotAllowed}, nil
+		}
+	}
+}
+
+func aliasResponse(alias aliases.Alias) (events.APIGatewayProxyResponse, error) {
+	resBody, err := json.Marshal(alias)
+	if err != nil {
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+	}
+	return events.APIGatewayProxyResponse{StatusCode: http.StatusOK, Body: string(resBody)}, nil
+}
+
+// lookupAliasWarnings returns a warning if the provider has moved to a new address.
+// Failures are logged and ignored, as aliases should never prevent serving the versions themselves.
+func lookupAliasWarnings(ctx context.Context, config config.Config, namespace, providerType string) []string {
+	if config.ProviderAliasesStore == nil {
+		return nil
+	}
+	alias, err := config.ProviderAliasesStore.Get(ctx, aliases.Address(namespace, providerType))
+	if err != nil {
+		logging.FromContext(ctx).Error("Error getting provider alias", "error", err)
+		return nil
+	}
+	if alias == nil {
+		return nil
+	}
+	return []string{alias.Warning()}
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/opentofu/registry/internal/access"
+	"github.com/opentofu/registry/internal/aliases"
+	"github.com/opentofu/registry/internal/awstest"
+	"github.com/opentofu/registry/internal/config"
+)
+
+func TestListProviderAliases(t *testing.T) {
+	scans := 0
+	client := awstest.DynamoDBClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+		if strings.HasSuffix(r.Header.Get("X-Amz-Target"), ".Scan") {
+			scans++
+			_, _ = w.Write([]byte(`{"Items":[
+				{"from":{"S":"old/aws"},"to":{"S":"new/aws"},"reason":{"S":"renamed"}},
+				{"from":{"S":"old/secret"},"to":{"S":"example/secret"}}
+			]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	store := &aliases.Handler{TableName: aws.String("aliases"), Client: client}
+	cfg := config.Config{
+		AdminAPIToken:        "secret",
+		ProviderAliasesStore: store,
+		ProviderAliases:      aliases.NewLoader(store, time.Hour),
+		PrivateProviders:     access.ACLs{"example/secret": {"read-token"}},
+	}
+
+	list := func(query map[string]string) ListProviderAliasesResponse {
+		t.Helper()
+		req := events.APIGatewayProxyRequest{Headers: map[string]string{"Host": "registry.example.com"}, QueryStringParameters: query}
+		response, err := listProviderAliases(cfg)(context.Background(), req)
+		if err != nil || response.StatusCode != http.StatusOK {
+			t.Fatalf("listProviderAliases() = %d, %v", response.StatusCode, err)
+		}
+		var listed ListProviderAliasesResponse
+		if err := json.Unmarshal([]byte(response.Body), &listed); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return listed
+	}
+
+	// the aliases to private providers the request cannot read are left out
+	listed := list(nil)
+	if len(listed.Aliases) != 1 || listed.Aliases[0].From != "old/aws" || listed.Aliases[0].VersionsURL != "https://registry.example.com/v1/providers/new/aws/versions" {
+		t.Errorf("unexpected aliases %+v", listed.Aliases)
+	}
+	if listed := list(map[string]string{"from": "old/unknown"}); len(listed.Aliases) != 0 {
+		t.Errorf("expected no aliases from old/unknown, got %+v", listed.Aliases)
+	}
+	if scans != 1 {
+		t.Errorf("table scanned %d times, want the aliases to be cached", scans)
+	}
+
+	// changes made through the admin API are listed right away
+	put := adminRequest(http.MethodPut, map[string]string{"namespace": "old", "type": "random"})
+	put.Body = `{"to":"new/random"}`
+	if response, err := adminProviderAlias(cfg)(context.Background(), put); err != nil || response.StatusCode != http.StatusOK {
+		t.Fatalf("PUT = %d, %v", response.StatusCode, err)
+	}
+	list(nil)
+	if scans != 2 {
+		t.Errorf("table scanned %d times, want the aliases to be loaded again after a change", scans)
+	}
+}
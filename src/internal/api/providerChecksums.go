@@ -0,0 +1,161 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/logging"
+	"github.com/opentofu/registry/internal/platform"
+	"github.com/opentofu/registry/internal/providers"
+	"github.com/opentofu/registry/internal/providers/types"
+	"github.com/opentofu/registry/internal/quarantine"
+)
+
+type ProviderChecksumsPathParams struct {
+	Namespace string `json:"namespace"`
+	Type      string `json:"type"`
+	Version   string `json:"version"`
+}
+
+func (p ProviderChecksumsPathParams) AnnotateLogger(ctx context.Context) context.Context {
+	logger := logging.FromContext(ctx).
+		With("namespace", p.Namespace).
+		With("type", p.Type).
+		With("version", p.Version)
+	return logging.WithLogger(ctx, logger)
+}
+
+func getProviderChecksumsPathParams(req events.APIGatewayProxyRequest) ProviderChecksumsPathParams {
+	return ProviderChecksumsPathParams{
+		Namespace: req.PathParameters["namespace"],
+		Type:      req.PathParameters["type"],
+		Version:   req.PathParameters["version"],
+	}
+}
+
+type ProviderChecksumsResponse struct {
+	Version             string                      `json:"version"`
+	SHASumsURL          string                      `json:"shasums_url"`
+	SHASumsSignatureURL string                      `json:"shasums_signature_url,omitempty"`
+	Signed              bool                        `json:"signed"`
+	Platforms           map[string]PlatformChecksum `json:"platforms"` // Keyed by "<os>_<arch>".
+}
+
+type PlatformChecksum struct {
+	Filename string `json:"filename"`
+	SHA256   string `json:"sha256"`
+	// Hash is the checksum in the "zh:" form used by dependency lock files.
+	Hash string `json:"hash"`
+}
+
+func providerChecksums(config config.Config) LambdaFunc {
+	return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		params := getProviderChecksumsPathParams(req)
+		ctx = params.AnnotateLogger(ctx)
+		effectiveNamespace := config.EffectiveProviderNamespace(params.Namespace)
+
+		if q := quarantine.Find(lookupQuarantines(ctx, config, effectiveNamespace, params.Type), params.Version); q != nil {
+			logging.FromContext(ctx).Warn("Version is quarantined, returning 404", "reason", q.Reason)
+			return versionQuarantinedResponse(q), nil
+		}
+
+		// For now, we will ignore errors from the cache and just fetch from GH instead
+		document, _ := config.ProviderVersionCache.GetItem(ctx, fmt.Sprintf("%s/%s", effectiveNamespace, params.Type))
+		if knownMissing(config, document) {
+			logging.FromContext(ctx).Info("Provider is known not to exist")
+			return NotFoundResponse, nil
+		}
+		if document != nil {
+			for _, v := range document.Versions {
+				if v.Version == params.Version {
+					if v.Yanked != nil {
+						logging.FromContext(ctx).Warn("Version is yanked, returning 404", "reason", v.Yanked.Reason)
+						return yankedResponse(&v), nil
+					}
+					logging.FromContext(ctx).Info("Found version in document")
+					return checksumsResponse(req, params, checksumsFromCacheVersion(v))
+				}
+			}
+		}
+
+		if config.MaintenanceMode {
+			return maintenanceResponse(), nil
+		}
+
+		source, err := config.ProviderSource(ctx, effectiveNamespace, params.Type)
+		if err != nil {
+			logging.FromContext(ctx).Error("Error looking up the repository of the provider", "error", err)
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+		checksums, err := providers.GetChecksums(ctx, source.Host, source.Owner, source.Name, params.Version, config.AssetPatterns(effectiveNamespace, params.Type))
+		if err != nil {
+			var fetchErr *providers.FetchError
+			if errors.As(err, &fetchErr) {
+				return handleFetchFromGithubErr(ctx, fetchErr)
+			}
+			logging.FromContext(ctx).Error("Error getting checksums", "error", err)
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+
+		return checksumsResponse(req, params, checksumsFromRelease(params.Version, checksums))
+	}
+}
+
+func checksumsFromCacheVersion(v types.CacheVersion) ProviderChecksumsResponse {
+	response := ProviderChecksumsResponse{
+		Version:   v.Version,
+		Platforms: make(map[string]PlatformChecksum, len(v.DownloadDetails)),
+	}
+	for _, d := range v.DownloadDetails {
+		response.SHASumsURL = d.SHASumsURL
+		response.SHASumsSignatureURL = d.SHASumsSignatureURL
+		response.Platforms[fmt.Sprintf("%s_%s", d.Platform.OS, d.Platform.Arch)] = newPlatformChecksum(d.Filename, d.SHASum)
+	}
+	response.Signed = response.SHASumsSignatureURL != ""
+	return response
+}
+
+func checksumsFromRelease(version string, checksums *providers.Checksums) ProviderChecksumsResponse {
+	response := ProviderChecksumsResponse{
+		Version:             version,
+		SHASumsURL:          checksums.SHASumsURL,
+		SHASumsSignatureURL: checksums.SHASumsSignatureURL,
+		Signed:              checksums.SHASumsSignatureURL != "",
+		Platforms:           make(map[string]PlatformChecksum, len(checksums.Files)),
+	}
+
+	// iterate in a stable order so that the result does not depend on map ordering
+	filenames := make([]string, 0, len(checksums.Files))
+	for filename := range checksums.Files {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+
+	for _, filename := range filenames {
+		p := platform.ExtractPlatformFromArtifact(filename)
+		if p == nil {
+			continue
+		}
+		response.Platforms[fmt.Sprintf("%s_%s", p.OS, p.Arch)] = newPlatformChecksum(filename, checksums.Files[filename])
+	}
+	return response
+}
+
+func newPlatformChecksum(filename, shaSum string) PlatformChecksum {
+	return PlatformChecksum{
+		Filename: filename,
+		SHA256:   shaSum,
+		Hash:     fmt.Sprintf("zh:%s", shaSum),
+	}
+}
+
+func checksumsResponse(req events.APIGatewayProxyRequest, params ProviderChecksumsPathParams, response ProviderChecksumsResponse) (events.APIGatewayProxyResponse, error) {
+	return negotiatedResponse(req, response, func() JSONAPIDocument {
+		return checksumsDocument(params.Namespace, params.Type, response)
+	})
+}
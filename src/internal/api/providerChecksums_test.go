@@ -0,0 +1,105 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/platform"
+	"github.com/opentofu/registry/internal/providers"
+	"github.com/opentofu/registry/internal/providers/types"
+)
+
+func TestChecksumsFromRelease(t *testing.T) {
+	response := checksumsFromRelease("1.0.0", &providers.Checksums{
+		SHASumsURL: "https://example.com/SHA256SUMS",
+		Files: map[string]string{
+			"terraform-provider-test_1.0.0_linux_amd64.zip":  "aaa",
+			"terraform-provider-test_1.0.0_darwin_arm64.zip": "bbb",
+			"terraform-provider-test_1.0.0_manifest.json":    "ccc",
+		},
+	})
+
+	want := ProviderChecksumsResponse{
+		Version:    "1.0.0",
+		SHASumsURL: "https://example.com/SHA256SUMS",
+		Platforms: map[string]PlatformChecksum{
+			"linux_amd64":  {Filename: "terraform-provider-test_1.0.0_linux_amd64.zip", SHA256: "aaa", Hash: "zh:aaa"},
+			"darwin_arm64": {Filename: "terraform-provider-test_1.0.0_darwin_arm64.zip", SHA256: "bbb", Hash: "zh:bbb"},
+		},
+	}
+	if !reflect.DeepEqual(response, want) {
+		t.Errorf("checksumsFromRelease() = %+v, want %+v", response, want)
+	}
+}
+
+func TestProviderChecksumsFromCache(t *testing.T) {
+	cfg := config.Config{QuarantineStore: testQuarantineStore(t, "0.9.0"), ProviderVersionCache: itemsProviderCache{items: map[string]*types.CacheItem{
+		"example/test": {
+			Provider: "example/test",
+			Versions: types.VersionList{{
+				Version: "1.0.0",
+				DownloadDetails: []types.CacheVersionDownloadDetails{{
+					Platform:            platform.Platform{OS: "linux", Arch: "amd64"},
+					Filename:            "terraform-provider-test_1.0.0_linux_amd64.zip",
+					SHASum:              "aaa",
+					SHASumsURL:          "https://example.com/SHA256SUMS",
+					SHASumsSignatureURL: "https://example.com/SHA256SUMS.sig",
+				}},
+			}},
+			LastUpdated: time.Now(),
+		},
+		"example/yanked": {
+			Provider:    "example/yanked",
+			Versions:    types.VersionList{{Version: "1.0.0", Yanked: &types.Yank{Reason: "CVE-2023-1234"}}},
+			LastUpdated: time.Now(),
+		},
+		"example/missing": {Provider: "example/missing", Metadata: types.ProviderMetadata{NotFound: true}, LastUpdated: time.Now()},
+	}}}
+	getVersion := func(provider, version string) events.APIGatewayProxyResponse {
+		req := events.APIGatewayProxyRequest{HTTPMethod: http.MethodGet, Path: "/v2/providers/" + provider + "/" + version + "/checksums"}
+		response, err := Router(cfg)(context.Background(), req)
+		if err != nil {
+			t.Fatalf("Router() error = %v", err)
+		}
+		return response
+	}
+	get := func(provider string) events.APIGatewayProxyResponse {
+		return getVersion(provider, "1.0.0")
+	}
+
+	response := get("example/test")
+	var checksums ProviderChecksumsResponse
+	if err := json.Unmarshal([]byte(response.Body), &checksums); err != nil {
+		t.Fatalf("unexpected error: %v in %d %s", err, response.StatusCode, response.Body)
+	}
+	if !checksums.Signed || checksums.SHASumsSignatureURL != "https://example.com/SHA256SUMS.sig" {
+		t.Errorf("expected the checksums to be signed, got %+v", checksums)
+	}
+	if got := checksums.Platforms["linux_amd64"]; got.Hash != "zh:aaa" || got.Filename != "terraform-provider-test_1.0.0_linux_amd64.zip" {
+		t.Errorf("unexpected platform checksum %+v", got)
+	}
+
+	if response := get("example/missing"); response.StatusCode != http.StatusNotFound {
+		t.Errorf("status = %d for a missing provider, want %d", response.StatusCode, http.StatusNotFound)
+	}
+
+	response = get("example/yanked")
+	var errDocument ErrorDocument
+	_ = json.Unmarshal([]byte(response.Body), &errDocument)
+	if response.StatusCode != http.StatusNotFound || len(errDocument.Errors) == 0 || errDocument.Errors[0].Code != ErrCodeVersionYanked {
+		t.Errorf("got %d %s for a yanked version, want %d with the %s code", response.StatusCode, response.Body, http.StatusNotFound, ErrCodeVersionYanked)
+	}
+
+	response = getVersion("example/test", "0.9.0")
+	errDocument = ErrorDocument{}
+	_ = json.Unmarshal([]byte(response.Body), &errDocument)
+	if response.StatusCode != http.StatusNotFound || len(errDocument.Errors) == 0 || errDocument.Errors[0].Code != ErrCodeVersionQuarantined {
+		t.Errorf("got %d %s for a quarantined version, want %d with the %s code", response.StatusCode, response.Body, http.StatusNotFound, ErrCodeVersionQuarantined)
+	}
+}
@@ -0,0 +1,399 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/logging"
+	"github.com/opentofu/registry/internal/providers/types"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/opentofu/registry/internal/mirror"
+	"github.com/opentofu/registry/internal/oci"
+	"github.com/opentofu/registry/internal/platform"
+	"github.com/opentofu/registry/internal/providers"
+	"github.com/opentofu/registry/internal/quarantine"
+)
+
+type DownloadHandlerPathParams struct {
+	Architecture string `json:"arch"`
+	OS           string `json:"os"`
+	Namespace    string `json:"namespace"`
+	Type         string `json:"type"`
+	Version      string `json:"version"`
+}
+
+func (p DownloadHandlerPathParams) AnnotateLogger(ctx context.Context) context.Context {
+	logger := logging.FromContext(ctx).
+		With("namespace", p.Namespace).
+		With("type", p.Type).
+		With("version", p.Version).
+		With("os", p.OS).
+		With("arch", p.Architecture)
+	return logging.WithLogger(ctx, logger)
+}
+
+// getDownloadPathParams reads the path parameters of a download, the platform normalized so that the aliases of a
+// platform, e.g. `macos/aarch64`, download its archive.
+func getDownloadPathParams(req events.APIGatewayProxyRequest) DownloadHandlerPathParams {
+	requested := platform.Normalize(platform.Platform{OS: req.PathParameters["os"], Arch: req.PathParameters["arch"]})
+	return DownloadHandlerPathParams{
+		Architecture: requested.Arch,
+		OS:           requested.OS,
+		Namespace:    req.PathParameters["namespace"],
+		Type:         req.PathParameters["type"],
+		Version:      req.PathParameters["version"],
+	}
+}
+
+func downloadProviderVersion(config config.Config) LambdaFunc {
+	return recordProviderDownloads(config, func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		params := getDownloadPathParams(req)
+		ctx = params.AnnotateLogger(ctx)
+		effectiveNamespace := config.EffectiveProviderNamespace(params.Namespace)
+
+		requested := platform.Platform{OS: params.OS, Arch: params.Architecture}
+		if !config.PlatformAllowlist(effectiveNamespace, params.Type).Allows(requested) {
+			logging.FromContext(ctx).Info("Platform is not served for this provider, returning 404")
+			// the platforms which are served are only known for cached versions
+			var available []platform.Platform
+			if document, _ := config.ProviderVersionCache.GetItem(ctx, fmt.Sprintf("%s/%s", effectiveNamespace, params.Type)); document != nil {
+				if v := findCacheVersion(document, params.Version); v != nil {
+					available = servedPlatforms(config, effectiveNamespace, params.Type, v.ToVersion().Platforms)
+				}
+			}
+			return platformUnavailableResponse(ErrCodePlatformNotAvailable, requested, types.PlatformExcluded, available), nil
+		}
+
+		if q := quarantine.Find(lookupQuarantines(ctx, config, effectiveNamespace, params.Type), params.Version); q != nil {
+			logging.FromContext(ctx).Warn("Version is quarantined, returning 404", "reason", q.Reason)
+			return quarantinedResponse(q, requested), nil
+		}
+
+		// For now, we will ignore errors from the cache and just fetch from GH instead
+		document, _ := config.ProviderVersionCache.GetItem(ctx, fmt.Sprintf("%s/%s", effectiveNamespace, params.Type))
+		if knownMissing(config, document) {
+			logging.FromContext(ctx).Info("Provider is known not to exist")
+			return NotFoundResponse, nil
+		}
+		if document != nil && !document.Metadata.NotFound {
+			return processDocumentForProviderDownload(ctx, config, req, document, effectiveNamespace, params)
+		}
+		if config.MaintenanceMode {
+			return maintenanceResponse(), nil
+		}
+
+		// providers published as OCI artifacts are not on GitHub
+		if ref, ok := config.OCIProvider(effectiveNamespace, params.Type); ok {
+			if triggerErr := triggerPopulateProviderVersions(ctx, config, effectiveNamespace, params.Type); triggerErr != nil {
+				logging.FromContext(ctx).Error("Error triggering lambda", "error", triggerErr)
+			}
+			return fetchVersionFromOCI(ctx, config, ref, effectiveNamespace, params)
+		}
+
+		// check the repo exists
+		source, err := config.ProviderSource(ctx, effectiveNamespace, params.Type)
+		if err != nil {
+			logging.FromContext(ctx).Error("Error looking up the repository of the provider", "error", err)
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+		exists, err := source.Host.RepositoryExists(ctx, source.Owner, source.Name)
+		if err != nil {
+			logging.FromContext(ctx).Error("Error checking if repo exists", "error", err)
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+		if !exists {
+			logging.FromContext(ctx).Info("Repo does not exist")
+			rememberMissing(ctx, config, effectiveNamespace, params.Type)
+			return NotFoundResponse, nil
+		}
+
+		// if the document didn't exist in the cache, trigger the lambda to populate it and return the current results from GH
+		if triggerErr := triggerPopulateProviderVersions(ctx, config, effectiveNamespace, params.Type); triggerErr != nil {
+			logging.FromContext(ctx).Error("Error triggering lambda", "error", triggerErr)
+		}
+
+		return fetchVersionFromGithub(ctx, config, effectiveNamespace, source, params)
+	})
+}
+
+func fetchVersionFromGithub(ctx context.Context, config config.Config, effectiveNamespace string, source config.ProviderSource, params DownloadHandlerPathParams) (events.APIGatewayProxyResponse, error) {
+	versionDownloadResponse, err := providers.GetVersion(ctx, source.Host, effectiveNamespace, source.Owner, source.Name, params.Version, params.OS, params.Architecture, config.AssetPatterns(effectiveNamespace, params.Type))
+	if err != nil {
+		var fetchErr *providers.FetchError
+		// if it's a providers.FetchError
+		if errors.As(err, &fetchErr) {
+			if response, ok := assetNotFoundResponse(ctx, config, effectiveNamespace, params, fetchErr); ok {
+				return response, nil
+			}
+			return handleFetchFromGithubErr(ctx, fetchErr)
+		}
+
+		logging.FromContext(ctx).Error("Error getting version", "error", err)
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+	}
+
+	// tenants have their own key store and keys can be registered through the admin API, replace the repository keys
+	// of the main registry
+	if config.TenantName != "" || config.KeyStore != nil {
+		publicKeys, keysErr := config.KeysForNamespace(ctx, effectiveNamespace)
+		if keysErr != nil {
+			logging.FromContext(ctx).Error("Could not get public keys", "error", keysErr)
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, keysErr
+		}
+		versionDownloadResponse.SigningKeys = types.SigningKeys{GPGPublicKeys: publicKeys}
+	}
+
+	if response, ok, err := verifyDownloadSignature(ctx, config, versionDownloadResponse); !ok {
+		return response, err
+	}
+
+	return downloadResponse(ctx, versionDownloadResponse, downloadWarnings(ctx, config, params, versionDownloadResponse, types.ProviderMetadata{}))
+}
+
+func fetchVersionFromOCI(ctx context.Context, config config.Config, ref oci.Reference, effectiveNamespace string, params DownloadHandlerPathParams) (events.APIGatewayProxyResponse, error) {
+	versionDetails, err := providers.GetVersionFromOCI(ctx, config.OCIClient, ref, params.Version, params.OS, params.Architecture)
+	if err != nil {
+		var fetchErr *providers.FetchError
+		if errors.As(err, &fetchErr) {
+			if response, ok := assetNotFoundResponse(ctx, config, effectiveNamespace, params, fetchErr); ok {
+				return response, nil
+			}
+			return handleFetchFromGithubErr(ctx, fetchErr)
+		}
+		if errors.Is(err, oci.ErrNotFound) {
+			logging.FromContext(ctx).Info("Repository not found in OCI registry", "repository", ref.String())
+			return NotFoundResponse, nil
+		}
+
+		logging.FromContext(ctx).Error("Error getting version from OCI registry", "error", err)
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+	}
+
+	publicKeys, err := config.KeysForNamespace(ctx, effectiveNamespace)
+	if err != nil {
+		logging.FromContext(ctx).Error("Could not get public keys", "error", err)
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+	}
+	versionDetails.SigningKeys = types.SigningKeys{GPGPublicKeys: publicKeys}
+
+	if response, ok, err := verifyDownloadSignature(ctx, config, versionDetails); !ok {
+		return response, err
+	}
+
+	return downloadResponse(ctx, versionDetails, downloadWarnings(ctx, config, params, versionDetails, types.ProviderMetadata{}))
+}
+
+// assetNotFoundResponse returns the error document of a version fetched from its release which has no archive for
+// the requested platform, listing the platforms it has one for, and false if the error is not about the platform.
+func assetNotFoundResponse(ctx context.Context, config config.Config, effectiveNamespace string, params DownloadHandlerPathParams, fetchErr *providers.FetchError) (events.APIGatewayProxyResponse, bool) {
+	if fetchErr.Code != providers.ErrCodeAssetNotFound || fetchErr.PlatformReason == "" {
+		return events.APIGatewayProxyResponse{}, false
+	}
+	logging.FromContext(ctx).Info("Asset for download not found in release", "reason", fetchErr.PlatformReason)
+	requested := platform.Platform{OS: params.OS, Arch: params.Architecture}
+	available := servedPlatforms(config, effectiveNamespace, params.Type, fetchErr.Available)
+	return platformUnavailableResponse(ErrCodeAssetNotFound, requested, fetchErr.PlatformReason, available), true
+}
+
+func handleFetchFromGithubErr(ctx context.Context, err *providers.FetchError) (events.APIGatewayProxyResponse, error) {
+	switch {
+	case err.Code == providers.ErrCodeReleaseNotFound:
+		logging.FromContext(ctx).Info("Release not found in repo")
+		return errorResponse(http.StatusNotFound, ErrCodeReleaseNotFound, "no GitHub release found for the version"), nil
+	case err.Code == providers.ErrCodeAssetNotFound:
+		logging.FromContext(ctx).Info("Asset for download not found in release")
+		return errorResponse(http.StatusNotFound, ErrCodeAssetNotFound, "the release has no archive for the platform"), nil
+	case err.Code == providers.ErrCodeSHASumsNotFound && err.Inner == nil:
+		// the release is incomplete, which only its author can fix, unlike a failure to download the file
+		logging.FromContext(ctx).Info("SHA256SUMS or its signature not found in release")
+		return errorResponse(http.StatusNotFound, ErrCodeSHASumsNotFound, "the release has no SHA256SUMS file or no signature of it"), nil
+	}
+	return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+}
+
+func processDocumentForProviderDownload(ctx context.Context, config config.Config, req events.APIGatewayProxyRequest, document *types.CacheItem, effectiveNamespace string, params DownloadHandlerPathParams) (events.APIGatewayProxyResponse, error) {
+	logging.FromContext(ctx).Info("Found document in cache", "last_updated", document.LastUpdated, "versions", len(document.Versions))
+
+	// try and find the version in the document
+	cached := findCacheVersion(document, params.Version)
+	if cached == nil {
+		logging.FromContext(ctx).Info("Version not found in document, returning 404", "version", params.Version)
+		return versionNotFoundResponse(params.Version), nil
+	}
+	if cached.Yanked != nil {
+		logging.FromContext(ctx).Warn("Version is yanked, returning 404", "reason", cached.Yanked.Reason)
+		return yankedResponse(cached), nil
+	}
+	versionDetails := cached.GetVersionDetails(params.OS, params.Architecture)
+	if versionDetails == nil {
+		requested := platform.Platform{OS: params.OS, Arch: params.Architecture}
+		reason := cached.UnavailableReason(requested)
+		logging.FromContext(ctx).Info("Platform not found in version, returning 404", "reason", reason)
+		available := servedPlatforms(config, effectiveNamespace, params.Type, cached.ToVersion().Platforms)
+		return platformUnavailableResponse(ErrCodePlatformNotAvailable, requested, reason, available), nil
+	}
+
+	// the cache holds the digest-based blob URLs of OCI artifacts, which need resolving to downloadable URLs
+	if ref, ok := config.OCIProvider(effectiveNamespace, params.Type); ok {
+		if err := providers.ResolveOCIDownloadURLs(ctx, config.OCIClient, ref, versionDetails); err != nil {
+			logging.FromContext(ctx).Error("Could not resolve OCI download URLs", "error", err)
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+	}
+
+	// attach the signing keys
+	publicKeys, keysErr := config.KeysForNamespace(ctx, effectiveNamespace)
+	if keysErr != nil {
+		logging.FromContext(ctx).Error("Could not get public keys", "error", keysErr)
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, keysErr
+	}
+
+	keys := types.SigningKeys{}
+	keys.GPGPublicKeys = publicKeys
+
+	versionDetails.SigningKeys = keys
+
+	// the signature is checked on the release assets, before the URLs are pointed at the mirrors
+	if response, ok, err := verifyDownloadSignature(ctx, config, versionDetails); !ok {
+		return response, err
+	}
+
+	// the pre-signed URLs of the asset mirror expire, so the responses pointing at it must not be reused
+	warn := downloadWarnings(ctx, config, params, versionDetails, document.Metadata)
+	etag := documentETag(document, "", append(warn, keyIDs(publicKeys)...)...)
+	cacheable := config.AssetMirror == nil
+	if cacheable && notModified(req, etag, document.LastUpdated) {
+		logging.FromContext(ctx).Info("Document not modified since the client fetched it, returning 304")
+		return withCacheHeaders(events.APIGatewayProxyResponse{StatusCode: http.StatusNotModified}, etag, responseMaxAge(config)), nil
+	}
+
+	// the release assets of the providers ingested from GitHub are mirrored to S3, OCI artifacts are not
+	var locations []mirror.Location
+	if _, ok := config.OCIProvider(effectiveNamespace, params.Type); !ok {
+		var err error
+		if locations, err = downloadLocations(ctx, config, effectiveNamespace, params, versionDetails); err != nil {
+			logging.FromContext(ctx).Error("Could not list download locations", "error", err)
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+	}
+
+	logging.FromContext(ctx).Info("Found version in document", "version", params.Version)
+	response, err := downloadResponse(ctx, versionDetails, warn, locations...)
+	if cacheable {
+		response = withCacheHeaders(response, etag, responseMaxAge(config))
+	}
+	return response, err
+}
+
+// verifyDownloadSignature checks that the SHA256SUMS of the version is signed by one of the signing keys attached to
+// it, so that clients are never sent the metadata of archives they would reject. It returns false with the response
+// to send instead when the signature does not match or cannot be checked. The namespaces without any key are not
+// checked, there is nothing to check against.
+func verifyDownloadSignature(ctx context.Context, config config.Config, versionDetails *types.VersionDetails) (events.APIGatewayProxyResponse, bool, error) {
+	publicKeys := versionDetails.SigningKeys.GPGPublicKeys
+	if config.SignatureVerifications == nil || len(publicKeys) == 0 {
+		return events.APIGatewayProxyResponse{}, true, nil
+	}
+
+	keyID, err := config.SignatureVerifications.Verify(ctx, versionDetails.SHASumsURL, versionDetails.SHASumsSignatureURL, publicKeys)
+	if errors.Is(err, providers.ErrSignatureMismatch) {
+		logging.FromContext(ctx).Warn("SHA256SUMS signature does not match any key of the namespace, returning 404", "error", err)
+		return errorResponse(http.StatusNotFound, ErrCodeSignatureMismatch, "the SHA256SUMS of the release is not signed by any key registered for the namespace"), false, nil
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("Could not verify SHA256SUMS signature", "error", err)
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, false, err
+	}
+
+	logging.FromContext(ctx).Info("Verified SHA256SUMS signature", "key_id", keyID)
+	return events.APIGatewayProxyResponse{}, true, nil
+}
+
+// findCacheVersion returns the version of the cached document, nil if it has no such version.
+func findCacheVersion(document *types.CacheItem, version string) *types.CacheVersion {
+	for i := range document.Versions {
+		if document.Versions[i].Version == version {
+			return &document.Versions[i]
+		}
+	}
+	return nil
+}
+
+// servedPlatforms returns the platforms the registry serves for the provider out of the given ones.
+func servedPlatforms(config config.Config, effectiveNamespace, providerType string, platforms []platform.Platform) []platform.Platform {
+	allowlist := config.PlatformAllowlist(effectiveNamespace, providerType)
+	served := make([]platform.Platform, 0, len(platforms))
+	for _, p := range platforms {
+		if allowlist.Allows(p) {
+			served = append(served, p)
+		}
+	}
+	return served
+}
+
+//nolint:gochecknoglobals // This should be treated as a constant.
+var platformReasonMessages = map[string]string{
+	types.PlatformNeverBuilt:      "the release has no archive for %s",
+	types.PlatformAssetMissing:    "the SHA256SUMS of the release lists an archive for %s, but it is not attached to the release",
+	types.PlatformChecksumMissing: "the release has an archive for %s, but it is not listed in the SHA256SUMS of the release",
+	types.PlatformExcluded:        "the registry does not serve %s for this provider",
+}
+
+// platformUnavailableResponse returns the error document explaining why the requested platform is not available,
+// listing the platforms which are.
+func platformUnavailableResponse(code string, requested platform.Platform, reason string, available []platform.Platform) events.APIGatewayProxyResponse {
+	message := fmt.Sprintf(platformReasonMessages[reason], requested.OS+"_"+requested.Arch)
+	return platformResponse(code, message, PlatformAvailability{Requested: requested, Reason: reason, Available: available})
+}
+
+// downloadLocations lists where the files of a cached version ingested from GitHub can be downloaded from, and points
+// the URLs of the version at the S3 mirror if there is one. The mirrors have no files for the versions which are not
+// cached yet, so the other responses have no locations.
+func downloadLocations(ctx context.Context, config config.Config, effectiveNamespace string, params DownloadHandlerPathParams, versionDetails *types.VersionDetails) ([]mirror.Location, error) {
+	if config.AssetMirror == nil && len(config.RegionalMirrors) == 0 {
+		return nil, nil
+	}
+
+	locations := []mirror.Location{mirror.NewLocation(mirror.LocationOrigin, mirror.PriorityOrigin, versionDetails)}
+	for _, regional := range config.RegionalMirrors {
+		location, err := regional.Location(effectiveNamespace, params.Type, params.Version, versionDetails)
+		if err != nil {
+			return nil, err
+		}
+		locations = append(locations, location)
+	}
+
+	if config.AssetMirror != nil {
+		if err := config.AssetMirror.PresignVersion(ctx, effectiveNamespace, params.Type, params.Version, versionDetails); err != nil {
+			return nil, fmt.Errorf("could not pre-sign mirror URLs: %w", err)
+		}
+		locations = append(locations, mirror.NewLocation(mirror.LocationMirror, mirror.PriorityMirror, versionDetails))
+	}
+
+	mirror.SortLocations(locations)
+	return locations, nil
+}
+
+// ProviderDownloadResponse is the v1 download response, with the warnings that apply to the version.
+type ProviderDownloadResponse struct {
+	*types.VersionDetails
+	Warnings []string `json:"warnings,omitempty"`
+	// Mirrors is an extension listing every location the files of the version can be downloaded from, including the
+	// one of the URLs above, from the most preferred to the least. Clients of the registry protocol ignore it.
+	Mirrors []mirror.Location `json:"mirrors,omitempty"`
+}
+
+func downloadResponse(ctx context.Context, versionDetails *types.VersionDetails, warnings []string, mirrors ...mirror.Location) (events.APIGatewayProxyResponse, error) {
+	resBody, err := json.Marshal(ProviderDownloadResponse{VersionDetails: versionDetails, Warnings: warnings, Mirrors: mirrors})
+	if err != nil {
+		logging.FromContext(ctx).Error("Error marshalling response", "error", err)
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+	}
+	return events.APIGatewayProxyResponse{StatusCode: http.StatusOK, Body: string(resBody)}, nil
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/logging"
+)
+
+const (
+	// defaultSearchLimit is the number of providers per page unless the client asks for another `limit`.
+	defaultSearchLimit = 20
+	// maxSearchLimit is the largest page a client can ask for with the `limit` query parameter.
+	maxSearchLimit = 100
+	// maxSearchQueryLength bounds the query, which is matched against every cached provider.
+	maxSearchQueryLength = 64
+)
+
+type SearchProvidersResponse struct {
+	Meta      SearchMeta               `json:"meta"`
+	Providers []SearchProviderResponse `json:"providers"`
+}
+
+// SearchMeta follows the pagination metadata of the listings of the public registry protocol.
+type SearchMeta struct {
+	Limit         int  `json:"limit"`
+	CurrentOffset int  `json:"current_offset"`
+	NextOffset    *int `json:"next_offset,omitempty"`
+	PrevOffset    *int `json:"prev_offset,omitempty"`
+}
+
+type SearchProviderResponse struct {
+	ID          string `json:"id"` // The `<namespace>/<type>` address of the provider.
+	Namespace   string `json:"namespace"`
+	Name        string `json:"name"`
+	VersionsURL string `json:"versions_url"`
+}
+
+// searchQuery is what the client asked for, parsed from the query parameters.
+type searchQuery struct {
+	query  string
+	limit  int
+	offset int
+}
+
+func parseSearchQuery(req events.APIGatewayProxyRequest) (searchQuery, error) {
+	search := searchQuery{
+		query: strings.ToLower(strings.TrimSpace(req.QueryStringParameters["q"])),
+		limit: defaultSearchLimit,
+	}
+	if search.query == "" {
+		return search, fmt.Errorf("missing search query, expected a q parameter")
+	}
+	if len(search.query) > maxSearchQueryLength {
+		return search, fmt.Errorf("search query too long, expected at most %d characters", maxSearchQueryLength)
+	}
+
+	if value := req.QueryStringParameters["limit"]; value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxSearchLimit {
+			return search, fmt.Errorf("invalid limit, expected a number between 1 and %d", maxSearchLimit)
+		}
+		search.limit = limit
+	}
+
+	if value := req.QueryStringParameters["offset"]; value != "" {
+		offset, err := strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			return search, fmt.Errorf("invalid offset, expected a positive number")
+		}
+		search.offset = offset
+	}
+
+	return search, nil
+}
+
+// searchProviders searches the namespaces and names of the cached providers. Only the providers which have been
+// requested at least once are cached, so this is not an exhaustive search of the providers that can be served.
+func searchProviders(config config.Config) LambdaFunc {
+	return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		search, err := parseSearchQuery(req)
+		if err != nil {
+			return badRequestResponse(err.Error()), nil
+		}
+		ctx = logging.With(ctx, "query", search.query)
+
+		keys, err := config.ProviderVersionCache.Search(ctx, search.query)
+		if err != nil {
+			logging.FromContext(ctx).Error("Error searching providers in cache", "error", err)
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+
+		// the private providers the request cannot read are not found
+		readable := keys[:0]
+		for _, key := range keys {
+			if canReadAddress(config, key) {
+				readable = append(readable, key)
+			}
+		}
+
+		response := searchPage(readable, search)
+		for i, p := range response.Providers {
+			response.Providers[i].VersionsURL = selfURL(config, req, fmt.Sprintf("/v1/providers/%s/versions", p.ID))
+		}
+		return jsonResponse(response)
+	}
+}
+
+// searchPage returns the page of the sorted matching keys asked for.
+func searchPage(keys []string, search searchQuery) SearchProvidersResponse {
+	meta, page := searchWindow(keys, search)
+	response := SearchProvidersResponse{Meta: meta, Providers: []SearchProviderResponse{}}
+	for _, key := range page {
+		namespace, name, _ := strings.Cut(key, "/")
+		response.Providers = append(response.Providers, SearchProviderResponse{ID: key, Namespace: namespace, Name: name})
+	}
+	return response
+}
+
+// searchWindow returns the pagination metadata of the page of the sorted matching keys asked for, and its keys.
+func searchWindow(keys []string, search searchQuery) (SearchMeta, []string) {
+	meta := SearchMeta{Limit: search.limit, CurrentOffset: search.offset}
+	if search.offset > 0 {
+		prev := search.offset - search.limit
+		if prev < 0 {
+			prev = 0
+		}
+		meta.PrevOffset = &prev
+	}
+	if search.offset >= len(keys) {
+		return meta, nil
+	}
+
+	end := search.offset + search.limit
+	if end < len(keys) {
+		meta.NextOffset = &end
+	} else {
+		end = len(keys)
+	}
+	return meta, keys[search.offset:end]
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	search, err := parseSearchQuery(events.APIGatewayProxyRequest{QueryStringParameters: map[string]string{"q": " AWS ", "limit": "5", "offset": "10"}})
+	if err != nil || search.query != "aws" || search.limit != 5 || search.offset != 10 {
+		t.Errorf("parseSearchQuery() = %+v, %v", search, err)
+	}
+
+	for _, params := range []map[string]string{
+		{},
+		{"q": "aws", "limit": "0"},
+		{"q": "aws", "limit": "101"},
+		{"q": "aws", "offset": "-1"},
+	} {
+		if _, err := parseSearchQuery(events.APIGatewayProxyRequest{QueryStringParameters: params}); err == nil {
+			t.Errorf("parseSearchQuery(%v) should fail", params)
+		}
+	}
+}
+
+func TestSearchPage(t *testing.T) {
+	keys := []string{"a/aws", "b/aws", "c/aws"}
+
+	first := searchPage(keys, searchQuery{limit: 2})
+	if len(first.Providers) != 2 || first.Providers[1].Namespace != "b" || first.Providers[1].Name != "aws" {
+		t.Errorf("first page = %+v", first.Providers)
+	}
+	if first.Meta.NextOffset == nil || *first.Meta.NextOffset != 2 || first.Meta.PrevOffset != nil {
+		t.Errorf("first page meta = %+v", first.Meta)
+	}
+
+	last := searchPage(keys, searchQuery{limit: 2, offset: 2})
+	if len(last.Providers) != 1 || last.Providers[0].ID != "c/aws" {
+		t.Errorf("last page = %+v", last.Providers)
+	}
+	if last.Meta.NextOffset != nil || last.Meta.PrevOffset == nil || *last.Meta.PrevOffset != 0 {
+		t.Errorf("last page meta = %+v", last.Meta)
+	}
+
+	if beyond := searchPage(keys, searchQuery{limit: 2, offset: 5}); len(beyond.Providers) != 0 {
+		t.Errorf("page beyond the results = %+v", beyond.Providers)
+	}
+}
@@ -0,0 +1,268 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/logging"
+	"github.com/opentofu/registry/internal/oci"
+	"github.com/opentofu/registry/internal/providers"
+	"github.com/opentofu/registry/internal/providers/types"
+	"github.com/opentofu/registry/internal/refresh"
+)
+
+type ListProvidersPathParams struct {
+	Namespace string `json:"namespace"`
+	Type      string `json:"name"`
+}
+
+func (p ListProvidersPathParams) AnnotateLogger(ctx context.Context) context.Context {
+	logger := logging.FromContext(ctx).
+		With("namespace", p.Namespace).
+		With("type", p.Type)
+	return logging.WithLogger(ctx, logger)
+}
+
+func getListProvidersPathParams(req events.APIGatewayProxyRequest) ListProvidersPathParams {
+	return ListProvidersPathParams{
+		Namespace: req.PathParameters["namespace"],
+		Type:      req.PathParameters["type"],
+	}
+}
+
+type ListProviderVersionsResponse struct {
+	Versions []types.Version `json:"versions"`
+	// Prereleases are only listed with `include=prereleases`, apart from the versions so that clients of the protocol
+	// never pick one. They are not paginated and come with the first page.
+	Prereleases []types.Version `json:"prereleases,omitempty"`
+	Warnings    []string        `json:"warnings,omitempty"`
+	// Next is the URL of the next page of versions, only set for paginated listings that have more versions.
+	Next string `json:"next,omitempty"`
+	// License is the SPDX identifier of the license of the provider, only in the v2 listings of cached providers.
+	License string `json:"license,omitempty"`
+}
+
+// listProviderVersions serves the v1 listing. It is only paginated if V1_VERSIONS_PAGE_SIZE is set, as clients of the
+// v1 protocol do not follow the continuation.
+func listProviderVersions(config config.Config) LambdaFunc {
+	return providerVersionsHandler(config, versionsListingOptions{pageSize: config.V1VersionsPageSize})
+}
+
+// listProviderVersionsV2 serves the same listing as the v1 endpoint, filtered by the `protocol` and `platform` query
+// parameters, paginated and optionally in the JSON:API format.
+func listProviderVersionsV2(config config.Config) LambdaFunc {
+	return providerVersionsHandler(config, versionsListingOptions{filters: true, pageSize: defaultVersionsPageSize, jsonAPI: true})
+}
+
+func providerVersionsHandler(config config.Config, options versionsListingOptions) LambdaFunc {
+	return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		params := getListProvidersPathParams(req)
+		ctx = params.AnnotateLogger(ctx)
+
+		listing, err := parseVersionsListing(req, options)
+		if err != nil {
+			return badRequestResponse(err.Error()), nil
+		}
+
+		effectiveNamespace := config.EffectiveProviderNamespace(params.Namespace)
+
+		// For now, we will ignore errors from the cache and just fetch from GH instead
+		document, _ := listVersionsFromCache(ctx, config, effectiveNamespace, params.Type)
+		if knownMissing(config, document) {
+			logging.FromContext(ctx).Info("Provider is known not to exist")
+			return NotFoundResponse, nil
+		}
+		if document != nil && len(document.Versions) > 0 {
+			versionList, lastUpdated := listing.extensions.ToVersions(document.Versions), document.LastUpdated
+			prereleases := listing.extensions.ToPrereleases(document.Versions)
+			variant := ""
+			if options.jsonAPI && acceptsJSONAPI(req) {
+				variant = "jsonapi"
+			}
+			warn := providerWarnings(ctx, config, params.Namespace, params.Type, append(versionList, prereleases...), document.Metadata)
+			etag := documentETag(document, variant, warn...)
+			cacheable := func(response events.APIGatewayProxyResponse) events.APIGatewayProxyResponse {
+				return withCacheHeaders(withLastModified(response, lastUpdated), etag, responseMaxAge(config))
+			}
+
+			if notModified(req, etag, lastUpdated) {
+				logging.FromContext(ctx).Info("Document not modified since the client fetched it, returning 304")
+				return cacheable(events.APIGatewayProxyResponse{StatusCode: http.StatusNotModified}), nil
+			}
+			page, next := listing.apply(config, req, versionList)
+			if response, ok := largeListingResponse(ctx, config, listing, document, warn); ok {
+				return cacheable(response), nil
+			}
+			response, err := listingResponse(req, params, options, ListProviderVersionsResponse{
+				Versions:    page,
+				Prereleases: listing.prereleases(prereleases),
+				Warnings:    warn,
+				Next:        next,
+				License:     document.Metadata.License,
+			})
+			return cacheable(response), err
+		}
+
+		if config.MaintenanceMode {
+			return maintenanceResponse(), nil
+		}
+
+		cacheVersions, repoExists, err := listVersionsFromRepository(ctx, config, effectiveNamespace, params.Type)
+		if !repoExists {
+			if err != nil {
+				logging.FromContext(ctx).Error("Error checking if repo exists", "error", err)
+				return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+			}
+			logging.FromContext(ctx).Info("Repo does not exist")
+			rememberMissing(ctx, config, effectiveNamespace, params.Type)
+			// if the repo doesn't exist, there's no point in trying to fetch versions
+			return NotFoundResponse, nil
+		}
+		if err != nil {
+			logging.FromContext(ctx).Error("Error fetching versions from github", "error", err)
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+
+		// if the document didn't exist in the cache, trigger the lambda to populate it
+		if err := triggerPopulateProviderVersions(ctx, config, effectiveNamespace, params.Type); err != nil {
+			logging.FromContext(ctx).Error("Error triggering lambda", "error", err)
+		}
+
+		versionList, prereleases := listing.extensions.ToVersions(cacheVersions), listing.extensions.ToPrereleases(cacheVersions)
+		page, next := listing.apply(config, req, versionList)
+		return listingResponse(req, params, options, ListProviderVersionsResponse{
+			Versions:    page,
+			Prereleases: listing.prereleases(prereleases),
+			Warnings:    providerWarnings(ctx, config, params.Namespace, params.Type, append(versionList, prereleases...), types.ProviderMetadata{}),
+			Next:        next,
+		})
+	}
+}
+
+// listVersionsFromCache retrieves the cached document for a given effective namespace and provider type.
+// - If the cached document is not present or there's an error during retrieval, the function returns nil.
+// - If the cached document is present and is not stale, it is returned directly.
+// - If the cached document is present and is detected as stale:
+//   - An asynchronous update via a lambda function is triggered.
+//   - The stale document is returned.
+func listVersionsFromCache(ctx context.Context, config config.Config, effectiveNamespace, providerType string) (*types.CacheItem, error) {
+	document, err := config.ProviderVersionCache.GetItem(ctx, fmt.Sprintf("%s/%s", effectiveNamespace, providerType))
+	if err != nil || document == nil {
+		return nil, err
+	}
+
+	logging.FromContext(ctx).Info("Found document in cache", "last_updated", document.LastUpdated, "versions", len(document.Versions))
+
+	// the existence of the provider is checked again by the request itself
+	if document.Metadata.NotFound && document.IsStale(config.CacheTTL) {
+		return nil, nil //nolint:nilnil // a stale missing provider is as good as not cached
+	}
+
+	if document.IsStale(config.CacheTTL) {
+		// if it's stale, trigger the lambda to update, and still return the stale document
+		logging.FromContext(ctx).Info("Document is stale, returning cached versions and triggering lambda", "last_updated", document.LastUpdated)
+		if triggerErr := triggerPopulateProviderVersions(ctx, config, effectiveNamespace, providerType); triggerErr != nil {
+			logging.FromContext(ctx).Error("Error triggering lambda", "error", triggerErr)
+		}
+	}
+
+	// if it's stale or not, we still return the cached document
+	return document, nil
+}
+
+// knownMissing returns true if the cache recently recorded that the provider does not exist. A client token may read
+// repositories the registry cannot, so what the cache recorded does not hold for it.
+func knownMissing(config config.Config, document *types.CacheItem) bool {
+	return !config.UsesClientToken() && document.KnownMissing(config.CacheTTL)
+}
+
+// rememberMissing records in the cache that the provider does not exist, so that the requests for it in the next
+// minutes, such as scans of made-up addresses, do not use up the GitHub quota. Failures are only logged.
+func rememberMissing(ctx context.Context, config config.Config, effectiveNamespace, providerType string) {
+	if config.MaintenanceMode || config.UsesClientToken() {
+		return
+	}
+	key := fmt.Sprintf("%s/%s", effectiveNamespace, providerType)
+	if err := config.ProviderVersionCache.Store(ctx, key, nil, types.ProviderMetadata{NotFound: true}); err != nil {
+		logging.FromContext(ctx).Error("Error recording missing provider in cache", "error", err)
+	}
+}
+
+func listVersionsFromRepository(ctx context.Context, config config.Config, effectiveNamespace, providerType string) (types.VersionList, bool, error) {
+	if ref, ok := config.OCIProvider(effectiveNamespace, providerType); ok {
+		logging.FromContext(ctx).Info("Fetching versions from OCI registry", "repository", ref.String())
+		versionList, err := providers.GetVersionsFromOCI(ctx, config.OCIClient, ref, nil)
+		if errors.Is(err, oci.ErrNotFound) {
+			return nil, false, nil
+		}
+		return versionList.WithPlatforms(config.PlatformAllowlist(effectiveNamespace, providerType)), true, err
+	}
+
+	source, err := config.ProviderSource(ctx, effectiveNamespace, providerType)
+	if err != nil {
+		return nil, false, err
+	}
+	exists, err := source.Host.RepositoryExists(ctx, source.Owner, source.Name)
+	if err != nil {
+		return nil, exists, err
+	}
+
+	logging.FromContext(ctx).Info("Fetching versions from github\n")
+	versionList, err := providers.GetVersions(ctx, source.Host, source.Owner, source.Name, nil, nil, config.AssetPatterns(effectiveNamespace, providerType))
+	return versionList.WithPlatforms(config.PlatformAllowlist(effectiveNamespace, providerType)), exists, err
+}
+
+func triggerPopulateProviderVersions(ctx context.Context, config config.Config, effectiveNamespace string, effectiveType string) error {
+	return startProviderRefresh(ctx, config, effectiveNamespace, effectiveType, false)
+}
+
+// startProviderRefresh starts refreshing the cached versions of a provider, even if they are not stale when force is
+// set.
+func startProviderRefresh(ctx context.Context, config config.Config, effectiveNamespace, effectiveType string, force bool) error {
+	// the cache must not change while the registry is in maintenance
+	if config.MaintenanceMode {
+		return nil
+	}
+
+	if config.PopulateProviderVersions != nil {
+		return config.PopulateProviderVersions(ctx, effectiveNamespace, effectiveType, config.TenantName, force)
+	}
+
+	return refresh.Send(ctx, config, refresh.Event{Namespace: effectiveNamespace, Type: effectiveType, Tenant: config.TenantName, Force: force})
+}
+
+// listingResponse renders a page of the versions listing, in the JSON:API format if the options allow it and the
+// client asked for it. The license is only part of the v2 listings.
+func listingResponse(req events.APIGatewayProxyRequest, params ListProvidersPathParams, options versionsListingOptions, response ListProviderVersionsResponse) (events.APIGatewayProxyResponse, error) {
+	if !options.jsonAPI {
+		return versionsResponse(response.Versions, response.Prereleases, response.Next, response.Warnings)
+	}
+
+	return negotiatedResponse(req, response, func() JSONAPIDocument {
+		return providerVersionsDocument(params.Namespace, params.Type, response)
+	})
+}
+
+func versionsResponse(versions, prereleases []types.Version, next string, warnings []string) (events.APIGatewayProxyResponse, error) {
+	response := ListProviderVersionsResponse{
+		Versions:    versions,
+		Prereleases: prereleases,
+		Next:        next,
+	}
+
+	if len(warnings) > 0 {
+		response.Warnings = warnings
+	}
+
+	resBody, err := json.Marshal(response)
+	if err != nil {
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+	}
+
+	return events.APIGatewayProxyResponse{StatusCode: http.StatusOK, Body: string(resBody)}, nil
+}
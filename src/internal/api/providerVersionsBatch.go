@@ -0,0 +1,125 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/logging"
+)
+
+// maxBatchProviders limits how many providers can be requested in a single batch call.
+const maxBatchProviders = 100
+
+type BatchProviderVersionsRequest struct {
+	Providers []string `json:"providers"` // Provider addresses in the form "namespace/type".
+}
+
+type BatchProviderVersionsResponse struct {
+	Providers map[string]ListProviderVersionsResponse `json:"providers"`
+	// Missing lists the requested providers that are not in the cache yet. A refresh is triggered for each of them,
+	// so they should be available on a later call if they exist.
+	Missing []string `json:"missing,omitempty"`
+}
+
+func batchProviderVersions(config config.Config) LambdaFunc {
+	return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		filter, err := parseVersionFilter(req)
+		if err != nil {
+			return badRequestResponse(err.Error()), nil
+		}
+		extensions, err := parseVersionExtensions(req)
+		if err != nil {
+			return badRequestResponse(err.Error()), nil
+		}
+
+		var body BatchProviderVersionsRequest
+		if err := json.Unmarshal([]byte(req.Body), &body); err != nil {
+			return badRequestResponse(fmt.Sprintf("invalid request body: %s", err)), nil
+		}
+		if len(body.Providers) == 0 {
+			return badRequestResponse("at least one provider is required"), nil
+		}
+		if len(body.Providers) > maxBatchProviders {
+			return badRequestResponse(fmt.Sprintf("at most %d providers can be requested at once", maxBatchProviders)), nil
+		}
+
+		// map each requested address onto its cache key, taking namespace redirects into account; addresses redirected
+		// onto the same provider share their key, which is only fetched and refreshed once
+		keys := make([]string, 0, len(body.Providers))
+		order := make([]string, 0, len(body.Providers))
+		requested := make(map[string]string, len(body.Providers))
+		fetched := make(map[string]bool, len(body.Providers))
+		for _, address := range body.Providers {
+			namespace, providerType, ok := strings.Cut(address, "/")
+			if !ok || namespace == "" || providerType == "" || strings.Contains(providerType, "/") {
+				return badRequestResponse(fmt.Sprintf("invalid provider address %q", address)), nil
+			}
+			key := fmt.Sprintf("%s/%s", config.EffectiveProviderNamespace(namespace), providerType)
+			if _, ok := requested[address]; !ok {
+				order = append(order, address)
+			}
+			requested[address] = key
+			if !fetched[key] {
+				fetched[key] = true
+				keys = append(keys, key)
+			}
+		}
+
+		logging.FromContext(ctx).Info("Fetching batch of provider versions", "providers", len(keys))
+		documents, err := config.ProviderVersionCache.GetItems(ctx, keys)
+		if err != nil {
+			logging.FromContext(ctx).Error("Error getting documents from cache", "error", err)
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+
+		response := BatchProviderVersionsResponse{Providers: make(map[string]ListProviderVersionsResponse, len(requested))}
+		private := false
+		refreshed := make(map[string]bool, len(keys))
+		for _, address := range order {
+			key := requested[address]
+			namespace, providerType, _ := strings.Cut(key, "/")
+			requestedNamespace, requestedType, _ := strings.Cut(address, "/")
+
+			// private providers the request cannot read are reported missing, as if they did not exist
+			if !config.CanReadProvider(requestedNamespace, requestedType) {
+				response.Missing = append(response.Missing, address)
+				continue
+			}
+
+			document, ok := documents[key]
+			if (!ok || document.IsStale(config.CacheTTL)) && !refreshed[key] {
+				refreshed[key] = true
+				if triggerErr := triggerPopulateProviderVersions(ctx, config, namespace, providerType); triggerErr != nil {
+					logging.FromContext(ctx).Error("Error triggering lambda", "error", triggerErr)
+				}
+			}
+			if !ok || document.Metadata.NotFound {
+				response.Missing = append(response.Missing, address)
+				continue
+			}
+
+			private = private || config.ProviderIsPrivate(requestedNamespace, requestedType)
+			versions := filter.Apply(extensions.ToVersions(document.Versions))
+			prereleases := filter.Apply(extensions.ToPrereleases(document.Versions))
+			response.Providers[address] = ListProviderVersionsResponse{
+				Versions:    versions,
+				Prereleases: prereleases,
+				Warnings:    providerWarnings(ctx, config, requestedNamespace, requestedType, append(versions, prereleases...), document.Metadata),
+				License:     document.Metadata.License,
+			}
+		}
+
+		res, err := negotiatedResponse(req, response, func() JSONAPIDocument {
+			return batchProviderVersionsDocument(response, order)
+		})
+		if private {
+			res = withPrivateCaching(res)
+		}
+		return res, err
+	}
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/opentofu/registry/internal/awstest"
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/deprecations"
+	"github.com/opentofu/registry/internal/providers/types"
+)
+
+// batchProviderCache is a provider cache which records the keys it is asked for in a batch.
+type batchProviderCache struct {
+	itemsProviderCache
+	requested *[]string
+}
+
+func (c batchProviderCache) GetItems(_ context.Context, keys []string) (map[string]*types.CacheItem, error) {
+	*c.requested = append(*c.requested, keys...)
+	items := make(map[string]*types.CacheItem)
+	for _, key := range keys {
+		if item, ok := c.items[key]; ok {
+			items[key] = item
+		}
+	}
+	return items, nil
+}
+
+func TestBatchProviderVersions(t *testing.T) {
+	var requested []string
+	cache := batchProviderCache{itemsProviderCache: itemsProviderCache{items: map[string]*types.CacheItem{
+		"example/fresh": {Provider: "example/fresh", Versions: types.VersionList{{Version: "1.0.0"}}, LastUpdated: time.Now()},
+		"example/stale": {Provider: "example/stale", Versions: types.VersionList{{Version: "2.0.0"}}, LastUpdated: time.Now().Add(-48 * time.Hour)},
+	}}, requested: &requested}
+
+	// the deprecations table holds a deprecation of example/stale
+	deprecationsClient := awstest.DynamoDBClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var input struct {
+			Key struct {
+				Address struct{ S string } `json:"address"`
+			}
+		}
+		_ = json.NewDecoder(r.Body).Decode(&input)
+		w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+		if input.Key.Address.S != deprecations.ProviderAddress("example", "stale") {
+			_, _ = w.Write([]byte(`{}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"Item":{"address":{"S":"providers/example/stale"},"deprecations":{"L":[{"M":{"message":{"S":"Use example/fresh."}}}]}}}`))
+	}))
+
+	var refreshed []string
+	cfg := config.Config{
+		ProviderVersionCache: cache,
+		ProviderRedirects:    map[string]string{"alias": "example"},
+		DeprecationsStore:    &deprecations.Handler{TableName: aws.String("deprecations"), Client: deprecationsClient},
+		PopulateProviderVersions: func(_ context.Context, namespace, providerType, _ string, _ bool) error {
+			refreshed = append(refreshed, namespace+"/"+providerType)
+			return nil
+		},
+	}
+
+	req := events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodPost,
+		Body:       `{"providers":["example/fresh","alias/fresh","example/fresh","example/stale","example/missing"]}`,
+	}
+	response, err := batchProviderVersions(cfg)(context.Background(), req)
+	if err != nil || response.StatusCode != http.StatusOK {
+		t.Fatalf("batchProviderVersions() = %d, %v", response.StatusCode, err)
+	}
+	var batch BatchProviderVersionsResponse
+	if err := json.Unmarshal([]byte(response.Body), &batch); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := []string{"example/fresh", "example/stale", "example/missing"}; !reflect.DeepEqual(requested, want) {
+		t.Errorf("requested keys = %v, want each cache key once: %v", requested, want)
+	}
+	if want := []string{"example/stale", "example/missing"}; !reflect.DeepEqual(refreshed, want) {
+		t.Errorf("refreshed = %v, want %v", refreshed, want)
+	}
+	if want := []string{"example/missing"}; !reflect.DeepEqual(batch.Missing, want) {
+		t.Errorf("missing = %v, want %v", batch.Missing, want)
+	}
+	for _, address := range []string{"example/fresh", "alias/fresh", "example/stale"} {
+		if _, ok := batch.Providers[address]; !ok {
+			t.Errorf("expected %s in the response, got %v", address, batch.Providers)
+		}
+	}
+	if got := batch.Providers["alias/fresh"].Versions; len(got) != 1 || got[0].Version != "1.0.0" {
+		t.Errorf("expected alias/fresh to be served the versions of example/fresh, got %+v", got)
+	}
+	if got := batch.Providers["example/stale"].Warnings; len(got) != 1 || got[0] != "Deprecated: Use example/fresh." {
+		t.Errorf("expected the deprecation of example/stale as a warning, got %v", got)
+	}
+	if got := batch.Providers["example/fresh"].Warnings; len(got) != 0 {
+		t.Errorf("expected no warnings for example/fresh, got %v", got)
+	}
+}
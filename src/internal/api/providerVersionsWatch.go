@@ -0,0 +1,154 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/logging"
+	"github.com/opentofu/registry/internal/providers/types"
+)
+
+const (
+	// defaultWatchTimeout is how long we hold a watch request open when the client does not ask for a timeout.
+	defaultWatchTimeout = 20 * time.Second
+	// maxWatchTimeout stays below the 29 second API Gateway integration timeout.
+	maxWatchTimeout = 25 * time.Second
+	// watchPollInterval is how often the cache is re-read while a watch request is open.
+	watchPollInterval = 2 * time.Second
+)
+
+type WatchProviderVersionsResponse struct {
+	// Cursor should be passed as the `since` query parameter of the next watch request.
+	Cursor string `json:"cursor"`
+	// Next is the URL of the next watch request, with the cursor already filled in.
+	Next     string          `json:"next"`
+	Changed  bool            `json:"changed"`
+	Versions []types.Version `json:"versions,omitempty"`
+	// Prereleases are only listed with `include=prereleases`, apart from the versions like in the listings.
+	Prereleases []types.Version `json:"prereleases,omitempty"`
+}
+
+// watchProviderVersions long-polls the cache for changes to a provider. The cursor is the last updated time of the
+// cache item, so any refresh of the item is reported as a change and the client receives the full version list.
+func watchProviderVersions(config config.Config) LambdaFunc {
+	return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		params := getListProvidersPathParams(req)
+		ctx = params.AnnotateLogger(ctx)
+
+		effectiveNamespace := config.EffectiveProviderNamespace(params.Namespace)
+		key := fmt.Sprintf("%s/%s", effectiveNamespace, params.Type)
+
+		since, err := parseWatchCursor(req.QueryStringParameters["since"])
+		if err != nil {
+			return badRequestResponse(err.Error()), nil
+		}
+
+		timeout, err := parseWatchTimeout(req.QueryStringParameters["timeout"])
+		if err != nil {
+			return badRequestResponse(err.Error()), nil
+		}
+
+		filter, err := parseVersionFilter(req)
+		if err != nil {
+			return badRequestResponse(err.Error()), nil
+		}
+		extensions, err := parseVersionExtensions(req)
+		if err != nil {
+			return badRequestResponse(err.Error()), nil
+		}
+
+		document, err := config.ProviderVersionCache.GetItem(ctx, key)
+		if err != nil {
+			logging.FromContext(ctx).Error("Error getting document from cache", "error", err)
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+
+		// make sure a refresh is underway, otherwise we would just be waiting for the next scheduled one
+		if document == nil || document.IsStale(config.CacheTTL) {
+			if triggerErr := triggerPopulateProviderVersions(ctx, config, effectiveNamespace, params.Type); triggerErr != nil {
+				logging.FromContext(ctx).Error("Error triggering lambda", "error", triggerErr)
+			}
+		}
+
+		deadline := time.Now().Add(timeout)
+		for !watchChanged(document, since) && time.Now().Add(watchPollInterval).Before(deadline) {
+			select {
+			case <-ctx.Done():
+				return events.APIGatewayProxyResponse{StatusCode: http.StatusGatewayTimeout}, ctx.Err()
+			case <-time.After(watchPollInterval):
+			}
+
+			document, err = config.ProviderVersionCache.GetItem(ctx, key)
+			if err != nil {
+				logging.FromContext(ctx).Error("Error getting document from cache", "error", err)
+				return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+			}
+		}
+
+		response := WatchProviderVersionsResponse{Cursor: formatWatchCursor(since)}
+		if watchChanged(document, since) {
+			logging.FromContext(ctx).Info("Provider changed since cursor", "last_updated", document.LastUpdated)
+			response.Cursor = formatWatchCursor(document.LastUpdated)
+			response.Changed = true
+			response.Versions = filter.Apply(extensions.ToVersions(document.Versions))
+			response.Prereleases = filter.Apply(extensions.ToPrereleases(document.Versions))
+		}
+		next := url.Values{"since": {response.Cursor}}
+		for _, name := range []string{"protocol", "platform", "include"} {
+			if values := queryValues(req, name); len(values) > 0 {
+				next.Set(name, strings.Join(values, ","))
+			}
+		}
+		response.Next = fmt.Sprintf("%s?%s", selfURL(config, req, req.Path), next.Encode())
+
+		return negotiatedResponse(req, response, func() JSONAPIDocument {
+			return watchProviderVersionsDocument(params.Namespace, params.Type, response)
+		})
+	}
+}
+
+func watchChanged(document *types.CacheItem, since time.Time) bool {
+	return document != nil && !document.Metadata.NotFound && document.LastUpdated.After(since)
+}
+
+// parseWatchCursor parses the opaque cursor handed out by the watch endpoint. An empty cursor means "since forever",
+// so the first watch request returns the current state immediately.
+func parseWatchCursor(cursor string) (time.Time, error) {
+	if cursor == "" {
+		return time.Time{}, nil
+	}
+	nanos, err := strconv.ParseInt(cursor, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid since cursor")
+	}
+	return time.Unix(0, nanos), nil
+}
+
+func formatWatchCursor(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func parseWatchTimeout(value string) (time.Duration, error) {
+	if value == "" {
+		return defaultWatchTimeout, nil
+	}
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		return 0, fmt.Errorf("invalid timeout, expected a number of seconds")
+	}
+	timeout := time.Duration(seconds) * time.Second
+	if timeout > maxWatchTimeout {
+		timeout = maxWatchTimeout
+	}
+	return timeout, nil
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/providers/types"
+)
+
+func TestParseWatchCursor(t *testing.T) {
+	since, err := parseWatchCursor("")
+	if err != nil || !since.IsZero() {
+		t.Errorf("parseWatchCursor(\"\") = %v, %v, want the zero time", since, err)
+	}
+
+	lastUpdated := time.Date(2023, 10, 1, 12, 0, 0, 123456789, time.UTC)
+	since, err = parseWatchCursor(formatWatchCursor(lastUpdated))
+	if err != nil || !since.Equal(lastUpdated) {
+		t.Errorf("parseWatchCursor() = %v, %v, want %v", since, err, lastUpdated)
+	}
+	if formatWatchCursor(time.Time{}) != "" {
+		t.Errorf("formatWatchCursor() of the zero time = %q, want it empty", formatWatchCursor(time.Time{}))
+	}
+
+	if _, err := parseWatchCursor("yesterday"); err == nil {
+		t.Error("expected an invalid cursor to be rejected")
+	}
+}
+
+func TestParseWatchTimeout(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "", want: defaultWatchTimeout},
+		{value: "0", want: 0},
+		{value: "10", want: 10 * time.Second},
+		{value: "600", want: maxWatchTimeout},
+		{value: "-1", wantErr: true},
+		{value: "1.5", wantErr: true},
+		{value: "soon", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseWatchTimeout(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseWatchTimeout(%q) = %v, %v, want %v, error %t", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestWatchProviderVersions(t *testing.T) {
+	lastUpdated := time.Now().Add(-time.Minute)
+	var refreshed []string
+	cfg := config.Config{
+		ProviderVersionCache: itemsProviderCache{items: map[string]*types.CacheItem{
+			"example/test": {
+				Provider:    "example/test",
+				Versions:    types.VersionList{{Version: "1.1.0", Protocols: []string{"6.0"}}, {Version: "1.0.0", Protocols: []string{"5.0"}}},
+				LastUpdated: lastUpdated,
+			},
+		}},
+		PopulateProviderVersions: func(_ context.Context, namespace, providerType, _ string, _ bool) error {
+			refreshed = append(refreshed, namespace+"/"+providerType)
+			return nil
+		},
+	}
+	watch := func(t *testing.T, providerType string, query map[string]string) (events.APIGatewayProxyResponse, WatchProviderVersionsResponse) {
+		t.Helper()
+		req := events.APIGatewayProxyRequest{
+			Path:                  "/v1/providers/example/" + providerType + "/watch",
+			Headers:               map[string]string{"Host": "registry.example.com"},
+			PathParameters:        map[string]string{"namespace": "example", "type": providerType},
+			QueryStringParameters: query,
+		}
+		response, err := watchProviderVersions(cfg)(context.Background(), req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var body WatchProviderVersionsResponse
+		if response.StatusCode == http.StatusOK {
+			if err := json.Unmarshal([]byte(response.Body), &body); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		return response, body
+	}
+
+	t.Run("first request", func(t *testing.T) {
+		_, body := watch(t, "test", map[string]string{"protocol": "6"})
+		if !body.Changed || body.Cursor != formatWatchCursor(lastUpdated) {
+			t.Errorf("expected the current versions at cursor %s, got %+v", formatWatchCursor(lastUpdated), body)
+		}
+		if len(body.Versions) != 1 || body.Versions[0].Version != "1.1.0" {
+			t.Errorf("expected the protocol filter to apply, got %+v", body.Versions)
+		}
+		next, err := url.Parse(body.Next)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if next.Host != "registry.example.com" || next.Query().Get("since") != body.Cursor || next.Query().Get("protocol") != "6" {
+			t.Errorf("expected the next URL to carry the cursor and the filter, got %s", body.Next)
+		}
+	})
+
+	t.Run("unchanged", func(t *testing.T) {
+		cursor := formatWatchCursor(lastUpdated)
+		_, body := watch(t, "test", map[string]string{"since": cursor, "timeout": "0"})
+		if body.Changed || body.Cursor != cursor || len(body.Versions) != 0 {
+			t.Errorf("expected no change at cursor %s, got %+v", cursor, body)
+		}
+	})
+
+	t.Run("changed", func(t *testing.T) {
+		cursor := strconv.FormatInt(lastUpdated.Add(-time.Second).UnixNano(), 10)
+		_, body := watch(t, "test", map[string]string{"since": cursor, "timeout": "0"})
+		if !body.Changed || len(body.Versions) != 2 {
+			t.Errorf("expected the versions refreshed since the cursor, got %+v", body)
+		}
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		refreshed = nil
+		_, body := watch(t, "missing", map[string]string{"timeout": "0"})
+		if body.Changed || body.Cursor != "" {
+			t.Errorf("expected no change for a provider which is not cached, got %+v", body)
+		}
+		if len(refreshed) != 1 || refreshed[0] != "example/missing" {
+			t.Errorf("expected a refresh of example/missing, got %v", refreshed)
+		}
+	})
+
+	for name, query := range map[string]map[string]string{
+		"invalid cursor":  {"since": "yesterday"},
+		"invalid timeout": {"timeout": "-1"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if response, _ := watch(t, "test", query); response.StatusCode != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d", response.StatusCode, http.StatusBadRequest)
+			}
+		})
+	}
+}
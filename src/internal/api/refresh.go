@@ -0,0 +1,28 @@
+package api
+
+import (
+	"context"
+
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/modules"
+	"github.com/opentofu/registry/internal/refresh"
+)
+
+// triggerModuleRefresh starts refreshing the cached versions of a module repository, see config.Config.ServeStale.
+func triggerModuleRefresh(ctx context.Context, config config.Config, repo modules.Repository) error {
+	return startModuleRefresh(ctx, config, repo, false)
+}
+
+// startModuleRefresh starts refreshing the cached versions of a module repository, even if they are not stale when
+// force is set.
+func startModuleRefresh(ctx context.Context, config config.Config, repo modules.Repository, force bool) error {
+	// the cache must not change while the registry is in maintenance
+	if config.MaintenanceMode {
+		return nil
+	}
+
+	if config.PopulateModuleVersions != nil {
+		return config.PopulateModuleVersions(ctx, repo, force)
+	}
+	return refresh.Send(ctx, config, refresh.Event{Module: &repo, Force: force})
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// requestIDHeader is the response header with the ID of the request, to quote when reporting a failure.
+const requestIDHeader = "X-Request-Id"
+
+// requestIDPattern matches the request IDs adopted as they are: the UUIDs of API Gateway, or the IDs of the proxies in
+// front of the HTTP server. Anything else could inject content into the logs and headers, so it is replaced.
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// withRequestID gives every request an ID, adopted from API Gateway when it has one and generated otherwise, which
+// the logs and traces of the request are annotated with, and returns it in the X-Request-Id header of the response.
+func withRequestID(next LambdaFunc) LambdaFunc {
+	return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		if !requestIDPattern.MatchString(req.RequestContext.RequestID) {
+			req.RequestContext.RequestID = newRequestID()
+		}
+
+		response, err := next(ctx, req)
+
+		// the headers of the shared responses must not be modified, so set the header on a copy
+		headers := make(map[string]string, len(response.Headers)+1)
+		for name, value := range response.Headers {
+			headers[name] = value
+		}
+		headers[requestIDHeader] = req.RequestContext.RequestID
+		response.Headers = headers
+		return response, err
+	}
+}
+
+func newRequestID() string {
+	id := make([]byte, 16) //nolint:gomnd // 128 bits, like the UUIDs of API Gateway
+	if _, err := rand.Read(id); err != nil {
+		// crypto/rand does not fail on the platforms we run on
+		panic(err)
+	}
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/opentofu/registry/internal/config"
+)
+
+func TestRequestID(t *testing.T) {
+	router := Router(config.Config{})
+
+	tests := []struct {
+		name      string
+		requestID string
+		adopted   bool
+	}{
+		{name: "from API Gateway", requestID: "c6af9ac6-7b61-11e6-9a41-93e8deadbeef", adopted: true},
+		{name: "missing"},
+		{name: "invalid", requestID: "abc\ninjected log line"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := events.APIGatewayProxyRequest{HTTPMethod: http.MethodGet, Path: "/unknown"}
+			req.RequestContext.RequestID = tt.requestID
+			response, err := router(context.Background(), req)
+			if err != nil || response.StatusCode != http.StatusNotFound {
+				t.Fatalf("Router() = %d, %v", response.StatusCode, err)
+			}
+
+			got := response.Headers[requestIDHeader]
+			if tt.adopted && got != tt.requestID {
+				t.Errorf("X-Request-Id = %q, want %q", got, tt.requestID)
+			}
+			if !tt.adopted && (got == tt.requestID || !requestIDPattern.MatchString(got)) {
+				t.Errorf("X-Request-Id = %q, want a generated ID", got)
+			}
+
+			var document ErrorDocument
+			if err := json.Unmarshal([]byte(response.Body), &document); err != nil || document.RequestID != got {
+				t.Errorf("the error document has the request ID %q, the header %q", document.RequestID, got)
+			}
+		})
+	}
+}
+
+func TestRequestIDDoesNotModifySharedResponses(t *testing.T) {
+	handler := withRequestID(func(context.Context, events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return NotFoundResponse, nil
+	})
+	if _, err := handler(context.Background(), events.APIGatewayProxyRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := NotFoundResponse.Headers[requestIDHeader]; ok {
+		t.Errorf("the request ID was set on the shared NotFoundResponse")
+	}
+}
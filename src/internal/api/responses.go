@@ -0,0 +1,172 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/opentofu/registry/internal/github"
+	"github.com/opentofu/registry/internal/platform"
+)
+
+// errorDocsURL documents the error codes, with a section for each code.
+const errorDocsURL = "https://github.com/opentofu/registry/blob/main/docs/errors.md"
+
+// Error codes of failures specific to the registry. Other failures use the code derived from their status, such as
+// `not_found` or `internal_server_error`. The codes are stable, clients and documentation can rely on them.
+const (
+	ErrCodeRouteNotFound        = "route_not_found"
+	ErrCodeVersionQuarantined   = "version_quarantined"
+	ErrCodeVersionYanked        = "version_yanked"
+	ErrCodeReleaseNotFound      = "release_not_found"
+	ErrCodeAssetNotFound        = "asset_not_found"
+	ErrCodeSHASumsNotFound      = "shasums_not_found"
+	ErrCodeSignatureMismatch    = "signature_mismatch"
+	ErrCodePlatformNotAvailable = "platform_not_available"
+	ErrCodeMaintenance          = "maintenance"
+	ErrCodeVersionNotFound      = "version_not_found"
+	ErrCodeUpstreamRateLimited  = "upstream_rate_limited"
+	ErrCodeUpstreamUnavailable  = "upstream_unavailable"
+	ErrCodeRateLimited          = "rate_limited"
+)
+
+// ErrorDocument is the envelope of the errors of every failed request, `{"errors":[{"code":"...","detail":"..."}]}`.
+// The request ID helps whoever is debugging the failure find it in the logs.
+type ErrorDocument struct {
+	Errors    []ErrorObject `json:"errors"`
+	RequestID string        `json:"request_id,omitempty"`
+}
+
+// ErrorObject is a single error of an ErrorDocument. Clients can rely on the code, the detail is meant for people.
+type ErrorObject struct {
+	Code    string `json:"code"`
+	Detail  string `json:"detail"`
+	DocsURL string `json:"docs_url,omitempty"`
+	// Platform explains why a platform of a version cannot be downloaded.
+	Platform *PlatformAvailability `json:"platform,omitempty"`
+}
+
+// newErrorObject returns the error with the given code, linked to its documentation.
+func newErrorObject(code string, detail string) ErrorObject {
+	return ErrorObject{Code: code, Detail: detail, DocsURL: errorDocsURL + "#" + code}
+}
+
+// PlatformAvailability tells why the requested platform of a version is not available, see the types.Platform*
+// reasons, and which platforms are.
+type PlatformAvailability struct {
+	Requested platform.Platform   `json:"requested"`
+	Reason    string              `json:"reason"`
+	Available []platform.Platform `json:"available"`
+}
+
+//nolint:gochecknoglobals // This should be treated as a constant.
+var NotFoundResponse = errorResponse(http.StatusNotFound, "", "not found")
+
+//nolint:gochecknoglobals // This should be treated as a constant.
+var UnauthorizedResponse = errorResponse(http.StatusUnauthorized, "", "unauthorized")
+
+// errorResponse returns an error document with the given status. The code defaults to the one of the status.
+func errorResponse(statusCode int, code string, message string) events.APIGatewayProxyResponse {
+	if code == "" {
+		code = statusErrorCode(statusCode)
+	}
+	body, _ := json.Marshal(ErrorDocument{Errors: []ErrorObject{newErrorObject(code, message)}})
+	return events.APIGatewayProxyResponse{StatusCode: statusCode, Body: string(body)}
+}
+
+// versionNotFoundResponse returns the error document for a version the provider or module does not have.
+func versionNotFoundResponse(version string) events.APIGatewayProxyResponse {
+	return errorResponse(http.StatusNotFound, ErrCodeVersionNotFound, fmt.Sprintf("version %s not found", version))
+}
+
+// platformResponse returns the error document for a platform of a version which is not available.
+func platformResponse(code string, message string, availability PlatformAvailability) events.APIGatewayProxyResponse {
+	if availability.Available == nil {
+		availability.Available = []platform.Platform{}
+	}
+	errorObject := newErrorObject(code, message)
+	errorObject.Platform = &availability
+	body, _ := json.Marshal(ErrorDocument{Errors: []ErrorObject{errorObject}})
+	return events.APIGatewayProxyResponse{StatusCode: http.StatusNotFound, Body: string(body)}
+}
+
+// failedResponse returns the error document of a request whose handler failed with err. Failures of the services the
+// registry depends on, such as GitHub, are told apart from the registry's own, so that clients know to retry later.
+func failedResponse(response events.APIGatewayProxyResponse, err error) events.APIGatewayProxyResponse {
+	if wait, ok := github.RateLimited(err); ok {
+		response = errorResponse(http.StatusServiceUnavailable, ErrCodeUpstreamRateLimited, "GitHub is rate limiting the registry, try again later")
+		if wait > 0 {
+			response.Headers = map[string]string{"Retry-After": strconv.Itoa(int(math.Ceil(wait.Seconds())))}
+		}
+		return response
+	}
+	if github.Unavailable(err) {
+		return errorResponse(http.StatusBadGateway, ErrCodeUpstreamUnavailable, "a service the registry depends on, such as GitHub, is unavailable, try again later")
+	}
+	if response.StatusCode < http.StatusInternalServerError {
+		return errorResponse(http.StatusInternalServerError, "", "internal server error")
+	}
+	return response
+}
+
+// methodNotAllowedResponse returns the error document for a method the route does not support, listing the ones it
+// does in the Allow header.
+func methodNotAllowedResponse(allow string) events.APIGatewayProxyResponse {
+	response := errorResponse(http.StatusMethodNotAllowed, "", "method not allowed")
+	response.Headers = map[string]string{"Allow": allow}
+	return response
+}
+
+func badRequestResponse(message string) events.APIGatewayProxyResponse {
+	return errorResponse(http.StatusBadRequest, "", message)
+}
+
+// statusErrorCode returns the error code of a status, its text in snake case, e.g. `method_not_allowed`.
+func statusErrorCode(statusCode int) string {
+	return strings.ReplaceAll(strings.ToLower(http.StatusText(statusCode)), " ", "_")
+}
+
+// withErrorDocument makes sure error responses are a JSON error document, as some clients parse the body of failed
+// requests, and adds the request ID to it. Handlers returning an error status without a body get the generic
+// document for the status.
+func withErrorDocument(response events.APIGatewayProxyResponse, requestID string) events.APIGatewayProxyResponse {
+	if response.StatusCode < http.StatusBadRequest {
+		return response
+	}
+
+	var document ErrorDocument
+	if response.Body == "" {
+		document = ErrorDocument{Errors: []ErrorObject{{Detail: http.StatusText(response.StatusCode)}}}
+	} else if err := json.Unmarshal([]byte(response.Body), &document); err != nil || len(document.Errors) == 0 {
+		// not an error document, leave the body alone
+		document = ErrorDocument{}
+	}
+	if len(document.Errors) > 0 {
+		for i := range document.Errors {
+			if document.Errors[i].Code == "" {
+				document.Errors[i].Code = statusErrorCode(response.StatusCode)
+			}
+			if document.Errors[i].DocsURL == "" {
+				document.Errors[i].DocsURL = errorDocsURL + "#" + document.Errors[i].Code
+			}
+		}
+		document.RequestID = requestID
+		body, _ := json.Marshal(document)
+		response.Body = string(body)
+	}
+
+	// the headers of the shared responses must not be modified, so set the content type on a copy
+	if _, ok := response.Headers["Content-Type"]; !ok {
+		headers := make(map[string]string, len(response.Headers)+1)
+		for name, value := range response.Headers {
+			headers[name] = value
+		}
+		headers["Content-Type"] = "application/json"
+		response.Headers = headers
+	}
+	return response
+}
@@ -0,0 +1,185 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	gogithub "github.com/google/go-github/v54/github"
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/providers/types"
+)
+
+func TestRouterUnknownRoute(t *testing.T) {
+	req := events.APIGatewayProxyRequest{HTTPMethod: http.MethodGet, Path: "/v1/unknown"}
+	req.RequestContext.RequestID = "abc-123"
+	response, err := Router(config.Config{})(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Router() error = %v", err)
+	}
+	if response.StatusCode != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", response.StatusCode)
+	}
+	want := `{"errors":[{"code":"route_not_found","detail":"no route found for path /v1/unknown","docs_url":"https://github.com/opentofu/registry/blob/main/docs/errors.md#route_not_found"}],"request_id":"abc-123"}`
+	if response.Body != want {
+		t.Errorf("body = %q", response.Body)
+	}
+	if got := response.Headers["Content-Type"]; got != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got)
+	}
+}
+
+func TestWithErrorDocument(t *testing.T) {
+	response := withErrorDocument(events.APIGatewayProxyResponse{StatusCode: http.StatusMethodNotAllowed}, "abc-123")
+	want := `{"errors":[{"code":"method_not_allowed","detail":"Method Not Allowed","docs_url":"https://github.com/opentofu/registry/blob/main/docs/errors.md#method_not_allowed"}],"request_id":"abc-123"}`
+	if response.Body != want || response.Headers["Content-Type"] != "application/json" {
+		t.Errorf("unexpected response %+v", response)
+	}
+
+	response = withErrorDocument(NotFoundResponse, "abc-123")
+	want = `{"errors":[{"code":"not_found","detail":"not found","docs_url":"https://github.com/opentofu/registry/blob/main/docs/errors.md#not_found"}],"request_id":"abc-123"}`
+	if response.Body != want || response.Headers["Content-Type"] != "application/json" {
+		t.Errorf("unexpected response %+v", response)
+	}
+	if NotFoundResponse.Headers != nil {
+		t.Error("withErrorDocument() modified the shared NotFoundResponse")
+	}
+
+	response = withErrorDocument(events.APIGatewayProxyResponse{StatusCode: http.StatusBadGateway, Body: "upstream failed"}, "abc-123")
+	if response.Body != "upstream failed" {
+		t.Errorf("withErrorDocument() replaced a body which is not an error document: %q", response.Body)
+	}
+
+	response = withErrorDocument(events.APIGatewayProxyResponse{StatusCode: http.StatusOK, Body: "ok"}, "abc-123")
+	if response.Headers != nil {
+		t.Errorf("withErrorDocument() modified a successful response: %+v", response)
+	}
+}
+
+func TestFailedResponse(t *testing.T) {
+	reset := time.Now().Add(time.Minute)
+	tests := []struct {
+		name       string
+		response   events.APIGatewayProxyResponse
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{
+			name:       "rate limited",
+			err:        fmt.Errorf("listing releases: %w", &gogithub.RateLimitError{Rate: gogithub.Rate{Reset: gogithub.Timestamp{Time: reset}}}),
+			wantStatus: http.StatusServiceUnavailable,
+			wantCode:   ErrCodeUpstreamRateLimited,
+		},
+		{
+			name:       "unavailable",
+			response:   events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError},
+			err:        &url.Error{Op: "Get", URL: "https://api.github.com/", Err: errors.New("connection reset")},
+			wantStatus: http.StatusBadGateway,
+			wantCode:   ErrCodeUpstreamUnavailable,
+		},
+		{name: "registry failure", err: errors.New("boom"), wantStatus: http.StatusInternalServerError, wantCode: "internal_server_error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := withErrorDocument(failedResponse(tt.response, tt.err), "abc-123")
+			if response.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", response.StatusCode, tt.wantStatus)
+			}
+			var document ErrorDocument
+			if err := json.Unmarshal([]byte(response.Body), &document); err != nil || len(document.Errors) != 1 || document.Errors[0].Code != tt.wantCode {
+				t.Errorf("body = %s, want an error with code %s", response.Body, tt.wantCode)
+			}
+			if strings.Contains(response.Body, tt.err.Error()) {
+				t.Errorf("the error leaked into the body: %s", response.Body)
+			}
+		})
+	}
+
+	response := failedResponse(events.APIGatewayProxyResponse{}, &gogithub.RateLimitError{Rate: gogithub.Rate{Reset: gogithub.Timestamp{Time: reset}}})
+	if retryAfter, err := strconv.Atoi(response.Headers["Retry-After"]); err != nil || retryAfter < 1 || retryAfter > 60 {
+		t.Errorf("Retry-After = %q, want the seconds until the rate limit resets", response.Headers["Retry-After"])
+	}
+}
+
+func TestWithEffectiveAddress(t *testing.T) {
+	cfg := config.Config{ProviderRedirects: map[string]string{"old": "middle", "middle": "new"}}
+
+	response := withEffectiveAddress(cfg, map[string]string{"namespace": "old", "type": "foo"}, NotFoundResponse)
+	if got := response.Headers[effectiveAddressHeader]; got != "new/foo" {
+		t.Errorf("%s = %q, want new/foo", effectiveAddressHeader, got)
+	}
+	if _, ok := NotFoundResponse.Headers[effectiveAddressHeader]; ok {
+		t.Errorf("the shared response was modified")
+	}
+
+	response = withEffectiveAddress(cfg, map[string]string{"namespace": "other", "type": "foo"}, events.APIGatewayProxyResponse{})
+	if _, ok := response.Headers[effectiveAddressHeader]; ok {
+		t.Errorf("expected no header without a redirect")
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	lastUpdated := time.Date(2023, 9, 1, 12, 0, 0, 0, time.UTC)
+	document := &types.CacheItem{LastUpdated: lastUpdated, Versions: types.VersionList{{Version: "1.0.0"}}}
+	etag := documentETag(document, "")
+	if etag == documentETag(document, "jsonapi") {
+		t.Errorf("the representations of a document have the same ETag %s", etag)
+	}
+	updated := &types.CacheItem{LastUpdated: lastUpdated.Add(time.Minute), Versions: document.Versions}
+	if etag == documentETag(updated, "") {
+		t.Errorf("the ETag %s did not change with the document", etag)
+	}
+	if etag != documentETag(document, "", []string{}...) {
+		t.Errorf("the ETag changed without inputs")
+	}
+	warned := documentETag(document, "", "deprecated", "KEY1")
+	if warned == etag || warned == documentETag(document, "", "deprecated", "KEY2") || warned == documentETag(document, "", "deprecatedK", "EY1") {
+		t.Errorf("the ETag %s did not change with the inputs", warned)
+	}
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    bool
+	}{
+		{name: "no conditions", headers: map[string]string{}},
+		{name: "matching ETag", headers: map[string]string{"If-None-Match": `"other", ` + etag}, want: true},
+		{name: "strong form of the ETag", headers: map[string]string{"if-none-match": strings.TrimPrefix(etag, "W/")}, want: true},
+		{name: "any", headers: map[string]string{"If-None-Match": "*"}, want: true},
+		{name: "other ETag", headers: map[string]string{"If-None-Match": `W/"other"`}},
+		{name: "not modified since", headers: map[string]string{"If-Modified-Since": lastUpdated.Format(http.TimeFormat)}, want: true},
+		{
+			name:    "ETag over date",
+			headers: map[string]string{"If-None-Match": `W/"other"`, "If-Modified-Since": lastUpdated.Format(http.TimeFormat)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := events.APIGatewayProxyRequest{Headers: tt.headers}
+			if got := notModified(req, etag, lastUpdated); got != tt.want {
+				t.Errorf("notModified() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithCacheHeaders(t *testing.T) {
+	response := withCacheHeaders(events.APIGatewayProxyResponse{StatusCode: http.StatusOK}, `W/"abc"`, 5*time.Minute)
+	if response.Headers["ETag"] != `W/"abc"` || response.Headers["Cache-Control"] != "public, max-age=300" {
+		t.Errorf("headers = %v", response.Headers)
+	}
+
+	response = withCacheHeaders(NotFoundResponse, `W/"abc"`, 5*time.Minute)
+	if _, ok := response.Headers["Cache-Control"]; ok {
+		t.Errorf("a failed response got the caching headers %v", response.Headers)
+	}
+}
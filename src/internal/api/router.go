@@ -0,0 +1,380 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/faults"
+	"github.com/opentofu/registry/internal/github"
+	"github.com/opentofu/registry/internal/logging"
+	"github.com/opentofu/registry/internal/metrics"
+	"golang.org/x/exp/slog"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+)
+
+// NewConfigBuilder returns a config builder including everything the API serves.
+func NewConfigBuilder() *config.Builder {
+	return config.NewBuilder(config.WithProviderRedirects(), config.WithDeprecations(), config.WithAdminAPI(), config.WithProviderAliases(), config.WithModuleRewrites(), config.WithOCIProviders(), config.WithGitLab(), config.WithQuarantine(), config.WithGitHubAppAuth(), config.WithServiceDiscovery())
+}
+
+// LambdaFunc handles an API Gateway proxy request. The handlers are shared between the Lambda build and the HTTP
+// server, which converts its requests to this form.
+type LambdaFunc func(context.Context, events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error)
+
+// Route is a path pattern such as `/v1/providers/{namespace}/{type}/versions`, whose `{name}` segments match any single
+// path segment and are passed to the handler as path parameters, and the HTTP methods the handler accepts. HEAD is
+// accepted wherever GET is.
+type Route struct {
+	Pattern string
+	Methods []string
+	Handler LambdaFunc
+}
+
+func getRoute(pattern string, handler LambdaFunc) Route {
+	return Route{Pattern: pattern, Methods: []string{http.MethodGet}, Handler: handler}
+}
+
+func newRoute(pattern string, handler LambdaFunc, methods ...string) Route {
+	return Route{Pattern: pattern, Methods: methods, Handler: handler}
+}
+
+// Allows returns true if the route accepts the given HTTP method.
+func (r Route) Allows(method string) bool {
+	if method == http.MethodHead {
+		method = http.MethodGet
+	}
+	for _, m := range r.Methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// allowHeader returns the methods of the route, as listed in the Allow header.
+func (r Route) allowHeader() string {
+	methods := append([]string{}, r.Methods...)
+	if r.Allows(http.MethodGet) {
+		methods = append(methods, http.MethodHead)
+	}
+	return strings.Join(methods, ", ")
+}
+
+// match returns the path parameters of the path if it matches the pattern of the route.
+func (r Route) match(path string) (map[string]string, bool) {
+	patternSegments := strings.Split(strings.TrimPrefix(r.Pattern, "/"), "/")
+	pathSegments := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	if len(patternSegments) != len(pathSegments) {
+		return nil, false
+	}
+
+	params := make(map[string]string)
+	for i, segment := range patternSegments {
+		if name, ok := pathParamName(segment); ok {
+			if pathSegments[i] == "" {
+				return nil, false
+			}
+			params[name] = pathSegments[i]
+			continue
+		}
+		if segment != pathSegments[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// pathParamName returns the name of a `{name}` pattern segment.
+func pathParamName(segment string) (string, bool) {
+	if !strings.HasPrefix(segment, "{") || !strings.HasSuffix(segment, "}") {
+		return "", false
+	}
+	return segment[1 : len(segment)-1], true
+}
+
+// RouteHandlers returns the routes of the API. They are tried in order and the first matching one is used, so a route
+// with a static segment must come before a route with a parameter in its place.
+func RouteHandlers(config config.Config) []Route {
+	return []Route{
+		// Download provider version
+		getRoute("/v1/providers/{namespace}/{type}/{version}/download/{os}/{arch}", downloadProviderVersion(config)),
+
+		// Search providers
+		// `/v1/providers/search?q={query}`
+		getRoute("/v1/providers/search", searchProviders(config)),
+
+		// List provider versions
+		getRoute("/v1/providers/{namespace}/{type}/versions", listProviderVersions(config)),
+
+		// Latest stable version of a provider
+		getRoute("/v1/providers/{namespace}/{type}/latest", latestProviderVersion(config)),
+
+		// Download statistics of a provider
+		getRoute("/v1/providers/{namespace}/{type}/downloads/summary", providerDownloadSummary(config)),
+
+		// Search modules
+		// `/v1/modules/search?q={query}&namespace={namespace}&provider={system}`
+		getRoute("/v1/modules/search", searchModules(config)),
+
+		// List module versions
+		getRoute("/v1/modules/{namespace}/{name}/{system}/versions", listModuleVersions(config)),
+
+		// Latest stable version of a module
+		getRoute("/v1/modules/{namespace}/{name}/{system}", latestModuleVersion(config)),
+
+		// Download module version
+		getRoute("/v1/modules/{namespace}/{name}/{system}/{version}/download", downloadModuleVersion(config)),
+
+		// Inputs, outputs, submodules and examples of a module version
+		getRoute("/v1/modules/{namespace}/{name}/{system}/{version}", getModuleVersionMetadata(config)),
+
+		// .well-known/terraform.json
+		getRoute("/.well-known/terraform.json", terraformWellKnownMetadataHandler(config)),
+
+		// Prometheus metrics, only reachable in server mode as API Gateway does not route this path
+		getRoute("/metrics", metricsHandler()),
+
+		// Read-only GraphQL API over the registry metadata
+		newRoute("/v2/graphql", graphqlHandler(config), http.MethodGet, http.MethodPost),
+
+		// List versions for several providers at once
+		newRoute("/v2/providers/versions:batch", batchProviderVersions(config), http.MethodPost),
+
+		// List provider versions, filtered by protocol and platform
+		// `/v2/providers/{namespace}/{type}/versions?protocol={protocol}&platform={os}_{arch}`
+		getRoute("/v2/providers/{namespace}/{type}/versions", listProviderVersionsV2(config)),
+
+		// Watch a provider for new versions
+		// `/v2/providers/{namespace}/{type}/versions/watch?since={cursor}`
+		getRoute("/v2/providers/{namespace}/{type}/versions/watch", watchProviderVersions(config)),
+
+		// Checksums of a provider version
+		getRoute("/v2/providers/{namespace}/{type}/{version}/checksums", providerChecksums(config)),
+
+		// Statistics about a namespace
+		getRoute("/v2/namespaces/{namespace}", namespaceStats(config)),
+
+		// Most downloaded providers and modules
+		// `/v2/providers/top?period={day|week|month}&limit={limit}`
+		getRoute("/v2/providers/top", topProviders(config)),
+		getRoute("/v2/modules/top", topModules(config)),
+
+		// List provider aliases
+		getRoute("/v2/provider-aliases", listProviderAliases(config)),
+
+		// Manage a provider alias
+		newRoute("/admin/v1/provider-aliases/{namespace}/{type}", adminProviderAlias(config), http.MethodGet, http.MethodPut, http.MethodDelete),
+
+		// Manage provider deprecations
+		newRoute("/admin/v1/deprecations/providers/{namespace}/{type}", adminProviderDeprecations(config), http.MethodGet, http.MethodPut, http.MethodDelete),
+
+		// Manage module deprecations
+		newRoute("/admin/v1/deprecations/modules/{namespace}/{name}/{system}", adminModuleDeprecations(config), http.MethodGet, http.MethodPut, http.MethodDelete),
+
+		// List the quarantines of all providers
+		getRoute("/admin/v1/quarantine", adminListQuarantines(config)),
+
+		// Manage quarantined provider versions
+		newRoute("/admin/v1/quarantine/providers/{namespace}/{type}", adminProviderQuarantine(config), http.MethodGet, http.MethodPut, http.MethodDelete),
+
+		// Manage the GPG keys of a namespace
+		newRoute("/admin/v1/gpg-keys/{namespace}", adminNamespaceKeys(config), http.MethodGet, http.MethodPost, http.MethodDelete),
+
+		// List the repositories mapped to providers and modules
+		getRoute("/admin/v1/repository-mappings", adminListRepositoryMappings(config)),
+
+		// Manage the repository mapped to a provider
+		newRoute("/admin/v1/repository-mappings/providers/{namespace}/{type}", adminProviderRepositoryMapping(config), http.MethodGet, http.MethodPut, http.MethodDelete),
+
+		// Manage the repository mapped to a module
+		newRoute("/admin/v1/repository-mappings/modules/{namespace}/{name}/{system}", adminModuleRepositoryMapping(config), http.MethodGet, http.MethodPut, http.MethodDelete),
+
+		// List the provider namespace redirects
+		getRoute("/admin/v1/provider-redirects", adminListProviderRedirects(config)),
+
+		// Manage the redirect of a provider namespace
+		newRoute("/admin/v1/provider-redirects/{namespace}", adminProviderRedirect(config), http.MethodGet, http.MethodPut, http.MethodDelete),
+
+		// Inspect or delete the cached versions of a provider
+		newRoute("/admin/v1/cache/providers/{namespace}/{type}", adminProviderCache(config), http.MethodGet, http.MethodDelete),
+
+		// Refresh the cached versions of a provider right away
+		newRoute("/admin/v1/cache/providers/{namespace}/{type}/refresh", adminRefreshProvider(config), http.MethodPost),
+
+		// Yank a cached provider version, or serve it again
+		newRoute("/admin/v1/cache/providers/{namespace}/{type}/{version}/yank", adminYankProviderVersion(config), http.MethodPut, http.MethodDelete),
+
+		// Inspect or delete the cached versions of a module
+		newRoute("/admin/v1/cache/modules/{namespace}/{name}/{system}", adminModuleCache(config), http.MethodGet, http.MethodDelete),
+
+		// Refresh the cached versions of a module right away
+		newRoute("/admin/v1/cache/modules/{namespace}/{name}/{system}/refresh", adminRefreshModule(config), http.MethodPost),
+
+		// Terraform Cloud private registry compatible paths
+		// `/api/registry/v1/...` mirrors the v1 registry protocol
+		getRoute("/api/registry/v1/providers/{namespace}/{type}/{version}/download/{os}/{arch}", downloadProviderVersion(config)),
+		getRoute("/api/registry/v1/providers/{namespace}/{type}/versions", listProviderVersions(config)),
+		getRoute("/api/registry/v1/modules/{namespace}/{name}/{system}/versions", listModuleVersions(config)),
+		getRoute("/api/registry/v1/modules/{namespace}/{name}/{system}/{version}/download", downloadModuleVersion(config)),
+
+		// `/api/v2/organizations/{organization}/registry-providers/{registry}/{namespace}/{type}`
+		getRoute("/api/v2/organizations/{organization}/registry-providers/{registry}/{namespace}/{type}", tfcProvider(config)),
+		getRoute("/api/v2/organizations/{organization}/registry-providers/{registry}/{namespace}/{type}/versions", tfcProviderVersions(config)),
+		getRoute("/api/v2/organizations/{organization}/registry-providers/{registry}/{namespace}/{type}/versions/{version}/platforms", tfcProviderPlatforms(config)),
+		getRoute("/api/v2/organizations/{organization}/registry-modules/{registry}/{namespace}/{name}/{system}", tfcModule(config)),
+	}
+}
+
+// matchRoute finds the first route matching the given path, nil if there is none. The `{name}` segments of the route
+// are returned as path parameters, so that handlers behind a greedy API Gateway resource still receive them.
+func matchRoute(routes []Route, path string) (*Route, map[string]string) {
+	for i := range routes {
+		if params, ok := routes[i].match(path); ok {
+			return &routes[i], params
+		}
+	}
+	return nil, nil
+}
+
+// Router routes the requests to the handlers of the API, with the CORS headers of config.CORSAllowedOrigins and the
+// X-Request-Id header.
+func Router(config config.Config) LambdaFunc {
+	return withRequestID(withCORS(config, routeRequest(config)))
+}
+
+func routeRequest(config config.Config) LambdaFunc {
+	return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		ctx, segment := xray.BeginSubsegment(ctx, "registry.handle")
+		xray.AddAnnotation(ctx, "request_id", req.RequestContext.RequestID)
+
+		// the logger is carried by the context of the request rather than made the default, the server serves several
+		// requests at once
+		logger := slog.Default().
+			With("request_id", req.RequestContext.RequestID).
+			With("path", req.Path)
+		// the lines the Lambda runtime logs for the invocation, e.g. its timeouts, carry the ID of the invocation
+		if lambdaContext, ok := lambdacontext.FromContext(ctx); ok {
+			logger = logger.With("lambda_request_id", lambdaContext.AwsRequestID)
+		}
+		ctx = logging.WithLogger(ctx, logger)
+
+		// each tenant hostname is served as a separate logical registry
+		config := config.ForHost(getHeader(req, "Host"))
+		if config.TenantName != "" {
+			ctx = logging.With(ctx, "tenant", config.TenantName)
+		}
+
+		// the bearer token of the request decides the private providers it can read
+		config = config.WithReadToken(bearerToken(req))
+
+		// in passthrough mode, a client bringing its own token is served what that token can read on GitHub
+		token := clientGithubToken(config, req)
+		if token != "" {
+			ctx = logging.With(ctx, "github_token", "client")
+			config = config.WithClientToken(token)
+		}
+
+		// the GitHub clients only inject faults into requests whose context carries the injector
+		ctx = faults.WithInjector(ctx, config.Faults)
+		// the releases are listed with the REST API while the GraphQL API is failing
+		ctx = github.WithRESTFallback(ctx, config.GithubClient)
+
+		matched, params := matchRoute(RouteHandlers(config), req.Path)
+		if matched == nil {
+			logging.FromContext(ctx).Error("No route handler found for path")
+			segment.Close(nil)
+			metrics.Inc(metrics.HTTPRequests, metrics.Labels{"route": "unmatched", "status": strconv.Itoa(http.StatusNotFound)})
+			return withErrorDocument(errorResponse(http.StatusNotFound, ErrCodeRouteNotFound, fmt.Sprintf("no route found for path %s", req.Path)), req.RequestContext.RequestID), nil
+		}
+		handler, route := matched.Handler, matched.Pattern
+
+		if !matched.Allows(req.HTTPMethod) {
+			logging.FromContext(ctx).Warn("Rejecting request with a method the route does not support", "method", req.HTTPMethod)
+			segment.Close(nil)
+			metrics.Inc(metrics.HTTPRequests, metrics.Labels{"route": route, "status": strconv.Itoa(http.StatusMethodNotAllowed)})
+			return withErrorDocument(methodNotAllowedResponse(matched.allowHeader()), req.RequestContext.RequestID), nil
+		}
+
+		if err := validatePathParams(params); err != nil {
+			logging.FromContext(ctx).Warn("Rejecting request with invalid path parameters", "error", err)
+			segment.Close(nil)
+			metrics.Inc(metrics.HTTPRequests, metrics.Labels{"route": route, "status": strconv.Itoa(http.StatusBadRequest)})
+			return withErrorDocument(badRequestResponse(err.Error()), req.RequestContext.RequestID), nil
+		}
+
+		// in maintenance mode the registry is read-only
+		if config.MaintenanceMode && isWriteRequest(req) {
+			segment.Close(nil)
+			metrics.Inc(metrics.HTTPRequests, metrics.Labels{"route": route, "status": strconv.Itoa(http.StatusServiceUnavailable)})
+			return withErrorDocument(maintenanceResponse(), req.RequestContext.RequestID), nil
+		}
+
+		// each client is rate limited before any handler can spend the GitHub quota of the registry on it
+		if response, throttled := throttleRequest(ctx, config, req); throttled {
+			segment.Close(nil)
+			metrics.Inc(metrics.HTTPRequests, metrics.Labels{"route": route, "status": strconv.Itoa(response.StatusCode)})
+			return withErrorDocument(response, req.RequestContext.RequestID), nil
+		}
+
+		// private providers are served as if they did not exist to the requests without a token allowed to read them
+		private := false
+		if namespace, providerType := params["namespace"], params["type"]; namespace != "" && providerType != "" && !strings.HasPrefix(req.Path, "/admin/") {
+			if !config.CanReadProvider(namespace, providerType) {
+				logging.FromContext(ctx).Info("Rejecting request for a private provider")
+				segment.Close(nil)
+				metrics.Inc(metrics.HTTPRequests, metrics.Labels{"route": route, "status": strconv.Itoa(http.StatusNotFound)})
+				return withErrorDocument(withPrivateCaching(NotFoundResponse), req.RequestContext.RequestID), nil
+			}
+			private = config.ProviderIsPrivate(namespace, providerType)
+		}
+
+		// API Gateway populates the path parameters for explicitly defined resources, only fill in the missing ones.
+		if req.PathParameters == nil {
+			req.PathParameters = make(map[string]string)
+		}
+		for name, value := range params {
+			if _, ok := req.PathParameters[name]; !ok {
+				req.PathParameters[name] = value
+			}
+		}
+
+		// With binary media types enabled, API Gateway hands us request bodies base64 encoded.
+		if req.IsBase64Encoded {
+			body, err := base64.StdEncoding.DecodeString(req.Body)
+			if err != nil {
+				segment.Close(nil)
+				return withErrorDocument(badRequestResponse("invalid base64 encoded body"), req.RequestContext.RequestID), nil
+			}
+			req.Body = string(body)
+			req.IsBase64Encoded = false
+		}
+
+		start := time.Now()
+		response, err := handler(ctx, req)
+		segment.Close(err)
+		if err != nil {
+			// failing the invocation would make API Gateway answer with a bare 502, answer with an error document instead
+			logging.FromContext(ctx).Error("Handler failed", "error", err)
+			response = failedResponse(response, err)
+		}
+
+		metrics.Observe(metrics.HTTPRequestDuration, metrics.Labels{"route": route}, time.Since(start).Seconds())
+		metrics.Inc(metrics.HTTPRequests, metrics.Labels{"route": route, "status": strconv.Itoa(response.StatusCode)})
+
+		logging.FromContext(ctx).Info("Returning response", "status_code", response.StatusCode)
+		response = withEffectiveAddress(config, params, response)
+		if token != "" || private {
+			response = withPrivateCaching(response)
+		}
+		return encodeResponse(req, withErrorDocument(response, req.RequestContext.RequestID)), nil
+	}
+}
@@ -0,0 +1,182 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/providers/types"
+	"golang.org/x/exp/slog"
+)
+
+func TestMatchRoute(t *testing.T) {
+	routes := RouteHandlers(config.Config{})
+	tests := []struct {
+		path        string
+		wantPattern string
+		wantParams  map[string]string
+	}{
+		{
+			path:        "/v1/providers/hashicorp/aws/5.0.0/download/linux/amd64",
+			wantPattern: "/v1/providers/{namespace}/{type}/{version}/download/{os}/{arch}",
+			wantParams:  map[string]string{"namespace": "hashicorp", "type": "aws", "version": "5.0.0", "os": "linux", "arch": "amd64"},
+		},
+		{path: "/v1/providers/search", wantPattern: "/v1/providers/search", wantParams: map[string]string{}},
+		{path: "/v2/providers/versions:batch", wantPattern: "/v2/providers/versions:batch", wantParams: map[string]string{}},
+		{
+			path:        "/v2/providers/hashicorp/aws/versions/watch",
+			wantPattern: "/v2/providers/{namespace}/{type}/versions/watch",
+			wantParams:  map[string]string{"namespace": "hashicorp", "type": "aws"},
+		},
+		{path: "/v1/providers/hashicorp/aws/versions/"},
+		{path: "/v1/providers//aws/versions"},
+		{path: "/v1/providers/hashicorp/aws"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			matched, params := matchRoute(routes, tt.path)
+			if tt.wantPattern == "" {
+				if matched != nil {
+					t.Errorf("matched %s, want no route", matched.Pattern)
+				}
+				return
+			}
+			if matched == nil || matched.Pattern != tt.wantPattern {
+				t.Fatalf("matched %+v, want %s", matched, tt.wantPattern)
+			}
+			if !reflect.DeepEqual(params, tt.wantParams) {
+				t.Errorf("params = %v, want %v", params, tt.wantParams)
+			}
+		})
+	}
+}
+
+func TestRouterMethodNotAllowed(t *testing.T) {
+	req := events.APIGatewayProxyRequest{HTTPMethod: http.MethodPost, Path: "/v1/providers/hashicorp/aws/versions"}
+	response, err := Router(config.Config{})(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Router() error = %v", err)
+	}
+	if response.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("status = %d, want 405", response.StatusCode)
+	}
+	if got := response.Headers["Allow"]; got != "GET, HEAD" {
+		t.Errorf("Allow = %q, want GET, HEAD", got)
+	}
+}
+
+func TestRouterLogsThroughContext(t *testing.T) {
+	var out bytes.Buffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&out, nil)))
+	t.Cleanup(func() { slog.SetDefault(defaultLogger) })
+	logger := slog.Default()
+
+	req := events.APIGatewayProxyRequest{HTTPMethod: http.MethodGet, Path: "/unknown"}
+	req.RequestContext.RequestID = "request-1"
+	if _, err := Router(config.Config{})(context.Background(), req); err != nil {
+		t.Fatalf("Router() error = %v", err)
+	}
+
+	if slog.Default() != logger {
+		t.Error("expected the default logger to be left as it is")
+	}
+	var line map[string]any
+	if err := json.NewDecoder(&out).Decode(&line); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if line["request_id"] != "request-1" || line["path"] != "/unknown" {
+		t.Errorf("expected the request to be logged with its attributes, got %v", line)
+	}
+}
+
+// lookupsProviderCache is a provider cache which records the keys it is asked for, and which knows the providers it
+// does not hold to be missing.
+type lookupsProviderCache struct {
+	itemsProviderCache
+	lookups *[]string
+}
+
+func (c lookupsProviderCache) GetItem(_ context.Context, key string) (*types.CacheItem, error) {
+	*c.lookups = append(*c.lookups, key)
+	if item, ok := c.items[key]; ok {
+		return item, nil
+	}
+	return &types.CacheItem{Provider: key, Metadata: types.ProviderMetadata{NotFound: true}, LastUpdated: time.Now()}, nil
+}
+
+func TestRouterTenantHosts(t *testing.T) {
+	var mainLookups, tenantLookups []string
+	newCache := func(lookups *[]string, version string, keys ...string) lookupsProviderCache {
+		cache := lookupsProviderCache{itemsProviderCache{items: make(map[string]*types.CacheItem)}, lookups}
+		for _, key := range keys {
+			cache.items[key] = &types.CacheItem{Provider: key, Versions: types.VersionList{{Version: version}}, LastUpdated: time.Now()}
+		}
+		return cache
+	}
+	cfg := config.Config{
+		ProviderVersionCache: newCache(&mainLookups, "1.0.0", "example/shared", "example/main"),
+		Tenants: map[string]*config.Tenant{
+			"internal.example.com": {
+				Name:                 "internal",
+				ProviderVersionCache: newCache(&tenantLookups, "2.0.0", "example/shared", "renamed/moved"),
+				ProviderRedirects:    map[string]string{"old": "renamed"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name              string
+		host              string
+		provider          string
+		wantVersion       string
+		wantMainLookups   []string
+		wantTenantLookups []string
+	}{
+		{name: "main registry", host: "registry.example.com", provider: "example/shared", wantVersion: "1.0.0", wantMainLookups: []string{"example/shared"}},
+		{name: "unknown host", host: "unknown.example.com", provider: "example/shared", wantVersion: "1.0.0", wantMainLookups: []string{"example/shared"}},
+		{name: "tenant", host: "internal.example.com", provider: "example/shared", wantVersion: "2.0.0", wantTenantLookups: []string{"example/shared"}},
+		{name: "tenant hostname case", host: "Internal.Example.com", provider: "example/shared", wantVersion: "2.0.0", wantTenantLookups: []string{"example/shared"}},
+		{name: "tenant redirects", host: "internal.example.com", provider: "old/moved", wantVersion: "2.0.0", wantTenantLookups: []string{"renamed/moved"}},
+		{name: "tenant redirects in main", host: "registry.example.com", provider: "old/moved", wantMainLookups: []string{"old/moved"}},
+		{name: "main provider in tenant", host: "internal.example.com", provider: "example/main", wantTenantLookups: []string{"example/main"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mainLookups, tenantLookups = nil, nil
+			req := events.APIGatewayProxyRequest{
+				HTTPMethod: http.MethodGet,
+				Path:       "/v1/providers/" + tt.provider + "/versions",
+				Headers:    map[string]string{"Host": tt.host},
+			}
+			response, err := Router(cfg)(context.Background(), req)
+			if err != nil {
+				t.Fatalf("Router() error = %v", err)
+			}
+
+			if tt.wantVersion == "" {
+				if response.StatusCode != http.StatusNotFound {
+					t.Errorf("status = %d, want %d", response.StatusCode, http.StatusNotFound)
+				}
+			} else {
+				var body ListProviderVersionsResponse
+				if err := json.Unmarshal([]byte(response.Body), &body); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if len(body.Versions) != 1 || body.Versions[0].Version != tt.wantVersion {
+					t.Errorf("versions = %+v, want %s", body.Versions, tt.wantVersion)
+				}
+			}
+			if !reflect.DeepEqual(mainLookups, tt.wantMainLookups) || !reflect.DeepEqual(tenantLookups, tt.wantTenantLookups) {
+				t.Errorf("looked up %v in the main cache and %v in the tenant one, want %v and %v", mainLookups, tenantLookups, tt.wantMainLookups, tt.wantTenantLookups)
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package api
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"golang.org/x/exp/slog"
+)
+
+// maxRequestBodySize matches the largest payload API Gateway accepts.
+const maxRequestBodySize = 10 << 20
+
+// errRequestTooLarge is returned for request bodies larger than maxRequestBodySize, which API Gateway rejects too.
+var errRequestTooLarge = fmt.Errorf("request body is larger than %d bytes", maxRequestBodySize)
+
+// NewHTTPHandler serves the given handler over plain HTTP, converting requests and responses the way API Gateway
+// does, so the server runs the exact handlers deployed to Lambda. X-Forwarded-For is only used to find the client's
+// address for requests coming from one of the trusted proxies.
+func NewHTTPHandler(handler LambdaFunc, trustedProxies []netip.Prefix) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, err := toProxyRequest(r, trustedProxies)
+		if errors.Is(err, errRequestTooLarge) {
+			writeProxyResponse(w, errorResponse(http.StatusRequestEntityTooLarge, "", err.Error()))
+			return
+		}
+		if err != nil {
+			writeProxyResponse(w, badRequestResponse(err.Error()))
+			return
+		}
+
+		response, err := handler(r.Context(), req)
+		if err != nil {
+			slog.Error("Handler returned an error", "path", r.URL.Path, "error", err)
+			// API Gateway turns Lambda errors into a 502, whatever response came with them
+			if response.StatusCode < http.StatusInternalServerError {
+				response = events.APIGatewayProxyResponse{StatusCode: http.StatusBadGateway}
+			}
+		}
+		writeProxyResponse(w, response)
+	})
+}
+
+func toProxyRequest(r *http.Request, trustedProxies []netip.Prefix) (events.APIGatewayProxyRequest, error) {
+	// one byte more than the limit is read to tell a body over it from one exactly at it
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestBodySize+1))
+	if err != nil {
+		return events.APIGatewayProxyRequest{}, fmt.Errorf("could not read request body: %w", err)
+	}
+	if len(body) > maxRequestBodySize {
+		return events.APIGatewayProxyRequest{}, errRequestTooLarge
+	}
+
+	req := events.APIGatewayProxyRequest{
+		Path:                            r.URL.Path,
+		HTTPMethod:                      r.Method,
+		Headers:                         make(map[string]string, len(r.Header)+1),
+		MultiValueHeaders:               make(map[string][]string, len(r.Header)+1),
+		QueryStringParameters:           make(map[string]string),
+		MultiValueQueryStringParameters: make(map[string][]string),
+		Body:                            string(body),
+		RequestContext: events.APIGatewayProxyRequestContext{
+			RequestID:  r.Header.Get("X-Request-Id"),
+			HTTPMethod: r.Method,
+			Path:       r.URL.Path,
+			Identity:   events.APIGatewayRequestIdentity{SourceIP: sourceIP(r, trustedProxies)},
+		},
+	}
+	if req.RequestContext.RequestID == "" {
+		req.RequestContext.RequestID = fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+
+	// like API Gateway, the single value maps hold the last value of each header and query parameter
+	for name, values := range r.Header {
+		req.Headers[name] = values[len(values)-1]
+		req.MultiValueHeaders[name] = values
+	}
+	req.Headers["Host"] = r.Host
+	req.MultiValueHeaders["Host"] = []string{r.Host}
+
+	for name, values := range r.URL.Query() {
+		req.QueryStringParameters[name] = values[len(values)-1]
+		req.MultiValueQueryStringParameters[name] = values
+	}
+
+	return req, nil
+}
+
+// ParseTrustedProxies parses a comma separated list of the addresses or CIDR ranges of the proxies in front of the
+// server.
+func ParseTrustedProxies(value string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			addr, err := netip.ParseAddr(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// sourceIP returns the address of the client, which becomes its throttling key. Anyone can send X-Forwarded-For, so
+// it is only read when the connection comes from a trusted proxy, and then from the right: each proxy appends the
+// address it got the request from, and the right-most hop which is not a trusted proxy is the last one we can vouch
+// for.
+func sourceIP(r *http.Request, trustedProxies []netip.Prefix) string {
+	remote := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		remote = host
+	}
+	if !isTrustedProxy(remote, trustedProxies) {
+		return remote
+	}
+
+	var hops []string
+	for _, value := range r.Header.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(value, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		if !isTrustedProxy(hops[i], trustedProxies) {
+			return hops[i]
+		}
+	}
+	// every hop is a proxy of ours, the left-most one is the closest to the client
+	if len(hops) > 0 {
+		return hops[0]
+	}
+	return remote
+}
+
+func isTrustedProxy(address string, trustedProxies []netip.Prefix) bool {
+	addr, err := netip.ParseAddr(address)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func writeProxyResponse(w http.ResponseWriter, response events.APIGatewayProxyResponse) {
+	for name, value := range response.Headers {
+		w.Header().Set(name, value)
+	}
+	for name, values := range response.MultiValueHeaders {
+		for _, value := range values {
+			w.Header().Add(name, value)
+		}
+	}
+
+	body := []byte(response.Body)
+	if response.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(response.Body)
+		if err != nil {
+			slog.Error("Handler returned an invalid base64 body", "error", err)
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		body = decoded
+	}
+
+	status := response.StatusCode
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
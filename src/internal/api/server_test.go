@@ -0,0 +1,128 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestHTTPHandler(t *testing.T) {
+	var received events.APIGatewayProxyRequest
+	handler := NewHTTPHandler(func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		received = req
+		if req.Path == "/fail" {
+			return events.APIGatewayProxyResponse{}, errors.New("boom")
+		}
+		return events.APIGatewayProxyResponse{
+			StatusCode:      http.StatusCreated,
+			Headers:         map[string]string{"Content-Type": "text/plain"},
+			Body:            "aGVsbG8=",
+			IsBase64Encoded: true,
+		}, nil
+	}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "http://registry.example.com/v2/providers/versions:batch?platform=linux_amd64&platform=darwin_arm64", strings.NewReader(`{"providers":[]}`))
+	req.Header.Set("Accept", "application/json")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if received.Path != "/v2/providers/versions:batch" || received.HTTPMethod != http.MethodPost {
+		t.Errorf("unexpected path or method: %s %s", received.HTTPMethod, received.Path)
+	}
+	if received.Body != `{"providers":[]}` {
+		t.Errorf("unexpected body %q", received.Body)
+	}
+	if got := getHeader(received, "host"); got != "registry.example.com" {
+		t.Errorf("expected the Host header to be passed on, got %q", got)
+	}
+	if got := getHeader(received, "accept"); got != "application/json" {
+		t.Errorf("expected the Accept header to be passed on, got %q", got)
+	}
+	if got := received.MultiValueQueryStringParameters["platform"]; len(got) != 2 {
+		t.Errorf("expected both platform parameters, got %v", got)
+	}
+	if received.RequestContext.RequestID == "" {
+		t.Error("expected a request ID to be generated")
+	}
+
+	body, _ := io.ReadAll(rec.Result().Body)
+	if rec.Code != http.StatusCreated || string(body) != "hello" || rec.Header().Get("Content-Type") != "text/plain" {
+		t.Errorf("unexpected response %d %q %v", rec.Code, body, rec.Header())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/fail", nil))
+	if rec.Code != http.StatusBadGateway {
+		t.Errorf("expected handler errors to become a 502, got %d", rec.Code)
+	}
+
+	received = events.APIGatewayProxyRequest{}
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader(strings.Repeat("a", maxRequestBodySize+1))))
+	if rec.Code != http.StatusRequestEntityTooLarge || received.Path != "" {
+		t.Errorf("expected a body over the limit to be rejected with a 413 without calling the handler, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader(strings.Repeat("a", maxRequestBodySize))))
+	if rec.Code != http.StatusCreated || len(received.Body) != maxRequestBodySize {
+		t.Errorf("expected a body at the limit to be passed on whole, got %d with %d bytes", rec.Code, len(received.Body))
+	}
+}
+
+func TestSourceIP(t *testing.T) {
+	trustedProxies, err := ParseTrustedProxies("10.0.0.0/8, 192.0.2.1")
+	if err != nil {
+		t.Fatalf("ParseTrustedProxies() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		want       string
+	}{
+		{name: "direct", remoteAddr: "198.51.100.7:1234", want: "198.51.100.7"},
+		{name: "forwarded by an untrusted client", remoteAddr: "198.51.100.7:1234", forwarded: []string{"203.0.113.9"}, want: "198.51.100.7"},
+		{name: "forwarded by a trusted proxy", remoteAddr: "10.1.2.3:1234", forwarded: []string{"203.0.113.9"}, want: "203.0.113.9"},
+		{name: "spoofed hops are skipped", remoteAddr: "10.1.2.3:1234", forwarded: []string{"1.2.3.4, 203.0.113.9"}, want: "203.0.113.9"},
+		{name: "trusted hops are skipped", remoteAddr: "10.1.2.3:1234", forwarded: []string{"1.2.3.4, 203.0.113.9", "192.0.2.1, 10.4.5.6"}, want: "203.0.113.9"},
+		{name: "only trusted hops", remoteAddr: "10.1.2.3:1234", forwarded: []string{"192.0.2.1, 10.4.5.6"}, want: "192.0.2.1"},
+		{name: "trusted proxy without a header", remoteAddr: "10.1.2.3:1234", want: "10.1.2.3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, value := range tt.forwarded {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+			if got := sourceIP(req, trustedProxies); got != tt.want {
+				t.Errorf("sourceIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.1.2.3:1234"
+	req.Header.Set("X-Forwarded-For", "203.0.113.9")
+	if got := sourceIP(req, nil); got != "10.1.2.3" {
+		t.Errorf("expected X-Forwarded-For to be ignored without trusted proxies, got %q", got)
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	if _, err := ParseTrustedProxies("10.0.0.0/8,not-an-address"); err == nil {
+		t.Error("expected an error for an invalid entry")
+	}
+	prefixes, err := ParseTrustedProxies("")
+	if err != nil || len(prefixes) != 0 {
+		t.Errorf("ParseTrustedProxies(\"\") = %v, %v", prefixes, err)
+	}
+}
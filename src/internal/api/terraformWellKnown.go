@@ -0,0 +1,25 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/opentofu/registry/internal/config"
+)
+
+// terraformWellKnownMetadataHandler serves the service discovery document advertising the services configured in
+// config.ServiceDiscovery.
+func terraformWellKnownMetadataHandler(config config.Config) LambdaFunc {
+	return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		resBody, err := json.Marshal(config.ServiceDiscovery.Document())
+		if err != nil {
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+		return events.APIGatewayProxyResponse{
+			StatusCode: http.StatusOK,
+			Body:       string(resBody),
+		}, nil
+	}
+}
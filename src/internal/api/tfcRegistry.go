@@ -0,0 +1,283 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/opentofu/registry/internal/logging"
+
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/providers/types"
+	"github.com/opentofu/registry/internal/quarantine"
+)
+
+// This file implements the subset of the Terraform Cloud private registry API that tooling commonly relies on, mapped
+// onto our cache. The organization in the path is accepted but ignored, the registry name must be "public" or
+// "private" and both serve the same data.
+
+type TFCProviderPathParams struct {
+	Organization string `json:"organization"`
+	Registry     string `json:"registry"`
+	Namespace    string `json:"namespace"`
+	Type         string `json:"type"`
+	Version      string `json:"version"`
+}
+
+func (p TFCProviderPathParams) AnnotateLogger(ctx context.Context) context.Context {
+	logger := logging.FromContext(ctx).
+		With("organization", p.Organization).
+		With("registry", p.Registry).
+		With("namespace", p.Namespace).
+		With("type", p.Type).
+		With("version", p.Version)
+	return logging.WithLogger(ctx, logger)
+}
+
+func getTFCProviderPathParams(req events.APIGatewayProxyRequest) TFCProviderPathParams {
+	return TFCProviderPathParams{
+		Organization: req.PathParameters["organization"],
+		Registry:     req.PathParameters["registry"],
+		Namespace:    req.PathParameters["namespace"],
+		Type:         req.PathParameters["type"],
+		Version:      req.PathParameters["version"],
+	}
+}
+
+// basePath is the TFC API path of the provider, used for the links in responses.
+func (p TFCProviderPathParams) basePath() string {
+	return fmt.Sprintf("/api/v2/organizations/%s/registry-providers/%s/%s/%s", p.Organization, p.Registry, p.Namespace, p.Type)
+}
+
+type TFCProviderAttributes struct {
+	Name         string `json:"name"`
+	Namespace    string `json:"namespace"`
+	RegistryName string `json:"registry-name"`
+	UpdatedAt    string `json:"updated-at"`
+}
+
+type TFCProviderVersionAttributes struct {
+	Version            string   `json:"version"`
+	Protocols          []string `json:"protocols"`
+	ShasumsUploaded    bool     `json:"shasums-uploaded"`
+	ShasumsSigUploaded bool     `json:"shasums-sig-uploaded"`
+}
+
+type TFCProviderPlatformAttributes struct {
+	OS                     string `json:"os"`
+	Arch                   string `json:"arch"`
+	Filename               string `json:"filename"`
+	Shasum                 string `json:"shasum"`
+	ProviderBinaryUploaded bool   `json:"provider-binary-uploaded"`
+}
+
+type TFCModuleAttributes struct {
+	Name            string                   `json:"name"`
+	Namespace       string                   `json:"namespace"`
+	Provider        string                   `json:"provider"`
+	RegistryName    string                   `json:"registry-name"`
+	Status          string                   `json:"status"`
+	VersionStatuses []TFCModuleVersionStatus `json:"version-statuses"`
+}
+
+type TFCModuleVersionStatus struct {
+	Version string `json:"version"`
+	Status  string `json:"status"`
+}
+
+func tfcProvider(config config.Config) LambdaFunc {
+	return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		params := getTFCProviderPathParams(req)
+		ctx = params.AnnotateLogger(ctx)
+		if !isTFCRegistryName(params.Registry) {
+			return NotFoundResponse, nil
+		}
+
+		document, err := tfcProviderDocument(ctx, config, params)
+		if err != nil || document == nil {
+			return tfcDocumentErrorResponse(ctx, err)
+		}
+
+		return jsonAPIResponse(JSONAPIDocument{Data: JSONAPIResource{
+			ID:   fmt.Sprintf("prov-%s-%s", params.Namespace, params.Type),
+			Type: "registry-providers",
+			Attributes: TFCProviderAttributes{
+				Name:         params.Type,
+				Namespace:    params.Namespace,
+				RegistryName: params.Registry,
+				UpdatedAt:    document.LastUpdated.UTC().Format("2006-01-02T15:04:05.000Z"),
+			},
+			Links: map[string]string{"self": params.basePath()},
+		}})
+	}
+}
+
+func tfcProviderVersions(config config.Config) LambdaFunc {
+	return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		params := getTFCProviderPathParams(req)
+		ctx = params.AnnotateLogger(ctx)
+		if !isTFCRegistryName(params.Registry) {
+			return NotFoundResponse, nil
+		}
+
+		document, err := tfcProviderDocument(ctx, config, params)
+		if err != nil || document == nil {
+			return tfcDocumentErrorResponse(ctx, err)
+		}
+
+		// yanked and quarantined versions cannot be downloaded, so they are not listed
+		effectiveNamespace := config.EffectiveProviderNamespace(params.Namespace)
+		versions := quarantine.Exclude(document.Versions.WithoutYanked(), lookupQuarantines(ctx, config, effectiveNamespace, params.Type))
+		resources := make([]JSONAPIResource, 0, len(versions))
+		for _, v := range versions {
+			resources = append(resources, tfcProviderVersionResource(params, v))
+		}
+		return jsonAPIResponse(JSONAPIDocument{Data: resources})
+	}
+}
+
+func tfcProviderPlatforms(config config.Config) LambdaFunc {
+	return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		params := getTFCProviderPathParams(req)
+		ctx = params.AnnotateLogger(ctx)
+		if !isTFCRegistryName(params.Registry) {
+			return NotFoundResponse, nil
+		}
+
+		document, err := tfcProviderDocument(ctx, config, params)
+		if err != nil || document == nil {
+			return tfcDocumentErrorResponse(ctx, err)
+		}
+
+		for _, v := range document.Versions {
+			if v.Version != params.Version {
+				continue
+			}
+			if v.Yanked != nil {
+				logging.FromContext(ctx).Warn("Version is yanked, returning 404", "reason", v.Yanked.Reason)
+				return yankedResponse(&v), nil
+			}
+			effectiveNamespace := config.EffectiveProviderNamespace(params.Namespace)
+			if q := quarantine.Find(lookupQuarantines(ctx, config, effectiveNamespace, params.Type), v.Version); q != nil {
+				logging.FromContext(ctx).Warn("Version is quarantined, returning 404", "reason", q.Reason)
+				return versionQuarantinedResponse(q), nil
+			}
+
+			resources := make([]JSONAPIResource, 0, len(v.DownloadDetails))
+			for _, d := range v.DownloadDetails {
+				resources = append(resources, JSONAPIResource{
+					ID:   fmt.Sprintf("provpltfrm-%s-%s-%s-%s-%s", params.Namespace, params.Type, v.Version, d.Platform.OS, d.Platform.Arch),
+					Type: "registry-provider-platforms",
+					Attributes: TFCProviderPlatformAttributes{
+						OS:                     d.Platform.OS,
+						Arch:                   d.Platform.Arch,
+						Filename:               d.Filename,
+						Shasum:                 d.SHASum,
+						ProviderBinaryUploaded: true,
+					},
+					Links: map[string]string{"provider-binary-download": d.DownloadURL},
+				})
+			}
+			return jsonAPIResponse(JSONAPIDocument{Data: resources})
+		}
+
+		logging.FromContext(ctx).Info("Version not found in document, returning 404")
+		return versionNotFoundResponse(params.Version), nil
+	}
+}
+
+func tfcModule(config config.Config) LambdaFunc {
+	return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		params := getListModuleVersionsPathParams(req)
+		ctx = params.AnnotateLogger(ctx)
+		registry := req.PathParameters["registry"]
+		if !isTFCRegistryName(registry) {
+			return NotFoundResponse, nil
+		}
+
+		repo, err := config.ModuleRepository(ctx, params.Namespace, params.Name, params.System)
+		if err != nil {
+			logging.FromContext(ctx).Error("Error looking up the repository of the module", "error", err)
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+		versions, exists, err := getModuleVersions(ctx, config, repo)
+		if err != nil {
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+		if !exists {
+			return NotFoundResponse, nil
+		}
+
+		statuses := make([]TFCModuleVersionStatus, len(versions))
+		for i, v := range versions {
+			statuses[i] = TFCModuleVersionStatus{Version: v.Version, Status: "ok"}
+		}
+
+		return jsonAPIResponse(JSONAPIDocument{Data: JSONAPIResource{
+			ID:   fmt.Sprintf("mod-%s-%s-%s", params.Namespace, params.Name, params.System),
+			Type: "registry-modules",
+			Attributes: TFCModuleAttributes{
+				Name:            params.Name,
+				Namespace:       params.Namespace,
+				Provider:        params.System,
+				RegistryName:    registry,
+				Status:          "setup_complete",
+				VersionStatuses: statuses,
+			},
+		}})
+	}
+}
+
+func tfcProviderVersionResource(params TFCProviderPathParams, v types.CacheVersion) JSONAPIResource {
+	var shaSumsUploaded, shaSumsSigUploaded bool
+	if len(v.DownloadDetails) > 0 {
+		shaSumsUploaded = v.DownloadDetails[0].SHASumsURL != ""
+		shaSumsSigUploaded = v.DownloadDetails[0].SHASumsSignatureURL != ""
+	}
+
+	return JSONAPIResource{
+		ID:   fmt.Sprintf("provver-%s-%s-%s", params.Namespace, params.Type, v.Version),
+		Type: "registry-provider-versions",
+		Attributes: TFCProviderVersionAttributes{
+			Version:            v.Version,
+			Protocols:          v.Protocols,
+			ShasumsUploaded:    shaSumsUploaded,
+			ShasumsSigUploaded: shaSumsSigUploaded,
+		},
+		Links: map[string]string{"platforms": fmt.Sprintf("%s/versions/%s/platforms", params.basePath(), v.Version)},
+	}
+}
+
+// tfcProviderDocument reads the provider from the cache only, like the GraphQL API. If it is not cached yet, a refresh
+// is triggered and nil is returned.
+func tfcProviderDocument(ctx context.Context, config config.Config, params TFCProviderPathParams) (*types.CacheItem, error) {
+	effectiveNamespace := config.EffectiveProviderNamespace(params.Namespace)
+
+	document, err := config.ProviderVersionCache.GetItem(ctx, fmt.Sprintf("%s/%s", effectiveNamespace, params.Type))
+	if err != nil {
+		return nil, err
+	}
+	if document == nil || document.IsStale(config.CacheTTL) {
+		if triggerErr := triggerPopulateProviderVersions(ctx, config, effectiveNamespace, params.Type); triggerErr != nil {
+			logging.FromContext(ctx).Error("Error triggering lambda", "error", triggerErr)
+		}
+	}
+	if document != nil && document.Metadata.NotFound {
+		return nil, nil //nolint:nilnil // the provider does not exist, which is the same as not cached to the callers
+	}
+	return document, nil
+}
+
+func tfcDocumentErrorResponse(ctx context.Context, err error) (events.APIGatewayProxyResponse, error) {
+	if err != nil {
+		logging.FromContext(ctx).Error("Error getting document from cache", "error", err)
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+	}
+	logging.FromContext(ctx).Info("Provider not cached yet, returning 404")
+	return NotFoundResponse, nil
+}
+
+func isTFCRegistryName(registry string) bool {
+	return registry == "public" || registry == "private"
+}
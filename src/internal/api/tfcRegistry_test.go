@@ -0,0 +1,139 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/platform"
+	"github.com/opentofu/registry/internal/providers/types"
+)
+
+func TestTFCRegistryProviders(t *testing.T) {
+	lastUpdated := time.Date(2023, 9, 1, 12, 0, 0, 0, time.UTC)
+	details := types.CacheVersionDownloadDetails{
+		Platform:    platform.Platform{OS: "linux", Arch: "amd64"},
+		Filename:    "terraform-provider-test_1.0.0_linux_amd64.zip",
+		DownloadURL: "https://example.com/terraform-provider-test_1.0.0_linux_amd64.zip",
+		SHASum:      "aaa",
+		SHASumsURL:  "https://example.com/SHA256SUMS",
+	}
+	var refreshed []string
+	cfg := config.Config{
+		CacheTTL: types.CacheTTL{Providers: 100 * 365 * 24 * time.Hour},
+		ProviderVersionCache: itemsProviderCache{items: map[string]*types.CacheItem{
+			"example/test": {
+				Provider: "example/test",
+				Versions: types.VersionList{
+					{Version: "1.0.0", Protocols: []string{"5.0"}, DownloadDetails: []types.CacheVersionDownloadDetails{details}},
+					{Version: "0.9.0", Protocols: []string{"5.0"}, DownloadDetails: []types.CacheVersionDownloadDetails{details}, Yanked: &types.Yank{Reason: "CVE-2023-1234"}},
+					{Version: "0.8.0", Protocols: []string{"5.0"}, DownloadDetails: []types.CacheVersionDownloadDetails{details}},
+				},
+				LastUpdated: lastUpdated,
+			},
+		}},
+		QuarantineStore: testQuarantineStore(t, "0.8.0"),
+		PopulateProviderVersions: func(_ context.Context, namespace, providerType, _ string, _ bool) error {
+			refreshed = append(refreshed, namespace+"/"+providerType)
+			return nil
+		},
+	}
+	get := func(t *testing.T, path string) (events.APIGatewayProxyResponse, JSONAPIDocument) {
+		t.Helper()
+		req := events.APIGatewayProxyRequest{HTTPMethod: http.MethodGet, Path: "/api/v2/organizations/example-org/registry-providers" + path}
+		response, err := Router(cfg)(context.Background(), req)
+		if err != nil {
+			t.Fatalf("Router() error = %v", err)
+		}
+		var document JSONAPIDocument
+		if response.StatusCode == http.StatusOK {
+			if err := json.Unmarshal([]byte(response.Body), &document); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		return response, document
+	}
+
+	t.Run("provider", func(t *testing.T) {
+		_, document := get(t, "/private/example/test")
+		want := map[string]any{
+			"id":         "prov-example-test",
+			"type":       "registry-providers",
+			"attributes": map[string]any{"name": "test", "namespace": "example", "registry-name": "private", "updated-at": "2023-09-01T12:00:00.000Z"},
+			"links":      map[string]any{"self": "/api/v2/organizations/example-org/registry-providers/private/example/test"},
+		}
+		if !reflect.DeepEqual(document.Data, want) {
+			t.Errorf("data = %v, want %v", document.Data, want)
+		}
+	})
+
+	t.Run("versions", func(t *testing.T) {
+		_, document := get(t, "/public/example/test/versions")
+		want := []any{map[string]any{
+			"id":         "provver-example-test-1.0.0",
+			"type":       "registry-provider-versions",
+			"attributes": map[string]any{"version": "1.0.0", "protocols": []any{"5.0"}, "shasums-uploaded": true, "shasums-sig-uploaded": false},
+			"links":      map[string]any{"platforms": "/api/v2/organizations/example-org/registry-providers/public/example/test/versions/1.0.0/platforms"},
+		}}
+		if !reflect.DeepEqual(document.Data, want) {
+			t.Errorf("data = %v, want %v", document.Data, want)
+		}
+	})
+
+	t.Run("platforms", func(t *testing.T) {
+		_, document := get(t, "/public/example/test/versions/1.0.0/platforms")
+		want := []any{map[string]any{
+			"id":         "provpltfrm-example-test-1.0.0-linux-amd64",
+			"type":       "registry-provider-platforms",
+			"attributes": map[string]any{"os": "linux", "arch": "amd64", "filename": details.Filename, "shasum": "aaa", "provider-binary-uploaded": true},
+			"links":      map[string]any{"provider-binary-download": details.DownloadURL},
+		}}
+		if !reflect.DeepEqual(document.Data, want) {
+			t.Errorf("data = %v, want %v", document.Data, want)
+		}
+	})
+
+	for name, path := range map[string]string{
+		"unknown registry name": "/other/example/test",
+		"unknown version":       "/public/example/test/versions/2.0.0/platforms",
+	} {
+		t.Run(name, func(t *testing.T) {
+			if response, _ := get(t, path); response.StatusCode != http.StatusNotFound {
+				t.Errorf("status = %d, want %d", response.StatusCode, http.StatusNotFound)
+			}
+		})
+	}
+
+	t.Run("yanked version", func(t *testing.T) {
+		response, _ := get(t, "/public/example/test/versions/0.9.0/platforms")
+		var errDocument ErrorDocument
+		_ = json.Unmarshal([]byte(response.Body), &errDocument)
+		if response.StatusCode != http.StatusNotFound || len(errDocument.Errors) == 0 || errDocument.Errors[0].Code != ErrCodeVersionYanked {
+			t.Errorf("got %d %s, want %d with the %s code", response.StatusCode, response.Body, http.StatusNotFound, ErrCodeVersionYanked)
+		}
+	})
+
+	t.Run("quarantined version", func(t *testing.T) {
+		response, _ := get(t, "/public/example/test/versions/0.8.0/platforms")
+		var errDocument ErrorDocument
+		_ = json.Unmarshal([]byte(response.Body), &errDocument)
+		if response.StatusCode != http.StatusNotFound || len(errDocument.Errors) == 0 || errDocument.Errors[0].Code != ErrCodeVersionQuarantined {
+			t.Errorf("got %d %s, want %d with the %s code", response.StatusCode, response.Body, http.StatusNotFound, ErrCodeVersionQuarantined)
+		}
+	})
+
+	t.Run("not cached", func(t *testing.T) {
+		refreshed = nil
+		if response, _ := get(t, "/public/example/uncached/versions"); response.StatusCode != http.StatusNotFound {
+			t.Errorf("status = %d, want %d", response.StatusCode, http.StatusNotFound)
+		}
+		if !reflect.DeepEqual(refreshed, []string{"example/uncached"}) {
+			t.Errorf("refreshed %v, want the provider to be refreshed", refreshed)
+		}
+	})
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/logging"
+)
+
+// throttleTimeout bounds how long a request waits for its token, the limiter must not slow down the registry.
+const throttleTimeout = time.Second
+
+// throttleRequest takes a token from the bucket of the client of the request, returning the response rejecting it if
+// the client made too many requests. The requests of the admin, and the requests which could not be checked, are let
+// through.
+func throttleRequest(ctx context.Context, config config.Config, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, bool) {
+	if config.RequestThrottle == nil || (strings.HasPrefix(req.Path, "/admin/") && isAdminRequest(config, req)) {
+		return events.APIGatewayProxyResponse{}, false
+	}
+	client := throttleClient(config, req)
+	if client == "" {
+		return events.APIGatewayProxyResponse{}, false
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, throttleTimeout)
+	defer cancel()
+	retryAfter, allowed, err := config.RequestThrottle.Allow(ctx, client)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Could not check the rate limit of the client, letting the request through", "client", client, "error", err)
+		return events.APIGatewayProxyResponse{}, false
+	}
+	if allowed {
+		return events.APIGatewayProxyResponse{}, false
+	}
+
+	logging.FromContext(ctx).Info("Rejecting request of a client over its rate limit", "client", client, "retry_after", retryAfter)
+	response := errorResponse(http.StatusTooManyRequests, ErrCodeRateLimited, "too many requests, retry later")
+	response.Headers = map[string]string{"Retry-After": strconv.Itoa(int(math.Ceil(retryAfter.Seconds())))}
+	return response, true
+}
+
+// throttleClient returns the key of the client of the request: the API Gateway API key it was made with, the read
+// token of private providers it carries, or else its source IP. Other bearer tokens are not used, as clients could
+// make a new one up for every request.
+func throttleClient(config config.Config, req events.APIGatewayProxyRequest) string {
+	if apiKeyID := req.RequestContext.Identity.APIKeyID; apiKeyID != "" {
+		return "key:" + apiKeyID
+	}
+	if token := bearerToken(req); token != "" && config.PrivateProviders.Known(token) {
+		// the tokens are secrets, they must not end up in the table or the logs
+		hash := sha256.Sum256([]byte(token))
+		return "token:" + hex.EncodeToString(hash[:])
+	}
+	if sourceIP := req.RequestContext.Identity.SourceIP; sourceIP != "" {
+		return "ip:" + sourceIP
+	}
+	return ""
+}
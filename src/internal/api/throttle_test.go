@@ -0,0 +1,43 @@
+package api
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/opentofu/registry/internal/access"
+	"github.com/opentofu/registry/internal/config"
+)
+
+func TestThrottleClient(t *testing.T) {
+	cfg := config.Config{PrivateProviders: access.ACLs{"example/secret": {"read-token"}}}
+
+	tests := []struct {
+		name          string
+		apiKeyID      string
+		authorization string
+		wantPrefix    string
+	}{
+		{name: "source IP", wantPrefix: "ip:192.0.2.1"},
+		{name: "API key", apiKeyID: "abc123", authorization: "Bearer read-token", wantPrefix: "key:abc123"},
+		{name: "read token", authorization: "Bearer read-token", wantPrefix: "token:"},
+		{name: "unknown token", authorization: "Bearer made-up", wantPrefix: "ip:192.0.2.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := events.APIGatewayProxyRequest{Headers: map[string]string{}}
+			req.RequestContext.Identity = events.APIGatewayRequestIdentity{SourceIP: "192.0.2.1", APIKeyID: tt.apiKeyID}
+			if tt.authorization != "" {
+				req.Headers["Authorization"] = tt.authorization
+			}
+
+			got := throttleClient(cfg, req)
+			if !strings.HasPrefix(got, tt.wantPrefix) {
+				t.Errorf("throttleClient() = %q, want a key starting with %q", got, tt.wantPrefix)
+			}
+			if strings.Contains(got, "read-token") {
+				t.Errorf("throttleClient() = %q contains the token", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/opentofu/registry/internal/platform"
+	"github.com/opentofu/registry/internal/providers/types"
+)
+
+// parseVersionFilter reads the `protocol` and `platform` query parameters. Both can be repeated or given as a
+// comma-separated list, platforms are formatted as `<os>_<arch>` and normalized, see platform.Normalize.
+func parseVersionFilter(req events.APIGatewayProxyRequest) (types.VersionFilter, error) {
+	var filter types.VersionFilter
+
+	filter.Protocols = queryValues(req, "protocol")
+
+	for _, value := range queryValues(req, "platform") {
+		os, arch, ok := strings.Cut(value, "_")
+		if !ok || os == "" || arch == "" {
+			return types.VersionFilter{}, fmt.Errorf("invalid platform %q, expected <os>_<arch>", value)
+		}
+		filter.Platforms = append(filter.Platforms, platform.Normalize(platform.Platform{OS: os, Arch: arch}))
+	}
+
+	return filter, nil
+}
+
+// parseVersionExtensions reads the `include` query parameter, which adds the release creation time (`timestamps`) and
+// the URL of the release notes (`changelog`) to the versions of a listing. It is opt-in to keep the default responses
+// as small as the protocol needs them. `prereleases` lists the prereleases too, for authors testing release candidates,
+// and `yanked` the yanked versions, marked as such, for auditing which versions were pulled.
+func parseVersionExtensions(req events.APIGatewayProxyRequest) (types.VersionExtensions, error) {
+	var extensions types.VersionExtensions
+	for _, value := range queryValues(req, "include") {
+		switch value {
+		case "timestamps":
+			extensions.Timestamps = true
+		case "changelog":
+			extensions.Changelog = true
+		case "prereleases":
+			extensions.Prereleases = true
+		case "yanked":
+			extensions.Yanked = true
+		default:
+			return types.VersionExtensions{}, fmt.Errorf("invalid include %q, expected timestamps, changelog, prereleases or yanked", value)
+		}
+	}
+	return extensions, nil
+}
+
+// queryValues returns all values of a query parameter, splitting comma-separated lists.
+func queryValues(req events.APIGatewayProxyRequest, name string) []string {
+	raw := req.MultiValueQueryStringParameters[name]
+	if len(raw) == 0 {
+		if value, ok := req.QueryStringParameters[name]; ok {
+			raw = []string{value}
+		}
+	}
+
+	var values []string
+	for _, r := range raw {
+		for _, value := range strings.Split(r, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
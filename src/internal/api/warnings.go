@@ -0,0 +1,56 @@
+package api
+
+import (
+	"context"
+
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/deprecations"
+	"github.com/opentofu/registry/internal/providers/types"
+	"github.com/opentofu/registry/internal/warnings"
+)
+
+// providerWarnings collects the warnings for a provider versions listing: the static warnings, any deprecations
+// recorded for the listed versions, a moved provider, quarantined versions and an archived source repository.
+func providerWarnings(ctx context.Context, config config.Config, namespace, providerType string, versionList []types.Version, metadata types.ProviderMetadata) []string {
+	// Warnings lookup: https://github.com/opentofu/registry/issues/108
+	warn := warnings.ProviderWarnings(namespace, providerType)
+
+	versionNumbers := make([]string, len(versionList))
+	for i, v := range versionList {
+		versionNumbers[i] = v.Version
+	}
+
+	address := deprecations.ProviderAddress(namespace, providerType)
+	warn = append(warn, lookupDeprecationWarnings(ctx, config, address, versionNumbers)...)
+	warn = append(warn, lookupAliasWarnings(ctx, config, namespace, providerType)...)
+	for _, q := range lookupQuarantines(ctx, config, config.EffectiveProviderNamespace(namespace), providerType) {
+		if containsVersion(versionNumbers, q.Version) {
+			warn = append(warn, warnings.Quarantined(q.Version, q.Reason))
+		}
+	}
+
+	if metadata.Archived {
+		warn = append(warn, warnings.ArchivedRepository)
+	}
+	return warn
+}
+
+func containsVersion(versions []string, version string) bool {
+	for _, v := range versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// downloadWarnings collects the warnings for the download of a single provider version. On top of the provider
+// warnings, it warns when the client will not be able to verify the version.
+func downloadWarnings(ctx context.Context, config config.Config, params DownloadHandlerPathParams, details *types.VersionDetails, metadata types.ProviderMetadata) []string {
+	warn := providerWarnings(ctx, config, params.Namespace, params.Type, []types.Version{{Version: params.Version}}, metadata)
+
+	if details.SHASumsSignatureURL == "" || len(details.SigningKeys.GPGPublicKeys) == 0 {
+		warn = append(warn, warnings.UnsignedChecksums)
+	}
+	return warn
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/opentofu/registry/internal/awstest"
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/providers/types"
+	"github.com/opentofu/registry/internal/quarantine"
+	"github.com/opentofu/registry/internal/warnings"
+)
+
+// testQuarantineStore returns a quarantine store whose table holds a quarantine of the version of every provider, for
+// a checksum mismatch.
+func testQuarantineStore(t *testing.T, version string) *quarantine.Handler {
+	client := awstest.DynamoDBClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var input struct {
+			Key map[string]struct{ S string }
+		}
+		_ = json.NewDecoder(r.Body).Decode(&input)
+		w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+		_, _ = fmt.Fprintf(w, `{"Item":{"provider":{"S":%q},"quarantines":{"L":[{"M":{"version":{"S":%q},"reason":{"S":"checksum mismatch"}}}]}}}`, input.Key["provider"].S, version)
+	}))
+	return &quarantine.Handler{TableName: aws.String("quarantines"), Client: client}
+}
+
+func TestProviderWarnings(t *testing.T) {
+	cfg := config.Config{QuarantineStore: testQuarantineStore(t, "1.1.0")}
+
+	tests := []struct {
+		name         string
+		namespace    string
+		providerType string
+		versions     []types.Version
+		metadata     types.ProviderMetadata
+		want         []string
+	}{
+		{name: "no warnings", namespace: "example", providerType: "test", versions: []types.Version{{Version: "1.0.0"}}},
+		{
+			name:         "static warning",
+			namespace:    "hashicorp",
+			providerType: "terraform",
+			want:         warnings.ProviderWarnings("hashicorp", "terraform"),
+		},
+		{
+			name:         "archived repository",
+			namespace:    "example",
+			providerType: "test",
+			versions:     []types.Version{{Version: "1.0.0"}},
+			metadata:     types.ProviderMetadata{Archived: true},
+			want:         []string{warnings.ArchivedRepository},
+		},
+		{
+			name:         "quarantined version",
+			namespace:    "example",
+			providerType: "test",
+			versions:     []types.Version{{Version: "1.1.0"}, {Version: "1.0.0"}},
+			want:         []string{warnings.Quarantined("1.1.0", "checksum mismatch")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := providerWarnings(context.Background(), cfg, tt.namespace, tt.providerType, tt.versions, tt.metadata)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("providerWarnings() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDownloadWarnings(t *testing.T) {
+	params := DownloadHandlerPathParams{Namespace: "example", Type: "test", Version: "1.0.0", OS: "linux", Architecture: "amd64"}
+	signed := &types.VersionDetails{
+		SHASumsSignatureURL: "https://example.com/SHA256SUMS.sig",
+		SigningKeys:         types.SigningKeys{GPGPublicKeys: []types.GPGPublicKey{{KeyID: "ABCDEF"}}},
+	}
+	if got := downloadWarnings(context.Background(), config.Config{}, params, signed, types.ProviderMetadata{}); len(got) != 0 {
+		t.Errorf("downloadWarnings() = %v, want no warnings for signed checksums", got)
+	}
+
+	for name, details := range map[string]*types.VersionDetails{
+		"unsigned":   {SigningKeys: signed.SigningKeys},
+		"no keys":    {SHASumsSignatureURL: signed.SHASumsSignatureURL},
+		"no details": {},
+	} {
+		got := downloadWarnings(context.Background(), config.Config{}, params, details, types.ProviderMetadata{Archived: true})
+		if want := []string{warnings.ArchivedRepository, warnings.UnsignedChecksums}; !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: downloadWarnings() = %v, want %v", name, got, want)
+		}
+	}
+}
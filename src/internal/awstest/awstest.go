@@ -0,0 +1,54 @@
+// Package awstest fakes the AWS services called by the registry, for the tests of the packages calling them. The
+// clients it returns sign their requests with example credentials and send them to a test server instead of AWS.
+package awstest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+)
+
+// Config returns the configuration of the clients under test, in eu-west-1 and with static example credentials.
+func Config() aws.Config {
+	return aws.Config{
+		Region: "eu-west-1",
+		Credentials: aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+			return aws.Credentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "secret"}, nil
+		}),
+	}
+}
+
+// DynamoDBClient returns a DynamoDB client sending its calls to handler, without retrying them. The server behind it
+// is closed at the end of the test.
+func DynamoDBClient(t testing.TB, handler http.Handler) *dynamodb.Client {
+	server := newServer(t, handler)
+	cfg := Config()
+	cfg.HTTPClient = server.Client()
+	return dynamodb.NewFromConfig(cfg, func(o *dynamodb.Options) {
+		o.EndpointResolver = dynamodb.EndpointResolverFromURL(server.URL)
+		o.RetryMaxAttempts = 1
+	})
+}
+
+// LambdaClient returns a Lambda client sending its calls to handler, without retrying them. The server behind it is
+// closed at the end of the test.
+func LambdaClient(t testing.TB, handler http.Handler) *lambda.Client {
+	server := newServer(t, handler)
+	cfg := Config()
+	cfg.HTTPClient = server.Client()
+	return lambda.NewFromConfig(cfg, func(o *lambda.Options) {
+		o.EndpointResolver = lambda.EndpointResolverFromURL(server.URL)
+		o.RetryMaxAttempts = 1
+	})
+}
+
+func newServer(t testing.TB, handler http.Handler) *httptest.Server {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return server
+}
@@ -0,0 +1,116 @@
+// Package bucket makes the few signed S3 requests the registry needs itself, rather than pulling in the S3 client of
+// the AWS SDK: reading and writing the objects of a bucket, and pre-signing URLs to them. The snapshot, listings and
+// mirror stores are built on it.
+package bucket
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-xray-sdk-go/xray"
+)
+
+// UnsignedPayload is the payload hash of the requests whose body is not signed, either because it is streamed or, for
+// pre-signed URLs, because it is not known when signing.
+const UnsignedPayload = "UNSIGNED-PAYLOAD"
+
+// Client sends signed requests for the objects of an S3 bucket.
+type Client struct {
+	Name        string
+	Region      string
+	Credentials aws.CredentialsProvider
+
+	// Endpoint is the base URL of the bucket, and HTTPClient the client the requests are sent with, both overridden in
+	// tests.
+	Endpoint   string
+	HTTPClient *http.Client
+
+	signer *v4.Signer
+}
+
+// New returns a client for the named bucket, whose requests time out after timeout.
+func New(awsConfig aws.Config, name string, timeout time.Duration) *Client {
+	return &Client{
+		Name:        name,
+		Region:      awsConfig.Region,
+		Credentials: awsConfig.Credentials,
+		Endpoint:    fmt.Sprintf("https://%s.s3.%s.amazonaws.com", name, awsConfig.Region),
+		HTTPClient:  xray.Client(&http.Client{Timeout: timeout}),
+		signer: v4.NewSigner(func(o *v4.SignerOptions) {
+			// S3 object keys are signed as they are, not escaped a second time like for other services
+			o.DisableURIPathEscaping = true
+		}),
+	}
+}
+
+// Do sends a signed request for the object under key, with body as its payload, which is signed as well.
+func (c *Client) Do(ctx context.Context, method, key string, header http.Header, body []byte) (*http.Response, error) {
+	payloadHash := sha256.Sum256(body)
+	return c.send(ctx, method, key, header, bytes.NewReader(body), int64(len(body)), hex.EncodeToString(payloadHash[:]))
+}
+
+// Stream sends a signed request for the object under key, streaming body, of the given length, as its payload. The
+// payload is not signed, so that it does not have to be read twice.
+func (c *Client) Stream(ctx context.Context, method, key string, header http.Header, body io.Reader, length int64) (*http.Response, error) {
+	return c.send(ctx, method, key, header, body, length, UnsignedPayload)
+}
+
+func (c *Client) send(ctx context.Context, method, key string, header http.Header, body io.Reader, length int64, payloadHash string) (*http.Response, error) {
+	credentials, err := c.Credentials.Retrieve(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve AWS credentials: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.Endpoint+"/"+key, body)
+	if err != nil {
+		return nil, fmt.Errorf("could not create request for %s: %w", key, err)
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.ContentLength = length
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if err := c.signer.SignHTTP(ctx, credentials, req, payloadHash, "s3", c.Region, time.Now()); err != nil {
+		return nil, fmt.Errorf("could not sign request for %s: %w", key, err)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not %s object %s: %w", method, key, err)
+	}
+	// drain the error bodies so that the connection can be reused
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, resp.Body)
+	}
+	return resp, nil
+}
+
+// Presign returns a URL to GET the object under key, pre-signed at now and valid for ttl.
+func (c *Client) Presign(ctx context.Context, key string, ttl time.Duration, now time.Time) (string, error) {
+	credentials, err := c.Credentials.Retrieve(ctx)
+	if err != nil {
+		return "", fmt.Errorf("could not retrieve AWS credentials: %w", err)
+	}
+
+	query := url.Values{}
+	query.Set("X-Amz-Expires", strconv.FormatInt(int64(ttl/time.Second), 10))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.Endpoint+"/"+key+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", fmt.Errorf("could not create request for %s: %w", key, err)
+	}
+	signed, _, err := c.signer.PresignHTTP(ctx, credentials, req, UnsignedPayload, "s3", c.Region, now)
+	if err != nil {
+		return "", fmt.Errorf("could not pre-sign URL for %s: %w", key, err)
+	}
+	return signed, nil
+}
@@ -0,0 +1,60 @@
+package bucket
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/opentofu/registry/internal/awstest"
+)
+
+func TestClient(t *testing.T) {
+	type received struct {
+		method, path, payloadHash, contentType, authorization, body string
+	}
+	var got received
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got = received{r.Method, r.URL.Path, r.Header.Get("X-Amz-Content-Sha256"), r.Header.Get("Content-Type"), r.Header.Get("Authorization"), string(body)}
+	}))
+	defer server.Close()
+
+	client := New(awstest.Config(), "registry-bucket", time.Second)
+	if client.Endpoint != "https://registry-bucket.s3.eu-west-1.amazonaws.com" {
+		t.Errorf("unexpected endpoint %q", client.Endpoint)
+	}
+	client.Endpoint = server.URL
+	client.HTTPClient = server.Client()
+	ctx := context.Background()
+
+	resp, err := client.Do(ctx, http.MethodPut, "objects/foo.json", http.Header{"Content-Type": {"application/json"}}, []byte(`{}`))
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	resp.Body.Close()
+	sum := sha256.Sum256([]byte(`{}`))
+	if got.method != http.MethodPut || got.path != "/objects/foo.json" || got.body != `{}` || got.contentType != "application/json" {
+		t.Errorf("unexpected request %+v", got)
+	}
+	if got.payloadHash != hex.EncodeToString(sum[:]) {
+		t.Errorf("expected the payload to be signed, got %q", got.payloadHash)
+	}
+	if !strings.Contains(got.authorization, "AKIDEXAMPLE/") || !strings.Contains(got.authorization, "/eu-west-1/s3/aws4_request") {
+		t.Errorf("unexpected authorization %q", got.authorization)
+	}
+
+	resp, err = client.Stream(ctx, http.MethodPut, "objects/foo.zip", nil, strings.NewReader("zip"), 3)
+	if err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+	resp.Body.Close()
+	if got.body != "zip" || got.payloadHash != UnsignedPayload {
+		t.Errorf("expected the streamed payload not to be signed, got %+v", got)
+	}
+}
@@ -0,0 +1,190 @@
+package bundle
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+
+	"golang.org/x/exp/slog"
+
+	"github.com/opentofu/registry/internal/platform"
+	"github.com/opentofu/registry/internal/providers/types"
+)
+
+// Builder downloads providers through the v1 API of a registry and writes them into a bundle. The archives are
+// fetched from the download URLs the registry hands out, so a registry that mirrors its assets to S3 serves the
+// bundle contents from the mirror.
+type Builder struct {
+	hostname   string
+	httpClient *http.Client
+}
+
+func NewBuilder(hostname string) *Builder {
+	return &Builder{
+		hostname:   hostname,
+		httpClient: http.DefaultClient,
+	}
+}
+
+type mirrorIndex struct {
+	Versions map[string]struct{} `json:"versions"`
+}
+
+type mirrorVersion struct {
+	Archives map[string]mirrorArchive `json:"archives"`
+}
+
+type mirrorArchive struct {
+	URL    string   `json:"url"`
+	Hashes []string `json:"hashes"`
+}
+
+// Build writes a bundle with the newest version matching each requirement, for each of the given platforms the
+// version is available for. The bundle contains, below `<hostname>/<namespace>/<type>/`:
+//   - the provider archives, named as in the release,
+//   - the SHA256SUMS file of the version and its signature, to verify the archives offline,
+//   - `index.json` and `<version>.json`, the index of the provider network mirror protocol.
+func (b *Builder) Build(ctx context.Context, w io.Writer, requirements []Requirement, platforms []platform.Platform) error {
+	archive := zip.NewWriter(w)
+
+	for _, requirement := range requirements {
+		if err := b.addProvider(ctx, archive, requirement, platforms); err != nil {
+			return fmt.Errorf("failed to bundle %s/%s: %w", requirement.Namespace, requirement.Type, err)
+		}
+	}
+
+	return archive.Close()
+}
+
+func (b *Builder) addProvider(ctx context.Context, archive *zip.Writer, requirement Requirement, platforms []platform.Platform) error {
+	var versions struct {
+		Versions []types.Version `json:"versions"`
+	}
+	if err := b.getJSON(ctx, fmt.Sprintf("/v1/providers/%s/%s/versions", requirement.Namespace, requirement.Type), &versions); err != nil {
+		return err
+	}
+
+	selected, ok := selectVersion(requirement, versions.Versions)
+	if !ok {
+		return fmt.Errorf("no version matches the constraints")
+	}
+	logger := slog.Default().With("provider", fmt.Sprintf("%s/%s", requirement.Namespace, requirement.Type), "version", selected.Version)
+
+	dir := path.Join(b.hostname, requirement.Namespace, requirement.Type)
+	index := mirrorVersion{Archives: make(map[string]mirrorArchive)}
+	var shaSumsURL, shaSumsSignatureURL string
+
+	for _, p := range platforms {
+		if !hasPlatform(selected, p) {
+			logger.Warn("Version is not available for platform, skipping", "platform", p)
+			continue
+		}
+
+		var details types.VersionDetails
+		downloadPath := fmt.Sprintf("/v1/providers/%s/%s/%s/download/%s/%s", requirement.Namespace, requirement.Type, selected.Version, p.OS, p.Arch)
+		if err := b.getJSON(ctx, downloadPath, &details); err != nil {
+			return err
+		}
+
+		logger.Info("Adding archive", "filename", details.Filename)
+		if err := b.addFile(ctx, archive, path.Join(dir, details.Filename), details.DownloadURL, details.SHASum); err != nil {
+			return err
+		}
+
+		index.Archives[fmt.Sprintf("%s_%s", p.OS, p.Arch)] = mirrorArchive{
+			URL:    details.Filename,
+			Hashes: []string{"zh:" + details.SHASum},
+		}
+		shaSumsURL, shaSumsSignatureURL = details.SHASumsURL, details.SHASumsSignatureURL
+	}
+
+	if len(index.Archives) == 0 {
+		return fmt.Errorf("version %s is not available for any of the requested platforms", selected.Version)
+	}
+
+	shaSumsName := fmt.Sprintf("terraform-provider-%s_%s_SHA256SUMS", requirement.Type, selected.Version)
+	if err := b.addFile(ctx, archive, path.Join(dir, shaSumsName), shaSumsURL, ""); err != nil {
+		return err
+	}
+	if shaSumsSignatureURL != "" {
+		if err := b.addFile(ctx, archive, path.Join(dir, shaSumsName+".sig"), shaSumsSignatureURL, ""); err != nil {
+			return err
+		}
+	}
+
+	if err := writeJSON(archive, path.Join(dir, selected.Version+".json"), index); err != nil {
+		return err
+	}
+	return writeJSON(archive, path.Join(dir, "index.json"), mirrorIndex{Versions: map[string]struct{}{selected.Version: {}}})
+}
+
+// addFile downloads the file at the given URL into the bundle. If shaSum is set, the contents are verified against it.
+func (b *Builder) addFile(ctx context.Context, archive *zip.Writer, name, u, shaSum string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := b.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", u, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download %s: unexpected status %d", u, resp.StatusCode)
+	}
+
+	// the archives are already compressed, store them as they are
+	f, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store})
+	if err != nil {
+		return err
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(f, hash), resp.Body); err != nil {
+		return fmt.Errorf("failed to download %s: %w", u, err)
+	}
+	if sum := hex.EncodeToString(hash.Sum(nil)); shaSum != "" && sum != shaSum {
+		return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", name, shaSum, sum)
+	}
+	return nil
+}
+
+func (b *Builder) getJSON(ctx context.Context, p string, v any) error {
+	u := fmt.Sprintf("https://%s%s", b.hostname, p)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := b.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to get %s: %w", u, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to get %s: unexpected status %d", u, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func writeJSON(archive *zip.Writer, name string, v any) error {
+	f, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(f).Encode(v)
+}
+
+func hasPlatform(v types.Version, p platform.Platform) bool {
+	for _, candidate := range v.Platforms {
+		if candidate == p {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+// Package bundle assembles offline bundles of providers for air-gapped environments. A bundle is a zip archive laid
+// out as a provider mirror: it can be extracted and used as a filesystem mirror, or served as a network mirror.
+package bundle
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/opentofu/registry/internal/platform"
+	"github.com/opentofu/registry/internal/providers/types"
+	"github.com/opentofu/registry/internal/version"
+)
+
+// Requirement is a provider and the version constraints of the version to include in a bundle.
+type Requirement struct {
+	Namespace   string
+	Type        string
+	Constraints version.Constraints
+}
+
+// ParseRequirement parses a requirement of the form `<namespace>/<type>[:<constraints>]`, e.g. `hashicorp/aws:~> 5.0`.
+// Without constraints, the latest version is bundled.
+func ParseRequirement(value string) (Requirement, error) {
+	address, constraints, _ := strings.Cut(value, ":")
+	namespace, providerType, ok := strings.Cut(strings.TrimSpace(address), "/")
+	if !ok || namespace == "" || providerType == "" || strings.Contains(providerType, "/") {
+		return Requirement{}, fmt.Errorf("invalid provider address %q", address)
+	}
+
+	parsed, err := version.ParseConstraints(constraints)
+	if err != nil {
+		return Requirement{}, fmt.Errorf("invalid constraints for %s: %w", address, err)
+	}
+
+	return Requirement{
+		Namespace:   strings.ToLower(namespace),
+		Type:        strings.ToLower(providerType),
+		Constraints: parsed,
+	}, nil
+}
+
+// ParsePlatform parses a platform of the form `<os>_<arch>`, e.g. `linux_amd64`, and normalizes it.
+func ParsePlatform(value string) (platform.Platform, error) {
+	os, arch, ok := strings.Cut(value, "_")
+	if !ok || os == "" || arch == "" {
+		return platform.Platform{}, fmt.Errorf("invalid platform %q, expected <os>_<arch>", value)
+	}
+	return platform.Normalize(platform.Platform{OS: os, Arch: arch}), nil
+}
+
+// selectVersion returns the newest version matching the requirement, skipping prereleases.
+func selectVersion(requirement Requirement, versions []types.Version) (types.Version, bool) {
+	var selected types.Version
+	found := false
+	for _, v := range versions {
+		if strings.ContainsAny(v.Version, "-+") || !requirement.Constraints.Check(v.Version) {
+			continue
+		}
+		if !found || version.Compare(v.Version, selected.Version) > 0 {
+			selected = v
+			found = true
+		}
+	}
+	return selected, found
+}
@@ -0,0 +1,60 @@
+package bundle
+
+import (
+	"testing"
+
+	"github.com/opentofu/registry/internal/providers/types"
+)
+
+func TestParseRequirement(t *testing.T) {
+	requirement, err := ParseRequirement("Hashicorp/AWS:>= 5.0, < 6.0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if requirement.Namespace != "hashicorp" || requirement.Type != "aws" {
+		t.Errorf("unexpected address %s/%s", requirement.Namespace, requirement.Type)
+	}
+	if !requirement.Constraints.Check("5.1.0") || requirement.Constraints.Check("6.0.0") {
+		t.Error("constraints were not parsed correctly")
+	}
+
+	for _, invalid := range []string{"aws", "hashicorp/aws/extra", "hashicorp/aws:>= five"} {
+		if _, err := ParseRequirement(invalid); err == nil {
+			t.Errorf("expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestSelectVersion(t *testing.T) {
+	versions := []types.Version{
+		{Version: "4.9.0"},
+		{Version: "5.10.0"},
+		{Version: "5.2.0"},
+		{Version: "6.0.0-beta1"},
+		{Version: "6.1.0"},
+	}
+
+	tests := []struct {
+		name        string
+		requirement string
+		want        string
+		wantFound   bool
+	}{
+		{name: "latest without constraints", requirement: "hashicorp/aws", want: "6.1.0", wantFound: true},
+		{name: "newest matching", requirement: "hashicorp/aws:~> 5.0", want: "5.10.0", wantFound: true},
+		{name: "prereleases are skipped", requirement: "hashicorp/aws:>= 6.0.0, < 6.1.0", wantFound: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requirement, err := ParseRequirement(tt.requirement)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got, found := selectVersion(requirement, versions)
+			if found != tt.wantFound || got.Version != tt.want {
+				t.Errorf("selectVersion() = %q, %v, want %q, %v", got.Version, found, tt.want, tt.wantFound)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/opentofu/registry/internal/mirror"
+	"github.com/opentofu/registry/internal/secrets"
+)
+
+// buildAssetMirrorSigner returns the signer of the download URLs of the asset mirror bucket: pre-signed S3 URLs, or,
+// if ASSET_MIRROR_CLOUDFRONT_URL is set, signed URLs of the CloudFront distribution in front of the bucket. The
+// distribution is configured by ASSET_MIRROR_CLOUDFRONT_KEY_PAIR_ID, the ID of its public key, and the PEM encoded
+// private key is read from the secret named by ASSET_MIRROR_CLOUDFRONT_SECRET_ASM_NAME.
+func buildAssetMirrorSigner(ctx context.Context, awsConfig aws.Config, secretsHandler *secrets.Handler, bucket string, ttl time.Duration) (mirror.Signer, error) {
+	cloudFrontURL := os.Getenv("ASSET_MIRROR_CLOUDFRONT_URL")
+	if cloudFrontURL == "" {
+		return mirror.NewPresigner(awsConfig, bucket, ttl), nil
+	}
+
+	privateKey, err := secretsHandler.GetSecretValueFromEnvReference(ctx, "ASSET_MIRROR_CLOUDFRONT_SECRET_ASM_NAME")
+	if err != nil {
+		return nil, fmt.Errorf("could not get CloudFront private key: %w", err)
+	}
+	signer, err := mirror.NewCloudFrontSigner(cloudFrontURL, os.Getenv("ASSET_MIRROR_CLOUDFRONT_KEY_PAIR_ID"), []byte(privateKey), ttl)
+	if err != nil {
+		return nil, fmt.Errorf("could not set up the CloudFront asset mirror: %w", err)
+	}
+	return signer, nil
+}
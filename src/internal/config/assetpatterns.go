@@ -0,0 +1,74 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/opentofu/registry/internal/providers"
+)
+
+// AssetPatternSettings are the regular expressions matching the release assets of a provider, as found in the
+// ASSET_NAME_PATTERNS environment variable. Patterns left empty match the standard names.
+type AssetPatternSettings struct {
+	Platform         string `json:"platform"`
+	SHASums          string `json:"shasums"`
+	SHASumsSignature string `json:"shasums_signature"`
+	Manifest         string `json:"manifest"`
+}
+
+// parseAssetPatterns parses the ASSET_NAME_PATTERNS value, a JSON object mapping either a namespace or a
+// `<namespace>/<type>` provider address to the patterns of its release assets, e.g.
+// `{"example/foo": {"platform": "-(?P<os>[a-z]+)-(?P<arch>[a-z0-9]+)\\.zip$", "shasums": "\\.sha256sums$"}}`.
+func parseAssetPatterns(value string) (map[string]providers.AssetPatterns, error) {
+	patterns := make(map[string]providers.AssetPatterns)
+	if value == "" {
+		return patterns, nil
+	}
+
+	var settings map[string]AssetPatternSettings
+	if err := json.Unmarshal([]byte(value), &settings); err != nil {
+		return nil, fmt.Errorf("could not parse ASSET_NAME_PATTERNS: %w", err)
+	}
+
+	for key, s := range settings {
+		var p providers.AssetPatterns
+		var err error
+		if p.Platform, err = compileAssetPattern(s.Platform); err != nil {
+			return nil, fmt.Errorf("could not parse ASSET_NAME_PATTERNS for %s: %w", key, err)
+		}
+		if p.Platform != nil {
+			if err = providers.ValidatePlatformPattern(p.Platform); err != nil {
+				return nil, fmt.Errorf("could not parse ASSET_NAME_PATTERNS for %s: %w", key, err)
+			}
+		}
+		if p.SHASums, err = compileAssetPattern(s.SHASums); err != nil {
+			return nil, fmt.Errorf("could not parse ASSET_NAME_PATTERNS for %s: %w", key, err)
+		}
+		if p.SHASumsSignature, err = compileAssetPattern(s.SHASumsSignature); err != nil {
+			return nil, fmt.Errorf("could not parse ASSET_NAME_PATTERNS for %s: %w", key, err)
+		}
+		if p.Manifest, err = compileAssetPattern(s.Manifest); err != nil {
+			return nil, fmt.Errorf("could not parse ASSET_NAME_PATTERNS for %s: %w", key, err)
+		}
+		patterns[strings.ToLower(key)] = p
+	}
+	return patterns, nil
+}
+
+func compileAssetPattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil //nolint:nilnil // No pattern means the standard names are matched.
+	}
+	return regexp.Compile(pattern)
+}
+
+// AssetPatterns returns the patterns matching the release assets of the given provider: those configured for the
+// provider if any, else those configured for its namespace, else the standard names.
+func (c Config) AssetPatterns(namespace, providerType string) providers.AssetPatterns {
+	if p, ok := c.ProviderAssetPatterns[strings.ToLower(fmt.Sprintf("%s/%s", namespace, providerType))]; ok {
+		return p
+	}
+	return c.ProviderAssetPatterns[strings.ToLower(namespace)]
+}
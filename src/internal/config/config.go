@@ -4,21 +4,61 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/lambda"
 	"github.com/aws/aws-xray-sdk-go/xray"
 	gogithub "github.com/google/go-github/v54/github"
+	"github.com/opentofu/registry/internal/access"
+	"github.com/opentofu/registry/internal/aliases"
+	"github.com/opentofu/registry/internal/deprecations"
+	"github.com/opentofu/registry/internal/downloads"
+	"github.com/opentofu/registry/internal/etags"
+	"github.com/opentofu/registry/internal/faults"
 	"github.com/opentofu/registry/internal/github"
+	"github.com/opentofu/registry/internal/keys"
+	"github.com/opentofu/registry/internal/listings"
+	"github.com/opentofu/registry/internal/mirror"
+	"github.com/opentofu/registry/internal/modules"
+	"github.com/opentofu/registry/internal/modules/modulecache"
+	"github.com/opentofu/registry/internal/oci"
+	"github.com/opentofu/registry/internal/platform"
+	"github.com/opentofu/registry/internal/providers"
 	"github.com/opentofu/registry/internal/providers/providercache"
+	"github.com/opentofu/registry/internal/providers/types"
+	"github.com/opentofu/registry/internal/quarantine"
+	"github.com/opentofu/registry/internal/queue"
+	"github.com/opentofu/registry/internal/quota"
+	"github.com/opentofu/registry/internal/redirects"
+	"github.com/opentofu/registry/internal/repomappings"
 	"github.com/opentofu/registry/internal/secrets"
+	"github.com/opentofu/registry/internal/snapshot"
+	"github.com/opentofu/registry/internal/throttle"
+	"github.com/opentofu/registry/internal/vcs"
 	"github.com/shurcooL/githubv4"
+	"golang.org/x/exp/slog"
+	"golang.org/x/oauth2"
 )
 
 type Builder struct {
 	IncludeProviderRedirects bool
+	IncludeDeprecations      bool
+	IncludeAdminAPI          bool
+	IncludeProviderAliases   bool
+	IncludeModuleRewrites    bool
+	IncludeOCIProviders      bool
+	IncludeQuarantine        bool
+	IncludeGitHubAppAuth     bool
+	IncludeReleaseWebhook    bool
+	IncludeGitLab            bool
+	IncludeServiceDiscovery  bool
 }
 
 func NewBuilder(options ...func(*Builder)) *Builder {
@@ -35,15 +75,277 @@ func WithProviderRedirects() func(*Builder) {
 	}
 }
 
+func WithDeprecations() func(*Builder) {
+	return func(builder *Builder) {
+		builder.IncludeDeprecations = true
+	}
+}
+
+func WithAdminAPI() func(*Builder) {
+	return func(builder *Builder) {
+		builder.IncludeAdminAPI = true
+	}
+}
+
+func WithProviderAliases() func(*Builder) {
+	return func(builder *Builder) {
+		builder.IncludeProviderAliases = true
+	}
+}
+
+func WithModuleRewrites() func(*Builder) {
+	return func(builder *Builder) {
+		builder.IncludeModuleRewrites = true
+	}
+}
+
+func WithOCIProviders() func(*Builder) {
+	return func(builder *Builder) {
+		builder.IncludeOCIProviders = true
+	}
+}
+
+func WithQuarantine() func(*Builder) {
+	return func(builder *Builder) {
+		builder.IncludeQuarantine = true
+	}
+}
+
+// WithGitLab serves the namespaces mapped to GitLab groups from the releases of their GitLab projects.
+func WithGitLab() func(*Builder) {
+	return func(builder *Builder) {
+		builder.IncludeGitLab = true
+	}
+}
+
+// WithReleaseWebhook loads the secret the GitHub release webhooks are signed with.
+func WithReleaseWebhook() func(*Builder) {
+	return func(builder *Builder) {
+		builder.IncludeReleaseWebhook = true
+	}
+}
+
+// WithGitHubAppAuth authenticates the GitHub clients as an installation of a GitHub App, if one is configured,
+// instead of with the registry's static token.
+func WithGitHubAppAuth() func(*Builder) {
+	return func(builder *Builder) {
+		builder.IncludeGitHubAppAuth = true
+	}
+}
+
+// WithServiceDiscovery reads the services advertised in `/.well-known/terraform.json` from the environment, see
+// ServiceDiscovery.
+func WithServiceDiscovery() func(*Builder) {
+	return func(builder *Builder) {
+		builder.IncludeServiceDiscovery = true
+	}
+}
+
 type Config struct {
+	// ManagedGithubClient and RawGithubv4Client call GitHub with the registry's token, or spread their calls over it
+	// and the tokens of the pool, if there is one.
 	ManagedGithubClient *gogithub.Client
 	RawGithubv4Client   *githubv4.Client
 
+	// NamespaceGithubClients are used instead of the clients above for the repositories of the namespaces which have
+	// a GitHub token of their own, keyed by lowercase namespace. Use GithubClient and Githubv4Client to pick.
+	NamespaceGithubClients map[string]GithubClients
+
 	LambdaClient         *lambda.Client
-	ProviderVersionCache *providercache.Handler
+	ProviderVersionCache providercache.Cache
 	SecretsHandler       *secrets.Handler
+	DeprecationsStore    *deprecations.Handler
+	ProviderAliasesStore *aliases.Handler
+	QuarantineStore      *quarantine.Handler
+
+	// ProviderAliases caches the aliases of ProviderAliasesStore for the listing of all of them, and is set with it.
+	ProviderAliases *aliases.Loader
+
+	// RepositoryMappings, if set, holds the repositories mapped by hand to providers and modules, consulted before
+	// ProviderRepoNames and ModuleRepositories. See ProviderSource and ModuleRepository.
+	RepositoryMappings *repomappings.Handler
+
+	// ModuleVersionCache, if set, keeps the module versions fetched from GitHub, see modulecache. Without it, modules
+	// are always served from GitHub.
+	ModuleVersionCache modulecache.Cache
+
+	// KeyStore, if set, holds the GPG keys registered through the admin API, served with the keys of the repository.
+	KeyStore *keys.Handler
+
+	// SignatureVerifications, if set, makes the downloads check that the SHA256SUMS of the version is signed by one of
+	// the keys of its namespace, remembering the results. Without it, the signatures are not checked.
+	SignatureVerifications *providers.SignatureCache
+
+	// DownloadStats, if set, counts the downloads served, per version and platform and per day. Without it, downloads
+	// are not recorded and neither the download summaries nor the most downloaded providers and modules are served.
+	DownloadStats downloads.Store
+
+	// ReleaseETags, if set, remembers the ETags of the release listings of the providers, so that the refreshes skip
+	// fetching the releases of the providers which have no new ones.
+	ReleaseETags *etags.Handler
+
+	// RefreshQueue, if set, is the SQS queue the providers to refresh are sent to, one message each, for the worker
+	// lambda to refresh them.
+	RefreshQueue *queue.Queue
+
+	// PopulateProviderVersions, if set, refreshes the cached versions of a provider instead of invoking the
+	// populate_provider_versions Lambda. The server sets it to run the refresh in-process. Forced refreshes fetch the
+	// versions even if the cached ones are not stale.
+	PopulateProviderVersions func(ctx context.Context, namespace, providerType, tenant string, force bool) error
+
+	// PopulateModuleVersions, if set, refreshes the cached versions of a module repository instead of invoking the
+	// populate_provider_versions Lambda, like PopulateProviderVersions.
+	PopulateModuleVersions func(ctx context.Context, repo modules.Repository, force bool) error
 
 	ProviderRedirects map[string]string
+
+	// DynamicProviderRedirects, if set, loads the namespace redirects managed through the admin API, applied on top of
+	// ProviderRedirects. Tenants only get their own ProviderRedirects.
+	DynamicProviderRedirects *redirects.Loader
+
+	// OCIClient and OCIProviders serve providers published as OCI artifacts instead of GitHub releases.
+	OCIClient    *oci.Client
+	OCIProviders map[string]oci.Reference
+
+	// GitLab and GitLabNamespaces serve the providers and modules of the namespaces mapped to GitLab groups from the
+	// releases of their GitLab projects instead of GitHub. Use VCS to get the code host of a namespace.
+	GitLab           *vcs.GitLab
+	GitLabNamespaces map[string]string
+
+	// AssetMirror, if set, serves the downloads of cached provider versions from the private S3 bucket mirroring their
+	// release assets, through pre-signed S3 URLs or signed URLs of a CloudFront distribution in front of the bucket.
+	AssetMirror mirror.Signer
+
+	// AssetMirrorUploader, if set, copies the release assets of the versions fetched by the provider refreshes into the
+	// asset mirror before they are cached, so that the bucket does not have to be kept in sync separately.
+	AssetMirrorUploader *mirror.Uploader
+
+	// RegionalMirrors are public mirrors of the release assets, listed with the other download locations of cached
+	// provider versions.
+	RegionalMirrors []mirror.Regional
+
+	// IngestionSnapshots, if set, keeps the GitHub releases seen by the provider refreshes in an S3 bucket, from which
+	// the cached versions can be rebuilt without querying GitHub.
+	IngestionSnapshots *snapshot.Store
+
+	// LargeListings, if set, keeps the v1 versions listings of the providers whose listing is larger than
+	// LargeListingThreshold bytes in an S3 bucket, served from there instead of rendered on every request. The cached
+	// versions too large to fit in an item of the cache table are kept in the same bucket.
+	LargeListings         *listings.Store
+	LargeListingThreshold int
+	// LargeListingsURL is the base URL of the CDN in front of the listings bucket, which clients are redirected to for
+	// the large listings. Without it, the listings are read from the bucket and returned by the API.
+	LargeListingsURL string
+
+	// ModuleSourceRewrites are applied, in order, to the source returned for module downloads.
+	ModuleSourceRewrites []modules.RewriteRule
+
+	// ModuleArchiveSources makes module downloads point at the GitHub archive of the release instead of the git
+	// repository, see modules.Repository.Archive.
+	ModuleArchiveSources bool
+
+	// ModuleStableArchives makes module downloads point at the tarball attached to the release or, for the releases
+	// without one, at an archive of the tree of the tag the registry stores in the asset mirror bucket, which, unlike
+	// the archives generated by GitHub, are the same bytes every time. The archives are built by the module refreshes
+	// when AssetMirrorUploader is set, see modules.NormalizeArchive.
+	ModuleStableArchives bool
+
+	// ModuleRepositories maps `<namespace>/<name>/<system>` module addresses to the repositories of the modules which
+	// do not follow the naming convention, see ModuleRepository.
+	ModuleRepositories map[string]modules.Repository
+
+	// ProviderRepoNames are the names of the repositories of the providers which do not follow the naming convention,
+	// see ProviderRepoName.
+	ProviderRepoNames providers.RepoNames
+
+	// MaintenanceMode makes the registry read-only: the writes are refused, nothing is refreshed, and the providers
+	// are only served from the cache.
+	MaintenanceMode bool
+
+	// ServeStale makes the stale cached module versions be served as they are while they are refreshed in the
+	// background, like the provider versions, instead of fetching them from GitHub during the request.
+	ServeStale bool
+
+	// DriftAutoHeal makes the drift checks add the missing versions to the cache and remove the deleted ones.
+	DriftAutoHeal bool
+
+	// GithubTokenPassthrough makes the requests carrying a bearer token of their own call GitHub with that token
+	// instead of the registry's, see WithClientToken.
+	GithubTokenPassthrough bool
+	clientToken            bool
+
+	// PrivateProviders are the providers only served to the requests carrying a token allowed to read them. Use
+	// CanReadProvider to check a request.
+	PrivateProviders access.ACLs
+	readToken        string
+
+	// AdminAPIToken is the bearer token required to call the admin endpoints. Empty if the admin API is disabled.
+	AdminAPIToken string
+
+	// ReleaseWebhookSecret is the secret the GitHub release webhooks are signed with. Empty unless the release webhook
+	// is included.
+	ReleaseWebhookSecret string
+
+	// Hostnames are the hostnames this registry is served on. The first one is the primary hostname, which is used
+	// for self-referencing URLs unless the request came in on one of the others.
+	Hostnames []string
+
+	// RequestThrottle, if set, rate limits the requests of each client, identified by its API key, bearer token or
+	// source IP. Without it, the clients are not rate limited.
+	RequestThrottle *throttle.Handler
+
+	// CORSAllowedOrigins are the origins, e.g. `https://registry-ui.example.com`, of the browser-based frontends
+	// allowed to call the API directly. `*` allows any origin. Empty disables CORS.
+	CORSAllowedOrigins []string
+
+	// ServiceDiscovery are the services advertised in `/.well-known/terraform.json`.
+	ServiceDiscovery ServiceDiscovery
+
+	// ProviderAssetPatterns match the release assets of providers not named the standard way, keyed by lowercase
+	// namespace or `<namespace>/<type>`. Use AssetPatterns to look them up.
+	ProviderAssetPatterns map[string]providers.AssetPatterns
+
+	// PlatformAllowlists restrict the platforms ingested and served, keyed by lowercase namespace or
+	// `<namespace>/<type>`, with "*" applying to the providers not listed. Use PlatformAllowlist to look them up.
+	PlatformAllowlists map[string]platform.Allowlist
+
+	// IngestionQuotas limit the work of a single provider refresh, keyed by lowercase namespace, with "*" applying to
+	// the namespaces without their own. Use IngestionLimits to look them up.
+	IngestionQuotas map[string]quota.Limits
+
+	// ChecksumReconcileVersions is how many of the newest versions of a provider have their cached checksums compared
+	// with the published SHA256SUMS on every refresh. Zero disables the reconciliation.
+	ChecksumReconcileVersions int
+
+	// RefreshQuarantineFailures is how many refreshes of a provider have to fail in a row for its refreshes to be
+	// quarantined. Zero disables the quarantine of refreshes.
+	RefreshQuarantineFailures int
+
+	// CacheTTL is how old the cached provider items get before they are refreshed, and ModuleCacheTTL the cached
+	// module versions. Zero durations stand for the defaults, see types.DefaultCacheTTL and modulecache.DefaultTTL.
+	CacheTTL       types.CacheTTL
+	ModuleCacheTTL time.Duration
+
+	// ResponseMaxAge is how long clients and CDNs may reuse the versions listings and downloads of cached providers.
+	// Zero stands for the default of the API.
+	ResponseMaxAge time.Duration
+
+	// RefreshWorkers is how many providers the population lambdas refresh or check for drift at a time, when they are
+	// given several at once.
+	RefreshWorkers int
+
+	// V1VersionsPageSize caps the number of versions in a v1 versions listing, with a continuation URL to the rest.
+	// Zero disables pagination of the v1 listing.
+	V1VersionsPageSize int
+
+	// Faults, if set, injects failures into the calls to GitHub and the provider cache, for testing in staging only.
+	// The handlers carry it in the context of their requests, see faults.WithInjector.
+	Faults *faults.Injector
+
+	// Tenants maps tenant hostnames to the logical registries served on them.
+	Tenants map[string]*Tenant
+	// TenantName is the name of the tenant this configuration is scoped to, empty for the main registry.
+	TenantName string
 }
 
 // BuildConfig will build a configuration object for the application. This
@@ -69,9 +371,44 @@ func (c Builder) BuildConfig(ctx context.Context, xraySegmentName string) (confi
 
 	secretsHandler := secrets.NewHandler(awsConfig)
 
-	githubAPIToken, err := secretsHandler.GetSecretValueFromEnvReference(ctx, "GITHUB_TOKEN_SECRET_ASM_NAME")
+	// without an installation of a GitHub App to authenticate as, the registry's static token is used
+	var githubTokenSource oauth2.TokenSource
+	if c.IncludeGitHubAppAuth {
+		githubTokenSource, err = buildGithubAppTokenSource(ctx, secretsHandler)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var managedGithubClient *gogithub.Client
+	var rawGithubv4Client *githubv4.Client
+	if githubTokenSource != nil {
+		managedGithubClient, rawGithubv4Client = github.NewAppGithubClients(githubTokenSource)
+	} else {
+		var githubAPIToken string
+		githubAPIToken, err = secretsHandler.GetSecretValueFromEnvReference(ctx, "GITHUB_TOKEN_SECRET_ASM_NAME")
+		if err != nil {
+			err = fmt.Errorf("could not get GitHub API token: %w", err)
+			return nil, err
+		}
+		githubTokenSource = oauth2.StaticTokenSource(&oauth2.Token{AccessToken: githubAPIToken})
+		managedGithubClient, rawGithubv4Client = github.NewManagedGithubClient(githubAPIToken), github.NewRawGithubv4Client(githubAPIToken)
+	}
+
+	githubTokens, err := buildGithubTokenPool(ctx, secretsHandler, githubTokenSource)
+	if err != nil {
+		return nil, err
+	}
+	if len(githubTokens) > 1 {
+		managedGithubClient, rawGithubv4Client = github.NewPooledGithubClients(github.NewTokenPool(githubTokens))
+	}
+
+	namespaceGithubClients, err := buildNamespaceGithubClients(ctx, secretsHandler)
+	if err != nil {
+		return nil, err
+	}
+
+	privateProviders, err := buildPrivateProviders(ctx, secretsHandler)
 	if err != nil {
-		err = fmt.Errorf("could not get GitHub API token: %w", err)
 		return nil, err
 	}
 
@@ -87,29 +424,550 @@ func (c Builder) BuildConfig(ctx context.Context, xraySegmentName string) (confi
 			if err := json.Unmarshal([]byte(redirectsJSON), &providerRedirects); err != nil {
 				panic(fmt.Errorf("could not parse PROVIDER_NAMESPACE_REDIRECTS: %w", err))
 			}
+			if err := validateProviderRedirects(providerRedirects); err != nil {
+				return nil, fmt.Errorf("invalid PROVIDER_NAMESPACE_REDIRECTS: %w", err)
+			}
+		}
+	}
+
+	var dynamicProviderRedirects *redirects.Loader
+	if redirectsTableName := os.Getenv("PROVIDER_REDIRECTS_TABLE_NAME"); c.IncludeProviderRedirects && redirectsTableName != "" {
+		var refreshInterval time.Duration
+		if refreshInterval, err = parseTTL("PROVIDER_REDIRECTS_REFRESH_INTERVAL"); err != nil {
+			return nil, err
+		}
+		dynamicProviderRedirects = redirects.NewLoader(redirects.NewHandler(awsConfig, redirectsTableName), refreshInterval)
+	}
+
+	var moduleSourceRewrites []modules.RewriteRule
+	if c.IncludeModuleRewrites {
+		if rewritesJSON, ok := os.LookupEnv("MODULE_SOURCE_REWRITES"); ok {
+			if moduleSourceRewrites, err = modules.ParseRewriteRules(rewritesJSON); err != nil {
+				return nil, fmt.Errorf("could not parse MODULE_SOURCE_REWRITES: %w", err)
+			}
+		}
+	}
+
+	var deprecationsStore *deprecations.Handler
+	if c.IncludeDeprecations {
+		var deprecationsTableName string
+		if deprecationsTableName, err = requiredEnv("DEPRECATIONS_TABLE_NAME"); err != nil {
+			return nil, err
+		}
+		deprecationsStore = deprecations.NewHandler(awsConfig, deprecationsTableName)
+	}
+
+	var providerAliasesStore *aliases.Handler
+	var providerAliases *aliases.Loader
+	if c.IncludeProviderAliases {
+		var aliasesTableName string
+		if aliasesTableName, err = requiredEnv("PROVIDER_ALIASES_TABLE_NAME"); err != nil {
+			return nil, err
+		}
+		providerAliasesStore = aliases.NewHandler(awsConfig, aliasesTableName)
+		providerAliases = aliases.NewLoader(providerAliasesStore, aliases.DefaultRefreshInterval)
+	}
+
+	var quarantineStore *quarantine.Handler
+	var checksumReconcileVersions, refreshQuarantineFailures int
+	if c.IncludeQuarantine {
+		var quarantineTableName string
+		if quarantineTableName, err = requiredEnv("QUARANTINE_TABLE_NAME"); err != nil {
+			return nil, err
+		}
+		quarantineStore = quarantine.NewHandler(awsConfig, quarantineTableName)
+
+		if value := os.Getenv("CHECKSUM_RECONCILE_VERSIONS"); value != "" {
+			if checksumReconcileVersions, err = strconv.Atoi(value); err != nil || checksumReconcileVersions < 0 {
+				return nil, fmt.Errorf("invalid CHECKSUM_RECONCILE_VERSIONS %q", value)
+			}
+		}
+		if value := os.Getenv("REFRESH_QUARANTINE_FAILURES"); value != "" {
+			if refreshQuarantineFailures, err = strconv.Atoi(value); err != nil || refreshQuarantineFailures < 0 {
+				return nil, fmt.Errorf("invalid REFRESH_QUARANTINE_FAILURES %q", value)
+			}
+		}
+	}
+
+	var assetMirror mirror.Signer
+	var assetMirrorUploader *mirror.Uploader
+	if bucket := os.Getenv("ASSET_MIRROR_BUCKET"); bucket != "" {
+		ttl := mirror.DefaultURLTTL
+		if value := os.Getenv("ASSET_MIRROR_URL_TTL"); value != "" {
+			if ttl, err = time.ParseDuration(value); err != nil || ttl < time.Second {
+				return nil, fmt.Errorf("invalid ASSET_MIRROR_URL_TTL %q", value)
+			}
+		}
+		if assetMirror, err = buildAssetMirrorSigner(ctx, awsConfig, secretsHandler, bucket, ttl); err != nil {
+			return nil, err
+		}
+
+		if value := os.Getenv("ASSET_MIRROR_POPULATE"); value != "" {
+			populate, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid ASSET_MIRROR_POPULATE %q", value)
+			}
+			if populate {
+				assetMirrorUploader = mirror.NewUploader(awsConfig, bucket)
+			}
+		}
+	}
+
+	regionalMirrors, err := mirror.ParseRegional(os.Getenv("REGIONAL_MIRRORS"))
+	if err != nil {
+		return nil, err
+	}
+
+	var cacheTTL types.CacheTTL
+	if cacheTTL.Providers, err = parseTTL("PROVIDER_CACHE_TTL"); err != nil {
+		return nil, err
+	}
+	if cacheTTL.NotFound, err = parseTTL("NOT_FOUND_CACHE_TTL"); err != nil {
+		return nil, err
+	}
+	moduleCacheTTL, err := parseTTL("MODULE_CACHE_TTL")
+	if err != nil {
+		return nil, err
+	}
+	responseMaxAge, err := parseTTL("RESPONSE_MAX_AGE")
+	if err != nil {
+		return nil, err
+	}
+
+	var moduleVersionCache modulecache.Cache
+	if moduleTableName := os.Getenv("MODULE_VERSIONS_TABLE_NAME"); moduleTableName != "" {
+		moduleVersionCache = modulecache.NewHandler(awsConfig, moduleTableName, moduleCacheTTL)
+	}
+
+	var keyStore *keys.Handler
+	if keysTableName := os.Getenv("GPG_KEYS_TABLE_NAME"); keysTableName != "" {
+		keyStore = keys.NewHandler(awsConfig, keysTableName)
+	}
+
+	var downloadStats downloads.Store
+	if downloadsTableName := os.Getenv("DOWNLOAD_STATS_TABLE_NAME"); downloadsTableName != "" {
+		downloadStats = downloads.NewHandler(awsConfig, downloadsTableName)
+	}
+
+	var refreshQueue *queue.Queue
+	if queueURL := os.Getenv("REFRESH_QUEUE_URL"); queueURL != "" {
+		refreshQueue = queue.New(awsConfig, queueURL)
+	}
+
+	var repositoryMappings *repomappings.Handler
+	if mappingsTableName := os.Getenv("REPOSITORY_MAPPINGS_TABLE_NAME"); mappingsTableName != "" {
+		repositoryMappings = repomappings.NewHandler(awsConfig, mappingsTableName)
+	}
+
+	var releaseETags *etags.Handler
+	if etagsTableName := os.Getenv("RELEASE_ETAGS_TABLE_NAME"); etagsTableName != "" {
+		releaseETags = etags.NewHandler(awsConfig, etagsTableName)
+	}
+
+	var requestThrottle *throttle.Handler
+	if throttleTableName := os.Getenv("RATE_LIMIT_TABLE_NAME"); throttleTableName != "" {
+		limits, enabled, err := parseThrottleLimits()
+		if err != nil {
+			return nil, err
+		}
+		if enabled {
+			requestThrottle = throttle.NewHandler(awsConfig, throttleTableName, limits)
+		}
+	}
+
+	var ingestionSnapshots *snapshot.Store
+	if bucket := os.Getenv("INGESTION_SNAPSHOT_BUCKET"); bucket != "" {
+		ingestionSnapshots = snapshot.NewStore(awsConfig, bucket)
+	}
+
+	var largeListings *listings.Store
+	largeListingThreshold := listings.DefaultThreshold
+	if bucket := os.Getenv("LARGE_LISTINGS_BUCKET"); bucket != "" {
+		largeListings = listings.NewStore(awsConfig, bucket)
+		if value := os.Getenv("LARGE_LISTING_THRESHOLD"); value != "" {
+			if largeListingThreshold, err = strconv.Atoi(value); err != nil || largeListingThreshold < 1 {
+				return nil, fmt.Errorf("invalid LARGE_LISTING_THRESHOLD %q", value)
+			}
+		}
+	}
+
+	refreshWorkers := defaultRefreshWorkers
+	if value := os.Getenv("REFRESH_WORKERS"); value != "" {
+		if refreshWorkers, err = strconv.Atoi(value); err != nil || refreshWorkers < 1 {
+			return nil, fmt.Errorf("invalid REFRESH_WORKERS %q", value)
+		}
+	}
+
+	var v1VersionsPageSize int
+	if value := os.Getenv("V1_VERSIONS_PAGE_SIZE"); value != "" {
+		if v1VersionsPageSize, err = strconv.Atoi(value); err != nil || v1VersionsPageSize < 0 {
+			return nil, fmt.Errorf("invalid V1_VERSIONS_PAGE_SIZE %q", value)
+		}
+	}
+
+	providerRepoNames, err := providers.ParseRepoNames(os.Getenv("PROVIDER_REPOSITORIES"))
+	if err != nil {
+		return nil, err
+	}
+
+	moduleRepositories, err := modules.ParseRepositories(os.Getenv("MODULE_REPOSITORIES"))
+	if err != nil {
+		return nil, err
+	}
+
+	assetPatterns, err := parseAssetPatterns(os.Getenv("ASSET_NAME_PATTERNS"))
+	if err != nil {
+		return nil, err
+	}
+
+	platformAllowlists, err := parsePlatformAllowlists(os.Getenv("PLATFORM_ALLOWLIST"))
+	if err != nil {
+		return nil, err
+	}
+
+	ingestionQuotas, err := parseIngestionQuotas(os.Getenv("INGESTION_QUOTAS"))
+	if err != nil {
+		return nil, err
+	}
+
+	faultInjector, err := faults.Parse(os.Getenv("FAULT_INJECTION"))
+	if err != nil {
+		return nil, err
+	}
+
+	var ociClient *oci.Client
+	var ociProviders map[string]oci.Reference
+	if c.IncludeOCIProviders {
+		if ociClient, ociProviders, err = buildOCI(ctx, secretsHandler); err != nil {
+			return nil, err
+		}
+	}
+
+	var gitLab *vcs.GitLab
+	var gitLabNamespaces map[string]string
+	if c.IncludeGitLab {
+		if gitLab, gitLabNamespaces, err = buildGitLab(ctx, secretsHandler); err != nil {
+			return nil, err
+		}
+	}
+
+	cacheSchemaVersion := providercache.CurrentSchemaVersion
+	if value := os.Getenv("CACHE_SCHEMA_WRITE_VERSION"); value != "" {
+		if cacheSchemaVersion, err = strconv.Atoi(value); err != nil {
+			return nil, fmt.Errorf("invalid CACHE_SCHEMA_WRITE_VERSION %q", value)
+		}
+		if err = providercache.ValidateWriteSchemaVersion(cacheSchemaVersion); err != nil {
+			return nil, fmt.Errorf("invalid CACHE_SCHEMA_WRITE_VERSION: %w", err)
+		}
+	}
+
+	tenants, err := parseTenants(os.Getenv("REGISTRY_TENANTS"), func(tableName string) providercache.Cache {
+		return providercache.NewHandler(awsConfig, tableName, cacheSchemaVersion, largeListings, cacheTTL)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var maintenanceMode bool
+	if value := os.Getenv("MAINTENANCE_MODE"); value != "" {
+		if maintenanceMode, err = strconv.ParseBool(value); err != nil {
+			return nil, fmt.Errorf("invalid MAINTENANCE_MODE %q", value)
+		}
+	}
+
+	var serveStale bool
+	if value := os.Getenv("SERVE_STALE"); value != "" {
+		if serveStale, err = strconv.ParseBool(value); err != nil {
+			return nil, fmt.Errorf("invalid SERVE_STALE %q", value)
+		}
+	}
+
+	var moduleArchiveSources bool
+	if value := os.Getenv("MODULE_ARCHIVE_SOURCES"); value != "" {
+		if moduleArchiveSources, err = strconv.ParseBool(value); err != nil {
+			return nil, fmt.Errorf("invalid MODULE_ARCHIVE_SOURCES %q", value)
+		}
+	}
+
+	var moduleStableArchives bool
+	if value := os.Getenv("MODULE_STABLE_ARCHIVES"); value != "" {
+		if moduleStableArchives, err = strconv.ParseBool(value); err != nil {
+			return nil, fmt.Errorf("invalid MODULE_STABLE_ARCHIVES %q", value)
+		}
+		if moduleStableArchives && assetMirror == nil {
+			return nil, fmt.Errorf("MODULE_STABLE_ARCHIVES requires ASSET_MIRROR_BUCKET")
+		}
+	}
+
+	var driftAutoHeal bool
+	if value := os.Getenv("DRIFT_AUTO_HEAL"); value != "" {
+		if driftAutoHeal, err = strconv.ParseBool(value); err != nil {
+			return nil, fmt.Errorf("invalid DRIFT_AUTO_HEAL %q", value)
+		}
+	}
+
+	var githubTokenPassthrough bool
+	if value := os.Getenv("GITHUB_TOKEN_PASSTHROUGH"); value != "" {
+		if githubTokenPassthrough, err = strconv.ParseBool(value); err != nil {
+			return nil, fmt.Errorf("invalid GITHUB_TOKEN_PASSTHROUGH %q", value)
+		}
+	}
+
+	var releaseWebhookSecret string
+	if c.IncludeReleaseWebhook {
+		releaseWebhookSecret, err = secretsHandler.GetSecretValueFromEnvReference(ctx, "RELEASE_WEBHOOK_SECRET_ASM_NAME")
+		if err != nil {
+			err = fmt.Errorf("could not get release webhook secret: %w", err)
+			return nil, err
+		}
+	}
+
+	var serviceDiscovery ServiceDiscovery
+	if c.IncludeServiceDiscovery {
+		if serviceDiscovery, err = buildServiceDiscovery(); err != nil {
+			return nil, err
+		}
+	}
+
+	var adminAPIToken string
+	if c.IncludeAdminAPI {
+		adminAPIToken, err = secretsHandler.GetSecretValueFromEnvReference(ctx, "ADMIN_API_TOKEN_SECRET_ASM_NAME")
+		if err != nil {
+			err = fmt.Errorf("could not get admin API token: %w", err)
+			return nil, err
 		}
 	}
 
 	config = &Config{
-		ManagedGithubClient: github.NewManagedGithubClient(githubAPIToken),
-		RawGithubv4Client:   github.NewRawGithubv4Client(githubAPIToken),
+		ManagedGithubClient: managedGithubClient,
+		RawGithubv4Client:   rawGithubv4Client,
+
+		NamespaceGithubClients: namespaceGithubClients,
 
 		SecretsHandler:       secretsHandler,
-		ProviderVersionCache: providercache.NewHandler(awsConfig, tableName),
+		ProviderVersionCache: providercache.NewHandler(awsConfig, tableName, cacheSchemaVersion, largeListings, cacheTTL),
 		LambdaClient:         lambda.NewFromConfig(awsConfig),
+		DeprecationsStore:    deprecationsStore,
+		ProviderAliasesStore: providerAliasesStore,
+		ProviderAliases:      providerAliases,
+		RepositoryMappings:   repositoryMappings,
+		QuarantineStore:      quarantineStore,
+		ModuleVersionCache:   moduleVersionCache,
+		KeyStore:             keyStore,
+		DownloadStats:        downloadStats,
+		ReleaseETags:         releaseETags,
+		RefreshQueue:         refreshQueue,
+
+		SignatureVerifications: providers.NewSignatureCache(),
+
+		ProviderRedirects:    providerRedirects,
+		ModuleSourceRewrites: moduleSourceRewrites,
+		ModuleArchiveSources: moduleArchiveSources,
+		ModuleStableArchives: moduleStableArchives,
+		ModuleRepositories:   moduleRepositories,
+		ProviderRepoNames:    providerRepoNames,
+
+		DynamicProviderRedirects: dynamicProviderRedirects,
+
+		OCIClient:    ociClient,
+		OCIProviders: ociProviders,
+
+		GitLab:           gitLab,
+		GitLabNamespaces: gitLabNamespaces,
+
+		AssetMirror:         assetMirror,
+		AssetMirrorUploader: assetMirrorUploader,
+		RegionalMirrors:     regionalMirrors,
+		IngestionSnapshots:  ingestionSnapshots,
 
-		ProviderRedirects: providerRedirects,
+		LargeListings:         largeListings,
+		LargeListingThreshold: largeListingThreshold,
+		LargeListingsURL:      strings.TrimSuffix(os.Getenv("LARGE_LISTINGS_URL"), "/"),
+
+		MaintenanceMode:        maintenanceMode,
+		ServeStale:             serveStale,
+		DriftAutoHeal:          driftAutoHeal,
+		GithubTokenPassthrough: githubTokenPassthrough,
+		PrivateProviders:       privateProviders,
+		AdminAPIToken:          adminAPIToken,
+		ReleaseWebhookSecret:   releaseWebhookSecret,
+
+		Hostnames: parseHostnames(os.Getenv("REGISTRY_HOSTNAMES")),
+		Tenants:   tenants,
+
+		RequestThrottle:    requestThrottle,
+		CORSAllowedOrigins: parseOrigins(os.Getenv("CORS_ALLOWED_ORIGINS")),
+
+		ServiceDiscovery: serviceDiscovery,
+
+		V1VersionsPageSize: v1VersionsPageSize,
+		IngestionQuotas:    ingestionQuotas,
+
+		ChecksumReconcileVersions: checksumReconcileVersions,
+		RefreshQuarantineFailures: refreshQuarantineFailures,
+		RefreshWorkers:            refreshWorkers,
+
+		CacheTTL:       cacheTTL,
+		ModuleCacheTTL: moduleCacheTTL,
+		ResponseMaxAge: responseMaxAge,
+
+		ProviderAssetPatterns: assetPatterns,
+		PlatformAllowlists:    platformAllowlists,
+
+		Faults: faultInjector,
 	}
+	config.filterPlatforms()
+	config.injectFaults()
 	return config, nil
 }
 
+// parseTTL parses the duration of a cache TTL from the environment variable, zero if it is not set.
+func parseTTL(name string) (time.Duration, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return 0, nil
+	}
+	ttl, err := time.ParseDuration(value)
+	if err != nil || ttl < time.Minute {
+		return 0, fmt.Errorf("invalid %s %q, expected a duration of at least a minute", name, value)
+	}
+	return ttl, nil
+}
+
+func requiredEnv(name string) (string, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return "", fmt.Errorf("%s environment variable not set", name)
+	}
+	return value, nil
+}
+
+func parseHostnames(value string) []string {
+	var hostnames []string
+	for _, hostname := range strings.Split(value, ",") {
+		if hostname = strings.ToLower(strings.TrimSpace(hostname)); hostname != "" {
+			hostnames = append(hostnames, hostname)
+		}
+	}
+	return hostnames
+}
+
+// parseOrigins parses the comma separated CORS_ALLOWED_ORIGINS value. Origins have no path, so a trailing slash is
+// dropped.
+func parseOrigins(value string) []string {
+	var origins []string
+	for _, origin := range strings.Split(value, ",") {
+		if origin = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(origin)), "/"); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
+}
+
+// Hostname returns the hostname to use in self-referencing URLs for a request that came in on requestHost. If the
+// request host is not one of the configured hostnames (or none is known), the primary hostname is used instead.
+func (c Config) Hostname(requestHost string) string {
+	requestHost = strings.ToLower(requestHost)
+	if host, _, err := net.SplitHostPort(requestHost); err == nil {
+		requestHost = host
+	}
+
+	for _, hostname := range c.Hostnames {
+		if hostname == requestHost {
+			return hostname
+		}
+	}
+
+	if len(c.Hostnames) > 0 {
+		return c.Hostnames[0]
+	}
+	return requestHost
+}
+
+// PublicURL builds an absolute URL to the given path on this registry, for a request that came in on requestHost.
+func (c Config) PublicURL(requestHost, path string) string {
+	return (&url.URL{Scheme: "https", Host: c.Hostname(requestHost), Path: path}).String()
+}
+
+// ProviderRepoName returns the name of the repository of the given provider, within its namespace.
+func (c Config) ProviderRepoName(namespace, providerType string) string {
+	return c.ProviderRepoNames.RepoName(namespace, providerType)
+}
+
+// ModuleRepository returns the repository of the given module: the one mapped to it in the repository mappings table
+// if any, else the one configured for it, else the one following the naming convention, in the GitLab group of the
+// namespace if it is mapped to one.
+func (c Config) ModuleRepository(ctx context.Context, namespace, name, system string) (modules.Repository, error) {
+	mapping, err := c.repositoryMapping(ctx, repomappings.ModuleAddress(namespace, name, system))
+	if err != nil {
+		return modules.Repository{}, err
+	}
+	if mapping != nil {
+		r := modules.Repository{Owner: mapping.Owner, Name: mapping.Repository, Subdirectory: mapping.Subdirectory, TagPrefix: mapping.TagPrefix}
+		if _, onGitLab := c.GitLabGroup(namespace); onGitLab {
+			r.Host = c.GitLab.BaseURL
+		}
+		return r, nil
+	}
+
+	if r, ok := c.ModuleRepositories[strings.ToLower(fmt.Sprintf("%s/%s/%s", namespace, name, system))]; ok {
+		return r, nil
+	}
+	if group, ok := c.GitLabGroup(namespace); ok {
+		r := modules.DefaultRepository(group, name, system)
+		r.Host = c.GitLab.BaseURL
+		return r, nil
+	}
+	return modules.DefaultRepository(namespace, name, system), nil
+}
+
 // EffectiveProviderNamespace will map namespaces for providers in situations
 // where the author (owner of the namespace) does not release artifacts as
-// GitHub Releases.
+// GitHub Releases. Chained redirects are followed to the namespace they end at.
 func (c Config) EffectiveProviderNamespace(namespace string) string {
-	if redirect, ok := c.ProviderRedirects[namespace]; ok {
-		return redirect
+	effective, err := resolveProviderNamespace(c.providerRedirects(), namespace)
+	if err != nil {
+		// the redirects are validated when loading the configuration and when stored, so this is not expected to happen
+		slog.Error("Could not resolve provider namespace redirects", "namespace", namespace, "error", err)
+		return namespace
 	}
+	return effective
+}
+
+// maxProviderRedirectDepth is the longest chain of namespace redirects followed.
+const maxProviderRedirectDepth = 8
 
-	return namespace
+// defaultRefreshWorkers is how many providers are refreshed at a time when REFRESH_WORKERS is not set.
+const defaultRefreshWorkers = 4
+
+// resolveProviderNamespace follows the redirects from namespace to the namespace they end at. Chains looping back on
+// themselves, or longer than maxProviderRedirectDepth, are errors.
+func resolveProviderNamespace(redirects map[string]string, namespace string) (string, error) {
+	chain := []string{namespace}
+	seen := map[string]bool{namespace: true}
+	for {
+		next, ok := redirects[chain[len(chain)-1]]
+		if !ok || next == chain[len(chain)-1] {
+			return chain[len(chain)-1], nil
+		}
+		chain = append(chain, next)
+		if seen[next] {
+			return "", fmt.Errorf("provider namespace redirects loop: %s", strings.Join(chain, " -> "))
+		}
+		if len(chain) > maxProviderRedirectDepth+1 {
+			return "", fmt.Errorf("provider namespace redirects from %s are longer than %d hops", namespace, maxProviderRedirectDepth)
+		}
+		seen[next] = true
+	}
+}
+
+// validateProviderRedirects checks that every redirect resolves, so that loops are reported on startup rather than
+// when serving requests.
+func validateProviderRedirects(redirects map[string]string) error {
+	for namespace := range redirects {
+		if _, err := resolveProviderNamespace(redirects, namespace); err != nil {
+			return err
+		}
+	}
+	return nil
 }
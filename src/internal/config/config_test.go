@@ -0,0 +1,253 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	gogithub "github.com/google/go-github/v54/github"
+	"github.com/opentofu/registry/internal/keys"
+	"github.com/opentofu/registry/internal/modules"
+	"github.com/opentofu/registry/internal/providers/types"
+	"github.com/opentofu/registry/internal/vcs"
+)
+
+func TestParseHostnames(t *testing.T) {
+	got := parseHostnames(" registry.opentofu.org, Registry.Example.com ,,")
+	want := []string{"registry.opentofu.org", "registry.example.com"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseHostnames() = %v, want %v", got, want)
+	}
+}
+
+func TestParseOrigins(t *testing.T) {
+	got := parseOrigins(" https://UI.example.com/, http://localhost:3000 ,,")
+	want := []string{"https://ui.example.com", "http://localhost:3000"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseOrigins() = %v, want %v", got, want)
+	}
+}
+
+func TestHostname(t *testing.T) {
+	config := Config{Hostnames: []string{"registry.opentofu.org", "registry.example.com"}}
+
+	tests := []struct {
+		name        string
+		requestHost string
+		want        string
+	}{
+		{name: "primary hostname", requestHost: "registry.opentofu.org", want: "registry.opentofu.org"},
+		{name: "alternate hostname", requestHost: "Registry.Example.com", want: "registry.example.com"},
+		{name: "alternate hostname with port", requestHost: "registry.example.com:443", want: "registry.example.com"},
+		{name: "unknown hostname", requestHost: "abc123.execute-api.eu-west-1.amazonaws.com", want: "registry.opentofu.org"},
+		{name: "no hostname", requestHost: "", want: "registry.opentofu.org"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := config.Hostname(tt.requestHost); got != tt.want {
+				t.Errorf("Hostname(%q) = %q, want %q", tt.requestHost, got, tt.want)
+			}
+		})
+	}
+
+	if got := config.PublicURL("registry.example.com", "/v1/providers/"); got != "https://registry.example.com/v1/providers/" {
+		t.Errorf("PublicURL() = %q", got)
+	}
+}
+
+func TestGithubClientForNamespace(t *testing.T) {
+	defaultClient := gogithub.NewClient(nil)
+	namespaceClient := gogithub.NewClient(nil)
+	config := Config{
+		ManagedGithubClient:    defaultClient,
+		NamespaceGithubClients: map[string]GithubClients{"example": {Managed: namespaceClient}},
+	}
+
+	if config.GithubClient("Example") != namespaceClient {
+		t.Errorf("expected the namespace client for a namespace with its own token")
+	}
+	if config.GithubClient("other") != defaultClient {
+		t.Errorf("expected the default client for a namespace without its own token")
+	}
+}
+
+func TestEffectiveProviderNamespace(t *testing.T) {
+	config := Config{ProviderRedirects: map[string]string{"a": "b", "b": "c", "self": "self"}}
+
+	tests := map[string]string{"a": "c", "b": "c", "c": "c", "self": "self", "other": "other"}
+	for namespace, want := range tests {
+		if got := config.EffectiveProviderNamespace(namespace); got != want {
+			t.Errorf("EffectiveProviderNamespace(%q) = %q, want %q", namespace, got, want)
+		}
+	}
+}
+
+func TestModuleRepository(t *testing.T) {
+	monorepo := modules.Repository{Owner: "example", Name: "infrastructure", Subdirectory: "modules/vpc"}
+	config := Config{ModuleRepositories: map[string]modules.Repository{"example/vpc/aws": monorepo}}
+
+	ctx := context.Background()
+
+	if got, err := config.ModuleRepository(ctx, "Example", "vpc", "aws"); err != nil || got != monorepo {
+		t.Errorf("ModuleRepository() = %+v, %v, want the configured repository", got, err)
+	}
+	if got, err := config.ModuleRepository(ctx, "example", "subnet", "aws"); err != nil || got != modules.DefaultRepository("example", "subnet", "aws") {
+		t.Errorf("ModuleRepository() = %+v, %v, want the conventional repository", got, err)
+	}
+
+	config.GitLab = vcs.NewGitLab("https://gitlab.example.com/", "")
+	config.GitLabNamespaces = map[string]string{"acme": "acme/terraform"}
+	want := modules.Repository{Host: "https://gitlab.example.com", Owner: "acme/terraform", Name: "terraform-aws-subnet"}
+	if got, err := config.ModuleRepository(ctx, "Acme", "subnet", "aws"); err != nil || got != want {
+		t.Errorf("ModuleRepository() = %+v, %v, want %+v", got, err, want)
+	}
+}
+
+func TestParseGitLabNamespaces(t *testing.T) {
+	namespaces, err := parseGitLabNamespaces(`{"Acme": "/acme/terraform/"}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if namespaces["acme"] != "acme/terraform" {
+		t.Errorf("namespaces = %v", namespaces)
+	}
+
+	for _, value := range []string{`{`, `{"acme": ""}`, `{"acme": "/"}`} {
+		if _, err := parseGitLabNamespaces(value); err == nil {
+			t.Errorf("parseGitLabNamespaces(%s) succeeded, want an error", value)
+		}
+	}
+}
+
+func TestValidateProviderRedirects(t *testing.T) {
+	if err := validateProviderRedirects(map[string]string{"a": "b", "b": "c"}); err != nil {
+		t.Errorf("unexpected error for a chain: %v", err)
+	}
+
+	err := validateProviderRedirects(map[string]string{"a": "b", "b": "c", "c": "a"})
+	if err == nil || !strings.Contains(err.Error(), "loop") {
+		t.Errorf("expected a loop error, got %v", err)
+	}
+
+	long := make(map[string]string)
+	for i := 0; i <= maxProviderRedirectDepth; i++ {
+		long[fmt.Sprintf("ns%d", i)] = fmt.Sprintf("ns%d", i+1)
+	}
+	if err := validateProviderRedirects(long); err == nil {
+		t.Errorf("expected an error for a chain longer than %d hops", maxProviderRedirectDepth)
+	}
+}
+
+func TestMergeProviderRedirects(t *testing.T) {
+	merged := mergeProviderRedirects(map[string]string{"a": "b", "old": "static"}, map[string]string{"old": "dynamic", "c": "d"})
+
+	want := map[string]string{"a": "b", "old": "dynamic", "c": "d"}
+	if len(merged) != len(want) {
+		t.Fatalf("mergeProviderRedirects() = %v, want %v", merged, want)
+	}
+	for from, to := range want {
+		if merged[from] != to {
+			t.Errorf("mergeProviderRedirects()[%q] = %q, want %q", from, merged[from], to)
+		}
+	}
+}
+
+func TestMergeKeys(t *testing.T) {
+	now := time.Now()
+	expiredAt := now.Add(-time.Hour)
+	stored := keys.Active([]keys.Key{
+		{KeyID: "AAAA", ASCIIArmor: "duplicate"},
+		{KeyID: "BBBB", ASCIIArmor: "revoked", RevokedAt: &now},
+		{KeyID: "CCCC", ASCIIArmor: "registered"},
+		{KeyID: "DDDD", ASCIIArmor: "expired", ExpiresAt: &expiredAt},
+	}, now)
+	repository := []types.GPGPublicKey{{KeyID: "aaaa", ASCIIArmor: "repository"}}
+
+	got := mergeKeys(repository, stored)
+	want := []types.GPGPublicKey{{KeyID: "aaaa", ASCIIArmor: "repository"}, {KeyID: "CCCC", ASCIIArmor: "registered"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mergeKeys() = %v, want %v", got, want)
+	}
+}
+
+func TestParseTTL(t *testing.T) {
+	for value, want := range map[string]time.Duration{"": 0, "2h": 2 * time.Hour, "90s": 90 * time.Second} {
+		t.Setenv("PROVIDER_CACHE_TTL", value)
+		if got, err := parseTTL("PROVIDER_CACHE_TTL"); err != nil || got != want {
+			t.Errorf("parseTTL(%q) = %v, %v, want %v", value, got, err, want)
+		}
+	}
+	for _, value := range []string{"soon", "30s", "-1h"} {
+		t.Setenv("PROVIDER_CACHE_TTL", value)
+		if _, err := parseTTL("PROVIDER_CACHE_TTL"); err == nil {
+			t.Errorf("parseTTL(%q) did not fail", value)
+		}
+	}
+}
+
+func TestParseThrottleLimits(t *testing.T) {
+	if _, enabled, err := parseThrottleLimits(); enabled || err != nil {
+		t.Fatalf("parseThrottleLimits() without configuration = %v, %v", enabled, err)
+	}
+
+	t.Setenv("RATE_LIMIT_REQUESTS_PER_MINUTE", "0.5")
+	if limits, enabled, err := parseThrottleLimits(); !enabled || err != nil || limits.Burst != 1 {
+		t.Errorf("parseThrottleLimits() = %+v, %v, %v, want a burst of 1", limits, enabled, err)
+	}
+
+	t.Setenv("RATE_LIMIT_REQUESTS_PER_MINUTE", "600")
+	t.Setenv("RATE_LIMIT_BURST", "50")
+	if limits, enabled, err := parseThrottleLimits(); !enabled || err != nil || limits.RequestsPerMinute != 600 || limits.Burst != 50 {
+		t.Errorf("parseThrottleLimits() = %+v, %v, %v", limits, enabled, err)
+	}
+
+	for name, value := range map[string]string{
+		"RATE_LIMIT_REQUESTS_PER_MINUTE": "-1",
+		"RATE_LIMIT_BURST":               "-1",
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(name, value)
+			if _, _, err := parseThrottleLimits(); err == nil {
+				t.Errorf("expected an error for %s=%s", name, value)
+			}
+		})
+	}
+}
+
+func TestBuildServiceDiscovery(t *testing.T) {
+	discovery, err := buildServiceDiscovery()
+	if err != nil || !reflect.DeepEqual(discovery.Document(), map[string]any{"modules.v1": "/v1/modules/", "providers.v1": "/v1/providers/"}) {
+		t.Fatalf("buildServiceDiscovery() without configuration = %v, %v", discovery.Document(), err)
+	}
+
+	t.Setenv("SERVICE_DISCOVERY_PROVIDERS_V2", "true")
+	t.Setenv("SERVICE_DISCOVERY_LOGIN", `{"client":"registry","grant_types":["authz_code"],"authz":"/oauth/authorize","token":"/oauth/token","ports":[10000,10010]}`)
+	t.Setenv("SERVICE_DISCOVERY_SERVICES", `{"providers.mirror.v1":"https://mirror.example.com/"}`)
+	discovery, err = buildServiceDiscovery()
+	if err != nil {
+		t.Fatalf("buildServiceDiscovery() error = %v", err)
+	}
+	document := discovery.Document()
+	if document["providers.v2"] != "/v2/providers/" || document["providers.mirror.v1"] != "https://mirror.example.com/" {
+		t.Errorf("Document() = %v", document)
+	}
+	if login, ok := document["login.v1"].(*LoginService); !ok || login.Client != "registry" || login.Token != "/oauth/token" {
+		t.Errorf("Document()[login.v1] = %v", document["login.v1"])
+	}
+
+	for name, value := range map[string]string{
+		"SERVICE_DISCOVERY_LOGIN":    `{"client":"registry"}`,
+		"SERVICE_DISCOVERY_SERVICES": `{"mirror":"https://mirror.example.com/"}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(name, value)
+			if _, err := buildServiceDiscovery(); err == nil {
+				t.Errorf("expected an error for %s=%s", name, value)
+			}
+		})
+	}
+}
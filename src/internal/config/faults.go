@@ -0,0 +1,20 @@
+package config
+
+import (
+	"github.com/opentofu/registry/internal/providers/providercache"
+	"golang.org/x/exp/slog"
+)
+
+// injectFaults wraps the provider caches of the registry and its tenants with the fault injector, if faults are
+// configured. The GitHub clients pick it up from the context of their requests instead.
+func (c *Config) injectFaults() {
+	if c.Faults == nil {
+		return
+	}
+
+	slog.Warn("Fault injection is enabled, requests will fail on purpose")
+	c.ProviderVersionCache = providercache.FaultInjector{Cache: c.ProviderVersionCache, Injector: c.Faults}
+	for _, tenant := range c.Tenants {
+		tenant.ProviderVersionCache = providercache.FaultInjector{Cache: tenant.ProviderVersionCache, Injector: c.Faults}
+	}
+}
@@ -0,0 +1,150 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	gogithub "github.com/google/go-github/v54/github"
+	"github.com/opentofu/registry/internal/github"
+	"github.com/opentofu/registry/internal/secrets"
+	"github.com/shurcooL/githubv4"
+	"golang.org/x/oauth2"
+)
+
+// GithubClients are the GitHub clients authenticated with a namespace specific token.
+type GithubClients struct {
+	Managed *gogithub.Client
+	Rawv4   *githubv4.Client
+}
+
+// buildNamespaceGithubClients sets up the clients for the namespaces which have a GitHub token of their own.
+//
+// The tokens are optional and read from the secret named by NAMESPACE_GITHUB_TOKENS_SECRET_ASM_NAME, a JSON object
+// mapping each namespace to its token, e.g. `{"example": "ghp_..."}`.
+func buildNamespaceGithubClients(ctx context.Context, secretsHandler *secrets.Handler) (map[string]GithubClients, error) {
+	clients := make(map[string]GithubClients)
+	if os.Getenv("NAMESPACE_GITHUB_TOKENS_SECRET_ASM_NAME") == "" {
+		return clients, nil
+	}
+
+	tokensJSON, err := secretsHandler.GetSecretValueFromEnvReference(ctx, "NAMESPACE_GITHUB_TOKENS_SECRET_ASM_NAME")
+	if err != nil {
+		return nil, fmt.Errorf("could not get namespace GitHub tokens: %w", err)
+	}
+
+	var tokens map[string]string
+	if err := json.Unmarshal([]byte(tokensJSON), &tokens); err != nil {
+		// the value is a secret, so do not wrap the error which may quote it
+		return nil, fmt.Errorf("could not parse namespace GitHub tokens: invalid JSON object")
+	}
+	for namespace, token := range tokens {
+		if namespace == "" || token == "" {
+			return nil, fmt.Errorf("could not parse namespace GitHub tokens: namespace and token must not be empty")
+		}
+		namespace = strings.ToLower(namespace)
+		managed, rawv4 := github.NewNamespaceGithubClients(token, namespace)
+		clients[namespace] = GithubClients{Managed: managed, Rawv4: rawv4}
+	}
+	return clients, nil
+}
+
+// buildGithubTokenPool returns the sources of the tokens the registry's own GitHub calls are spread over: its token, or
+// GitHub App installation, then the additional tokens.
+//
+// The additional tokens are optional and read from the secret named by GITHUB_TOKEN_POOL_SECRET_ASM_NAME, a JSON array
+// of tokens, e.g. `["ghp_...", "ghs_..."]`.
+func buildGithubTokenPool(ctx context.Context, secretsHandler *secrets.Handler, defaultSource oauth2.TokenSource) ([]oauth2.TokenSource, error) {
+	sources := []oauth2.TokenSource{defaultSource}
+	if os.Getenv("GITHUB_TOKEN_POOL_SECRET_ASM_NAME") == "" {
+		return sources, nil
+	}
+
+	poolJSON, err := secretsHandler.GetSecretValueFromEnvReference(ctx, "GITHUB_TOKEN_POOL_SECRET_ASM_NAME")
+	if err != nil {
+		return nil, fmt.Errorf("could not get GitHub token pool: %w", err)
+	}
+
+	var additional []string
+	if err := json.Unmarshal([]byte(poolJSON), &additional); err != nil {
+		// the value is a secret, so do not wrap the error which may quote it
+		return nil, fmt.Errorf("could not parse GitHub token pool: invalid JSON array")
+	}
+	for _, token := range additional {
+		if token == "" {
+			return nil, fmt.Errorf("could not parse GitHub token pool: tokens must not be empty")
+		}
+		sources = append(sources, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token}))
+	}
+	return sources, nil
+}
+
+// buildGithubAppTokenSource returns the source of the installation tokens of the GitHub App the registry authenticates
+// as, nil if there is none.
+//
+// The app is configured by GITHUB_APP_ID and GITHUB_APP_INSTALLATION_ID, and its PEM encoded private key is read from
+// the secret named by GITHUB_APP_PRIVATE_KEY_SECRET_ASM_NAME.
+func buildGithubAppTokenSource(ctx context.Context, secretsHandler *secrets.Handler) (oauth2.TokenSource, error) {
+	appIDValue := os.Getenv("GITHUB_APP_ID")
+	if appIDValue == "" {
+		return nil, nil //nolint:nilnil // The static token is used instead.
+	}
+	appID, err := strconv.ParseInt(appIDValue, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GITHUB_APP_ID %q", appIDValue)
+	}
+	installationIDValue := os.Getenv("GITHUB_APP_INSTALLATION_ID")
+	installationID, err := strconv.ParseInt(installationIDValue, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GITHUB_APP_INSTALLATION_ID %q", installationIDValue)
+	}
+
+	privateKey, err := secretsHandler.GetSecretValueFromEnvReference(ctx, "GITHUB_APP_PRIVATE_KEY_SECRET_ASM_NAME")
+	if err != nil {
+		return nil, fmt.Errorf("could not get GitHub App private key: %w", err)
+	}
+	source, err := github.NewAppTokenSource(appID, installationID, []byte(privateKey))
+	if err != nil {
+		return nil, fmt.Errorf("could not set up GitHub App authentication: %w", err)
+	}
+	return source, nil
+}
+
+// GithubClient returns the GitHub REST client to use for the repositories of the given namespace: the one for its own
+// token if it has one, the registry's otherwise.
+func (c Config) GithubClient(namespace string) *gogithub.Client {
+	if clients, ok := c.NamespaceGithubClients[strings.ToLower(namespace)]; ok {
+		return clients.Managed
+	}
+	return c.ManagedGithubClient
+}
+
+// Githubv4Client returns the GitHub GraphQL client to use for the repositories of the given namespace, see
+// GithubClient.
+func (c Config) Githubv4Client(namespace string) *githubv4.Client {
+	if clients, ok := c.NamespaceGithubClients[strings.ToLower(namespace)]; ok {
+		return clients.Rawv4
+	}
+	return c.RawGithubv4Client
+}
+
+// WithClientToken returns a copy of the configuration making all the GitHub calls with a token sent by a registry
+// client, so that the repositories the token can read are served to that client only. The namespace tokens are not
+// used, the client's token decides what it can access. The module cache is shared by all the clients, so it is not
+// used either.
+func (c Config) WithClientToken(token string) Config {
+	c.ManagedGithubClient, c.RawGithubv4Client = github.NewClientTokenGithubClients(token)
+	c.NamespaceGithubClients = nil
+	c.ModuleVersionCache = nil
+	c.clientToken = true
+	return c
+}
+
+// UsesClientToken returns true if GitHub is called with a token sent by the client, so what GitHub answers does not
+// hold for the other clients.
+func (c Config) UsesClientToken() bool {
+	return c.clientToken
+}
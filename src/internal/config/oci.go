@@ -0,0 +1,52 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/opentofu/registry/internal/oci"
+	"github.com/opentofu/registry/internal/secrets"
+)
+
+// buildOCI sets up the client and the provider mapping for providers published as OCI artifacts.
+//
+// OCI_PROVIDERS maps `<namespace>/<type>` to the repository the provider is published to, e.g.
+// `{"example/foo": "ghcr.io/example/terraform-provider-foo"}`. Registry credentials are optional and read from the
+// secret named by OCI_REGISTRY_CREDENTIALS_SECRET_ASM_NAME, in the form `<username>:<password>`.
+func buildOCI(ctx context.Context, secretsHandler *secrets.Handler) (*oci.Client, map[string]oci.Reference, error) {
+	providers := make(map[string]oci.Reference)
+	if providersJSON, ok := os.LookupEnv("OCI_PROVIDERS"); ok && providersJSON != "" {
+		var references map[string]string
+		if err := json.Unmarshal([]byte(providersJSON), &references); err != nil {
+			return nil, nil, fmt.Errorf("could not parse OCI_PROVIDERS: %w", err)
+		}
+		for provider, value := range references {
+			ref, err := oci.ParseReference(value)
+			if err != nil {
+				return nil, nil, fmt.Errorf("could not parse OCI_PROVIDERS: %w", err)
+			}
+			providers[strings.ToLower(provider)] = ref
+		}
+	}
+
+	var username, password string
+	if os.Getenv("OCI_REGISTRY_CREDENTIALS_SECRET_ASM_NAME") != "" {
+		credentials, err := secretsHandler.GetSecretValueFromEnvReference(ctx, "OCI_REGISTRY_CREDENTIALS_SECRET_ASM_NAME")
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not get OCI registry credentials: %w", err)
+		}
+		username, password, _ = strings.Cut(credentials, ":")
+	}
+
+	return oci.NewClient(username, password), providers, nil
+}
+
+// OCIProvider returns the OCI repository the given provider is published to, if it is served from an OCI registry
+// rather than GitHub releases.
+func (c Config) OCIProvider(namespace, providerType string) (oci.Reference, bool) {
+	ref, ok := c.OCIProviders[strings.ToLower(fmt.Sprintf("%s/%s", namespace, providerType))]
+	return ref, ok
+}
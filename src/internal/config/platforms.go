@@ -0,0 +1,65 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/opentofu/registry/internal/platform"
+	"github.com/opentofu/registry/internal/providers/providercache"
+)
+
+// defaultPlatformAllowlistKey is the key of the allowlist applying to the providers without one of their own.
+const defaultPlatformAllowlistKey = "*"
+
+// parsePlatformAllowlists parses the PLATFORM_ALLOWLIST value, a JSON object mapping a namespace or a
+// `<namespace>/<type>` provider address to the platforms served for it, with "*" applying to the providers not
+// listed, e.g. `{"*": ["linux_*", "darwin_*", "windows_amd64"], "example/foo": ["linux_amd64"]}`.
+func parsePlatformAllowlists(value string) (map[string]platform.Allowlist, error) {
+	allowlists := make(map[string]platform.Allowlist)
+	if value == "" {
+		return allowlists, nil
+	}
+
+	var entries map[string][]string
+	if err := json.Unmarshal([]byte(value), &entries); err != nil {
+		return nil, fmt.Errorf("could not parse PLATFORM_ALLOWLIST: %w", err)
+	}
+	for key, platforms := range entries {
+		allowlist, err := platform.ParseAllowlist(platforms)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse PLATFORM_ALLOWLIST for %s: %w", key, err)
+		}
+		allowlists[strings.ToLower(key)] = allowlist
+	}
+	return allowlists, nil
+}
+
+// PlatformAllowlist returns the platforms served for the given provider: those configured for the provider if any,
+// else those configured for its namespace, else the default ones. An empty allowlist allows every platform.
+func (c Config) PlatformAllowlist(namespace, providerType string) platform.Allowlist {
+	if allowlist, ok := c.PlatformAllowlists[strings.ToLower(fmt.Sprintf("%s/%s", namespace, providerType))]; ok {
+		return allowlist
+	}
+	if allowlist, ok := c.PlatformAllowlists[strings.ToLower(namespace)]; ok {
+		return allowlist
+	}
+	return c.PlatformAllowlists[defaultPlatformAllowlistKey]
+}
+
+// filterPlatforms applies the platform allowlists to the provider caches of the registry and its tenants, so that
+// they are enforced both when ingesting and when serving the listings.
+func (c *Config) filterPlatforms() {
+	if len(c.PlatformAllowlists) == 0 {
+		return
+	}
+
+	allowlist := func(key string) platform.Allowlist {
+		namespace, providerType, _ := strings.Cut(key, "/")
+		return c.PlatformAllowlist(namespace, providerType)
+	}
+	c.ProviderVersionCache = providercache.PlatformFilter{Cache: c.ProviderVersionCache, Allowlist: allowlist}
+	for _, tenant := range c.Tenants {
+		tenant.ProviderVersionCache = providercache.PlatformFilter{Cache: tenant.ProviderVersionCache, Allowlist: allowlist}
+	}
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/opentofu/registry/internal/access"
+	"github.com/opentofu/registry/internal/secrets"
+)
+
+// buildPrivateProviders reads the ACLs of the private providers, optional, from the secret named by
+// PRIVATE_PROVIDERS_SECRET_ASM_NAME, see access.Parse.
+func buildPrivateProviders(ctx context.Context, secretsHandler *secrets.Handler) (access.ACLs, error) {
+	if os.Getenv("PRIVATE_PROVIDERS_SECRET_ASM_NAME") == "" {
+		return make(access.ACLs), nil
+	}
+
+	value, err := secretsHandler.GetSecretValueFromEnvReference(ctx, "PRIVATE_PROVIDERS_SECRET_ASM_NAME")
+	if err != nil {
+		return nil, fmt.Errorf("could not get private providers: %w", err)
+	}
+	return access.Parse(value)
+}
+
+// WithReadToken returns a copy of the configuration for a request carrying the given token, which decides the private
+// providers it can read, see CanReadProvider.
+func (c Config) WithReadToken(token string) Config {
+	c.readToken = token
+	return c
+}
+
+// CanReadProvider returns true if the request can read the provider: both the requested address and the one its
+// namespace is redirected to must be public or allowed for the token of the request.
+func (c Config) CanReadProvider(namespace, providerType string) bool {
+	return c.PrivateProviders.CanRead(namespace, providerType, c.readToken) &&
+		c.PrivateProviders.CanRead(c.EffectiveProviderNamespace(namespace), providerType, c.readToken)
+}
+
+// ProviderIsPrivate returns true if the provider, or the one its namespace is redirected to, is private.
+func (c Config) ProviderIsPrivate(namespace, providerType string) bool {
+	return c.PrivateProviders.Private(namespace, providerType) ||
+		c.PrivateProviders.Private(c.EffectiveProviderNamespace(namespace), providerType)
+}
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/opentofu/registry/internal/quota"
+)
+
+// defaultQuotaKey is the key of the quota applying to the namespaces without one of their own.
+const defaultQuotaKey = "*"
+
+// parseIngestionQuotas parses the INGESTION_QUOTAS value, a JSON object mapping namespaces to their limits, e.g.
+// `{"*": {"releases_per_run": 50}, "example": {"releases_per_run": 10, "github_calls": 200}}`.
+func parseIngestionQuotas(value string) (map[string]quota.Limits, error) {
+	quotas := make(map[string]quota.Limits)
+	if value == "" {
+		return quotas, nil
+	}
+
+	var parsed map[string]quota.Limits
+	if err := json.Unmarshal([]byte(value), &parsed); err != nil {
+		return nil, fmt.Errorf("could not parse INGESTION_QUOTAS: %w", err)
+	}
+	for namespace, limits := range parsed {
+		if limits.GithubCalls < 0 || limits.ReleasesPerRun < 0 || limits.AssetBytes < 0 {
+			return nil, fmt.Errorf("could not parse INGESTION_QUOTAS: negative limit for %q", namespace)
+		}
+		quotas[strings.ToLower(namespace)] = limits
+	}
+	return quotas, nil
+}
+
+// IngestionLimits returns the limits of a single refresh of a provider in the given namespace.
+func (c Config) IngestionLimits(namespace string) quota.Limits {
+	if limits, ok := c.IngestionQuotas[strings.ToLower(namespace)]; ok {
+		return limits
+	}
+	return c.IngestionQuotas[defaultQuotaKey]
+}
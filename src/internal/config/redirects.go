@@ -0,0 +1,47 @@
+package config
+
+import (
+	"context"
+	"time"
+
+	"github.com/opentofu/registry/internal/redirects"
+)
+
+// providerRedirectsLoadTimeout bounds how long a request waits for the dynamic redirects to be loaded again.
+const providerRedirectsLoadTimeout = 5 * time.Second
+
+// providerRedirects returns the namespace redirects in effect: ProviderRedirects, overridden by the dynamic redirects
+// for the namespaces redirected in both.
+func (c Config) providerRedirects() map[string]string {
+	if c.DynamicProviderRedirects == nil {
+		return c.ProviderRedirects
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), providerRedirectsLoadTimeout)
+	defer cancel()
+	dynamic := c.DynamicProviderRedirects.Redirects(ctx)
+	if len(dynamic) == 0 {
+		return c.ProviderRedirects
+	}
+	return mergeProviderRedirects(c.ProviderRedirects, dynamic)
+}
+
+func mergeProviderRedirects(static, dynamic map[string]string) map[string]string {
+	merged := make(map[string]string, len(static)+len(dynamic))
+	for from, to := range static {
+		merged[from] = to
+	}
+	for from, to := range dynamic {
+		merged[from] = to
+	}
+	return merged
+}
+
+// CheckProviderRedirect checks that adding the redirect to the stored dynamic redirects would not make any chain of
+// redirects loop or grow longer than maxProviderRedirectDepth. The stored redirects should be read from the table
+// rather than the cache, to account for the changes made on other instances.
+func (c Config) CheckProviderRedirect(stored []redirects.Redirect, redirect redirects.Redirect) error {
+	dynamic := redirects.Map(stored)
+	dynamic[redirect.From] = redirect.To
+	return validateProviderRedirects(mergeProviderRedirects(c.ProviderRedirects, dynamic))
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+)
+
+// ServiceDiscovery configures the services advertised in `/.well-known/terraform.json` on top of `modules.v1` and
+// `providers.v1`, which are always advertised. The zero value advertises only those two.
+type ServiceDiscovery struct {
+	// ProvidersV2 advertises the `/v2/providers/` endpoints as `providers.v2`.
+	ProvidersV2 bool
+	// Login, if set, is advertised as `login.v1`, for `tofu login` to get a token from.
+	Login *LoginService
+	// Services are further services by service ID, e.g. `{"providers.mirror.v1": "https://mirror.example.com/"}`,
+	// advertised as they are. They take precedence over the services above.
+	Services map[string]any
+}
+
+// LoginService is the `login.v1` service, an OAuth 2.0 client of the authorization server issuing the tokens the
+// registry accepts.
+type LoginService struct {
+	Client     string   `json:"client"`
+	GrantTypes []string `json:"grant_types,omitempty"`
+	Authz      string   `json:"authz,omitempty"`
+	Token      string   `json:"token"`
+	Ports      []int    `json:"ports,omitempty"`
+}
+
+//nolint:gochecknoglobals // This should be treated as a constant.
+var serviceIDPattern = regexp.MustCompile(`^[a-z][a-z0-9_-]*(\.[a-z0-9_-]+)*\.v[0-9]+$`)
+
+// buildServiceDiscovery reads the services to advertise from SERVICE_DISCOVERY_PROVIDERS_V2, SERVICE_DISCOVERY_LOGIN,
+// a JSON object like LoginService, and SERVICE_DISCOVERY_SERVICES, a JSON object of further services by service ID.
+func buildServiceDiscovery() (ServiceDiscovery, error) {
+	var discovery ServiceDiscovery
+	if value := os.Getenv("SERVICE_DISCOVERY_PROVIDERS_V2"); value != "" {
+		providersV2, err := strconv.ParseBool(value)
+		if err != nil {
+			return discovery, fmt.Errorf("invalid SERVICE_DISCOVERY_PROVIDERS_V2 %q", value)
+		}
+		discovery.ProvidersV2 = providersV2
+	}
+
+	if value := os.Getenv("SERVICE_DISCOVERY_LOGIN"); value != "" && value != "null" {
+		var login LoginService
+		if err := json.Unmarshal([]byte(value), &login); err != nil {
+			return discovery, fmt.Errorf("could not parse SERVICE_DISCOVERY_LOGIN: %w", err)
+		}
+		if login.Client == "" || login.Token == "" {
+			return discovery, fmt.Errorf("invalid SERVICE_DISCOVERY_LOGIN: client and token are required")
+		}
+		discovery.Login = &login
+	}
+
+	if value := os.Getenv("SERVICE_DISCOVERY_SERVICES"); value != "" {
+		if err := json.Unmarshal([]byte(value), &discovery.Services); err != nil {
+			return discovery, fmt.Errorf("could not parse SERVICE_DISCOVERY_SERVICES: %w", err)
+		}
+		for id := range discovery.Services {
+			if !serviceIDPattern.MatchString(id) {
+				return discovery, fmt.Errorf("invalid SERVICE_DISCOVERY_SERVICES: %q is not a service ID like providers.v1", id)
+			}
+		}
+	}
+
+	return discovery, nil
+}
+
+// Document returns the service discovery document served on `/.well-known/terraform.json`.
+func (s ServiceDiscovery) Document() map[string]any {
+	document := map[string]any{
+		"modules.v1":   "/v1/modules/",
+		"providers.v1": "/v1/providers/",
+	}
+	if s.ProvidersV2 {
+		document["providers.v2"] = "/v2/providers/"
+	}
+	if s.Login != nil {
+		document["login.v1"] = s.Login
+	}
+	for id, service := range s.Services {
+		document[id] = service
+	}
+	return document
+}
@@ -0,0 +1,136 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/opentofu/registry/internal/keys"
+	"github.com/opentofu/registry/internal/providers"
+	"github.com/opentofu/registry/internal/providers/providercache"
+	"github.com/opentofu/registry/internal/providers/types"
+)
+
+// Tenant is a logical registry served from the same deployment on its own hostname. Each tenant has its own provider
+// cache table, namespace redirects and key store.
+type Tenant struct {
+	Name                 string
+	ProviderVersionCache providercache.Cache
+	ProviderRedirects    map[string]string
+}
+
+// TenantSettings is the configuration of a single tenant, as found in the REGISTRY_TENANTS environment variable.
+type TenantSettings struct {
+	Name                       string            `json:"name"`
+	ProviderVersionsTableName  string            `json:"provider_versions_table_name"`
+	ProviderNamespaceRedirects map[string]string `json:"provider_namespace_redirects"`
+}
+
+// parseTenants parses the REGISTRY_TENANTS value, a JSON object mapping each tenant hostname to its settings. The
+// provider cache of each tenant is made by newCache from the name of its table.
+func parseTenants(value string, newCache func(tableName string) providercache.Cache) (map[string]*Tenant, error) {
+	tenants := make(map[string]*Tenant)
+	if value == "" {
+		return tenants, nil
+	}
+
+	var settings map[string]TenantSettings
+	if err := json.Unmarshal([]byte(value), &settings); err != nil {
+		return nil, fmt.Errorf("could not parse REGISTRY_TENANTS: %w", err)
+	}
+
+	for hostname, s := range settings {
+		if s.Name == "" || s.ProviderVersionsTableName == "" {
+			return nil, fmt.Errorf("tenant for %s must have a name and a provider_versions_table_name", hostname)
+		}
+		redirects := s.ProviderNamespaceRedirects
+		if redirects == nil {
+			redirects = make(map[string]string)
+		}
+		if err := validateProviderRedirects(redirects); err != nil {
+			return nil, fmt.Errorf("invalid provider_namespace_redirects of tenant for %s: %w", hostname, err)
+		}
+		tenants[strings.ToLower(hostname)] = &Tenant{
+			Name:                 s.Name,
+			ProviderVersionCache: newCache(s.ProviderVersionsTableName),
+			ProviderRedirects:    redirects,
+		}
+	}
+
+	return tenants, nil
+}
+
+// ForHost returns the configuration for a request made to the given hostname. Requests to a tenant hostname get a
+// copy of the configuration scoped to that tenant, any other hostname gets the main registry configuration.
+func (c Config) ForHost(requestHost string) Config {
+	hostname := strings.ToLower(requestHost)
+	tenant, ok := c.Tenants[hostname]
+	if !ok {
+		return c
+	}
+	return c.withTenant(hostname, tenant)
+}
+
+// ForTenant returns the configuration scoped to the tenant with the given name, or the main registry configuration if
+// the name is empty or unknown.
+func (c Config) ForTenant(name string) Config {
+	if name == "" {
+		return c
+	}
+	for hostname, tenant := range c.Tenants {
+		if tenant.Name == name {
+			return c.withTenant(hostname, tenant)
+		}
+	}
+	return c
+}
+
+func (c Config) withTenant(hostname string, tenant *Tenant) Config {
+	c.TenantName = tenant.Name
+	c.ProviderVersionCache = tenant.ProviderVersionCache
+	c.ProviderRedirects = tenant.ProviderRedirects
+	c.DynamicProviderRedirects = nil
+	c.Hostnames = []string{hostname}
+	return c
+}
+
+// KeysForNamespace returns the GPG public keys for the given namespace from the key store of the current tenant: the
+// keys of the repository, and the active keys registered through the admin API.
+func (c Config) KeysForNamespace(ctx context.Context, namespace string) ([]types.GPGPublicKey, error) {
+	var publicKeys []types.GPGPublicKey
+	var err error
+	if c.TenantName != "" {
+		publicKeys, err = providers.KeysForTenantNamespace(c.TenantName, namespace)
+	} else {
+		publicKeys, err = providers.KeysForNamespace(namespace)
+	}
+	if err != nil || c.KeyStore == nil {
+		return publicKeys, err
+	}
+
+	stored, err := c.KeyStore.Get(ctx, keys.Owner(c.TenantName, namespace))
+	if err != nil {
+		return nil, fmt.Errorf("could not get stored keys: %w", err)
+	}
+	return mergeKeys(publicKeys, keys.Active(stored, time.Now())), nil
+}
+
+// mergeKeys appends the stored keys which are not already in the repository ones.
+func mergeKeys(repository, stored []types.GPGPublicKey) []types.GPGPublicKey {
+	merged := repository
+	for _, key := range stored {
+		known := false
+		for _, k := range merged {
+			if strings.EqualFold(k.KeyID, key.KeyID) {
+				known = true
+				break
+			}
+		}
+		if !known {
+			merged = append(merged, key)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+
+	"github.com/opentofu/registry/internal/throttle"
+)
+
+// parseThrottleLimits parses the rate limit of the clients from RATE_LIMIT_REQUESTS_PER_MINUTE and RATE_LIMIT_BURST.
+// The clients are not rate limited when RATE_LIMIT_REQUESTS_PER_MINUTE is not set or zero, and the burst defaults to
+// a minute of requests when RATE_LIMIT_BURST is not set or zero.
+func parseThrottleLimits() (throttle.Limits, bool, error) {
+	value := os.Getenv("RATE_LIMIT_REQUESTS_PER_MINUTE")
+	if value == "" {
+		return throttle.Limits{}, false, nil
+	}
+	requestsPerMinute, err := strconv.ParseFloat(value, 64)
+	if err != nil || requestsPerMinute < 0 || math.IsNaN(requestsPerMinute) || math.IsInf(requestsPerMinute, 0) {
+		return throttle.Limits{}, false, fmt.Errorf("invalid RATE_LIMIT_REQUESTS_PER_MINUTE %q", value)
+	}
+	if requestsPerMinute == 0 {
+		return throttle.Limits{}, false, nil
+	}
+
+	limits := throttle.Limits{RequestsPerMinute: requestsPerMinute, Burst: int(math.Max(1, math.Ceil(requestsPerMinute)))}
+	if value := os.Getenv("RATE_LIMIT_BURST"); value != "" && value != "0" {
+		if limits.Burst, err = strconv.Atoi(value); err != nil || limits.Burst < 1 {
+			return throttle.Limits{}, false, fmt.Errorf("invalid RATE_LIMIT_BURST %q", value)
+		}
+	}
+	return limits, true, nil
+}
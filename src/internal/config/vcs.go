@@ -0,0 +1,137 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/opentofu/registry/internal/modules"
+	"github.com/opentofu/registry/internal/repomappings"
+	"github.com/opentofu/registry/internal/secrets"
+	"github.com/opentofu/registry/internal/vcs"
+)
+
+// buildGitLab sets up the GitLab instance the namespaces mapped to GitLab groups release their providers and modules
+// on.
+//
+// GITLAB_NAMESPACES maps namespaces to the full path of their GitLab group, e.g. `{"example": "example/terraform"}`.
+// GITLAB_URL is the URL of the instance, gitlab.com unless set. The access token is optional, without it only the
+// public projects can be read, and is read from the secret named by GITLAB_TOKEN_SECRET_ASM_NAME.
+func buildGitLab(ctx context.Context, secretsHandler *secrets.Handler) (*vcs.GitLab, map[string]string, error) {
+	namespaces, err := parseGitLabNamespaces(os.Getenv("GITLAB_NAMESPACES"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	baseURL := os.Getenv("GITLAB_URL")
+	if baseURL == "" {
+		baseURL = vcs.DefaultGitLabURL
+	}
+	if !strings.HasPrefix(baseURL, "https://") && !strings.HasPrefix(baseURL, "http://") {
+		return nil, nil, fmt.Errorf("invalid GITLAB_URL %q", baseURL)
+	}
+
+	var token string
+	if os.Getenv("GITLAB_TOKEN_SECRET_ASM_NAME") != "" {
+		if token, err = secretsHandler.GetSecretValueFromEnvReference(ctx, "GITLAB_TOKEN_SECRET_ASM_NAME"); err != nil {
+			return nil, nil, fmt.Errorf("could not get GitLab token: %w", err)
+		}
+	}
+
+	return vcs.NewGitLab(baseURL, token), namespaces, nil
+}
+
+func parseGitLabNamespaces(value string) (map[string]string, error) {
+	namespaces := make(map[string]string)
+	if value == "" {
+		return namespaces, nil
+	}
+
+	var groups map[string]string
+	if err := json.Unmarshal([]byte(value), &groups); err != nil {
+		return nil, fmt.Errorf("could not parse GITLAB_NAMESPACES: %w", err)
+	}
+	for namespace, group := range groups {
+		group = strings.Trim(group, "/")
+		if namespace == "" || group == "" {
+			return nil, fmt.Errorf("invalid GITLAB_NAMESPACES entry %q: %q", namespace, group)
+		}
+		namespaces[strings.ToLower(namespace)] = group
+	}
+	return namespaces, nil
+}
+
+// GitLabGroup returns the GitLab group the repositories of the given namespace are in, if they are on GitLab rather
+// than GitHub.
+func (c Config) GitLabGroup(namespace string) (string, bool) {
+	if c.GitLab == nil {
+		return "", false
+	}
+	group, ok := c.GitLabNamespaces[strings.ToLower(namespace)]
+	return group, ok
+}
+
+// VCS returns the code host of the repositories of the given namespace, and the owner of the repositories on it: the
+// GitLab group the namespace is mapped to, or the namespace itself on GitHub.
+func (c Config) VCS(namespace string) (vcs.Host, string) {
+	if group, ok := c.GitLabGroup(namespace); ok {
+		return c.GitLab, group
+	}
+	return c.githubHost(namespace), namespace
+}
+
+func (c Config) githubHost(owner string) vcs.Host {
+	return vcs.GitHub{REST: c.GithubClient(owner), GraphQL: c.Githubv4Client(owner)}
+}
+
+// RepositoryHost returns the code host of the given module repository, only listing the releases of the module if
+// they have a tag prefix.
+func (c Config) RepositoryHost(repo modules.Repository) vcs.Host {
+	if repo.Host != "" && c.GitLab != nil {
+		return vcs.WithTagPrefix(c.GitLab, repo.TagPrefix)
+	}
+	return vcs.WithTagPrefix(c.githubHost(repo.Owner), repo.TagPrefix)
+}
+
+// ProviderSource is the repository the releases of a provider are published in.
+type ProviderSource struct {
+	Host  vcs.Host
+	Owner string
+	Name  string
+	// TagPrefix is the prefix of the tags of the releases of the provider, already applied by Host. It tells apart the
+	// providers released from the same repository.
+	TagPrefix string
+}
+
+// ProviderSource returns the repository of the given provider, on the code host of its namespace: the one mapped to
+// it in the repository mappings table if any, else the one of ProviderRepoName.
+func (c Config) ProviderSource(ctx context.Context, namespace, providerType string) (ProviderSource, error) {
+	mapping, err := c.repositoryMapping(ctx, repomappings.ProviderAddress(namespace, providerType))
+	if err != nil {
+		return ProviderSource{}, err
+	}
+
+	host, owner := c.VCS(namespace)
+	if mapping == nil {
+		return ProviderSource{Host: host, Owner: owner, Name: c.ProviderRepoName(namespace, providerType)}, nil
+	}
+	if _, onGitLab := c.GitLabGroup(namespace); !onGitLab {
+		host = c.githubHost(mapping.Owner)
+	}
+	return ProviderSource{Host: vcs.WithTagPrefix(host, mapping.TagPrefix), Owner: mapping.Owner, Name: mapping.Repository, TagPrefix: mapping.TagPrefix}, nil
+}
+
+// repositoryMapping returns the repository mapped to the provider or module at the given address, nil if there is
+// none or the registry has no repository mappings table.
+func (c Config) repositoryMapping(ctx context.Context, address string) (*repomappings.Mapping, error) {
+	if c.RepositoryMappings == nil {
+		return nil, nil //nolint:nilnil // The repositories follow the configuration or the convention.
+	}
+	mapping, err := c.RepositoryMappings.Get(ctx, address)
+	if err != nil {
+		return nil, fmt.Errorf("could not look up the repository of %s: %w", address, err)
+	}
+	return mapping, nil
+}
@@ -0,0 +1,77 @@
+// Package deprecations holds the deprecation records that maintainers attach to providers, modules or ranges of their
+// versions, and turns them into the warnings we surface in the versions responses.
+package deprecations
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/opentofu/registry/internal/version"
+)
+
+// Deprecation marks a provider or module (or a range of its versions) as deprecated.
+type Deprecation struct {
+	// VersionRange restricts the deprecation to the matching versions, e.g. ">= 1.0.0, < 2.0.0".
+	// If empty, the deprecation applies to every version.
+	VersionRange string    `json:"version_range,omitempty" dynamodbav:"version_range"`
+	Message      string    `json:"message" dynamodbav:"message"`
+	Replacement  string    `json:"replacement,omitempty" dynamodbav:"replacement"` // The suggested replacement address.
+	CreatedAt    time.Time `json:"created_at" dynamodbav:"created_at"`
+}
+
+// Validate checks that the deprecation can be stored and evaluated.
+func (d Deprecation) Validate() error {
+	if d.Message == "" {
+		return fmt.Errorf("message is required")
+	}
+	if _, err := version.ParseConstraints(d.VersionRange); err != nil {
+		return fmt.Errorf("invalid version_range: %w", err)
+	}
+	return nil
+}
+
+// Matches returns true if the given version falls within the deprecated version range.
+func (d Deprecation) Matches(v string) bool {
+	constraints, err := version.ParseConstraints(d.VersionRange)
+	if err != nil {
+		return false
+	}
+	return constraints.Check(v)
+}
+
+// Warning renders the deprecation as a human-readable warning.
+func (d Deprecation) Warning() string {
+	var sb strings.Builder
+	if d.VersionRange == "" {
+		sb.WriteString("Deprecated: ")
+	} else {
+		sb.WriteString(fmt.Sprintf("Versions matching %q are deprecated: ", d.VersionRange))
+	}
+	sb.WriteString(d.Message)
+	if d.Replacement != "" {
+		sb.WriteString(fmt.Sprintf(" Use %s instead.", d.Replacement))
+	}
+	return sb.String()
+}
+
+// Warnings renders a warning for each deprecation that affects at least one of the given versions.
+// Deprecations without a version range always produce a warning.
+func Warnings(deprecations []Deprecation, versions []string) []string {
+	var warnings []string
+	for _, d := range deprecations {
+		if d.VersionRange == "" || anyMatches(d, versions) {
+			warnings = append(warnings, d.Warning())
+		}
+	}
+	return warnings
+}
+
+func anyMatches(d Deprecation, versions []string) bool {
+	for _, v := range versions {
+		if d.Matches(v) {
+			return true
+		}
+	}
+	return false
+}
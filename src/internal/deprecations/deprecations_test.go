@@ -0,0 +1,63 @@
+package deprecations
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDeprecationMatches(t *testing.T) {
+	tests := []struct {
+		name         string
+		versionRange string
+		version      string
+		want         bool
+	}{
+		{name: "empty range matches everything", versionRange: "", version: "1.2.3", want: true},
+		{name: "exact match", versionRange: "1.2.3", version: "1.2.3", want: true},
+		{name: "exact mismatch", versionRange: "= 1.2.3", version: "1.2.4", want: false},
+		{name: "within range", versionRange: ">= 1.0.0, < 2.0.0", version: "1.9.9", want: true},
+		{name: "range upper bound", versionRange: ">= 1.0.0, < 2.0.0", version: "2.0.0", want: false},
+		{name: "range lower bound", versionRange: ">= 1.0.0, < 2.0.0", version: "0.9.0", want: false},
+		{name: "prefixed and prerelease", versionRange: "<= 1.2", version: "v1.2.0-beta1", want: true},
+		{name: "not equal", versionRange: "!= 3.0.0", version: "3.0.1", want: true},
+		{name: "invalid version", versionRange: "> 1.0.0", version: "latest", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Deprecation{VersionRange: tt.versionRange, Message: "test"}
+			if got := d.Matches(tt.version); got != tt.want {
+				t.Errorf("Matches(%q) = %v, want %v", tt.version, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeprecationValidate(t *testing.T) {
+	if err := (Deprecation{}).Validate(); err == nil {
+		t.Fatal("expected an error for a missing message")
+	}
+	if err := (Deprecation{Message: "m", VersionRange: ">= one"}).Validate(); err == nil {
+		t.Fatal("expected an error for an invalid version range")
+	}
+	if err := (Deprecation{Message: "m", VersionRange: ">= 1.0.0, < 2.0.0"}).Validate(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	deprecations := []Deprecation{
+		{Message: "Archived.", Replacement: "opentofu/other"},
+		{VersionRange: "< 1.0.0", Message: "Old versions are insecure."},
+		{VersionRange: ">= 5.0.0", Message: "Never released."},
+	}
+
+	got := Warnings(deprecations, []string{"0.9.0", "1.0.0"})
+	want := []string{
+		"Deprecated: Archived. Use opentofu/other instead.",
+		`Versions matching "< 1.0.0" are deprecated: Old versions are insecure.`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Warnings() = %v, want %v", got, want)
+	}
+}
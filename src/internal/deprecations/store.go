@@ -0,0 +1,98 @@
+package deprecations
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"golang.org/x/exp/slog"
+)
+
+type Handler struct {
+	TableName *string
+	Client    *dynamodb.Client
+}
+
+func NewHandler(awsConfig aws.Config, tableName string) *Handler {
+	ddbClient := dynamodb.NewFromConfig(awsConfig)
+
+	return &Handler{
+		TableName: aws.String(tableName),
+		Client:    ddbClient,
+	}
+}
+
+// Item is the record stored in DynamoDB, holding all of the deprecations for a single address.
+type Item struct {
+	Address      string        `dynamodbav:"address"`
+	Deprecations []Deprecation `dynamodbav:"deprecations"`
+}
+
+// ProviderAddress returns the key under which deprecations for a provider are stored.
+func ProviderAddress(namespace, providerType string) string {
+	return fmt.Sprintf("providers/%s/%s", namespace, providerType)
+}
+
+// ModuleAddress returns the key under which deprecations for a module are stored.
+func ModuleAddress(namespace, name, system string) string {
+	return fmt.Sprintf("modules/%s/%s/%s", namespace, name, system)
+}
+
+// Get returns the deprecations recorded for the given address, or nil if there are none.
+func (h *Handler) Get(ctx context.Context, address string) ([]Deprecation, error) {
+	result, err := h.Client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: h.TableName,
+		Key: map[string]types.AttributeValue{
+			"address": &types.AttributeValueMemberS{Value: address},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deprecations: %w", err)
+	}
+
+	if len(result.Item) == 0 {
+		return nil, nil
+	}
+
+	var item Item
+	if err := attributevalue.UnmarshalMap(result.Item, &item); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal deprecations: %w", err)
+	}
+	return item.Deprecations, nil
+}
+
+// Put replaces the deprecations recorded for the given address.
+func (h *Handler) Put(ctx context.Context, address string, deprecations []Deprecation) error {
+	marshalledItem, err := attributevalue.MarshalMap(Item{Address: address, Deprecations: deprecations})
+	if err != nil {
+		return fmt.Errorf("failed to marshal deprecations: %w", err)
+	}
+
+	slog.Info("Storing deprecations", "address", address, "deprecations", len(deprecations))
+	_, err = h.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		Item:      marshalledItem,
+		TableName: h.TableName,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store deprecations: %w", err)
+	}
+	return nil
+}
+
+// Delete removes all deprecations recorded for the given address.
+func (h *Handler) Delete(ctx context.Context, address string) error {
+	slog.Info("Deleting deprecations", "address", address)
+	_, err := h.Client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: h.TableName,
+		Key: map[string]types.AttributeValue{
+			"address": &types.AttributeValueMemberS{Value: address},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete deprecations: %w", err)
+	}
+	return nil
+}
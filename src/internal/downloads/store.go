@@ -0,0 +1,253 @@
+// Package downloads records the downloads served by the registry, counted per version and platform.
+package downloads
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"golang.org/x/exp/slog"
+)
+
+type Handler struct {
+	TableName *string
+	Client    *dynamodb.Client
+}
+
+func NewHandler(awsConfig aws.Config, tableName string) *Handler {
+	ddbClient := dynamodb.NewFromConfig(awsConfig)
+
+	return &Handler{
+		TableName: aws.String(tableName),
+		Client:    ddbClient,
+	}
+}
+
+// Count is the number of downloads of a version of a provider or module, on a single platform for providers.
+type Count struct {
+	Subject          string    `dynamodbav:"subject"`
+	Entry            string    `dynamodbav:"entry"`
+	Version          string    `dynamodbav:"version"`
+	Platform         string    `dynamodbav:"platform,omitempty"`
+	Downloads        int64     `dynamodbav:"downloads"`
+	LastDownloadedAt time.Time `dynamodbav:"last_downloaded_at"`
+}
+
+// Store is the storage of the download counts. Handler keeps them in DynamoDB.
+type Store interface {
+	// Record counts a download of a version of the subject, on the given platform, `<os>_<arch>`, empty for modules.
+	Record(ctx context.Context, subject Subject, version, platform string) error
+	// Get returns all of the download counts of the subject.
+	Get(ctx context.Context, subject Subject) ([]Count, error)
+	// Top returns the downloads of the providers or modules of a tenant over the last days, up to MaxTopDays, most
+	// downloaded first.
+	Top(ctx context.Context, tenant, kind string, days int) ([]Total, error)
+}
+
+var _ Store = (*Handler)(nil)
+
+// The kinds of subjects whose downloads are counted.
+const (
+	KindProviders = "providers"
+	KindModules   = "modules"
+)
+
+// Subject is a provider or module whose downloads are counted. Tenants have their own counts, the downloads of a
+// provider of the main registry are not shared with them.
+type Subject struct {
+	Tenant string
+	Kind   string
+	// Address is `<namespace>/<type>` for providers and `<namespace>/<name>/<system>` for modules.
+	Address string
+}
+
+// ProviderSubject returns the subject of the downloads of a provider.
+func ProviderSubject(tenant, namespace, providerType string) Subject {
+	return Subject{Tenant: tenant, Kind: KindProviders, Address: strings.ToLower(fmt.Sprintf("%s/%s", namespace, providerType))}
+}
+
+// ModuleSubject returns the subject of the downloads of a module.
+func ModuleSubject(tenant, namespace, name, system string) Subject {
+	return Subject{Tenant: tenant, Kind: KindModules, Address: strings.ToLower(fmt.Sprintf("%s/%s/%s", namespace, name, system))}
+}
+
+// key returns the key under which the downloads of the subject are counted, `<kind>/<address>`, prefixed with the
+// tenant for tenants.
+func (s Subject) key() string {
+	return withTenant(s.Tenant, fmt.Sprintf("%s/%s", s.Kind, s.Address))
+}
+
+// MaxTopDays is the longest period Top sums the downloads over. The daily rollups are kept a little longer, see
+// rollupRetention.
+const MaxTopDays = 30
+
+// rollupRetention is how long the daily rollups are kept before DynamoDB expires them.
+const rollupRetention = (MaxTopDays + 5) * 24 * time.Hour
+
+// rollupKey returns the key under which the downloads of the subjects of a kind are counted on the day,
+// `<kind>#<yyyy-mm-dd>` prefixed with the tenant for tenants, by subject address. The `#` keeps them apart from the
+// counts of the subjects, whose keys have no `#`.
+func rollupKey(tenant, kind string, day time.Time) string {
+	return withTenant(tenant, fmt.Sprintf("%s#%s", kind, day.UTC().Format("2006-01-02")))
+}
+
+func withTenant(tenant, key string) string {
+	if tenant == "" {
+		return key
+	}
+	return fmt.Sprintf("%s/%s", tenant, key)
+}
+
+// Record counts a download of a version of the subject, on the given platform, `<os>_<arch>`, empty for modules. The
+// download is also counted in the daily rollup of the kind of the subject, which Top sums up.
+func (h *Handler) Record(ctx context.Context, subject Subject, version, platform string) error {
+	entry := version
+	if platform != "" {
+		entry = fmt.Sprintf("%s/%s", version, platform)
+	}
+
+	now := time.Now()
+	marshalledNow, err := attributevalue.Marshal(now)
+	if err != nil {
+		return fmt.Errorf("failed to marshal download time: %w", err)
+	}
+
+	update := "ADD downloads :one SET #version = :version, last_downloaded_at = :now"
+	values := map[string]types.AttributeValue{
+		":one":     &types.AttributeValueMemberN{Value: "1"},
+		":version": &types.AttributeValueMemberS{Value: version},
+		":now":     marshalledNow,
+	}
+	if platform != "" {
+		update += ", platform = :platform"
+		values[":platform"] = &types.AttributeValueMemberS{Value: platform}
+	}
+
+	_, err = h.Client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: h.TableName,
+		Key: map[string]types.AttributeValue{
+			"subject": &types.AttributeValueMemberS{Value: subject.key()},
+			"entry":   &types.AttributeValueMemberS{Value: entry},
+		},
+		UpdateExpression: aws.String(update),
+		// version is a reserved word
+		ExpressionAttributeNames: map[string]string{
+			"#version": "version",
+		},
+		ExpressionAttributeValues: values,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record download: %w", err)
+	}
+
+	_, err = h.Client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: h.TableName,
+		Key: map[string]types.AttributeValue{
+			"subject": &types.AttributeValueMemberS{Value: rollupKey(subject.Tenant, subject.Kind, now)},
+			"entry":   &types.AttributeValueMemberS{Value: subject.Address},
+		},
+		UpdateExpression: aws.String("ADD downloads :one SET expires_at = :expires"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":one":     &types.AttributeValueMemberN{Value: "1"},
+			":expires": &types.AttributeValueMemberN{Value: strconv.FormatInt(now.Add(rollupRetention).Unix(), 10)},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record download in the daily rollup: %w", err)
+	}
+	slog.Info("Recorded download", "subject", subject.key(), "entry", entry)
+	return nil
+}
+
+// Get returns all of the download counts of the subject.
+func (h *Handler) Get(ctx context.Context, subject Subject) ([]Count, error) {
+	var counts []Count
+	paginator := dynamodb.NewQueryPaginator(h.Client, &dynamodb.QueryInput{
+		TableName:              h.TableName,
+		KeyConditionExpression: aws.String("#subject = :subject"),
+		ExpressionAttributeNames: map[string]string{
+			"#subject": "subject",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":subject": &types.AttributeValueMemberS{Value: subject.key()},
+		},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query download counts: %w", err)
+		}
+
+		var items []Count
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &items); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal download counts: %w", err)
+		}
+		counts = append(counts, items...)
+	}
+	return counts, nil
+}
+
+// Total is the number of downloads of a provider or module over a period.
+type Total struct {
+	Address   string `dynamodbav:"entry"`
+	Downloads int64  `dynamodbav:"downloads"`
+}
+
+// Top sums up the daily rollups of the last days, today included, one query per day.
+func (h *Handler) Top(ctx context.Context, tenant, kind string, days int) ([]Total, error) {
+	if days < 1 || days > MaxTopDays {
+		return nil, fmt.Errorf("the downloads can only be summed over 1 to %d days", MaxTopDays)
+	}
+
+	downloads := make(map[string]int64)
+	now := time.Now()
+	for day := 0; day < days; day++ {
+		paginator := dynamodb.NewQueryPaginator(h.Client, &dynamodb.QueryInput{
+			TableName:              h.TableName,
+			KeyConditionExpression: aws.String("#subject = :subject"),
+			ExpressionAttributeNames: map[string]string{
+				"#subject": "subject",
+			},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":subject": &types.AttributeValueMemberS{Value: rollupKey(tenant, kind, now.AddDate(0, 0, -day))},
+			},
+		})
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to query daily downloads: %w", err)
+			}
+
+			var items []Total
+			if err := attributevalue.UnmarshalListOfMaps(page.Items, &items); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal daily downloads: %w", err)
+			}
+			for _, item := range items {
+				downloads[item.Address] += item.Downloads
+			}
+		}
+	}
+	return rank(downloads), nil
+}
+
+// rank returns the totals of the addresses, most downloaded first and by address among equals.
+func rank(downloads map[string]int64) []Total {
+	totals := make([]Total, 0, len(downloads))
+	for address, n := range downloads {
+		totals = append(totals, Total{Address: address, Downloads: n})
+	}
+	sort.Slice(totals, func(i, j int) bool {
+		if totals[i].Downloads != totals[j].Downloads {
+			return totals[i].Downloads > totals[j].Downloads
+		}
+		return totals[i].Address < totals[j].Address
+	})
+	return totals
+}
@@ -0,0 +1,43 @@
+package downloads
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSubjectKey(t *testing.T) {
+	tests := []struct {
+		subject Subject
+		want    string
+	}{
+		{subject: ProviderSubject("", "Example", "Foo"), want: "providers/example/foo"},
+		{subject: ProviderSubject("acme", "example", "foo"), want: "acme/providers/example/foo"},
+		{subject: ModuleSubject("", "example", "vpc", "AWS"), want: "modules/example/vpc/aws"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.subject.key(); got != tt.want {
+			t.Errorf("%+v.key() = %q, want %q", tt.subject, got, tt.want)
+		}
+	}
+}
+
+func TestRollupKey(t *testing.T) {
+	// the day is the one of UTC
+	day := time.Date(2024, 3, 1, 23, 30, 0, 0, time.FixedZone("UTC-2", -2*60*60))
+	if got := rollupKey("", KindProviders, day); got != "providers#2024-03-02" {
+		t.Errorf("rollupKey() = %q", got)
+	}
+	if got := rollupKey("acme", KindModules, day); got != "acme/modules#2024-03-02" {
+		t.Errorf("rollupKey() = %q", got)
+	}
+}
+
+func TestRank(t *testing.T) {
+	totals := rank(map[string]int64{"example/b": 3, "example/a": 3, "example/c": 7})
+	want := []Total{{Address: "example/c", Downloads: 7}, {Address: "example/a", Downloads: 3}, {Address: "example/b", Downloads: 3}}
+	if !reflect.DeepEqual(totals, want) {
+		t.Errorf("rank() = %+v, want %+v", totals, want)
+	}
+}
@@ -0,0 +1,87 @@
+// Package etags remembers the ETags of the GitHub release listings of the repositories, so that the refreshes can
+// tell whether anything changed without fetching every release again.
+package etags
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"golang.org/x/exp/slog"
+)
+
+type Handler struct {
+	TableName *string
+	Client    *dynamodb.Client
+}
+
+func NewHandler(awsConfig aws.Config, tableName string) *Handler {
+	ddbClient := dynamodb.NewFromConfig(awsConfig)
+
+	return &Handler{
+		TableName: aws.String(tableName),
+		Client:    ddbClient,
+	}
+}
+
+// Item is the ETag of the release listing of a repository, as last seen by a refresh of the cache.
+type Item struct {
+	Repository string    `dynamodbav:"repository"`
+	ETag       string    `dynamodbav:"etag"`
+	CheckedAt  time.Time `dynamodbav:"checked_at"`
+}
+
+// Key returns the key under which the ETag of a repository is stored. Tenants have their own caches, refreshed
+// independently of the main registry's, so they have their own ETags too.
+func Key(tenant, owner, name string) string {
+	key := strings.ToLower(fmt.Sprintf("%s/%s", owner, name))
+	if tenant == "" {
+		return key
+	}
+	return fmt.Sprintf("%s/%s", tenant, key)
+}
+
+// Get returns the stored ETag of the repository, an empty string if there is none.
+func (h *Handler) Get(ctx context.Context, repository string) (string, error) {
+	output, err := h.Client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: h.TableName,
+		Key: map[string]types.AttributeValue{
+			"repository": &types.AttributeValueMemberS{Value: repository},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get ETag: %w", err)
+	}
+	if output.Item == nil {
+		return "", nil
+	}
+
+	var item Item
+	if err := attributevalue.UnmarshalMap(output.Item, &item); err != nil {
+		return "", fmt.Errorf("failed to unmarshal ETag: %w", err)
+	}
+	return item.ETag, nil
+}
+
+// Put stores the ETag of the repository.
+func (h *Handler) Put(ctx context.Context, repository, etag string) error {
+	marshalledItem, err := attributevalue.MarshalMap(Item{Repository: repository, ETag: etag, CheckedAt: time.Now()})
+	if err != nil {
+		return fmt.Errorf("failed to marshal ETag: %w", err)
+	}
+
+	slog.Info("Storing ETag", "repository", repository)
+	_, err = h.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		Item:      marshalledItem,
+		TableName: h.TableName,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store ETag: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package etags
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/opentofu/registry/internal/awstest"
+)
+
+// testTable serves the GetItem and PutItem calls of a Handler from memory.
+type testTable struct {
+	mu    sync.Mutex
+	items map[string]map[string]json.RawMessage
+}
+
+func newTestHandler(t *testing.T) *Handler {
+	table := &testTable{items: make(map[string]map[string]json.RawMessage)}
+	client := awstest.DynamoDBClient(t, table)
+	return &Handler{TableName: aws.String("etags"), Client: client}
+}
+
+func (table *testTable) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	table.mu.Lock()
+	defer table.mu.Unlock()
+
+	var input struct {
+		Key  map[string]struct{ S string }
+		Item map[string]json.RawMessage
+	}
+	_ = json.NewDecoder(r.Body).Decode(&input)
+
+	w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+	switch strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "DynamoDB_20120810.") {
+	case "GetItem":
+		_ = json.NewEncoder(w).Encode(map[string]any{"Item": table.items[input.Key["repository"].S]})
+	case "PutItem":
+		var repository struct{ S string }
+		_ = json.Unmarshal(input.Item["repository"], &repository)
+		table.items[repository.S] = input.Item
+		_, _ = w.Write([]byte(`{}`))
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	handler := newTestHandler(t)
+	ctx := context.Background()
+	key := Key("", "example", "terraform-provider-foo")
+
+	etag, err := handler.Get(ctx, key)
+	if err != nil || etag != "" {
+		t.Fatalf("Get() = %q, %v, want no ETag", etag, err)
+	}
+
+	if err := handler.Put(ctx, key, `W/"abc"`); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if etag, err := handler.Get(ctx, key); err != nil || etag != `W/"abc"` {
+		t.Errorf("Get() = %q, %v, want the stored ETag", etag, err)
+	}
+	if etag, err := handler.Get(ctx, Key("internal", "example", "terraform-provider-foo")); err != nil || etag != "" {
+		t.Errorf("Get() of the tenant = %q, %v, want the ETag of the main registry not to apply", etag, err)
+	}
+}
+
+func TestKey(t *testing.T) {
+	if got := Key("", "Example", "Terraform-Provider-Foo"); got != "example/terraform-provider-foo" {
+		t.Errorf("Key() = %q, want it lowercase", got)
+	}
+	if got := Key("internal", "example", "terraform-provider-foo"); got != "internal/example/terraform-provider-foo" {
+		t.Errorf("Key() of a tenant = %q, want it prefixed with the tenant", got)
+	}
+}
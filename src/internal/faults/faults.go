@@ -0,0 +1,154 @@
+// Package faults injects failures into the calls the registry makes to its dependencies, so that the way it copes with
+// them, such as serving stale listings, can be exercised in a staging environment. It is configured with the
+// FAULT_INJECTION environment variable and must never be enabled in production.
+package faults
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// The dependencies faults can be injected into.
+const (
+	// TargetGithub covers the calls to the GitHub REST (`rest`) and GraphQL (`graphql`) APIs, and the downloads of
+	// release assets (`asset`).
+	TargetGithub = "github"
+	// TargetProviderCache covers the `get_item`, `get_items`, `list_namespace` and `store` calls to the provider cache.
+	TargetProviderCache = "providercache"
+)
+
+// ErrInjected is wrapped by the errors returned for injected faults.
+var ErrInjected = errors.New("injected fault")
+
+// Rule describes a fault to inject into the calls to a dependency.
+type Rule struct {
+	Target string `json:"target"`
+	// Operation restricts the rule to one kind of call of the target, empty for all of them.
+	Operation string `json:"operation,omitempty"`
+	// Probability is the chance of a call to be affected, between 0 and 1. Defaults to 1.
+	Probability *float64 `json:"probability,omitempty"`
+	// Latency is added to the affected calls, as a Go duration.
+	Latency string `json:"latency,omitempty"`
+	// Error fails the affected calls with the given message.
+	Error string `json:"error,omitempty"`
+	// RateLimit answers the affected GitHub calls as if the rate limit of the token was exhausted.
+	RateLimit bool `json:"rate_limit,omitempty"`
+
+	latency time.Duration
+}
+
+// Fault is what happens to a single call.
+type Fault struct {
+	Latency   time.Duration
+	Err       error
+	RateLimit bool
+}
+
+// Injector decides which calls fail. A nil injector never injects anything.
+type Injector struct {
+	rules  []Rule
+	random func() float64
+}
+
+// Parse parses the FAULT_INJECTION value, a JSON list of rules. It returns nil if there are no rules.
+func Parse(value string) (*Injector, error) {
+	if value == "" {
+		return nil, nil //nolint:nilnil // No rules means no injector.
+	}
+
+	var rules []Rule
+	if err := json.Unmarshal([]byte(value), &rules); err != nil {
+		return nil, fmt.Errorf("could not parse FAULT_INJECTION: %w", err)
+	}
+	if len(rules) == 0 {
+		return nil, nil //nolint:nilnil // No rules means no injector.
+	}
+
+	for i := range rules {
+		rule := &rules[i]
+		if rule.Target != TargetGithub && rule.Target != TargetProviderCache {
+			return nil, fmt.Errorf("fault rule %d has an unknown target %q", i, rule.Target)
+		}
+		if rule.Probability != nil && (*rule.Probability < 0 || *rule.Probability > 1) {
+			return nil, fmt.Errorf("fault rule %d has a probability outside of [0, 1]", i)
+		}
+		if rule.Latency != "" {
+			latency, err := time.ParseDuration(rule.Latency)
+			if err != nil || latency < 0 {
+				return nil, fmt.Errorf("fault rule %d has an invalid latency %q", i, rule.Latency)
+			}
+			rule.latency = latency
+		}
+		if rule.RateLimit && rule.Target != TargetGithub {
+			return nil, fmt.Errorf("fault rule %d simulates a rate limit for %s, only github has one", i, rule.Target)
+		}
+		if rule.latency == 0 && rule.Error == "" && !rule.RateLimit {
+			return nil, fmt.Errorf("fault rule %d does not inject anything", i)
+		}
+	}
+
+	return &Injector{rules: rules, random: rand.Float64}, nil
+}
+
+// Fault returns the fault to inject into a call to the operation of the target. The latencies of all the matching
+// rules add up, the first matching rule failing the call wins.
+func (i *Injector) Fault(target, operation string) Fault {
+	var fault Fault
+	if i == nil {
+		return fault
+	}
+
+	for _, rule := range i.rules {
+		if rule.Target != target || (rule.Operation != "" && rule.Operation != operation) {
+			continue
+		}
+		if rule.Probability != nil && i.random() >= *rule.Probability {
+			continue
+		}
+		fault.Latency += rule.latency
+		if fault.Err != nil || fault.RateLimit {
+			continue
+		}
+		if rule.Error != "" {
+			fault.Err = fmt.Errorf("%w into %s %s: %s", ErrInjected, target, operation, rule.Error)
+		}
+		fault.RateLimit = rule.RateLimit
+	}
+	return fault
+}
+
+// Inject waits for the latency of the fault to inject into a call and returns its error, if any. Rate limits are left
+// to the caller, which knows how to answer like the dependency would.
+func (i *Injector) Inject(ctx context.Context, target, operation string) (Fault, error) {
+	fault := i.Fault(target, operation)
+	if fault.Latency > 0 {
+		timer := time.NewTimer(fault.Latency)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return fault, ctx.Err()
+		case <-timer.C:
+		}
+	}
+	return fault, fault.Err
+}
+
+type contextKey struct{}
+
+// WithInjector returns a context carrying the injector, which the clients of the dependencies consult on each call.
+func WithInjector(ctx context.Context, injector *Injector) context.Context {
+	if injector == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, contextKey{}, injector)
+}
+
+// FromContext returns the injector of the context, nil if there is none.
+func FromContext(ctx context.Context) *Injector {
+	injector, _ := ctx.Value(contextKey{}).(*Injector)
+	return injector
+}
@@ -0,0 +1,84 @@
+package faults
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	for _, value := range []string{"", "[]"} {
+		injector, err := Parse(value)
+		if err != nil || injector != nil {
+			t.Fatalf("Parse(%q) = %v, %v, want no injector", value, injector, err)
+		}
+	}
+
+	invalid := map[string]string{
+		"not json":          `{`,
+		"unknown target":    `[{"target": "s3", "error": "boom"}]`,
+		"probability":       `[{"target": "github", "error": "boom", "probability": 2}]`,
+		"latency":           `[{"target": "github", "latency": "soon"}]`,
+		"cache rate limit":  `[{"target": "providercache", "rate_limit": true}]`,
+		"nothing to inject": `[{"target": "github", "operation": "rest"}]`,
+	}
+	for name, value := range invalid {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Parse(value); err == nil {
+				t.Errorf("Parse(%s) succeeded, want an error", value)
+			}
+		})
+	}
+}
+
+func TestFault(t *testing.T) {
+	injector, err := Parse(`[
+		{"target": "github", "operation": "graphql", "rate_limit": true},
+		{"target": "github", "latency": "50ms"},
+		{"target": "providercache", "operation": "store", "error": "throttled", "probability": 0.5},
+		{"target": "providercache", "latency": "10ms", "probability": 0.1}
+	]`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	injector.random = func() float64 { return 0.3 }
+
+	if fault := injector.Fault(TargetGithub, "graphql"); !fault.RateLimit || fault.Latency != 50*time.Millisecond || fault.Err != nil {
+		t.Errorf("graphql fault = %+v", fault)
+	}
+	if fault := injector.Fault(TargetGithub, "rest"); fault.RateLimit || fault.Latency != 50*time.Millisecond {
+		t.Errorf("rest fault = %+v", fault)
+	}
+	if fault := injector.Fault(TargetProviderCache, "store"); !errors.Is(fault.Err, ErrInjected) || fault.Latency != 0 {
+		t.Errorf("store fault = %+v", fault)
+	}
+	if fault := injector.Fault(TargetProviderCache, "get_item"); fault != (Fault{}) {
+		t.Errorf("get_item fault = %+v, want none", fault)
+	}
+
+	var none *Injector
+	if fault := none.Fault(TargetGithub, "rest"); fault != (Fault{}) {
+		t.Errorf("nil injector fault = %+v, want none", fault)
+	}
+}
+
+func TestInject(t *testing.T) {
+	injector, err := Parse(`[{"target": "github", "latency": "1h"}]`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := injector.Inject(ctx, TargetGithub, "rest"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Inject() error = %v, want the context error while waiting", err)
+	}
+
+	if FromContext(WithInjector(context.Background(), injector)) != injector {
+		t.Errorf("expected the injector from the context")
+	}
+	if FromContext(WithInjector(context.Background(), nil)) != nil {
+		t.Errorf("expected no injector in the context")
+	}
+}
@@ -0,0 +1,127 @@
+package github
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// appTokenEarlyExpiry is how long before they expire the installation tokens are renewed, so that a token is not used
+// up while a request is under way. They are valid for an hour.
+const appTokenEarlyExpiry = 5 * time.Minute
+
+// appTokenSource mints the installation tokens of a GitHub App.
+type appTokenSource struct {
+	appID          int64
+	installationID int64
+	key            *rsa.PrivateKey
+
+	// baseURL is the GitHub REST API the tokens are minted with. The tokens are minted with a POST, which the API
+	// Gateway does not proxy, so it is GitHub itself.
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewAppTokenSource returns the source of the installation tokens of a GitHub App, authenticated with its PEM encoded
+// private key. The tokens are minted when first needed and renewed shortly before they expire.
+func NewAppTokenSource(appID, installationID int64, privateKeyPEM []byte) (oauth2.TokenSource, error) {
+	key, err := parseAppPrivateKey(privateKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	source := &appTokenSource{
+		appID:          appID,
+		installationID: installationID,
+		key:            key,
+		baseURL:        "https://api.github.com/",
+		httpClient:     &http.Client{Timeout: 30 * time.Second},
+	}
+	return oauth2.ReuseTokenSourceWithExpiry(nil, source, appTokenEarlyExpiry), nil
+}
+
+func parseAppPrivateKey(privateKeyPEM []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(privateKeyPEM)
+	if block == nil {
+		return nil, fmt.Errorf("failed to parse GitHub App private key: no PEM block found")
+	}
+
+	// GitHub generates PKCS #1 keys, PKCS #8 is accepted for keys which were converted
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub App private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("failed to parse GitHub App private key: not an RSA key")
+	}
+	return key, nil
+}
+
+// appJWT returns the JSON Web Token authenticating as the app itself. It is valid for the 10 minutes GitHub allows at
+// most, starting a minute in the past to allow for clock drift.
+func (s *appTokenSource) appJWT(now time.Time) (string, error) {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	claims, err := json.Marshal(map[string]int64{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": s.appID,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal JWT claims: %w", err)
+	}
+
+	signingInput := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign JWT: %w", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// Token mints a new installation token.
+func (s *appTokenSource) Token() (*oauth2.Token, error) {
+	jwt, err := s.appJWT(time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%sapp/installations/%d/access_tokens", s.baseURL, s.installationID), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mint installation token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("failed to mint installation token: unexpected status code %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode installation token: %w", err)
+	}
+	return &oauth2.Token{AccessToken: body.Token, Expiry: body.ExpiresAt}, nil
+}
@@ -0,0 +1,71 @@
+package github
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAppTokenSource(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/app/installations/42/access_tokens" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+
+		parts := strings.Split(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), ".")
+		if len(parts) != 3 {
+			t.Fatalf("Authorization is not a JWT")
+		}
+		signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+		digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature); err != nil {
+			t.Errorf("invalid JWT signature: %v", err)
+		}
+		claimsJSON, _ := base64.RawURLEncoding.DecodeString(parts[1])
+		var claims map[string]int64
+		if err := json.Unmarshal(claimsJSON, &claims); err != nil || claims["iss"] != 7 {
+			t.Errorf("claims = %s, want the app ID as issuer", claimsJSON)
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]any{"token": "ghs_installation", "expires_at": expiresAt})
+	}))
+	defer server.Close()
+
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	parsed, err := parseAppPrivateKey(keyPEM)
+	if err != nil {
+		t.Fatalf("parseAppPrivateKey() error = %v", err)
+	}
+	source := &appTokenSource{appID: 7, installationID: 42, key: parsed, baseURL: server.URL + "/", httpClient: server.Client()}
+
+	token, err := source.Token()
+	if err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+	if token.AccessToken != "ghs_installation" || !token.Expiry.Equal(expiresAt) {
+		t.Errorf("Token() = %+v", token)
+	}
+}
+
+func TestParseAppPrivateKeyInvalid(t *testing.T) {
+	if _, err := parseAppPrivateKey([]byte("not a key")); err == nil {
+		t.Error("expected an error for a value without a PEM block")
+	}
+}
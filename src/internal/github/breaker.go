@@ -0,0 +1,72 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/opentofu/registry/internal/quota"
+)
+
+// graphqlFailureThreshold is how many GraphQL calls in a row have to fail for the releases to be listed with the REST
+// API instead, see listReleases.
+const graphqlFailureThreshold = 3
+
+// graphqlCooldown is how long the releases are listed with the REST API once the GraphQL API failed, before it is
+// tried again.
+const graphqlCooldown = time.Minute
+
+// graphqlStatusError is how the GraphQL client starts the errors it returns for the responses with a status other than
+// 200 OK, such as the 502s GitHub answers with when the GraphQL API is degraded.
+const graphqlStatusError = "non-200 OK status code"
+
+// graphqlBreaker tracks the failures of the GraphQL API for all the clients of the process, as they all call the same
+// API.
+//
+//nolint:gochecknoglobals // The state of the GraphQL API is the same for the whole process.
+var graphqlBreaker = &breaker{threshold: graphqlFailureThreshold, cooldown: graphqlCooldown}
+
+// breaker is a circuit breaker: once threshold calls in a row failed, it stays open for cooldown, and the calls should
+// not be made. Once the cooldown is over, the next call goes ahead, and opens it again straight away if it fails too.
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+}
+
+// allow returns false while the breaker is open.
+func (b *breaker) allow(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return !now.Before(b.openUntil)
+}
+
+// record records the outcome of a call which went ahead.
+func (b *breaker) record(failed bool, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !failed {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openUntil = now.Add(b.cooldown)
+	}
+}
+
+// isGraphQLOutage returns true if a GraphQL call failed because of the API rather than because of the query, the
+// caller or its ingestion budget: the connection failed, or GitHub answered with an error status.
+func isGraphQLOutage(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, quota.ErrExceeded) {
+		return false
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr) || strings.Contains(err.Error(), graphqlStatusError)
+}
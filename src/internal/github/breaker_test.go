@@ -0,0 +1,62 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/opentofu/registry/internal/quota"
+)
+
+func TestBreaker(t *testing.T) {
+	b := &breaker{threshold: 2, cooldown: time.Minute}
+	now := time.Now()
+
+	b.record(true, now)
+	b.record(false, now)
+	b.record(true, now)
+	if !b.allow(now) {
+		t.Fatal("expected the breaker to stay closed after failures which were not in a row")
+	}
+
+	b.record(true, now)
+	if b.allow(now.Add(30 * time.Second)) {
+		t.Fatal("expected the breaker to open after two failures in a row")
+	}
+	if !b.allow(now.Add(time.Minute)) {
+		t.Fatal("expected the breaker to let a call through after the cooldown")
+	}
+
+	// a single failure after the cooldown opens it again
+	b.record(true, now.Add(time.Minute))
+	if b.allow(now.Add(90 * time.Second)) {
+		t.Fatal("expected the breaker to open again")
+	}
+	b.record(false, now.Add(2*time.Minute))
+	b.record(true, now.Add(2*time.Minute))
+	if !b.allow(now.Add(2 * time.Minute)) {
+		t.Fatal("expected a success to close the breaker")
+	}
+}
+
+func TestIsGraphQLOutage(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{fmt.Errorf("failed to query for releases: %w", &url.Error{Op: "Post", URL: "https://api.github.com/graphql", Err: errors.New("connection reset")}), true},
+		{errors.New(`non-200 OK status code: 502 Bad Gateway body: ""`), true},
+		{errors.New("Could not resolve to a Repository with the name 'example/missing'."), false},
+		{&url.Error{Op: "Post", URL: "https://api.github.com/graphql", Err: context.Canceled}, false},
+		{&url.Error{Op: "Post", URL: "https://api.github.com/graphql", Err: quota.ErrExceeded}, false},
+	}
+	for _, tt := range tests {
+		if got := isGraphQLOutage(tt.err); got != tt.want {
+			t.Errorf("isGraphQLOutage(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
@@ -3,28 +3,190 @@ package github
 import (
 	"context"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-xray-sdk-go/xray"
 	"github.com/google/go-github/v54/github"
+	"github.com/opentofu/registry/internal/faults"
+	"github.com/opentofu/registry/internal/metrics"
+	"github.com/opentofu/registry/internal/quota"
 	"github.com/shurcooL/githubv4"
 	"golang.org/x/oauth2"
 )
 
-func getGithubOauth2Client(token string) *http.Client {
-	return xray.Client(oauth2.NewClient(context.Background(), oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: token},
-	)))
+// defaultTokenName is how the rate limit of the registry's own token is labelled, see NewNamespaceGithubClients.
+const defaultTokenName = "default"
+
+// clientTokenName is how the rate limits of the tokens sent by registry clients are labelled, see
+// NewClientTokenGithubClients.
+const clientTokenName = "client"
+
+func getGithubOauth2Client(token, tokenName string) *http.Client {
+	return getGithubTokenSourceClient(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token}), tokenName)
+}
+
+// getGithubTokenSourceClient returns an HTTP client authenticated with the tokens of the source, such as the
+// installation tokens of a GitHub App, which are renewed as they expire.
+func getGithubTokenSourceClient(source oauth2.TokenSource, tokenName string) *http.Client {
+	client := oauth2.NewClient(context.Background(), source)
+	client.Transport = retryTransport{next: quotaTransport{next: faultTransport{next: rateLimitTransport{next: client.Transport, tokenName: tokenName}}}}
+	return xray.Client(client)
+}
+
+// quotaTransport charges every request to the ingestion budget of its context, if any.
+type quotaTransport struct {
+	next http.RoundTripper
+}
+
+func (t quotaTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := quota.FromContext(req.Context()).UseGithubCall(); err != nil {
+		return nil, err
+	}
+	return t.next.RoundTrip(req)
+}
+
+// faultTransport injects the faults configured for GitHub into the requests whose context carries a fault injector.
+// Simulated rate limits are answered like GitHub does, and still go through rateLimitTransport so they are recorded.
+type faultTransport struct {
+	next http.RoundTripper
+}
+
+func (t faultTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	operation := "rest"
+	if strings.Contains(req.URL.Path, "graphql") {
+		operation = "graphql"
+	}
+	if resp, err := injectFault(req, operation); resp != nil || err != nil {
+		return resp, err
+	}
+	return t.next.RoundTrip(req)
+}
+
+// injectFault returns the response or error replacing the request for the fault injected into it, both nil if the
+// request should go ahead.
+func injectFault(req *http.Request, operation string) (*http.Response, error) {
+	fault, err := faults.FromContext(req.Context()).Inject(req.Context(), faults.TargetGithub, operation)
+	if err != nil {
+		return nil, err
+	}
+	if !fault.RateLimit {
+		return nil, nil //nolint:nilnil // The request is not affected.
+	}
+
+	resource := "core"
+	if operation == "graphql" {
+		resource = "graphql"
+	}
+	header := make(http.Header)
+	header.Set("Content-Type", "application/json")
+	header.Set("X-RateLimit-Limit", "5000")
+	header.Set("X-RateLimit-Remaining", "0")
+	header.Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+	header.Set("X-RateLimit-Resource", resource)
+	body := `{"message":"API rate limit exceeded (injected fault)","documentation_url":"https://docs.github.com/rest/overview/resources-in-the-rest-api#rate-limiting"}`
+	return &http.Response{
+		Status:        "403 Forbidden",
+		StatusCode:    http.StatusForbidden,
+		Proto:         req.Proto,
+		ProtoMajor:    req.ProtoMajor,
+		ProtoMinor:    req.ProtoMinor,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// rateLimitTransport records the rate limit GitHub reports on every response, so that the remaining quota of each
+// token can be monitored, along with the time taken by every call.
+type rateLimitTransport struct {
+	next      http.RoundTripper
+	tokenName string
+}
+
+func (t rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		metrics.Observe(metrics.GithubRequestDuration, metrics.Labels{"token": t.tokenName, "resource": "unknown"}, time.Since(start).Seconds())
+		return resp, err
+	}
+
+	resource := resp.Header.Get("X-RateLimit-Resource")
+	if resource == "" {
+		resource = "unknown"
+	}
+	labels := metrics.Labels{"token": t.tokenName, "resource": resource}
+	metrics.Observe(metrics.GithubRequestDuration, labels, time.Since(start).Seconds())
+	if limit, parseErr := strconv.ParseFloat(resp.Header.Get("X-RateLimit-Limit"), 64); parseErr == nil {
+		metrics.Set(metrics.GithubRateLimit, labels, limit)
+	}
+	if remaining, parseErr := strconv.ParseFloat(resp.Header.Get("X-RateLimit-Remaining"), 64); parseErr == nil {
+		metrics.Set(metrics.GithubRateLimitRemaining, labels, remaining)
+	}
+	return resp, nil
 }
 
 func NewManagedGithubClient(token string) *github.Client {
-	client := github.NewClient(getGithubOauth2Client(token))
+	return newManagedGithubClient(token, defaultTokenName)
+}
+
+func NewRawGithubv4Client(token string) *githubv4.Client {
+	return newRawGithubv4Client(token, defaultTokenName)
+}
+
+// NewNamespaceGithubClients returns the REST and GraphQL clients for a token dedicated to a single namespace, such as
+// one delegated by the provider authors. Its rate limit is reported under the namespace rather than as the default
+// token's.
+func NewNamespaceGithubClients(token, namespace string) (*github.Client, *githubv4.Client) {
+	return newManagedGithubClient(token, namespace), newRawGithubv4Client(token, namespace)
+}
+
+// NewClientTokenGithubClients returns the REST and GraphQL clients for a token sent by a registry client, used in
+// passthrough mode instead of the registry's token. The rate limits of all client tokens are reported together.
+func NewClientTokenGithubClients(token string) (*github.Client, *githubv4.Client) {
+	return newManagedGithubClient(token, clientTokenName), newRawGithubv4Client(token, clientTokenName)
+}
+
+// NewAppGithubClients returns the REST and GraphQL clients authenticated as an installation of a GitHub App, see
+// NewAppTokenSource. They are used instead of the registry's token, so their rate limit is reported as the default
+// token's.
+func NewAppGithubClients(source oauth2.TokenSource) (*github.Client, *githubv4.Client) {
+	return newGatewayGithubClients(getGithubTokenSourceClient(source, defaultTokenName))
+}
+
+// newGatewayGithubClients returns the REST and GraphQL clients calling GitHub through the API Gateway with the given
+// HTTP client.
+func newGatewayGithubClients(httpClient *http.Client) (*github.Client, *githubv4.Client) {
+	managed := github.NewClient(httpClient)
+	managed.BaseURL, _ = url.Parse(fmt.Sprintf("https://%s/github/rest/", os.Getenv("GITHUB_API_GW_URL")))
+	rawv4 := githubv4.NewEnterpriseClient(fmt.Sprintf("https://%s/github/graphql/", os.Getenv("GITHUB_API_GW_URL")), httpClient)
+	return managed, rawv4
+}
+
+func newManagedGithubClient(token, tokenName string) *github.Client {
+	client := github.NewClient(getGithubOauth2Client(token, tokenName))
 	client.BaseURL, _ = url.Parse(fmt.Sprintf("https://%s/github/rest/", os.Getenv("GITHUB_API_GW_URL")))
 	return client
 }
 
-func NewRawGithubv4Client(token string) *githubv4.Client {
-	return githubv4.NewEnterpriseClient(fmt.Sprintf("https://%s/github/graphql/", os.Getenv("GITHUB_API_GW_URL")), getGithubOauth2Client(token))
+func newRawGithubv4Client(token, tokenName string) *githubv4.Client {
+	return githubv4.NewEnterpriseClient(fmt.Sprintf("https://%s/github/graphql/", os.Getenv("GITHUB_API_GW_URL")), getGithubOauth2Client(token, tokenName))
+}
+
+// NewDirectGithubClient returns a client for the GitHub REST API itself, for the self-hosted mode where there is no
+// API Gateway in front of GitHub.
+func NewDirectGithubClient(token string) *github.Client {
+	return github.NewClient(getGithubOauth2Client(token, defaultTokenName))
+}
+
+// NewDirectGithubv4Client returns a client for the GitHub GraphQL API itself, see NewDirectGithubClient.
+func NewDirectGithubv4Client(token string) *githubv4.Client {
+	return githubv4.NewClient(getGithubOauth2Client(token, defaultTokenName))
 }
@@ -0,0 +1,39 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v54/github"
+)
+
+// graphqlRateLimitError is how the GraphQL API words the errors of the queries made once the rate limit of the token
+// is exhausted.
+const graphqlRateLimitError = "rate limit exceeded"
+
+// RateLimited returns true if the call failed because GitHub rate limited the token, and how long until the limit
+// lifts, zero if GitHub did not tell.
+func RateLimited(err error) (time.Duration, bool) {
+	var rateLimitErr *github.RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		return time.Until(rateLimitErr.Rate.Reset.Time), true
+	}
+	var abuseErr *github.AbuseRateLimitError
+	if errors.As(err, &abuseErr) {
+		return abuseErr.GetRetryAfter(), true
+	}
+	return 0, err != nil && strings.Contains(strings.ToLower(err.Error()), graphqlRateLimitError)
+}
+
+// Unavailable returns true if the call failed because of GitHub rather than because of the request: the connection
+// failed or timed out, or GitHub answered with a server error.
+func Unavailable(err error) bool {
+	if isGraphQLOutage(err) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var responseErr *github.ErrorResponse
+	return errors.As(err, &responseErr) && responseErr.Response != nil && responseErr.Response.StatusCode >= http.StatusInternalServerError
+}
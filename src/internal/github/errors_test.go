@@ -0,0 +1,60 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v54/github"
+)
+
+func TestRateLimited(t *testing.T) {
+	reset := time.Now().Add(10 * time.Minute)
+	rateLimitErr := fmt.Errorf("listing releases: %w", &github.RateLimitError{Rate: github.Rate{Reset: github.Timestamp{Time: reset}}})
+	if wait, ok := RateLimited(rateLimitErr); !ok || wait < 9*time.Minute || wait > 10*time.Minute {
+		t.Errorf("RateLimited() of a rate limit error = %s, %t", wait, ok)
+	}
+
+	retryAfter := 30 * time.Second
+	if wait, ok := RateLimited(&github.AbuseRateLimitError{RetryAfter: &retryAfter}); !ok || wait != retryAfter {
+		t.Errorf("RateLimited() of a secondary rate limit error = %s, %t", wait, ok)
+	}
+
+	if _, ok := RateLimited(errors.New("API rate limit exceeded for user ID 1.")); !ok {
+		t.Errorf("RateLimited() of a GraphQL rate limit error = false")
+	}
+	if _, ok := RateLimited(errors.New("not found")); ok {
+		t.Errorf("RateLimited() of another error = true")
+	}
+	if _, ok := RateLimited(nil); ok {
+		t.Errorf("RateLimited(nil) = true")
+	}
+}
+
+func TestUnavailable(t *testing.T) {
+	// go-github always records the request of an error response, its message names it
+	request := &http.Request{Method: http.MethodGet, URL: &url.URL{Scheme: "https", Host: "api.github.com", Path: "/repos/example/foo/releases"}}
+	serverErr := &github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusBadGateway, Request: request}}
+	notFoundErr := &github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusNotFound, Request: request}}
+
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{err: &url.Error{Op: "Get", URL: "https://api.github.com/", Err: errors.New("connection reset")}, want: true},
+		{err: fmt.Errorf("%s: 502 Bad Gateway", graphqlStatusError), want: true},
+		{err: fmt.Errorf("getting release: %w", serverErr), want: true},
+		{err: context.DeadlineExceeded, want: true},
+		{err: notFoundErr, want: false},
+		{err: errors.New("invalid version"), want: false},
+	}
+	for _, tt := range tests {
+		if got := Unavailable(tt.err); got != tt.want {
+			t.Errorf("Unavailable(%v) = %t, want %t", tt.err, got, tt.want)
+		}
+	}
+}
@@ -10,6 +10,8 @@ import (
 
 	"github.com/aws/aws-xray-sdk-go/xray"
 	"github.com/google/go-github/v54/github"
+	"github.com/opentofu/registry/internal/quota"
+	"github.com/opentofu/registry/internal/version"
 	"github.com/shurcooL/githubv4"
 	"golang.org/x/exp/slog"
 )
@@ -33,6 +35,7 @@ type GHRepository struct {
 type GHRelease struct {
 	ID            string // The ID of the release.
 	TagName       string // The tag name associated with the release.
+	URL           string // The URL of the release page on GitHub, with the release notes.
 	ReleaseAssets struct {
 		Nodes []ReleaseAsset // A list of assets for the release.
 	} `graphql:"releaseAssets(first:100)"`
@@ -79,39 +82,71 @@ func RepositoryExists(ctx context.Context, managedGhClient *github.Client, names
 	return exists, err
 }
 
+// RepositoryInfo holds the details of a repository the registry keeps with its releases.
+type RepositoryInfo struct {
+	// Archived is true if the repository has been archived by its owner.
+	Archived bool
+	// License is the SPDX identifier of the license GitHub detected in the repository, `NOASSERTION` if it did not
+	// recognize it, and empty if there is none.
+	License string
+}
+
+// GetRepositoryInfo returns the details of the repository, nil if it does not exist.
+func GetRepositoryInfo(ctx context.Context, managedGhClient *github.Client, namespace, name string) (info *RepositoryInfo, err error) {
+	err = xray.Capture(ctx, "github.repository.info", func(tracedCtx context.Context) error {
+		xray.AddAnnotation(tracedCtx, "namespace", namespace)
+		xray.AddAnnotation(tracedCtx, "name", name)
+
+		repo, response, getErr := managedGhClient.Repositories.Get(tracedCtx, namespace, name)
+		if getErr != nil {
+			if response != nil && response.StatusCode == http.StatusNotFound {
+				return nil
+			}
+			return fmt.Errorf("failed to get repository: %w", getErr)
+		}
+
+		info = &RepositoryInfo{Archived: repo.GetArchived(), License: repo.GetLicense().GetSPDXID()}
+		return nil
+	})
+
+	return info, err
+}
+
+// FindRelease returns the published release of the version, tagged `v<version>` or `<version>`, or nil if there is
+// none.
 func FindRelease(ctx context.Context, ghClient *githubv4.Client, namespace, name, versionNumber string) (release *GHRelease, err error) {
+	return FindReleaseByTag(ctx, ghClient, namespace, name, version.Tags(versionNumber)...)
+}
+
+// FindReleaseByTag returns the first published release, newest first, tagged with one of the given tags, or nil if
+// there is none.
+func FindReleaseByTag(ctx context.Context, ghClient *githubv4.Client, namespace, name string, tags ...string) (release *GHRelease, err error) {
 	err = xray.Capture(ctx, "github.release.find", func(tracedCtx context.Context) error {
 		xray.AddAnnotation(tracedCtx, "namespace", namespace)
 		xray.AddAnnotation(tracedCtx, "name", name)
-		xray.AddAnnotation(tracedCtx, "versionNumber", versionNumber)
-
-		variables := initVariables(namespace, name)
+		xray.AddAnnotation(tracedCtx, "tags", strings.Join(tags, ","))
 
 		slog.Info("Finding release")
 
-		for {
-			nodes, endCursor, fetchErr := fetchReleaseNodes(tracedCtx, ghClient, variables)
-			if fetchErr != nil {
-				slog.Error("Failed to fetch release nodes", "error", fetchErr)
-				return fmt.Errorf("failed to fetch release nodes: %w", fetchErr)
-			}
-
+		fetchErr := listReleases(tracedCtx, ghClient, namespace, name, func(nodes []GHRelease) bool {
 			for _, r := range nodes {
 				if r.IsDraft {
 					continue
 				}
 
-				if r.TagName == fmt.Sprintf("v%s", versionNumber) {
-					rCopy := r
-					release = &rCopy
-					return nil
+				for _, tag := range tags {
+					if r.TagName == tag {
+						rCopy := r
+						release = &rCopy
+						return true
+					}
 				}
 			}
-
-			if endCursor == nil {
-				break
-			}
-			variables["endCursor"] = githubv4.String(*endCursor)
+			return false
+		})
+		if fetchErr != nil {
+			slog.Error("Failed to fetch release nodes", "error", fetchErr)
+			return fmt.Errorf("failed to fetch release nodes: %w", fetchErr)
 		}
 
 		return nil
@@ -133,17 +168,14 @@ func FetchReleases(ctx context.Context, ghClient *githubv4.Client, namespace, na
 		xray.AddAnnotation(tracedCtx, "namespace", namespace)
 		xray.AddAnnotation(tracedCtx, "name", name)
 
-		variables := initVariables(namespace, name)
-
-		slog.Info("Fetching new releases", "since", since)
-
-		for {
-			nodes, endCursor, fetchErr := fetchReleaseNodes(tracedCtx, ghClient, variables)
-			if fetchErr != nil {
-				slog.Error("Failed to fetch release nodes", "error", fetchErr)
-				return fmt.Errorf("failed to fetch release nodes: %w", fetchErr)
-			}
+		// a nil time cannot be logged by every handler
+		if since != nil {
+			slog.Info("Fetching new releases", "since", *since)
+		} else {
+			slog.Info("Fetching all releases")
+		}
 
+		fetchErr := listReleases(tracedCtx, ghClient, namespace, name, func(nodes []GHRelease) bool {
 			slog.Info("Checking for possible new releases", "count", len(nodes))
 
 			for _, r := range nodes {
@@ -153,22 +185,20 @@ func FetchReleases(ctx context.Context, ghClient *githubv4.Client, namespace, na
 
 				// if we have been provided a "since" time, we should only fetch releases created after that time
 				// if the release was created before the given time, we can stop fetching
-				// this is because all releases are ordered by creation date
+				// this is because all releases are ordered by creation date, and the older pages only have older ones
 				if since != nil && r.CreatedAt.Before(since.Add(-sincePadding)) {
 					slog.Info("New release was created before given time, stopping reading releases", "release", r.TagName, "created_at", r.CreatedAt, "since", since)
-					break
+					return true
 				}
 
 				slog.Info("New release fetched", "release", r.TagName, "created_at", r.CreatedAt)
 				releases = append(releases, r)
 			}
-
-			if endCursor == nil {
-				slog.Info("No more releases to fetch")
-				break
-			}
-
-			variables["endCursor"] = githubv4.String(*endCursor)
+			return false
+		})
+		if fetchErr != nil {
+			slog.Error("Failed to fetch release nodes", "error", fetchErr)
+			return fmt.Errorf("failed to fetch release nodes: %w", fetchErr)
 		}
 
 		return nil
@@ -178,6 +208,42 @@ func FetchReleases(ctx context.Context, ghClient *githubv4.Client, namespace, na
 	return releases, err
 }
 
+// ReleasesChanged makes a conditional request for the newest releases of the repository, and returns whether they
+// changed since the request which returned the given ETag, with the current ETag to pass on the next call. GitHub does
+// not count the requests answered with `304 Not Modified` against the rate limit, so this is cheaper than fetching the
+// releases to find out. Without an ETag, the releases are always changed.
+func ReleasesChanged(ctx context.Context, managedGhClient *github.Client, namespace, name, etag string) (changed bool, current string, err error) {
+	err = xray.Capture(ctx, "github.releases.changed", func(tracedCtx context.Context) error {
+		xray.AddAnnotation(tracedCtx, "namespace", namespace)
+		xray.AddAnnotation(tracedCtx, "name", name)
+
+		req, reqErr := managedGhClient.NewRequest(http.MethodGet, fmt.Sprintf("repos/%s/%s/releases?per_page=100", namespace, name), nil)
+		if reqErr != nil {
+			return fmt.Errorf("failed to create request: %w", reqErr)
+		}
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+
+		response, doErr := managedGhClient.BareDo(tracedCtx, req)
+		if response != nil && response.StatusCode == http.StatusNotModified {
+			current = etag
+			return nil
+		}
+		if doErr != nil {
+			return fmt.Errorf("failed to check releases: %w", doErr)
+		}
+		defer response.Body.Close()
+
+		// a cached response comes back with the ETag it was first served with
+		current = response.Header.Get("ETag")
+		changed = current == "" || current != etag
+		return nil
+	})
+
+	return changed, current, err
+}
+
 func initVariables(namespace, name string) map[string]interface{} {
 	perPage := 100 // TODO: make this configurable
 	return map[string]interface{}{
@@ -210,6 +276,61 @@ func fetchReleaseNodes(ctx context.Context, ghClient *githubv4.Client, variables
 	return releases, endCursor, err
 }
 
+// GHRepositoryOwner lists the repositories of a user or organization.
+type GHRepositoryOwner struct {
+	RepositoryOwner struct {
+		Repositories struct {
+			PageInfo struct {
+				HasNextPage bool
+				EndCursor   string
+			}
+			Nodes []struct {
+				Name string
+			}
+		} `graphql:"repositories(first: $perPage, after: $endCursor, isFork: false)"`
+	} `graphql:"repositoryOwner(login: $owner)"`
+}
+
+// maxRepositoryPages bounds how many pages of repositories FetchRepositoryNames reads, so that listing the owners with
+// the most repositories does not use up the rate limit.
+const maxRepositoryPages = 10
+
+// FetchRepositoryNames returns the names of the repositories of the owner which are not forks. It stops after
+// maxRepositoryPages pages, and returns whether the list is complete.
+func FetchRepositoryNames(ctx context.Context, ghClient *githubv4.Client, owner string) (names []string, complete bool, err error) {
+	err = xray.Capture(ctx, "github.repositories.fetch", func(tracedCtx context.Context) error {
+		xray.AddAnnotation(tracedCtx, "owner", owner)
+
+		variables := map[string]interface{}{
+			"owner":     githubv4.String(owner),
+			"perPage":   githubv4.Int(100), //nolint:gomnd // The maximum page size of the GitHub API.
+			"endCursor": (*githubv4.String)(nil),
+		}
+
+		for page := 0; page < maxRepositoryPages; page++ {
+			var query GHRepositoryOwner
+			if queryErr := ghClient.Query(tracedCtx, &query, variables); queryErr != nil {
+				return fmt.Errorf("failed to query for repositories: %w", queryErr)
+			}
+
+			for _, node := range query.RepositoryOwner.Repositories.Nodes {
+				names = append(names, node.Name)
+			}
+
+			if !query.RepositoryOwner.Repositories.PageInfo.HasNextPage {
+				complete = true
+				return nil
+			}
+			variables["endCursor"] = githubv4.String(query.RepositoryOwner.Repositories.PageInfo.EndCursor)
+		}
+
+		slog.Warn("Stopped listing repositories", "owner", owner, "pages", maxRepositoryPages)
+		return nil
+	})
+
+	return names, complete, err
+}
+
 func FindAssetBySuffix(assets []ReleaseAsset, suffix string) *ReleaseAsset {
 	slog.Info("Finding asset by suffix", "suffix", suffix)
 	for _, asset := range assets {
@@ -225,7 +346,7 @@ func FindAssetBySuffix(assets []ReleaseAsset, suffix string) *ReleaseAsset {
 const githubAssetDownloadTimeout = 60 * time.Second
 
 func DownloadAssetContents(ctx context.Context, downloadURL string) (body io.ReadCloser, err error) {
-	httpClient := xray.Client(&http.Client{Timeout: githubAssetDownloadTimeout})
+	httpClient := xray.Client(&http.Client{Timeout: githubAssetDownloadTimeout, Transport: assetFaultTransport{next: http.DefaultTransport}})
 
 	err = xray.Capture(ctx, "github.asset.download", func(tracedCtx context.Context) error {
 		slog.Info("Downloading asset", "url", downloadURL)
@@ -248,6 +369,9 @@ func DownloadAssetContents(ctx context.Context, downloadURL string) (body io.Rea
 		}
 
 		body = resp.Body
+		if budget := quota.FromContext(ctx); budget != nil {
+			body = &budgetedReader{ReadCloser: resp.Body, budget: budget}
+		}
 
 		return nil
 	})
@@ -255,3 +379,29 @@ func DownloadAssetContents(ctx context.Context, downloadURL string) (body io.Rea
 	slog.Info("Asset downloaded successfully")
 	return body, err
 }
+
+// budgetedReader charges the bytes read from an asset to an ingestion budget, failing once it is used up.
+type budgetedReader struct {
+	io.ReadCloser
+	budget *quota.Budget
+}
+
+func (r *budgetedReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if budgetErr := r.budget.UseAssetBytes(int64(n)); budgetErr != nil {
+		return n, budgetErr
+	}
+	return n, err
+}
+
+// assetFaultTransport injects the faults configured for the GitHub `asset` operation into asset downloads.
+type assetFaultTransport struct {
+	next http.RoundTripper
+}
+
+func (t assetFaultTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if resp, err := injectFault(req, "asset"); resp != nil || err != nil {
+		return resp, err
+	}
+	return t.next.RoundTrip(req)
+}
@@ -0,0 +1,55 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-github/v54/github"
+)
+
+func TestReleasesChanged(t *testing.T) {
+	const current = `W/"current"`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path != "/repos/example/terraform-provider-foo/releases":
+			http.NotFound(w, r)
+		case r.Header.Get("If-None-Match") == current:
+			w.WriteHeader(http.StatusNotModified)
+		default:
+			w.Header().Set("ETag", current)
+			_, _ = w.Write([]byte(`[]`))
+		}
+	}))
+	defer server.Close()
+
+	client := github.NewClient(server.Client())
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	tests := []struct {
+		name        string
+		repository  string
+		etag        string
+		wantChanged bool
+		wantETag    string
+		wantErr     bool
+	}{
+		{name: "without ETag", repository: "terraform-provider-foo", wantChanged: true, wantETag: current},
+		{name: "unchanged", repository: "terraform-provider-foo", etag: current, wantETag: current},
+		{name: "changed", repository: "terraform-provider-foo", etag: `W/"previous"`, wantChanged: true, wantETag: current},
+		{name: "missing repository", repository: "terraform-provider-missing", etag: current, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changed, etag, err := ReleasesChanged(context.Background(), client, "example", tt.repository, tt.etag)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReleasesChanged() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if changed != tt.wantChanged || etag != tt.wantETag {
+				t.Errorf("ReleasesChanged() = %v, %q, want %v, %q", changed, etag, tt.wantChanged, tt.wantETag)
+			}
+		})
+	}
+}
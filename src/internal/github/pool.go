@@ -0,0 +1,141 @@
+package github
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/google/go-github/v54/github"
+	"github.com/shurcooL/githubv4"
+	"golang.org/x/oauth2"
+)
+
+// TokenPool spreads the GitHub calls of the registry over several tokens, each with a rate limit of its own. Every
+// call goes to the token with the most remaining quota for its API, as last reported by GitHub.
+type TokenPool struct {
+	mu     sync.Mutex
+	tokens []*pooledToken
+}
+
+type pooledToken struct {
+	name   string
+	source oauth2.TokenSource
+	// limits are the rate limits last reported for the token, keyed by resource, "core" or "graphql".
+	limits map[string]rateLimit
+}
+
+type rateLimit struct {
+	remaining int64
+	reset     time.Time
+}
+
+// NewTokenPool returns a pool of the tokens of the given sources. The first one is the registry's own token, or GitHub
+// App installation, its rate limit is reported as the default token's; the others are reported as `pool-<n>`,
+// counting from 1.
+func NewTokenPool(sources []oauth2.TokenSource) *TokenPool {
+	pool := &TokenPool{}
+	for i, source := range sources {
+		name := defaultTokenName
+		if i > 0 {
+			name = fmt.Sprintf("pool-%d", i)
+		}
+		pool.tokens = append(pool.tokens, &pooledToken{name: name, source: source, limits: make(map[string]rateLimit)})
+	}
+	return pool
+}
+
+// remaining returns the quota left to the token for the resource. Tokens which have not been used yet, or whose rate
+// limit has been reset since, are assumed to have it all.
+func (t *pooledToken) remaining(resource string, now time.Time) int64 {
+	limit, ok := t.limits[resource]
+	if !ok || (!limit.reset.IsZero() && now.After(limit.reset)) {
+		return math.MaxInt64
+	}
+	return limit.remaining
+}
+
+// pick returns the token with the most quota left for the resource, the first one on ties. The call is counted
+// against it right away, so that concurrent calls are spread over the tokens before GitHub reports back.
+func (p *TokenPool) pick(resource string) *pooledToken {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	var best *pooledToken
+	var bestRemaining int64
+	for _, token := range p.tokens {
+		if remaining := token.remaining(resource, now); best == nil || remaining > bestRemaining {
+			best, bestRemaining = token, remaining
+		}
+	}
+
+	limit := best.limits[resource]
+	if bestRemaining == math.MaxInt64 {
+		limit = rateLimit{}
+	}
+	limit.remaining = bestRemaining - 1
+	best.limits[resource] = limit
+	return best
+}
+
+// update records the rate limit GitHub reported for the token in the headers of a response.
+func (p *TokenPool) update(token *pooledToken, resource string, header http.Header) {
+	remaining, err := strconv.ParseInt(header.Get("X-RateLimit-Remaining"), 10, 64)
+	if err != nil {
+		return
+	}
+	limit := rateLimit{remaining: remaining}
+	if reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		limit.reset = time.Unix(reset, 0)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	token.limits[resource] = limit
+}
+
+// poolTransport authenticates every request with the token of the pool with the most quota left for it.
+type poolTransport struct {
+	next http.RoundTripper
+	pool *TokenPool
+}
+
+func (t poolTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resource := "core"
+	if strings.Contains(req.URL.Path, "graphql") {
+		resource = "graphql"
+	}
+	token := t.pool.pick(resource)
+	value, err := token.source.Token()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get GitHub token %s: %w", token.name, err)
+	}
+
+	// a RoundTripper must not modify the request it is given
+	req = req.Clone(req.Context())
+	value.SetAuthHeader(req)
+
+	resp, err := rateLimitTransport{next: t.next, tokenName: token.name}.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	t.pool.update(token, resource, resp.Header)
+	return resp, nil
+}
+
+func getGithubPoolClient(pool *TokenPool) *http.Client {
+	client := &http.Client{
+		Transport: retryTransport{next: quotaTransport{next: faultTransport{next: poolTransport{next: http.DefaultTransport, pool: pool}}}},
+	}
+	return xray.Client(client)
+}
+
+// NewPooledGithubClients returns the REST and GraphQL clients spreading their calls over the tokens of the pool.
+func NewPooledGithubClients(pool *TokenPool) (*github.Client, *githubv4.Client) {
+	return newGatewayGithubClients(getGithubPoolClient(pool))
+}
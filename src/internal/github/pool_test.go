@@ -0,0 +1,85 @@
+package github
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+func staticTokenSources(tokens ...string) []oauth2.TokenSource {
+	var sources []oauth2.TokenSource
+	for _, token := range tokens {
+		sources = append(sources, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token}))
+	}
+	return sources
+}
+
+func rateLimitHeader(remaining int, reset time.Time) http.Header {
+	header := make(http.Header)
+	header.Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+	header.Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+	return header
+}
+
+func TestTokenPoolPick(t *testing.T) {
+	pool := NewTokenPool(staticTokenSources("first", "second", "third"))
+	inAnHour := time.Now().Add(time.Hour)
+
+	// the unused tokens are tried in turn
+	for _, want := range []string{"default", "pool-1", "pool-2"} {
+		if got := pool.pick("core"); got.name != want {
+			t.Errorf("pick() = %s, want %s", got.name, want)
+		}
+	}
+
+	pool.update(pool.tokens[0], "core", rateLimitHeader(100, inAnHour))
+	pool.update(pool.tokens[1], "core", rateLimitHeader(4000, inAnHour))
+	pool.update(pool.tokens[2], "core", rateLimitHeader(2000, inAnHour))
+	if got := pool.pick("core"); got.name != "pool-1" {
+		t.Errorf("pick() = %s, want the token with the most quota left", got.name)
+	}
+
+	// the rate limit of each API is tracked separately
+	if got := pool.pick("graphql"); got.name != "default" {
+		t.Errorf("pick(graphql) = %s, want default", got.name)
+	}
+
+	// a token whose rate limit was reset has its whole quota again
+	pool.update(pool.tokens[0], "core", rateLimitHeader(0, time.Now().Add(-time.Minute)))
+	if got := pool.pick("core"); got.name != "default" {
+		t.Errorf("pick() = %s, want the reset token", got.name)
+	}
+}
+
+func TestPoolTransport(t *testing.T) {
+	remaining := map[string]int{"Bearer first": 10, "Bearer second": 20}
+	var used []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization := r.Header.Get("Authorization")
+		used = append(used, authorization)
+		remaining[authorization]--
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining[authorization]))
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: poolTransport{next: http.DefaultTransport, pool: NewTokenPool(staticTokenSources("first", "second"))}}
+	for i := 0; i < 4; i++ {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	want := []string{"Bearer first", "Bearer second", "Bearer second", "Bearer second"}
+	for i := range want {
+		if used[i] != want[i] {
+			t.Errorf("request %d used %q, want %q", i, used[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/go-github/v54/github"
+	"github.com/opentofu/registry/internal/metrics"
+	"github.com/shurcooL/githubv4"
+	"golang.org/x/exp/slog"
+)
+
+// restPerPage is the number of releases per page of the REST API, its maximum.
+const restPerPage = 100
+
+type restFallbackKey struct{}
+
+// WithRESTFallback returns a context carrying the REST clients, by repository owner, to list the releases with while
+// the GraphQL API is failing. Without them, the failures of the GraphQL API are returned to the callers.
+func WithRESTFallback(ctx context.Context, clients func(owner string) *github.Client) context.Context {
+	return context.WithValue(ctx, restFallbackKey{}, clients)
+}
+
+func restFallbackClient(ctx context.Context, owner string) *github.Client {
+	clients, _ := ctx.Value(restFallbackKey{}).(func(owner string) *github.Client)
+	if clients == nil {
+		return nil
+	}
+	return clients(owner)
+}
+
+// listReleases calls fn with the pages of releases of the repository, drafts included, newest first, until it returns
+// true or there are no more. The releases are listed with the GraphQL API, or with the REST API of the context while
+// the GraphQL API is failing, see WithRESTFallback and graphqlBreaker.
+func listReleases(ctx context.Context, ghClient *githubv4.Client, namespace, name string, fn func(page []GHRelease) (done bool)) error {
+	restClient := restFallbackClient(ctx, namespace)
+	if restClient != nil && !graphqlBreaker.allow(time.Now()) {
+		metrics.Inc(metrics.GithubGraphQLFallbacks, metrics.Labels{"reason": "circuit_open"})
+		slog.Warn("GitHub GraphQL API is failing, listing releases with the REST API")
+		return listReleasesREST(ctx, restClient, namespace, name, fn)
+	}
+
+	variables := initVariables(namespace, name)
+	for page := 0; ; page++ {
+		nodes, endCursor, err := fetchReleaseNodes(ctx, ghClient, variables)
+		outage := isGraphQLOutage(err)
+		graphqlBreaker.record(outage, time.Now())
+		if err != nil {
+			// the pages already passed to fn cannot be taken back, so only a listing failing on its first page starts over
+			if outage && restClient != nil && page == 0 {
+				metrics.Inc(metrics.GithubGraphQLFallbacks, metrics.Labels{"reason": "graphql_error"})
+				slog.Warn("GitHub GraphQL call failed, listing releases with the REST API", "error", err)
+				return listReleasesREST(ctx, restClient, namespace, name, fn)
+			}
+			return err
+		}
+
+		if fn(nodes) || endCursor == nil {
+			return nil
+		}
+		variables["endCursor"] = githubv4.String(*endCursor)
+	}
+}
+
+// listReleasesREST lists the releases of the repository with the REST API, like listReleases. A repository which does
+// not exist has no releases, as with the GraphQL API.
+func listReleasesREST(ctx context.Context, client *github.Client, namespace, name string, fn func(page []GHRelease) (done bool)) error {
+	opts := &github.ListOptions{PerPage: restPerPage}
+	for {
+		releases, response, err := client.Repositories.ListReleases(ctx, namespace, name, opts)
+		if err != nil {
+			if response != nil && response.StatusCode == http.StatusNotFound {
+				return nil
+			}
+			return fmt.Errorf("failed to list releases: %w", err)
+		}
+
+		page := make([]GHRelease, 0, len(releases))
+		for _, r := range releases {
+			page = append(page, restRelease(r))
+		}
+		if fn(page) || response.NextPage == 0 {
+			return nil
+		}
+		opts.Page = response.NextPage
+	}
+}
+
+// restRelease converts a release of the REST API to the GraphQL one. The REST API does not tell which release is the
+// latest, and links to the tarball of the release rather than of its tag commit, which has the same contents.
+func restRelease(r *github.RepositoryRelease) GHRelease {
+	release := GHRelease{
+		ID:           r.GetNodeID(),
+		TagName:      r.GetTagName(),
+		URL:          r.GetHTMLURL(),
+		IsDraft:      r.GetDraft(),
+		IsPrerelease: r.GetPrerelease(),
+		CreatedAt:    r.GetCreatedAt().Time,
+	}
+	release.TagCommit.TarballUrl = r.GetTarballURL()
+	for _, asset := range r.Assets {
+		release.ReleaseAssets.Nodes = append(release.ReleaseAssets.Nodes, ReleaseAsset{
+			ID:          asset.GetNodeID(),
+			DownloadURL: asset.GetBrowserDownloadURL(),
+			Name:        asset.GetName(),
+		})
+	}
+	return release
+}
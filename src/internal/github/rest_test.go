@@ -0,0 +1,78 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v54/github"
+	"github.com/shurcooL/githubv4"
+)
+
+func TestFetchReleasesRESTFallback(t *testing.T) {
+	previous := graphqlBreaker
+	graphqlBreaker = &breaker{threshold: 2, cooldown: time.Hour}
+	t.Cleanup(func() { graphqlBreaker = previous })
+
+	var graphqlCalls int32
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/graphql":
+			atomic.AddInt32(&graphqlCalls, 1)
+			w.WriteHeader(http.StatusBadGateway)
+		case r.URL.Path == "/repos/example/terraform-provider-foo/releases" && r.URL.Query().Get("page") == "":
+			w.Header().Set("Link", fmt.Sprintf(`<%s/repos/example/terraform-provider-foo/releases?page=2>; rel="next"`, server.URL))
+			fmt.Fprint(w, `[{"node_id":"R3","tag_name":"v1.2.0","draft":true,"created_at":"2023-09-03T00:00:00Z"},
+				{"node_id":"R2","tag_name":"v1.1.0","created_at":"2023-09-02T00:00:00Z",
+				 "assets":[{"node_id":"A1","name":"terraform-provider-foo_1.1.0_SHA256SUMS","browser_download_url":"https://example.com/SHA256SUMS"}]}]`)
+		case r.URL.Path == "/repos/example/terraform-provider-foo/releases":
+			fmt.Fprint(w, `[{"node_id":"R1","tag_name":"v1.0.0","prerelease":true,"created_at":"2023-09-01T00:00:00Z"}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	graphqlClient := githubv4.NewEnterpriseClient(server.URL+"/graphql", server.Client())
+	restClient := github.NewClient(server.Client())
+	restClient.BaseURL, _ = url.Parse(server.URL + "/")
+	ctx := WithRESTFallback(context.Background(), func(string) *github.Client { return restClient })
+
+	for i := 0; i < 3; i++ {
+		releases, err := FetchReleases(ctx, graphqlClient, "example", "terraform-provider-foo", nil)
+		if err != nil {
+			t.Fatalf("FetchReleases() error = %v", err)
+		}
+		if len(releases) != 2 || releases[0].TagName != "v1.1.0" || releases[1].TagName != "v1.0.0" || !releases[1].IsPrerelease {
+			t.Fatalf("FetchReleases() = %+v, want v1.1.0 and the v1.0.0 prerelease", releases)
+		}
+		if assets := releases[0].ReleaseAssets.Nodes; len(assets) != 1 || assets[0].DownloadURL != "https://example.com/SHA256SUMS" {
+			t.Errorf("assets of v1.1.0 = %+v", assets)
+		}
+	}
+	// the third listing does not try GraphQL any more
+	if calls := atomic.LoadInt32(&graphqlCalls); calls != 2 {
+		t.Errorf("got %d GraphQL calls, want 2", calls)
+	}
+
+	release, err := FindRelease(ctx, graphqlClient, "example", "terraform-provider-foo", "1.0.0")
+	if err != nil || release == nil || release.ID != "R1" {
+		t.Errorf("FindRelease() = %+v, %v", release, err)
+	}
+
+	// a missing repository has no releases, like with GraphQL
+	if releases, err := FetchReleases(ctx, graphqlClient, "example", "terraform-provider-missing", nil); err != nil || len(releases) != 0 {
+		t.Errorf("FetchReleases() of a missing repository = %+v, %v", releases, err)
+	}
+
+	// without REST clients, the failures of GraphQL are returned
+	if _, err := FetchReleases(context.Background(), graphqlClient, "example", "terraform-provider-foo", nil); err == nil {
+		t.Error("expected an error without a REST fallback")
+	}
+}
@@ -0,0 +1,119 @@
+package github
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/opentofu/registry/internal/metrics"
+	"golang.org/x/exp/slog"
+)
+
+// maxRetries is how many times a GitHub call failing with a transient error is retried.
+const maxRetries = 3
+
+// retryBaseDelay is the delay before the first retry of a call without any hint from GitHub of how long to wait. It
+// doubles for each of the following ones.
+const retryBaseDelay = time.Second
+
+// maxRetryWait is the longest a call waits before being retried. API Gateway times requests out after 29 seconds, so
+// the calls GitHub asks to hold off for longer fail right away.
+const maxRetryWait = 20 * time.Second
+
+// maxInspectedBodySize is how much of an error response is read to tell a secondary rate limit from a refusal.
+const maxInspectedBodySize = 64 << 10
+
+// retryTransport retries the GitHub calls which fail with a transient error: a server error, or a primary or secondary
+// rate limit lifting soon enough. Each attempt is a call of its own, charged to the ingestion budget.
+type retryTransport struct {
+	next http.RoundTripper
+}
+
+func (t retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// the body of the request has to be sent again, which is only possible if it can be rewound
+	rewindable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+
+	attemptReq := req
+	for attempt := 0; ; attempt++ {
+		resp, err := t.next.RoundTrip(attemptReq)
+		if err != nil || attempt == maxRetries || !rewindable {
+			return resp, err
+		}
+		reason, wait := retryDelay(resp, attempt)
+		if reason == "" || wait > maxRetryWait {
+			return resp, nil
+		}
+
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		metrics.Inc(metrics.GithubRetries, metrics.Labels{"reason": reason})
+		slog.Warn("Retrying GitHub call", "reason", reason, "status", resp.StatusCode, "wait", wait, "attempt", attempt+1)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+
+		attemptReq = req.Clone(req.Context())
+		if req.GetBody != nil {
+			if attemptReq.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// retryDelay returns why the call answered with the response should be retried and how long to wait before doing so,
+// an empty reason if it should not.
+func retryDelay(resp *http.Response, attempt int) (string, time.Duration) {
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return "server_error", backoff(attempt)
+	case http.StatusForbidden, http.StatusTooManyRequests:
+	default:
+		return "", 0
+	}
+
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		return "secondary_rate_limit", time.Duration(seconds) * time.Second
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+		if err != nil {
+			return "", 0
+		}
+		return "rate_limit", time.Until(time.Unix(reset, 0))
+	}
+	if isSecondaryRateLimit(resp) {
+		return "secondary_rate_limit", backoff(attempt)
+	}
+	// anything else is a refusal, e.g. the token lacks access
+	return "", 0
+}
+
+// isSecondaryRateLimit returns true if the response is GitHub refusing a call under one of its secondary rate limits,
+// such as its abuse detection. The body is left for the caller to read.
+func isSecondaryRateLimit(resp *http.Response) bool {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxInspectedBodySize))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+
+	message := strings.ToLower(string(body))
+	return strings.Contains(message, "secondary rate limit") || strings.Contains(message, "abuse detection")
+}
+
+// backoff returns the delay before the retry following the given attempt: exponentially increasing, and jittered so
+// that the calls failing together are not retried together.
+func backoff(attempt int) time.Duration {
+	delay := retryBaseDelay << attempt
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2))) //nolint:gosec // The jitter needs no secure randomness.
+}
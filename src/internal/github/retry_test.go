@@ -0,0 +1,93 @@
+package github
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetryTransport(t *testing.T) {
+	tests := []struct {
+		name      string
+		failure   func(w http.ResponseWriter)
+		wantCalls int
+		wantCode  int
+	}{
+		{
+			name:      "server error",
+			failure:   func(w http.ResponseWriter) { w.WriteHeader(http.StatusBadGateway) },
+			wantCalls: 2,
+			wantCode:  http.StatusOK,
+		},
+		{
+			name: "secondary rate limit",
+			failure: func(w http.ResponseWriter) {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusForbidden)
+			},
+			wantCalls: 2,
+			wantCode:  http.StatusOK,
+		},
+		{
+			name: "rate limit resetting later",
+			failure: func(w http.ResponseWriter) {
+				w.Header().Set("X-RateLimit-Remaining", "0")
+				w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+				w.WriteHeader(http.StatusForbidden)
+			},
+			wantCalls: 1,
+			wantCode:  http.StatusForbidden,
+		},
+		{
+			name: "refusal",
+			failure: func(w http.ResponseWriter) {
+				w.WriteHeader(http.StatusForbidden)
+				_, _ = w.Write([]byte(`{"message":"Resource not accessible by integration"}`))
+			},
+			wantCalls: 1,
+			wantCode:  http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				if body, _ := io.ReadAll(r.Body); string(body) != "query" {
+					t.Errorf("body = %q, want it sent again on retries", body)
+				}
+				if calls == 1 {
+					tt.failure(w)
+				}
+			}))
+			defer server.Close()
+
+			client := &http.Client{Transport: retryTransport{next: http.DefaultTransport}}
+			resp, err := client.Post(server.URL, "text/plain", strings.NewReader("query"))
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if calls != tt.wantCalls || resp.StatusCode != tt.wantCode {
+				t.Errorf("got %d calls and status %d, want %d and %d", calls, resp.StatusCode, tt.wantCalls, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestIsSecondaryRateLimitKeepsBody(t *testing.T) {
+	message := `{"message":"You have exceeded a secondary rate limit."}`
+	resp := &http.Response{Body: io.NopCloser(strings.NewReader(message))}
+	if !isSecondaryRateLimit(resp) {
+		t.Error("isSecondaryRateLimit() = false, want true")
+	}
+	if body, _ := io.ReadAll(resp.Body); string(body) != message {
+		t.Errorf("body = %q, want it unchanged", body)
+	}
+}
@@ -0,0 +1,145 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/slog"
+)
+
+// Check is a dependency the server needs to serve requests.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// Result is the outcome of the last run of a check.
+type Result struct {
+	Name string
+	Err  error
+	// FailingSince is when the check started failing, zero if it passed.
+	FailingSince time.Time
+}
+
+// Checker answers the liveness and readiness probes of the server. Checks are run at most once per interval, however
+// often the probes are called, and a failing check only makes the server unready once it has been failing for longer
+// than the grace period, so that a blip in a dependency does not take every replica out of the load balancer at once.
+type Checker struct {
+	checks   []Check
+	interval time.Duration
+	grace    time.Duration
+	timeout  time.Duration
+
+	mu           sync.Mutex
+	lastRun      time.Time
+	results      []Result
+	shuttingDown bool
+}
+
+func NewChecker(interval, grace, timeout time.Duration, checks ...Check) *Checker {
+	return &Checker{checks: checks, interval: interval, grace: grace, timeout: timeout}
+}
+
+// ShutDown makes the readiness probe fail from now on, so that the server is taken out of rotation before it stops.
+func (c *Checker) ShutDown() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.shuttingDown = true
+}
+
+// Ready runs the checks if their results are older than the interval, and returns whether the server is ready along
+// with the result of each check.
+func (c *Checker) Ready(ctx context.Context) (bool, []Result) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if c.results == nil || now.Sub(c.lastRun) >= c.interval {
+		c.run(ctx, now)
+	}
+
+	ready := !c.shuttingDown
+	for _, r := range c.results {
+		if r.Err != nil && now.Sub(r.FailingSince) >= c.grace {
+			ready = false
+		}
+	}
+	return ready, c.results
+}
+
+func (c *Checker) run(ctx context.Context, now time.Time) {
+	results := make([]Result, len(c.checks))
+	for i, check := range c.checks {
+		checkCtx, cancel := context.WithTimeout(ctx, c.timeout)
+		err := check.Run(checkCtx)
+		cancel()
+
+		results[i] = Result{Name: check.Name, Err: err}
+		if err == nil {
+			continue
+		}
+
+		slog.Error("Health check failed", "check", check.Name, "error", err)
+		results[i].FailingSince = now
+		// keep counting from the first failure, so the grace period is not extended on every run
+		if i < len(c.results) && c.results[i].Err != nil {
+			results[i].FailingSince = c.results[i].FailingSince
+		}
+	}
+	c.results = results
+	c.lastRun = now
+}
+
+// LivezHandler answers the liveness probe. It only tells that the process is able to serve HTTP, a failing dependency
+// should not get the server restarted.
+func (c *Checker) LivezHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeProbe(w, r, true, nil, false)
+	})
+}
+
+// ReadyzHandler answers the readiness probe. The result of every check is listed with `?verbose`.
+func (c *Checker) ReadyzHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.mu.Lock()
+		shuttingDown := c.shuttingDown
+		c.mu.Unlock()
+
+		ready, results := c.Ready(r.Context())
+		writeProbe(w, r, ready, results, shuttingDown)
+	})
+}
+
+// writeProbe writes the probe response in the format of the Kubernetes API server's own probes.
+func writeProbe(w http.ResponseWriter, r *http.Request, ok bool, results []Result, shuttingDown bool) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if !ok {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	if _, verbose := r.URL.Query()["verbose"]; verbose {
+		var b strings.Builder
+		for _, result := range results {
+			if result.Err != nil {
+				fmt.Fprintf(&b, "[-]%s failed: %s\n", result.Name, result.Err)
+			} else {
+				fmt.Fprintf(&b, "[+]%s ok\n", result.Name)
+			}
+		}
+		if shuttingDown {
+			b.WriteString("[-]shutdown in progress\n")
+		}
+		_, _ = w.Write([]byte(b.String()))
+	}
+
+	if ok {
+		_, _ = w.Write([]byte("ok\n"))
+	} else {
+		_, _ = w.Write([]byte("not ready\n"))
+	}
+}
@@ -0,0 +1,86 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckerGracePeriod(t *testing.T) {
+	var failing bool
+	c := NewChecker(0, 50*time.Millisecond, time.Second, Check{Name: "storage", Run: func(ctx context.Context) error {
+		if failing {
+			return errors.New("unreachable")
+		}
+		return nil
+	}})
+
+	if ready, _ := c.Ready(context.Background()); !ready {
+		t.Fatal("expected to be ready while the check passes")
+	}
+
+	failing = true
+	if ready, results := c.Ready(context.Background()); !ready || results[0].Err == nil {
+		t.Fatalf("expected to stay ready within the grace period, with the failure reported, got %v %v", ready, results)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if ready, _ := c.Ready(context.Background()); ready {
+		t.Fatal("expected not to be ready once the check failed for longer than the grace period")
+	}
+
+	failing = false
+	if ready, _ := c.Ready(context.Background()); !ready {
+		t.Fatal("expected to be ready again once the check passes")
+	}
+}
+
+func TestCheckerCachesResults(t *testing.T) {
+	var runs int
+	c := NewChecker(time.Hour, 0, time.Second, Check{Name: "github", Run: func(ctx context.Context) error {
+		runs++
+		return nil
+	}})
+
+	c.Ready(context.Background())
+	c.Ready(context.Background())
+	if runs != 1 {
+		t.Errorf("expected the check to run once within the interval, ran %d times", runs)
+	}
+}
+
+func TestReadyzHandler(t *testing.T) {
+	c := NewChecker(0, 0, time.Second,
+		Check{Name: "config", Run: func(ctx context.Context) error { return nil }},
+		Check{Name: "github", Run: func(ctx context.Context) error { return errors.New("bad credentials") }},
+	)
+
+	rec := httptest.NewRecorder()
+	c.ReadyzHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz?verbose", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503, got %d", rec.Code)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, "[+]config ok") || !strings.Contains(body, "[-]github failed: bad credentials") {
+		t.Errorf("unexpected body %q", body)
+	}
+
+	rec = httptest.NewRecorder()
+	c.LivezHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected liveness not to depend on the checks, got %d", rec.Code)
+	}
+}
+
+func TestShutDown(t *testing.T) {
+	c := NewChecker(0, 0, time.Second)
+	c.ShutDown()
+
+	if ready, _ := c.Ready(context.Background()); ready {
+		t.Error("expected not to be ready after shutting down")
+	}
+}
@@ -0,0 +1,423 @@
+// Package integration drives the real router with recorded API Gateway events, against an in-memory cache and a
+// fake GitHub, and compares the full responses with golden files. Run `go test ./internal/integration -update` to
+// rewrite the golden files after an intended change of the responses.
+package integration
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/aws/aws-xray-sdk-go/xraylog"
+	gogithub "github.com/google/go-github/v54/github"
+	"github.com/opentofu/registry/internal/api"
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/modules"
+	"github.com/opentofu/registry/internal/modules/modulecache"
+	"github.com/opentofu/registry/internal/populate"
+	"github.com/opentofu/registry/internal/providers/providercache"
+	"github.com/opentofu/registry/internal/providers/types"
+	"github.com/shurcooL/githubv4"
+)
+
+//nolint:gochecknoglobals // Test flag.
+var update = flag.Bool("update", false, "rewrite the golden files")
+
+// fakeGithubURL replaces the URL of the fake GitHub in the responses, which changes on every run.
+const fakeGithubURL = "https://github.test"
+
+// memoryCache is an in-memory providercache.Cache.
+type memoryCache struct {
+	mu    sync.Mutex
+	items map[string]*types.CacheItem
+}
+
+func (c *memoryCache) GetItem(_ context.Context, key string) (*types.CacheItem, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.items[key], nil
+}
+
+func (c *memoryCache) GetItems(_ context.Context, keys []string) (map[string]*types.CacheItem, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	items := make(map[string]*types.CacheItem)
+	for _, key := range keys {
+		if item, ok := c.items[key]; ok {
+			items[key] = item
+		}
+	}
+	return items, nil
+}
+
+func (c *memoryCache) ListNamespace(_ context.Context, namespace string) ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var keys []string
+	for key := range c.items {
+		if strings.HasPrefix(key, namespace+"/") {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (c *memoryCache) Sample(_ context.Context, n int) ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var keys []string
+	for key := range c.items {
+		if len(keys) < n {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (c *memoryCache) Search(_ context.Context, query string) ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var keys []string
+	for key, item := range c.items {
+		if strings.Contains(key, query) && !item.Metadata.NotFound {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (c *memoryCache) Keys(ctx context.Context) ([]string, error) {
+	return c.Search(ctx, "")
+}
+
+func (c *memoryCache) Store(_ context.Context, key string, versions types.VersionList, metadata types.ProviderMetadata) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items[key] = &types.CacheItem{Provider: key, Versions: versions, LastUpdated: time.Now(), Metadata: metadata}
+	return nil
+}
+
+func (c *memoryCache) AppendVersions(_ context.Context, key string, versions types.VersionList, metadata types.ProviderMetadata) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if item, ok := c.items[key]; ok {
+		versions = append(item.Versions, versions...).Deduplicate()
+	}
+	c.items[key] = &types.CacheItem{Provider: key, Versions: versions, LastUpdated: time.Now(), Metadata: metadata}
+	return nil
+}
+
+func (c *memoryCache) UpdateVersions(_ context.Context, key string, update func(item *types.CacheItem) error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	item, ok := c.items[key]
+	if !ok {
+		return providercache.ErrNotFound
+	}
+	updated := *item
+	if err := update(&updated); err != nil {
+		return err
+	}
+	c.items[key] = &updated
+	return nil
+}
+
+func (c *memoryCache) StoreBatch(ctx context.Context, entries []providercache.Entry) error {
+	for _, entry := range entries {
+		_ = c.Store(ctx, entry.Key, entry.Versions, entry.Metadata)
+	}
+	return nil
+}
+
+func (c *memoryCache) StoreMetadata(_ context.Context, key string, metadata types.ProviderMetadata) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if item, ok := c.items[key]; ok {
+		updated := *item
+		updated.Metadata = metadata
+		c.items[key] = &updated
+	}
+	return nil
+}
+
+func (c *memoryCache) Delete(_ context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.items, key)
+	return nil
+}
+
+// memoryModuleCache is a modulecache.Cache kept in memory.
+type memoryModuleCache struct {
+	mu    sync.Mutex
+	items map[string]*modulecache.CacheItem
+}
+
+func (c *memoryModuleCache) GetItem(_ context.Context, key string) (*modulecache.CacheItem, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.items[key], nil
+}
+
+func (c *memoryModuleCache) Store(_ context.Context, key string, versions []modules.Version) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items[key] = &modulecache.CacheItem{Repository: key, Versions: versions, LastUpdated: time.Now()}
+	return nil
+}
+
+func (c *memoryModuleCache) Keys(_ context.Context) ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	keys := make([]string, 0, len(c.items))
+	for key := range c.items {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (c *memoryModuleCache) Delete(_ context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.items, key)
+	return nil
+}
+
+// fakeRelease is a GitHub release of a fake repository. Its assets are served with the given contents.
+type fakeRelease struct {
+	Tag       string
+	CreatedAt time.Time
+	Assets    map[string]string
+}
+
+// fakeGithub serves the parts of the GitHub REST and GraphQL APIs the registry uses, and the release assets, for a
+// fixed set of repositories keyed by `<owner>/<name>`.
+type fakeGithub struct {
+	*httptest.Server
+	repos map[string][]fakeRelease
+}
+
+func newFakeGithub(t *testing.T, repos map[string][]fakeRelease) *fakeGithub {
+	f := &fakeGithub{repos: repos}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/", f.serveRepository)
+	mux.HandleFunc("/graphql", f.serveGraphQL)
+	mux.HandleFunc("/assets/", f.serveAsset)
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeGithub) serveRepository(w http.ResponseWriter, r *http.Request) {
+	repo := strings.TrimPrefix(r.URL.Path, "/repos/")
+	if _, ok := f.repos[repo]; !ok {
+		http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+		return
+	}
+	owner, name, _ := strings.Cut(repo, "/")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"name": name, "full_name": repo, "owner": map[string]string{"login": owner}, "archived": false, "license": map[string]string{"spdx_id": "MPL-2.0"}})
+}
+
+// serveGraphQL answers the releases query of github.FetchReleases and github.FindRelease, in a single page.
+func (f *fakeGithub) serveGraphQL(w http.ResponseWriter, r *http.Request) {
+	var query struct {
+		Variables struct {
+			Owner string `json:"owner"`
+			Name  string `json:"name"`
+		} `json:"variables"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	repo := query.Variables.Owner + "/" + query.Variables.Name
+	nodes := []map[string]interface{}{}
+	for _, release := range f.repos[repo] {
+		names := make([]string, 0, len(release.Assets))
+		for name := range release.Assets {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		assets := []map[string]string{}
+		for _, name := range names {
+			assets = append(assets, map[string]string{
+				"id":          name,
+				"name":        name,
+				"downloadUrl": fmt.Sprintf("%s/assets/%s/%s/%s", f.URL, repo, release.Tag, name),
+			})
+		}
+		nodes = append(nodes, map[string]interface{}{
+			"id":            release.Tag,
+			"tagName":       release.Tag,
+			"releaseAssets": map[string]interface{}{"nodes": assets},
+			"isDraft":       false,
+			"isLatest":      false,
+			"isPrerelease":  false,
+			"tagCommit":     map[string]string{"tarballUrl": fmt.Sprintf("%s/tarball/%s/%s", f.URL, repo, release.Tag)},
+			"createdAt":     release.CreatedAt,
+		})
+	}
+
+	var repository interface{}
+	if _, ok := f.repos[repo]; ok {
+		repository = map[string]interface{}{
+			"releases": map[string]interface{}{
+				"pageInfo": map[string]interface{}{"hasNextPage": false, "endCursor": ""},
+				"nodes":    nodes,
+			},
+		}
+	}
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"repository": repository}})
+}
+
+func (f *fakeGithub) serveAsset(w http.ResponseWriter, r *http.Request) {
+	// /assets/<owner>/<name>/<tag>/<asset>
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/assets/"), "/")
+	if len(parts) == 4 {
+		for _, release := range f.repos[parts[0]+"/"+parts[1]] {
+			if contents, ok := release.Assets[parts[3]]; ok && release.Tag == parts[2] {
+				_, _ = io.WriteString(w, contents)
+				return
+			}
+		}
+	}
+	http.NotFound(w, r)
+}
+
+// providerRelease returns a release of a provider for the given platforms, with its SHA256SUMS and signature.
+func providerRelease(providerType, version string, createdAt time.Time, platforms ...string) fakeRelease {
+	assets := make(map[string]string)
+	var shaSums strings.Builder
+	for _, p := range platforms {
+		name := fmt.Sprintf("terraform-provider-%s_%s_%s.zip", providerType, version, p)
+		assets[name] = "archive " + name
+		sum := sha256.Sum256([]byte(assets[name]))
+		fmt.Fprintf(&shaSums, "%s  %s\n", hex.EncodeToString(sum[:]), name)
+	}
+	assets[fmt.Sprintf("terraform-provider-%s_%s_SHA256SUMS", providerType, version)] = shaSums.String()
+	assets[fmt.Sprintf("terraform-provider-%s_%s_SHA256SUMS.sig", providerType, version)] = "signature"
+	return fakeRelease{Tag: "v" + version, CreatedAt: createdAt, Assets: assets}
+}
+
+func TestMain(m *testing.M) {
+	// requests are not traced, don't report the missing segments
+	xray.SetLogger(xraylog.NullLogger)
+	os.Exit(m.Run())
+}
+
+// harness is a registry served from an in-memory cache and a fake GitHub, with refreshes running in-process.
+type harness struct {
+	github    *fakeGithub
+	cache     *memoryCache
+	populator *populate.InProcess
+	router    api.LambdaFunc
+}
+
+// newHarness sets up a registry serving the given repositories, with the options applied to its configuration.
+func newHarness(t *testing.T, repos map[string][]fakeRelease, options ...func(*config.Config)) *harness {
+	github := newFakeGithub(t, repos)
+	cache := &memoryCache{items: make(map[string]*types.CacheItem)}
+
+	restClient := gogithub.NewClient(github.Client())
+	restClient.BaseURL, _ = url.Parse(github.URL + "/")
+
+	cfg := &config.Config{
+		ManagedGithubClient:  restClient,
+		RawGithubv4Client:    githubv4.NewEnterpriseClient(github.URL+"/graphql", github.Client()),
+		ProviderVersionCache: cache,
+		Hostnames:            []string{"registry.example.com"},
+	}
+	for _, option := range options {
+		option(cfg)
+	}
+	populator := populate.NewInProcess(cfg)
+	cfg.PopulateProviderVersions = populator.Populate
+	cfg.PopulateModuleVersions = populator.PopulateModule
+
+	return &harness{github: github, cache: cache, populator: populator, router: api.Router(*cfg)}
+}
+
+// drain waits for the refreshes triggered so far to complete.
+func (h *harness) drain(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := h.populator.Drain(ctx); err != nil {
+		t.Fatalf("refreshes did not complete: %v", err)
+	}
+}
+
+// serve sends the recorded event in testdata/events/<name>.json to the router.
+func (h *harness) serve(t *testing.T, name string) events.APIGatewayProxyResponse {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "events", name+".json"))
+	if err != nil {
+		t.Fatalf("could not read event: %v", err)
+	}
+	var req events.APIGatewayProxyRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		t.Fatalf("could not parse event: %v", err)
+	}
+
+	response, err := h.router(context.Background(), req)
+	if err != nil {
+		t.Fatalf("router returned an error: %v", err)
+	}
+	response.Body = strings.ReplaceAll(response.Body, h.github.URL, fakeGithubURL)
+	return response
+}
+
+// assertGolden compares the status and body of the response with testdata/golden/<name>.json. The order of the
+// versions of a listing is not specified, so they are sorted first.
+func assertGolden(t *testing.T, name string, response events.APIGatewayProxyResponse) {
+	t.Helper()
+	var body interface{}
+	if err := json.Unmarshal([]byte(response.Body), &body); err != nil {
+		t.Fatalf("response body is not JSON: %v: %q", err, response.Body)
+	}
+	if document, ok := body.(map[string]interface{}); ok {
+		if versions, ok := document["versions"].([]interface{}); ok {
+			sort.Slice(versions, func(i, j int) bool {
+				return fmt.Sprint(versions[i].(map[string]interface{})["version"]) < fmt.Sprint(versions[j].(map[string]interface{})["version"])
+			})
+		}
+	}
+	got, err := json.MarshalIndent(map[string]interface{}{"status": response.StatusCode, "body": body}, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = append(got, '\n')
+
+	path := filepath.Join("testdata", "golden", name+".json")
+	if *update {
+		if err := os.WriteFile(path, got, 0o600); err != nil { //nolint:gomnd // Test data.
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("could not read golden file, run with -update to create it: %v", err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("response does not match %s, run with -update if the change is intended\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
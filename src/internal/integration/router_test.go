@@ -0,0 +1,209 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/opentofu/registry/internal/access"
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/modules"
+	"github.com/opentofu/registry/internal/modules/modulecache"
+)
+
+func testRepos() map[string][]fakeRelease {
+	published := time.Date(2023, 9, 1, 12, 0, 0, 0, time.UTC)
+	return map[string][]fakeRelease{
+		// releases are listed newest first, like GitHub does
+		"example/terraform-provider-foo": {
+			providerRelease("foo", "1.1.0", published.Add(24*time.Hour), "darwin_arm64", "linux_amd64"),
+			providerRelease("foo", "1.0.0", published, "linux_amd64"),
+		},
+		"example/terraform-aws-vpc": {
+			{Tag: "v1.0.0", CreatedAt: published},
+		},
+	}
+}
+
+func TestWellKnown(t *testing.T) {
+	h := newHarness(t, testRepos())
+	assertGolden(t, "well-known", h.serve(t, "well-known"))
+}
+
+func TestProviderVersions(t *testing.T) {
+	h := newHarness(t, testRepos())
+
+	// the first request is served from GitHub and populates the cache
+	uncached := h.serve(t, "provider-versions")
+	h.drain(t)
+	item, _ := h.cache.GetItem(context.Background(), "example/foo")
+	if item == nil || len(item.Versions) != 2 {
+		t.Fatalf("expected the cache to be populated with 2 versions, got %+v", item)
+	}
+	if item.Metadata.License != "MPL-2.0" {
+		t.Errorf("expected the license of the repository to be cached, got %q", item.Metadata.License)
+	}
+
+	// the cached listing must be the same as the one served from GitHub
+	cached := h.serve(t, "provider-versions")
+	if cached.Headers["Last-Modified"] == "" {
+		t.Error("expected the cached listing to have a Last-Modified header")
+	}
+	assertGolden(t, "provider-versions", uncached)
+	assertGolden(t, "provider-versions", cached)
+}
+
+func TestProviderDownload(t *testing.T) {
+	h := newHarness(t, testRepos())
+
+	// served from GitHub, then from the cache
+	assertGolden(t, "provider-download", h.serve(t, "provider-download"))
+	h.drain(t)
+	assertGolden(t, "provider-download", h.serve(t, "provider-download"))
+
+	assertGolden(t, "provider-download-unknown-platform", h.serve(t, "provider-download-unknown-platform"))
+}
+
+func TestNotFound(t *testing.T) {
+	h := newHarness(t, testRepos())
+	assertGolden(t, "provider-versions-unknown", h.serve(t, "provider-versions-unknown"))
+	assertGolden(t, "unknown-route", h.serve(t, "unknown-route"))
+}
+
+func TestNegativeCache(t *testing.T) {
+	h := newHarness(t, testRepos())
+	assertGolden(t, "provider-versions-unknown", h.serve(t, "provider-versions-unknown"))
+
+	item, _ := h.cache.GetItem(context.Background(), "example/unknown")
+	if item == nil || !item.Metadata.NotFound {
+		t.Fatalf("expected the missing provider to be cached, got %+v", item)
+	}
+
+	// GitHub is not asked again until the entry is stale, so a provider published meanwhile is not found yet
+	h.github.repos["example/terraform-provider-unknown"] = []fakeRelease{
+		providerRelease("unknown", "1.0.0", time.Date(2023, 9, 1, 12, 0, 0, 0, time.UTC), "linux_amd64"),
+	}
+	if response := h.serve(t, "provider-versions-unknown"); response.StatusCode != http.StatusNotFound {
+		t.Fatalf("got status %d while the entry is fresh, want 404", response.StatusCode)
+	}
+
+	item.LastUpdated = time.Now().Add(-time.Hour)
+	if response := h.serve(t, "provider-versions-unknown"); response.StatusCode != http.StatusOK {
+		t.Fatalf("got status %d once the entry is stale, want 200", response.StatusCode)
+	}
+	h.drain(t)
+}
+
+func TestModules(t *testing.T) {
+	h := newHarness(t, testRepos())
+	assertGolden(t, "module-versions", h.serve(t, "module-versions"))
+
+	response := h.serve(t, "module-download")
+	if response.StatusCode != http.StatusNoContent {
+		t.Fatalf("got status %d, want 204", response.StatusCode)
+	}
+	if got, want := response.Headers["X-Terraform-Get"], "git::https://github.com/example/terraform-aws-vpc?ref=v1.0.0"; got != want {
+		t.Errorf("X-Terraform-Get = %q, want %q", got, want)
+	}
+
+	// a version without a release is not pointed at a tag which does not exist
+	if response := h.serve(t, "module-download-unknown-version"); response.StatusCode != http.StatusNotFound {
+		t.Errorf("got status %d for a version without a release, want 404", response.StatusCode)
+	}
+}
+
+func TestModuleArchiveSources(t *testing.T) {
+	h := newHarness(t, testRepos(), func(cfg *config.Config) {
+		cfg.ModuleArchiveSources = true
+	})
+
+	response := h.serve(t, "module-download")
+	if got, want := response.Headers["X-Terraform-Get"], "https://github.com/example/terraform-aws-vpc/archive/refs/tags/v1.0.0.tar.gz//*"; got != want {
+		t.Errorf("X-Terraform-Get = %q, want %q", got, want)
+	}
+}
+
+func TestModuleCache(t *testing.T) {
+	moduleCache := &memoryModuleCache{items: make(map[string]*modulecache.CacheItem)}
+	h := newHarness(t, testRepos(), func(cfg *config.Config) {
+		cfg.ModuleVersionCache = moduleCache
+	})
+	assertGolden(t, "module-versions", h.serve(t, "module-versions"))
+
+	// once cached, the module is served without GitHub
+	delete(h.github.repos, "example/terraform-aws-vpc")
+	assertGolden(t, "module-versions", h.serve(t, "module-versions"))
+
+	response := h.serve(t, "module-download")
+	if got, want := response.Headers["X-Terraform-Get"], "git::https://github.com/example/terraform-aws-vpc?ref=v1.0.0"; got != want {
+		t.Errorf("X-Terraform-Get = %q, want %q", got, want)
+	}
+}
+
+func TestModuleServeStale(t *testing.T) {
+	moduleCache := &memoryModuleCache{items: map[string]*modulecache.CacheItem{
+		"example/terraform-aws-vpc": {
+			Repository:  "example/terraform-aws-vpc",
+			Versions:    []modules.Version{{Version: "0.9.0", Tag: "v0.9.0"}},
+			LastUpdated: time.Now().Add(-time.Hour),
+		},
+	}}
+	h := newHarness(t, testRepos(), func(cfg *config.Config) {
+		cfg.ModuleVersionCache = moduleCache
+		cfg.ServeStale = true
+	})
+
+	// the stale versions are served while the refresh runs
+	response := h.serve(t, "module-versions")
+	if response.StatusCode != http.StatusOK || !strings.Contains(response.Body, `"0.9.0"`) {
+		t.Fatalf("got status %d and body %s, want the stale versions", response.StatusCode, response.Body)
+	}
+
+	h.drain(t)
+	if item, _ := moduleCache.GetItem(context.Background(), "example/terraform-aws-vpc"); item == nil || item.Version("1.0.0") == nil {
+		t.Fatalf("expected the refresh to cache the released version, got %+v", item)
+	}
+}
+
+func TestPrivateProvider(t *testing.T) {
+	h := newHarness(t, testRepos(), func(cfg *config.Config) {
+		cfg.PrivateProviders = access.ACLs{"example/foo": {"private-token"}}
+	})
+
+	// without a token the provider does not exist
+	response := h.serve(t, "provider-versions")
+	if response.StatusCode != http.StatusNotFound {
+		t.Fatalf("got status %d without a token, want 404", response.StatusCode)
+	}
+
+	response = h.serve(t, "provider-versions-authorized")
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("got status %d with the token, want 200", response.StatusCode)
+	}
+	if got := response.Headers["Cache-Control"]; got != "private, no-store" {
+		t.Errorf("Cache-Control = %q, want private, no-store", got)
+	}
+	h.drain(t)
+}
+
+func TestMaintenanceMode(t *testing.T) {
+	h := newHarness(t, testRepos(), func(cfg *config.Config) {
+		cfg.MaintenanceMode = true
+	})
+
+	// the provider is not cached, and GitHub must not be used instead
+	response := h.serve(t, "provider-versions")
+	if response.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("got status %d, want 503", response.StatusCode)
+	}
+	if response.Headers["Retry-After"] == "" {
+		t.Error("expected a Retry-After header")
+	}
+
+	h.drain(t)
+	if item, _ := h.cache.GetItem(context.Background(), "example/foo"); item != nil {
+		t.Errorf("expected no refresh in maintenance mode, got %+v", item)
+	}
+}
@@ -0,0 +1,161 @@
+package keys
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	providertypes "github.com/opentofu/registry/internal/providers/types"
+	"golang.org/x/exp/slog"
+)
+
+// ErrNotFound is returned when revoking a key which is not stored.
+var ErrNotFound = errors.New("key not found")
+
+type Handler struct {
+	TableName *string
+	Client    *dynamodb.Client
+}
+
+func NewHandler(awsConfig aws.Config, tableName string) *Handler {
+	ddbClient := dynamodb.NewFromConfig(awsConfig)
+
+	return &Handler{
+		TableName: aws.String(tableName),
+		Client:    ddbClient,
+	}
+}
+
+// Key is a GPG public key submitted by the owner of a namespace. Revoked keys are kept, so that the history of the
+// keys of a namespace can be audited, but are not served anymore.
+type Key struct {
+	Owner          string     `dynamodbav:"owner" json:"-"`
+	KeyID          string     `dynamodbav:"key_id" json:"key_id"`
+	ASCIIArmor     string     `dynamodbav:"ascii_armor" json:"ascii_armor"`
+	TrustSignature string     `dynamodbav:"trust_signature,omitempty" json:"trust_signature,omitempty"`
+	Source         string     `dynamodbav:"source,omitempty" json:"source,omitempty"`
+	SourceURL      string     `dynamodbav:"source_url,omitempty" json:"source_url,omitempty"`
+	ExpiresAt      *time.Time `dynamodbav:"expires_at,omitempty" json:"expires_at,omitempty"`
+	CreatedAt      time.Time  `dynamodbav:"created_at" json:"created_at"`
+	RevokedAt      *time.Time `dynamodbav:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+}
+
+// Revoked returns true if the key must not be used to verify providers anymore.
+func (k Key) Revoked() bool {
+	return k.RevokedAt != nil
+}
+
+// PublicKey returns the key as served in the download responses.
+func (k Key) PublicKey() providertypes.GPGPublicKey {
+	return providertypes.GPGPublicKey{
+		KeyID:          k.KeyID,
+		ASCIIArmor:     k.ASCIIArmor,
+		TrustSignature: k.TrustSignature,
+		Source:         k.Source,
+		SourceURL:      k.SourceURL,
+		ExpiresAt:      k.ExpiresAt,
+	}
+}
+
+// Owner returns the key under which the keys of a namespace are stored. Tenants have their own keys, so the keys of
+// a namespace of the main registry are not shared with them.
+func Owner(tenant, namespace string) string {
+	namespace = strings.ToLower(namespace)
+	if tenant == "" {
+		return namespace
+	}
+	return fmt.Sprintf("%s/%s", tenant, namespace)
+}
+
+// Active returns the public keys of the keys which are neither revoked nor expired at the given time.
+func Active(stored []Key, now time.Time) []providertypes.GPGPublicKey {
+	var active []providertypes.GPGPublicKey
+	for _, k := range stored {
+		if !k.Revoked() {
+			active = append(active, k.PublicKey())
+		}
+	}
+	return providertypes.ValidKeys(active, now)
+}
+
+// Get returns all of the keys stored for the given owner, including the revoked ones.
+func (h *Handler) Get(ctx context.Context, owner string) ([]Key, error) {
+	var stored []Key
+	paginator := dynamodb.NewQueryPaginator(h.Client, &dynamodb.QueryInput{
+		TableName:              h.TableName,
+		KeyConditionExpression: aws.String("#owner = :owner"),
+		ExpressionAttributeNames: map[string]string{
+			"#owner": "owner",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":owner": &types.AttributeValueMemberS{Value: owner},
+		},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query keys: %w", err)
+		}
+
+		var items []Key
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &items); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal keys: %w", err)
+		}
+		stored = append(stored, items...)
+	}
+	return stored, nil
+}
+
+// Put stores a key, replacing the key with the same ID if there is one.
+func (h *Handler) Put(ctx context.Context, key Key) error {
+	marshalledItem, err := attributevalue.MarshalMap(key)
+	if err != nil {
+		return fmt.Errorf("failed to marshal key: %w", err)
+	}
+
+	slog.Info("Storing key", "owner", key.Owner, "key_id", key.KeyID)
+	_, err = h.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		Item:      marshalledItem,
+		TableName: h.TableName,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store key: %w", err)
+	}
+	return nil
+}
+
+// Revoke marks a stored key as revoked, it returns ErrNotFound if the key is not stored.
+func (h *Handler) Revoke(ctx context.Context, owner, keyID string) error {
+	revokedAt, err := attributevalue.Marshal(time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to marshal revocation time: %w", err)
+	}
+
+	slog.Info("Revoking key", "owner", owner, "key_id", keyID)
+	_, err = h.Client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: h.TableName,
+		Key: map[string]types.AttributeValue{
+			"owner":  &types.AttributeValueMemberS{Value: owner},
+			"key_id": &types.AttributeValueMemberS{Value: keyID},
+		},
+		UpdateExpression:    aws.String("SET revoked_at = :revoked_at"),
+		ConditionExpression: aws.String("attribute_exists(key_id)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":revoked_at": revokedAt,
+		},
+	})
+	if err != nil {
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("failed to revoke key: %w", err)
+	}
+	return nil
+}
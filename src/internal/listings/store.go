@@ -0,0 +1,75 @@
+// Package listings keeps the version data of providers with too many versions to be served comfortably from the cache
+// table in an S3 bucket: their rendered v1 versions listings, which the API redirects clients to or streams, and the
+// cached versions which would not fit in a DynamoDB item.
+package listings
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/opentofu/registry/internal/bucket"
+)
+
+// DefaultThreshold is the size in bytes above which a rendered v1 versions listing is written to the bucket when not
+// configured.
+const DefaultThreshold = 1 << 20
+
+// Store reads and writes objects of the listings bucket.
+type Store struct {
+	client *bucket.Client
+}
+
+func NewStore(awsConfig aws.Config, name string) *Store {
+	return &Store{client: bucket.New(awsConfig, name, 30*time.Second)} //nolint:gomnd // Listings are a few MB at most.
+}
+
+// Key returns the object key of the rendered v1 versions listing of a provider,
+// `listings/providers/<namespace>/<type>/versions.json`, under `listings/tenants/<tenant>/` for the providers of a
+// tenant registry.
+func Key(tenant, namespace, providerType string) string {
+	if tenant != "" {
+		return path.Join("listings", "tenants", tenant, "providers", namespace, providerType, "versions.json")
+	}
+	return path.Join("listings", "providers", namespace, providerType, "versions.json")
+}
+
+// Get returns the content of the object stored under the key, nil if there is none.
+func (s *Store) Get(ctx context.Context, key string) ([]byte, error) {
+	resp, err := s.client.Do(ctx, http.MethodGet, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d getting object %s", resp.StatusCode, key)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("could not read object %s: %w", key, err)
+	}
+	return body, nil
+}
+
+// Put stores the content under the key, served with the given content type.
+func (s *Store) Put(ctx context.Context, key string, body []byte, contentType string) error {
+	resp, err := s.client.Do(ctx, http.MethodPut, key, http.Header{"Content-Type": {contentType}}, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d putting object %s", resp.StatusCode, key)
+	}
+	return nil
+}
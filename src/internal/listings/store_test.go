@@ -0,0 +1,74 @@
+package listings
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/opentofu/registry/internal/awstest"
+)
+
+func TestKey(t *testing.T) {
+	if got := Key("", "hashicorp", "aws"); got != "listings/providers/hashicorp/aws/versions.json" {
+		t.Errorf("Key() = %q", got)
+	}
+	if got := Key("acme", "hashicorp", "aws"); got != "listings/tenants/acme/providers/hashicorp/aws/versions.json" {
+		t.Errorf("Key() = %q", got)
+	}
+}
+
+func TestStore(t *testing.T) {
+	var mu sync.Mutex
+	objects := make(map[string][]byte)
+	contentTypes := make(map[string]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ") || r.Header.Get("X-Amz-Content-Sha256") == "" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			objects[r.URL.Path] = body
+			contentTypes[r.URL.Path] = r.Header.Get("Content-Type")
+		case http.MethodGet:
+			body, ok := objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write(body)
+		}
+	}))
+	defer server.Close()
+
+	store := NewStore(awstest.Config(), "registry-listings")
+	store.client.Endpoint = server.URL
+	store.client.HTTPClient = server.Client()
+
+	ctx := context.Background()
+	key := Key("", "example", "foo")
+
+	missing, err := store.Get(ctx, key)
+	if err != nil || missing != nil {
+		t.Fatalf("Get() of a missing object = %q, %v", missing, err)
+	}
+
+	if err := store.Put(ctx, key, []byte(`{"versions":[]}`), "application/json"); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if contentTypes["/"+key] != "application/json" {
+		t.Errorf("object stored with content type %q", contentTypes["/"+key])
+	}
+
+	got, err := store.Get(ctx, key)
+	if err != nil || string(got) != `{"versions":[]}` {
+		t.Errorf("Get() = %q, %v", got, err)
+	}
+}
@@ -0,0 +1,142 @@
+package loadgen
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewReport(t *testing.T) {
+	var results []Result
+	for i := 1; i <= 100; i++ {
+		results = append(results, Result{Kind: KindVersions, Status: http.StatusOK, Duration: time.Duration(i) * time.Millisecond})
+	}
+	results = append(results,
+		Result{Kind: KindDownload, Status: http.StatusNotFound, Duration: time.Millisecond},
+		Result{Kind: KindDownload, Status: http.StatusBadGateway, Duration: 2 * time.Millisecond},
+		Result{Kind: KindDownload, Err: errors.New("connection reset"), Duration: 3 * time.Millisecond},
+	)
+
+	report := NewReport("versions", time.Second, results)
+	if len(report.Kinds) != 2 || report.Kinds[0].Kind != KindDownload || report.Kinds[1].Kind != KindVersions {
+		t.Fatalf("unexpected kinds %+v", report.Kinds)
+	}
+
+	versions := report.Kinds[1]
+	if versions.Requests != 100 || versions.Errors != 0 {
+		t.Errorf("unexpected versions counts %+v", versions)
+	}
+	if versions.P50 != 50*time.Millisecond || versions.P90 != 90*time.Millisecond || versions.P99 != 99*time.Millisecond || versions.Max != 100*time.Millisecond {
+		t.Errorf("unexpected versions latencies %+v", versions)
+	}
+
+	downloads := report.Kinds[0]
+	if downloads.Requests != 3 || downloads.Errors != 2 {
+		t.Errorf("expected the 502 and the failed request to be errors, got %+v", downloads)
+	}
+	if downloads.Statuses["404"] != 1 || downloads.Statuses["502"] != 1 || downloads.Statuses["error"] != 1 {
+		t.Errorf("unexpected statuses %v", downloads.Statuses)
+	}
+
+	var out strings.Builder
+	if err := report.Write(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "103 requests") || !strings.Contains(out.String(), "404:1 502:1 error:1") {
+		t.Errorf("unexpected table:\n%s", out.String())
+	}
+}
+
+func TestMixPick(t *testing.T) {
+	mix := Mixes["cold"]
+	rnd := rand.New(rand.NewSource(1))
+
+	counts := make(map[Kind]int)
+	for i := 0; i < 10000; i++ {
+		kind, ok := mix.pick(rnd, nil)
+		if !ok {
+			t.Fatal("expected a kind to be picked")
+		}
+		counts[kind]++
+	}
+	// 50/20/30 with some slack
+	if counts[KindVersions] < 4500 || counts[KindDownload] < 1500 || counts[KindColdVersions] < 2500 {
+		t.Errorf("unexpected distribution %v", counts)
+	}
+
+	for i := 0; i < 100; i++ {
+		if kind, _ := mix.pick(rnd, map[Kind]bool{KindColdVersions: true}); kind == KindColdVersions {
+			t.Fatal("picked a skipped kind")
+		}
+	}
+	if _, ok := mix.pick(rnd, map[Kind]bool{KindVersions: true, KindDownload: true, KindColdVersions: true}); ok {
+		t.Error("expected nothing to be picked when every kind is skipped")
+	}
+}
+
+func TestParseProvider(t *testing.T) {
+	if p, err := ParseProvider("hashicorp/aws"); err != nil || p.String() != "hashicorp/aws" {
+		t.Errorf("ParseProvider() = %v, %v", p, err)
+	}
+	for _, value := range []string{"", "aws", "hashicorp/", "/aws", "hashicorp/aws/extra"} {
+		if _, err := ParseProvider(value); err == nil {
+			t.Errorf("expected an error for %q", value)
+		}
+	}
+}
+
+func TestRunner(t *testing.T) {
+	var linux, darwin, cold atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v1/providers/example/foo/versions":
+			_, _ = w.Write([]byte(`{"versions":[{"version":"1.0.0","platforms":[{"os":"linux","arch":"amd64"}]},{"version":"1.10.0","platforms":[{"os":"linux","arch":"amd64"},{"os":"darwin","arch":"arm64"}]}]}`))
+		case r.URL.Path == "/v1/providers/example/foo/1.10.0/download/linux/amd64":
+			linux.Add(1)
+			_, _ = w.Write([]byte(`{}`))
+		case r.URL.Path == "/v1/providers/example/foo/1.10.0/download/darwin/arm64":
+			darwin.Add(1)
+			_, _ = w.Write([]byte(`{}`))
+		case strings.HasPrefix(r.URL.Path, "/v1/providers/cold/"):
+			cold.Add(1)
+			http.NotFound(w, r)
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	runner := &Runner{
+		BaseURL:       server.URL,
+		HTTPClient:    server.Client(),
+		Mix:           Mix{Name: "test", Weights: map[Kind]int{KindVersions: 1, KindDownload: 1, KindColdVersions: 1}, DownloadBurst: 3},
+		Providers:     []Provider{{Namespace: "example", Type: "foo"}},
+		ColdProviders: []Provider{{Namespace: "cold", Type: "a"}, {Namespace: "cold", Type: "b"}},
+		Workers:       4,
+		Duration:      100 * time.Millisecond,
+	}
+	report, err := runner.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	// bursts of 3 cycle through the platforms of the newest version
+	if linux.Load() == 0 || darwin.Load() == 0 {
+		t.Errorf("expected the downloads of 1.10.0 for both platforms, got %d linux and %d darwin", linux.Load(), darwin.Load())
+	}
+	if cold.Load() != 2 {
+		t.Errorf("expected each cold provider to be requested once, got %d requests", cold.Load())
+	}
+	for _, k := range report.Kinds {
+		if k.Errors != 0 {
+			t.Errorf("unexpected errors %+v", k)
+		}
+	}
+}
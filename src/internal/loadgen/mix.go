@@ -0,0 +1,85 @@
+// Package loadgen replays traffic mixes against a deployed registry and reports the latency and error distributions
+// of the requests, to validate performance changes before they reach production.
+package loadgen
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+// Kind is a kind of request sent by the load generator. The report is broken down by kind.
+type Kind string
+
+const (
+	// KindVersions lists the versions of a provider, like `tofu init` does for every provider.
+	KindVersions Kind = "versions"
+	// KindDownload gets the download details of a provider version for a platform.
+	KindDownload Kind = "download"
+	// KindColdVersions lists the versions of a provider which is requested only once, so that it is unlikely to be
+	// cached and the registry has to fall back to GitHub.
+	KindColdVersions Kind = "cold_versions"
+)
+
+// Mix is a traffic mix: how often each kind of request is sent.
+type Mix struct {
+	Name string
+	// Weights are the relative frequencies of the kinds of requests.
+	Weights map[Kind]int
+	// DownloadBurst is the number of download requests sent at once, for every platform of a version in turn, like a
+	// fleet of CI jobs starting together. 1 sends downloads one by one.
+	DownloadBurst int
+}
+
+// Mixes are the traffic mixes the load generator can replay, by name.
+//
+//nolint:gochecknoglobals // This should be treated as a constant.
+var Mixes = map[string]Mix{
+	// most traffic of the public registry: `tofu init` listing versions, with the lock file already pinning hashes
+	"versions": {Name: "versions", Weights: map[Kind]int{KindVersions: 90, KindDownload: 10}, DownloadBurst: 1},
+	// many clients installing the same provider at once, e.g. after a release
+	"downloads": {Name: "downloads", Weights: map[Kind]int{KindVersions: 10, KindDownload: 90}, DownloadBurst: 20},
+	// a share of rarely used providers, which miss the cache
+	"cold": {Name: "cold", Weights: map[Kind]int{KindVersions: 50, KindDownload: 20, KindColdVersions: 30}, DownloadBurst: 1},
+}
+
+// ParseMix returns the mix with the given name.
+func ParseMix(name string) (Mix, error) {
+	mix, ok := Mixes[name]
+	if !ok {
+		names := make([]string, 0, len(Mixes))
+		for n := range Mixes {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return Mix{}, fmt.Errorf("unknown mix %q, expected one of %s", name, strings.Join(names, ", "))
+	}
+	return mix, nil
+}
+
+// pick returns a kind of request at random, following the weights of the mix. Kinds in skip are never picked.
+func (m Mix) pick(rnd *rand.Rand, skip map[Kind]bool) (Kind, bool) {
+	kinds := make([]Kind, 0, len(m.Weights))
+	total := 0
+	for kind, weight := range m.Weights {
+		if weight > 0 && !skip[kind] {
+			kinds = append(kinds, kind)
+			total += weight
+		}
+	}
+	if total == 0 {
+		return "", false
+	}
+	// map order is random, sort to pick the same kinds for the same seed
+	sort.Slice(kinds, func(i, j int) bool { return kinds[i] < kinds[j] })
+
+	n := rnd.Intn(total)
+	for _, kind := range kinds {
+		if n < m.Weights[kind] {
+			return kind, true
+		}
+		n -= m.Weights[kind]
+	}
+	return kinds[len(kinds)-1], true
+}
@@ -0,0 +1,122 @@
+package loadgen
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+// Result is the outcome of a single request.
+type Result struct {
+	Kind     Kind
+	Status   int // Zero if the request failed without a response.
+	Err      error
+	Duration time.Duration
+}
+
+// failed reports whether the request counts as an error: it got no response or a server error. Client errors such
+// as a 404 for a provider without the requested platform are expected.
+func (r Result) failed() bool {
+	return r.Err != nil || r.Status >= 500
+}
+
+// Report is the distribution of the results of a run, broken down by kind of request.
+type Report struct {
+	Mix      string        `json:"mix"`
+	Duration time.Duration `json:"duration"`
+	Kinds    []KindReport  `json:"kinds"`
+}
+
+// KindReport is the distribution of the results of a kind of request.
+type KindReport struct {
+	Kind     Kind           `json:"kind"`
+	Requests int            `json:"requests"`
+	Errors   int            `json:"errors"`
+	Statuses map[string]int `json:"statuses"` // By status code, "error" for requests without a response.
+	P50      time.Duration  `json:"p50"`
+	P90      time.Duration  `json:"p90"`
+	P99      time.Duration  `json:"p99"`
+	Max      time.Duration  `json:"max"`
+}
+
+// NewReport computes the distribution of the results.
+func NewReport(mix string, duration time.Duration, results []Result) Report {
+	byKind := make(map[Kind][]Result)
+	for _, r := range results {
+		byKind[r.Kind] = append(byKind[r.Kind], r)
+	}
+
+	report := Report{Mix: mix, Duration: duration}
+	for kind, kindResults := range byKind {
+		kr := KindReport{Kind: kind, Requests: len(kindResults), Statuses: make(map[string]int)}
+		durations := make([]time.Duration, len(kindResults))
+		for i, r := range kindResults {
+			durations[i] = r.Duration
+			if r.failed() {
+				kr.Errors++
+			}
+			status := "error"
+			if r.Status != 0 {
+				status = strconv.Itoa(r.Status)
+			}
+			kr.Statuses[status]++
+		}
+		sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+		kr.P50 = percentile(durations, 50) //nolint:gomnd // Median.
+		kr.P90 = percentile(durations, 90) //nolint:gomnd // 90th percentile.
+		kr.P99 = percentile(durations, 99) //nolint:gomnd // 99th percentile.
+		kr.Max = durations[len(durations)-1]
+		report.Kinds = append(report.Kinds, kr)
+	}
+	sort.Slice(report.Kinds, func(i, j int) bool { return report.Kinds[i].Kind < report.Kinds[j].Kind })
+	return report
+}
+
+// percentile returns the nearest-rank percentile p of the sorted durations.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100 //nolint:gomnd // Rounds up to the nearest rank.
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// Write writes the report as a table.
+func (r Report) Write(w io.Writer) error {
+	total := 0
+	for _, k := range r.Kinds {
+		total += k.Requests
+	}
+	rate := float64(total) / r.Duration.Seconds()
+	if _, err := fmt.Fprintf(w, "mix %s: %d requests in %s (%.1f/s)\n\n", r.Mix, total, r.Duration.Round(time.Millisecond), rate); err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0) //nolint:gomnd // Column padding.
+	fmt.Fprintln(tw, "KIND\tREQUESTS\tERRORS\tP50\tP90\tP99\tMAX\tSTATUSES")
+	for _, k := range r.Kinds {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\t%s\t%s\t%s\n", k.Kind, k.Requests, k.Errors,
+			k.P50.Round(time.Millisecond), k.P90.Round(time.Millisecond), k.P99.Round(time.Millisecond), k.Max.Round(time.Millisecond),
+			formatStatuses(k.Statuses))
+	}
+	return tw.Flush()
+}
+
+func formatStatuses(statuses map[string]int) string {
+	keys := make([]string, 0, len(statuses))
+	for status := range statuses {
+		keys = append(keys, status)
+	}
+	sort.Strings(keys)
+	formatted := ""
+	for i, status := range keys {
+		if i > 0 {
+			formatted += " "
+		}
+		formatted += fmt.Sprintf("%s:%d", status, statuses[status])
+	}
+	return formatted
+}
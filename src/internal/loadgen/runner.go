@@ -0,0 +1,229 @@
+package loadgen
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/opentofu/registry/internal/platform"
+	"github.com/opentofu/registry/internal/providers/types"
+	"github.com/opentofu/registry/internal/version"
+)
+
+// Provider is a provider requested by the load generator, as `<namespace>/<type>`.
+type Provider struct {
+	Namespace string
+	Type      string
+}
+
+// ParseProvider parses a provider address, `<namespace>/<type>`.
+func ParseProvider(value string) (Provider, error) {
+	namespace, providerType, ok := strings.Cut(value, "/")
+	if !ok || namespace == "" || providerType == "" || strings.Contains(providerType, "/") {
+		return Provider{}, fmt.Errorf("invalid provider %q, expected <namespace>/<type>", value)
+	}
+	return Provider{Namespace: namespace, Type: providerType}, nil
+}
+
+func (p Provider) String() string {
+	return p.Namespace + "/" + p.Type
+}
+
+// download is a version of a provider whose downloads are requested, with the platforms it is available for.
+type download struct {
+	provider  Provider
+	version   string
+	platforms []platform.Platform
+}
+
+// Runner sends the requests of a traffic mix to a registry for a duration.
+type Runner struct {
+	// BaseURL is the URL of the registry, e.g. `https://registry.example.com`.
+	BaseURL    string
+	HTTPClient *http.Client
+
+	Mix Mix
+	// Providers are requested over and over, and their newest version downloaded.
+	Providers []Provider
+	// ColdProviders are each listed once, for the cold versions requests of the mix.
+	ColdProviders []Provider
+
+	// Workers is the number of requests in flight at once, not counting the downloads of a burst.
+	Workers int
+	// Rate caps the number of requests started per second, zero is unlimited. A download burst counts as one.
+	Rate     float64
+	Duration time.Duration
+	Seed     int64
+
+	downloads []download
+	cold      atomic.Int64
+
+	mu      sync.Mutex
+	results []Result
+}
+
+// Run sends requests until the duration is over or ctx is done, and reports their results. The versions to download
+// are resolved first, from the listings of the providers.
+func (r *Runner) Run(ctx context.Context) (Report, error) {
+	if r.Workers < 1 {
+		return Report{}, fmt.Errorf("at least one worker is required")
+	}
+	if err := r.resolveDownloads(ctx); err != nil {
+		return Report{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.Duration)
+	defer cancel()
+
+	var tokens <-chan time.Time
+	if r.Rate > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / r.Rate))
+		defer ticker.Stop()
+		tokens = ticker.C
+	}
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < r.Workers; i++ {
+		wg.Add(1)
+		go func(rnd *rand.Rand) {
+			defer wg.Done()
+			r.work(ctx, rnd, tokens)
+		}(rand.New(rand.NewSource(r.Seed + int64(i)))) //nolint:gosec // Not used for security.
+	}
+	wg.Wait()
+
+	return NewReport(r.Mix.Name, time.Since(start), r.results), nil
+}
+
+func (r *Runner) work(ctx context.Context, rnd *rand.Rand, tokens <-chan time.Time) {
+	skip := map[Kind]bool{
+		KindVersions:     len(r.Providers) == 0,
+		KindDownload:     len(r.downloads) == 0,
+		KindColdVersions: len(r.ColdProviders) == 0,
+	}
+	for {
+		if tokens != nil {
+			select {
+			case <-tokens:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		kind, ok := r.Mix.pick(rnd, skip)
+		if !ok {
+			return
+		}
+		switch kind {
+		case KindVersions:
+			p := r.Providers[rnd.Intn(len(r.Providers))]
+			r.record(r.get(ctx, KindVersions, fmt.Sprintf("/v1/providers/%s/versions", p)))
+		case KindDownload:
+			r.burst(ctx, r.downloads[rnd.Intn(len(r.downloads))])
+		case KindColdVersions:
+			i := int(r.cold.Add(1)) - 1
+			if i >= len(r.ColdProviders) {
+				// every cold provider has been requested, they are not cold anymore
+				skip[KindColdVersions] = true
+				continue
+			}
+			r.record(r.get(ctx, KindColdVersions, fmt.Sprintf("/v1/providers/%s/versions", r.ColdProviders[i])))
+		}
+	}
+}
+
+// burst requests the downloads of the version at once, cycling through its platforms.
+func (r *Runner) burst(ctx context.Context, d download) {
+	size := r.Mix.DownloadBurst
+	if size < 1 {
+		size = 1
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < size; i++ {
+		p := d.platforms[i%len(d.platforms)]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.record(r.get(ctx, KindDownload, fmt.Sprintf("/v1/providers/%s/%s/download/%s/%s", d.provider, d.version, p.OS, p.Arch)))
+		}()
+	}
+	wg.Wait()
+}
+
+func (r *Runner) get(ctx context.Context, kind Kind, path string) Result {
+	start := time.Now()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.BaseURL+path, nil)
+	if err != nil {
+		return Result{Kind: kind, Err: err}
+	}
+	resp, err := r.HTTPClient.Do(req)
+	if err != nil {
+		return Result{Kind: kind, Err: err, Duration: time.Since(start)}
+	}
+	defer resp.Body.Close()
+	// the latency includes reading the body, like for a client
+	_, err = io.Copy(io.Discard, resp.Body)
+	return Result{Kind: kind, Status: resp.StatusCode, Err: err, Duration: time.Since(start)}
+}
+
+func (r *Runner) record(result Result) {
+	// requests cut short by the end of the run are not part of the results
+	if errors.Is(result.Err, context.DeadlineExceeded) || errors.Is(result.Err, context.Canceled) {
+		return
+	}
+	r.mu.Lock()
+	r.results = append(r.results, result)
+	r.mu.Unlock()
+}
+
+// resolveDownloads lists the versions of the providers, to download the newest version of each.
+func (r *Runner) resolveDownloads(ctx context.Context) error {
+	r.downloads = nil
+	for _, p := range r.Providers {
+		var listing struct {
+			Versions []types.Version `json:"versions"`
+		}
+		if err := r.getJSON(ctx, fmt.Sprintf("/v1/providers/%s/versions", p), &listing); err != nil {
+			return fmt.Errorf("could not list the versions of %s: %w", p, err)
+		}
+
+		var newest *types.Version
+		for i, v := range listing.Versions {
+			if len(v.Platforms) > 0 && (newest == nil || version.Compare(v.Version, newest.Version) > 0) {
+				newest = &listing.Versions[i]
+			}
+		}
+		if newest != nil {
+			r.downloads = append(r.downloads, download{provider: p, version: newest.Version, platforms: newest.Platforms})
+		}
+	}
+	return nil
+}
+
+func (r *Runner) getJSON(ctx context.Context, path string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.BaseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := r.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
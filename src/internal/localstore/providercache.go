@@ -0,0 +1,399 @@
+package localstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/opentofu/registry/internal/providers/providercache"
+	"github.com/opentofu/registry/internal/providers/types"
+	bolt "go.etcd.io/bbolt"
+	"golang.org/x/exp/slog"
+)
+
+// providerVersionsBucket holds one entry per provider, keyed by "namespace/type".
+var providerVersionsBucket = []byte("provider_versions") //nolint:gochecknoglobals // This should be treated as a constant.
+
+// ProviderCache keeps the provider version listings in a local bbolt database file. It implements
+// providercache.Cache for the self-hosted mode, where there is no DynamoDB.
+type ProviderCache struct {
+	db *bolt.DB
+}
+
+// storedItem is how a cache item is serialized in the database. The versions are kept raw, so that items of an older
+// schema version can be migrated before they are decoded.
+type storedItem struct {
+	Versions      json.RawMessage        `json:"versions"`
+	LastUpdated   time.Time              `json:"last_updated"`
+	Metadata      types.ProviderMetadata `json:"metadata"`
+	SchemaVersion int                    `json:"schema_version"`
+	// Revision is incremented by every write of the item. The writes are transactions, which bbolt runs one at a
+	// time, so it is only recorded for the item to look the same as in DynamoDB.
+	Revision int64 `json:"revision,omitempty"`
+}
+
+// OpenProviderCache opens the database at path, creating it if it does not exist yet.
+func OpenProviderCache(path string) (*ProviderCache, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second}) //nolint:gomnd // Only readable by the registry.
+	if err != nil {
+		return nil, fmt.Errorf("could not open database %s: %w", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(providerVersionsBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("could not create bucket: %w", err)
+	}
+
+	return &ProviderCache{db: db}, nil
+}
+
+// Close closes the database file.
+func (c *ProviderCache) Close() error {
+	return c.db.Close()
+}
+
+// Ping checks that the database can be read.
+func (c *ProviderCache) Ping() error {
+	return c.db.View(func(tx *bolt.Tx) error {
+		if tx.Bucket(providerVersionsBucket) == nil {
+			return fmt.Errorf("bucket %s is missing", providerVersionsBucket)
+		}
+		return nil
+	})
+}
+
+func (c *ProviderCache) GetItem(_ context.Context, key string) (*types.CacheItem, error) {
+	var item *types.CacheItem
+	migrated := make(map[string][]byte)
+	err := c.db.View(func(tx *bolt.Tx) error {
+		var err error
+		item, err = decodeItem(key, tx.Bucket(providerVersionsBucket).Get([]byte(key)), migrated)
+		return err
+	})
+	if err != nil {
+		slog.Error("Failed to get item from cache", "key", key, "error", err)
+		return nil, err
+	}
+	c.rewrite(migrated)
+	return item, nil
+}
+
+func (c *ProviderCache) GetItems(_ context.Context, keys []string) (map[string]*types.CacheItem, error) {
+	items := make(map[string]*types.CacheItem, len(keys))
+	migrated := make(map[string][]byte)
+	err := c.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(providerVersionsBucket)
+		for _, key := range keys {
+			item, err := decodeItem(key, bucket.Get([]byte(key)), migrated)
+			if err != nil {
+				return err
+			}
+			if item != nil {
+				items[key] = item
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		slog.Error("Failed to get items from cache", "error", err)
+		return nil, err
+	}
+	c.rewrite(migrated)
+	return items, nil
+}
+
+func (c *ProviderCache) Store(_ context.Context, key string, versions types.VersionList, metadata types.ProviderMetadata) error {
+	slog.Info("Storing provider versions", "key", key, "versions", len(versions))
+	err := c.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(providerVersionsBucket)
+		data, err := encodeItem(versions, metadata, time.Now(), storedRevision(bucket, key)+1)
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(key), data)
+	})
+	if err != nil {
+		return fmt.Errorf("could not store item: %w", err)
+	}
+	return nil
+}
+
+// AppendVersions reads and writes the item in a single transaction, which bbolt does not run concurrently with any
+// other write.
+func (c *ProviderCache) AppendVersions(_ context.Context, key string, versions types.VersionList, metadata types.ProviderMetadata) error {
+	slog.Info("Appending provider versions", "key", key, "versions", len(versions))
+	err := c.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(providerVersionsBucket)
+		// a migrated item is written in the current schema version below anyway
+		current, err := decodeItem(key, bucket.Get([]byte(key)), make(map[string][]byte))
+		if err != nil {
+			return err
+		}
+
+		merged, revision := versions, int64(0)
+		if current != nil {
+			merged, revision = append(current.Versions, versions...).Deduplicate(), current.Revision
+		}
+		data, err := encodeItem(merged, metadata, time.Now(), revision+1)
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(key), data)
+	})
+	if err != nil {
+		return fmt.Errorf("could not append versions: %w", err)
+	}
+	return nil
+}
+
+// UpdateVersions reads, changes and writes the item in a single transaction, like AppendVersions, keeping its update
+// time.
+func (c *ProviderCache) UpdateVersions(_ context.Context, key string, update func(item *types.CacheItem) error) error {
+	return c.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(providerVersionsBucket)
+		item, err := decodeItem(key, bucket.Get([]byte(key)), make(map[string][]byte))
+		if err != nil {
+			return err
+		}
+		if item == nil {
+			return fmt.Errorf("could not update %s: %w", key, providercache.ErrNotFound)
+		}
+
+		if err := update(item); err != nil {
+			return err
+		}
+		data, err := encodeItem(item.Versions, item.Metadata, item.LastUpdated, item.Revision+1)
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(key), data)
+	})
+}
+
+// StoreBatch stores the items in a single transaction.
+func (c *ProviderCache) StoreBatch(_ context.Context, entries []providercache.Entry) error {
+	slog.Info("Storing provider versions in batch", "items", len(entries))
+	err := c.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(providerVersionsBucket)
+		for _, entry := range entries {
+			data, err := encodeItem(entry.Versions, entry.Metadata, time.Now(), storedRevision(bucket, entry.Key)+1)
+			if err != nil {
+				return fmt.Errorf("could not store %s: %w", entry.Key, err)
+			}
+			if err := bucket.Put([]byte(entry.Key), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("could not store items: %w", err)
+	}
+	return nil
+}
+
+// storedRevision returns the revision of the item stored under key, 0 if there is none or it has no revision.
+func storedRevision(bucket *bolt.Bucket, key string) int64 {
+	var stored struct {
+		Revision int64 `json:"revision"`
+	}
+	if data := bucket.Get([]byte(key)); data != nil {
+		_ = json.Unmarshal(data, &stored)
+	}
+	return stored.Revision
+}
+
+func encodeItem(versions types.VersionList, metadata types.ProviderMetadata, lastUpdated time.Time, revision int64) ([]byte, error) {
+	versionsData, err := json.Marshal(versions)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal versions: %w", err)
+	}
+	data, err := json.Marshal(storedItem{
+		Versions:      versionsData,
+		LastUpdated:   lastUpdated,
+		Metadata:      metadata,
+		SchemaVersion: providercache.CurrentSchemaVersion,
+		Revision:      revision,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal item: %w", err)
+	}
+	return data, nil
+}
+
+func (c *ProviderCache) StoreMetadata(_ context.Context, key string, metadata types.ProviderMetadata) error {
+	err := c.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(providerVersionsBucket)
+		data := bucket.Get([]byte(key))
+		if data == nil {
+			return nil
+		}
+
+		var stored storedItem
+		if err := json.Unmarshal(data, &stored); err != nil {
+			return fmt.Errorf("could not unmarshal item %s: %w", key, err)
+		}
+		stored.Metadata = metadata
+		stored.Revision++
+		updated, err := json.Marshal(stored)
+		if err != nil {
+			return fmt.Errorf("could not marshal item: %w", err)
+		}
+		return bucket.Put([]byte(key), updated)
+	})
+	if err != nil {
+		return fmt.Errorf("could not store metadata: %w", err)
+	}
+	return nil
+}
+
+func (c *ProviderCache) Delete(_ context.Context, key string) error {
+	slog.Info("Deleting provider versions", "key", key)
+	err := c.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(providerVersionsBucket).Delete([]byte(key))
+	})
+	if err != nil {
+		return fmt.Errorf("could not delete item: %w", err)
+	}
+	return nil
+}
+
+// ListNamespace returns the keys of the items of the providers of a namespace.
+func (c *ProviderCache) ListNamespace(_ context.Context, namespace string) ([]string, error) {
+	var keys []string
+	prefix := []byte(namespace + "/")
+	err := c.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(providerVersionsBucket).Cursor()
+		for key, _ := cursor.Seek(prefix); key != nil && bytes.HasPrefix(key, prefix); key, _ = cursor.Next() {
+			keys = append(keys, string(key))
+		}
+		return nil
+	})
+	if err != nil {
+		slog.Error("Failed to list namespace from cache", "namespace", namespace, "error", err)
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Sample returns the keys of up to n items picked at random.
+func (c *ProviderCache) Sample(_ context.Context, n int) ([]string, error) {
+	var keys []string
+	err := c.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(providerVersionsBucket).ForEach(func(key, _ []byte) error {
+			keys = append(keys, string(key))
+			return nil
+		})
+	})
+	if err != nil {
+		slog.Error("Failed to sample cache", "error", err)
+		return nil, err
+	}
+	rand.Shuffle(len(keys), func(i, j int) { keys[i], keys[j] = keys[j], keys[i] })
+	if len(keys) > n {
+		keys = keys[:n]
+	}
+	return keys, nil
+}
+
+// Search returns the keys of the items whose key contains query, sorted, leaving out the providers known not to exist.
+func (c *ProviderCache) Search(_ context.Context, query string) ([]string, error) {
+	var keys []string
+	err := c.db.View(func(tx *bolt.Tx) error {
+		// bbolt iterates in key order, so the keys are already sorted
+		return tx.Bucket(providerVersionsBucket).ForEach(func(key, data []byte) error {
+			if !bytes.Contains(key, []byte(query)) {
+				return nil
+			}
+			var stored storedItem
+			if err := json.Unmarshal(data, &stored); err != nil {
+				return fmt.Errorf("failed to unmarshal item %s: %w", key, err)
+			}
+			if !stored.Metadata.NotFound {
+				keys = append(keys, string(key))
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		slog.Error("Failed to search cache", "query", query, "error", err)
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Keys returns the keys of all the items, sorted, leaving out the providers known not to exist.
+func (c *ProviderCache) Keys(ctx context.Context) ([]string, error) {
+	return c.Search(ctx, "")
+}
+
+// decodeItem unmarshals a stored item, returning nil if there is none. The data is only valid within the transaction
+// it was read in, so it must not be retained. Items of an older schema version are migrated, and added to migrated
+// encoded in the current one, to be written back.
+func decodeItem(key string, data []byte, migrated map[string][]byte) (*types.CacheItem, error) {
+	if data == nil {
+		return nil, nil //nolint:nilnil // This is not an error, it just means the provider is not cached.
+	}
+
+	var stored storedItem
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("could not unmarshal item %s: %w", key, err)
+	}
+
+	versionsData, wasMigrated, err := providercache.MigrateVersions(stored.SchemaVersion, stored.Versions)
+	if err != nil {
+		return nil, fmt.Errorf("could not migrate item %s: %w", key, err)
+	}
+
+	item := &types.CacheItem{
+		Provider:      key,
+		LastUpdated:   stored.LastUpdated,
+		Metadata:      stored.Metadata,
+		SchemaVersion: stored.SchemaVersion,
+		Revision:      stored.Revision,
+	}
+	if err := json.Unmarshal(versionsData, &item.Versions); err != nil {
+		return nil, fmt.Errorf("could not unmarshal versions of item %s: %w", key, err)
+	}
+
+	if wasMigrated {
+		item.SchemaVersion = providercache.CurrentSchemaVersion
+		stored.Versions = versionsData
+		stored.SchemaVersion = providercache.CurrentSchemaVersion
+		if migrated[key], err = json.Marshal(stored); err != nil {
+			return nil, fmt.Errorf("could not marshal migrated item %s: %w", key, err)
+		}
+	}
+	return item, nil
+}
+
+// rewrite writes back the items migrated on read, unless they were stored again since. Failures are logged, the items
+// are migrated again on the next read.
+func (c *ProviderCache) rewrite(migrated map[string][]byte) {
+	if len(migrated) == 0 {
+		return
+	}
+	err := c.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(providerVersionsBucket)
+		for key, data := range migrated {
+			var current storedItem
+			if err := json.Unmarshal(bucket.Get([]byte(key)), &current); err != nil || current.SchemaVersion >= providercache.CurrentSchemaVersion {
+				continue
+			}
+			if err := bucket.Put([]byte(key), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		slog.Error("Failed to rewrite migrated items", "error", err)
+	}
+}
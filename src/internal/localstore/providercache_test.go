@@ -0,0 +1,131 @@
+package localstore
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/opentofu/registry/internal/providers/providercache"
+	"github.com/opentofu/registry/internal/providers/types"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestProviderCacheRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "registry.db")
+
+	cache, err := OpenProviderCache(path)
+	if err != nil {
+		t.Fatalf("unexpected error opening cache: %v", err)
+	}
+
+	item, err := cache.GetItem(ctx, "example/missing")
+	if err != nil || item != nil {
+		t.Fatalf("expected no item and no error, got %v, %v", item, err)
+	}
+
+	versions := types.VersionList{{Version: "1.0.0", Protocols: []string{"5.0"}}}
+	if err := cache.Store(ctx, "example/cached", versions, types.ProviderMetadata{Archived: true}); err != nil {
+		t.Fatalf("unexpected error storing item: %v", err)
+	}
+	if err := cache.Close(); err != nil {
+		t.Fatalf("unexpected error closing cache: %v", err)
+	}
+
+	// the items have to survive reopening the database
+	cache, err = OpenProviderCache(path)
+	if err != nil {
+		t.Fatalf("unexpected error reopening cache: %v", err)
+	}
+	defer cache.Close()
+
+	items, err := cache.GetItems(ctx, []string{"example/cached", "example/missing"})
+	if err != nil {
+		t.Fatalf("unexpected error getting items: %v", err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("expected 1 item, got %d", len(items))
+	}
+	cached := items["example/cached"]
+	if cached.Provider != "example/cached" || len(cached.Versions) != 1 || cached.Versions[0].Version != "1.0.0" {
+		t.Errorf("unexpected item %+v", cached)
+	}
+	if !cached.Metadata.Archived || cached.LastUpdated.IsZero() {
+		t.Errorf("expected metadata and last updated time to be stored, got %+v", cached)
+	}
+
+	// namespaces sharing a prefix must not be listed together
+	if err := cache.Store(ctx, "examples/other", versions, types.ProviderMetadata{}); err != nil {
+		t.Fatalf("unexpected error storing item: %v", err)
+	}
+	keys, err := cache.ListNamespace(ctx, "example")
+	if err != nil {
+		t.Fatalf("unexpected error listing namespace: %v", err)
+	}
+	if len(keys) != 1 || keys[0] != "example/cached" {
+		t.Errorf("ListNamespace() = %v, want [example/cached]", keys)
+	}
+}
+
+func TestProviderCacheMigratesLegacyItems(t *testing.T) {
+	ctx := context.Background()
+	cache, err := OpenProviderCache(filepath.Join(t.TempDir(), "registry.db"))
+	if err != nil {
+		t.Fatalf("unexpected error opening cache: %v", err)
+	}
+	defer cache.Close()
+
+	// an item stored before the schema version was recorded
+	legacy := `{"versions":[{"version":"1.0.0","protocols":["5.0"]}],"last_updated":"2023-09-01T12:00:00Z","metadata":{}}`
+	err = cache.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(providerVersionsBucket).Put([]byte("example/legacy"), []byte(legacy))
+	})
+	if err != nil {
+		t.Fatalf("unexpected error storing legacy item: %v", err)
+	}
+
+	item, err := cache.GetItem(ctx, "example/legacy")
+	if err != nil {
+		t.Fatalf("unexpected error getting item: %v", err)
+	}
+	if item.SchemaVersion != providercache.CurrentSchemaVersion || len(item.Versions) != 1 || item.Versions[0].Version != "1.0.0" {
+		t.Errorf("unexpected item %+v", item)
+	}
+
+	// the migrated item is written back, keeping its update time
+	var stored storedItem
+	err = cache.db.View(func(tx *bolt.Tx) error {
+		return json.Unmarshal(tx.Bucket(providerVersionsBucket).Get([]byte("example/legacy")), &stored)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error reading item: %v", err)
+	}
+	if stored.SchemaVersion != providercache.CurrentSchemaVersion || !stored.LastUpdated.Equal(item.LastUpdated) {
+		t.Errorf("expected the migrated item to be rewritten, got %+v", stored)
+	}
+}
+
+func TestProviderCacheAppendVersions(t *testing.T) {
+	ctx := context.Background()
+	cache, err := OpenProviderCache(filepath.Join(t.TempDir(), "registry.db"))
+	if err != nil {
+		t.Fatalf("unexpected error opening cache: %v", err)
+	}
+	defer cache.Close()
+
+	if err := cache.AppendVersions(ctx, "example/cached", types.VersionList{{Version: "1.0.0"}}, types.ProviderMetadata{}); err != nil {
+		t.Fatalf("unexpected error appending to a missing item: %v", err)
+	}
+	if err := cache.AppendVersions(ctx, "example/cached", types.VersionList{{Version: "1.0.0"}, {Version: "1.1.0"}}, types.ProviderMetadata{Archived: true}); err != nil {
+		t.Fatalf("unexpected error appending versions: %v", err)
+	}
+
+	item, err := cache.GetItem(ctx, "example/cached")
+	if err != nil || item == nil {
+		t.Fatalf("GetItem() = %v, %v", item, err)
+	}
+	if len(item.Versions) != 2 || !item.Metadata.Archived {
+		t.Errorf("expected versions 1.0.0 and 1.1.0 with the new metadata, got %+v", item)
+	}
+}
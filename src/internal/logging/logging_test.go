@@ -0,0 +1,38 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"golang.org/x/exp/slog"
+)
+
+func TestWith(t *testing.T) {
+	if FromContext(context.Background()) != slog.Default() {
+		t.Error("expected the default logger without a logger in the context")
+	}
+
+	var out bytes.Buffer
+	ctx := WithLogger(context.Background(), slog.New(slog.NewJSONHandler(&out, nil)))
+	first := With(ctx, "request_id", "first")
+	second := With(ctx, "request_id", "second")
+
+	FromContext(first).Info("served")
+	FromContext(second).Info("served")
+	FromContext(ctx).Info("served")
+
+	var ids []any
+	decoder := json.NewDecoder(&out)
+	for decoder.More() {
+		var line map[string]any
+		if err := decoder.Decode(&line); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		ids = append(ids, line["request_id"])
+	}
+	if len(ids) != 3 || ids[0] != "first" || ids[1] != "second" || ids[2] != nil {
+		t.Errorf("request_id = %v, want each context to keep its own attributes", ids)
+	}
+}
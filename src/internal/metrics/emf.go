@@ -0,0 +1,111 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/slog"
+)
+
+// DefaultEMFNamespace is the CloudWatch namespace of the metrics of the Lambdas, unless METRICS_NAMESPACE is set.
+const DefaultEMFNamespace = "OpenTofuRegistry"
+
+// propertyLabels are the labels with too many values to be CloudWatch dimensions, as every combination of dimensions
+// is billed as a metric of its own. They are kept as properties of the EMF documents, which CloudWatch Logs Insights
+// can still query.
+//
+//nolint:gochecknoglobals // This should be treated as a constant.
+var propertyLabels = map[string]bool{"namespace": true, "type": true}
+
+// WriteEMF writes the families in the CloudWatch Embedded Metric Format, one JSON document per series and line, which
+// CloudWatch Logs turns into metrics when they are logged. Histograms are written as the values and counts of their
+// buckets, each bucket counting as its upper bound, and the observations above the last one as the largest of them.
+func WriteEMF(w io.Writer, namespace string, timestamp time.Time, families []Family) error {
+	for _, f := range families {
+		for _, s := range f.Samples {
+			dimensions := make([]string, 0, len(s.Labels))
+			document := make(map[string]any, len(s.Labels)+2) //nolint:gomnd // _aws and the metric itself.
+			for _, name := range s.Labels.names() {
+				document[name] = s.Labels[name]
+				if !propertyLabels[name] {
+					dimensions = append(dimensions, name)
+				}
+			}
+			document[f.Name] = emfValue(f.Kind, s)
+			document["_aws"] = map[string]any{
+				"Timestamp": timestamp.UnixMilli(),
+				"CloudWatchMetrics": []map[string]any{{
+					"Namespace":  namespace,
+					"Dimensions": [][]string{dimensions},
+					"Metrics":    []map[string]string{{"Name": f.Name, "Unit": emfUnit(f)}},
+				}},
+			}
+
+			line, err := json.Marshal(document)
+			if err != nil {
+				return fmt.Errorf("could not marshal metric %s: %w", f.Name, err)
+			}
+			// a single write per document, so that the documents are not interleaved with other logs
+			if _, err := w.Write(append(line, '\n')); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func emfValue(kind Kind, s Sample) any {
+	if kind != KindHistogram {
+		return s.Value
+	}
+
+	values := make([]float64, 0, len(s.Buckets)+1)
+	counts := make([]uint64, 0, len(s.Buckets)+1)
+	var previous uint64
+	for _, b := range s.Buckets {
+		if b.Count > previous {
+			values = append(values, b.UpperBound)
+			counts = append(counts, b.Count-previous)
+		}
+		previous = b.Count
+	}
+	if s.Count > previous {
+		values = append(values, s.Max)
+		counts = append(counts, s.Count-previous)
+	}
+	return map[string]any{"Values": values, "Counts": counts}
+}
+
+func emfUnit(f Family) string {
+	switch {
+	case f.Kind == KindCounter:
+		return "Count"
+	case strings.HasSuffix(f.Name, "_seconds"):
+		return "Seconds"
+	default:
+		return "None"
+	}
+}
+
+// WithEMF wraps a Lambda handler to log the metrics recorded during each invocation in the Embedded Metric Format once
+// it is done, under the namespace of METRICS_NAMESPACE. The registry is drained every time, so the counters of each
+// invocation are only reported once, and the gauges are only reported when they were set.
+func WithEMF[In, Out any](handler func(context.Context, In) (Out, error)) func(context.Context, In) (Out, error) {
+	namespace := os.Getenv("METRICS_NAMESPACE")
+	if namespace == "" {
+		namespace = DefaultEMFNamespace
+	}
+
+	return func(ctx context.Context, event In) (Out, error) {
+		out, err := handler(ctx, event)
+		if emfErr := WriteEMF(os.Stdout, namespace, time.Now(), defaultRegistry.Drain()); emfErr != nil {
+			slog.Error("Could not write metrics", "error", emfErr)
+		}
+		return out, err
+	}
+}
@@ -0,0 +1,33 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteEMF(t *testing.T) {
+	r := NewRegistry()
+	r.Add(HTTPRequests, Labels{"route": "/v1/providers/{namespace}/{type}/versions", "status": "404"}, 2)
+	r.Add(RefreshQuarantines, Labels{"namespace": "hashicorp", "type": "aws"}, 1)
+	r.Observe(HTTPRequestDuration, Labels{"route": "/metrics"}, 0.03)
+	r.Observe(HTTPRequestDuration, Labels{"route": "/metrics"}, 0.04)
+	r.Observe(HTTPRequestDuration, Labels{"route": "/metrics"}, 20)
+
+	var b strings.Builder
+	if err := WriteEMF(&b, "Test", time.UnixMilli(1700000000000), r.Drain()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := `{"_aws":{"CloudWatchMetrics":[{"Dimensions":[["route"]],"Metrics":[{"Name":"registry_http_request_duration_seconds","Unit":"Seconds"}],"Namespace":"Test"}],"Timestamp":1700000000000},"registry_http_request_duration_seconds":{"Counts":[2,1],"Values":[0.05,20]},"route":"/metrics"}
+{"_aws":{"CloudWatchMetrics":[{"Dimensions":[["route","status"]],"Metrics":[{"Name":"registry_http_requests_total","Unit":"Count"}],"Namespace":"Test"}],"Timestamp":1700000000000},"registry_http_requests_total":2,"route":"/v1/providers/{namespace}/{type}/versions","status":"404"}
+{"_aws":{"CloudWatchMetrics":[{"Dimensions":[[]],"Metrics":[{"Name":"registry_refresh_quarantines_total","Unit":"Count"}],"Namespace":"Test"}],"Timestamp":1700000000000},"namespace":"hashicorp","registry_refresh_quarantines_total":1,"type":"aws"}
+`
+	if got := b.String(); got != want {
+		t.Errorf("WriteEMF() =\n%s\nwant\n%s", got, want)
+	}
+
+	if families := r.Snapshot(); len(families) != 0 {
+		t.Errorf("the registry still has %d families after being drained", len(families))
+	}
+}
@@ -0,0 +1,231 @@
+package metrics
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Kind is the type of a metric family.
+type Kind string
+
+const (
+	KindCounter   Kind = "counter"
+	KindGauge     Kind = "gauge"
+	KindHistogram Kind = "histogram"
+)
+
+// Labels are the dimensions of a single series within a metric family.
+type Labels map[string]string
+
+// key returns a stable representation of the labels, used to find the series they belong to.
+func (l Labels) key() string {
+	names := l.names()
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name)
+		b.WriteByte(0)
+		b.WriteString(l[name])
+		b.WriteByte(0)
+	}
+	return b.String()
+}
+
+func (l Labels) names() []string {
+	names := make([]string, 0, len(l))
+	for name := range l {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DefaultBuckets are the upper bounds, in seconds, of the histogram buckets. They cover everything from cache reads
+// to slow calls to GitHub.
+//
+//nolint:gochecknoglobals // This should be treated as a constant.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Registry holds the metrics of the process. It is the single source for every way metrics leave the process, the
+// Prometheus endpoint in server mode and CloudWatch in Lambda mode, so both report the same names and labels.
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]*family
+}
+
+type family struct {
+	name   string
+	help   string
+	kind   Kind
+	series map[string]*series
+}
+
+type series struct {
+	labels  Labels
+	value   float64
+	buckets []uint64 // Only for histograms, the count of observations per bucket (not cumulative).
+	count   uint64
+	max     float64 // Only for histograms.
+}
+
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]*family)}
+}
+
+//nolint:gochecknoglobals // The default registry is shared by the whole process, like slog.Default.
+var defaultRegistry = NewRegistry()
+
+// Default returns the registry the package level functions record into.
+func Default() *Registry {
+	return defaultRegistry
+}
+
+// Add increments the counter with the given name by value.
+func (r *Registry) Add(name string, labels Labels, value float64) {
+	r.record(name, KindCounter, labels, func(s *series) {
+		s.value += value
+	})
+}
+
+// Set sets the gauge with the given name to value.
+func (r *Registry) Set(name string, labels Labels, value float64) {
+	r.record(name, KindGauge, labels, func(s *series) {
+		s.value = value
+	})
+}
+
+// Observe records value in the histogram with the given name.
+func (r *Registry) Observe(name string, labels Labels, value float64) {
+	r.record(name, KindHistogram, labels, func(s *series) {
+		if s.buckets == nil {
+			s.buckets = make([]uint64, len(DefaultBuckets))
+		}
+		for i, bound := range DefaultBuckets {
+			if value <= bound {
+				s.buckets[i]++
+				break
+			}
+		}
+		if s.count == 0 || value > s.max {
+			s.max = value
+		}
+		s.value += value
+		s.count++
+	})
+}
+
+func (r *Registry) record(name string, kind Kind, labels Labels, update func(s *series)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f, ok := r.families[name]
+	if !ok {
+		f = &family{name: name, help: Help(name), kind: kind, series: make(map[string]*series)}
+		r.families[name] = f
+	}
+
+	key := labels.key()
+	s, ok := f.series[key]
+	if !ok {
+		copied := make(Labels, len(labels))
+		for k, v := range labels {
+			copied[k] = v
+		}
+		s = &series{labels: copied}
+		f.series[key] = s
+	}
+	update(s)
+}
+
+// Family is a point in time copy of a metric family.
+type Family struct {
+	Name    string
+	Help    string
+	Kind    Kind
+	Samples []Sample
+}
+
+// Sample is a point in time copy of a single series. For histograms, Value is the sum of the observations.
+type Sample struct {
+	Labels  Labels
+	Value   float64
+	Buckets []Bucket // Only for histograms.
+	Count   uint64   // Only for histograms.
+	Max     float64  // Only for histograms, the largest observation.
+}
+
+// Bucket is a cumulative histogram bucket.
+type Bucket struct {
+	UpperBound float64
+	Count      uint64
+}
+
+// Snapshot copies the current state of the registry, sorted by family name and labels.
+func (r *Registry) Snapshot() []Family {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.snapshot()
+}
+
+// Drain copies the current state of the registry like Snapshot, and empties it, so that the next copy only has what
+// was recorded since.
+func (r *Registry) Drain() []Family {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	families := r.snapshot()
+	r.families = make(map[string]*family)
+	return families
+}
+
+func (r *Registry) snapshot() []Family {
+	families := make([]Family, 0, len(r.families))
+	for _, f := range r.families {
+		keys := make([]string, 0, len(f.series))
+		for key := range f.series {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		snapshot := Family{Name: f.name, Help: f.help, Kind: f.kind, Samples: make([]Sample, 0, len(keys))}
+		for _, key := range keys {
+			s := f.series[key]
+			sample := Sample{Labels: s.labels, Value: s.value, Count: s.count, Max: s.max}
+			if f.kind == KindHistogram {
+				var cumulative uint64
+				for i, bound := range DefaultBuckets {
+					cumulative += s.buckets[i]
+					sample.Buckets = append(sample.Buckets, Bucket{UpperBound: bound, Count: cumulative})
+				}
+			}
+			snapshot.Samples = append(snapshot.Samples, sample)
+		}
+		families = append(families, snapshot)
+	}
+
+	sort.Slice(families, func(i, j int) bool {
+		return families[i].Name < families[j].Name
+	})
+	return families
+}
+
+// Add increments a counter in the default registry.
+func Add(name string, labels Labels, value float64) {
+	defaultRegistry.Add(name, labels, value)
+}
+
+// Inc increments a counter in the default registry by one.
+func Inc(name string, labels Labels) {
+	defaultRegistry.Add(name, labels, 1)
+}
+
+// Set sets a gauge in the default registry.
+func Set(name string, labels Labels, value float64) {
+	defaultRegistry.Set(name, labels, value)
+}
+
+// Observe records a histogram observation in the default registry.
+func Observe(name string, labels Labels, value float64) {
+	defaultRegistry.Observe(name, labels, value)
+}
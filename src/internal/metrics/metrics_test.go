@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWritePrometheus(t *testing.T) {
+	r := NewRegistry()
+	r.Add(HTTPRequests, Labels{"route": "/v1/providers/{namespace}/{type}/versions", "status": "200"}, 1)
+	r.Add(HTTPRequests, Labels{"status": "200", "route": "/v1/providers/{namespace}/{type}/versions"}, 2)
+	r.Set(GithubRateLimitRemaining, Labels{"token": "default", "resource": "graphql"}, 4200)
+	r.Observe(HTTPRequestDuration, Labels{"route": "/metrics"}, 0.03)
+	r.Observe(HTTPRequestDuration, Labels{"route": "/metrics"}, 20)
+
+	var b strings.Builder
+	if err := r.WritePrometheus(&b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := `# HELP registry_github_rate_limit_remaining The GitHub API requests left in the current rate limit window, by token and resource.
+# TYPE registry_github_rate_limit_remaining gauge
+registry_github_rate_limit_remaining{resource="graphql",token="default"} 4200
+# HELP registry_http_request_duration_seconds Time taken to handle requests, by route.
+# TYPE registry_http_request_duration_seconds histogram
+registry_http_request_duration_seconds_bucket{route="/metrics",le="0.005"} 0
+registry_http_request_duration_seconds_bucket{route="/metrics",le="0.01"} 0
+registry_http_request_duration_seconds_bucket{route="/metrics",le="0.025"} 0
+registry_http_request_duration_seconds_bucket{route="/metrics",le="0.05"} 1
+registry_http_request_duration_seconds_bucket{route="/metrics",le="0.1"} 1
+registry_http_request_duration_seconds_bucket{route="/metrics",le="0.25"} 1
+registry_http_request_duration_seconds_bucket{route="/metrics",le="0.5"} 1
+registry_http_request_duration_seconds_bucket{route="/metrics",le="1"} 1
+registry_http_request_duration_seconds_bucket{route="/metrics",le="2.5"} 1
+registry_http_request_duration_seconds_bucket{route="/metrics",le="5"} 1
+registry_http_request_duration_seconds_bucket{route="/metrics",le="10"} 1
+registry_http_request_duration_seconds_bucket{route="/metrics",le="+Inf"} 2
+registry_http_request_duration_seconds_sum{route="/metrics"} 20.03
+registry_http_request_duration_seconds_count{route="/metrics"} 2
+# HELP registry_http_requests_total Number of requests handled, by route and status code.
+# TYPE registry_http_requests_total counter
+registry_http_requests_total{route="/v1/providers/{namespace}/{type}/versions",status="200"} 3
+`
+	if got := b.String(); got != want {
+		t.Errorf("WritePrometheus() =\n%s\nwant\n%s", got, want)
+	}
+}
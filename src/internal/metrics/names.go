@@ -0,0 +1,68 @@
+package metrics
+
+// The metrics recorded by the registry. Keeping them in one place makes sure the names and help texts are the same
+// wherever they are recorded and exported.
+const (
+	HTTPRequests             = "registry_http_requests_total"
+	HTTPRequestDuration      = "registry_http_request_duration_seconds"
+	CacheLookups             = "registry_cache_lookups_total"
+	GithubRateLimit          = "registry_github_rate_limit"
+	GithubRateLimitRemaining = "registry_github_rate_limit_remaining"
+	IngestionQuotaExceeded   = "registry_ingestion_quota_exceeded_total"
+	ChecksumMismatches       = "registry_checksum_mismatches_total"
+	CacheMigrations          = "registry_cache_migrations_total"
+	CacheDrift               = "registry_cache_drift_total"
+	ModuleCacheLookups       = "registry_module_cache_lookups_total"
+	GithubRetries            = "registry_github_retries_total"
+	RefreshQuarantines       = "registry_refresh_quarantines_total"
+	GithubGraphQLFallbacks   = "registry_github_graphql_fallbacks_total"
+	GithubRequestDuration    = "registry_github_request_duration_seconds"
+	CacheWriteConflicts      = "registry_cache_write_conflicts_total"
+	AssetMirrorFailures      = "registry_asset_mirror_failures_total"
+	AssetMirrorRepairs       = "registry_asset_mirror_repairs_total"
+	ModuleArchiveFailures    = "registry_module_archive_failures_total"
+)
+
+// Help returns the description of a metric.
+func Help(name string) string {
+	switch name {
+	case HTTPRequests:
+		return "Number of requests handled, by route and status code."
+	case HTTPRequestDuration:
+		return "Time taken to handle requests, by route."
+	case CacheLookups:
+		return "Number of provider cache lookups, by result."
+	case GithubRateLimit:
+		return "The GitHub API rate limit, by token and resource."
+	case GithubRateLimitRemaining:
+		return "The GitHub API requests left in the current rate limit window, by token and resource."
+	case IngestionQuotaExceeded:
+		return "Number of provider refreshes cut short by an ingestion quota, by namespace and budget."
+	case ChecksumMismatches:
+		return "Number of provider versions quarantined because their published checksums changed, by namespace and type."
+	case CacheMigrations:
+		return "Number of provider cache items upgraded from an older schema version, by schema version."
+	case CacheDrift:
+		return "Number of differences between the provider cache and GitHub found by the drift checks, by namespace, type and kind."
+	case ModuleCacheLookups:
+		return "Number of module cache lookups, by result."
+	case GithubRetries:
+		return "Number of GitHub calls retried after a transient failure, by reason."
+	case RefreshQuarantines:
+		return "Number of providers whose refreshes were quarantined after failing too many times in a row, by namespace and type."
+	case GithubGraphQLFallbacks:
+		return "Number of release listings made with the GitHub REST API because the GraphQL API was failing, by reason."
+	case GithubRequestDuration:
+		return "Time taken by the calls to the GitHub API, by token and resource."
+	case CacheWriteConflicts:
+		return "Number of provider cache writes started over because the item was stored concurrently, by operation."
+	case AssetMirrorFailures:
+		return "Number of provider versions whose release assets could not be mirrored, by namespace and type."
+	case AssetMirrorRepairs:
+		return "Number of asset mirror objects found missing or corrupted by the reconciliation, by namespace, type and problem."
+	case ModuleArchiveFailures:
+		return "Number of module versions whose stable archive could not be built, by owner of the repository."
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// PrometheusContentType is the content type of the Prometheus text exposition format.
+const PrometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// WritePrometheus writes the registry in the Prometheus text exposition format.
+func (r *Registry) WritePrometheus(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, f := range r.Snapshot() {
+		if f.Help != "" {
+			fmt.Fprintf(bw, "# HELP %s %s\n", f.Name, escapeHelp(f.Help))
+		}
+		fmt.Fprintf(bw, "# TYPE %s %s\n", f.Name, f.Kind)
+
+		for _, s := range f.Samples {
+			if f.Kind != KindHistogram {
+				fmt.Fprintf(bw, "%s%s %s\n", f.Name, formatLabels(s.Labels, "", ""), formatValue(s.Value))
+				continue
+			}
+
+			for _, b := range s.Buckets {
+				fmt.Fprintf(bw, "%s_bucket%s %d\n", f.Name, formatLabels(s.Labels, "le", formatValue(b.UpperBound)), b.Count)
+			}
+			fmt.Fprintf(bw, "%s_bucket%s %d\n", f.Name, formatLabels(s.Labels, "le", "+Inf"), s.Count)
+			fmt.Fprintf(bw, "%s_sum%s %s\n", f.Name, formatLabels(s.Labels, "", ""), formatValue(s.Value))
+			fmt.Fprintf(bw, "%s_count%s %d\n", f.Name, formatLabels(s.Labels, "", ""), s.Count)
+		}
+	}
+	return bw.Flush()
+}
+
+// formatLabels renders the labels of a sample, with an optional extra label such as the `le` of histogram buckets.
+func formatLabels(labels Labels, extraName, extraValue string) string {
+	if len(labels) == 0 && extraName == "" {
+		return ""
+	}
+
+	pairs := make([]string, 0, len(labels)+1)
+	for _, name := range labels.names() {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", name, labels[name]))
+	}
+	if extraName != "" {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", extraName, extraValue))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
+
+func escapeHelp(help string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+}
@@ -0,0 +1,139 @@
+package mirror
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1" //nolint:gosec // CloudFront only accepts SHA-1 signatures of its policies.
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/opentofu/registry/internal/providers/types"
+)
+
+// Signer points the URLs of a provider version at the mirror bucket, with URLs clients can download the files from
+// for a limited time. Presigner signs S3 URLs, CloudFrontSigner the URLs of a CloudFront distribution in front of the
+// bucket.
+type Signer interface {
+	PresignVersion(ctx context.Context, namespace, providerType, version string, details *types.VersionDetails) error
+	// PresignObject returns the URL clients download the object at an URL returned by ObjectURL from.
+	PresignObject(ctx context.Context, objectURL string) (string, error)
+}
+
+var (
+	_ Signer = (*Presigner)(nil)
+	_ Signer = (*CloudFrontSigner)(nil)
+)
+
+// cloudFrontEncoding is the base64 alphabet of CloudFront signatures, with the characters which are not safe in a query
+// string replaced.
+//
+//nolint:gochecknoglobals // This should be treated as a constant.
+var cloudFrontEncoding = strings.NewReplacer("+", "-", "=", "_", "/", "~")
+
+// CloudFrontSigner generates signed URLs of a CloudFront distribution serving the mirror bucket, with a canned policy,
+// so that the files are served from the edge locations of CloudFront rather than from the region of the bucket, and
+// from a domain corporate proxies can allow.
+type CloudFrontSigner struct {
+	URL       string
+	KeyPairID string
+	TTL       time.Duration
+
+	key *rsa.PrivateKey
+	now func() time.Time
+}
+
+// NewCloudFrontSigner returns a signer of the URLs under baseURL with the PEM encoded RSA private key of the public key
+// registered in CloudFront under keyPairID.
+func NewCloudFrontSigner(baseURL, keyPairID string, privateKeyPEM []byte, ttl time.Duration) (*CloudFrontSigner, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("invalid CloudFront URL %q, it must be https", baseURL)
+	}
+	if keyPairID == "" {
+		return nil, fmt.Errorf("a CloudFront key pair ID is required")
+	}
+	key, err := parsePrivateKey(privateKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+	return &CloudFrontSigner{
+		URL:       strings.TrimSuffix(baseURL, "/"),
+		KeyPairID: keyPairID,
+		TTL:       ttl,
+		key:       key,
+		now:       time.Now,
+	}, nil
+}
+
+func parsePrivateKey(privateKeyPEM []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(privateKeyPEM)
+	if block == nil {
+		return nil, fmt.Errorf("failed to parse CloudFront private key: no PEM block found")
+	}
+
+	// `openssl genrsa` generates PKCS #1 keys with OpenSSL 1 and PKCS #8 keys with OpenSSL 3
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CloudFront private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("failed to parse CloudFront private key: not an RSA key")
+	}
+	return key, nil
+}
+
+// Sign returns the signed URL of the object with the given key, valid for the TTL of the signer.
+func (s *CloudFrontSigner) Sign(key string) (string, error) {
+	resource := s.URL + "/" + key
+	expires := s.now().Add(s.TTL).Unix()
+	policy := fmt.Sprintf(`{"Statement":[{"Resource":"%s","Condition":{"DateLessThan":{"AWS:EpochTime":%d}}}]}`, resource, expires)
+
+	hash := sha1.Sum([]byte(policy)) //nolint:gosec // CloudFront only accepts SHA-1 signatures of its policies.
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA1, hash[:])
+	if err != nil {
+		return "", fmt.Errorf("could not sign URL for %s: %w", key, err)
+	}
+
+	query := url.Values{}
+	query.Set("Expires", strconv.FormatInt(expires, 10))
+	query.Set("Signature", cloudFrontEncoding.Replace(base64.StdEncoding.EncodeToString(signature)))
+	query.Set("Key-Pair-Id", s.KeyPairID)
+	return resource + "?" + query.Encode(), nil
+}
+
+// PresignVersion points the download, checksums and signature URLs of the version at the distribution, like
+// Presigner.PresignVersion.
+func (s *CloudFrontSigner) PresignVersion(_ context.Context, namespace, providerType, version string, details *types.VersionDetails) error {
+	keys, err := objectKeys(namespace, providerType, version, details)
+	if err != nil {
+		return err
+	}
+	urls := []*string{&details.DownloadURL, &details.SHASumsURL, &details.SHASumsSignatureURL}
+	for i, u := range urls {
+		if *u, err = s.Sign(keys[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PresignObject returns the signed URL of the object at objectURL.
+func (s *CloudFrontSigner) PresignObject(_ context.Context, objectURL string) (string, error) {
+	key, _, err := parseObjectURL(objectURL)
+	if err != nil {
+		return "", err
+	}
+	return s.Sign(key)
+}
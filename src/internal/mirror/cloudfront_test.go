@@ -0,0 +1,94 @@
+package mirror
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1" //nolint:gosec // CloudFront only accepts SHA-1 signatures of its policies.
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/opentofu/registry/internal/providers/types"
+)
+
+func testCloudFrontSigner(t *testing.T) (*CloudFrontSigner, *rsa.PrivateKey) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("could not generate key: %v", err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	s, err := NewCloudFrontSigner("https://downloads.example.com/", "K2JCJMDEHXQW5F", keyPEM, 10*time.Minute)
+	if err != nil {
+		t.Fatalf("NewCloudFrontSigner() error = %v", err)
+	}
+	s.now = func() time.Time { return time.Date(2023, 9, 1, 12, 0, 0, 0, time.UTC) }
+	return s, key
+}
+
+func TestCloudFrontSign(t *testing.T) {
+	s, key := testCloudFrontSigner(t)
+	signed, err := s.Sign("providers/example/foo/1.0.0/terraform-provider-foo_1.0.0_linux_amd64.zip")
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+
+	resource, rawQuery, ok := strings.Cut(signed, "?")
+	if !ok || resource != "https://downloads.example.com/providers/example/foo/1.0.0/terraform-provider-foo_1.0.0_linux_amd64.zip" {
+		t.Fatalf("Sign() = %q, want a signed URL of the object", signed)
+	}
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		t.Fatalf("Sign() returned an invalid query %q: %v", rawQuery, err)
+	}
+	expires := time.Date(2023, 9, 1, 12, 10, 0, 0, time.UTC).Unix()
+	if query.Get("Expires") != fmt.Sprint(expires) || query.Get("Key-Pair-Id") != "K2JCJMDEHXQW5F" {
+		t.Errorf("query = %v", query)
+	}
+
+	encoded := strings.NewReplacer("-", "+", "_", "=", "~", "/").Replace(query.Get("Signature"))
+	signature, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		t.Fatalf("could not decode signature: %v", err)
+	}
+	policy := fmt.Sprintf(`{"Statement":[{"Resource":"%s","Condition":{"DateLessThan":{"AWS:EpochTime":%d}}}]}`, resource, expires)
+	hash := sha1.Sum([]byte(policy)) //nolint:gosec // CloudFront only accepts SHA-1 signatures of its policies.
+	if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA1, hash[:], signature); err != nil {
+		t.Errorf("signature does not match the canned policy: %v", err)
+	}
+}
+
+func TestCloudFrontPresignVersion(t *testing.T) {
+	s, _ := testCloudFrontSigner(t)
+	details := &types.VersionDetails{
+		DownloadURL:         "https://github.com/example/terraform-provider-foo/releases/download/v1.0.0/terraform-provider-foo_1.0.0_linux_amd64.zip",
+		SHASumsURL:          "https://github.com/example/terraform-provider-foo/releases/download/v1.0.0/terraform-provider-foo_1.0.0_SHA256SUMS",
+		SHASumsSignatureURL: "https://github.com/example/terraform-provider-foo/releases/download/v1.0.0/terraform-provider-foo_1.0.0_SHA256SUMS.sig",
+	}
+	if err := s.PresignVersion(context.Background(), "example", "foo", "1.0.0", details); err != nil {
+		t.Fatalf("PresignVersion() error = %v", err)
+	}
+
+	prefix := "https://downloads.example.com/providers/example/foo/1.0.0/terraform-provider-foo_1.0.0_"
+	for _, u := range []string{details.DownloadURL, details.SHASumsURL, details.SHASumsSignatureURL} {
+		if !strings.HasPrefix(u, prefix) || !strings.Contains(u, "Signature=") {
+			t.Errorf("URL %q is not a signed URL of the distribution", u)
+		}
+	}
+}
+
+func TestNewCloudFrontSignerRejectsInvalidSettings(t *testing.T) {
+	if _, err := NewCloudFrontSigner("http://downloads.example.com", "K2JCJMDEHXQW5F", nil, time.Minute); err == nil {
+		t.Errorf("expected an error for a URL which is not https")
+	}
+	if _, err := NewCloudFrontSigner("https://downloads.example.com", "K2JCJMDEHXQW5F", []byte("not a key"), time.Minute); err == nil {
+		t.Errorf("expected an error for a key which is not PEM encoded")
+	}
+}
@@ -0,0 +1,112 @@
+package mirror
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/opentofu/registry/internal/providers/types"
+)
+
+// Names and default priorities of the download locations. Lower priorities are preferred: the S3 mirror is the most
+// reliable source, regional mirrors are closer to some clients, and GitHub is the origin every other location copies.
+const (
+	LocationMirror = "s3"
+	LocationOrigin = "github"
+
+	PriorityMirror   = 10
+	PriorityRegional = 50
+	PriorityOrigin   = 100
+)
+
+// Location is a place the files of a provider version can be downloaded from, listed in the download responses so
+// that clients and mirroring tools can pick the closest or most reliable one.
+type Location struct {
+	Name                string `json:"name"`
+	Priority            int    `json:"priority"`
+	DownloadURL         string `json:"download_url"`
+	SHASumsURL          string `json:"shasums_url"`
+	SHASumsSignatureURL string `json:"shasums_signature_url"`
+}
+
+// NewLocation returns the location serving the files at the URLs of the version.
+func NewLocation(name string, priority int, details *types.VersionDetails) Location {
+	return Location{
+		Name:                name,
+		Priority:            priority,
+		DownloadURL:         details.DownloadURL,
+		SHASumsURL:          details.SHASumsURL,
+		SHASumsSignatureURL: details.SHASumsSignatureURL,
+	}
+}
+
+// SortLocations orders the locations from the most preferred to the least, keeping the order of equal priorities.
+func SortLocations(locations []Location) {
+	sort.SliceStable(locations, func(i, j int) bool {
+		return locations[i].Priority < locations[j].Priority
+	})
+}
+
+// Regional is a public mirror of the release assets, with the same layout as the S3 mirror, see Key.
+type Regional struct {
+	Name     string `json:"name"`
+	URL      string `json:"url"`
+	Priority int    `json:"priority,omitempty"`
+}
+
+// ParseRegional parses the REGIONAL_MIRRORS value, a JSON list of regional mirrors. Mirrors without a priority, or
+// with 0, get PriorityRegional.
+func ParseRegional(value string) ([]Regional, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	var mirrors []Regional
+	if err := json.Unmarshal([]byte(value), &mirrors); err != nil {
+		return nil, fmt.Errorf("could not parse REGIONAL_MIRRORS: %w", err)
+	}
+
+	names := make(map[string]bool, len(mirrors))
+	for i := range mirrors {
+		m := &mirrors[i]
+		if m.Name == "" || m.Name == LocationMirror || m.Name == LocationOrigin || names[m.Name] {
+			return nil, fmt.Errorf("regional mirror %d needs a unique name other than %s and %s", i, LocationMirror, LocationOrigin)
+		}
+		names[m.Name] = true
+
+		u, err := url.Parse(m.URL)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return nil, fmt.Errorf("regional mirror %s has an invalid URL %q, it must be https", m.Name, m.URL)
+		}
+		m.URL = strings.TrimSuffix(m.URL, "/")
+
+		if m.Priority == 0 {
+			m.Priority = PriorityRegional
+		}
+		if m.Priority < 0 {
+			return nil, fmt.Errorf("regional mirror %s has a negative priority", m.Name)
+		}
+	}
+	return mirrors, nil
+}
+
+// Location returns where the regional mirror serves the files of the version. The files keep the names they have in
+// the release.
+func (r Regional) Location(namespace, providerType, version string, details *types.VersionDetails) (Location, error) {
+	location := Location{Name: r.Name, Priority: r.Priority}
+	urls := map[*string]string{
+		&location.DownloadURL:         details.DownloadURL,
+		&location.SHASumsURL:          details.SHASumsURL,
+		&location.SHASumsSignatureURL: details.SHASumsSignatureURL,
+	}
+	for target, source := range urls {
+		filename, err := filenameOf(source)
+		if err != nil {
+			return Location{}, err
+		}
+		*target = r.URL + "/" + Key(namespace, providerType, version, filename)
+	}
+	return location, nil
+}
@@ -0,0 +1,61 @@
+package mirror
+
+import (
+	"testing"
+
+	"github.com/opentofu/registry/internal/providers/types"
+)
+
+func TestParseRegional(t *testing.T) {
+	mirrors, err := ParseRegional(`[{"name": "eu", "url": "https://eu.mirror.example.com/"}, {"name": "us", "url": "https://us.mirror.example.com", "priority": 5}]`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(mirrors) != 2 || mirrors[0].URL != "https://eu.mirror.example.com" || mirrors[0].Priority != PriorityRegional || mirrors[1].Priority != 5 {
+		t.Errorf("ParseRegional() = %+v", mirrors)
+	}
+
+	invalid := map[string]string{
+		"not json":          `{`,
+		"reserved name":     `[{"name": "github", "url": "https://example.com"}]`,
+		"duplicate name":    `[{"name": "eu", "url": "https://a.example.com"}, {"name": "eu", "url": "https://b.example.com"}]`,
+		"plain http":        `[{"name": "eu", "url": "http://eu.mirror.example.com"}]`,
+		"negative priority": `[{"name": "eu", "url": "https://eu.mirror.example.com", "priority": -1}]`,
+	}
+	for name, value := range invalid {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseRegional(value); err == nil {
+				t.Errorf("ParseRegional(%s) succeeded, want an error", value)
+			}
+		})
+	}
+}
+
+func TestRegionalLocation(t *testing.T) {
+	details := &types.VersionDetails{
+		DownloadURL:         "https://github.com/example/terraform-provider-foo/releases/download/v1.0.0/terraform-provider-foo_1.0.0_linux_amd64.zip",
+		SHASumsURL:          "https://github.com/example/terraform-provider-foo/releases/download/v1.0.0/terraform-provider-foo_1.0.0_SHA256SUMS",
+		SHASumsSignatureURL: "https://github.com/example/terraform-provider-foo/releases/download/v1.0.0/terraform-provider-foo_1.0.0_SHA256SUMS.sig",
+	}
+	location, err := Regional{Name: "eu", URL: "https://eu.mirror.example.com", Priority: PriorityRegional}.Location("example", "foo", "1.0.0", details)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	prefix := "https://eu.mirror.example.com/providers/example/foo/1.0.0/"
+	if location.DownloadURL != prefix+"terraform-provider-foo_1.0.0_linux_amd64.zip" ||
+		location.SHASumsURL != prefix+"terraform-provider-foo_1.0.0_SHA256SUMS" ||
+		location.SHASumsSignatureURL != prefix+"terraform-provider-foo_1.0.0_SHA256SUMS.sig" {
+		t.Errorf("Location() = %+v", location)
+	}
+
+	locations := []Location{
+		NewLocation(LocationOrigin, PriorityOrigin, details),
+		location,
+		NewLocation(LocationMirror, PriorityMirror, details),
+	}
+	SortLocations(locations)
+	if locations[0].Name != LocationMirror || locations[1].Name != "eu" || locations[2].Name != LocationOrigin {
+		t.Errorf("SortLocations() = %+v", locations)
+	}
+}
@@ -0,0 +1,77 @@
+package mirror
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/opentofu/registry/internal/providers/types"
+)
+
+// objectsPrefix is the prefix of the objects the registry copies into the bucket, named after the SHA256 checksum of
+// their contents, so that the files shared by versions or re-uploaded under another name are stored once.
+const objectsPrefix = "objects/sha256"
+
+// ObjectKey returns the key of the object holding the file with the given hex encoded SHA256 checksum.
+func ObjectKey(checksum string) string {
+	return path.Join(objectsPrefix, strings.ToLower(checksum))
+}
+
+// ObjectURL returns the `s3://` URL recorded in the cache for the object under key in bucket.
+func ObjectURL(bucket, key string) string {
+	return (&url.URL{Scheme: "s3", Host: bucket, Path: "/" + key}).String()
+}
+
+// parseObjectURL returns the key and checksum of the object at an URL returned by ObjectURL.
+func parseObjectURL(rawURL string) (key, checksum string, err error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "s3" {
+		return "", "", fmt.Errorf("invalid mirror object URL %q", rawURL)
+	}
+	key = strings.TrimPrefix(u.Path, "/")
+	dir, checksum := path.Split(key)
+	if path.Clean(dir) != objectsPrefix || !validChecksum(checksum) {
+		return "", "", fmt.Errorf("mirror object URL %q is not content-addressed", rawURL)
+	}
+	return key, checksum, nil
+}
+
+// objectKeys returns the keys of the objects the download, checksums and signature URLs of the version are served
+// from: the objects recorded when the registry mirrored the version, or the files named as in the release under Key
+// for the versions mirrored by other means.
+func objectKeys(namespace, providerType, version string, details *types.VersionDetails) ([3]string, error) {
+	var keys [3]string
+	if details.Mirror != nil {
+		for i, u := range []string{details.Mirror.DownloadURL, details.Mirror.SHASumsURL, details.Mirror.SHASumsSignatureURL} {
+			key, _, err := parseObjectURL(u)
+			if err != nil {
+				return keys, err
+			}
+			keys[i] = key
+		}
+		return keys, nil
+	}
+
+	for i, u := range []string{details.DownloadURL, details.SHASumsURL, details.SHASumsSignatureURL} {
+		filename, err := filenameOf(u)
+		if err != nil {
+			return keys, err
+		}
+		keys[i] = Key(namespace, providerType, version, filename)
+	}
+	return keys, nil
+}
+
+// validChecksum reports whether the value is a lower case hex encoded SHA256 checksum.
+func validChecksum(value string) bool {
+	if len(value) != 64 { //nolint:gomnd // SHA256 checksums are 32 bytes.
+		return false
+	}
+	for _, c := range value {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
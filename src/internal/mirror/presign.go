@@ -0,0 +1,82 @@
+// Package mirror serves provider release assets from a private S3 bucket mirroring them, instead of from GitHub.
+// Clients get short-lived pre-signed URLs, so the bucket does not need to be public and downloads are still a single
+// GET without redirects. The registry copies the assets of the versions it caches into the bucket itself, verified
+// against the SHA256SUMS of their release, see Uploader.
+package mirror
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/opentofu/registry/internal/bucket"
+	"github.com/opentofu/registry/internal/providers/types"
+)
+
+// DefaultURLTTL is how long pre-signed URLs are valid for when not configured.
+const DefaultURLTTL = 15 * time.Minute
+
+// Presigner generates pre-signed GET URLs for the objects of the mirror bucket.
+type Presigner struct {
+	Bucket string
+	TTL    time.Duration
+
+	client *bucket.Client
+	now    func() time.Time
+}
+
+func NewPresigner(awsConfig aws.Config, name string, ttl time.Duration) *Presigner {
+	// the client only signs, it does not send requests
+	return &Presigner{Bucket: name, TTL: ttl, client: bucket.New(awsConfig, name, 0), now: time.Now}
+}
+
+// Key returns the object key of a release asset of a provider version. The mirror holds the files of each release,
+// named as in the release, under `providers/<namespace>/<type>/<version>/`.
+func Key(namespace, providerType, version, filename string) string {
+	return path.Join("providers", namespace, providerType, version, filename)
+}
+
+// Presign returns a pre-signed URL to GET the object with the given key, valid for the TTL of the presigner.
+func (p *Presigner) Presign(ctx context.Context, key string) (string, error) {
+	return p.client.Presign(ctx, key, p.TTL, p.now())
+}
+
+// PresignVersion points the download, checksums and signature URLs of the version at their objects in the mirror
+// bucket, see objectKeys.
+func (p *Presigner) PresignVersion(ctx context.Context, namespace, providerType, version string, details *types.VersionDetails) error {
+	keys, err := objectKeys(namespace, providerType, version, details)
+	if err != nil {
+		return err
+	}
+	urls := []*string{&details.DownloadURL, &details.SHASumsURL, &details.SHASumsSignatureURL}
+	for i, u := range urls {
+		if *u, err = p.Presign(ctx, keys[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PresignObject returns a pre-signed URL of the object at objectURL.
+func (p *Presigner) PresignObject(ctx context.Context, objectURL string) (string, error) {
+	key, _, err := parseObjectURL(objectURL)
+	if err != nil {
+		return "", err
+	}
+	return p.Presign(ctx, key)
+}
+
+func filenameOf(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("could not parse URL %q: %w", rawURL, err)
+	}
+	filename := path.Base(u.Path)
+	if filename == "." || filename == "/" {
+		return "", fmt.Errorf("no filename in URL %q", rawURL)
+	}
+	return filename, nil
+}
@@ -0,0 +1,96 @@
+package mirror
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/opentofu/registry/internal/awstest"
+	"github.com/opentofu/registry/internal/providers/types"
+)
+
+func testPresigner() *Presigner {
+	p := NewPresigner(awstest.Config(), "registry-mirror", 10*time.Minute)
+	p.now = func() time.Time { return time.Date(2023, 9, 1, 12, 0, 0, 0, time.UTC) }
+	return p
+}
+
+func TestPresign(t *testing.T) {
+	signed, err := testPresigner().Presign(context.Background(), "providers/example/foo/1.0.0/terraform-provider-foo_1.0.0_linux_amd64.zip")
+	if err != nil {
+		t.Fatalf("Presign() error = %v", err)
+	}
+
+	u, err := url.Parse(signed)
+	if err != nil {
+		t.Fatalf("Presign() returned an invalid URL %q: %v", signed, err)
+	}
+	if u.Host != "registry-mirror.s3.eu-west-1.amazonaws.com" {
+		t.Errorf("host = %q", u.Host)
+	}
+	if u.Path != "/providers/example/foo/1.0.0/terraform-provider-foo_1.0.0_linux_amd64.zip" {
+		t.Errorf("path = %q", u.Path)
+	}
+	query := u.Query()
+	if got := query.Get("X-Amz-Expires"); got != "600" {
+		t.Errorf("X-Amz-Expires = %q, want 600", got)
+	}
+	if got := query.Get("X-Amz-Credential"); got != "AKIDEXAMPLE/20230901/eu-west-1/s3/aws4_request" {
+		t.Errorf("X-Amz-Credential = %q", got)
+	}
+	if query.Get("X-Amz-Signature") == "" {
+		t.Errorf("URL %q is not signed", signed)
+	}
+}
+
+func TestPresignVersion(t *testing.T) {
+	details := &types.VersionDetails{
+		DownloadURL:         "https://github.com/example/terraform-provider-foo/releases/download/v1.0.0/terraform-provider-foo_1.0.0_linux_amd64.zip",
+		SHASumsURL:          "https://github.com/example/terraform-provider-foo/releases/download/v1.0.0/terraform-provider-foo_1.0.0_SHA256SUMS",
+		SHASumsSignatureURL: "https://github.com/example/terraform-provider-foo/releases/download/v1.0.0/terraform-provider-foo_1.0.0_SHA256SUMS.sig",
+	}
+	if err := testPresigner().PresignVersion(context.Background(), "example", "foo", "1.0.0", details); err != nil {
+		t.Fatalf("PresignVersion() error = %v", err)
+	}
+
+	prefix := "https://registry-mirror.s3.eu-west-1.amazonaws.com/providers/example/foo/1.0.0/terraform-provider-foo_1.0.0_"
+	for _, u := range []string{details.DownloadURL, details.SHASumsURL, details.SHASumsSignatureURL} {
+		if !strings.HasPrefix(u, prefix) {
+			t.Errorf("URL %q does not point at the mirror", u)
+		}
+	}
+	if !strings.Contains(details.SHASumsSignatureURL, "_SHA256SUMS.sig?") {
+		t.Errorf("signature URL %q does not keep the filename", details.SHASumsSignatureURL)
+	}
+}
+
+func TestPresignMirroredVersion(t *testing.T) {
+	checksum := strings.Repeat("ab", 32)
+	details := &types.VersionDetails{
+		DownloadURL:         "https://github.com/example/terraform-provider-foo/releases/download/v1.0.0/terraform-provider-foo_1.0.0_linux_amd64.zip",
+		SHASumsURL:          "https://github.com/example/terraform-provider-foo/releases/download/v1.0.0/terraform-provider-foo_1.0.0_SHA256SUMS",
+		SHASumsSignatureURL: "https://github.com/example/terraform-provider-foo/releases/download/v1.0.0/terraform-provider-foo_1.0.0_SHA256SUMS.sig",
+		Mirror: &types.MirrorObjects{
+			DownloadURL:         ObjectURL("registry-mirror", ObjectKey(checksum)),
+			SHASumsURL:          ObjectURL("registry-mirror", ObjectKey(checksum)),
+			SHASumsSignatureURL: ObjectURL("registry-mirror", ObjectKey(checksum)),
+		},
+	}
+	if err := testPresigner().PresignVersion(context.Background(), "example", "foo", "1.0.0", details); err != nil {
+		t.Fatalf("PresignVersion() error = %v", err)
+	}
+
+	prefix := "https://registry-mirror.s3.eu-west-1.amazonaws.com/objects/sha256/" + checksum + "?"
+	for _, u := range []string{details.DownloadURL, details.SHASumsURL, details.SHASumsSignatureURL} {
+		if !strings.HasPrefix(u, prefix) {
+			t.Errorf("URL %q does not point at the mirrored object", u)
+		}
+	}
+
+	details.Mirror.DownloadURL = "s3://registry-mirror/providers/example/foo/1.0.0/terraform-provider-foo_1.0.0_linux_amd64.zip"
+	if err := testPresigner().PresignVersion(context.Background(), "example", "foo", "1.0.0", details); err == nil {
+		t.Errorf("expected an error for an object which is not content-addressed")
+	}
+}
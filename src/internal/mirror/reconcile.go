@@ -0,0 +1,141 @@
+package mirror
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/opentofu/registry/internal/providers/types"
+	"golang.org/x/exp/slog"
+)
+
+// The problems of mirrored objects Reconcile repairs, the `problem` label of metrics.AssetMirrorRepairs.
+const (
+	// ProblemMissing is an object recorded in the cache which is not in the bucket.
+	ProblemMissing = "missing"
+	// ProblemCorrupted is an object whose contents do not have the checksum it is named after.
+	ProblemCorrupted = "corrupted"
+)
+
+// Repair is an object Reconcile found missing or corrupted, and copied again from the release.
+type Repair struct {
+	Key     string
+	Problem string
+	// Err is why the object could not be copied again, nil if it was.
+	Err error
+}
+
+// Reconcile checks the objects recorded in the download details of the version, and copies again from the release
+// the ones which are missing from the bucket or whose contents do not have the checksum they are named after. The
+// copies are verified like in MirrorVersion, so a release whose assets were replaced since the version was mirrored
+// is not repaired with them. The platforms which were not mirrored by the registry are skipped.
+func (u *Uploader) Reconcile(ctx context.Context, version types.CacheVersion) ([]Repair, error) {
+	var repairs []Repair
+	checked := make(map[string]bool)
+	for _, d := range version.DownloadDetails {
+		if d.Mirror == nil {
+			continue
+		}
+		objects := map[string]string{
+			d.Mirror.DownloadURL:         d.DownloadURL,
+			d.Mirror.SHASumsURL:          d.SHASumsURL,
+			d.Mirror.SHASumsSignatureURL: d.SHASumsSignatureURL,
+		}
+		for objectURL, source := range objects {
+			key, checksum, err := parseObjectURL(objectURL)
+			if err != nil {
+				return repairs, err
+			}
+			if checked[key] {
+				continue
+			}
+			checked[key] = true
+
+			problem, err := u.check(ctx, key, checksum)
+			if err != nil {
+				return repairs, err
+			}
+			if problem == "" {
+				continue
+			}
+
+			slog.Warn("Mirrored object needs repairing, copying it again", "object", key, "problem", problem, "source", source)
+			repair := Repair{Key: key, Problem: problem}
+			repair.Err = u.copy(ctx, source, checksum, true)
+			repairs = append(repairs, repair)
+		}
+	}
+	return repairs, nil
+}
+
+// check returns the problem of the object under key, empty if it is in the bucket with the checksum. The checksum S3
+// recorded for the object on upload is compared when there is one, the objects uploaded without one are downloaded
+// and hashed.
+func (u *Uploader) check(ctx context.Context, key, checksum string) (string, error) {
+	header := http.Header{}
+	header.Set("X-Amz-Checksum-Mode", "ENABLED")
+	resp, err := u.client.Stream(ctx, http.MethodHead, key, header, nil, 0)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return ProblemMissing, nil
+	default:
+		return "", fmt.Errorf("unexpected status %d checking object %s", resp.StatusCode, key)
+	}
+
+	if recorded := resp.Header.Get("X-Amz-Checksum-Sha256"); recorded != "" {
+		sum, err := base64.StdEncoding.DecodeString(recorded)
+		if err != nil || hex.EncodeToString(sum) != checksum {
+			return ProblemCorrupted, nil
+		}
+		return "", nil
+	}
+
+	matches, err := u.hashObject(ctx, key, checksum)
+	if err != nil {
+		return "", err
+	}
+	if !matches {
+		return ProblemCorrupted, nil
+	}
+	return "", nil
+}
+
+// hashObject downloads the object under key and reports whether its contents have the checksum.
+func (u *Uploader) hashObject(ctx context.Context, key, checksum string) (bool, error) {
+	resp, err := u.client.Stream(ctx, http.MethodGet, key, nil, nil, 0)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("unexpected status %d getting object %s", resp.StatusCode, key)
+	}
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, resp.Body); err != nil {
+		return false, fmt.Errorf("could not read object %s: %w", key, err)
+	}
+	return matches(hasher, checksum), nil
+}
+
+// Failed returns the repairs which failed, joined in a single error, nil if they all succeeded.
+func Failed(repairs []Repair) error {
+	var errs []error
+	for _, r := range repairs {
+		if r.Err != nil {
+			errs = append(errs, fmt.Errorf("could not repair %s: %w", r.Key, r.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
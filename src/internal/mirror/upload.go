@@ -0,0 +1,257 @@
+package mirror
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/opentofu/registry/internal/bucket"
+	"github.com/opentofu/registry/internal/providers"
+	"github.com/opentofu/registry/internal/providers/types"
+	"golang.org/x/exp/slog"
+)
+
+// uploadTimeout bounds the copy of a single release asset, from the start of its download to the end of its upload.
+const uploadTimeout = 5 * time.Minute
+
+// maxSignedFileSize caps the size of the checksums and signature files, which are read in full to be verified.
+const maxSignedFileSize = 1 << 20
+
+// ErrChecksumMismatch is returned when a file does not match the checksum it is expected to have.
+var ErrChecksumMismatch = errors.New("the file does not match its SHA256 checksum")
+
+// Uploader copies the release assets of provider versions into the mirror bucket, so that the download URLs of the
+// versions can be pointed at it once they are cached. The assets are streamed from GitHub to S3 without being held in
+// memory.
+type Uploader struct {
+	Bucket string
+
+	client *bucket.Client
+}
+
+func NewUploader(awsConfig aws.Config, name string) *Uploader {
+	return &Uploader{Bucket: name, client: bucket.New(awsConfig, name, uploadTimeout)}
+}
+
+// MirrorVersion copies the archives of the version and its checksums and signature files into the bucket, under the
+// keys of their checksums, and records the URLs of the objects in the download details of the version. Each archive
+// is verified against the SHA256SUMS of the release and the checksum cached for it before it is stored, and S3 is
+// given the checksum of every object to reject uploads not matching it. The objects already in the bucket are not
+// copied again, their key is their checksum.
+func (u *Uploader) MirrorVersion(ctx context.Context, version *types.CacheVersion) error {
+	// the checksums and signature files are shared by the platforms of the version
+	checksums := make(map[string]map[string]string)
+	objects := make(map[string]string)
+
+	for i := range version.DownloadDetails {
+		d := &version.DownloadDetails[i]
+
+		sums, ok := checksums[d.SHASumsURL]
+		if !ok {
+			data, err := u.mirrorSignedFile(ctx, d.SHASumsURL, objects)
+			if err != nil {
+				return err
+			}
+			if sums, err = providers.ParseShaSums(bytes.NewReader(data)); err != nil {
+				return fmt.Errorf("could not parse %s: %w", d.SHASumsURL, err)
+			}
+			checksums[d.SHASumsURL] = sums
+		}
+		if _, ok := objects[d.SHASumsSignatureURL]; !ok {
+			if _, err := u.mirrorSignedFile(ctx, d.SHASumsSignatureURL, objects); err != nil {
+				return err
+			}
+		}
+
+		checksum, ok := sums[d.Filename]
+		if !ok {
+			return fmt.Errorf("%s is not listed in %s", d.Filename, d.SHASumsURL)
+		}
+		if d.SHASum != "" && !strings.EqualFold(d.SHASum, checksum) {
+			return fmt.Errorf("the cached checksum of %s is not the one listed in %s: %w", d.Filename, d.SHASumsURL, ErrChecksumMismatch)
+		}
+		if err := u.copy(ctx, d.DownloadURL, checksum, false); err != nil {
+			return err
+		}
+
+		d.Mirror = &types.MirrorObjects{
+			DownloadURL:         ObjectURL(u.Bucket, ObjectKey(checksum)),
+			SHASumsURL:          objects[d.SHASumsURL],
+			SHASumsSignatureURL: objects[d.SHASumsSignatureURL],
+		}
+	}
+	return nil
+}
+
+// mirrorSignedFile downloads the checksums or signature file at source, stores it in the bucket unless it is there
+// already, and records the URL of its object in objects. The file is returned, it is small enough to be held in
+// memory.
+func (u *Uploader) mirrorSignedFile(ctx context.Context, source string, objects map[string]string) ([]byte, error) {
+	body, _, err := u.download(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	// read one byte more than allowed to tell a file of the maximum size from a bigger one
+	data, err := io.ReadAll(io.LimitReader(body, maxSignedFileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("could not download %s: %w", source, err)
+	}
+	if len(data) > maxSignedFileSize {
+		return nil, fmt.Errorf("%s is larger than %d bytes", source, maxSignedFileSize)
+	}
+
+	sum := sha256.Sum256(data)
+	objectURL, err := u.StoreObject(ctx, bytes.NewReader(data), hex.EncodeToString(sum[:]), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	objects[source] = objectURL
+	return data, nil
+}
+
+// StoreObject stores the contents of body, of the given length and hex encoded SHA256 checksum, in the bucket under
+// the key of the checksum, unless there is an object under the key already, and returns the URL of the object.
+func (u *Uploader) StoreObject(ctx context.Context, body io.Reader, checksum string, length int64) (string, error) {
+	key := ObjectKey(checksum)
+	exists, err := u.exists(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		if err := u.put(ctx, key, checksum, body, length); err != nil {
+			return "", err
+		}
+		slog.Info("Stored object", "object", key, "size", length)
+	}
+	return ObjectURL(u.Bucket, key), nil
+}
+
+// copy streams the file at source into the bucket under the key of its checksum, unless there is an object under
+// the key already and overwrite is false. The copy fails with ErrChecksumMismatch if the file does not have the
+// checksum.
+func (u *Uploader) copy(ctx context.Context, source, checksum string, overwrite bool) error {
+	key := ObjectKey(checksum)
+	if !overwrite {
+		exists, err := u.exists(ctx, key)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return nil
+		}
+	}
+
+	body, length, err := u.download(ctx, source)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	// S3 needs the length of streamed uploads up front, so the rare downloads without one are read in full first
+	var reader io.Reader = body
+	if length < 0 {
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return fmt.Errorf("could not download %s: %w", source, err)
+		}
+		reader, length = bytes.NewReader(data), int64(len(data))
+	}
+
+	// S3 rejects the upload if the file does not have the checksum, hashing it as well tells that apart from other
+	// failures
+	hashed := &hashingReader{r: reader, hash: sha256.New()}
+	err = u.put(ctx, key, checksum, hashed, length)
+	if hashed.n == length && !matches(hashed.hash, checksum) {
+		return fmt.Errorf("could not mirror %s: %w", source, ErrChecksumMismatch)
+	}
+	if err != nil {
+		return err
+	}
+	slog.Info("Mirrored release asset", "source", source, "object", key, "size", length)
+	return nil
+}
+
+// download starts the download of the file at source, returning its body and length, -1 if it is not known.
+func (u *Uploader) download(ctx context.Context, source string) (io.ReadCloser, int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("could not create request for %s: %w", source, err)
+	}
+	resp, err := u.client.HTTPClient.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("could not download %s: %w", source, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, 0, fmt.Errorf("unexpected status %d downloading %s", resp.StatusCode, source)
+	}
+	return resp.Body, resp.ContentLength, nil
+}
+
+// put uploads the object under key with the contents of body, which S3 verifies against the checksum.
+func (u *Uploader) put(ctx context.Context, key, checksum string, body io.Reader, length int64) error {
+	sum, err := hex.DecodeString(checksum)
+	if err != nil {
+		return fmt.Errorf("invalid checksum %q: %w", checksum, err)
+	}
+	header := http.Header{}
+	header.Set("X-Amz-Checksum-Sha256", base64.StdEncoding.EncodeToString(sum))
+
+	resp, err := u.client.Stream(ctx, http.MethodPut, key, header, body, length)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d putting object %s", resp.StatusCode, key)
+	}
+	return nil
+}
+
+func (u *Uploader) exists(ctx context.Context, key string) (bool, error) {
+	resp, err := u.client.Stream(ctx, http.MethodHead, key, nil, nil, 0)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("unexpected status %d checking object %s", resp.StatusCode, key)
+	}
+}
+
+// hashingReader hashes and counts the data read through it.
+type hashingReader struct {
+	r    io.Reader
+	hash hash.Hash
+	n    int64
+}
+
+func (h *hashingReader) Read(p []byte) (int, error) {
+	n, err := h.r.Read(p)
+	h.hash.Write(p[:n])
+	h.n += int64(n)
+	return n, err
+}
+
+// matches reports whether the data written to hasher has the hex encoded checksum.
+func matches(hasher hash.Hash, checksum string) bool {
+	return strings.EqualFold(hex.EncodeToString(hasher.Sum(nil)), checksum)
+}
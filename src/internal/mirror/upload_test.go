@@ -0,0 +1,228 @@
+package mirror
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/opentofu/registry/internal/awstest"
+	"github.com/opentofu/registry/internal/providers/types"
+)
+
+// testBucket is an S3 bucket served over HTTP, keeping the objects put into it in memory.
+type testBucket struct {
+	mu        sync.Mutex
+	objects   map[string]string
+	checksums map[string]string
+	puts      int
+}
+
+func newTestBucket() *testBucket {
+	return &testBucket{objects: map[string]string{}, checksums: map[string]string{}}
+}
+
+func (b *testBucket) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	key := strings.TrimPrefix(r.URL.Path, "/")
+	object, ok := b.objects[key]
+	switch r.Method {
+	case http.MethodHead, http.MethodGet:
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if checksum := b.checksums[key]; checksum != "" && r.Header.Get("X-Amz-Checksum-Mode") == "ENABLED" {
+			w.Header().Set("X-Amz-Checksum-Sha256", checksum)
+		}
+		if r.Method == http.MethodGet {
+			_, _ = io.WriteString(w, object)
+		}
+	case http.MethodPut:
+		if r.Header.Get("Authorization") == "" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		sum := sha256.Sum256(body)
+		if r.Header.Get("X-Amz-Checksum-Sha256") != base64.StdEncoding.EncodeToString(sum[:]) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		b.objects[key] = string(body)
+		b.checksums[key] = r.Header.Get("X-Amz-Checksum-Sha256")
+		b.puts++
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// testRelease serves the files of a release, and the URLs of a version of two platforms built from it.
+type testRelease struct {
+	*httptest.Server
+	files map[string]string
+}
+
+func newTestRelease(t *testing.T) *testRelease {
+	r := &testRelease{files: map[string]string{
+		"terraform-provider-foo_1.0.0_linux_amd64.zip":  "linux archive",
+		"terraform-provider-foo_1.0.0_darwin_arm64.zip": "darwin archive",
+		"terraform-provider-foo_1.0.0_SHA256SUMS.sig":   "signature",
+	}}
+	r.files["terraform-provider-foo_1.0.0_SHA256SUMS"] = checksum("linux archive") + "  terraform-provider-foo_1.0.0_linux_amd64.zip\n" +
+		checksum("darwin archive") + "  terraform-provider-foo_1.0.0_darwin_arm64.zip\n"
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		file, ok := r.files[strings.TrimPrefix(req.URL.Path, "/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = io.WriteString(w, file)
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *testRelease) version() types.CacheVersion {
+	details := func(platform string) types.CacheVersionDownloadDetails {
+		filename := "terraform-provider-foo_1.0.0_" + platform + ".zip"
+		return types.CacheVersionDownloadDetails{
+			Filename:            filename,
+			DownloadURL:         r.URL + "/" + filename,
+			SHASumsURL:          r.URL + "/terraform-provider-foo_1.0.0_SHA256SUMS",
+			SHASumsSignatureURL: r.URL + "/terraform-provider-foo_1.0.0_SHA256SUMS.sig",
+			SHASum:              checksum(r.files[filename]),
+		}
+	}
+	return types.CacheVersion{
+		Version:         "1.0.0",
+		DownloadDetails: []types.CacheVersionDownloadDetails{details("linux_amd64"), details("darwin_arm64")},
+	}
+}
+
+func checksum(contents string) string {
+	sum := sha256.Sum256([]byte(contents))
+	return hex.EncodeToString(sum[:])
+}
+
+func testUploader(t *testing.T, bucket *testBucket) *Uploader {
+	s3 := httptest.NewServer(bucket)
+	t.Cleanup(s3.Close)
+
+	u := NewUploader(awstest.Config(), "registry-mirror")
+	u.client.Endpoint = s3.URL
+	return u
+}
+
+func TestMirrorVersion(t *testing.T) {
+	release := newTestRelease(t)
+	bucket := newTestBucket()
+	// the signature was mirrored with another version
+	bucket.objects[ObjectKey(checksum("signature"))] = "signature"
+	u := testUploader(t, bucket)
+
+	version := release.version()
+	if err := u.MirrorVersion(context.Background(), &version); err != nil {
+		t.Fatalf("MirrorVersion() error = %v", err)
+	}
+
+	if bucket.puts != 3 {
+		t.Errorf("puts = %d, want 3, the shared checksums once and not the object already mirrored", bucket.puts)
+	}
+	if got := bucket.objects[ObjectKey(checksum("linux archive"))]; got != "linux archive" {
+		t.Errorf("the linux archive is stored as %q", got)
+	}
+
+	d := version.DownloadDetails[0]
+	if d.Mirror == nil {
+		t.Fatalf("the mirrored objects are not recorded")
+	}
+	want := types.MirrorObjects{
+		DownloadURL:         "s3://registry-mirror/objects/sha256/" + checksum("linux archive"),
+		SHASumsURL:          "s3://registry-mirror/objects/sha256/" + checksum(release.files["terraform-provider-foo_1.0.0_SHA256SUMS"]),
+		SHASumsSignatureURL: "s3://registry-mirror/objects/sha256/" + checksum("signature"),
+	}
+	if *d.Mirror != want {
+		t.Errorf("mirror = %+v, want %+v", *d.Mirror, want)
+	}
+}
+
+func TestMirrorVersionVerifiesChecksums(t *testing.T) {
+	release := newTestRelease(t)
+	// the archive was replaced after the SHA256SUMS was published
+	release.files["terraform-provider-foo_1.0.0_linux_amd64.zip"] = "tampered archive"
+	bucket := newTestBucket()
+	u := testUploader(t, bucket)
+
+	version := release.version()
+	version.DownloadDetails[0].SHASum = ""
+	err := u.MirrorVersion(context.Background(), &version)
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("MirrorVersion() error = %v, want a checksum mismatch", err)
+	}
+	for key, object := range bucket.objects {
+		if object == "tampered archive" {
+			t.Errorf("the tampered archive was stored under %s", key)
+		}
+	}
+}
+
+func TestMirrorVersionFailsOnMissingAsset(t *testing.T) {
+	release := newTestRelease(t)
+	delete(release.files, "terraform-provider-foo_1.0.0_darwin_arm64.zip")
+	bucket := newTestBucket()
+	u := testUploader(t, bucket)
+
+	version := release.version()
+	if err := u.MirrorVersion(context.Background(), &version); err == nil {
+		t.Fatalf("expected an error for an asset which cannot be downloaded")
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	release := newTestRelease(t)
+	bucket := newTestBucket()
+	u := testUploader(t, bucket)
+
+	version := release.version()
+	if err := u.MirrorVersion(context.Background(), &version); err != nil {
+		t.Fatalf("MirrorVersion() error = %v", err)
+	}
+
+	linux, darwin := ObjectKey(checksum("linux archive")), ObjectKey(checksum("darwin archive"))
+	delete(bucket.objects, linux)
+	bucket.objects[darwin] = "corrupted"
+	delete(bucket.checksums, darwin)
+
+	repairs, err := u.Reconcile(context.Background(), version)
+	if err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if err := Failed(repairs); err != nil {
+		t.Fatalf("Failed() = %v", err)
+	}
+	problems := make(map[string]string)
+	for _, r := range repairs {
+		problems[r.Key] = r.Problem
+	}
+	if len(problems) != 2 || problems[linux] != ProblemMissing || problems[darwin] != ProblemCorrupted {
+		t.Errorf("repairs = %+v", repairs)
+	}
+	if bucket.objects[linux] != "linux archive" || bucket.objects[darwin] != "darwin archive" {
+		t.Errorf("the objects were not copied again")
+	}
+
+	// the objects are all there now
+	if repairs, err := u.Reconcile(context.Background(), version); err != nil || len(repairs) != 0 {
+		t.Errorf("Reconcile() = %+v, %v, want nothing to repair", repairs, err)
+	}
+}
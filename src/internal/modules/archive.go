@@ -0,0 +1,113 @@
+package modules
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+// archiveModTime is the modification time of every entry of the normalized archives, the Unix epoch.
+const archiveModTime = 0
+
+// NormalizeArchive rewrites the gzipped tarball of a repository tree, as generated by GitHub or GitLab for a tag, into
+// an archive which is the same bytes whenever the same tree is archived, and returns its hex encoded SHA256 checksum.
+// The generated tarballs are not: their top-level directory and global header name the commit, and their entries
+// and gzip header carry the time they were generated at. The normalized archive has the files of the tree at its root,
+// with their timestamps, owners and permissions reset, in the order of the tree, and is compressed without a name or
+// time in its gzip header.
+func NormalizeArchive(dst io.Writer, src io.Reader) (string, error) {
+	gz, err := gzip.NewReader(io.LimitReader(src, maxArchiveSize))
+	if err != nil {
+		return "", fmt.Errorf("could not read the archive: %w", err)
+	}
+	defer gz.Close()
+
+	hasher := sha256.New()
+	zw, err := gzip.NewWriterLevel(io.MultiWriter(dst, hasher), gzip.BestCompression)
+	if err != nil {
+		return "", err
+	}
+	tw := tar.NewWriter(zw)
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("could not read the archive: %w", err)
+		}
+
+		normalized, ok, err := normalizeHeader(header)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			continue
+		}
+		if err := tw.WriteHeader(normalized); err != nil {
+			return "", fmt.Errorf("could not write %s: %w", normalized.Name, err)
+		}
+		if normalized.Typeflag == tar.TypeReg {
+			if _, err := io.Copy(tw, tr); err != nil {
+				return "", fmt.Errorf("could not write %s: %w", normalized.Name, err)
+			}
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return "", err
+	}
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// normalizeHeader returns the header of the entry in the normalized archive, and false for the entries left out: the
+// global header, the top-level directory and anything but files, directories and symbolic links.
+func normalizeHeader(header *tar.Header) (*tar.Header, bool, error) {
+	switch header.Typeflag {
+	case tar.TypeReg, tar.TypeDir, tar.TypeSymlink:
+	default:
+		return nil, false, nil
+	}
+
+	// the top-level directory is named after the repository and the commit
+	_, name, _ := strings.Cut(header.Name, "/")
+	if name == "" {
+		return nil, false, nil
+	}
+	if clean := path.Clean(name); path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return nil, false, fmt.Errorf("invalid archive entry %q", header.Name)
+	}
+
+	normalized := &tar.Header{
+		Typeflag: header.Typeflag,
+		Name:     name,
+		Linkname: header.Linkname,
+		Size:     header.Size,
+		ModTime:  time.Unix(archiveModTime, 0),
+	}
+	switch {
+	case header.Typeflag == tar.TypeDir:
+		normalized.Mode = 0o755
+		normalized.Size = 0
+	case header.Typeflag == tar.TypeSymlink:
+		normalized.Mode = 0o777
+		normalized.Size = 0
+	case header.Mode&0o111 != 0:
+		normalized.Mode = 0o755
+	default:
+		normalized.Mode = 0o644
+	}
+	return normalized, true, nil
+}
@@ -0,0 +1,97 @@
+package modules
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// buildGeneratedArchive builds a tarball like the ones GitHub generates for a tag, at the given time and commit.
+func buildGeneratedArchive(t *testing.T, generated time.Time, commit string) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.ModTime = generated
+	tw := tar.NewWriter(gz)
+	top := "example-terraform-aws-vpc-" + commit + "/"
+	headers := []*tar.Header{
+		{Typeflag: tar.TypeXGlobalHeader, Name: "pax_global_header", PAXRecords: map[string]string{"comment": commit}},
+		{Typeflag: tar.TypeDir, Name: top, Mode: 0o775, ModTime: generated},
+		{Typeflag: tar.TypeReg, Name: top + "main.tf", Mode: 0o664, Size: 4, ModTime: generated, Uname: "root"},
+		{Typeflag: tar.TypeReg, Name: top + "scripts/run.sh", Mode: 0o775, Size: 4, ModTime: generated},
+		{Typeflag: tar.TypeSymlink, Name: top + "README", Linkname: "README.md", ModTime: generated},
+	}
+	for _, h := range headers {
+		if err := tw.WriteHeader(h); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if h.Typeflag == tar.TypeReg {
+			if _, err := tw.Write([]byte("data")); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return &buf
+}
+
+func TestNormalizeArchive(t *testing.T) {
+	var first, second bytes.Buffer
+	firstSum, err := NormalizeArchive(&first, buildGeneratedArchive(t, time.Date(2023, 9, 1, 12, 0, 0, 0, time.UTC), "a1b2c3d"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	secondSum, err := NormalizeArchive(&second, buildGeneratedArchive(t, time.Date(2023, 9, 2, 8, 30, 0, 0, time.UTC), "e4f5a6b"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if firstSum != secondSum || !bytes.Equal(first.Bytes(), second.Bytes()) {
+		t.Errorf("the archives of the same tree differ: %s and %s", firstSum, secondSum)
+	}
+
+	gz, err := gzip.NewReader(&first)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tr := tar.NewReader(gz)
+	var names []string
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		names = append(names, header.Name)
+		if !header.ModTime.Equal(time.Unix(0, 0)) || header.Uname != "" {
+			t.Errorf("entry %s is not normalized: %+v", header.Name, header)
+		}
+		if header.Name == "scripts/run.sh" && header.Mode != 0o755 {
+			t.Errorf("executable mode = %o, want 755", header.Mode)
+		}
+		if header.Name == "main.tf" && header.Mode != 0o644 {
+			t.Errorf("file mode = %o, want 644", header.Mode)
+		}
+	}
+	if want := []string{"main.tf", "scripts/run.sh", "README"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("entries = %v, want %v", names, want)
+	}
+}
+
+func TestNormalizeArchiveRejectsEscapingEntries(t *testing.T) {
+	archive := buildArchive(t, map[string]string{"terraform-aws-vpc-1.0.0/../../etc/passwd": "root"})
+	if _, err := NormalizeArchive(io.Discard, archive); err == nil {
+		t.Errorf("expected an error for an entry outside of the archive")
+	}
+}
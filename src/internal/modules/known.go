@@ -0,0 +1,26 @@
+package modules
+
+import (
+	_ "embed"
+	"strings"
+)
+
+//go:embed known_modules.txt
+var knownModules string
+
+// KnownModules returns the `<namespace>/<name>/<system>` addresses of the modules listed in known_modules.txt, in
+// lower case. Blank lines, comments starting with `#` and lines which are not module addresses are left out.
+func KnownModules() []string {
+	var addresses []string
+	for _, line := range strings.Split(knownModules, "\n") {
+		line = strings.ToLower(strings.TrimSpace(line))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if parts := strings.Split(line, "/"); len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+			continue
+		}
+		addresses = append(addresses, line)
+	}
+	return addresses
+}
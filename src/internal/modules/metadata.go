@@ -0,0 +1,226 @@
+package modules
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+const (
+	// maxArchiveSize bounds the compressed size of the archives read, so that a huge repository cannot exhaust the
+	// memory or the time of the Lambda.
+	maxArchiveSize = 100 << 20
+	// maxConfigFileSize bounds the size of the configuration files parsed, larger ones are skipped.
+	maxConfigFileSize = 1 << 20
+)
+
+// Metadata describes the root module of a version of a module, and the submodules and examples it ships with, as
+// declared by their configuration.
+type Metadata struct {
+	Root       ModuleMetadata   `json:"root"`
+	Submodules []ModuleMetadata `json:"submodules"`
+	Examples   []ModuleMetadata `json:"examples"`
+}
+
+// ModuleMetadata describes the inputs and outputs of a module of a repository.
+type ModuleMetadata struct {
+	// Path is the directory of the module, relative to the root module, empty for the root module itself.
+	Path    string   `json:"path"`
+	Name    string   `json:"name"`
+	Inputs  []Input  `json:"inputs"`
+	Outputs []Output `json:"outputs"`
+}
+
+// Input is a variable of a module. Its type and default are the source of their expressions, as they are written in
+// the configuration.
+type Input struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Description string `json:"description"`
+	Default     string `json:"default"`
+	Required    bool   `json:"required"`
+	Sensitive   bool   `json:"sensitive"`
+}
+
+// Output is an output value of a module.
+type Output struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Sensitive   bool   `json:"sensitive"`
+}
+
+// ParseArchive reads the metadata of a module from the gzipped tarball of its repository at a tag, such as the GitHub
+// and GitLab archives, whose files are all under a single top-level directory. The module is in the subdirectory of
+// the repository, its submodules under `modules/<name>` and its examples under `examples/<name>`.
+//
+// The configuration is parsed statically, nothing is evaluated: only the descriptions written as plain strings are
+// read, and files in the JSON syntax, override files and the parts of files with syntax errors are left out.
+func ParseArchive(archive io.Reader, subdirectory string) (*Metadata, error) {
+	gz, err := gzip.NewReader(io.LimitReader(archive, maxArchiveSize))
+	if err != nil {
+		return nil, fmt.Errorf("could not read module archive: %w", err)
+	}
+	defer gz.Close()
+
+	byDir := make(map[string]*ModuleMetadata)
+	reader := tar.NewReader(gz)
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not read module archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg || header.Size > maxConfigFileSize {
+			continue
+		}
+
+		dir, ok := moduleDir(header.Name, subdirectory)
+		if !ok {
+			continue
+		}
+		src, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, fmt.Errorf("could not read %s from module archive: %w", header.Name, err)
+		}
+
+		module, ok := byDir[dir]
+		if !ok {
+			module = &ModuleMetadata{Path: dir, Name: path.Base(dir), Inputs: []Input{}, Outputs: []Output{}}
+			if dir == "" {
+				module.Name = ""
+			}
+			byDir[dir] = module
+		}
+		parseConfigFile(module, header.Name, src)
+	}
+
+	metadata := &Metadata{
+		Root:       ModuleMetadata{Inputs: []Input{}, Outputs: []Output{}},
+		Submodules: []ModuleMetadata{},
+		Examples:   []ModuleMetadata{},
+	}
+	for dir, module := range byDir {
+		sort.Slice(module.Inputs, func(i, j int) bool { return module.Inputs[i].Name < module.Inputs[j].Name })
+		sort.Slice(module.Outputs, func(i, j int) bool { return module.Outputs[i].Name < module.Outputs[j].Name })
+		switch {
+		case dir == "":
+			metadata.Root = *module
+		case strings.HasPrefix(dir, "modules/"):
+			metadata.Submodules = append(metadata.Submodules, *module)
+		default:
+			metadata.Examples = append(metadata.Examples, *module)
+		}
+	}
+	sort.Slice(metadata.Submodules, func(i, j int) bool { return metadata.Submodules[i].Path < metadata.Submodules[j].Path })
+	sort.Slice(metadata.Examples, func(i, j int) bool { return metadata.Examples[i].Path < metadata.Examples[j].Path })
+	return metadata, nil
+}
+
+// moduleDir returns the directory of the module a file of the archive belongs to, relative to the root module, and
+// false if the file is not the configuration of the root module, a submodule or an example.
+func moduleDir(name, subdirectory string) (string, bool) {
+	// the files are all under the directory named after the repository and the tag
+	_, name, ok := strings.Cut(name, "/")
+	if !ok || !strings.HasSuffix(name, ".tf") {
+		return "", false
+	}
+	if base := path.Base(name); base == "override.tf" || strings.HasSuffix(base, "_override.tf") {
+		return "", false
+	}
+	if subdirectory != "" {
+		if name, ok = strings.CutPrefix(name, subdirectory+"/"); !ok {
+			return "", false
+		}
+	}
+
+	switch parts := strings.Split(path.Dir(name), "/"); {
+	case len(parts) == 1 && parts[0] == ".":
+		return "", true
+	case len(parts) == 2 && (parts[0] == "modules" || parts[0] == "examples"):
+		return path.Join(parts...), true
+	default:
+		return "", false
+	}
+}
+
+// parseConfigFile adds the variables and outputs declared by a configuration file to its module.
+func parseConfigFile(module *ModuleMetadata, filename string, src []byte) {
+	// a file with syntax errors still has the blocks before them
+	file, _ := hclsyntax.ParseConfig(src, filename, hcl.InitialPos)
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return
+	}
+
+	for _, block := range body.Blocks {
+		if len(block.Labels) != 1 {
+			continue
+		}
+		attributes := block.Body.Attributes
+		switch block.Type {
+		case "variable":
+			_, hasDefault := attributes["default"]
+			module.Inputs = append(module.Inputs, Input{
+				Name:        block.Labels[0],
+				Type:        expressionSource(attributes["type"], src),
+				Description: stringValue(attributes["description"]),
+				Default:     expressionSource(attributes["default"], src),
+				Required:    !hasDefault,
+				Sensitive:   boolValue(attributes["sensitive"]),
+			})
+		case "output":
+			module.Outputs = append(module.Outputs, Output{
+				Name:        block.Labels[0],
+				Description: stringValue(attributes["description"]),
+				Sensitive:   boolValue(attributes["sensitive"]),
+			})
+		}
+	}
+}
+
+// expressionSource returns the source of the expression of the attribute, empty if the attribute is not set.
+func expressionSource(attribute *hclsyntax.Attribute, src []byte) string {
+	if attribute == nil {
+		return ""
+	}
+	return string(attribute.Expr.Range().SliceBytes(src))
+}
+
+// stringValue returns the value of the attribute if it is a string which does not need to be evaluated, empty
+// otherwise.
+func stringValue(attribute *hclsyntax.Attribute) string {
+	value, ok := literalValue(attribute, cty.String)
+	if !ok {
+		return ""
+	}
+	return value.AsString()
+}
+
+// boolValue returns the value of the attribute if it is a literal bool, false otherwise.
+func boolValue(attribute *hclsyntax.Attribute) bool {
+	value, ok := literalValue(attribute, cty.Bool)
+	return ok && value.True()
+}
+
+func literalValue(attribute *hclsyntax.Attribute, want cty.Type) (cty.Value, bool) {
+	if attribute == nil {
+		return cty.NilVal, false
+	}
+	// without an evaluation context, expressions referring to anything fail
+	value, diags := attribute.Expr.Value(nil)
+	if diags.HasErrors() || !value.IsKnown() || value.IsNull() || !value.Type().Equals(want) {
+		return cty.NilVal, false
+	}
+	return value, true
+}
@@ -0,0 +1,119 @@
+package modules
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"reflect"
+	"testing"
+)
+
+func buildArchive(t *testing.T, files map[string]string) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return &buf
+}
+
+func TestParseArchive(t *testing.T) {
+	archive := buildArchive(t, map[string]string{
+		"terraform-aws-vpc-1.0.0/variables.tf": `
+variable "name" {
+  type        = string
+  description = "Name of the VPC."
+}
+
+variable "cidr" {
+  type    = string
+  default = "10.0.0.0/16"
+}
+
+variable "tags" {
+  type        = map(string)
+  description = <<-EOT
+    Tags of the VPC.
+  EOT
+  default     = {}
+  sensitive   = true
+}
+`,
+		"terraform-aws-vpc-1.0.0/outputs.tf": `
+output "id" {
+  description = "ID of the ${var.name} VPC."
+  value       = aws_vpc.this.id
+}
+`,
+		"terraform-aws-vpc-1.0.0/override.tf":                   `variable "ignored" {}`,
+		"terraform-aws-vpc-1.0.0/modules/subnets/main.tf":       `variable "vpc_id" {}`,
+		"terraform-aws-vpc-1.0.0/modules/subnets/nested/foo.tf": `variable "ignored" {}`,
+		"terraform-aws-vpc-1.0.0/examples/simple/main.tf":       `output "vpc" { value = module.vpc }`,
+		"terraform-aws-vpc-1.0.0/test/main.tf":                  `variable "ignored" {}`,
+		"terraform-aws-vpc-1.0.0/README.md":                     `# VPC`,
+	})
+
+	got, err := ParseArchive(archive, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := &Metadata{
+		Root: ModuleMetadata{
+			Inputs: []Input{
+				{Name: "cidr", Type: "string", Default: `"10.0.0.0/16"`},
+				{Name: "name", Type: "string", Description: "Name of the VPC.", Required: true},
+				{Name: "tags", Type: "map(string)", Description: "Tags of the VPC.\n", Default: "{}", Sensitive: true},
+			},
+			Outputs: []Output{{Name: "id"}},
+		},
+		Submodules: []ModuleMetadata{{
+			Path:    "modules/subnets",
+			Name:    "subnets",
+			Inputs:  []Input{{Name: "vpc_id", Required: true}},
+			Outputs: []Output{},
+		}},
+		Examples: []ModuleMetadata{{
+			Path:    "examples/simple",
+			Name:    "simple",
+			Inputs:  []Input{},
+			Outputs: []Output{{Name: "vpc"}},
+		}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseArchive() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseArchiveSubdirectory(t *testing.T) {
+	archive := buildArchive(t, map[string]string{
+		"infrastructure-v2.0.0/main.tf":                         `variable "ignored" {}`,
+		"infrastructure-v2.0.0/modules/vpc/main.tf":             `variable "name" {}`,
+		"infrastructure-v2.0.0/modules/vpc/modules/nat/main.tf": `variable "subnet_id" {}`,
+	})
+
+	got, err := ParseArchive(archive, "modules/vpc")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got.Root.Inputs) != 1 || got.Root.Inputs[0].Name != "name" {
+		t.Errorf("root inputs = %+v, want only name", got.Root.Inputs)
+	}
+	if len(got.Submodules) != 1 || got.Submodules[0].Path != "modules/nat" {
+		t.Errorf("submodules = %+v, want only modules/nat", got.Submodules)
+	}
+}
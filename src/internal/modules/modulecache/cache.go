@@ -0,0 +1,81 @@
+package modulecache
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/opentofu/registry/internal/modules"
+)
+
+// Cache is the storage of the module version listings, so that modules are still served when GitHub is slow or
+// rate limits the registry. Handler stores them in DynamoDB.
+type Cache interface {
+	// GetItem returns the item stored under key, or nil if there is none.
+	GetItem(ctx context.Context, key string) (*CacheItem, error)
+	// Store replaces the item stored under key.
+	Store(ctx context.Context, key string, versions []modules.Version) error
+	// Keys returns the keys of all the items, sorted.
+	Keys(ctx context.Context) ([]string, error)
+	// Delete deletes the item stored under key. It does nothing if there is none.
+	Delete(ctx context.Context, key string) error
+}
+
+var _ Cache = (*Handler)(nil)
+
+// CacheItem holds the versions of the modules of a single repository, with the tags they were released under.
+type CacheItem struct {
+	Repository  string            `dynamodbav:"repository"`
+	Versions    []modules.Version `dynamodbav:"versions"`
+	LastUpdated time.Time         `dynamodbav:"last_updated"`
+}
+
+// DefaultTTL is the age after which the versions are refreshed unless configured otherwise. It is shorter than the
+// provider one, as listing the releases of a module costs a single GitHub query.
+const DefaultTTL = 15 * time.Minute
+
+// IsStale returns true if the versions are older than ttl, or than DefaultTTL if it is zero, and should be fetched
+// from GitHub again.
+func (i *CacheItem) IsStale(ttl time.Duration) bool {
+	if ttl == 0 {
+		ttl = DefaultTTL
+	}
+	return time.Since(i.LastUpdated) > ttl
+}
+
+// Version returns the cached version, or nil if it is not cached.
+func (i *CacheItem) Version(version string) *modules.Version {
+	for j := range i.Versions {
+		if i.Versions[j].Version == version {
+			return &i.Versions[j]
+		}
+	}
+	return nil
+}
+
+// Key returns the key of the item of a repository. The modules of a repository share its releases, so the versions
+// are cached per repository rather than per module address. The repositories on GitLab are keyed by their URL, so that
+// they never share an item with a GitHub repository of the same owner and name. The modules released with a tag prefix
+// only have some of the releases of their repository, so the prefix is part of their key.
+func Key(repo modules.Repository) string {
+	key := fmt.Sprintf("%s/%s", repo.Owner, repo.Name)
+	if repo.Host != "" {
+		key = fmt.Sprintf("%s/%s", repo.Host, key)
+	}
+	if repo.TagPrefix != "" {
+		key += "@" + repo.TagPrefix
+	}
+	return strings.ToLower(key)
+}
+
+// ModuleAddress returns the `<namespace>/<name>/<system>` address of the module following the naming convention
+// whose versions are stored under key, and false if the key is not the one of such a repository on GitHub.
+func ModuleAddress(key string) (string, bool) {
+	owner, repoName, ok := strings.Cut(key, "/")
+	if !ok || strings.ContainsAny(repoName, "/@") || !modules.IsModuleRepo(repoName) {
+		return "", false
+	}
+	system, name, _ := strings.Cut(strings.TrimPrefix(repoName, "terraform-"), "-")
+	return fmt.Sprintf("%s/%s/%s", owner, name, system), true
+}
@@ -0,0 +1,20 @@
+package modulecache
+
+import "testing"
+
+func TestModuleAddress(t *testing.T) {
+	tests := map[string]string{
+		"example/terraform-aws-vpc":                            "example/vpc/aws",
+		"example/terraform-google-network-vpc":                 "example/network-vpc/google",
+		"example/terraform-aws-vpc@vpc/":                       "",
+		"https://gitlab.example.com/example/terraform-aws-vpc": "",
+		"example/infrastructure":                               "",
+		"example/terraform-provider-aws":                       "",
+	}
+	for key, want := range tests {
+		got, ok := ModuleAddress(key)
+		if ok != (want != "") || got != want {
+			t.Errorf("ModuleAddress(%q) = %q, %v, want %q", key, got, ok, want)
+		}
+	}
+}
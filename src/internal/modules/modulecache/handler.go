@@ -0,0 +1,135 @@
+package modulecache
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/opentofu/registry/internal/metrics"
+	"github.com/opentofu/registry/internal/modules"
+	"golang.org/x/exp/slog"
+)
+
+type Handler struct {
+	TableName *string
+	Client    *dynamodb.Client
+	// TTL tells the stale items apart from the fresh ones in the lookup metrics, see CacheItem.IsStale.
+	TTL time.Duration
+}
+
+func NewHandler(awsConfig aws.Config, tableName string, ttl time.Duration) *Handler {
+	ddbClient := dynamodb.NewFromConfig(awsConfig)
+
+	return &Handler{
+		TableName: aws.String(tableName),
+		Client:    ddbClient,
+		TTL:       ttl,
+	}
+}
+
+func (h *Handler) GetItem(ctx context.Context, key string) (*CacheItem, error) {
+	slog.Info("Getting module versions from cache", "key", key)
+
+	result, err := h.Client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: h.TableName,
+		Key: map[string]types.AttributeValue{
+			"repository": &types.AttributeValueMemberS{Value: key},
+		},
+	})
+	if err != nil {
+		slog.Error("Failed to get module versions from cache", "key", key, "error", err)
+		return nil, err
+	}
+
+	if len(result.Item) == 0 {
+		slog.Info("Module versions not found in cache", "key", key)
+		metrics.Inc(metrics.ModuleCacheLookups, metrics.Labels{"result": "miss"})
+		return nil, nil //nolint:nilnil // This is not an error, the module is just not cached yet.
+	}
+
+	var item CacheItem
+	if err := attributevalue.UnmarshalMap(result.Item, &item); err != nil {
+		slog.Error("Failed to unmarshal module versions from cache", "key", key, "error", err)
+		return nil, fmt.Errorf("failed to unmarshal module versions: %w", err)
+	}
+
+	if item.IsStale(h.TTL) {
+		metrics.Inc(metrics.ModuleCacheLookups, metrics.Labels{"result": "stale"})
+	} else {
+		metrics.Inc(metrics.ModuleCacheLookups, metrics.Labels{"result": "hit"})
+	}
+	return &item, nil
+}
+
+func (h *Handler) Store(ctx context.Context, key string, versions []modules.Version) error {
+	marshalledItem, err := attributevalue.MarshalMap(CacheItem{
+		Repository:  key,
+		Versions:    versions,
+		LastUpdated: time.Now(),
+	})
+	if err != nil {
+		slog.Error("got error marshalling module versions", "error", err)
+		return fmt.Errorf("got error marshalling module versions: %w", err)
+	}
+
+	slog.Info("Storing module versions", "key", key, "versions", len(versions))
+	_, err = h.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		Item:      marshalledItem,
+		TableName: h.TableName,
+	})
+	if err != nil {
+		slog.Error("got error calling PutItem", "error", err)
+		return fmt.Errorf("got error calling PutItem: %w", err)
+	}
+	return nil
+}
+
+func (h *Handler) Delete(ctx context.Context, key string) error {
+	slog.Info("Deleting module versions", "key", key)
+	_, err := h.Client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: h.TableName,
+		Key: map[string]types.AttributeValue{
+			"repository": &types.AttributeValueMemberS{Value: key},
+		},
+	})
+	if err != nil {
+		slog.Error("got error calling DeleteItem", "error", err)
+		return fmt.Errorf("got error calling DeleteItem: %w", err)
+	}
+	return nil
+}
+
+// Keys returns the keys of all the items, sorted. DynamoDB has no index to list keys with, so this scans the whole
+// table, only reading the keys.
+func (h *Handler) Keys(ctx context.Context) ([]string, error) {
+	var keys []string
+	input := &dynamodb.ScanInput{
+		TableName:                h.TableName,
+		ProjectionExpression:     aws.String("#repository"),
+		ExpressionAttributeNames: map[string]string{"#repository": "repository"},
+	}
+	for {
+		result, err := h.Client.Scan(ctx, input)
+		if err != nil {
+			slog.Error("Failed to scan module versions table", "error", err)
+			return nil, fmt.Errorf("failed to scan module versions: %w", err)
+		}
+		for _, item := range result.Items {
+			if repository, ok := item["repository"].(*types.AttributeValueMemberS); ok {
+				keys = append(keys, repository.Value)
+			}
+		}
+		if len(result.LastEvaluatedKey) == 0 {
+			break
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+
+	sort.Strings(keys)
+	return keys, nil
+}
@@ -1,9 +1,23 @@
 package modules
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // GetRepoName returns the repo name for a module
 // The repo name should match the format `terraform-<system>-<name>`
 func GetRepoName(system, name string) string {
 	return fmt.Sprintf("terraform-%s-%s", system, name)
 }
+
+// IsModuleRepo returns true if the repo name has the format of a module repo, `terraform-<system>-<name>`. Provider
+// repos, `terraform-provider-<type>`, share the format and are not modules.
+func IsModuleRepo(repoName string) bool {
+	rest, ok := strings.CutPrefix(repoName, "terraform-")
+	if !ok || strings.HasPrefix(rest, "provider-") {
+		return false
+	}
+	system, name, ok := strings.Cut(rest, "-")
+	return ok && system != "" && name != ""
+}
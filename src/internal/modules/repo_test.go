@@ -0,0 +1,19 @@
+package modules
+
+import "testing"
+
+func TestIsModuleRepo(t *testing.T) {
+	tests := map[string]bool{
+		"terraform-aws-vpc":            true,
+		"terraform-google-network-vpc": true,
+		"terraform-provider-aws":       false,
+		"terraform-aws":                false,
+		"terraform-aws-":               false,
+		"registry":                     false,
+	}
+	for name, want := range tests {
+		if got := IsModuleRepo(name); got != want {
+			t.Errorf("IsModuleRepo(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package modules
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Repository is where the code of a module lives, on GitHub unless Host is set.
+type Repository struct {
+	// Host is the URL of the GitLab instance the repository is on, empty for GitHub.
+	Host  string `json:"host,omitempty"`
+	Owner string `json:"owner"`
+	Name  string `json:"repository"`
+	// Subdirectory is the directory of the module within the repository, empty if it is at the root.
+	Subdirectory string `json:"subdirectory,omitempty"`
+	// TagPrefix is the prefix of the tags of the releases of the module, see vcs.WithTagPrefix. The tags of its
+	// versions are the ones without the prefix.
+	TagPrefix string `json:"tag_prefix,omitempty"`
+}
+
+// DefaultRepository returns the repository of a module following the naming convention, `terraform-<system>-<name>`
+// owned by its namespace.
+func DefaultRepository(namespace, name, system string) Repository {
+	return Repository{Owner: namespace, Name: GetRepoName(system, name)}
+}
+
+// Source returns the module source of the repository at the given git ref, the tag of a version of the module.
+func (r Repository) Source(ref string) string {
+	ref = r.TagPrefix + ref
+	source := fmt.Sprintf("git::https://github.com/%s/%s", r.Owner, r.Name)
+	if r.Host != "" {
+		source = fmt.Sprintf("git::%s/%s/%s.git", r.Host, r.Owner, r.Name)
+	}
+	if r.Subdirectory != "" {
+		source += "//" + r.Subdirectory
+	}
+	return fmt.Sprintf("%s?ref=%s", source, ref)
+}
+
+// Archive returns the module source of the GitHub or GitLab archive of the repository at the given tag. The archive
+// holds a single directory named after the repository and the tag, which the `//*` subdirectory of the source selects.
+func (r Repository) Archive(tag string) string {
+	source := r.ArchiveURL(tag) + "//*"
+	if r.Subdirectory != "" {
+		source += "/" + r.Subdirectory
+	}
+	return source
+}
+
+// ArchiveURL returns the URL of the gzipped tarball of the repository at the given tag, on GitHub or GitLab.
+func (r Repository) ArchiveURL(tag string) string {
+	tag = r.TagPrefix + tag
+	if r.Host != "" {
+		return fmt.Sprintf("%s/%s/%s/-/archive/%s/%s-%s.tar.gz", r.Host, r.Owner, r.Name, tag, r.Name, tag)
+	}
+	return fmt.Sprintf("https://github.com/%s/%s/archive/refs/tags/%s.tar.gz", r.Owner, r.Name, tag)
+}
+
+// ParseRepositories parses the MODULE_REPOSITORIES value, a JSON object mapping `<namespace>/<name>/<system>` module
+// addresses to the repositories of modules which do not follow the naming convention, e.g.
+// `{"example/vpc/aws": {"owner": "example", "repository": "infrastructure", "subdirectory": "modules/vpc"}}`.
+func ParseRepositories(value string) (map[string]Repository, error) {
+	repositories := make(map[string]Repository)
+	if value == "" {
+		return repositories, nil
+	}
+
+	var settings map[string]Repository
+	if err := json.Unmarshal([]byte(value), &settings); err != nil {
+		return nil, fmt.Errorf("could not parse MODULE_REPOSITORIES: %w", err)
+	}
+
+	for address, r := range settings {
+		if parts := strings.Split(address, "/"); len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+			return nil, fmt.Errorf("invalid module address %q in MODULE_REPOSITORIES, expected <namespace>/<name>/<system>", address)
+		}
+		if r.Owner == "" || r.Name == "" || strings.Contains(r.Owner, "/") || strings.Contains(r.Name, "/") {
+			return nil, fmt.Errorf("module %s needs an owner and a repository name in MODULE_REPOSITORIES", address)
+		}
+		if r.Subdirectory != "" {
+			subdirectory := path.Clean(strings.Trim(r.Subdirectory, "/"))
+			if subdirectory == "." || subdirectory == ".." || strings.HasPrefix(subdirectory, "../") {
+				return nil, fmt.Errorf("module %s has an invalid subdirectory %q in MODULE_REPOSITORIES", address, r.Subdirectory)
+			}
+			r.Subdirectory = subdirectory
+		}
+		repositories[strings.ToLower(address)] = r
+	}
+	return repositories, nil
+}
@@ -0,0 +1,60 @@
+package modules
+
+import "testing"
+
+func TestParseRepositories(t *testing.T) {
+	repositories, err := ParseRepositories(`{"Example/vpc/aws": {"owner": "example", "repository": "infrastructure", "subdirectory": "/modules/vpc/"}}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := Repository{Owner: "example", Name: "infrastructure", Subdirectory: "modules/vpc"}
+	if got := repositories["example/vpc/aws"]; got != want {
+		t.Errorf("repository = %+v, want %+v", got, want)
+	}
+
+	invalid := map[string]string{
+		"not json":              `{`,
+		"short address":         `{"example/vpc": {"owner": "example", "repository": "infrastructure"}}`,
+		"no owner":              `{"example/vpc/aws": {"repository": "infrastructure"}}`,
+		"escaping subdirectory": `{"example/vpc/aws": {"owner": "example", "repository": "infrastructure", "subdirectory": "../other"}}`,
+	}
+	for name, value := range invalid {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseRepositories(value); err == nil {
+				t.Errorf("ParseRepositories(%s) succeeded, want an error", value)
+			}
+		})
+	}
+}
+
+func TestRepositorySource(t *testing.T) {
+	if got := DefaultRepository("example", "vpc", "aws").Source("v1.0.0"); got != "git::https://github.com/example/terraform-aws-vpc?ref=v1.0.0" {
+		t.Errorf("Source() = %q", got)
+	}
+	monorepo := Repository{Owner: "example", Name: "infrastructure", Subdirectory: "modules/vpc"}
+	if got := monorepo.Source("v1.0.0"); got != "git::https://github.com/example/infrastructure//modules/vpc?ref=v1.0.0" {
+		t.Errorf("Source() = %q", got)
+	}
+	prefixed := Repository{Owner: "example", Name: "infrastructure", Subdirectory: "modules/vpc", TagPrefix: "vpc/"}
+	if got := prefixed.Source("v1.0.0"); got != "git::https://github.com/example/infrastructure//modules/vpc?ref=vpc/v1.0.0" {
+		t.Errorf("Source() = %q", got)
+	}
+	gitlab := Repository{Host: "https://gitlab.com", Owner: "example/terraform", Name: "terraform-aws-vpc"}
+	if got := gitlab.Source("v1.0.0"); got != "git::https://gitlab.com/example/terraform/terraform-aws-vpc.git?ref=v1.0.0" {
+		t.Errorf("Source() = %q", got)
+	}
+}
+
+func TestRepositoryArchive(t *testing.T) {
+	if got := DefaultRepository("example", "vpc", "aws").Archive("v1.0.0"); got != "https://github.com/example/terraform-aws-vpc/archive/refs/tags/v1.0.0.tar.gz//*" {
+		t.Errorf("Archive() = %q", got)
+	}
+	monorepo := Repository{Owner: "example", Name: "infrastructure", Subdirectory: "modules/vpc"}
+	if got := monorepo.Archive("1.0.0"); got != "https://github.com/example/infrastructure/archive/refs/tags/1.0.0.tar.gz//*/modules/vpc" {
+		t.Errorf("Archive() = %q", got)
+	}
+	gitlab := Repository{Host: "https://gitlab.com", Owner: "example/terraform", Name: "terraform-aws-vpc"}
+	if got := gitlab.Archive("v1.0.0"); got != "https://gitlab.com/example/terraform/terraform-aws-vpc/-/archive/v1.0.0/terraform-aws-vpc-v1.0.0.tar.gz//*" {
+		t.Errorf("Archive() = %q", got)
+	}
+}
@@ -0,0 +1,48 @@
+package modules
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
+
+// RewriteRule rewrites module sources matching a pattern, for example to point github.com sources at an internal Git
+// mirror or at archive URLs for consumers that cannot reach GitHub directly.
+type RewriteRule struct {
+	// Match is a regular expression matched against the full module source, e.g. `^git::https://github\.com/(.*)$`.
+	Match string `json:"match"`
+	// Replace is the replacement for the matched source, it can reference capture groups of Match, e.g. `$1`.
+	Replace string `json:"replace"`
+
+	pattern *regexp.Regexp
+}
+
+// ParseRewriteRules parses a JSON list of rewrite rules and compiles their patterns.
+func ParseRewriteRules(value string) ([]RewriteRule, error) {
+	var rules []RewriteRule
+	if err := json.Unmarshal([]byte(value), &rules); err != nil {
+		return nil, err
+	}
+
+	for i := range rules {
+		pattern, err := regexp.Compile(rules[i].Match)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q for rewrite rule %d: %w", rules[i].Match, i, err)
+		}
+		rules[i].pattern = pattern
+	}
+
+	return rules, nil
+}
+
+// RewriteSource applies the first rule matching the given source and returns the rewritten source.
+// If no rule matches, the source is returned unchanged.
+func RewriteSource(rules []RewriteRule, source string) string {
+	for _, rule := range rules {
+		if rule.pattern == nil || !rule.pattern.MatchString(source) {
+			continue
+		}
+		return rule.pattern.ReplaceAllString(source, rule.Replace)
+	}
+	return source
+}
@@ -0,0 +1,49 @@
+package modules
+
+import "testing"
+
+func TestRewriteSource(t *testing.T) {
+	rules, err := ParseRewriteRules(`[
+		{"match": "^git::https://github\\.com/internal/(.*)$", "replace": "git::https://git.example.com/$1"},
+		{"match": "^git::https://github\\.com/([^/]+)/([^?]+)\\?ref=(.+)$", "replace": "https://mirror.example.com/$1/$2/archive/$3.tar.gz"}
+	]`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{
+			name:   "first matching rule wins",
+			source: "git::https://github.com/internal/terraform-aws-vpc?ref=v1.0.0",
+			want:   "git::https://git.example.com/terraform-aws-vpc?ref=v1.0.0",
+		},
+		{
+			name:   "capture groups are substituted",
+			source: "git::https://github.com/example/terraform-aws-vpc?ref=v1.0.0",
+			want:   "https://mirror.example.com/example/terraform-aws-vpc/archive/v1.0.0.tar.gz",
+		},
+		{
+			name:   "unmatched sources are unchanged",
+			source: "git::https://gitlab.com/example/terraform-aws-vpc?ref=v1.0.0",
+			want:   "git::https://gitlab.com/example/terraform-aws-vpc?ref=v1.0.0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RewriteSource(rules, tt.source); got != tt.want {
+				t.Errorf("RewriteSource() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRewriteRulesInvalidPattern(t *testing.T) {
+	if _, err := ParseRewriteRules(`[{"match": "(", "replace": ""}]`); err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+}
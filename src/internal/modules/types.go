@@ -1,7 +1,65 @@
 package modules
 
+import "github.com/opentofu/registry/internal/version"
+
 type Version struct {
-	Version string `json:"version"`
+	Version string `json:"version" dynamodbav:"version"`
+	// Tag is the git tag the version was released under, which may or may not have a "v" prefix.
+	Tag string `json:"-" dynamodbav:"tag"`
+	// Prerelease is set if the release is marked as a prerelease on GitHub. Prereleases are only listed on request.
+	Prerelease bool `json:"-" dynamodbav:"prerelease,omitempty"`
+	// TarballAsset is the URL of the `.tar.gz` or `.tgz` file attached to the release, if any. Such files do not change
+	// once published, so they are served as they are rather than archived by the registry.
+	TarballAsset string `json:"-" dynamodbav:"tarball_asset,omitempty"`
+	// Archive is the `s3://` URL of the archive of the tree of the tag the registry built, see NormalizeArchive, empty
+	// until it is built.
+	Archive string `json:"-" dynamodbav:"archive,omitempty"`
+}
+
+// KeepArchives returns the versions with the archives built for the versions of previous released under the same tag,
+// so that the versions fetched again do not lose them.
+func KeepArchives(versions, previous []Version) []Version {
+	archives := make(map[string]string, len(previous))
+	for _, v := range previous {
+		if v.Archive != "" {
+			archives[v.Tag] = v.Archive
+		}
+	}
+	if len(archives) == 0 {
+		return versions
+	}
+
+	kept := make([]Version, len(versions))
+	for i, v := range versions {
+		if v.Archive == "" {
+			v.Archive = archives[v.Tag]
+		}
+		kept[i] = v
+	}
+	return kept
+}
+
+// WithoutPrereleases returns the versions which are not prereleases, marked as such on GitHub or with a prerelease
+// suffix, as the versions cached before the GitHub flag was recorded only have the latter.
+func WithoutPrereleases(versions []Version) []Version {
+	filtered := make([]Version, 0, len(versions))
+	for _, v := range versions {
+		if !v.Prerelease && !version.IsPrerelease(v.Version) {
+			filtered = append(filtered, v)
+		}
+	}
+	return filtered
+}
+
+// Prereleases returns the versions which are prereleases, see WithoutPrereleases.
+func Prereleases(versions []Version) []Version {
+	filtered := make([]Version, 0, len(versions))
+	for _, v := range versions {
+		if v.Prerelease || version.IsPrerelease(v.Version) {
+			filtered = append(filtered, v)
+		}
+	}
+	return filtered
 }
 
 // VersionDetails provides comprehensive details about a specific provider version.
@@ -7,29 +7,38 @@ import (
 	"time"
 
 	"github.com/aws/aws-xray-sdk-go/xray"
-	"github.com/shurcooL/githubv4"
 	"golang.org/x/exp/slog"
 
 	"github.com/opentofu/registry/internal/github"
+	"github.com/opentofu/registry/internal/vcs"
+	"github.com/opentofu/registry/internal/version"
 )
 
-// GetVersions fetches a list of versions for a GitHub repository identified by its namespace and name.
-func GetVersions(ctx context.Context, ghClient *githubv4.Client, namespace string, name string, since *time.Time) (versions []Version, err error) {
+// GetVersions fetches a list of versions for a repository on the given host identified by its namespace and name.
+func GetVersions(ctx context.Context, host vcs.Host, namespace string, name string, since *time.Time) (versions []Version, err error) {
 	err = xray.Capture(ctx, "module.versions", func(tracedCtx context.Context) error {
 		xray.AddAnnotation(tracedCtx, "namespace", namespace)
 		xray.AddAnnotation(tracedCtx, "name", name)
 
 		slog.Info("Fetching releases")
 
-		releases, fetchErr := github.FetchReleases(tracedCtx, ghClient, namespace, name, since)
-		if err != nil {
+		releases, fetchErr := host.Releases(tracedCtx, namespace, name, since)
+		if fetchErr != nil {
 			return fmt.Errorf("failed to fetch releases: %w", fetchErr)
 		}
 
 		for _, release := range releases {
+			// Normalize the version string to remove the leading "v" if it exists.
+			v, ok := version.FromTag(release.TagName)
+			if !ok {
+				slog.Warn("Skipping release, its tag is not a version", "tag", release.TagName)
+				continue
+			}
 			versions = append(versions, Version{
-				// Normalize the version string to remove the leading "v" if it exists.
-				Version: strings.TrimPrefix(release.TagName, "v"),
+				Version:      v,
+				Tag:          release.TagName,
+				Prerelease:   release.IsPrerelease,
+				TarballAsset: tarballAsset(release.ReleaseAssets.Nodes),
 			})
 		}
 
@@ -38,3 +47,13 @@ func GetVersions(ctx context.Context, ghClient *githubv4.Client, namespace strin
 
 	return versions, err
 }
+
+// tarballAsset returns the download URL of the first gzipped tarball attached to a release, empty if there is none.
+func tarballAsset(assets []github.ReleaseAsset) string {
+	for _, asset := range assets {
+		if strings.HasSuffix(asset.Name, ".tar.gz") || strings.HasSuffix(asset.Name, ".tgz") {
+			return asset.DownloadURL
+		}
+	}
+	return ""
+}
@@ -0,0 +1,303 @@
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-xray-sdk-go/xray"
+)
+
+// MediaTypeImageManifest is the media type of OCI image manifests, which artifacts are stored as.
+const MediaTypeImageManifest = "application/vnd.oci.image.manifest.v1+json"
+
+// AnnotationTitle is the annotation holding the filename of a layer.
+const AnnotationTitle = "org.opencontainers.image.title"
+
+// ErrNotFound is returned when a manifest or repository does not exist in the registry.
+var ErrNotFound = errors.New("not found in OCI registry")
+
+// Manifest is an OCI image manifest. Only the fields the registry needs are decoded.
+type Manifest struct {
+	MediaType   string            `json:"mediaType"`
+	Layers      []Descriptor      `json:"layers"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+// Descriptor describes a blob referenced by a manifest.
+type Descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+// Title returns the filename of the blob, as set by tools like oras when pushing files.
+func (d Descriptor) Title() string {
+	return d.Annotations[AnnotationTitle]
+}
+
+// Client talks to the distribution API of OCI registries. Anonymous and basic credentials are exchanged for bearer
+// tokens as requested by the registry, tokens are cached per repository.
+type Client struct {
+	httpClient *http.Client
+	username   string
+	password   string
+
+	mu     sync.Mutex
+	tokens map[string]string
+}
+
+// NewClient returns a client authenticating with the given credentials, leave them empty for anonymous access.
+func NewClient(username, password string) *Client {
+	return &Client{
+		httpClient: xray.Client(&http.Client{
+			// Blob requests redirect to the storage backend, we want to hand that location out instead of following it.
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}),
+		username: username,
+		password: password,
+		tokens:   make(map[string]string),
+	}
+}
+
+// ListTags returns all tags in the repository.
+func (c *Client) ListTags(ctx context.Context, ref Reference) ([]string, error) {
+	var tags []string
+	next := ref.tagsURL()
+	for next != "" {
+		resp, err := c.do(ctx, ref, http.MethodGet, next, "")
+		if err != nil {
+			return nil, err
+		}
+
+		var page struct {
+			Tags []string `json:"tags"`
+		}
+		err = decodeResponse(resp, &page)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tags of %s: %w", ref, err)
+		}
+		tags = append(tags, page.Tags...)
+
+		next, err = nextPage(resp)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return tags, nil
+}
+
+// GetManifest returns the image manifest for the given tag.
+func (c *Client) GetManifest(ctx context.Context, ref Reference, tag string) (*Manifest, error) {
+	resp, err := c.do(ctx, ref, http.MethodGet, ref.manifestURL(tag), MediaTypeImageManifest)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest Manifest
+	if err := decodeResponse(resp, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to get manifest %s:%s: %w", ref, tag, err)
+	}
+	return &manifest, nil
+}
+
+// FetchBlob returns the contents of a blob. The caller must close the returned reader.
+func (c *Client) FetchBlob(ctx context.Context, ref Reference, digest string) (io.ReadCloser, error) {
+	resp, err := c.do(ctx, ref, http.MethodGet, ref.BlobURL(digest), "")
+	if err != nil {
+		return nil, err
+	}
+
+	// follow the redirect to the storage backend ourselves, it must not receive the registry token
+	if location := resp.Header.Get("Location"); isRedirect(resp.StatusCode) && location != "" {
+		resp.Body.Close()
+		req, reqErr := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+		if reqErr != nil {
+			return nil, reqErr
+		}
+		if resp, err = c.httpClient.Do(req); err != nil {
+			return nil, err
+		}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to fetch blob %s from %s: unexpected status %d", digest, ref, resp.StatusCode)
+	}
+	return resp.Body, nil
+}
+
+// ResolveBlobURL returns a URL the blob can be downloaded from without credentials. Most registries redirect blob
+// requests to a short-lived pre-signed storage URL, which is returned. Otherwise, the blob URL itself is returned.
+func (c *Client) ResolveBlobURL(ctx context.Context, ref Reference, digest string) (string, error) {
+	resp, err := c.do(ctx, ref, http.MethodGet, ref.BlobURL(digest), "")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case isRedirect(resp.StatusCode):
+		location, err := resp.Location()
+		if err != nil {
+			return "", fmt.Errorf("invalid redirect for blob %s: %w", digest, err)
+		}
+		return location.String(), nil
+	case resp.StatusCode == http.StatusOK:
+		return ref.BlobURL(digest), nil
+	case resp.StatusCode == http.StatusNotFound:
+		return "", ErrNotFound
+	default:
+		return "", fmt.Errorf("failed to resolve blob %s from %s: unexpected status %d", digest, ref, resp.StatusCode)
+	}
+}
+
+// do performs a request against the registry, authenticating with a bearer token if the registry asks for one.
+func (c *Client) do(ctx context.Context, ref Reference, method, target, accept string) (*http.Response, error) {
+	send := func() (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, method, target, nil)
+		if err != nil {
+			return nil, err
+		}
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		if token := c.token(ref); token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		return c.httpClient.Do(req)
+	}
+
+	resp, err := send()
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusUnauthorized {
+		return resp, nil
+	}
+
+	challenge := resp.Header.Get("WWW-Authenticate")
+	resp.Body.Close()
+	if err := c.authenticate(ctx, ref, challenge); err != nil {
+		return nil, err
+	}
+	return send()
+}
+
+func (c *Client) token(ref Reference) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.tokens[ref.String()]
+}
+
+// authenticate exchanges the configured credentials for a bearer token, following the registry token flow.
+func (c *Client) authenticate(ctx context.Context, ref Reference, challenge string) error {
+	params, ok := parseBearerChallenge(challenge)
+	if !ok || params["realm"] == "" {
+		return fmt.Errorf("unsupported authentication challenge from %s: %q", ref.Registry, challenge)
+	}
+
+	query := url.Values{}
+	if service := params["service"]; service != "" {
+		query.Set("service", service)
+	}
+	scope := params["scope"]
+	if scope == "" {
+		scope = fmt.Sprintf("repository:%s:pull", ref.Repository)
+	}
+	query.Set("scope", scope)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, params["realm"]+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	if c.username != "" || c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to request token from %s: %w", ref.Registry, err)
+	}
+
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := decodeResponse(resp, &token); err != nil {
+		return fmt.Errorf("failed to request token from %s: %w", ref.Registry, err)
+	}
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+
+	c.mu.Lock()
+	c.tokens[ref.String()] = token.Token
+	c.mu.Unlock()
+	return nil
+}
+
+// parseBearerChallenge parses a `WWW-Authenticate: Bearer realm="...",service="...",scope="..."` header.
+func parseBearerChallenge(challenge string) (map[string]string, bool) {
+	scheme, rest, _ := strings.Cut(challenge, " ")
+	if !strings.EqualFold(scheme, "bearer") {
+		return nil, false
+	}
+
+	params := make(map[string]string)
+	for rest != "" {
+		var key, value string
+		key, rest, _ = strings.Cut(strings.TrimLeft(rest, " ,"), "=")
+		if strings.HasPrefix(rest, `"`) {
+			value, rest, _ = strings.Cut(rest[1:], `"`)
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+		}
+		if key = strings.TrimSpace(key); key != "" {
+			params[strings.ToLower(key)] = value
+		}
+	}
+	return params, true
+}
+
+// nextPage returns the URL of the next page of a paginated response, as given by its Link header.
+func nextPage(resp *http.Response) (string, error) {
+	link := resp.Header.Get("Link")
+	if link == "" || !strings.Contains(link, `rel="next"`) {
+		return "", nil
+	}
+
+	start, end := strings.Index(link, "<"), strings.Index(link, ">")
+	if start < 0 || end < start {
+		return "", fmt.Errorf("invalid Link header %q", link)
+	}
+	next, err := resp.Request.URL.Parse(link[start+1 : end])
+	if err != nil {
+		return "", fmt.Errorf("invalid Link header %q: %w", link, err)
+	}
+	return next.String(), nil
+}
+
+func decodeResponse(resp *http.Response, v any) error {
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func isRedirect(statusCode int) bool {
+	return statusCode >= http.StatusMultipleChoices && statusCode < http.StatusBadRequest
+}
@@ -0,0 +1,51 @@
+package oci
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    Reference
+		wantErr bool
+	}{
+		{value: "ghcr.io/example/terraform-provider-foo", want: Reference{Registry: "ghcr.io", Repository: "example/terraform-provider-foo"}},
+		{value: "oci://public.ecr.aws/example/foo", want: Reference{Registry: "public.ecr.aws", Repository: "example/foo"}},
+		{value: "ghcr.io", wantErr: true},
+		{value: "ghcr.io/example/foo:v1.0.0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseReference(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseReference() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseReference() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseBearerChallenge(t *testing.T) {
+	got, ok := parseBearerChallenge(`Bearer realm="https://ghcr.io/token",service="ghcr.io",scope="repository:example/foo:pull"`)
+	if !ok {
+		t.Fatal("expected the challenge to be parsed")
+	}
+
+	want := map[string]string{
+		"realm":   "https://ghcr.io/token",
+		"service": "ghcr.io",
+		"scope":   "repository:example/foo:pull",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseBearerChallenge() = %v, want %v", got, want)
+	}
+
+	if _, ok := parseBearerChallenge(`Basic realm="registry"`); ok {
+		t.Error("expected basic challenges to be rejected")
+	}
+}
@@ -0,0 +1,42 @@
+package oci
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Reference identifies a repository in an OCI registry, e.g. `ghcr.io/example/terraform-provider-foo`.
+type Reference struct {
+	Registry   string // The registry hostname, e.g. `ghcr.io`.
+	Repository string // The repository within the registry, e.g. `example/terraform-provider-foo`.
+}
+
+// ParseReference parses a repository reference of the form `<registry>/<repository>`.
+func ParseReference(value string) (Reference, error) {
+	value = strings.TrimPrefix(value, "oci://")
+	registry, repository, ok := strings.Cut(value, "/")
+	if !ok || registry == "" || repository == "" {
+		return Reference{}, fmt.Errorf("invalid OCI repository reference %q", value)
+	}
+	if strings.ContainsAny(repository, ":@") {
+		return Reference{}, fmt.Errorf("OCI repository reference %q must not contain a tag or digest", value)
+	}
+	return Reference{Registry: registry, Repository: repository}, nil
+}
+
+func (r Reference) String() string {
+	return fmt.Sprintf("%s/%s", r.Registry, r.Repository)
+}
+
+// BlobURL returns the URL of a blob in the repository, addressed by its digest.
+func (r Reference) BlobURL(digest string) string {
+	return fmt.Sprintf("https://%s/v2/%s/blobs/%s", r.Registry, r.Repository, digest)
+}
+
+func (r Reference) manifestURL(tag string) string {
+	return fmt.Sprintf("https://%s/v2/%s/manifests/%s", r.Registry, r.Repository, tag)
+}
+
+func (r Reference) tagsURL() string {
+	return fmt.Sprintf("https://%s/v2/%s/tags/list", r.Registry, r.Repository)
+}
@@ -0,0 +1,40 @@
+package platform
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Allowlist restricts the platforms of providers to those matching one of its entries, written as `<os>_<arch>` where
+// either part can be `*`, such as `linux_*`. An empty allowlist allows every platform.
+type Allowlist []string
+
+// ParseAllowlist checks the entries of an allowlist, and normalizes their platforms, see Normalize.
+func ParseAllowlist(entries []string) (Allowlist, error) {
+	allowlist := make(Allowlist, 0, len(entries))
+	for _, entry := range entries {
+		os, arch, ok := strings.Cut(entry, "_")
+		// the architecture may be the x86_64 alias, whose separator is only gone once normalized
+		normalized := Normalize(Platform{OS: os, Arch: arch})
+		if !ok || os == "" || arch == "" || strings.Contains(normalized.Arch, "_") {
+			return nil, fmt.Errorf("invalid platform %q, expected <os>_<arch>", entry)
+		}
+		allowlist = append(allowlist, normalized.OS+"_"+normalized.Arch)
+	}
+	return allowlist, nil
+}
+
+// Allows returns true if the platform matches one of the entries, or the allowlist is empty.
+func (a Allowlist) Allows(p Platform) bool {
+	if len(a) == 0 {
+		return true
+	}
+	p = Normalize(p)
+	for _, entry := range a {
+		os, arch, _ := strings.Cut(entry, "_")
+		if (os == "*" || os == p.OS) && (arch == "*" || arch == p.Arch) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package platform
+
+import "strings"
+
+// The aliases some release pipelines name operating systems and architectures with, and the GOOS and GOARCH values
+// of Go the registry protocol names them with.
+//
+//nolint:gochecknoglobals // These should be treated as constants.
+var (
+	osAliases = map[string]string{
+		"macos": "darwin",
+		"osx":   "darwin",
+		"mac":   "darwin",
+		"win":   "windows",
+	}
+	archAliases = map[string]string{
+		"x86_64":  "amd64",
+		"x64":     "amd64",
+		"aarch64": "arm64",
+		"armv8":   "arm64",
+		"i386":    "386",
+		"i686":    "386",
+		"x86":     "386",
+		"armv7":   "arm",
+		"armv6":   "arm",
+	}
+)
+
+// Normalize returns the platform named the way the registry protocol names platforms, in lower case and with the Go
+// names of its operating system and architecture, e.g. `darwin_arm64` for `macOS_aarch64`.
+func Normalize(p Platform) Platform {
+	os, arch := strings.ToLower(p.OS), strings.ToLower(p.Arch)
+	if alias, ok := osAliases[os]; ok {
+		os = alias
+	}
+	if alias, ok := archAliases[arch]; ok {
+		arch = alias
+	}
+	return Platform{OS: os, Arch: arch}
+}
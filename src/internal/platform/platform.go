@@ -1,6 +1,9 @@
 package platform
 
-import "regexp"
+import (
+	"regexp"
+	"strings"
+)
 
 type Platform struct {
 	OS   string `json:"os"`
@@ -9,17 +12,21 @@ type Platform struct {
 
 var platformPattern = regexp.MustCompile(`.*_(?P<Os>[a-zA-Z0-9]+)_(?P<Arch>[a-zA-Z0-9]+)`)
 
+// ExtractPlatformFromArtifact returns the normalized platform of an archive named `<name>_<version>_<os>_<arch>`, nil
+// if the name does not end with a platform.
 func ExtractPlatformFromArtifact(releaseArtifact string) *Platform {
+	// the separator of the platform would split the x86_64 alias
+	releaseArtifact = strings.ReplaceAll(releaseArtifact, "_x86_64", "_amd64")
 	matches := platformPattern.FindStringSubmatch(releaseArtifact)
 
 	if matches == nil {
 		return nil
 	}
 
-	platform := Platform{
+	platform := Normalize(Platform{
 		OS:   matches[platformPattern.SubexpIndex("Os")],
 		Arch: matches[platformPattern.SubexpIndex("Arch")],
-	}
+	})
 
 	return &platform
 }
@@ -48,3 +48,59 @@ func TestExtractPlatformFromArtifact(t *testing.T) {
 		})
 	}
 }
+
+func TestAllowlist(t *testing.T) {
+	allowlist, err := ParseAllowlist([]string{"linux_*", "Darwin_arm64"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		platform Platform
+		allowed  bool
+	}{
+		{Platform{OS: "linux", Arch: "amd64"}, true},
+		{Platform{OS: "linux", Arch: "riscv64"}, true},
+		{Platform{OS: "darwin", Arch: "arm64"}, true},
+		{Platform{OS: "darwin", Arch: "amd64"}, false},
+		{Platform{OS: "freebsd", Arch: "386"}, false},
+		{Platform{OS: "macos", Arch: "aarch64"}, true},
+	}
+	for _, test := range tests {
+		if got := allowlist.Allows(test.platform); got != test.allowed {
+			t.Errorf("Allows(%v) = %v, want %v", test.platform, got, test.allowed)
+		}
+	}
+
+	if !(Allowlist{}).Allows(Platform{OS: "plan9", Arch: "arm"}) {
+		t.Errorf("expected an empty allowlist to allow every platform")
+	}
+	if aliased, err := ParseAllowlist([]string{"macos_x86_64"}); err != nil || !aliased.Allows(Platform{OS: "darwin", Arch: "amd64"}) {
+		t.Errorf("expected an entry with aliases to allow darwin_amd64, got %v, %v", aliased, err)
+	}
+	if _, err := ParseAllowlist([]string{"linux"}); err == nil {
+		t.Errorf("expected an error for an entry without an architecture")
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		platform Platform
+		want     Platform
+	}{
+		{Platform{OS: "linux", Arch: "amd64"}, Platform{OS: "linux", Arch: "amd64"}},
+		{Platform{OS: "macOS", Arch: "aarch64"}, Platform{OS: "darwin", Arch: "arm64"}},
+		{Platform{OS: "osx", Arch: "x86_64"}, Platform{OS: "darwin", Arch: "amd64"}},
+		{Platform{OS: "Windows", Arch: "i686"}, Platform{OS: "windows", Arch: "386"}},
+		{Platform{OS: "freebsd", Arch: "riscv64"}, Platform{OS: "freebsd", Arch: "riscv64"}},
+	}
+	for _, test := range tests {
+		if got := Normalize(test.platform); got != test.want {
+			t.Errorf("Normalize(%v) = %v, want %v", test.platform, got, test.want)
+		}
+	}
+
+	if got := ExtractPlatformFromArtifact("my-provider_0.0.1_macos_x86_64.zip"); got == nil || *got != (Platform{OS: "darwin", Arch: "amd64"}) {
+		t.Errorf("ExtractPlatformFromArtifact() of an archive named with aliases = %v, want darwin_amd64", got)
+	}
+}
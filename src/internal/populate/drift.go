@@ -0,0 +1,258 @@
+package populate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/github"
+	"github.com/opentofu/registry/internal/metrics"
+	"github.com/opentofu/registry/internal/providers"
+	"github.com/opentofu/registry/internal/providers/providercache"
+	"github.com/opentofu/registry/internal/providers/types"
+	"github.com/opentofu/registry/internal/quarantine"
+	"github.com/opentofu/registry/internal/quota"
+	"github.com/opentofu/registry/internal/version"
+	"golang.org/x/exp/slog"
+)
+
+// The kinds of drift between the cache and GitHub, the `kind` label of metrics.CacheDrift.
+const (
+	// driftMissingVersion is a release on GitHub whose version is not cached.
+	driftMissingVersion = "missing_version"
+	// driftDeletedRelease is a cached version whose release no longer exists on GitHub.
+	driftDeletedRelease = "deleted_release"
+	// driftStaleChecksum is a cached checksum which is not the one in the published SHA256SUMS anymore.
+	driftStaleChecksum = "stale_checksum"
+)
+
+// driftReport is how the cached versions of a provider differ from its releases on GitHub.
+type driftReport struct {
+	Missing        types.VersionList
+	Deleted        []string
+	StaleChecksums []string
+}
+
+func (r driftReport) empty() bool {
+	return len(r.Missing) == 0 && len(r.Deleted) == 0 && len(r.StaleChecksums) == 0
+}
+
+// checkDrift compares the cached versions of the provider of the event, or of a sample of the cached providers, with
+// their releases on GitHub. The differences are logged and counted, and the missing and deleted versions are fixed
+// in the cache if auto-healing is enabled.
+func checkDrift(ctx context.Context, e Event, config *config.Config) error {
+	keys, err := sampleKeys(ctx, e, config)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("Checking providers for drift", "providers", len(keys))
+	healed := make([]*providercache.Entry, len(keys))
+	forEach(ctx, len(keys), config.RefreshWorkers, func(ctx context.Context, i int) {
+		namespace, providerType, _ := strings.Cut(keys[i], "/")
+		// a failure to check one provider should not prevent checking the others
+		entry, err := checkProviderDrift(ctx, config, namespace, providerType)
+		if err != nil {
+			slog.Error("Error checking provider for drift", "provider", keys[i], "error", err)
+		}
+		healed[i] = entry
+	})
+
+	var entries []providercache.Entry
+	for _, entry := range healed {
+		if entry != nil {
+			entries = append(entries, *entry)
+		}
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	slog.Info("Healing cache", "providers", len(entries))
+	if err := config.ProviderVersionCache.StoreBatch(ctx, entries); err != nil {
+		return fmt.Errorf("could not heal the cache: %w", err)
+	}
+	return nil
+}
+
+// checkProviderDrift checks a provider for drift, and returns the entry healing it if auto-healing is enabled and the
+// cache has missing or deleted versions, nil otherwise.
+//
+//nolint:nilnil // Most providers do not need healing.
+func checkProviderDrift(ctx context.Context, config *config.Config, namespace, providerType string) (*providercache.Entry, error) {
+	logger := slog.Default().With("namespace", namespace, "type", providerType)
+
+	// OCI artifacts are not released on GitHub
+	if _, ok := config.OCIProvider(namespace, providerType); ok {
+		logger.Info("Skipping drift check of OCI provider")
+		return nil, nil
+	}
+
+	key := fmt.Sprintf("%s/%s", namespace, providerType)
+	document, err := config.ProviderVersionCache.GetItem(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if document == nil || document.Metadata.NotFound {
+		logger.Info("Provider is not cached or does not exist, skipping drift check")
+		return nil, nil
+	}
+
+	budget := quota.NewBudget(namespace, config.IngestionLimits(namespace))
+	ctx = quota.WithBudget(ctx, budget)
+
+	source, err := config.ProviderSource(ctx, namespace, providerType)
+	if err != nil {
+		return nil, err
+	}
+	releases, err := source.Host.Releases(ctx, source.Owner, source.Name, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch releases: %w", err)
+	}
+
+	report := driftReport{Deleted: deletedVersions(document.Versions, releases)}
+
+	// the releases failing to process are not cached by the refreshes either, so only the ones with versions count
+	unknown := unknownReleases(document.Versions, releases)
+	if len(unknown) > 0 {
+		missing, versionsErr := providers.VersionsFromReleases(ctx, unknown, config.AssetPatterns(namespace, providerType))
+		if versionsErr != nil {
+			return nil, fmt.Errorf("could not build the missing versions: %w", versionsErr)
+		}
+		report.Missing = missing.WithPlatforms(config.PlatformAllowlist(namespace, providerType))
+	}
+
+	for _, v := range quarantine.Newest(document.Versions, config.ChecksumReconcileVersions) {
+		if len(v.DownloadDetails) == 0 {
+			continue
+		}
+		published, checksumsErr := providers.DownloadChecksums(ctx, v.DownloadDetails[0].SHASumsURL)
+		if checksumsErr != nil {
+			logger.Warn("Could not download the published checksums", "version", v.Version, "error", checksumsErr)
+			continue
+		}
+		if len(quarantine.CompareChecksums(v, published)) > 0 {
+			report.StaleChecksums = append(report.StaleChecksums, v.Version)
+		}
+	}
+
+	// an incomplete check would report the versions it did not get to as drift
+	if budget.Exhausted() {
+		logger.Warn("Ingestion quota exceeded, drift check is inconclusive")
+		return nil, nil
+	}
+
+	labels := metrics.Labels{"namespace": namespace, "type": providerType}
+	metrics.Add(metrics.CacheDrift, withKind(labels, driftMissingVersion), float64(len(report.Missing)))
+	metrics.Add(metrics.CacheDrift, withKind(labels, driftDeletedRelease), float64(len(report.Deleted)))
+	metrics.Add(metrics.CacheDrift, withKind(labels, driftStaleChecksum), float64(len(report.StaleChecksums)))
+
+	if report.empty() {
+		logger.Info("Cache matches GitHub")
+		return nil, nil
+	}
+	logger.Warn("Cache drifted from GitHub", "missing", versionNumbers(report.Missing), "deleted", report.Deleted, "stale_checksums", report.StaleChecksums)
+
+	// stale checksums are left to the quarantine, re-uploaded assets must not be trusted automatically
+	if !config.DriftAutoHeal || (len(report.Missing) == 0 && len(report.Deleted) == 0) {
+		return nil, nil
+	}
+	logger.Info("Cache will be healed")
+	// the stored listing has the versions before healing, so it is rendered by the API until the next refresh
+	metadata := document.Metadata
+	metadata.Listing = ""
+	return &providercache.Entry{Key: key, Versions: healVersions(document.Versions, report), Metadata: metadata}, nil
+}
+
+// errNoProviders is returned by sampleKeys for an event without a provider or a sample size.
+var errNoProviders = errors.New("a provider or a sample size is needed")
+
+// sampleKeys returns the key of the provider of the event or, if it has none, the keys of Sample providers picked at
+// random from the cache.
+func sampleKeys(ctx context.Context, e Event, config *config.Config) ([]string, error) {
+	switch {
+	case e.Namespace != "" || e.Type != "":
+		if err := e.Validate(); err != nil {
+			return nil, err
+		}
+		return []string{fmt.Sprintf("%s/%s", e.Namespace, e.Type)}, nil
+	case e.Sample > 0:
+		keys, err := config.ProviderVersionCache.Sample(ctx, e.Sample)
+		if err != nil {
+			return nil, fmt.Errorf("could not sample the cache: %w", err)
+		}
+		return keys, nil
+	default:
+		return nil, errNoProviders
+	}
+}
+
+func withKind(labels metrics.Labels, kind string) metrics.Labels {
+	withKind := metrics.Labels{"kind": kind}
+	for name, value := range labels {
+		withKind[name] = value
+	}
+	return withKind
+}
+
+// deletedVersions returns the cached versions without a release, sorted.
+func deletedVersions(cached types.VersionList, releases []github.GHRelease) []string {
+	released := make(map[string]bool, len(releases))
+	for _, r := range releases {
+		if v, ok := version.FromTag(r.TagName); ok {
+			released[v] = true
+		}
+	}
+
+	var deleted []string
+	for _, v := range cached {
+		if !released[v.Version] {
+			deleted = append(deleted, v.Version)
+		}
+	}
+	sort.Strings(deleted)
+	return deleted
+}
+
+// unknownReleases returns the releases whose version is not cached.
+func unknownReleases(cached types.VersionList, releases []github.GHRelease) []github.GHRelease {
+	known := make(map[string]bool, len(cached))
+	for _, v := range cached {
+		known[v.Version] = true
+	}
+
+	var unknown []github.GHRelease
+	for _, r := range releases {
+		if v, ok := version.FromTag(r.TagName); ok && !known[v] {
+			unknown = append(unknown, r)
+		}
+	}
+	return unknown
+}
+
+// healVersions returns the cached versions without the deleted ones, and with the missing ones.
+func healVersions(cached types.VersionList, report driftReport) types.VersionList {
+	deleted := make(map[string]bool, len(report.Deleted))
+	for _, v := range report.Deleted {
+		deleted[v] = true
+	}
+
+	healed := make(types.VersionList, 0, len(cached)+len(report.Missing))
+	for _, v := range cached {
+		if !deleted[v.Version] {
+			healed = append(healed, v)
+		}
+	}
+	return append(healed, report.Missing...)
+}
+
+func versionNumbers(versions types.VersionList) []string {
+	numbers := make([]string, len(versions))
+	for i, v := range versions {
+		numbers[i] = v.Version
+	}
+	sort.Strings(numbers)
+	return numbers
+}
@@ -0,0 +1,28 @@
+package populate
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/opentofu/registry/internal/github"
+	"github.com/opentofu/registry/internal/providers/types"
+)
+
+func TestDrift(t *testing.T) {
+	cached := types.VersionList{{Version: "1.0.0"}, {Version: "1.1.0"}, {Version: "1.2.0"}}
+	releases := []github.GHRelease{{TagName: "v1.0.0"}, {TagName: "v1.2.0"}, {TagName: "v1.3.0"}, {TagName: "not-a-version"}}
+
+	if deleted := deletedVersions(cached, releases); !reflect.DeepEqual(deleted, []string{"1.1.0"}) {
+		t.Errorf("expected 1.1.0 to be deleted, got %v", deleted)
+	}
+
+	unknown := unknownReleases(cached, releases)
+	if len(unknown) != 1 || unknown[0].TagName != "v1.3.0" {
+		t.Errorf("expected v1.3.0 to be unknown, got %v", unknown)
+	}
+
+	healed := healVersions(cached, driftReport{Missing: types.VersionList{{Version: "1.3.0"}}, Deleted: []string{"1.1.0"}})
+	if got := versionNumbers(healed); !reflect.DeepEqual(got, []string{"1.0.0", "1.2.0", "1.3.0"}) {
+		t.Errorf("unexpected healed versions %v", got)
+	}
+}
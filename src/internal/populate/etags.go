@@ -0,0 +1,57 @@
+package populate
+
+import (
+	"context"
+
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/etags"
+	"github.com/opentofu/registry/internal/github"
+	"golang.org/x/exp/slog"
+)
+
+// checkReleasesETag returns whether the releases of the provider of the event changed since the last refresh, and the
+// current ETag of its releases, to remember once the refresh succeeds. Without an ETag store, or if the check fails,
+// the releases are fetched as if they changed. The releases on GitLab have no ETag, so they are always fetched.
+func checkReleasesETag(ctx context.Context, e Event, config *config.Config, source config.ProviderSource) (bool, string) {
+	if config.ReleaseETags == nil {
+		return true, ""
+	}
+	if _, ok := config.GitLabGroup(e.Namespace); ok {
+		return true, ""
+	}
+
+	stored, err := config.ReleaseETags.Get(ctx, releasesETagKey(config, source))
+	if err != nil {
+		slog.Error("Error getting releases ETag", "error", err)
+		return true, ""
+	}
+
+	changed, current, err := github.ReleasesChanged(ctx, config.GithubClient(source.Owner), source.Owner, source.Name, stored)
+	if err != nil {
+		slog.Error("Error checking whether releases changed", "error", err)
+		return true, ""
+	}
+	return changed, current
+}
+
+// rememberReleasesETag stores the ETag of the releases of the provider of the event, once they have been refreshed. A
+// failure only means the releases are fetched again on the next refresh.
+func rememberReleasesETag(ctx context.Context, config *config.Config, source config.ProviderSource, etag string) {
+	if config.ReleaseETags == nil || etag == "" {
+		return
+	}
+
+	if err := config.ReleaseETags.Put(ctx, releasesETagKey(config, source), etag); err != nil {
+		slog.Error("Error storing releases ETag", "error", err)
+	}
+}
+
+// releasesETagKey returns the key of the ETag of the releases of a provider. The providers released from the same
+// repository with a tag prefix each remember the ETag the releases had on their own last refresh.
+func releasesETagKey(config *config.Config, source config.ProviderSource) string {
+	name := source.Name
+	if source.TagPrefix != "" {
+		name += "@" + source.TagPrefix
+	}
+	return etags.Key(config.TenantName, source.Owner, name)
+}
@@ -0,0 +1,80 @@
+package populate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/metrics"
+	"github.com/opentofu/registry/internal/providers/types"
+	"github.com/opentofu/registry/internal/quarantine"
+	"golang.org/x/exp/slog"
+)
+
+// errRefreshQuarantined is returned when the refreshes of the provider are quarantined.
+var errRefreshQuarantined = errors.New("refreshes are quarantined")
+
+// refreshQuarantined returns true if the refreshes of the provider are quarantined, see recordRefreshFailure. If the
+// quarantines cannot be read, the provider is refreshed: a refresh too many is better than a provider left stale.
+func refreshQuarantined(ctx context.Context, e Event, config *config.Config) bool {
+	if config.QuarantineStore == nil {
+		return false
+	}
+	quarantines, err := config.QuarantineStore.Get(ctx, quarantine.Address(config.TenantName, e.Namespace, e.Type))
+	if err != nil {
+		slog.Error("Error getting quarantines", "error", err)
+		return false
+	}
+	return quarantine.Find(quarantines, quarantine.AllVersions) != nil
+}
+
+// countFailure returns the metadata of the cached provider with the failed refresh counted, and whether it failed
+// often enough in a row for its refreshes to be quarantined.
+func countFailure(metadata types.ProviderMetadata, refreshErr error, threshold int) (types.ProviderMetadata, bool) {
+	metadata.RefreshFailures++
+	metadata.LastRefreshError = refreshErr.Error()
+	return metadata, threshold > 0 && metadata.RefreshFailures >= threshold
+}
+
+// recordRefreshFailure counts the failed refresh in the metadata of the cached provider. Once its refreshes failed
+// config.RefreshQuarantineFailures times in a row, they are quarantined, so that a broken repository, e.g. renamed or
+// with unparseable tags, does not use up the GitHub quota of every run until an operator resolves it. Providers which
+// are not cached yet have nothing to record the failure on. Failures are logged, the refresh failed already.
+func recordRefreshFailure(ctx context.Context, e Event, config *config.Config, document *types.CacheItem, refreshErr error) {
+	if document == nil {
+		return
+	}
+
+	metadata, quarantined := countFailure(document.Metadata, refreshErr, config.RefreshQuarantineFailures)
+	key := fmt.Sprintf("%s/%s", e.Namespace, e.Type)
+	if err := config.ProviderVersionCache.StoreMetadata(ctx, key, metadata); err != nil {
+		slog.Error("Error recording refresh failure", "error", err)
+		return
+	}
+	if !quarantined || config.QuarantineStore == nil {
+		return
+	}
+
+	address := quarantine.Address(config.TenantName, e.Namespace, e.Type)
+	quarantines, err := config.QuarantineStore.Get(ctx, address)
+	if err != nil {
+		slog.Error("Error getting quarantines", "error", err)
+		return
+	}
+	if quarantine.Find(quarantines, quarantine.AllVersions) != nil {
+		return
+	}
+
+	slog.Error("Refreshes failed too many times in a row, quarantining them", "failures", metadata.RefreshFailures)
+	metrics.Inc(metrics.RefreshQuarantines, metrics.Labels{"namespace": e.Namespace, "type": e.Type})
+	quarantines = append(quarantines, quarantine.Quarantine{
+		Version:    quarantine.AllVersions,
+		Reason:     fmt.Sprintf("The last %d refreshes failed, the last one with: %s", metadata.RefreshFailures, refreshErr),
+		DetectedAt: time.Now(),
+	})
+	if err := config.QuarantineStore.Put(ctx, address, quarantines); err != nil {
+		slog.Error("Error storing quarantines", "error", err)
+	}
+}
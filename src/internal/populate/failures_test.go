@@ -0,0 +1,26 @@
+package populate
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/opentofu/registry/internal/providers/types"
+)
+
+func TestCountFailure(t *testing.T) {
+	refreshErr := errors.New("failed to get versions")
+
+	metadata, quarantined := countFailure(types.ProviderMetadata{License: "MPL-2.0", RefreshFailures: 1}, refreshErr, 3)
+	if quarantined || metadata.RefreshFailures != 2 || metadata.LastRefreshError != refreshErr.Error() || metadata.License != "MPL-2.0" {
+		t.Errorf("countFailure() = %+v, %v, want the second failure recorded", metadata, quarantined)
+	}
+
+	metadata, quarantined = countFailure(metadata, refreshErr, 3)
+	if !quarantined || metadata.RefreshFailures != 3 {
+		t.Errorf("countFailure() = %+v, %v, want the third failure to quarantine the refreshes", metadata, quarantined)
+	}
+
+	if _, quarantined = countFailure(metadata, refreshErr, 0); quarantined {
+		t.Error("countFailure() quarantined the refreshes with the quarantine disabled")
+	}
+}
@@ -0,0 +1,81 @@
+package populate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/opentofu/registry/internal/config"
+	"golang.org/x/exp/slog"
+)
+
+// QueueFunc handles a batch of messages of the refresh queue.
+type QueueFunc func(ctx context.Context, sqsEvent events.SQSEvent) (events.SQSEventResponse, error)
+
+// enqueueRefreshes sends a message to the refresh queue for every cached provider, for the worker lambda to refresh
+// them one by one, see HandleQueue. A failing refresh is then retried on its own, and the others do not wait for it.
+func enqueueRefreshes(ctx context.Context, e Event, config *config.Config) error {
+	if config.RefreshQueue == nil {
+		return errors.New("the refresh queue is not configured")
+	}
+
+	keys, err := config.ProviderVersionCache.Keys(ctx)
+	if err != nil {
+		return fmt.Errorf("could not list the cached providers: %w", err)
+	}
+
+	messages := make([]string, 0, len(keys))
+	for _, key := range keys {
+		namespace, providerType, _ := strings.Cut(key, "/")
+		message, err := json.Marshal(Event{Namespace: namespace, Type: providerType, Tenant: e.Tenant})
+		if err != nil {
+			return err
+		}
+		messages = append(messages, string(message))
+	}
+
+	sent, err := config.RefreshQueue.SendAll(ctx, messages)
+	if err != nil {
+		return fmt.Errorf("enqueued %d of %d provider refreshes: %w", sent, len(messages), err)
+	}
+	slog.Info("Enqueued provider refreshes", "providers", sent)
+	return nil
+}
+
+// HandleQueue refreshes the provider of each message of the refresh queue, like HandleRequest does for a single
+// event, up to RefreshWorkers of them at a time. The messages whose refresh failed are reported back to SQS, which
+// delivers them again later, and moves them to the dead-letter queue once they failed too many times.
+func HandleQueue(baseConfig *config.Config) QueueFunc {
+	return handleQueue(HandleRequest(baseConfig), baseConfig.RefreshWorkers)
+}
+
+func handleQueue(handle LambdaFunc, workers int) QueueFunc {
+	return func(ctx context.Context, sqsEvent events.SQSEvent) (events.SQSEventResponse, error) {
+		failed := make([]bool, len(sqsEvent.Records))
+		forEach(ctx, len(sqsEvent.Records), workers, func(ctx context.Context, i int) {
+			record := sqsEvent.Records[i]
+			var e Event
+			err := json.Unmarshal([]byte(record.Body), &e)
+			if err == nil {
+				// the messages only ask for refreshes, never for more messages
+				e.Enqueue = false
+				_, err = handle(ctx, e)
+			}
+			if err != nil {
+				slog.Error("Error handling refresh message", "message_id", record.MessageId, "error", err)
+				failed[i] = true
+			}
+		})
+
+		var response events.SQSEventResponse
+		for i, record := range sqsEvent.Records {
+			if failed[i] {
+				response.BatchItemFailures = append(response.BatchItemFailures, events.SQSBatchItemFailure{ItemIdentifier: record.MessageId})
+			}
+		}
+		return response, nil
+	}
+}
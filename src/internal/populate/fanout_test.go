@@ -0,0 +1,38 @@
+package populate
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestHandleQueue(t *testing.T) {
+	var handled []Event
+	handle := handleQueue(func(ctx context.Context, e Event) (string, error) {
+		handled = append(handled, e)
+		if e.Type == "broken" {
+			return "", errors.New("refresh failed")
+		}
+		return "", nil
+	}, 1)
+
+	response, err := handle(context.Background(), events.SQSEvent{Records: []events.SQSMessage{
+		{MessageId: "1", Body: `{"namespace": "hashicorp", "type": "aws"}`},
+		{MessageId: "2", Body: `{"namespace": "example", "type": "broken"}`},
+		{MessageId: "3", Body: `{`},
+		{MessageId: "4", Body: `{"namespace": "example", "type": "foo", "enqueue": true}`},
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(handled) != 3 || handled[2].Enqueue {
+		t.Errorf("handled %+v, want the three valid messages as refreshes", handled)
+	}
+	failures := response.BatchItemFailures
+	if len(failures) != 2 || failures[0].ItemIdentifier != "2" || failures[1].ItemIdentifier != "3" {
+		t.Errorf("failures = %+v, want messages 2 and 3", failures)
+	}
+}
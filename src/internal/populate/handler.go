@@ -0,0 +1,390 @@
+package populate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/faults"
+	"github.com/opentofu/registry/internal/github"
+	"github.com/opentofu/registry/internal/modules"
+	"github.com/opentofu/registry/internal/oci"
+	"github.com/opentofu/registry/internal/providers"
+	"github.com/opentofu/registry/internal/providers/types"
+	"github.com/opentofu/registry/internal/quota"
+	"github.com/opentofu/registry/internal/snapshot"
+	"golang.org/x/exp/slog"
+)
+
+// errRepoNotFound is returned when the repository of the provider does not exist.
+var errRepoNotFound = errors.New("repository does not exist")
+
+type Event struct {
+	Namespace string `json:"namespace"`
+	Type      string `json:"type"`
+	// Tenant is the name of the tenant registry the provider belongs to, empty for the main registry.
+	Tenant string `json:"tenant,omitempty"`
+	// Force refreshes the cached versions even if they are not stale, e.g. because a release was just published.
+	Force bool `json:"force,omitempty"`
+	// Enqueue sends a message to the refresh queue for every cached provider instead of refreshing one, see
+	// HandleQueue.
+	Enqueue bool `json:"enqueue,omitempty"`
+	// Replay rebuilds the cached versions from the ingestion snapshot of the provider instead of querying GitHub.
+	Replay bool `json:"replay,omitempty"`
+	// Drift compares the cached versions with GitHub instead of refreshing them, for the provider of the event or, if
+	// it has none, for Sample providers picked at random from the cache.
+	Drift  bool `json:"drift,omitempty"`
+	Sample int  `json:"sample,omitempty"`
+	// MirrorReconcile checks the asset mirror objects of the cached versions instead of refreshing them, for the
+	// provider of the event or for Sample providers, see reconcileMirror.
+	MirrorReconcile bool `json:"mirror_reconcile,omitempty"`
+	// Module refreshes the cached versions of the module repository instead of a provider, see refreshModule.
+	Module *modules.Repository `json:"module,omitempty"`
+}
+
+func (p Event) Validate() error {
+	if p.Namespace == "" {
+		return fmt.Errorf("namespace is required")
+	}
+	if p.Type == "" {
+		return fmt.Errorf("type is required")
+	}
+	return nil
+}
+
+type LambdaFunc func(ctx context.Context, e Event) (string, error)
+
+func setupLogging(e Event) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	logger = logger.
+		With("namespace", e.Namespace).
+		With("type", e.Type).
+		With("tenant", e.Tenant)
+	slog.SetDefault(logger)
+}
+
+func HandleRequest(baseConfig *config.Config) LambdaFunc {
+	return func(ctx context.Context, e Event) (string, error) {
+		setupLogging(e)
+
+		// the cache must not change while the registry is in maintenance
+		if baseConfig.MaintenanceMode {
+			slog.Warn("Registry is in maintenance mode, skipping refresh")
+			return "", nil
+		}
+
+		// each tenant has its own cache table, so scope the config to the tenant the event was raised for
+		tenantConfig := baseConfig.ForTenant(e.Tenant)
+		config := &tenantConfig
+		ctx = faults.WithInjector(ctx, config.Faults)
+		ctx = github.WithRESTFallback(ctx, config.GithubClient)
+
+		if e.Enqueue {
+			if err := enqueueRefreshes(ctx, e, config); err != nil {
+				slog.Error("Error enqueueing provider refreshes", "error", err)
+				return "", err
+			}
+			return "", nil
+		}
+
+		if e.Module != nil {
+			if err := refreshModule(ctx, e, config); err != nil {
+				slog.Error("Error refreshing module versions", "error", err)
+				return "", err
+			}
+			return "", nil
+		}
+
+		if e.Drift {
+			if err := checkDrift(ctx, e, config); err != nil {
+				slog.Error("Error checking for drift", "error", err)
+				return "", err
+			}
+			return "", nil
+		}
+
+		if e.MirrorReconcile {
+			if err := reconcileMirror(ctx, e, config); err != nil {
+				slog.Error("Error reconciling the asset mirror", "error", err)
+				return "", err
+			}
+			return "", nil
+		}
+
+		if e.Replay {
+			if err := e.Validate(); err != nil {
+				slog.Error("invalid event", "error", err)
+				return "", fmt.Errorf("invalid event: %w", err)
+			}
+			if err := replayVersions(ctx, e, config); err != nil {
+				slog.Error("Error replaying ingestion snapshot", "error", err)
+				return "", err
+			}
+			return "", nil
+		}
+
+		// fetched are the versions this refresh fetched, which versions adds to the cached ones
+		var versions, fetched, cached types.VersionList
+		var releasesETag string
+		// previous is the cached item the refresh started from, which its failure is recorded on
+		var previous *types.CacheItem
+
+		// charge the GitHub calls and asset downloads of this refresh to the quota of the namespace
+		budget := quota.NewBudget(e.Namespace, config.IngestionLimits(e.Namespace))
+		ctx = quota.WithBudget(ctx, budget)
+
+		// keep the releases fetched from GitHub, so that the versions can be rebuilt from them later
+		var recorder *snapshot.Recorder
+		if config.IngestionSnapshots != nil {
+			recorder = &snapshot.Recorder{}
+			ctx = snapshot.WithRecorder(ctx, recorder)
+		}
+
+		// the repository of the provider, looked up once for the whole refresh
+		source, err := config.ProviderSource(ctx, e.Namespace, e.Type)
+		if err != nil {
+			slog.Error("Error looking up the repository of the provider", "error", err)
+			return "", err
+		}
+
+		slog.Info("Populating provider versions")
+		err = xray.Capture(ctx, "populate_provider_versions.handle", func(tracedCtx context.Context) error {
+			xray.AddAnnotation(tracedCtx, "namespace", e.Namespace)
+			xray.AddAnnotation(tracedCtx, "type", e.Type)
+
+			err := e.Validate()
+			if err != nil {
+				slog.Error("invalid event", "error", err)
+				return fmt.Errorf("invalid event: %w", err)
+			}
+
+			var since *time.Time
+
+			// check if the document exists in dynamodb, if it does, and it's newer than the allowed max age,
+			// we should treat it as a noop and just return
+			document, err := config.ProviderVersionCache.GetItem(tracedCtx, fmt.Sprintf("%s/%s", e.Namespace, e.Type))
+			if err != nil {
+				// if there was an error getting the document, that's fine. we'll just log it and carry on
+				slog.Error("Error getting document from cache", "error", err)
+			}
+			if document != nil && !document.IsStale(config.CacheTTL) && !e.Force {
+				slog.Info("Document is up to date, not updating")
+				return nil
+			}
+			if document != nil && document.Metadata.NotFound {
+				// the provider may have been published since, which is checked from scratch
+				document = nil
+			}
+			if refreshQuarantined(tracedCtx, e, config) {
+				return errRefreshQuarantined
+			}
+			previous = document
+			if document != nil {
+				cached = document.Versions
+				if document.Metadata.Incomplete {
+					// the last run ran out of quota, so there may be releases older than its update we have not seen
+					slog.Info("Document is incomplete, fetching the remaining versions", "last_updated", document.LastUpdated)
+				} else {
+					slog.Info("Document is stale, fetching versions", "last_updated", document.LastUpdated)
+					since = &document.LastUpdated
+				}
+			}
+
+			var fetchedVersions types.VersionList
+			if ref, ok := config.OCIProvider(e.Namespace, e.Type); ok {
+				fetchedVersions, err = fetchFromOCI(tracedCtx, config, ref, document)
+			} else {
+				var changed bool
+				changed, releasesETag = checkReleasesETag(tracedCtx, e, config, source)
+				// only a refresh looking for new releases can be skipped, the others need all of them
+				if !changed && since != nil {
+					slog.Info("Releases did not change since the last refresh, not fetching them")
+				} else {
+					fetchedVersions, err = fetchFromGithub(tracedCtx, e, config, source, since, document)
+				}
+			}
+			if err != nil {
+				return err
+			}
+			fetched = fetchedVersions
+
+			// if we have a document, we should combine the fetched versions with the existing versions
+			// this is so that we don't lose any versions that were added since the last time we fetched
+			// but also so we don't add duplicates
+			if document != nil {
+				fetchedVersions = append(document.Versions, fetchedVersions...)
+				slog.Info("Combined versions", "versions", len(fetchedVersions))
+
+				// deduplicate the versions
+				fetchedVersions = fetchedVersions.Deduplicate()
+				slog.Info("Deduplicated versions", "versions", len(fetchedVersions))
+			}
+
+			versions = fetchedVersions
+			return nil
+		})
+
+		if errors.Is(err, errRepoNotFound) {
+			// recorded so that the requests for the provider do not check GitHub again for a while
+			slog.Info("Repo does not exist, recording the provider as missing")
+			return "", storeNotFound(ctx, e, config)
+		}
+		if errors.Is(err, errRefreshQuarantined) {
+			// not an error, so that queued refreshes are not retried
+			slog.Warn("Refreshes of the provider are quarantined, not refreshing")
+			return "", nil
+		}
+		if err != nil {
+			slog.Error("Error fetching versions", "error", err)
+			recordRefreshFailure(ctx, e, config, previous, err)
+			return "", err
+		}
+
+		if recorder != nil {
+			saveSnapshot(ctx, e, config, recorder)
+		}
+
+		// checked before fetching the metadata, which may run into the quota too
+		incomplete := budget.Exhausted()
+		if incomplete {
+			slog.Warn("Ingestion quota exceeded, the remaining versions will be fetched on the next run")
+		}
+		// the downloads of cached versions are served from the asset mirror, so their assets must be there first
+		if _, oci := config.OCIProvider(e.Namespace, e.Type); config.AssetMirrorUploader != nil && !oci {
+			var unmirrored bool
+			versions, fetched, unmirrored = mirrorVersions(ctx, e, config, versions, fetched)
+			incomplete = incomplete || unmirrored
+		}
+		metadata := fetchMetadata(ctx, e, config, source)
+		metadata.Incomplete = incomplete
+
+		if previous != nil {
+			err = appendVersions(ctx, e, versions, fetched, metadata, config)
+		} else {
+			err = storeVersions(ctx, e, versions, metadata, config)
+		}
+		if err != nil {
+			return "", err
+		}
+		// the releases left for the next run must not be skipped by it
+		if !incomplete {
+			rememberReleasesETag(ctx, config, source, releasesETag)
+		}
+
+		reconcileChecksums(ctx, e, config, cached)
+
+		return "", nil
+	}
+}
+
+func storeVersions(ctx context.Context, e Event, versions types.VersionList, metadata types.ProviderMetadata, config *config.Config) error {
+	if len(versions) == 0 {
+		slog.Error("No versions found, skipping storage")
+		return nil
+	}
+
+	key := fmt.Sprintf("%s/%s", e.Namespace, e.Type)
+	metadata.Listing = storeListing(ctx, e, config, versions)
+
+	err := config.ProviderVersionCache.Store(ctx, key, versions, metadata)
+	if err != nil {
+		return fmt.Errorf("failed to store provider listing: %w", err)
+	}
+	return nil
+}
+
+// appendVersions stores the versions fetched by the refresh of a cached provider, merged into the item as it is when
+// it is written rather than as the refresh read it, so that the versions stored by a concurrent refresh are not lost.
+// versions is the listing the refresh expects to end up with, which the large listing is rendered from.
+func appendVersions(ctx context.Context, e Event, versions, fetched types.VersionList, metadata types.ProviderMetadata, config *config.Config) error {
+	key := fmt.Sprintf("%s/%s", e.Namespace, e.Type)
+	metadata.Listing = storeListing(ctx, e, config, versions)
+
+	if err := config.ProviderVersionCache.AppendVersions(ctx, key, fetched, metadata); err != nil {
+		return fmt.Errorf("failed to append provider versions: %w", err)
+	}
+	return nil
+}
+
+func storeNotFound(ctx context.Context, e Event, config *config.Config) error {
+	key := fmt.Sprintf("%s/%s", e.Namespace, e.Type)
+	if err := config.ProviderVersionCache.Store(ctx, key, nil, types.ProviderMetadata{NotFound: true}); err != nil {
+		return fmt.Errorf("failed to store missing provider: %w", err)
+	}
+	return nil
+}
+
+// fetchMetadata looks up the provider metadata we record alongside the versions. Failing to do so should not prevent
+// the versions from being stored, so errors are only logged.
+func fetchMetadata(ctx context.Context, e Event, config *config.Config, source config.ProviderSource) types.ProviderMetadata {
+	var metadata types.ProviderMetadata
+	if _, ok := config.OCIProvider(e.Namespace, e.Type); ok {
+		return metadata
+	}
+	// the archived flag and the license are only known for the repositories on GitHub
+	if _, ok := config.GitLabGroup(e.Namespace); ok {
+		return metadata
+	}
+
+	info, err := github.GetRepositoryInfo(ctx, config.GithubClient(source.Owner), source.Owner, source.Name)
+	if err != nil || info == nil {
+		slog.Error("Failed to get repository details", "error", err)
+		return metadata
+	}
+	metadata.Archived = info.Archived
+	metadata.License = info.License
+	return metadata
+}
+
+func fetchFromGithub(ctx context.Context, e Event, config *config.Config, source config.ProviderSource, since *time.Time, document *types.CacheItem) (types.VersionList, error) {
+	// if we already have a document we don't have to check if the repo exists
+	// we can assume that it does because we've already fetched versions from it before
+	if document == nil {
+		// check the repo exists
+		exists, err := source.Host.RepositoryExists(ctx, source.Owner, source.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check if repo exists: %w", err)
+		}
+		if !exists {
+			return nil, fmt.Errorf("repo %s/%s: %w", source.Owner, source.Name, errRepoNotFound)
+		}
+	} else {
+		slog.Info("Skipping repo existence check because we already have a document in dynamodb")
+	}
+
+	slog.Info("Fetching versions")
+
+	// releases we already have are not processed again, which matters when catching up on an incomplete document
+	var known types.VersionList
+	if document != nil {
+		known = document.Versions
+	}
+
+	v, err := providers.GetVersions(ctx, source.Host, source.Owner, source.Name, since, known, config.AssetPatterns(e.Namespace, e.Type))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get versions: %w", err)
+	}
+
+	return v, nil
+}
+
+func fetchFromOCI(ctx context.Context, config *config.Config, ref oci.Reference, document *types.CacheItem) (types.VersionList, error) {
+	// only fetch the manifests of tags we haven't seen yet, the existing versions are combined with the new ones
+	var known types.VersionList
+	if document != nil {
+		known = document.Versions
+	}
+
+	slog.Info("Fetching versions from OCI registry", "repository", ref.String())
+
+	v, err := providers.GetVersionsFromOCI(ctx, config.OCIClient, ref, known)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get versions from OCI registry: %w", err)
+	}
+
+	return v, nil
+}
@@ -0,0 +1,100 @@
+package populate
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/modules"
+	"github.com/opentofu/registry/internal/modules/modulecache"
+	"golang.org/x/exp/slog"
+)
+
+// InProcess refreshes providers and modules in goroutines of the current process instead of invoking the Lambda. Its Populate
+// method plugs into config.Config.PopulateProviderVersions.
+type InProcess struct {
+	handle LambdaFunc
+
+	mu       sync.Mutex
+	inFlight map[string]bool
+	draining bool
+	wg       sync.WaitGroup
+}
+
+func NewInProcess(baseConfig *config.Config) *InProcess {
+	return &InProcess{handle: HandleRequest(baseConfig), inFlight: make(map[string]bool)}
+}
+
+// Populate starts refreshing a provider. Like the asynchronous Lambda invocation, it returns straight away, and a
+// provider which is already being refreshed is not refreshed again in parallel, even if force is set. Once Drain has
+// been called, no new refreshes are started.
+func (p *InProcess) Populate(_ context.Context, namespace, providerType, tenant string, force bool) error {
+	e := Event{Namespace: namespace, Type: providerType, Tenant: tenant, Force: force}
+	if err := e.Validate(); err != nil {
+		return err
+	}
+	p.start(fmt.Sprintf("%s/%s/%s", tenant, namespace, providerType), e)
+	return nil
+}
+
+// PopulateModule starts refreshing the versions of a module repository, like Populate. Its method plugs into
+// config.Config.PopulateModuleVersions.
+func (p *InProcess) PopulateModule(_ context.Context, repo modules.Repository, force bool) error {
+	p.start("module:"+modulecache.Key(repo), Event{Module: &repo, Force: force})
+	return nil
+}
+
+func (p *InProcess) start(key string, e Event) {
+	p.mu.Lock()
+	if p.draining {
+		p.mu.Unlock()
+		slog.Info("Not refreshing while shutting down", "refresh", key)
+		return
+	}
+	if p.inFlight[key] {
+		p.mu.Unlock()
+		return
+	}
+	p.inFlight[key] = true
+	p.wg.Add(1)
+	p.mu.Unlock()
+
+	go func() {
+		defer func() {
+			p.mu.Lock()
+			delete(p.inFlight, key)
+			p.mu.Unlock()
+			p.wg.Done()
+		}()
+
+		// the refresh outlives the request that triggered it
+		if _, err := p.handle(context.Background(), e); err != nil {
+			slog.Error("Error refreshing", "refresh", key, "error", err)
+		}
+	}()
+}
+
+// Drain stops new refreshes from starting and waits for the running ones to finish, or for ctx to be done. A refresh
+// which is cut short leaves the cache as it was, so it is picked up again by the next instance.
+func (p *InProcess) Drain(ctx context.Context) error {
+	p.mu.Lock()
+	p.draining = true
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		p.mu.Lock()
+		running := len(p.inFlight)
+		p.mu.Unlock()
+		return fmt.Errorf("%d refreshes still running: %w", running, ctx.Err())
+	}
+}
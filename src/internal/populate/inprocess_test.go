@@ -0,0 +1,45 @@
+package populate
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestInProcessDrain(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 2)
+	p := &InProcess{
+		handle: func(ctx context.Context, e Event) (string, error) {
+			started <- struct{}{}
+			<-release
+			return "", nil
+		},
+		inFlight: make(map[string]bool),
+	}
+
+	if err := p.Populate(context.Background(), "hashicorp", "aws", "", false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := p.Drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the drain to time out, got %v", err)
+	}
+
+	// no new refreshes once draining
+	if err := p.Populate(context.Background(), "hashicorp", "random", "", false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	close(release)
+	if err := p.Drain(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(started) != 0 {
+		t.Fatalf("expected no refresh to start while draining")
+	}
+}
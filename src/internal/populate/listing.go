@@ -0,0 +1,44 @@
+package populate
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/listings"
+	"github.com/opentofu/registry/internal/providers/types"
+	"golang.org/x/exp/slog"
+)
+
+// storeListing writes the v1 versions listing of the provider to the listings bucket if it is larger than the
+// threshold, and returns the key of its object to record in the metadata, empty if the API renders the listing itself.
+// Failures are only logged, the API then renders the listing as usual.
+func storeListing(ctx context.Context, e Event, config *config.Config, versions types.VersionList) string {
+	if config.LargeListings == nil {
+		return ""
+	}
+
+	listing, err := renderListing(versions.WithPlatforms(config.PlatformAllowlist(e.Namespace, e.Type)))
+	if err != nil {
+		slog.Error("Failed to render versions listing", "error", err)
+		return ""
+	}
+	if len(listing) <= config.LargeListingThreshold {
+		return ""
+	}
+
+	key := listings.Key(config.TenantName, e.Namespace, e.Type)
+	if err := config.LargeListings.Put(ctx, key, listing, "application/json"); err != nil {
+		slog.Error("Failed to store large versions listing", "key", key, "error", err)
+		return ""
+	}
+	slog.Info("Stored large versions listing", "key", key, "size", len(listing))
+	return key
+}
+
+// renderListing renders the versions as the v1 listing does for a request without query parameters.
+func renderListing(versions types.VersionList) ([]byte, error) {
+	return json.Marshal(struct {
+		Versions []types.Version `json:"versions"`
+	}{Versions: types.VersionExtensions{}.ToVersions(versions)})
+}
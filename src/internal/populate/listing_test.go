@@ -0,0 +1,24 @@
+package populate
+
+import (
+	"testing"
+
+	"github.com/opentofu/registry/internal/platform"
+	"github.com/opentofu/registry/internal/providers/types"
+)
+
+func TestRenderListing(t *testing.T) {
+	versions := types.VersionList{
+		{Version: "1.1.0-rc1", Protocols: []string{"5.0"}},
+		{Version: "1.0.0", Protocols: []string{"5.0"}, DownloadDetails: []types.CacheVersionDownloadDetails{{Platform: platform.Platform{OS: "linux", Arch: "amd64"}}}},
+	}
+
+	listing, err := renderListing(versions)
+	if err != nil {
+		t.Fatalf("renderListing() error = %v", err)
+	}
+	want := `{"versions":[{"version":"1.0.0","protocols":["5.0"],"platforms":[{"os":"linux","arch":"amd64"}]}]}`
+	if string(listing) != want {
+		t.Errorf("renderListing() = %s, want %s", listing, want)
+	}
+}
@@ -0,0 +1,45 @@
+package populate
+
+import (
+	"context"
+
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/metrics"
+	"github.com/opentofu/registry/internal/providers/types"
+	"golang.org/x/exp/slog"
+)
+
+// mirrorVersions copies the release assets of the fetched versions into the asset mirror, before they are cached and
+// their downloads pointed at it, and records where the copies are in the versions. The versions whose assets could not
+// be copied are left out of both fetched and versions, and the refresh is reported incomplete, so that the next one
+// fetches them again.
+func mirrorVersions(ctx context.Context, e Event, config *config.Config, versions, fetched types.VersionList) (types.VersionList, types.VersionList, bool) {
+	mirrored := make(map[string]types.CacheVersion, len(fetched))
+	failed := make(map[string]bool)
+	for _, v := range fetched {
+		// the download details are shared with the versions of the other list, which are replaced below instead
+		v.DownloadDetails = append([]types.CacheVersionDownloadDetails(nil), v.DownloadDetails...)
+		if err := config.AssetMirrorUploader.MirrorVersion(ctx, &v); err != nil {
+			slog.Error("Could not mirror the release assets of the version, leaving it out", "version", v.Version, "error", err)
+			metrics.Inc(metrics.AssetMirrorFailures, metrics.Labels{"namespace": e.Namespace, "type": e.Type})
+			failed[v.Version] = true
+			continue
+		}
+		mirrored[v.Version] = v
+	}
+
+	withMirrors := func(l types.VersionList) types.VersionList {
+		kept := make(types.VersionList, 0, len(l))
+		for _, v := range l {
+			if failed[v.Version] {
+				continue
+			}
+			if m, ok := mirrored[v.Version]; ok {
+				v = m
+			}
+			kept = append(kept, v)
+		}
+		return kept
+	}
+	return withMirrors(versions), withMirrors(fetched), len(failed) > 0
+}
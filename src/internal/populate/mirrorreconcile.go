@@ -0,0 +1,149 @@
+package populate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/metrics"
+	"github.com/opentofu/registry/internal/mirror"
+	"github.com/opentofu/registry/internal/providers/providercache"
+	"github.com/opentofu/registry/internal/providers/types"
+	"golang.org/x/exp/slog"
+)
+
+// maxBackfilledVersions caps the versions cached before the asset mirror which are mirrored per provider and run, so
+// that a run over providers with long histories finishes within the timeout of the lambda. The next runs mirror the
+// others.
+const maxBackfilledVersions = 20
+
+// reconcileMirror checks the objects of the asset mirror of the provider of the event, or of a sample of the cached
+// providers, copying again the ones which are missing or corrupted. The versions cached before the registry started
+// mirroring them are mirrored too.
+func reconcileMirror(ctx context.Context, e Event, config *config.Config) error {
+	if config.AssetMirrorUploader == nil {
+		return errors.New("populating the asset mirror is not enabled")
+	}
+	keys, err := sampleKeys(ctx, e, config)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("Reconciling the asset mirror", "providers", len(keys))
+	var failed atomic.Int32
+	forEach(ctx, len(keys), config.RefreshWorkers, func(ctx context.Context, i int) {
+		namespace, providerType, _ := strings.Cut(keys[i], "/")
+		// a failure to reconcile one provider should not prevent reconciling the others
+		if err := reconcileProviderMirror(ctx, config, namespace, providerType); err != nil {
+			slog.Error("Error reconciling the asset mirror of the provider", "provider", keys[i], "error", err)
+			failed.Add(1)
+		}
+	})
+
+	if n := failed.Load(); n > 0 {
+		return fmt.Errorf("could not reconcile the asset mirror of %d of %d providers", n, len(keys))
+	}
+	return nil
+}
+
+func reconcileProviderMirror(ctx context.Context, config *config.Config, namespace, providerType string) error {
+	// the artifacts of OCI providers are not mirrored
+	if _, ok := config.OCIProvider(namespace, providerType); ok {
+		return nil
+	}
+
+	key := fmt.Sprintf("%s/%s", namespace, providerType)
+	document, err := config.ProviderVersionCache.GetItem(ctx, key)
+	if err != nil {
+		return err
+	}
+	if document == nil {
+		return nil
+	}
+
+	logger := slog.With("provider", key)
+	labels := metrics.Labels{"namespace": namespace, "type": providerType}
+	mirrored := make(map[string]types.CacheVersion)
+	var errs []error
+	backfilled := 0
+	for _, v := range document.Versions {
+		if !isMirrored(v) {
+			if backfilled == maxBackfilledVersions {
+				continue
+			}
+			backfilled++
+			v.DownloadDetails = append([]types.CacheVersionDownloadDetails(nil), v.DownloadDetails...)
+			if err := config.AssetMirrorUploader.MirrorVersion(ctx, &v); err != nil {
+				metrics.Inc(metrics.AssetMirrorFailures, labels)
+				errs = append(errs, fmt.Errorf("could not mirror %s: %w", v.Version, err))
+				continue
+			}
+			logger.Info("Mirrored version cached before the asset mirror", "version", v.Version)
+			mirrored[v.Version] = v
+			continue
+		}
+
+		repairs, err := config.AssetMirrorUploader.Reconcile(ctx, v)
+		for _, r := range repairs {
+			metrics.Inc(metrics.AssetMirrorRepairs, withProblem(labels, r.Problem))
+		}
+		if err == nil {
+			err = mirror.Failed(repairs)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("could not reconcile %s: %w", v.Version, err))
+		}
+	}
+
+	if len(mirrored) > 0 {
+		if err := storeMirrored(ctx, config, key, mirrored); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// storeMirrored records the mirrored objects of the versions in the cache. The item is changed as it is written back,
+// the versions could have been refreshed while they were mirrored, keeping its update time so that the next refresh
+// still fetches the releases published since the last one.
+func storeMirrored(ctx context.Context, config *config.Config, key string, mirrored map[string]types.CacheVersion) error {
+	err := config.ProviderVersionCache.UpdateVersions(ctx, key, func(item *types.CacheItem) error {
+		versions := make(types.VersionList, len(item.Versions))
+		for i, v := range item.Versions {
+			versions[i] = v
+			if m, ok := mirrored[v.Version]; ok && !isMirrored(v) {
+				versions[i] = m
+			}
+		}
+		item.Versions = versions
+		return nil
+	})
+	if errors.Is(err, providercache.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not store the mirrored versions: %w", err)
+	}
+	return nil
+}
+
+// isMirrored reports whether the registry mirrored every platform of the version.
+func isMirrored(v types.CacheVersion) bool {
+	for _, d := range v.DownloadDetails {
+		if d.Mirror == nil {
+			return false
+		}
+	}
+	return true
+}
+
+func withProblem(labels metrics.Labels, problem string) metrics.Labels {
+	withProblem := metrics.Labels{"problem": problem}
+	for name, value := range labels {
+		withProblem[name] = value
+	}
+	return withProblem
+}
@@ -0,0 +1,110 @@
+package populate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/github"
+	"github.com/opentofu/registry/internal/metrics"
+	"github.com/opentofu/registry/internal/modules"
+	"golang.org/x/exp/slog"
+)
+
+// maxArchivedVersions caps the archives built per module and refresh, so that the first refresh of a module with a
+// long history finishes within the timeout of the lambda. The next refreshes build the others.
+const maxArchivedVersions = 10
+
+// archiveModuleVersions builds the stable archives of the versions of the module which have neither one nor a tarball
+// attached to their release, see config.Config.ModuleStableArchives, stores them in the asset mirror bucket and records
+// them in the cached versions under key.
+func archiveModuleVersions(ctx context.Context, config *config.Config, repo modules.Repository, key string, versions []modules.Version) error {
+	if !config.ModuleStableArchives || config.AssetMirrorUploader == nil {
+		return nil
+	}
+
+	logger := slog.With("module", key)
+	archives := make(map[string]string)
+	var errs []error
+	for _, v := range versions {
+		if v.Archive != "" || v.TarballAsset != "" {
+			continue
+		}
+		if len(archives)+len(errs) == maxArchivedVersions {
+			break
+		}
+
+		archive, err := archiveModuleVersion(ctx, config, repo, v.Tag)
+		if err != nil {
+			metrics.Inc(metrics.ModuleArchiveFailures, metrics.Labels{"owner": repo.Owner})
+			errs = append(errs, fmt.Errorf("could not archive %s: %w", v.Tag, err))
+			continue
+		}
+		logger.Info("Archived module version", "tag", v.Tag, "archive", archive)
+		archives[v.Tag] = archive
+	}
+
+	if len(archives) > 0 {
+		if err := storeArchives(ctx, config, key, archives); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// archiveModuleVersion normalizes the archive of the tree of the tag and stores it in the asset mirror bucket,
+// returning the URL of its object. The normalized archive goes through a temporary file, S3 needs its length up front.
+func archiveModuleVersion(ctx context.Context, config *config.Config, repo modules.Repository, tag string) (string, error) {
+	source, err := github.DownloadAssetContents(ctx, repo.ArchiveURL(tag))
+	if err != nil {
+		return "", err
+	}
+	defer source.Close()
+
+	file, err := os.CreateTemp("", "module-archive-*.tar.gz")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	checksum, err := modules.NormalizeArchive(file, source)
+	if err != nil {
+		return "", err
+	}
+	size, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return "", err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return config.AssetMirrorUploader.StoreObject(ctx, file, checksum, size)
+}
+
+// storeArchives records the archives of the versions, by tag, in the cache. The item is read again, like in
+// storeMirrored.
+func storeArchives(ctx context.Context, config *config.Config, key string, archives map[string]string) error {
+	item, err := config.ModuleVersionCache.GetItem(ctx, key)
+	if err != nil {
+		return err
+	}
+	if item == nil {
+		return nil
+	}
+
+	versions := make([]modules.Version, len(item.Versions))
+	for i, v := range item.Versions {
+		if archive, ok := archives[v.Tag]; ok && v.Archive == "" {
+			v.Archive = archive
+		}
+		versions[i] = v
+	}
+	if err := config.ModuleVersionCache.Store(ctx, key, versions); err != nil {
+		return fmt.Errorf("could not store the module archives: %w", err)
+	}
+	return nil
+}
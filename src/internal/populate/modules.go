@@ -0,0 +1,55 @@
+package populate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/modules"
+	"github.com/opentofu/registry/internal/modules/modulecache"
+	"golang.org/x/exp/slog"
+)
+
+// refreshModule refreshes the cached versions of the module repository of the event, which the API serves stale in the
+// meantime, see config.Config.ServeStale. Listing the releases of a module costs a single GitHub query, so they are
+// fetched in full rather than since the last refresh. The stable archives of the versions are built afterwards, see
+// archiveModuleVersions.
+func refreshModule(ctx context.Context, e Event, config *config.Config) error {
+	if config.ModuleVersionCache == nil {
+		return errors.New("the module versions cache is not configured")
+	}
+	repo := *e.Module
+	key := modulecache.Key(repo)
+
+	item, err := config.ModuleVersionCache.GetItem(ctx, key)
+	if err != nil {
+		// the versions are fetched in full anyway
+		slog.Warn("Error getting module versions from cache, refreshing them", "module", key, "error", err)
+	} else if item != nil && !item.IsStale(config.ModuleCacheTTL) && !e.Force {
+		slog.Info("Module versions are up to date, not updating", "module", key)
+		return archiveModuleVersions(ctx, config, repo, key, item.Versions)
+	}
+
+	host := config.RepositoryHost(repo)
+	exists, err := host.RepositoryExists(ctx, repo.Owner, repo.Name)
+	if err != nil {
+		return fmt.Errorf("failed to check if repo exists: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("repo %s: %w", key, errRepoNotFound)
+	}
+
+	versions, err := modules.GetVersions(ctx, host, repo.Owner, repo.Name, nil)
+	if err != nil {
+		return fmt.Errorf("failed to fetch module versions: %w", err)
+	}
+	if item != nil {
+		versions = modules.KeepArchives(versions, item.Versions)
+	}
+	if err := config.ModuleVersionCache.Store(ctx, key, versions); err != nil {
+		return fmt.Errorf("failed to store module versions: %w", err)
+	}
+	slog.Info("Refreshed module versions", "module", key, "versions", len(versions))
+	return archiveModuleVersions(ctx, config, repo, key, versions)
+}
@@ -0,0 +1,28 @@
+package populate
+
+import (
+	"context"
+	"sync"
+)
+
+// forEach calls fn for each of the n items, at most workers of them at a time, and waits for all of them to return.
+func forEach(ctx context.Context, n, workers int, fn func(ctx context.Context, i int)) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, workers)
+	for i := 0; i < n; i++ {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			fn(ctx, i)
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,39 @@
+package populate
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestForEach(t *testing.T) {
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+	done := make([]bool, 10)
+
+	forEach(context.Background(), len(done), 3, func(_ context.Context, i int) {
+		mu.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mu.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		done[i] = true
+		mu.Unlock()
+	})
+
+	if maxRunning > 3 {
+		t.Errorf("%d calls ran at once, want at most 3", maxRunning)
+	}
+	for i, ok := range done {
+		if !ok {
+			t.Errorf("item %d was not processed", i)
+		}
+	}
+}
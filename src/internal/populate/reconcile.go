@@ -0,0 +1,69 @@
+package populate
+
+import (
+	"context"
+	"time"
+
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/metrics"
+	"github.com/opentofu/registry/internal/providers"
+	"github.com/opentofu/registry/internal/providers/types"
+	"github.com/opentofu/registry/internal/quarantine"
+	"golang.org/x/exp/slog"
+)
+
+// reconcileChecksums compares the cached checksums of the newest versions with the SHA256SUMS currently published for
+// them. A version whose assets were re-uploaded since we cached it is quarantined, so that we stop serving download
+// details the client would reject, until an operator resolves it. Failures are logged, as they should not prevent the
+// refresh itself.
+func reconcileChecksums(ctx context.Context, e Event, config *config.Config, cached types.VersionList) {
+	if config.QuarantineStore == nil || config.ChecksumReconcileVersions == 0 || len(cached) == 0 {
+		return
+	}
+	// OCI layers are addressed by their digest, so their checksums cannot change
+	if _, ok := config.OCIProvider(e.Namespace, e.Type); ok {
+		return
+	}
+
+	address := quarantine.Address(config.TenantName, e.Namespace, e.Type)
+	quarantines, err := config.QuarantineStore.Get(ctx, address)
+	if err != nil {
+		slog.Error("Error getting quarantines", "error", err)
+		return
+	}
+
+	detected := 0
+	for _, v := range quarantine.Newest(cached, config.ChecksumReconcileVersions) {
+		if quarantine.Find(quarantines, v.Version) != nil || len(v.DownloadDetails) == 0 {
+			continue
+		}
+
+		published, err := providers.DownloadChecksums(ctx, v.DownloadDetails[0].SHASumsURL)
+		if err != nil {
+			slog.Warn("Could not download the published checksums", "version", v.Version, "error", err)
+			continue
+		}
+
+		mismatches := quarantine.CompareChecksums(v, published)
+		if len(mismatches) == 0 {
+			continue
+		}
+
+		slog.Error("Checksum mismatch, quarantining version", "version", v.Version, "mismatches", mismatches)
+		metrics.Inc(metrics.ChecksumMismatches, metrics.Labels{"namespace": e.Namespace, "type": e.Type})
+		quarantines = append(quarantines, quarantine.Quarantine{
+			Version:    v.Version,
+			Reason:     "The published SHA256SUMS no longer match the checksums served by the registry.",
+			Mismatches: mismatches,
+			DetectedAt: time.Now(),
+		})
+		detected++
+	}
+
+	if detected == 0 {
+		return
+	}
+	if err := config.QuarantineStore.Put(ctx, address, quarantines); err != nil {
+		slog.Error("Error storing quarantines", "error", err)
+	}
+}
@@ -0,0 +1,87 @@
+package populate
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/providers"
+	"github.com/opentofu/registry/internal/providers/types"
+	"github.com/opentofu/registry/internal/snapshot"
+	"golang.org/x/exp/slog"
+)
+
+// saveSnapshot merges the releases recorded during a refresh into the snapshot of the provider. Failures are logged,
+// as they should not prevent the refresh itself.
+func saveSnapshot(ctx context.Context, e Event, config *config.Config, recorder *snapshot.Recorder) {
+	releases, complete := recorder.Releases()
+	if len(releases) == 0 {
+		return
+	}
+
+	key := snapshot.Key(config.TenantName, e.Namespace, e.Type)
+	s, err := config.IngestionSnapshots.Get(ctx, key)
+	if err != nil {
+		slog.Error("Error getting ingestion snapshot", "error", err)
+		return
+	}
+	if s == nil {
+		s = &snapshot.Snapshot{Namespace: e.Namespace, Type: e.Type}
+	}
+
+	s.Merge(releases)
+	s.Complete = s.Complete || complete
+	s.UpdatedAt = time.Now()
+
+	if err := config.IngestionSnapshots.Put(ctx, key, s); err != nil {
+		slog.Error("Error storing ingestion snapshot", "error", err)
+		return
+	}
+	slog.Info("Stored ingestion snapshot", "releases", len(s.Releases), "complete", s.Complete)
+}
+
+// replayVersions rebuilds the cached versions of a provider from its snapshot instead of the GitHub API. The assets of
+// the releases are downloaded again, which does not count against the GitHub API rate limit. If the snapshot does not
+// hold all the releases of the provider, the cached versions missing from it are kept.
+func replayVersions(ctx context.Context, e Event, config *config.Config) error {
+	if config.IngestionSnapshots == nil {
+		return fmt.Errorf("ingestion snapshots are not configured")
+	}
+	if _, ok := config.OCIProvider(e.Namespace, e.Type); ok {
+		return fmt.Errorf("OCI providers have no ingestion snapshot")
+	}
+
+	s, err := config.IngestionSnapshots.Get(ctx, snapshot.Key(config.TenantName, e.Namespace, e.Type))
+	if err != nil {
+		return fmt.Errorf("failed to get ingestion snapshot: %w", err)
+	}
+	if s == nil {
+		return fmt.Errorf("no ingestion snapshot found for %s/%s", e.Namespace, e.Type)
+	}
+
+	slog.Info("Replaying ingestion snapshot", "releases", len(s.Releases), "complete", s.Complete, "updated_at", s.UpdatedAt)
+	versions, err := providers.VersionsFromReleases(ctx, s.Releases, config.AssetPatterns(e.Namespace, e.Type))
+	if err != nil {
+		return fmt.Errorf("failed to rebuild versions: %w", err)
+	}
+
+	var metadata types.ProviderMetadata
+	document, err := config.ProviderVersionCache.GetItem(ctx, fmt.Sprintf("%s/%s", e.Namespace, e.Type))
+	if err != nil {
+		slog.Error("Error getting document from cache", "error", err)
+	}
+	if document != nil && !document.Metadata.NotFound {
+		metadata = document.Metadata
+		if !s.Complete {
+			slog.Warn("Ingestion snapshot is incomplete, keeping the cached versions missing from it")
+			versions = append(versions, document.Versions...).Deduplicate()
+		}
+	}
+	if s.Complete {
+		metadata.Incomplete = false
+	}
+
+	slog.Info("Rebuilt versions from ingestion snapshot", "versions", len(versions))
+	return storeVersions(ctx, e, versions, metadata, config)
+}
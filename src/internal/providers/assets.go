@@ -0,0 +1,116 @@
+package providers
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/opentofu/registry/internal/github"
+	"github.com/opentofu/registry/internal/platform"
+	"golang.org/x/exp/slog"
+)
+
+// The asset names of releases built by the standard goreleaser configuration for providers.
+//
+//nolint:gochecknoglobals // These should be treated as constants.
+var (
+	defaultPlatformAssetPattern         = regexp.MustCompile(`_(?P<os>[a-zA-Z0-9]+)_(?P<arch>x86_64|[a-zA-Z0-9]+)\.zip$`)
+	defaultSHASumsAssetPattern          = regexp.MustCompile(`_SHA256SUMS$`)
+	defaultSHASumsSignatureAssetPattern = regexp.MustCompile(`_SHA256SUMS\.sig$`)
+	defaultManifestAssetPattern         = regexp.MustCompile(`_manifest\.json$`)
+)
+
+// AssetPatterns match the release assets of a provider, for providers whose assets are not named the standard way.
+// Patterns left nil match the standard names.
+type AssetPatterns struct {
+	// Platform matches the archive of each platform, with named groups `os` and `arch`.
+	Platform         *regexp.Regexp
+	SHASums          *regexp.Regexp
+	SHASumsSignature *regexp.Regexp
+	Manifest         *regexp.Regexp
+}
+
+// ValidatePlatformPattern makes sure a platform pattern captures the platform of the archives it matches.
+func ValidatePlatformPattern(pattern *regexp.Regexp) error {
+	if pattern.SubexpIndex("os") < 0 || pattern.SubexpIndex("arch") < 0 {
+		return fmt.Errorf("platform pattern %q must have named groups os and arch", pattern.String())
+	}
+	return nil
+}
+
+func (p AssetPatterns) platform() *regexp.Regexp {
+	if p.Platform != nil {
+		return p.Platform
+	}
+	return defaultPlatformAssetPattern
+}
+
+type platformAsset struct {
+	Platform platform.Platform
+	Asset    github.ReleaseAsset
+}
+
+// platformOf returns the normalized platform of the archive with the given file name, false if it is not a platform
+// archive. Archives named with aliases, e.g. `macos_x86_64`, are the archives of the platform the aliases stand for.
+func (p AssetPatterns) platformOf(name string) (platform.Platform, bool) {
+	pattern := p.platform()
+	matches := pattern.FindStringSubmatch(name)
+	if matches == nil {
+		return platform.Platform{}, false
+	}
+	return platform.Normalize(platform.Platform{OS: matches[pattern.SubexpIndex("os")], Arch: matches[pattern.SubexpIndex("arch")]}), true
+}
+
+// platformAssets returns the archive of each platform found in the assets, in the order of the assets.
+func (p AssetPatterns) platformAssets(assets []github.ReleaseAsset) []platformAsset {
+	seen := make(map[platform.Platform]bool)
+	var found []platformAsset
+	for _, asset := range assets {
+		pl, ok := p.platformOf(asset.Name)
+		if !ok {
+			continue
+		}
+		if seen[pl] {
+			slog.Warn("Several assets for the same platform, using the first one", "platform", pl, "asset", asset.Name)
+			continue
+		}
+		seen[pl] = true
+		found = append(found, platformAsset{Platform: pl, Asset: asset})
+	}
+	return found
+}
+
+// platformAsset returns the archive of the given platform, nil if there is none.
+func (p AssetPatterns) platformAsset(assets []github.ReleaseAsset, os, arch string) *github.ReleaseAsset {
+	requested := platform.Normalize(platform.Platform{OS: os, Arch: arch})
+	for _, found := range p.platformAssets(assets) {
+		if found.Platform == requested {
+			return &found.Asset
+		}
+	}
+	return nil
+}
+
+func (p AssetPatterns) shaSumsAsset(assets []github.ReleaseAsset) *github.ReleaseAsset {
+	return findAsset(assets, p.SHASums, defaultSHASumsAssetPattern)
+}
+
+func (p AssetPatterns) shaSumsSignatureAsset(assets []github.ReleaseAsset) *github.ReleaseAsset {
+	return findAsset(assets, p.SHASumsSignature, defaultSHASumsSignatureAssetPattern)
+}
+
+func (p AssetPatterns) manifestAsset(assets []github.ReleaseAsset) *github.ReleaseAsset {
+	return findAsset(assets, p.Manifest, defaultManifestAssetPattern)
+}
+
+func findAsset(assets []github.ReleaseAsset, pattern, defaultPattern *regexp.Regexp) *github.ReleaseAsset {
+	if pattern == nil {
+		pattern = defaultPattern
+	}
+	for _, asset := range assets {
+		if pattern.MatchString(asset.Name) {
+			asset := asset
+			return &asset
+		}
+	}
+	return nil
+}
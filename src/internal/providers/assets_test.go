@@ -0,0 +1,154 @@
+package providers
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/opentofu/registry/internal/github"
+	"github.com/opentofu/registry/internal/providers/types"
+)
+
+func TestAssetPatterns(t *testing.T) {
+	standard := []github.ReleaseAsset{
+		{Name: "terraform-provider-foo_1.0.0_darwin_amd64.zip"},
+		{Name: "terraform-provider-foo_1.0.0_linux_arm64.zip"},
+		{Name: "terraform-provider-foo_1.0.0_manifest.json"},
+		{Name: "terraform-provider-foo_1.0.0_SHA256SUMS"},
+		{Name: "terraform-provider-foo_1.0.0_SHA256SUMS.sig"},
+	}
+	custom := []github.ReleaseAsset{
+		{Name: "foo-1.0.0-darwin-amd64.zip"},
+		{Name: "foo-1.0.0-linux-arm64.zip"},
+		{Name: "foo-1.0.0.sha256sums"},
+		{Name: "foo-1.0.0.sha256sums.asc"},
+	}
+	customPatterns := AssetPatterns{
+		Platform:         regexp.MustCompile(`-(?P<os>[a-z]+)-(?P<arch>[a-z0-9]+)\.zip$`),
+		SHASums:          regexp.MustCompile(`\.sha256sums$`),
+		SHASumsSignature: regexp.MustCompile(`\.sha256sums\.asc$`),
+	}
+
+	tests := []struct {
+		name      string
+		patterns  AssetPatterns
+		assets    []github.ReleaseAsset
+		platforms int
+		archive   string
+		shaSums   string
+		signature string
+	}{
+		{
+			name:      "standard names",
+			assets:    standard,
+			platforms: 2,
+			archive:   "terraform-provider-foo_1.0.0_linux_arm64.zip",
+			shaSums:   "terraform-provider-foo_1.0.0_SHA256SUMS",
+			signature: "terraform-provider-foo_1.0.0_SHA256SUMS.sig",
+		},
+		{
+			name:      "custom names",
+			patterns:  customPatterns,
+			assets:    custom,
+			platforms: 2,
+			archive:   "foo-1.0.0-linux-arm64.zip",
+			shaSums:   "foo-1.0.0.sha256sums",
+			signature: "foo-1.0.0.sha256sums.asc",
+		},
+		{
+			name:   "standard patterns do not match custom names",
+			assets: custom,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := len(tt.patterns.platformAssets(tt.assets)); got != tt.platforms {
+				t.Errorf("platformAssets() found %d platforms, want %d", got, tt.platforms)
+			}
+			if got := assetName(tt.patterns.platformAsset(tt.assets, "linux", "arm64")); got != tt.archive {
+				t.Errorf("platformAsset() = %q, want %q", got, tt.archive)
+			}
+			if got := assetName(tt.patterns.shaSumsAsset(tt.assets)); got != tt.shaSums {
+				t.Errorf("shaSumsAsset() = %q, want %q", got, tt.shaSums)
+			}
+			if got := assetName(tt.patterns.shaSumsSignatureAsset(tt.assets)); got != tt.signature {
+				t.Errorf("shaSumsSignatureAsset() = %q, want %q", got, tt.signature)
+			}
+		})
+	}
+}
+
+func TestValidatePlatformPattern(t *testing.T) {
+	if err := ValidatePlatformPattern(regexp.MustCompile(`_(?P<os>\w+)_(?P<arch>\w+)\.zip$`)); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := ValidatePlatformPattern(regexp.MustCompile(`_(\w+)_(\w+)\.zip$`)); err == nil {
+		t.Errorf("expected an error for a pattern without named groups")
+	}
+}
+
+func assetName(asset *github.ReleaseAsset) string {
+	if asset == nil {
+		return ""
+	}
+	return asset.Name
+}
+
+func TestMissingPlatformAssets(t *testing.T) {
+	patterns := AssetPatterns{}
+	platforms := patterns.platformAssets([]github.ReleaseAsset{
+		{Name: "terraform-provider-foo_1.0.0_linux_amd64.zip"},
+		{Name: "terraform-provider-foo_1.0.0_SHA256SUMS"},
+	})
+	shaSums := map[string]string{
+		"terraform-provider-foo_1.0.0_linux_amd64.zip":   "a",
+		"terraform-provider-foo_1.0.0_windows_amd64.zip": "b",
+		"terraform-provider-foo_1.0.0_darwin_arm64.zip":  "c",
+		"terraform-provider-foo_1.0.0_manifest.json":     "d",
+	}
+
+	missing := missingPlatformAssets(patterns, platforms, shaSums)
+	if len(missing) != 2 {
+		t.Fatalf("expected 2 missing platforms, got %+v", missing)
+	}
+	if missing[0].Platform.OS != "darwin" || missing[1].Platform.OS != "windows" {
+		t.Errorf("expected darwin then windows, got %+v", missing)
+	}
+	for _, m := range missing {
+		if m.Reason != types.PlatformAssetMissing {
+			t.Errorf("reason of %v = %q, want %q", m.Platform, m.Reason, types.PlatformAssetMissing)
+		}
+	}
+}
+
+func TestChecksummedPlatforms(t *testing.T) {
+	assets := []github.ReleaseAsset{
+		{Name: "terraform-provider-foo_1.0.0_linux_amd64.zip"},
+		{Name: "terraform-provider-foo_1.0.0_darwin_arm64.zip"},
+		{Name: "terraform-provider-foo_1.0.0_SHA256SUMS"},
+	}
+	shaSums := map[string]string{
+		"terraform-provider-foo_1.0.0_linux_amd64.zip":   "a",
+		"terraform-provider-foo_1.0.0_windows_amd64.zip": "b",
+	}
+
+	available := checksummedPlatforms(AssetPatterns{}, assets, shaSums)
+	if len(available) != 1 || available[0].OS != "linux" || available[0].Arch != "amd64" {
+		t.Errorf("checksummedPlatforms() = %+v, want only linux_amd64, attached and listed", available)
+	}
+}
+
+func TestPlatformAssetAliases(t *testing.T) {
+	assets := []github.ReleaseAsset{
+		{Name: "terraform-provider-foo_1.0.0_macos_aarch64.zip"},
+		{Name: "terraform-provider-foo_1.0.0_linux_x86_64.zip"},
+	}
+
+	patterns := AssetPatterns{}
+	if got := assetName(patterns.platformAsset(assets, "darwin", "arm64")); got != assets[0].Name {
+		t.Errorf("platformAsset(darwin, arm64) = %q, want %q", got, assets[0].Name)
+	}
+	if got := assetName(patterns.platformAsset(assets, "linux", "amd64")); got != assets[1].Name {
+		t.Errorf("platformAsset(linux, amd64) = %q, want %q", got, assets[1].Name)
+	}
+}
@@ -0,0 +1,59 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/opentofu/registry/internal/vcs"
+	"golang.org/x/exp/slog"
+)
+
+// Checksums holds the parsed contents of a release's SHA256SUMS file.
+type Checksums struct {
+	SHASumsURL          string
+	SHASumsSignatureURL string            // Empty if the release does not have a signature.
+	Files               map[string]string // Filename to SHA256 checksum.
+}
+
+// GetChecksums fetches and parses the SHA256SUMS file of a specific provider release hosted on GitHub or GitLab.
+func GetChecksums(ctx context.Context, host vcs.Host, namespace string, name string, version string, patterns AssetPatterns) (checksums *Checksums, err error) {
+	err = xray.Capture(ctx, "provider.checksums", func(tracedCtx context.Context) error {
+		xray.AddAnnotation(tracedCtx, "namespace", namespace)
+		xray.AddAnnotation(tracedCtx, "name", name)
+		xray.AddAnnotation(tracedCtx, "version", version)
+
+		slog.Info("Fetching checksums")
+
+		release, releaseErr := vcs.FindVersionRelease(tracedCtx, host, namespace, name, version)
+		if releaseErr != nil {
+			return fmt.Errorf("failed to find release: %w", releaseErr)
+		}
+		if release == nil {
+			return newFetchError("failed to find release", ErrCodeReleaseNotFound, nil)
+		}
+
+		assets := release.ReleaseAssets.Nodes
+		shaSumsAsset := patterns.shaSumsAsset(assets)
+		if shaSumsAsset == nil {
+			return newFetchError("failed to find shasums asset", ErrCodeSHASumsNotFound, nil)
+		}
+
+		files, shaSumsErr := downloadShaSums(tracedCtx, shaSumsAsset)
+		if shaSumsErr != nil {
+			return newFetchError("failed to download shasums", ErrCodeSHASumsNotFound, shaSumsErr)
+		}
+
+		checksums = &Checksums{
+			SHASumsURL: shaSumsAsset.DownloadURL,
+			Files:      files,
+		}
+		if signatureAsset := patterns.shaSumsSignatureAsset(assets); signatureAsset != nil {
+			checksums.SHASumsSignatureURL = signatureAsset.DownloadURL
+		}
+
+		return nil
+	})
+
+	return checksums, err
+}
@@ -0,0 +1,81 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/opentofu/registry/internal/github"
+)
+
+// releasesHost is a vcs.Host serving the given releases, whose assets are downloaded from their DownloadURL.
+type releasesHost struct {
+	releases []github.GHRelease
+}
+
+func (h releasesHost) RepositoryExists(context.Context, string, string) (bool, error) {
+	return true, nil
+}
+
+func (h releasesHost) Releases(context.Context, string, string, *time.Time) ([]github.GHRelease, error) {
+	return h.releases, nil
+}
+
+func (h releasesHost) AssetURL(_, _, _, asset string) string {
+	return asset
+}
+
+func newTestRelease(tag string, assets ...github.ReleaseAsset) github.GHRelease {
+	release := github.GHRelease{TagName: tag}
+	release.ReleaseAssets.Nodes = assets
+	return release
+}
+
+func TestGetChecksums(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/SHA256SUMS" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte("d8956cc5abcd5d1173b6cc25d5d8ed2c5cc456edab2fddb774a17d45e84820cb  terraform-provider-random_3.5.1_linux_amd64.zip\n" +
+			"5992f11c738812ccd7476d4c607cb8b76dea5aa612be491150c89957ec395ddd  terraform-provider-random_3.5.1_darwin_arm64.zip\n"))
+	}))
+	defer server.Close()
+
+	host := releasesHost{releases: []github.GHRelease{
+		newTestRelease("v3.5.1",
+			github.ReleaseAsset{Name: "terraform-provider-random_3.5.1_SHA256SUMS", DownloadURL: server.URL + "/SHA256SUMS"},
+			github.ReleaseAsset{Name: "terraform-provider-random_3.5.1_SHA256SUMS.sig", DownloadURL: server.URL + "/SHA256SUMS.sig"},
+		),
+		newTestRelease("v3.5.0",
+			github.ReleaseAsset{Name: "terraform-provider-random_3.5.0_SHA256SUMS", DownloadURL: server.URL + "/missing"},
+		),
+		newTestRelease("v3.4.0"),
+	}}
+
+	checksums, err := GetChecksums(context.Background(), host, "hashicorp", "terraform-provider-random", "3.5.1", AssetPatterns{})
+	if err != nil {
+		t.Fatalf("GetChecksums() error = %v", err)
+	}
+	if checksums.SHASumsURL != server.URL+"/SHA256SUMS" || checksums.SHASumsSignatureURL != server.URL+"/SHA256SUMS.sig" {
+		t.Errorf("unexpected URLs %q and %q", checksums.SHASumsURL, checksums.SHASumsSignatureURL)
+	}
+	if len(checksums.Files) != 2 || checksums.Files["terraform-provider-random_3.5.1_linux_amd64.zip"] != "d8956cc5abcd5d1173b6cc25d5d8ed2c5cc456edab2fddb774a17d45e84820cb" {
+		t.Errorf("unexpected checksums %v", checksums.Files)
+	}
+
+	for version, wantCode := range map[string]FetchErrorCode{
+		"3.5.0": ErrCodeSHASumsNotFound,
+		"3.4.0": ErrCodeSHASumsNotFound,
+		"3.3.0": ErrCodeReleaseNotFound,
+	} {
+		_, err := GetChecksums(context.Background(), host, "hashicorp", "terraform-provider-random", version, AssetPatterns{})
+		var fetchErr *FetchError
+		if !errors.As(err, &fetchErr) || fetchErr.Code != wantCode {
+			t.Errorf("GetChecksums(%s) error = %v, want code %d", version, err, wantCode)
+		}
+	}
+}
@@ -1,6 +1,10 @@
 package providers
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/opentofu/registry/internal/platform"
+)
 
 type FetchErrorCode int
 
@@ -16,6 +20,11 @@ type FetchError struct {
 	Inner   error
 	Message string
 	Code    FetchErrorCode
+
+	// Available are the platforms the release has an archive for, and PlatformReason why the requested platform has
+	// none, for ErrCodeAssetNotFound only.
+	Available      []platform.Platform
+	PlatformReason string
 }
 
 func (p *FetchError) Error() string {
@@ -8,18 +8,28 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/ProtonMail/gopenpgp/v2/crypto"
 	"github.com/opentofu/registry/internal/providers/types"
 )
 
-//go:embed keys/*
+//go:embed keys/* tenant_keys
 var keys embed.FS
 
-// KeysForNamespace returns the GPG public keys for the given namespace.
+// KeysForNamespace returns the GPG public keys for the given namespace. Expired keys are returned too, they are served
+// until they are removed from the repository.
 func KeysForNamespace(namespace string) ([]types.GPGPublicKey, error) {
-	dirName := filepath.Join("keys", namespace)
+	return keysInDirectory(filepath.Join("keys", namespace))
+}
+
+// KeysForTenantNamespace returns the GPG public keys for the given namespace of a tenant registry. Tenants have their
+// own key store, so the keys of the main registry are not shared with them.
+func KeysForTenantNamespace(tenant, namespace string) ([]types.GPGPublicKey, error) {
+	return keysInDirectory(filepath.Join("tenant_keys", tenant, namespace))
+}
 
+func keysInDirectory(dirName string) ([]types.GPGPublicKey, error) {
 	entries, err := keys.ReadDir(dirName)
 
 	if err != nil {
@@ -78,15 +88,48 @@ func buildKey(path string) (*types.GPGPublicKey, error) {
 		return nil, fmt.Errorf("could not read key file: %w", err)
 	}
 
+	return ParseArmoredKey(data)
+}
+
+// maxArmoredKeySize caps the size of an ASCII armored public key, real keys are a few KiB.
+const maxArmoredKeySize = 64 << 10
+
+const armoredPublicKeyHeader = "-----BEGIN PGP PUBLIC KEY BLOCK-----"
+
+// ParseArmoredKey parses an ASCII armored GPG public key as submitted by a namespace owner. Private keys are rejected,
+// as the armor is served verbatim to anyone downloading a provider.
+func ParseArmoredKey(data []byte) (*types.GPGPublicKey, error) {
+	if len(data) > maxArmoredKeySize {
+		return nil, fmt.Errorf("ascii armor is larger than %d bytes", maxArmoredKeySize)
+	}
+
 	asciiArmor := string(data)
+	if !strings.HasPrefix(strings.TrimSpace(asciiArmor), armoredPublicKeyHeader) {
+		return nil, fmt.Errorf("ascii armor is not a public key block")
+	}
 
 	key, err := crypto.NewKeyFromArmored(asciiArmor)
 	if err != nil {
 		return nil, fmt.Errorf("could not build public key from ascii armor: %w", err)
 	}
+	if key.IsPrivate() {
+		return nil, fmt.Errorf("ascii armor contains a private key")
+	}
 
 	return &types.GPGPublicKey{
 		ASCIIArmor: asciiArmor,
 		KeyID:      strings.ToUpper(key.GetHexKeyID()),
+		ExpiresAt:  keyExpiry(key),
 	}, nil
 }
+
+// keyExpiry returns when the primary key expires according to its self-signature, or nil if it does not expire.
+func keyExpiry(key *crypto.Key) *time.Time {
+	entity := key.GetEntity()
+	identity := entity.PrimaryIdentity()
+	if identity == nil || identity.SelfSignature == nil || identity.SelfSignature.KeyLifetimeSecs == nil || *identity.SelfSignature.KeyLifetimeSecs == 0 {
+		return nil
+	}
+	expiresAt := entity.PrimaryKey.CreationTime.Add(time.Duration(*identity.SelfSignature.KeyLifetimeSecs) * time.Second)
+	return &expiresAt
+}
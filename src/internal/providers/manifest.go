@@ -3,7 +3,9 @@ package providers
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
+	"regexp"
 
 	"github.com/opentofu/registry/internal/github"
 	"golang.org/x/exp/slog"
@@ -17,8 +19,21 @@ type ManifestMetadata struct {
 	ProtocolVersions []string `json:"protocol_versions"`
 }
 
-func findAndParseManifest(ctx context.Context, assets []github.ReleaseAsset) (*Manifest, error) {
-	manifestAsset := github.FindAssetBySuffix(assets, "_manifest.json")
+// defaultProtocols returns the protocol versions assumed for the releases without a manifest, or whose manifest lists
+// none. Like the legacy registry, they are assumed to support protocol 5.0 only.
+func defaultProtocols() []string {
+	return []string{"5.0"}
+}
+
+// Protocols returns the protocol versions listed by the manifest, the default ones if m is nil or lists none.
+func (m *Manifest) Protocols() []string {
+	if m == nil || len(m.Metadata.ProtocolVersions) == 0 {
+		return defaultProtocols()
+	}
+	return m.Metadata.ProtocolVersions
+}
+
+func findAndParseManifest(ctx context.Context, manifestAsset *github.ReleaseAsset) (*Manifest, error) {
 	if manifestAsset == nil {
 		slog.Warn("No manifest found in release assets")
 		return nil, nil //nolint:nilnil // This is not an error, it just means there is no manifest.
@@ -40,12 +55,26 @@ func findAndParseManifest(ctx context.Context, assets []github.ReleaseAsset) (*M
 	return manifest, nil
 }
 
+// maxManifestSize caps the size of the manifests read. A manifest only lists a few protocol versions.
+const maxManifestSize = 64 << 10
+
+// maxManifestProtocols caps the number of protocol versions a manifest can list.
+const maxManifestProtocols = 16
+
+//nolint:gochecknoglobals // This should be treated as a constant.
+var protocolVersionPattern = regexp.MustCompile(`^[0-9]{1,3}(\.[0-9]{1,3})?$`)
+
+// parseManifestContents parses a manifest published by the provider author. Manifests over maxManifestSize and
+// protocol versions which are not of the `<major>[.<minor>]` form are rejected, as they are served to clients as is.
 func parseManifestContents(assetContents io.ReadCloser) (*Manifest, error) {
-	contents, err := io.ReadAll(assetContents)
+	contents, err := io.ReadAll(io.LimitReader(assetContents, maxManifestSize+1))
 	if err != nil {
 		slog.Error("Failed to read manifest contents")
 		return nil, err
 	}
+	if len(contents) > maxManifestSize {
+		return nil, fmt.Errorf("manifest is larger than %d bytes", maxManifestSize)
+	}
 
 	var manifest *Manifest
 	err = json.Unmarshal(contents, &manifest)
@@ -53,6 +82,19 @@ func parseManifestContents(assetContents io.ReadCloser) (*Manifest, error) {
 		slog.Error("Failed to parse manifest contents")
 		return nil, err
 	}
+	if manifest == nil {
+		return nil, fmt.Errorf("manifest is empty")
+	}
+
+	protocols := manifest.Metadata.ProtocolVersions
+	if len(protocols) > maxManifestProtocols {
+		return nil, fmt.Errorf("manifest lists %d protocol versions, at most %d are allowed", len(protocols), maxManifestProtocols)
+	}
+	for _, protocol := range protocols {
+		if !protocolVersionPattern.MatchString(protocol) {
+			return nil, fmt.Errorf("invalid protocol version %q in manifest", protocol)
+		}
+	}
 
 	return manifest, nil
 }
@@ -0,0 +1,65 @@
+package providers
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestManifestProtocols(t *testing.T) {
+	var missing *Manifest
+	if got := missing.Protocols(); !reflect.DeepEqual(got, []string{"5.0"}) {
+		t.Errorf("Protocols() = %v without a manifest, want the default", got)
+	}
+	if got := (&Manifest{}).Protocols(); !reflect.DeepEqual(got, []string{"5.0"}) {
+		t.Errorf("Protocols() = %v for a manifest listing none, want the default", got)
+	}
+	manifest := &Manifest{Metadata: ManifestMetadata{ProtocolVersions: []string{"6.0"}}}
+	if got := manifest.Protocols(); !reflect.DeepEqual(got, []string{"6.0"}) {
+		t.Errorf("Protocols() = %v, want the listed ones", got)
+	}
+}
+
+func TestParseManifestContents(t *testing.T) {
+	manifest, err := parseManifestContents(io.NopCloser(strings.NewReader(`{"version":1,"metadata":{"protocol_versions":["5.0","6"]}}`)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(manifest.Metadata.ProtocolVersions, []string{"5.0", "6"}) {
+		t.Errorf("unexpected protocol versions %v", manifest.Metadata.ProtocolVersions)
+	}
+
+	invalid := map[string]string{
+		"not JSON":                 `{"version":`,
+		"null":                     `null`,
+		"invalid protocol version": `{"metadata":{"protocol_versions":["5.0<script>"]}}`,
+		"too many protocols":       `{"metadata":{"protocol_versions":[` + strings.Repeat(`"5.0",`, maxManifestProtocols) + `"6.0"]}}`,
+		"too large":                `{"padding":"` + strings.Repeat("a", maxManifestSize) + `"}`,
+	}
+	for name, contents := range invalid {
+		if _, err := parseManifestContents(io.NopCloser(strings.NewReader(contents))); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func FuzzParseManifestContents(f *testing.F) {
+	f.Add(`{"version":1,"metadata":{"protocol_versions":["5.0"]}}`)
+	f.Add(`{"metadata":{"protocol_versions":["6.0","5.0"]},"extra":[1,2,{"a":null}]}`)
+	f.Add(`null`)
+	f.Fuzz(func(t *testing.T, contents string) {
+		manifest, err := parseManifestContents(io.NopCloser(strings.NewReader(contents)))
+		if err != nil {
+			return
+		}
+		if manifest == nil {
+			t.Fatal("expected a manifest or an error")
+		}
+		for _, protocol := range manifest.Metadata.ProtocolVersions {
+			if !protocolVersionPattern.MatchString(protocol) {
+				t.Errorf("invalid protocol version %q", protocol)
+			}
+		}
+	})
+}
@@ -0,0 +1,215 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"golang.org/x/exp/slog"
+
+	"github.com/opentofu/registry/internal/oci"
+	"github.com/opentofu/registry/internal/platform"
+	"github.com/opentofu/registry/internal/providers/types"
+	"github.com/opentofu/registry/internal/version"
+)
+
+// GetVersionFromOCI fetches the details of a provider version published as an OCI artifact.
+//
+// Each version is expected to be tagged with its version number, with or without a "v" prefix. The artifact layers
+// are the files of a regular provider release, named by their `org.opencontainers.image.title` annotation: one
+// `_<os>_<arch>.zip` layer per platform, the `_SHA256SUMS` file, its `.sig` signature and optionally `_manifest.json`.
+//
+// The download URLs returned point to the blobs in the registry, resolved to a location that can be downloaded
+// without credentials.
+func GetVersionFromOCI(ctx context.Context, client *oci.Client, ref oci.Reference, version string, os string, arch string) (versionDetails *types.VersionDetails, err error) {
+	err = xray.Capture(ctx, "provider.oci.versiondetails", func(tracedCtx context.Context) error {
+		xray.AddAnnotation(tracedCtx, "repository", ref.String())
+		xray.AddAnnotation(tracedCtx, "version", version)
+		xray.AddAnnotation(tracedCtx, "OS", os)
+		xray.AddAnnotation(tracedCtx, "arch", arch)
+
+		slog.Info("Fetching version from OCI registry", "repository", ref.String())
+
+		manifest, tag, manifestErr := findOCIManifest(tracedCtx, client, ref, version)
+		if manifestErr != nil {
+			return manifestErr
+		}
+
+		cacheVersion, versionErr := ociCacheVersion(tracedCtx, client, ref, tag, manifest)
+		if versionErr != nil {
+			return versionErr
+		}
+
+		versionDetails = cacheVersion.GetVersionDetails(os, arch)
+		if versionDetails == nil {
+			return &FetchError{
+				Message:        "failed to find asset for platform",
+				Code:           ErrCodeAssetNotFound,
+				Available:      cacheVersion.ToVersion().Platforms,
+				PlatformReason: cacheVersion.UnavailableReason(platform.Platform{OS: os, Arch: arch}),
+			}
+		}
+
+		return ResolveOCIDownloadURLs(tracedCtx, client, ref, versionDetails)
+	})
+
+	return versionDetails, err
+}
+
+// GetVersionsFromOCI lists the versions of a provider published as OCI artifacts, see GetVersionFromOCI for the
+// expected layout. Versions already in known are skipped, so that refreshing a cached listing only fetches the
+// manifests of new tags.
+func GetVersionsFromOCI(ctx context.Context, client *oci.Client, ref oci.Reference, known types.VersionList) (versions types.VersionList, err error) {
+	err = xray.Capture(ctx, "provider.oci.versions", func(tracedCtx context.Context) error {
+		xray.AddAnnotation(tracedCtx, "repository", ref.String())
+
+		tags, tagsErr := client.ListTags(tracedCtx, ref)
+		if tagsErr != nil {
+			return fmt.Errorf("failed to list tags: %w", tagsErr)
+		}
+
+		knownVersions := make(map[string]bool, len(known))
+		for _, v := range known {
+			knownVersions[v.Version] = true
+		}
+
+		for _, tag := range tags {
+			// tags that are not versions, like "latest", are not releases
+			v, ok := version.FromTag(tag)
+			if !ok || knownVersions[v] {
+				continue
+			}
+
+			logger := slog.Default().With("tag", tag)
+			manifest, manifestErr := client.GetManifest(tracedCtx, ref, tag)
+			if manifestErr != nil {
+				logger.Error("Failed to get manifest", "error", manifestErr)
+				continue
+			}
+
+			cacheVersion, versionErr := ociCacheVersion(tracedCtx, client, ref, tag, manifest)
+			if versionErr != nil {
+				logger.Error("Failed to process artifact", "error", versionErr)
+				continue
+			}
+			if len(cacheVersion.DownloadDetails) == 0 {
+				logger.Warn("Artifact has no platform layers, skipping")
+				continue
+			}
+
+			knownVersions[cacheVersion.Version] = true
+			versions = append(versions, *cacheVersion)
+		}
+
+		return nil
+	})
+
+	return versions, err
+}
+
+// ResolveOCIDownloadURLs replaces the blob URLs in the given version details with URLs that can be downloaded without
+// registry credentials.
+func ResolveOCIDownloadURLs(ctx context.Context, client *oci.Client, ref oci.Reference, details *types.VersionDetails) error {
+	for _, u := range []*string{&details.DownloadURL, &details.SHASumsURL, &details.SHASumsSignatureURL} {
+		if *u == "" {
+			continue
+		}
+		digest, ok := blobDigest(ref, *u)
+		if !ok {
+			continue
+		}
+		resolved, err := client.ResolveBlobURL(ctx, ref, digest)
+		if err != nil {
+			return fmt.Errorf("failed to resolve blob %s: %w", digest, err)
+		}
+		*u = resolved
+	}
+	return nil
+}
+
+func findOCIManifest(ctx context.Context, client *oci.Client, ref oci.Reference, version string) (*oci.Manifest, string, error) {
+	for _, tag := range []string{"v" + version, version} {
+		manifest, err := client.GetManifest(ctx, ref, tag)
+		if errors.Is(err, oci.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		return manifest, tag, nil
+	}
+	return nil, "", newFetchError("failed to find tag", ErrCodeReleaseNotFound, nil)
+}
+
+// ociCacheVersion builds the cache version for the artifact with the given tag. Download URLs are the digest-based
+// blob URLs, which are stable and therefore safe to cache.
+func ociCacheVersion(ctx context.Context, client *oci.Client, ref oci.Reference, tag string, manifest *oci.Manifest) (*types.CacheVersion, error) {
+	var shaSumsURL, shaSumsSignatureURL string
+	protocols := defaultProtocols()
+	platformLayers := make(map[platform.Platform]oci.Descriptor)
+
+	for _, layer := range manifest.Layers {
+		title := layer.Title()
+		switch {
+		case strings.HasSuffix(title, "_SHA256SUMS"):
+			shaSumsURL = ref.BlobURL(layer.Digest)
+		case strings.HasSuffix(title, "_SHA256SUMS.sig"):
+			shaSumsSignatureURL = ref.BlobURL(layer.Digest)
+		case strings.HasSuffix(title, "_manifest.json"):
+			var err error
+			if protocols, err = fetchOCIManifestProtocols(ctx, client, ref, layer); err != nil {
+				return nil, err
+			}
+		case strings.HasSuffix(title, ".zip"):
+			if p := platform.ExtractPlatformFromArtifact(title); p != nil {
+				platformLayers[*p] = layer
+			}
+		}
+	}
+
+	if shaSumsURL == "" {
+		return nil, newFetchError("failed to find SHA256SUMS layer", ErrCodeSHASumsNotFound, nil)
+	}
+
+	downloadDetails := make([]types.CacheVersionDownloadDetails, 0, len(platformLayers))
+	for p, layer := range platformLayers {
+		downloadDetails = append(downloadDetails, types.CacheVersionDownloadDetails{
+			Platform:            p,
+			Filename:            layer.Title(),
+			DownloadURL:         ref.BlobURL(layer.Digest),
+			SHASumsURL:          shaSumsURL,
+			SHASumsSignatureURL: shaSumsSignatureURL,
+			// the layer digest is the sha256 of the zip, which is exactly the checksum listed in SHA256SUMS
+			SHASum: strings.TrimPrefix(layer.Digest, "sha256:"),
+		})
+	}
+
+	return &types.CacheVersion{
+		Version:         strings.TrimPrefix(tag, "v"),
+		Protocols:       protocols,
+		DownloadDetails: downloadDetails,
+	}, nil
+}
+
+func fetchOCIManifestProtocols(ctx context.Context, client *oci.Client, ref oci.Reference, layer oci.Descriptor) ([]string, error) {
+	contents, err := client.FetchBlob(ctx, ref, layer.Digest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch manifest layer: %w", err)
+	}
+	manifest, err := parseManifestContents(contents)
+	contents.Close()
+	if err != nil {
+		return nil, err
+	}
+	return manifest.Protocols(), nil
+}
+
+func blobDigest(ref oci.Reference, u string) (string, bool) {
+	prefix := ref.BlobURL("")
+	if !strings.HasPrefix(u, prefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(u, prefix), true
+}
@@ -0,0 +1,144 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/opentofu/registry/internal/oci"
+	"github.com/opentofu/registry/internal/platform"
+	"github.com/opentofu/registry/internal/providers/types"
+)
+
+// newTestOCIRegistry serves the given manifests, by tag, and blobs, by digest, of the example/foo repository. Blobs
+// with an empty content redirect to the storage backend like most registries do. The returned client is made to
+// trust the registry.
+func newTestOCIRegistry(t *testing.T, manifests map[string]oci.Manifest, blobs map[string]string) (*oci.Client, oci.Reference, *[]string) {
+	var requested []string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		path := strings.TrimPrefix(r.URL.Path, "/v2/example/foo/")
+		switch {
+		case path == "tags/list":
+			tags := make([]string, 0, len(manifests)+1)
+			for tag := range manifests {
+				tags = append(tags, tag)
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"tags": append(tags, "latest")})
+		case strings.HasPrefix(path, "manifests/"):
+			manifest, ok := manifests[strings.TrimPrefix(path, "manifests/")]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(manifest)
+		case strings.HasPrefix(path, "blobs/"):
+			digest := strings.TrimPrefix(path, "blobs/")
+			contents, ok := blobs[digest]
+			switch {
+			case !ok:
+				http.NotFound(w, r)
+			case contents == "":
+				http.Redirect(w, r, "https://storage.example.com/"+digest, http.StatusTemporaryRedirect)
+			default:
+				_, _ = w.Write([]byte(contents))
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	// the client sends its requests through the default transport
+	defaultTransport := http.DefaultTransport
+	http.DefaultTransport = server.Client().Transport
+	t.Cleanup(func() { http.DefaultTransport = defaultTransport })
+
+	ref := oci.Reference{Registry: strings.TrimPrefix(server.URL, "https://"), Repository: "example/foo"}
+	return oci.NewClient("", ""), ref, &requested
+}
+
+func ociLayer(title, digest string) oci.Descriptor {
+	return oci.Descriptor{Digest: digest, Annotations: map[string]string{oci.AnnotationTitle: title}}
+}
+
+func TestGetVersionsFromOCI(t *testing.T) {
+	manifests := map[string]oci.Manifest{
+		"v1.0.0": {Layers: []oci.Descriptor{
+			ociLayer("terraform-provider-foo_1.0.0_linux_amd64.zip", "sha256:aaa"),
+			ociLayer("terraform-provider-foo_1.0.0_SHA256SUMS", "sha256:sums"),
+			ociLayer("terraform-provider-foo_1.0.0_SHA256SUMS.sig", "sha256:sig"),
+			ociLayer("terraform-provider-foo_1.0.0_manifest.json", "sha256:manifest"),
+		}},
+		"v1.1.0": {Layers: []oci.Descriptor{
+			ociLayer("terraform-provider-foo_1.1.0_linux_amd64.zip", "sha256:bbb"),
+		}},
+		"1.2.0": {Layers: []oci.Descriptor{
+			ociLayer("terraform-provider-foo_1.2.0_SHA256SUMS", "sha256:sums2"),
+		}},
+		"v0.9.0": {},
+	}
+	blobs := map[string]string{"sha256:manifest": `{"version":1,"metadata":{"protocol_versions":["6.0"]}}`}
+	client, ref, requested := newTestOCIRegistry(t, manifests, blobs)
+
+	versions, err := GetVersionsFromOCI(context.Background(), client, ref, types.VersionList{{Version: "0.9.0"}})
+	if err != nil {
+		t.Fatalf("GetVersionsFromOCI() error = %v", err)
+	}
+	if len(versions) != 1 {
+		t.Fatalf("expected only 1.0.0 to be listed, without SHA256SUMS or platforms the others are skipped, got %+v", versions)
+	}
+	want := types.CacheVersion{
+		Version:   "1.0.0",
+		Protocols: []string{"6.0"},
+		DownloadDetails: []types.CacheVersionDownloadDetails{{
+			Platform:            platform.Platform{OS: "linux", Arch: "amd64"},
+			Filename:            "terraform-provider-foo_1.0.0_linux_amd64.zip",
+			DownloadURL:         ref.BlobURL("sha256:aaa"),
+			SHASumsURL:          ref.BlobURL("sha256:sums"),
+			SHASumsSignatureURL: ref.BlobURL("sha256:sig"),
+			SHASum:              "aaa",
+		}},
+	}
+	if !reflect.DeepEqual(versions[0], want) {
+		t.Errorf("version = %+v, want %+v", versions[0], want)
+	}
+	for _, path := range *requested {
+		if strings.HasSuffix(path, "/v0.9.0") || strings.HasSuffix(path, "/latest") {
+			t.Errorf("expected known versions and other tags not to be fetched, got a request for %s", path)
+		}
+	}
+}
+
+func TestGetVersionFromOCI(t *testing.T) {
+	manifests := map[string]oci.Manifest{
+		"v1.0.0": {Layers: []oci.Descriptor{
+			ociLayer("terraform-provider-foo_1.0.0_linux_amd64.zip", "sha256:aaa"),
+			ociLayer("terraform-provider-foo_1.0.0_SHA256SUMS", "sha256:sums"),
+		}},
+	}
+	// the archive is redirected to the storage backend, the checksums are served by the registry itself
+	blobs := map[string]string{"sha256:aaa": "", "sha256:sums": "aaa  terraform-provider-foo_1.0.0_linux_amd64.zip\n"}
+	client, ref, _ := newTestOCIRegistry(t, manifests, blobs)
+
+	details, err := GetVersionFromOCI(context.Background(), client, ref, "1.0.0", "linux", "amd64")
+	if err != nil {
+		t.Fatalf("GetVersionFromOCI() error = %v", err)
+	}
+	if details.DownloadURL != "https://storage.example.com/sha256:aaa" || details.SHASumsURL != ref.BlobURL("sha256:sums") {
+		t.Errorf("expected the archive URL to be resolved to the storage backend, got %q and %q", details.DownloadURL, details.SHASumsURL)
+	}
+
+	var fetchErr *FetchError
+	if _, err := GetVersionFromOCI(context.Background(), client, ref, "1.0.0", "darwin", "arm64"); !errors.As(err, &fetchErr) || fetchErr.Code != ErrCodeAssetNotFound {
+		t.Errorf("GetVersionFromOCI() error = %v, want code %d for a platform without a layer", err, ErrCodeAssetNotFound)
+	}
+	if _, err := GetVersionFromOCI(context.Background(), client, ref, "2.0.0", "linux", "amd64"); !errors.As(err, &fetchErr) || fetchErr.Code != ErrCodeReleaseNotFound {
+		t.Errorf("GetVersionFromOCI() error = %v, want code %d for an unknown version", err, ErrCodeReleaseNotFound)
+	}
+}
@@ -0,0 +1,62 @@
+package providercache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	dynamodbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/opentofu/registry/internal/providers/types"
+	"golang.org/x/exp/slog"
+)
+
+// ErrNotFound is returned by UpdateVersions when there is no item to update.
+var ErrNotFound = errors.New("no item is stored under the key")
+
+// AppendVersions reads the item and merges the versions into it, written back on the condition that it was not stored
+// since it was read, and merged again if it was, see putRevision.
+func (p *Handler) AppendVersions(ctx context.Context, key string, versions types.VersionList, metadata types.ProviderMetadata) error {
+	var merged types.VersionList
+	err := p.putRevision(ctx, key, "append_versions", true, func(current map[string]dynamodbtypes.AttributeValue) (*types.CacheItem, error) {
+		merged = versions
+		if current == nil {
+			return &types.CacheItem{Versions: merged, Metadata: metadata}, nil
+		}
+		item, err := p.decodeItem(ctx, current)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode item %s: %w", key, err)
+		}
+		merged = append(item.Versions, versions...).Deduplicate()
+		return &types.CacheItem{Versions: merged, Metadata: metadata}, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	slog.Info("Successfully appended provider versions", "key", key, "versions", len(versions), "merged", len(merged))
+	return nil
+}
+
+// UpdateVersions reads the item and changes it with update, written back with its update time on the condition that
+// it was not stored since it was read, and read and changed again if it was, see putRevision.
+func (p *Handler) UpdateVersions(ctx context.Context, key string, update func(item *types.CacheItem) error) error {
+	err := p.putRevision(ctx, key, "update_versions", true, func(current map[string]dynamodbtypes.AttributeValue) (*types.CacheItem, error) {
+		if current == nil {
+			return nil, fmt.Errorf("could not update %s: %w", key, ErrNotFound)
+		}
+		item, err := p.decodeItem(ctx, current)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode item %s: %w", key, err)
+		}
+		if err := update(item); err != nil {
+			return nil, err
+		}
+		return item, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	slog.Info("Successfully updated provider versions", "key", key)
+	return nil
+}
@@ -0,0 +1,90 @@
+package providercache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/opentofu/registry/internal/providers/types"
+)
+
+func TestUpdateVersionsKeepsUpdateTime(t *testing.T) {
+	handler, _ := newTestHandler(t)
+	stored := storeTestItem(t, handler, "example/test", types.VersionList{{Version: "1.0.0"}})
+
+	err := handler.UpdateVersions(context.Background(), "example/test", func(item *types.CacheItem) error {
+		item.Versions[0].Yanked = &types.Yank{Reason: "broken"}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("UpdateVersions() error = %v", err)
+	}
+
+	item, err := handler.GetItem(context.Background(), "example/test")
+	if err != nil {
+		t.Fatalf("GetItem() error = %v", err)
+	}
+	if item.Versions[0].Yanked == nil {
+		t.Errorf("expected 1.0.0 to be yanked, got %+v", item.Versions)
+	}
+	if !item.LastUpdated.Equal(stored.LastUpdated) || item.Revision != stored.Revision+1 {
+		t.Errorf("LastUpdated, Revision = %v, %d, want %v, %d", item.LastUpdated, item.Revision, stored.LastUpdated, stored.Revision+1)
+	}
+}
+
+func TestUpdateVersionsKeepsConcurrentAppend(t *testing.T) {
+	handler, table := newTestHandler(t)
+	storeTestItem(t, handler, "example/test", types.VersionList{{Version: "1.0.0"}})
+
+	var calls int
+	err := handler.UpdateVersions(context.Background(), "example/test", func(item *types.CacheItem) error {
+		calls++
+		if calls == 1 {
+			// a refresh appends a version between the read and the write of the update
+			if err := handler.AppendVersions(context.Background(), "example/test", types.VersionList{{Version: "2.0.0"}}, types.ProviderMetadata{}); err != nil {
+				t.Fatalf("AppendVersions() error = %v", err)
+			}
+		}
+		for i := range item.Versions {
+			if item.Versions[i].Version == "1.0.0" {
+				item.Versions[i].Yanked = &types.Yank{Reason: "broken", YankedAt: time.Now()}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("UpdateVersions() error = %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("update was called %d times, want it called again after the conflict", calls)
+	}
+
+	item, err := handler.GetItem(context.Background(), "example/test")
+	if err != nil {
+		t.Fatalf("GetItem() error = %v", err)
+	}
+	if len(item.Versions) != 2 || item.Revision != 3 || table.puts != 3 {
+		t.Fatalf("expected the appended version to be kept at revision 3, got %+v at revision %d after %d writes", item.Versions, item.Revision, table.puts)
+	}
+	for _, v := range item.Versions {
+		if (v.Version == "1.0.0") != (v.Yanked != nil) {
+			t.Errorf("expected only 1.0.0 to be yanked, got %+v", item.Versions)
+		}
+	}
+}
+
+func TestUpdateVersionsNotFound(t *testing.T) {
+	handler, table := newTestHandler(t)
+
+	err := handler.UpdateVersions(context.Background(), "example/missing", func(*types.CacheItem) error {
+		t.Error("update called without an item")
+		return nil
+	})
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("UpdateVersions() error = %v, want %v", err, ErrNotFound)
+	}
+	if table.puts != 0 {
+		t.Errorf("expected nothing to be written, got %d writes", table.puts)
+	}
+}
@@ -0,0 +1,54 @@
+package providercache
+
+import (
+	"context"
+
+	"github.com/opentofu/registry/internal/providers/types"
+)
+
+// Cache is the storage of the provider version listings. Handler stores them in DynamoDB, the self-hosted mode keeps
+// them in a local database instead.
+type Cache interface {
+	// GetItem returns the item stored under key, or nil if there is none.
+	GetItem(ctx context.Context, key string) (*types.CacheItem, error)
+	// GetItems returns the items stored under keys, keyed by the provider key. Missing keys are omitted.
+	GetItems(ctx context.Context, keys []string) (map[string]*types.CacheItem, error)
+	// Store replaces the item stored under key.
+	Store(ctx context.Context, key string, versions types.VersionList, metadata types.ProviderMetadata) error
+	// AppendVersions merges the versions into the item stored under key, or stores them if there is none, replacing
+	// its metadata and update time. The versions the item already has are kept, even those stored concurrently, so
+	// that a refresh only has to write the versions it fetched.
+	AppendVersions(ctx context.Context, key string, versions types.VersionList, metadata types.ProviderMetadata) error
+	// UpdateVersions changes the item stored under key with update, keeping its update time. The item update is given
+	// is the one stored when it is written back, like with AppendVersions, so update may be called again if it was
+	// stored concurrently. It returns ErrNotFound if there is no item.
+	UpdateVersions(ctx context.Context, key string, update func(item *types.CacheItem) error) error
+	// StoreBatch replaces the items of the entries, each the way Store does, in as few calls as the storage allows
+	// while doing so.
+	StoreBatch(ctx context.Context, entries []Entry) error
+	// StoreMetadata replaces the metadata of the item stored under key, keeping its versions and update time. It does
+	// nothing if there is no item.
+	StoreMetadata(ctx context.Context, key string, metadata types.ProviderMetadata) error
+	// Delete deletes the item stored under key, so that the provider is fetched again on the next request. It does
+	// nothing if there is no item.
+	Delete(ctx context.Context, key string) error
+	// ListNamespace returns the keys of the items of the providers of a namespace.
+	ListNamespace(ctx context.Context, namespace string) ([]string, error)
+	// Sample returns the keys of up to n items picked at random.
+	Sample(ctx context.Context, n int) ([]string, error)
+	// Search returns the keys of the items whose key contains query, sorted, leaving out the providers known not to
+	// exist.
+	Search(ctx context.Context, query string) ([]string, error)
+	// Keys returns the keys of all the items, sorted, leaving out the providers known not to exist. Unlike Search, it
+	// may read the whole cache, for the batch jobs rather than the requests.
+	Keys(ctx context.Context) ([]string, error)
+}
+
+// Entry is an item to store with StoreBatch.
+type Entry struct {
+	Key      string
+	Versions types.VersionList
+	Metadata types.ProviderMetadata
+}
+
+var _ Cache = (*Handler)(nil)
@@ -0,0 +1,217 @@
+package providercache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dynamodbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/opentofu/registry/internal/providers/types"
+	"golang.org/x/exp/slog"
+)
+
+// chunkSize is the number of versions in each chunk of an item split across several records, see storeChunks.
+const chunkSize = 100
+
+// batchWriteItemLimit is the maximum number of writes DynamoDB accepts in a single BatchWriteItem call.
+const batchWriteItemLimit = 25
+
+// chunkRecord is a record of the table holding a chunk of the versions of an item too large for a single record. The
+// item itself is the index record, with the number of chunks and the generation they were written at.
+type chunkRecord struct {
+	Provider string `dynamodbav:"provider"`
+	Data     string `dynamodbav:"data"`
+	// ChunkOf is the key of the item the chunk belongs to. The scans of the table leave out the records which have it.
+	ChunkOf string `dynamodbav:"chunk_of"`
+}
+
+// chunkKey returns the key of the record holding chunk i of the item stored under key, written at generation. Every
+// write of an item uses a new generation, so that reading the index record never mixes the chunks of two writes.
+func chunkKey(key string, generation int64, i int) string {
+	return fmt.Sprintf("%s#%d#%d", key, generation, i)
+}
+
+// encodeChunks encodes and compresses the versions in chunks of chunkSize versions, each small enough for a record.
+func encodeChunks(versions types.VersionList, schemaVersion int) ([]string, error) {
+	var chunks []string
+	for start := 0; start < len(versions); start += chunkSize {
+		end := start + chunkSize
+		if end > len(versions) {
+			end = len(versions)
+		}
+
+		jsonData, err := EncodeVersions(versions[start:end], schemaVersion)
+		if err != nil {
+			return nil, fmt.Errorf("got error marshalling chunk to JSON: %w", err)
+		}
+		compressedData, err := compress(jsonData)
+		if err != nil {
+			return nil, fmt.Errorf("got error compressing chunk: %w", err)
+		}
+		if len(compressedData) > maxInlineDataSize {
+			return nil, fmt.Errorf("chunk %d is %d bytes compressed, more than a record can hold", len(chunks), len(compressedData))
+		}
+		chunks = append(chunks, compressedData)
+	}
+	return chunks, nil
+}
+
+// storeChunks writes the versions of an item too large for a single record as chunk records, and sets on the item,
+// which becomes their index record, how to read them back.
+func (p *Handler) storeChunks(ctx context.Context, item *CompressedCacheItem, versions types.VersionList) error {
+	chunks, err := encodeChunks(versions, p.WriteSchemaVersion)
+	if err != nil {
+		return err
+	}
+
+	generation := time.Now().UnixNano()
+	writes := make([]dynamodbtypes.WriteRequest, 0, len(chunks))
+	for i, data := range chunks {
+		record, err := attributevalue.MarshalMap(chunkRecord{Provider: chunkKey(item.Provider, generation, i), Data: data, ChunkOf: item.Provider})
+		if err != nil {
+			return fmt.Errorf("got error marshalling chunk record: %w", err)
+		}
+		writes = append(writes, dynamodbtypes.WriteRequest{PutRequest: &dynamodbtypes.PutRequest{Item: record}})
+	}
+	if err := p.batchWrite(ctx, writes); err != nil {
+		return fmt.Errorf("got error writing chunk records: %w", err)
+	}
+
+	slog.Info("Stored item in chunks", "key", item.Provider, "chunks", len(chunks), "generation", generation)
+	item.Data = ""
+	item.Chunks = len(chunks)
+	item.ChunkGeneration = generation
+	return nil
+}
+
+// deleteChunks deletes the chunk records of a previous write of an item, once the index record no longer points at
+// them. Failures are only logged, the records are then left behind unused.
+func (p *Handler) deleteChunks(ctx context.Context, previous CompressedCacheItem) {
+	writes := make([]dynamodbtypes.WriteRequest, 0, previous.Chunks)
+	for i := 0; i < previous.Chunks; i++ {
+		writes = append(writes, dynamodbtypes.WriteRequest{DeleteRequest: &dynamodbtypes.DeleteRequest{
+			Key: map[string]dynamodbtypes.AttributeValue{
+				"provider": &dynamodbtypes.AttributeValueMemberS{Value: chunkKey(previous.Provider, previous.ChunkGeneration, i)},
+			},
+		}})
+	}
+	if err := p.batchWrite(ctx, writes); err != nil {
+		slog.Error("got error deleting previous chunk records", "key", previous.Provider, "generation", previous.ChunkGeneration, "error", err)
+	}
+}
+
+func (p *Handler) batchWrite(ctx context.Context, writes []dynamodbtypes.WriteRequest) error {
+	for start := 0; start < len(writes); start += batchWriteItemLimit {
+		end := start + batchWriteItemLimit
+		if end > len(writes) {
+			end = len(writes)
+		}
+
+		requestItems := map[string][]dynamodbtypes.WriteRequest{*p.TableName: writes[start:end]}
+		for len(requestItems) > 0 {
+			result, err := p.Client.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{RequestItems: requestItems})
+			if err != nil {
+				return err
+			}
+			// DynamoDB may not process all the writes in one go, in which case we need to retry the remaining ones
+			requestItems = result.UnprocessedItems
+		}
+	}
+	return nil
+}
+
+// readChunks reads back the versions of an item stored in chunks, migrating each chunk to the current schema version.
+func (p *Handler) readChunks(ctx context.Context, item CompressedCacheItem) (types.VersionList, bool, error) {
+	keys := make([]map[string]dynamodbtypes.AttributeValue, 0, item.Chunks)
+	for i := 0; i < item.Chunks; i++ {
+		keys = append(keys, map[string]dynamodbtypes.AttributeValue{
+			"provider": &dynamodbtypes.AttributeValueMemberS{Value: chunkKey(item.Provider, item.ChunkGeneration, i)},
+		})
+	}
+
+	records := make(map[string]string, item.Chunks)
+	for start := 0; start < len(keys); start += batchGetItemLimit {
+		end := start + batchGetItemLimit
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		requestItems := map[string]dynamodbtypes.KeysAndAttributes{*p.TableName: {Keys: keys[start:end], ConsistentRead: aws.Bool(true)}}
+		for len(requestItems) > 0 {
+			result, err := p.Client.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{RequestItems: requestItems})
+			if err != nil {
+				return nil, false, fmt.Errorf("failed to get chunk records: %w", err)
+			}
+			for _, rawRecord := range result.Responses[*p.TableName] {
+				var record chunkRecord
+				if err := attributevalue.UnmarshalMap(rawRecord, &record); err != nil {
+					return nil, false, fmt.Errorf("failed to unmarshal chunk record: %w", err)
+				}
+				records[record.Provider] = record.Data
+			}
+			requestItems = result.UnprocessedKeys
+		}
+	}
+
+	var versions types.VersionList
+	var migrated bool
+	for i := 0; i < item.Chunks; i++ {
+		key := chunkKey(item.Provider, item.ChunkGeneration, i)
+		data, ok := records[key]
+		if !ok {
+			return nil, false, fmt.Errorf("chunk record %s is missing", key)
+		}
+		chunk, chunkMigrated, err := decodeVersions(item.SchemaVersion, data)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to decode chunk record %s: %w", key, err)
+		}
+		versions = append(versions, chunk...)
+		migrated = migrated || chunkMigrated
+	}
+	return versions, migrated, nil
+}
+
+// decodeChunkedItem decodes an item stored in chunks. It is not written back when migrated, as that would mean
+// writing all of its chunks again: its chunks are migrated on every read until the item is stored again.
+func (p *Handler) decodeChunkedItem(ctx context.Context, compressedItem CompressedCacheItem) (*types.CacheItem, error) {
+	versions, migrated, err := p.readChunks(ctx, compressedItem)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chunks of item %s: %w", compressedItem.Provider, err)
+	}
+
+	item := types.CacheItem{
+		Provider:      compressedItem.Provider,
+		Versions:      versions,
+		LastUpdated:   compressedItem.LastUpdated,
+		Metadata:      compressedItem.Metadata,
+		SchemaVersion: compressedItem.SchemaVersion,
+		Revision:      compressedItem.Revision,
+	}
+	if migrated {
+		item.SchemaVersion = CurrentSchemaVersion
+	}
+	return &item, nil
+}
+
+// decodeVersions decompresses a chunk of the versions of an item, and decodes them, migrated to the current schema
+// version if they are of an older one.
+func decodeVersions(schemaVersion int, data string) (types.VersionList, bool, error) {
+	decompressedData, err := decompress(data)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to decompress data: %w", err)
+	}
+	versionsData, migrated, err := MigrateVersions(schemaVersion, decompressedData)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var versions types.VersionList
+	if err := json.Unmarshal(versionsData, &versions); err != nil {
+		return nil, false, fmt.Errorf("failed to unmarshal decompressed versions: %w", err)
+	}
+	return versions, migrated, nil
+}
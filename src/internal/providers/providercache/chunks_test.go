@@ -0,0 +1,41 @@
+package providercache
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/opentofu/registry/internal/providers/types"
+)
+
+func TestEncodeChunks(t *testing.T) {
+	var versions types.VersionList
+	for i := 0; i < 2*chunkSize+1; i++ {
+		versions = append(versions, types.CacheVersion{Version: fmt.Sprintf("1.0.%d", i), Protocols: []string{"5.0"}})
+	}
+
+	chunks, err := encodeChunks(versions, CurrentSchemaVersion)
+	if err != nil {
+		t.Fatalf("encodeChunks() error = %v", err)
+	}
+	if len(chunks) != 3 {
+		t.Fatalf("expected 3 chunks, got %d", len(chunks))
+	}
+
+	var decoded types.VersionList
+	for _, chunk := range chunks {
+		chunkVersions, migrated, err := decodeVersions(CurrentSchemaVersion, chunk)
+		if err != nil || migrated {
+			t.Fatalf("decodeVersions() = %v, %v", migrated, err)
+		}
+		decoded = append(decoded, chunkVersions...)
+	}
+	if len(decoded) != len(versions) || decoded[chunkSize].Version != "1.0.100" || decoded[len(decoded)-1].Version != "1.0.200" {
+		t.Errorf("chunks decoded to %d versions out of order", len(decoded))
+	}
+}
+
+func TestChunkKey(t *testing.T) {
+	if got := chunkKey("hashicorp/aws", 42, 3); got != "hashicorp/aws#42#3" {
+		t.Errorf("chunkKey() = %q", got)
+	}
+}
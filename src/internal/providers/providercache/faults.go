@@ -0,0 +1,101 @@
+package providercache
+
+import (
+	"context"
+
+	"github.com/opentofu/registry/internal/faults"
+	"github.com/opentofu/registry/internal/providers/types"
+)
+
+// FaultInjector injects the faults configured for the provider cache into the calls to a cache, to exercise how the
+// registry copes with it being slow or failing.
+type FaultInjector struct {
+	Cache
+	Injector *faults.Injector
+}
+
+var _ Cache = FaultInjector{}
+
+func (f FaultInjector) GetItem(ctx context.Context, key string) (*types.CacheItem, error) {
+	if _, err := f.Injector.Inject(ctx, faults.TargetProviderCache, "get_item"); err != nil {
+		return nil, err
+	}
+	return f.Cache.GetItem(ctx, key)
+}
+
+func (f FaultInjector) GetItems(ctx context.Context, keys []string) (map[string]*types.CacheItem, error) {
+	if _, err := f.Injector.Inject(ctx, faults.TargetProviderCache, "get_items"); err != nil {
+		return nil, err
+	}
+	return f.Cache.GetItems(ctx, keys)
+}
+
+func (f FaultInjector) Store(ctx context.Context, key string, versions types.VersionList, metadata types.ProviderMetadata) error {
+	if _, err := f.Injector.Inject(ctx, faults.TargetProviderCache, "store"); err != nil {
+		return err
+	}
+	return f.Cache.Store(ctx, key, versions, metadata)
+}
+
+func (f FaultInjector) AppendVersions(ctx context.Context, key string, versions types.VersionList, metadata types.ProviderMetadata) error {
+	if _, err := f.Injector.Inject(ctx, faults.TargetProviderCache, "append_versions"); err != nil {
+		return err
+	}
+	return f.Cache.AppendVersions(ctx, key, versions, metadata)
+}
+
+func (f FaultInjector) UpdateVersions(ctx context.Context, key string, update func(item *types.CacheItem) error) error {
+	if _, err := f.Injector.Inject(ctx, faults.TargetProviderCache, "update_versions"); err != nil {
+		return err
+	}
+	return f.Cache.UpdateVersions(ctx, key, update)
+}
+
+func (f FaultInjector) StoreBatch(ctx context.Context, entries []Entry) error {
+	if _, err := f.Injector.Inject(ctx, faults.TargetProviderCache, "store_batch"); err != nil {
+		return err
+	}
+	return f.Cache.StoreBatch(ctx, entries)
+}
+
+func (f FaultInjector) StoreMetadata(ctx context.Context, key string, metadata types.ProviderMetadata) error {
+	if _, err := f.Injector.Inject(ctx, faults.TargetProviderCache, "store_metadata"); err != nil {
+		return err
+	}
+	return f.Cache.StoreMetadata(ctx, key, metadata)
+}
+
+func (f FaultInjector) Delete(ctx context.Context, key string) error {
+	if _, err := f.Injector.Inject(ctx, faults.TargetProviderCache, "delete"); err != nil {
+		return err
+	}
+	return f.Cache.Delete(ctx, key)
+}
+
+func (f FaultInjector) ListNamespace(ctx context.Context, namespace string) ([]string, error) {
+	if _, err := f.Injector.Inject(ctx, faults.TargetProviderCache, "list_namespace"); err != nil {
+		return nil, err
+	}
+	return f.Cache.ListNamespace(ctx, namespace)
+}
+
+func (f FaultInjector) Sample(ctx context.Context, n int) ([]string, error) {
+	if _, err := f.Injector.Inject(ctx, faults.TargetProviderCache, "sample"); err != nil {
+		return nil, err
+	}
+	return f.Cache.Sample(ctx, n)
+}
+
+func (f FaultInjector) Search(ctx context.Context, query string) ([]string, error) {
+	if _, err := f.Injector.Inject(ctx, faults.TargetProviderCache, "search"); err != nil {
+		return nil, err
+	}
+	return f.Cache.Search(ctx, query)
+}
+
+func (f FaultInjector) Keys(ctx context.Context) ([]string, error) {
+	if _, err := f.Injector.Inject(ctx, faults.TargetProviderCache, "keys"); err != nil {
+		return nil, err
+	}
+	return f.Cache.Keys(ctx)
+}
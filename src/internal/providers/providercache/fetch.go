@@ -6,11 +6,16 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"io"
+	"math/rand"
+	"sort"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/opentofu/registry/internal/metrics"
 	providerTypes "github.com/opentofu/registry/internal/providers/types"
 	"golang.org/x/exp/slog"
 )
@@ -48,32 +53,293 @@ func (p *Handler) GetItem(ctx context.Context, key string) (*providerTypes.Cache
 	// check if the item is empty, if so return nil, this makes it easier to consume in other places
 	if len(result.Item) == 0 {
 		slog.Info("Item not found in cache", "key", key)
+		metrics.Inc(metrics.CacheLookups, metrics.Labels{"result": "miss"})
 		return nil, nil //nolint:nilnil // This is not an error, it just means there is no manifest.
 	}
 
-	var compressedItem CompressedCacheItem
-	err = attributevalue.UnmarshalMap(result.Item, &compressedItem)
+	item, err := p.decodeItem(ctx, result.Item)
 	if err != nil {
-		slog.Error("Failed to unmarshal compressed item from cache", "key", key, "error", err)
+		slog.Error("Failed to decode item from cache", "key", key, "error", err)
 		return nil, err
 	}
 
-	decompressedData, err := decompress(compressedItem.Data)
+	slog.Info("Successfully decompressed and unmarshalled item from cache", "key", key)
+	metrics.Inc(metrics.CacheLookups, cacheLookupLabels(item, p.TTL))
+	return item, nil
+}
+
+// batchGetItemLimit is the maximum number of keys DynamoDB accepts in a single BatchGetItem call.
+const batchGetItemLimit = 100
+
+// GetItems fetches several items from the cache at once using BatchGetItem. The returned map is keyed by the provider
+// key, keys which are not present in the cache are omitted from it.
+func (p *Handler) GetItems(ctx context.Context, keys []string) (map[string]*providerTypes.CacheItem, error) {
+	slog.Info("Getting items from cache", "keys", len(keys))
+
+	items := make(map[string]*providerTypes.CacheItem, len(keys))
+
+	for start := 0; start < len(keys); start += batchGetItemLimit {
+		end := start + batchGetItemLimit
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		requestKeys := make([]map[string]types.AttributeValue, 0, end-start)
+		for _, key := range keys[start:end] {
+			requestKeys = append(requestKeys, map[string]types.AttributeValue{
+				"provider": &types.AttributeValueMemberS{Value: key},
+			})
+		}
+
+		requestItems := map[string]types.KeysAndAttributes{*p.TableName: {Keys: requestKeys}}
+		for len(requestItems) > 0 {
+			result, err := p.Client.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{RequestItems: requestItems})
+			if err != nil {
+				slog.Error("Failed to batch get items from cache", "error", err)
+				return nil, err
+			}
+
+			for _, rawItem := range result.Responses[*p.TableName] {
+				item, err := p.decodeItem(ctx, rawItem)
+				if err != nil {
+					slog.Error("Failed to decode item from cache", "error", err)
+					return nil, err
+				}
+				items[item.Provider] = item
+				metrics.Inc(metrics.CacheLookups, cacheLookupLabels(item, p.TTL))
+			}
+
+			// DynamoDB may not process all the keys in one go, in which case we need to retry the remaining ones
+			requestItems = result.UnprocessedKeys
+		}
+	}
+
+	if missing := len(keys) - len(items); missing > 0 {
+		metrics.Add(metrics.CacheLookups, metrics.Labels{"result": "miss"}, float64(missing))
+	}
+
+	slog.Info("Successfully got items from cache", "keys", len(keys), "found", len(items))
+	return items, nil
+}
+
+// namespaceIndexName is the global secondary index of the table keyed by the namespace of the providers. It only
+// projects the keys, the items are fetched with GetItems.
+const namespaceIndexName = "namespace-index"
+
+// ListNamespace returns the keys of the items of the providers of a namespace, from the namespace index. Items stored
+// before the index existed are missing from it until they are refreshed.
+func (p *Handler) ListNamespace(ctx context.Context, namespace string) ([]string, error) {
+	slog.Info("Listing providers of namespace from cache", "namespace", namespace)
+
+	keys, err := p.queryKeys(ctx, &dynamodb.QueryInput{
+		TableName:              p.TableName,
+		IndexName:              aws.String(namespaceIndexName),
+		KeyConditionExpression: aws.String("namespace = :namespace"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":namespace": &types.AttributeValueMemberS{Value: namespace},
+		},
+		ProjectionExpression: aws.String("provider"),
+	})
 	if err != nil {
-		slog.Error("Failed to decompress item data", "key", key, "error", err)
+		slog.Error("Failed to query namespace index", "namespace", namespace, "error", err)
 		return nil, err
 	}
+	return keys, nil
+}
 
-	var item providerTypes.CacheItem
-	err = json.Unmarshal(decompressedData, &item.Versions)
+// queryKeys returns the provider keys of all the pages of the results of a query.
+func (p *Handler) queryKeys(ctx context.Context, input *dynamodb.QueryInput) ([]string, error) {
+	var keys []string
+	for {
+		result, err := p.Client.Query(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range result.Items {
+			if provider, ok := item["provider"].(*types.AttributeValueMemberS); ok {
+				keys = append(keys, provider.Value)
+			}
+		}
+		if len(result.LastEvaluatedKey) == 0 {
+			return keys, nil
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+}
+
+// sampleSegments is how many segments Sample splits the table into, starting from a random one, so that the samples
+// are not always the first items of the table.
+const sampleSegments = 16
+
+// Sample returns the keys of up to n items, scanning the segments of the table from a random one. The sample is only
+// as random as the distribution of the items over the segments, which is good enough to spread checks over the cache.
+func (p *Handler) Sample(ctx context.Context, n int) ([]string, error) {
+	var keys []string
+	start := rand.Intn(sampleSegments) //nolint:gosec // The sample does not need a secure random number.
+	for i := 0; i < sampleSegments && len(keys) < n; i++ {
+		input := &dynamodb.ScanInput{
+			TableName:            p.TableName,
+			ProjectionExpression: aws.String("provider"),
+			FilterExpression:     aws.String("attribute_not_exists(chunk_of)"),
+			Segment:              aws.Int32(int32((start + i) % sampleSegments)),
+			TotalSegments:        aws.Int32(sampleSegments),
+			Limit:                aws.Int32(int32(n - len(keys))),
+		}
+		for len(keys) < n {
+			result, err := p.Client.Scan(ctx, input)
+			if err != nil {
+				slog.Error("Failed to scan table for a sample", "error", err)
+				return nil, err
+			}
+			for _, item := range result.Items {
+				if provider, ok := item["provider"].(*types.AttributeValueMemberS); ok && len(keys) < n {
+					keys = append(keys, provider.Value)
+				}
+			}
+			if len(result.LastEvaluatedKey) == 0 {
+				break
+			}
+			input.ExclusiveStartKey = result.LastEvaluatedKey
+		}
+	}
+	return keys, nil
+}
+
+// searchIndexName is the global secondary index of the table listing the keys of all the items, in order, under a
+// single key, searchIndexPartition. It only projects the keys, and leaves out the providers known not to exist.
+const (
+	searchIndexName      = "search-index"
+	searchIndexPartition = "providers"
+)
+
+// Search returns the keys of the items whose key contains query, sorted, leaving out the providers known not to exist.
+// Rather than scanning the table, this queries the search index, which only holds the keys. Items stored before the
+// index existed are missing from it until they are refreshed.
+func (p *Handler) Search(ctx context.Context, query string) ([]string, error) {
+	slog.Info("Searching providers in cache", "query", query)
+
+	// the index is sorted by key, so the keys come in order
+	keys, err := p.queryKeys(ctx, &dynamodb.QueryInput{
+		TableName:              p.TableName,
+		IndexName:              aws.String(searchIndexName),
+		KeyConditionExpression: aws.String("search_partition = :partition"),
+		FilterExpression:       aws.String("contains(provider, :query)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":partition": &types.AttributeValueMemberS{Value: searchIndexPartition},
+			":query":     &types.AttributeValueMemberS{Value: query},
+		},
+		ProjectionExpression: aws.String("provider"),
+	})
 	if err != nil {
-		slog.Error("Failed to unmarshal decompressed item to CacheItem", "key", key, "error", err)
+		slog.Error("Failed to query search index", "query", query, "error", err)
 		return nil, err
 	}
+	return keys, nil
+}
+
+// Keys returns the keys of all the items, sorted, leaving out the providers known not to exist. It scans the whole
+// table, so it is meant for the batch jobs, which have to find the items missing from the search index too.
+func (p *Handler) Keys(ctx context.Context) ([]string, error) {
+	slog.Info("Listing all providers in cache")
+
+	var keys []string
+	input := &dynamodb.ScanInput{
+		TableName:            p.TableName,
+		ProjectionExpression: aws.String("provider"),
+		FilterExpression:     aws.String("NOT metadata.not_found = :true AND attribute_not_exists(chunk_of)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":true": &types.AttributeValueMemberBOOL{Value: true},
+		},
+	}
+	for {
+		result, err := p.Client.Scan(ctx, input)
+		if err != nil {
+			slog.Error("Failed to scan table for keys", "error", err)
+			return nil, err
+		}
+		for _, item := range result.Items {
+			if provider, ok := item["provider"].(*types.AttributeValueMemberS); ok {
+				keys = append(keys, provider.Value)
+			}
+		}
+		if len(result.LastEvaluatedKey) == 0 {
+			break
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// cacheLookupLabels tells apart fresh hits from stale ones, which trigger a refresh.
+func cacheLookupLabels(item *providerTypes.CacheItem, ttl providerTypes.CacheTTL) metrics.Labels {
+	if item.IsStale(ttl) {
+		return metrics.Labels{"result": "stale"}
+	}
+	return metrics.Labels{"result": "hit"}
+}
+
+// decodeItem unmarshals and decompresses a raw DynamoDB item into a CacheItem. Items of an older schema version are
+// migrated, and written back so that they are only migrated once.
+func (p *Handler) decodeItem(ctx context.Context, rawItem map[string]types.AttributeValue) (*providerTypes.CacheItem, error) {
+	var compressedItem CompressedCacheItem
+	err := attributevalue.UnmarshalMap(rawItem, &compressedItem)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal compressed item: %w", err)
+	}
+	if compressedItem.Chunks > 0 {
+		return p.decodeChunkedItem(ctx, compressedItem)
+	}
+
+	data := compressedItem.Data
+	if compressedItem.DataObject != "" {
+		if data, err = p.overflowData(ctx, compressedItem.DataObject); err != nil {
+			return nil, fmt.Errorf("failed to read data of item %s: %w", compressedItem.Provider, err)
+		}
+	}
+
+	decompressedData, err := decompress(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress item data: %w", err)
+	}
+
+	versionsData, migrated, err := MigrateVersions(compressedItem.SchemaVersion, decompressedData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to migrate item %s: %w", compressedItem.Provider, err)
+	}
+
+	var item providerTypes.CacheItem
+	err = json.Unmarshal(versionsData, &item.Versions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal decompressed item to CacheItem: %w", err)
+	}
 
 	item.Provider = compressedItem.Provider
 	item.LastUpdated = compressedItem.LastUpdated
+	item.Metadata = compressedItem.Metadata
+	item.SchemaVersion = compressedItem.SchemaVersion
+	item.Revision = compressedItem.Revision
+
+	if migrated {
+		item.SchemaVersion = CurrentSchemaVersion
+		p.rewrite(ctx, compressedItem, versionsData)
+	}
 
-	slog.Info("Successfully decompressed and unmarshalled item from cache", "key", key)
 	return &item, nil
 }
+
+// overflowData reads the compressed data of an item stored in the overflow bucket, see storeData.
+func (p *Handler) overflowData(ctx context.Context, objectKey string) (string, error) {
+	if p.Overflow == nil {
+		return "", fmt.Errorf("item data is stored in %s but there is no overflow bucket", objectKey)
+	}
+	data, err := p.Overflow.Get(ctx, objectKey)
+	if err != nil {
+		return "", err
+	}
+	if data == nil {
+		return "", fmt.Errorf("item data object %s does not exist", objectKey)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,35 @@
+package providercache
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/opentofu/registry/internal/providers/types"
+)
+
+func TestSearch(t *testing.T) {
+	handler, _ := newTestHandler(t)
+	ctx := context.Background()
+	storeTestItem(t, handler, "hashicorp/aws", types.VersionList{{Version: "1.0.0"}})
+	storeTestItem(t, handler, "example/aws-extras", types.VersionList{{Version: "1.0.0"}})
+	storeTestItem(t, handler, "example/random", types.VersionList{{Version: "1.0.0"}})
+	if err := handler.Store(ctx, "missing/aws", nil, types.ProviderMetadata{NotFound: true}); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+
+	keys, err := handler.Search(ctx, "aws")
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if want := []string{"example/aws-extras", "hashicorp/aws"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("Search() = %v, want %v", keys, want)
+	}
+
+	// once the provider is found, it is searched again
+	storeTestItem(t, handler, "missing/aws", types.VersionList{{Version: "1.0.0"}})
+	keys, err = handler.Search(ctx, "missing")
+	if err != nil || !reflect.DeepEqual(keys, []string{"missing/aws"}) {
+		t.Errorf("Search() = %v, %v, want [missing/aws]", keys, err)
+	}
+}
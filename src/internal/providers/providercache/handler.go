@@ -3,18 +3,30 @@ package providercache
 import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/opentofu/registry/internal/listings"
+	"github.com/opentofu/registry/internal/providers/types"
 )
 
 type Handler struct {
 	TableName *string
 	Client    *dynamodb.Client
+	// WriteSchemaVersion is the schema version items are stored in, the current one unless a schema change is being
+	// rolled out. Items migrated on read are only written back when it is the current one.
+	WriteSchemaVersion int
+	// Overflow, if set, holds the versions of the items too large to be stored in the table, see maxInlineDataSize.
+	Overflow *listings.Store
+	// TTL tells the stale items apart from the fresh ones in the lookup metrics, see types.CacheItem.IsStale.
+	TTL types.CacheTTL
 }
 
-func NewHandler(awsConfig aws.Config, tableName string) *Handler {
+func NewHandler(awsConfig aws.Config, tableName string, writeSchemaVersion int, overflow *listings.Store, ttl types.CacheTTL) *Handler {
 	ddbClient := dynamodb.NewFromConfig(awsConfig)
 
 	return &Handler{
-		TableName: aws.String(tableName),
-		Client:    ddbClient,
+		TableName:          aws.String(tableName),
+		Client:             ddbClient,
+		WriteSchemaVersion: writeSchemaVersion,
+		Overflow:           overflow,
+		TTL:                ttl,
 	}
 }
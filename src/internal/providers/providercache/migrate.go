@@ -0,0 +1,119 @@
+package providercache
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/opentofu/registry/internal/metrics"
+	"github.com/opentofu/registry/internal/providers/types"
+	"golang.org/x/exp/slog"
+)
+
+// CurrentSchemaVersion is the version of the format of the versions stored in cache items. Bump it, together with a
+// migration, whenever CacheVersion or CacheVersionDownloadDetails change in a way older items would decode wrongly.
+const CurrentSchemaVersion = 1
+
+// Migration converts the stored versions of an item between two consecutive schema versions. It works on the decoded
+// JSON rather than on CacheVersion, which only knows the current format.
+type Migration struct {
+	// Up upgrades versions of the older schema version to the newer one, when reading items.
+	Up func(versions []map[string]any) error
+	// Down turns versions of the newer schema version back into the older one, when writing items for the previous
+	// release of the registry, which does not know the newer schema yet.
+	Down func(versions []map[string]any) error
+}
+
+// migrations convert the items between schema versions i and i+1, so that there is one for each version before the
+// current.
+//
+//nolint:gochecknoglobals // This should be treated as a constant.
+var migrations = []Migration{
+	// 0 is the schema of the items stored before the schema version was recorded, which is the same as version 1.
+	{Up: unchanged, Down: unchanged},
+}
+
+func unchanged([]map[string]any) error { return nil }
+
+// PreviousSchemaVersion is the schema version of the previous release of the registry.
+const PreviousSchemaVersion = CurrentSchemaVersion - 1
+
+// ValidateWriteSchemaVersion checks that items can be written in the given schema version. During a rolling deploy of
+// a schema change, the new release writes items in the previous schema version, which both releases read, until it is
+// deployed everywhere.
+func ValidateWriteSchemaVersion(schemaVersion int) error {
+	if schemaVersion != CurrentSchemaVersion && schemaVersion != PreviousSchemaVersion {
+		return fmt.Errorf("schema version %d can not be written, only %d or %d", schemaVersion, PreviousSchemaVersion, CurrentSchemaVersion)
+	}
+	return nil
+}
+
+// MigrateVersions upgrades the stored versions of an item of the given schema version to the current one. It returns
+// whether the data was migrated, so that the caller can write the item back. Items of a newer schema version, written
+// by a newer release of the registry, are returned as they are.
+func MigrateVersions(schemaVersion int, data []byte) ([]byte, bool, error) {
+	if schemaVersion >= CurrentSchemaVersion {
+		if schemaVersion > CurrentSchemaVersion {
+			slog.Warn("Cache item has a newer schema version than supported", "schema_version", schemaVersion, "supported", CurrentSchemaVersion)
+		}
+		return data, false, nil
+	}
+	if schemaVersion < 0 {
+		return nil, false, fmt.Errorf("invalid schema version %d", schemaVersion)
+	}
+
+	versions, err := decodeRaw(schemaVersion, data)
+	if err != nil {
+		return nil, false, err
+	}
+
+	for from := schemaVersion; from < CurrentSchemaVersion; from++ {
+		if err := migrations[from].Up(versions); err != nil {
+			return nil, false, fmt.Errorf("could not migrate versions from schema version %d: %w", from, err)
+		}
+	}
+
+	migrated, err := json.Marshal(versions)
+	if err != nil {
+		return nil, false, fmt.Errorf("could not encode migrated versions: %w", err)
+	}
+
+	metrics.Inc(metrics.CacheMigrations, metrics.Labels{"from": strconv.Itoa(schemaVersion)})
+	return migrated, true, nil
+}
+
+// EncodeVersions encodes the versions to store in the given schema version, which ValidateWriteSchemaVersion accepts.
+func EncodeVersions(versions types.VersionList, schemaVersion int) ([]byte, error) {
+	if err := ValidateWriteSchemaVersion(schemaVersion); err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(versions)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode versions: %w", err)
+	}
+	if schemaVersion == CurrentSchemaVersion {
+		return data, nil
+	}
+
+	raw, err := decodeRaw(CurrentSchemaVersion, data)
+	if err != nil {
+		return nil, err
+	}
+	if err := migrations[schemaVersion].Down(raw); err != nil {
+		return nil, fmt.Errorf("could not migrate versions to schema version %d: %w", schemaVersion, err)
+	}
+	return json.Marshal(raw)
+}
+
+func decodeRaw(schemaVersion int, data []byte) ([]map[string]any, error) {
+	var versions []map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	// keep numbers as they are written, migrations which do not touch them should not change them
+	decoder.UseNumber()
+	if err := decoder.Decode(&versions); err != nil {
+		return nil, fmt.Errorf("could not decode versions of schema version %d: %w", schemaVersion, err)
+	}
+	return versions, nil
+}
@@ -0,0 +1,77 @@
+package providercache
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/opentofu/registry/internal/providers/types"
+)
+
+func TestMigrationsCoverEverySchemaVersion(t *testing.T) {
+	if len(migrations) != CurrentSchemaVersion {
+		t.Errorf("%d migrations for schema version %d, need one per older version", len(migrations), CurrentSchemaVersion)
+	}
+}
+
+func TestMigrateVersions(t *testing.T) {
+	data := []byte(`[{"version":"1.0.0","protocols":["5.0"],"download_details":[]}]`)
+
+	migrated, ok, err := MigrateVersions(0, data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ok {
+		t.Fatalf("expected an item of schema version 0 to be migrated")
+	}
+	var versions []map[string]any
+	if err := json.Unmarshal(migrated, &versions); err != nil || len(versions) != 1 || versions[0]["version"] != "1.0.0" {
+		t.Errorf("unexpected migrated versions %s: %v", migrated, err)
+	}
+
+	for _, schemaVersion := range []int{CurrentSchemaVersion, CurrentSchemaVersion + 1} {
+		got, ok, err := MigrateVersions(schemaVersion, data)
+		if err != nil || ok || string(got) != string(data) {
+			t.Errorf("MigrateVersions(%d) = %s, %v, %v, want the data unchanged", schemaVersion, got, ok, err)
+		}
+	}
+
+	if _, _, err := MigrateVersions(0, []byte(`{"not":"a list"}`)); err == nil {
+		t.Errorf("expected an error for invalid versions")
+	}
+}
+
+func TestEncodeVersions(t *testing.T) {
+	versions := types.VersionList{{Version: "1.0.0", Protocols: []string{"5.0"}}}
+
+	current, err := EncodeVersions(versions, CurrentSchemaVersion)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// items written for the previous release come back the same once migrated
+	previous, err := EncodeVersions(versions, PreviousSchemaVersion)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	migrated, _, err := MigrateVersions(PreviousSchemaVersion, previous)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got, want types.VersionList
+	if err := json.Unmarshal(migrated, &got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := json.Unmarshal(current, &want); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("migrated versions = %+v, want %+v", got, want)
+	}
+
+	for _, schemaVersion := range []int{PreviousSchemaVersion - 1, CurrentSchemaVersion + 1} {
+		if _, err := EncodeVersions(versions, schemaVersion); err == nil {
+			t.Errorf("expected an error writing schema version %d", schemaVersion)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package providercache
+
+import (
+	"context"
+
+	"github.com/opentofu/registry/internal/platform"
+	"github.com/opentofu/registry/internal/providers/types"
+)
+
+// PlatformFilter restricts the platforms of the provider listings going in and out of a cache, so that listings
+// ingested before the allowlist changed are served the same way as new ones.
+type PlatformFilter struct {
+	Cache
+	// Allowlist returns the allowlist of the provider stored under key.
+	Allowlist func(key string) platform.Allowlist
+}
+
+var _ Cache = PlatformFilter{}
+
+func (f PlatformFilter) GetItem(ctx context.Context, key string) (*types.CacheItem, error) {
+	item, err := f.Cache.GetItem(ctx, key)
+	if err != nil || item == nil {
+		return item, err
+	}
+	return f.filter(key, item), nil
+}
+
+func (f PlatformFilter) GetItems(ctx context.Context, keys []string) (map[string]*types.CacheItem, error) {
+	items, err := f.Cache.GetItems(ctx, keys)
+	if err != nil {
+		return items, err
+	}
+	for key, item := range items {
+		items[key] = f.filter(key, item)
+	}
+	return items, nil
+}
+
+func (f PlatformFilter) Store(ctx context.Context, key string, versions types.VersionList, metadata types.ProviderMetadata) error {
+	return f.Cache.Store(ctx, key, versions.WithPlatforms(f.Allowlist(key)), metadata)
+}
+
+func (f PlatformFilter) AppendVersions(ctx context.Context, key string, versions types.VersionList, metadata types.ProviderMetadata) error {
+	return f.Cache.AppendVersions(ctx, key, versions.WithPlatforms(f.Allowlist(key)), metadata)
+}
+
+func (f PlatformFilter) UpdateVersions(ctx context.Context, key string, update func(item *types.CacheItem) error) error {
+	return f.Cache.UpdateVersions(ctx, key, func(item *types.CacheItem) error {
+		item.Versions = item.Versions.WithPlatforms(f.Allowlist(key))
+		return update(item)
+	})
+}
+
+func (f PlatformFilter) StoreBatch(ctx context.Context, entries []Entry) error {
+	filtered := make([]Entry, len(entries))
+	for i, entry := range entries {
+		filtered[i] = Entry{Key: entry.Key, Versions: entry.Versions.WithPlatforms(f.Allowlist(entry.Key)), Metadata: entry.Metadata}
+	}
+	return f.Cache.StoreBatch(ctx, filtered)
+}
+
+func (f PlatformFilter) filter(key string, item *types.CacheItem) *types.CacheItem {
+	filtered := *item
+	filtered.Versions = item.Versions.WithPlatforms(f.Allowlist(key))
+	return &filtered
+}
@@ -0,0 +1,117 @@
+package providercache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dynamodbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/opentofu/registry/internal/metrics"
+	"github.com/opentofu/registry/internal/providers/types"
+	"golang.org/x/exp/slog"
+)
+
+// ErrConflict is returned when an item could not be written because it kept being stored concurrently.
+var ErrConflict = errors.New("the item kept being stored concurrently")
+
+// maxWriteAttempts is the number of times putRevision reads and writes an item before giving up on the concurrent
+// writes.
+const maxWriteAttempts = 3
+
+// putRevision writes the item stored under key as build returns it from the item as it is read, nil if there is none,
+// at the revision following the one read, updated now unless build returns its update time. The write is conditional on the revision being still the
+// one read, and is read and built again if it is not, up to maxWriteAttempts times. Only the key and revision of the
+// item are read when build does not use the item, see whole.
+//
+// The data of items too large for the table is written to the overflow bucket before the item, so an attempt losing
+// the race rewrites it; the next attempt writes it again.
+func (p *Handler) putRevision(ctx context.Context, key, operation string, whole bool, build func(current map[string]dynamodbtypes.AttributeValue) (*types.CacheItem, error)) error {
+	for attempt := 1; attempt <= maxWriteAttempts; attempt++ {
+		input := &dynamodb.GetItemInput{
+			TableName: p.TableName,
+			Key: map[string]dynamodbtypes.AttributeValue{
+				"provider": &dynamodbtypes.AttributeValueMemberS{Value: key},
+			},
+			// a stale read would only fail the condition
+			ConsistentRead: aws.Bool(true),
+		}
+		if !whole {
+			input.ProjectionExpression = aws.String("#provider, #revision")
+			input.ExpressionAttributeNames = map[string]string{"#provider": "provider", "#revision": "revision"}
+		}
+		result, err := p.Client.GetItem(ctx, input)
+		if err != nil {
+			slog.Error("got error calling GetItem", "error", err)
+			return fmt.Errorf("got error calling GetItem: %w", err)
+		}
+
+		var read CompressedCacheItem
+		current := result.Item
+		if len(current) == 0 {
+			current = nil
+		} else if err := attributevalue.UnmarshalMap(current, &read); err != nil {
+			return fmt.Errorf("failed to unmarshal item %s: %w", key, err)
+		}
+
+		item, err := build(current)
+		if err != nil {
+			return err
+		}
+		marshalledItem, err := p.marshalItem(ctx, key, item, read.Revision+1)
+		if err != nil {
+			return err
+		}
+
+		condition, values := revisionCondition(current != nil, read.Revision)
+		putResult, err := p.Client.PutItem(ctx, &dynamodb.PutItemInput{
+			Item:                      marshalledItem,
+			TableName:                 p.TableName,
+			ConditionExpression:       aws.String(condition),
+			ExpressionAttributeValues: values,
+			// the previous item tells which chunk records are no longer used
+			ReturnValues: dynamodbtypes.ReturnValueAllOld,
+		})
+		var conditionErr *dynamodbtypes.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			slog.Info("Item was stored since it was read, reading it again", "key", key, "revision", read.Revision, "attempt", attempt)
+			metrics.Inc(metrics.CacheWriteConflicts, metrics.Labels{"operation": operation})
+			// the chunk records of this attempt are not pointed at by any item
+			var unused CompressedCacheItem
+			if err := attributevalue.UnmarshalMap(marshalledItem, &unused); err == nil && unused.Chunks > 0 {
+				p.deleteChunks(ctx, unused)
+			}
+			continue
+		}
+		if err != nil {
+			slog.Error("got error calling PutItem", "error", err)
+			return fmt.Errorf("got error calling PutItem: %w", err)
+		}
+
+		var previous CompressedCacheItem
+		if err := attributevalue.UnmarshalMap(putResult.Attributes, &previous); err == nil && previous.Chunks > 0 {
+			p.deleteChunks(ctx, previous)
+		}
+		return nil
+	}
+
+	slog.Error("Item kept being stored concurrently, giving up", "key", key, "attempts", maxWriteAttempts)
+	return fmt.Errorf("could not store %s: %w", key, ErrConflict)
+}
+
+// revisionCondition returns the condition of a write over the item read at revision, or over no item.
+func revisionCondition(exists bool, revision int64) (string, map[string]dynamodbtypes.AttributeValue) {
+	switch {
+	case !exists:
+		return "attribute_not_exists(provider)", nil
+	case revision == 0:
+		return "attribute_exists(provider) AND attribute_not_exists(revision)", nil
+	default:
+		return "revision = :revision", map[string]dynamodbtypes.AttributeValue{
+			":revision": &dynamodbtypes.AttributeValueMemberN{Value: strconv.FormatInt(revision, 10)},
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package providercache
+
+import (
+	"testing"
+
+	dynamodbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func TestRevisionCondition(t *testing.T) {
+	tests := []struct {
+		name      string
+		exists    bool
+		revision  int64
+		condition string
+		value     string
+	}{
+		{name: "no item", condition: "attribute_not_exists(provider)"},
+		{name: "item without revision", exists: true, condition: "attribute_exists(provider) AND attribute_not_exists(revision)"},
+		{name: "item with revision", exists: true, revision: 7, condition: "revision = :revision", value: "7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			condition, values := revisionCondition(tt.exists, tt.revision)
+			if condition != tt.condition {
+				t.Errorf("condition = %q, want %q", condition, tt.condition)
+			}
+			if tt.value == "" {
+				if values != nil {
+					t.Errorf("expected no values, got %v", values)
+				}
+				return
+			}
+			value, ok := values[":revision"].(*dynamodbtypes.AttributeValueMemberN)
+			if !ok || value.Value != tt.value {
+				t.Errorf(":revision = %v, want %s", values[":revision"], tt.value)
+			}
+		})
+	}
+}
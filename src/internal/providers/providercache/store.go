@@ -5,20 +5,83 @@ import (
 	"compress/gzip"
 	"context"
 	"encoding/base64"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"path"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dynamodbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/opentofu/registry/internal/providers/types"
 	"golang.org/x/exp/slog"
 )
 
 type CompressedCacheItem struct {
-	Provider    string    `dynamodbav:"provider"`
-	Data        string    `dynamodbav:"data"`
-	LastUpdated time.Time `dynamodbav:"last_updated"`
+	Provider    string                 `dynamodbav:"provider"`
+	Data        string                 `dynamodbav:"data"`
+	LastUpdated time.Time              `dynamodbav:"last_updated"`
+	Metadata    types.ProviderMetadata `dynamodbav:"metadata"`
+	// SchemaVersion is absent, and so 0, on the items stored before it was recorded.
+	SchemaVersion int `dynamodbav:"schema_version"`
+	// Namespace is the key of the namespace index, see ListNamespace.
+	Namespace string `dynamodbav:"namespace,omitempty"`
+	// SearchPartition is the key of the search index, see Search. It is left out of the items of the providers known
+	// not to exist, which are not searched.
+	SearchPartition string `dynamodbav:"search_partition,omitempty"`
+	// DataObject is the key of the object of the overflow bucket holding the data of the item instead of Data, for
+	// the items too large to be stored in the table.
+	DataObject string `dynamodbav:"data_object,omitempty"`
+	// Chunks is the number of chunk records holding the data of the item instead of Data, written at ChunkGeneration,
+	// for the items too large to be stored in a single record when there is no overflow bucket, see storeChunks.
+	Chunks          int   `dynamodbav:"chunks,omitempty"`
+	ChunkGeneration int64 `dynamodbav:"chunk_generation,omitempty"`
+	// Revision is incremented by every write of the item, which is conditional on the revision it read, see
+	// putRevision. It is absent, and so 0, on the items stored before it was recorded.
+	Revision int64 `dynamodbav:"revision,omitempty"`
+}
+
+// maxInlineDataSize is the size of the compressed data above which it is written to the overflow bucket, or split into
+// chunk records without one, leaving room for the other attributes under the 400KB limit of DynamoDB items.
+const maxInlineDataSize = 350 * 1024
+
+// overflowKey returns the object key of the data of an item written to the overflow bucket,
+// `cache/<table>/<key>.json.gz`, so that the tables of the tenants can share the bucket.
+func overflowKey(tableName, key string) string {
+	return path.Join("cache", tableName, key+".json.gz")
+}
+
+// storeData returns the compressed data to store in the item, or, if it is too large for the item and the handler
+// has an overflow bucket, writes it there and returns the key of the object instead.
+func (p *Handler) storeData(ctx context.Context, key, compressedData string) (string, string, error) {
+	if len(compressedData) <= maxInlineDataSize || p.Overflow == nil {
+		return compressedData, "", nil
+	}
+
+	objectKey := overflowKey(*p.TableName, key)
+	// the data is kept base64 encoded, so that it is read back like the data of the table
+	if err := p.Overflow.Put(ctx, objectKey, []byte(compressedData), "text/plain"); err != nil {
+		return "", "", fmt.Errorf("got error writing item data to the overflow bucket: %w", err)
+	}
+	slog.Info("Stored item data in the overflow bucket", "key", key, "object", objectKey, "size", len(compressedData))
+	return "", objectKey, nil
+}
+
+// searchPartition returns the search index key of an item with the given metadata.
+func searchPartition(metadata types.ProviderMetadata) string {
+	if metadata.NotFound {
+		return ""
+	}
+	return searchIndexPartition
+}
+
+// keyNamespace returns the namespace of a provider key, `<namespace>/<type>`.
+func keyNamespace(key string) string {
+	namespace, _, _ := strings.Cut(key, "/")
+	return namespace
 }
 
 func compress(data []byte) (string, error) {
@@ -35,44 +98,203 @@ func compress(data []byte) (string, error) {
 	return base64.StdEncoding.EncodeToString(b.Bytes()), nil
 }
 
-func (p *Handler) Store(ctx context.Context, key string, versions types.VersionList) error {
-	jsonData, err := json.Marshal(versions)
+// marshalItem encodes the versions, metadata and update time of the item, now if it has none, into the item stored
+// under key at the given revision, writing its data to the overflow bucket or to chunk records first if it is too
+// large for a single record.
+func (p *Handler) marshalItem(ctx context.Context, key string, item *types.CacheItem, revision int64) (map[string]dynamodbtypes.AttributeValue, error) {
+	jsonData, err := EncodeVersions(item.Versions, p.WriteSchemaVersion)
 	if err != nil {
 		slog.Error("got error marshalling item to JSON", "error", err)
-		return fmt.Errorf("got error marshalling item to JSON: %w", err)
+		return nil, fmt.Errorf("got error marshalling item to JSON: %w", err)
 	}
 
 	compressedData, err := compress(jsonData)
 	if err != nil {
 		slog.Error("got error compressing JSON data", "error", err)
-		return fmt.Errorf("got error compressing JSON data: %w", err)
+		return nil, fmt.Errorf("got error compressing JSON data: %w", err)
+	}
+
+	data, dataObject, err := p.storeData(ctx, key, compressedData)
+	if err != nil {
+		slog.Error("got error storing large item data", "error", err)
+		return nil, err
+	}
+
+	lastUpdated := item.LastUpdated
+	if lastUpdated.IsZero() {
+		lastUpdated = time.Now()
 	}
 
 	// make an anonymous type to satisfy the MarshalMap function
 	toCache := CompressedCacheItem{
-		Provider:    key,
-		Data:        compressedData,
-		LastUpdated: time.Now(),
+		Provider:        key,
+		Data:            data,
+		LastUpdated:     lastUpdated,
+		Metadata:        item.Metadata,
+		SchemaVersion:   p.WriteSchemaVersion,
+		Namespace:       keyNamespace(key),
+		SearchPartition: searchPartition(item.Metadata),
+		DataObject:      dataObject,
+		Revision:        revision,
+	}
+	if len(toCache.Data) > maxInlineDataSize {
+		if err := p.storeChunks(ctx, &toCache, item.Versions); err != nil {
+			slog.Error("got error storing item in chunks", "error", err)
+			return nil, fmt.Errorf("got error storing item in chunks: %w", err)
+		}
 	}
 
 	marshalledItem, err := attributevalue.MarshalMap(toCache)
 	if err != nil {
 		slog.Error("got error marshalling dynamodb item", "error", err)
-		return fmt.Errorf("got error marshalling dynamodb item: %w", err)
+		return nil, fmt.Errorf("got error marshalling dynamodb item: %w", err)
+	}
+	return marshalledItem, nil
+}
+
+// Store replaces the item, on the condition that it was not stored between reading its revision and writing it, see
+// putRevision. A concurrent write is not merged, the item is replaced again, but no two writes get the same revision.
+func (p *Handler) Store(ctx context.Context, key string, versions types.VersionList, metadata types.ProviderMetadata) error {
+	slog.Info("Storing provider versions", "key", key, "versions", len(versions))
+	err := p.putRevision(ctx, key, "store", false, func(map[string]dynamodbtypes.AttributeValue) (*types.CacheItem, error) {
+		return &types.CacheItem{Versions: versions, Metadata: metadata}, nil
+	})
+	if err != nil {
+		return err
+	}
+	slog.Info("Successfully stored provider versions", "key", key, "versions", len(versions))
+	return nil
+}
+
+// StoreBatch stores the items one at a time, like Store. BatchWriteItem would take fewer calls, but it neither writes
+// conditionally nor returns the items it replaces, so a batch could overwrite a concurrent write of an item and leave
+// the chunk records of the one it replaced behind. The items failing to store do not prevent storing the others.
+func (p *Handler) StoreBatch(ctx context.Context, entries []Entry) error {
+	slog.Info("Storing provider versions in batch", "items", len(entries))
+	var errs []error
+	for _, entry := range entries {
+		entry := entry
+		err := p.putRevision(ctx, entry.Key, "store_batch", false, func(map[string]dynamodbtypes.AttributeValue) (*types.CacheItem, error) {
+			return &types.CacheItem{Versions: entry.Versions, Metadata: entry.Metadata}, nil
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("could not store %s: %w", entry.Key, err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	slog.Info("Successfully stored provider versions in batch", "items", len(entries))
+	return nil
+}
+
+// StoreMetadata updates the metadata of the item in place, so that its update time, which the next refresh fetches
+// the releases from, is left as it is. Its revision is incremented like by any other write.
+func (p *Handler) StoreMetadata(ctx context.Context, key string, metadata types.ProviderMetadata) error {
+	marshalledMetadata, err := attributevalue.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("got error marshalling metadata: %w", err)
 	}
 
-	putItemInput := &dynamodb.PutItemInput{
-		Item:      marshalledItem,
+	_, err = p.Client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
 		TableName: p.TableName,
+		Key: map[string]dynamodbtypes.AttributeValue{
+			"provider": &dynamodbtypes.AttributeValueMemberS{Value: key},
+		},
+		// a write of the whole item which read it before the update has to start over
+		UpdateExpression:    aws.String("SET metadata = :metadata ADD revision :one"),
+		ConditionExpression: aws.String("attribute_exists(provider)"),
+		ExpressionAttributeValues: map[string]dynamodbtypes.AttributeValue{
+			":metadata": marshalledMetadata,
+			":one":      &dynamodbtypes.AttributeValueMemberN{Value: "1"},
+		},
+	})
+	var conditionErr *dynamodbtypes.ConditionalCheckFailedException
+	if errors.As(err, &conditionErr) {
+		return nil
+	}
+	if err != nil {
+		slog.Error("got error calling UpdateItem", "error", err)
+		return fmt.Errorf("got error calling UpdateItem: %w", err)
 	}
+	return nil
+}
 
-	slog.Info("Storing provider versions", "key", key, "versions", len(versions))
-	_, err = p.Client.PutItem(ctx, putItemInput)
+// Delete deletes the item and its chunk records. The data written to the overflow bucket is left, the next write of
+// the item overwrites it.
+func (p *Handler) Delete(ctx context.Context, key string) error {
+	slog.Info("Deleting provider versions", "key", key)
+	result, err := p.Client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: p.TableName,
+		Key: map[string]dynamodbtypes.AttributeValue{
+			"provider": &dynamodbtypes.AttributeValueMemberS{Value: key},
+		},
+		ReturnValues: dynamodbtypes.ReturnValueAllOld,
+	})
 	if err != nil {
-		slog.Error("got error calling PutItem", "error", err)
-		return fmt.Errorf("got error calling PutItem: %w", err)
+		slog.Error("got error calling DeleteItem", "error", err)
+		return fmt.Errorf("got error calling DeleteItem: %w", err)
 	}
 
-	slog.Info("Successfully stored provider versions", "key", key, "versions", len(versions))
+	var previous CompressedCacheItem
+	if err := attributevalue.UnmarshalMap(result.Attributes, &previous); err == nil && previous.Chunks > 0 {
+		p.deleteChunks(ctx, previous)
+	}
 	return nil
 }
+
+// rewrite writes back an item migrated on read, with the same update time so that it is still refreshed when due. It
+// does not overwrite the item if it was stored again since it was read, as the new item is current already. Failures
+// are logged, the item is migrated again on the next read. While items are written in the previous schema version,
+// nothing is written back, as the previous release could not read it.
+func (p *Handler) rewrite(ctx context.Context, item CompressedCacheItem, versions []byte) {
+	if p.WriteSchemaVersion != CurrentSchemaVersion {
+		return
+	}
+	compressedData, err := compress(versions)
+	if err != nil {
+		slog.Error("got error compressing migrated JSON data", "key", item.Provider, "error", err)
+		return
+	}
+	data, dataObject, err := p.storeData(ctx, item.Provider, compressedData)
+	if err != nil {
+		slog.Error("got error storing migrated large item data", "key", item.Provider, "error", err)
+		return
+	}
+	fromVersion, revision := item.SchemaVersion, item.Revision
+	item.Revision++
+	item.Data = data
+	item.DataObject = dataObject
+	item.SchemaVersion = CurrentSchemaVersion
+	item.Namespace = keyNamespace(item.Provider)
+	item.SearchPartition = searchPartition(item.Metadata)
+
+	marshalledItem, err := attributevalue.MarshalMap(item)
+	if err != nil {
+		slog.Error("got error marshalling migrated dynamodb item", "key", item.Provider, "error", err)
+		return
+	}
+
+	// the revision also catches the metadata updates, which leave the schema version as it is
+	condition, values := revisionCondition(true, revision)
+	if values == nil {
+		values = make(map[string]dynamodbtypes.AttributeValue)
+	}
+	values[":current"] = &dynamodbtypes.AttributeValueMemberN{Value: strconv.Itoa(CurrentSchemaVersion)}
+	_, err = p.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		Item:                      marshalledItem,
+		TableName:                 p.TableName,
+		ConditionExpression:       aws.String("(attribute_not_exists(schema_version) OR schema_version < :current) AND " + condition),
+		ExpressionAttributeValues: values,
+	})
+	var conditionErr *dynamodbtypes.ConditionalCheckFailedException
+	if errors.As(err, &conditionErr) {
+		slog.Info("Migrated item was stored again since it was read, not rewriting it", "key", item.Provider)
+		return
+	}
+	if err != nil {
+		slog.Error("got error rewriting migrated item", "key", item.Provider, "error", err)
+		return
+	}
+	slog.Info("Rewrote migrated item", "key", item.Provider, "from_schema_version", fromVersion)
+}
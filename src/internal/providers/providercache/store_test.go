@@ -0,0 +1,31 @@
+package providercache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/opentofu/registry/internal/providers/types"
+)
+
+func TestStoreBatchWritesRevisions(t *testing.T) {
+	handler, table := newTestHandler(t)
+	stored := storeTestItem(t, handler, "example/stored", types.VersionList{{Version: "1.0.0"}})
+
+	err := handler.StoreBatch(context.Background(), []Entry{
+		{Key: "example/stored", Versions: types.VersionList{{Version: "1.1.0"}}},
+		{Key: "example/new", Versions: types.VersionList{{Version: "0.1.0"}}},
+	})
+	if err != nil {
+		t.Fatalf("StoreBatch() error = %v", err)
+	}
+	if table.puts != 3 {
+		t.Errorf("expected each item to be written with PutItem, got %d writes", table.puts)
+	}
+
+	if item, _ := handler.GetItem(context.Background(), "example/stored"); item == nil || item.Revision != stored.Revision+1 || item.Versions[0].Version != "1.1.0" {
+		t.Errorf("expected example/stored to be replaced at revision %d, got %+v", stored.Revision+1, item)
+	}
+	if item, _ := handler.GetItem(context.Background(), "example/new"); item == nil || item.Revision != 1 {
+		t.Errorf("expected example/new to be stored at revision 1, got %+v", item)
+	}
+}
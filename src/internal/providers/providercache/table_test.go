@@ -0,0 +1,132 @@
+package providercache
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/opentofu/registry/internal/awstest"
+	"github.com/opentofu/registry/internal/providers/types"
+)
+
+// attribute is an attribute value as DynamoDB sends it, only the string and number ones are looked into.
+type attribute struct {
+	S string `json:"S,omitempty"`
+	N string `json:"N,omitempty"`
+}
+
+// testTable serves the GetItem and PutItem calls of a handler from memory, evaluating the conditions putRevision
+// writes with, and the queries of the search index.
+type testTable struct {
+	mu    sync.Mutex
+	items map[string]map[string]json.RawMessage
+	puts  int
+}
+
+// newTestHandler returns a handler storing its items in a testTable.
+func newTestHandler(t *testing.T) (*Handler, *testTable) {
+	table := &testTable{items: make(map[string]map[string]json.RawMessage)}
+	client := awstest.DynamoDBClient(t, table)
+	return &Handler{TableName: aws.String("providers"), Client: client, WriteSchemaVersion: CurrentSchemaVersion}, table
+}
+
+func (table *testTable) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	table.mu.Lock()
+	defer table.mu.Unlock()
+
+	var input struct {
+		Key                       map[string]attribute
+		IndexName                 string
+		Item                      map[string]json.RawMessage
+		ConditionExpression       string
+		ExpressionAttributeValues map[string]attribute
+		ReturnValues              string
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeTableError(w, "ValidationException", err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+	switch strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "DynamoDB_20120810.") {
+	case "GetItem":
+		_ = json.NewEncoder(w).Encode(map[string]any{"Item": table.items[input.Key["provider"].S]})
+	case "PutItem":
+		var provider attribute
+		_ = json.Unmarshal(input.Item["provider"], &provider)
+		previous, exists := table.items[provider.S]
+		var revision attribute
+		_ = json.Unmarshal(previous["revision"], &revision)
+
+		var ok bool
+		switch input.ConditionExpression {
+		case "attribute_not_exists(provider)":
+			ok = !exists
+		case "attribute_exists(provider) AND attribute_not_exists(revision)":
+			ok = exists && revision.N == ""
+		case "revision = :revision":
+			ok = exists && revision.N == input.ExpressionAttributeValues[":revision"].N
+		default:
+			writeTableError(w, "ValidationException", "unexpected condition "+input.ConditionExpression)
+			return
+		}
+		if !ok {
+			writeTableError(w, "ConditionalCheckFailedException", "The conditional request failed")
+			return
+		}
+
+		table.items[provider.S] = input.Item
+		table.puts++
+		if input.ReturnValues == "ALL_OLD" {
+			_ = json.NewEncoder(w).Encode(map[string]any{"Attributes": previous})
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	case "Query":
+		if input.IndexName != searchIndexName {
+			writeTableError(w, "ValidationException", "unexpected index "+input.IndexName)
+			return
+		}
+		var keys []string
+		for key, item := range table.items {
+			var partition attribute
+			_ = json.Unmarshal(item["search_partition"], &partition)
+			if partition.S == input.ExpressionAttributeValues[":partition"].S && strings.Contains(key, input.ExpressionAttributeValues[":query"].S) {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		results := make([]map[string]attribute, len(keys))
+		for i, key := range keys {
+			results[i] = map[string]attribute{"provider": {S: key}}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"Items": results})
+	default:
+		writeTableError(w, "ValidationException", "unexpected operation "+r.Header.Get("X-Amz-Target"))
+	}
+}
+
+func writeTableError(w http.ResponseWriter, code, message string) {
+	w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+	w.WriteHeader(http.StatusBadRequest)
+	_ = json.NewEncoder(w).Encode(map[string]string{"__type": "com.amazonaws.dynamodb.v20120810#" + code, "message": message})
+}
+
+// storeTestItem stores the versions under key and returns the item as it was stored.
+func storeTestItem(t *testing.T, handler *Handler, key string, versions types.VersionList) *types.CacheItem {
+	t.Helper()
+	ctx := context.Background()
+	if err := handler.Store(ctx, key, versions, types.ProviderMetadata{}); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+	item, err := handler.GetItem(ctx, key)
+	if err != nil || item == nil {
+		t.Fatalf("GetItem() = %v, %v", item, err)
+	}
+	return item
+}
@@ -1,9 +1,95 @@
 package providers
 
-import "fmt"
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
 
 // GetRepoName returns the repo name for a provider
 // The repo name should match the format `terraform-provider-<name>`
 func GetRepoName(name string) string {
 	return fmt.Sprintf("terraform-provider-%s", name)
 }
+
+// typePlaceholder is replaced with the provider type in repo name patterns.
+const typePlaceholder = "{type}"
+
+//nolint:gochecknoglobals // This should be treated as a constant.
+var repoNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.{}-]{1,100}$`)
+
+// RepoNames maps either a namespace or a `<namespace>/<type>` provider address to the name of the repositories of its
+// providers, for the providers whose repositories do not follow the naming convention, e.g. forks or ecosystems which
+// renamed them. The names are patterns in which `{type}` is replaced with the type of the provider.
+type RepoNames map[string]string
+
+// ParseRepoNames parses the PROVIDER_REPOSITORIES value, a JSON object of RepoNames, e.g.
+// `{"example": "opentofu-provider-{type}", "example/foo": "foo"}`.
+func ParseRepoNames(value string) (RepoNames, error) {
+	names := make(RepoNames)
+	if value == "" {
+		return names, nil
+	}
+
+	var settings map[string]string
+	if err := json.Unmarshal([]byte(value), &settings); err != nil {
+		return nil, fmt.Errorf("could not parse PROVIDER_REPOSITORIES: %w", err)
+	}
+
+	for key, pattern := range settings {
+		if !repoNamePattern.MatchString(pattern) || strings.Count(pattern, "{")+strings.Count(pattern, "}") != 2*strings.Count(pattern, typePlaceholder) {
+			return nil, fmt.Errorf("invalid repository name %q for %s in PROVIDER_REPOSITORIES", pattern, key)
+		}
+		// all the providers of a namespace can not live in the same repository
+		if !strings.Contains(key, "/") && !strings.Contains(pattern, typePlaceholder) {
+			return nil, fmt.Errorf("the repository name %q for namespace %s in PROVIDER_REPOSITORIES needs a %s placeholder", pattern, key, typePlaceholder)
+		}
+		names[strings.ToLower(key)] = pattern
+	}
+	return names, nil
+}
+
+// RepoName returns the name of the repository of the given provider: the one configured for the provider if any, else
+// the one configured for its namespace, else the one following the naming convention.
+func (r RepoNames) RepoName(namespace, providerType string) string {
+	pattern, ok := r[strings.ToLower(fmt.Sprintf("%s/%s", namespace, providerType))]
+	if !ok {
+		if pattern, ok = r[strings.ToLower(namespace)]; !ok {
+			return GetRepoName(providerType)
+		}
+	}
+	return strings.ReplaceAll(pattern, typePlaceholder, providerType)
+}
+
+// ProviderType returns the type of the provider of the namespace whose repository has the given name, the inverse of
+// RepoName, and false if the repository does not hold a provider of the namespace.
+func (r RepoNames) ProviderType(namespace, repoName string) (string, bool) {
+	var candidates []string
+	prefix := strings.ToLower(namespace) + "/"
+	for key := range r {
+		if providerType, ok := strings.CutPrefix(key, prefix); ok {
+			candidates = append(candidates, providerType)
+		}
+	}
+	if pattern, ok := r[strings.ToLower(namespace)]; ok {
+		// the placeholders all stand for the type, so its length follows from the length of the name
+		placeholders := strings.Count(pattern, typePlaceholder)
+		start := strings.Index(pattern, typePlaceholder)
+		length := len(repoName) - len(pattern) + placeholders*len(typePlaceholder)
+		if placeholders > 0 && length > 0 && length%placeholders == 0 && start+length/placeholders <= len(repoName) {
+			candidates = append(candidates, repoName[start:start+length/placeholders])
+		}
+	}
+	if providerType, ok := strings.CutPrefix(repoName, GetRepoName("")); ok && providerType != "" {
+		candidates = append(candidates, providerType)
+	}
+
+	// the candidates are checked against RepoName, which decides which setting applies to the provider
+	for _, providerType := range candidates {
+		if strings.EqualFold(r.RepoName(namespace, providerType), repoName) {
+			return strings.ToLower(providerType), true
+		}
+	}
+	return "", false
+}
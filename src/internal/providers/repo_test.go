@@ -0,0 +1,63 @@
+package providers
+
+import "testing"
+
+func TestRepoNames(t *testing.T) {
+	names, err := ParseRepoNames(`{"Example": "opentofu-provider-{type}", "example/foo": "foo"}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		namespace, providerType, want string
+	}{
+		{namespace: "example", providerType: "foo", want: "foo"},
+		{namespace: "example", providerType: "bar", want: "opentofu-provider-bar"},
+		{namespace: "other", providerType: "bar", want: "terraform-provider-bar"},
+	}
+	for _, tt := range tests {
+		if got := names.RepoName(tt.namespace, tt.providerType); got != tt.want {
+			t.Errorf("RepoName(%q, %q) = %q, want %q", tt.namespace, tt.providerType, got, tt.want)
+		}
+	}
+
+	var none RepoNames
+	if got := none.RepoName("example", "foo"); got != "terraform-provider-foo" {
+		t.Errorf("RepoName() without configuration = %q", got)
+	}
+
+	reverse := []struct {
+		namespace, repoName, want string
+	}{
+		{namespace: "example", repoName: "foo", want: "foo"},
+		{namespace: "example", repoName: "opentofu-provider-bar", want: "bar"},
+		{namespace: "Example", repoName: "opentofu-provider-Bar", want: "bar"},
+		{namespace: "other", repoName: "terraform-provider-bar", want: "bar"},
+		// the repositories of the namespace are named differently
+		{namespace: "example", repoName: "terraform-provider-bar"},
+		// foo has a repository of its own
+		{namespace: "example", repoName: "opentofu-provider-foo"},
+		{namespace: "other", repoName: "terraform-aws-vpc"},
+		{namespace: "other", repoName: "terraform-provider-"},
+	}
+	for _, tt := range reverse {
+		got, ok := names.ProviderType(tt.namespace, tt.repoName)
+		if got != tt.want || ok != (tt.want != "") {
+			t.Errorf("ProviderType(%q, %q) = %q, %v, want %q", tt.namespace, tt.repoName, got, ok, tt.want)
+		}
+	}
+
+	invalid := map[string]string{
+		"not json":            `{`,
+		"namespace without":   `{"example": "providers"}`,
+		"unknown placeholder": `{"example": "provider-{name}"}`,
+		"invalid characters":  `{"example/foo": "foo/bar"}`,
+	}
+	for name, value := range invalid {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseRepoNames(value); err == nil {
+				t.Errorf("ParseRepoNames(%s) succeeded, want an error", value)
+			}
+		})
+	}
+}
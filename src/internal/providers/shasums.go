@@ -0,0 +1,64 @@
+package providers
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// maxShaSumsSize caps the size of the SHA256SUMS files read. Releases list a few dozen archives, so anything bigger
+// is not a SHA256SUMS file.
+const maxShaSumsSize = 1 << 20
+
+// ParseShaSums parses the contents of a SHA256SUMS file, as written by `sha256sum`, into the checksum of each file.
+// The file is published by the provider author, so lines which are not a valid checksum and a plain filename are
+// skipped, and files over maxShaSumsSize are rejected.
+func ParseShaSums(r io.Reader) (map[string]string, error) {
+	sums := make(map[string]string)
+
+	// read one byte more than allowed to tell a file of the maximum size from a bigger one
+	limited := &io.LimitedReader{R: r, N: maxShaSumsSize + 1}
+	scanner := bufio.NewScanner(limited)
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) != 2 { //nolint:gomnd // we expect 2 parts
+			continue
+		}
+
+		// the first part is the shasum, the second part is the filename, prefixed with `*` in binary mode
+		shaSum, filename := strings.ToLower(parts[0]), strings.TrimPrefix(parts[1], "*")
+		if !validShaSum(shaSum) || !validAssetFilename(filename) {
+			continue
+		}
+		sums[filename] = shaSum
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read shasums: %w", err)
+	}
+	if limited.N == 0 {
+		return nil, fmt.Errorf("shasums file is larger than %d bytes", maxShaSumsSize)
+	}
+	return sums, nil
+}
+
+// validShaSum reports whether the value is a hex encoded SHA256 checksum.
+func validShaSum(value string) bool {
+	decoded, err := hex.DecodeString(value)
+	return err == nil && len(decoded) == 32 //nolint:gomnd // SHA256 checksums are 32 bytes.
+}
+
+// validAssetFilename reports whether the value is the plain name of a release asset, without any path or control
+// characters which could end up in URLs or logs.
+func validAssetFilename(value string) bool {
+	if value == "" || value == "." || value == ".." || len(value) > 255 { //nolint:gomnd // Maximum filename length.
+		return false
+	}
+	for _, c := range value {
+		if c < ' ' || c == 0x7f || c == '/' || c == '\\' {
+			return false
+		}
+	}
+	return true
+}
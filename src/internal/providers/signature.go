@@ -0,0 +1,143 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ProtonMail/gopenpgp/v2/crypto"
+	"github.com/opentofu/registry/internal/github"
+	"github.com/opentofu/registry/internal/providers/types"
+)
+
+// ErrSignatureMismatch is returned when the SHA256SUMS of a version is not signed by any of the given keys.
+var ErrSignatureMismatch = errors.New("the SHA256SUMS signature does not match any key of the namespace")
+
+// maxSignatureSize caps the size of the detached signatures read, real ones are a few hundred bytes.
+const maxSignatureSize = 64 << 10
+
+const armoredSignatureHeader = "-----BEGIN PGP SIGNATURE-----"
+
+// VerifySHASumsSignature downloads the SHA256SUMS file of a version and its detached signature, and returns the ID of
+// the key which signed it, or ErrSignatureMismatch if none of the keys did.
+func VerifySHASumsSignature(ctx context.Context, shaSumsURL, signatureURL string, publicKeys []types.GPGPublicKey) (string, error) {
+	if signatureURL == "" {
+		return "", fmt.Errorf("%w: the release has no signature", ErrSignatureMismatch)
+	}
+	sums, err := downloadLimited(ctx, shaSumsURL, maxShaSumsSize)
+	if err != nil {
+		return "", fmt.Errorf("could not download SHA256SUMS: %w", err)
+	}
+	signature, err := downloadLimited(ctx, signatureURL, maxSignatureSize)
+	if err != nil {
+		return "", fmt.Errorf("could not download SHA256SUMS signature: %w", err)
+	}
+	return verifySignature(sums, signature, publicKeys)
+}
+
+// verifySignature returns the ID of the key which made the detached signature of message. The expiry of the keys is
+// not checked: the registry decides which keys are served, and the versions signed before a key expired stay valid.
+func verifySignature(message, signature []byte, publicKeys []types.GPGPublicKey) (string, error) {
+	var pgpSignature *crypto.PGPSignature
+	if bytes.HasPrefix(bytes.TrimSpace(signature), []byte(armoredSignatureHeader)) {
+		var err error
+		if pgpSignature, err = crypto.NewPGPSignatureFromArmored(string(signature)); err != nil {
+			return "", fmt.Errorf("%w: %s", ErrSignatureMismatch, err)
+		}
+	} else {
+		pgpSignature = crypto.NewPGPSignature(signature)
+	}
+
+	for _, publicKey := range publicKeys {
+		key, err := crypto.NewKeyFromArmored(publicKey.ASCIIArmor)
+		if err != nil {
+			continue
+		}
+		keyRing, err := crypto.NewKeyRing(key)
+		if err != nil {
+			continue
+		}
+		if keyRing.VerifyDetached(crypto.NewPlainMessage(message), pgpSignature, 0) == nil {
+			return publicKey.KeyID, nil
+		}
+	}
+	return "", ErrSignatureMismatch
+}
+
+// maxSignatureCacheEntries caps the number of verifications a SignatureCache remembers, it starts over once full.
+const maxSignatureCacheEntries = 10000
+
+// SignatureCache remembers the results of VerifySHASumsSignature per SHA256SUMS file, so that the signature of a
+// version is only downloaded and verified once for all its platforms and downloads. The results are keyed by the set
+// of keys too: a registered or revoked key makes the versions of the namespace be verified again.
+type SignatureCache struct {
+	mu      sync.Mutex
+	results map[string]signatureResult
+}
+
+type signatureResult struct {
+	keyID string
+	err   error
+}
+
+// NewSignatureCache returns an empty SignatureCache.
+func NewSignatureCache() *SignatureCache {
+	return &SignatureCache{results: make(map[string]signatureResult)}
+}
+
+// Verify returns the ID of the key which signed the SHA256SUMS of the version, see VerifySHASumsSignature. Only the
+// successes and ErrSignatureMismatch are remembered, failures to download the files are retried on the next call.
+func (c *SignatureCache) Verify(ctx context.Context, shaSumsURL, signatureURL string, publicKeys []types.GPGPublicKey) (string, error) {
+	key := signatureCacheKey(shaSumsURL, publicKeys)
+	c.mu.Lock()
+	result, ok := c.results[key]
+	c.mu.Unlock()
+	if ok {
+		return result.keyID, result.err
+	}
+
+	keyID, err := VerifySHASumsSignature(ctx, shaSumsURL, signatureURL, publicKeys)
+	if err != nil && !errors.Is(err, ErrSignatureMismatch) {
+		return "", err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.results) >= maxSignatureCacheEntries {
+		c.results = make(map[string]signatureResult)
+	}
+	c.results[key] = signatureResult{keyID: keyID, err: err}
+	return keyID, err
+}
+
+func signatureCacheKey(shaSumsURL string, publicKeys []types.GPGPublicKey) string {
+	ids := make([]string, len(publicKeys))
+	for i, k := range publicKeys {
+		ids[i] = k.KeyID
+	}
+	sort.Strings(ids)
+	return shaSumsURL + " " + strings.Join(ids, ",")
+}
+
+func downloadLimited(ctx context.Context, url string, limit int64) ([]byte, error) {
+	contents, err := github.DownloadAssetContents(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer contents.Close()
+
+	// read one byte more than allowed to tell a file of the maximum size from a bigger one
+	data, err := io.ReadAll(io.LimitReader(contents, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("file is larger than %d bytes", limit)
+	}
+	return data, nil
+}
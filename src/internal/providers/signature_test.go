@@ -0,0 +1,114 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ProtonMail/gopenpgp/v2/crypto"
+	"github.com/opentofu/registry/internal/providers/types"
+)
+
+func TestVerifySignature(t *testing.T) {
+	signer := generateKey(t, "signer")
+	other := generateKey(t, "other")
+
+	message := []byte("0123abcd  terraform-provider-example_1.0.0_linux_amd64.zip\n")
+	keyRing, err := crypto.NewKeyRing(signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := keyRing.SignDetached(crypto.NewPlainMessage(message))
+	if err != nil {
+		t.Fatal(err)
+	}
+	armoredSignature, err := signature.GetArmored()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signerKey, otherKey := publicKey(t, signer), publicKey(t, other)
+	for name, sig := range map[string][]byte{"binary": signature.GetBinary(), "armored": []byte(armoredSignature)} {
+		t.Run(name, func(t *testing.T) {
+			keyID, err := verifySignature(message, sig, []types.GPGPublicKey{otherKey, signerKey})
+			if err != nil || keyID != signerKey.KeyID {
+				t.Errorf("verifySignature() = %q, %v, want %q", keyID, err, signerKey.KeyID)
+			}
+		})
+	}
+
+	if _, err := verifySignature(message, signature.GetBinary(), []types.GPGPublicKey{otherKey}); !errors.Is(err, ErrSignatureMismatch) {
+		t.Errorf("verifySignature() with another key = %v, want ErrSignatureMismatch", err)
+	}
+	if _, err := verifySignature([]byte("tampered"), signature.GetBinary(), []types.GPGPublicKey{signerKey}); !errors.Is(err, ErrSignatureMismatch) {
+		t.Errorf("verifySignature() of a tampered message = %v, want ErrSignatureMismatch", err)
+	}
+}
+
+func TestSignatureCache(t *testing.T) {
+	signer := generateKey(t, "signer")
+	message := []byte("0123abcd  terraform-provider-example_1.0.0_linux_amd64.zip\n")
+	keyRing, err := crypto.NewKeyRing(signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := keyRing.SignDetached(crypto.NewPlainMessage(message))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	downloads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downloads++
+		if r.URL.Path == "/SHA256SUMS.sig" {
+			w.Write(signature.GetBinary())
+			return
+		}
+		w.Write(message)
+	}))
+	defer server.Close()
+
+	cache := NewSignatureCache()
+	signerKey, otherKey := publicKey(t, signer), publicKey(t, generateKey(t, "other"))
+	for i := 0; i < 2; i++ {
+		keyID, err := cache.Verify(context.Background(), server.URL+"/SHA256SUMS", server.URL+"/SHA256SUMS.sig", []types.GPGPublicKey{signerKey})
+		if err != nil || keyID != signerKey.KeyID {
+			t.Fatalf("Verify() = %q, %v, want %q", keyID, err, signerKey.KeyID)
+		}
+	}
+	if downloads != 2 {
+		t.Errorf("downloaded %d files, want the SHA256SUMS and its signature once", downloads)
+	}
+
+	// another set of keys is verified again
+	if _, err := cache.Verify(context.Background(), server.URL+"/SHA256SUMS", server.URL+"/SHA256SUMS.sig", []types.GPGPublicKey{otherKey}); !errors.Is(err, ErrSignatureMismatch) {
+		t.Errorf("Verify() with another key = %v, want ErrSignatureMismatch", err)
+	}
+	if downloads != 4 {
+		t.Errorf("downloaded %d files, want the files downloaded again for another set of keys", downloads)
+	}
+}
+
+func generateKey(t *testing.T, name string) *crypto.Key {
+	t.Helper()
+	key, err := crypto.GenerateKey(name, name+"@example.com", "rsa", 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func publicKey(t *testing.T, key *crypto.Key) types.GPGPublicKey {
+	t.Helper()
+	armor, err := key.GetArmoredPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParseArmoredKey([]byte(armor))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return *parsed
+}
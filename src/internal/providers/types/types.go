@@ -1,9 +1,11 @@
 package types
 
 import (
+	"strings"
 	"time"
 
 	"github.com/opentofu/registry/internal/platform"
+	"github.com/opentofu/registry/internal/version"
 )
 
 // Version represents an individual provider version.
@@ -13,6 +15,58 @@ type Version struct {
 	Version   string              `json:"version"`   // The version number of the provider.
 	Protocols []string            `json:"protocols"` // The protocol versions the provider supports.
 	Platforms []platform.Platform `json:"platforms"` // A list of platforms for which this provider version is available.
+
+	// The extended metadata is only set when the client asks for it, see VersionExtensions.
+	PublishedAt  *time.Time `json:"published_at,omitempty"`  // When the release of the version was created.
+	ChangelogURL string     `json:"changelog_url,omitempty"` // Where the release notes of the version are.
+	Yanked       bool       `json:"yanked,omitempty"`        // Whether the version was yanked, see CacheVersion.Yanked.
+	YankReason   string     `json:"yank_reason,omitempty"`   // Why the version was yanked.
+}
+
+// VersionExtensions selects the extended metadata added to the versions of a listing, and whether it lists the
+// prereleases and the yanked versions.
+type VersionExtensions struct {
+	Timestamps  bool
+	Changelog   bool
+	Prereleases bool
+	Yanked      bool
+}
+
+// ToVersions converts the versions which are not prereleases like VersionList.ToVersions, with the extended metadata
+// the extensions select, when it is known. Yanked versions are left out unless the extensions include them, they are
+// then marked as such. The prereleases are converted apart, see ToPrereleases.
+func (e VersionExtensions) ToVersions(l VersionList) []Version {
+	return e.convert(l.WithoutPrereleases())
+}
+
+// ToPrereleases converts the prereleases like ToVersions if the extensions include them, and returns nil otherwise.
+// They are listed apart from the other versions so that clients which do not know about them never pick one.
+func (e VersionExtensions) ToPrereleases(l VersionList) []Version {
+	if !e.Prereleases {
+		return nil
+	}
+	return e.convert(l.Prereleases())
+}
+
+func (e VersionExtensions) convert(l VersionList) []Version {
+	if !e.Yanked {
+		l = l.WithoutYanked()
+	}
+	versions := l.ToVersions()
+	for i := range versions {
+		if e.Timestamps && !l[i].PublishedAt.IsZero() {
+			publishedAt := l[i].PublishedAt
+			versions[i].PublishedAt = &publishedAt
+		}
+		if e.Changelog {
+			versions[i].ChangelogURL = l[i].ReleaseURL
+		}
+		if l[i].Yanked != nil {
+			versions[i].Yanked = true
+			versions[i].YankReason = l[i].Yanked.Reason
+		}
+	}
+	return versions
 }
 
 // VersionDetails provides comprehensive details about a specific provider version.
@@ -28,6 +82,10 @@ type VersionDetails struct {
 	SHASumsSignatureURL string      `json:"shasums_signature_url"` // The URL to the GPG signature of the SHA checksums file.
 	SHASum              string      `json:"shasum"`                // The SHA checksum of the provider binary.
 	SigningKeys         SigningKeys `json:"signing_keys"`          // The signing keys used for this provider version.
+
+	// Mirror is where the files are stored in the asset mirror bucket, nil if they were not mirrored by the registry.
+	// It is not part of the response, the URLs above are pointed at the mirror instead.
+	Mirror *MirrorObjects `json:"-"`
 }
 
 // SigningKeys represents the GPG public keys used to sign a provider version.
@@ -37,23 +95,102 @@ type SigningKeys struct {
 
 // GPGPublicKey represents an individual GPG public key.
 type GPGPublicKey struct {
-	KeyID      string `json:"key_id"`      // The ID of the GPG key.
-	ASCIIArmor string `json:"ascii_armor"` // The ASCII armored representation of the GPG public key.
+	KeyID          string     `json:"key_id"`                    // The ID of the GPG key.
+	ASCIIArmor     string     `json:"ascii_armor"`               // The ASCII armored representation of the GPG public key.
+	TrustSignature string     `json:"trust_signature,omitempty"` // The signature of the key by a trusted third party, if any.
+	Source         string     `json:"source,omitempty"`          // Who published the key, e.g. the organization of the namespace.
+	SourceURL      string     `json:"source_url,omitempty"`      // Where the key is published, to check it out of band.
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`      // When the key expires, nil if it does not.
+}
+
+// Expired returns true if the key cannot be used to verify signatures at the given time anymore.
+func (k GPGPublicKey) Expired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
+
+// ValidKeys returns the keys which have not expired at the given time. Namespaces rotating their key serve the old
+// and the new one until the old one expires, so that the versions signed with either can be verified.
+func ValidKeys(keys []GPGPublicKey, now time.Time) []GPGPublicKey {
+	valid := make([]GPGPublicKey, 0, len(keys))
+	for _, k := range keys {
+		if !k.Expired(now) {
+			valid = append(valid, k)
+		}
+	}
+	return valid
 }
 
 // CacheItem represents a single item in the cache. This single item corresponds to a single provider and will store all of the versions for that provider.
 // and the data required to serve the provider download and version listing endpoints.
 type CacheItem struct {
-	Provider    string      `dynamodbav:"provider"`
-	Versions    VersionList `dynamodbav:"versions"`
-	LastUpdated time.Time   `dynamodbav:"last_updated"`
+	Provider    string           `dynamodbav:"provider"`
+	Versions    VersionList      `dynamodbav:"versions"`
+	LastUpdated time.Time        `dynamodbav:"last_updated"`
+	Metadata    ProviderMetadata `dynamodbav:"metadata"`
+	// SchemaVersion is the version of the format the versions were stored in, see providercache.CurrentSchemaVersion.
+	SchemaVersion int `dynamodbav:"schema_version"`
+	// Revision is incremented by every write of the item, 0 for the items stored before it was recorded.
+	Revision int64 `dynamodbav:"revision"`
 }
 
-const allowedAge = (1 * time.Hour) - (5 * time.Minute) //nolint:gomnd // 55 minutes
+// ProviderMetadata holds what we know about a provider besides its versions, recorded when the cache is populated.
+type ProviderMetadata struct {
+	Archived bool `dynamodbav:"archived"` // The source repository is archived, so no new versions are expected.
+	// Incomplete is set when the last refresh ran out of ingestion quota, so older releases may still be missing.
+	Incomplete bool `dynamodbav:"incomplete"`
+	// License is the SPDX identifier of the license of the source repository, see github.RepositoryInfo.
+	License string `dynamodbav:"license,omitempty"`
+	// NotFound is set on the items recording that the provider does not exist, which have no versions, so that the
+	// requests for it are answered without asking GitHub again until the item is stale.
+	NotFound bool `dynamodbav:"not_found,omitempty"`
+	// RefreshFailures is the number of refreshes which failed in a row since the versions were last stored, and
+	// LastRefreshError the error of the last one. Storing the versions again resets them.
+	RefreshFailures  int    `dynamodbav:"refresh_failures,omitempty"`
+	LastRefreshError string `dynamodbav:"last_refresh_error,omitempty"`
+	// Listing is the key of the object of the listings bucket holding the rendered v1 versions listing, for the
+	// providers whose listing is too large to be rendered on every request. It is only valid for the versions stored
+	// with it, so the writes changing the listed versions leave it out.
+	Listing string `dynamodbav:"listing,omitempty"`
+}
 
-// IsStale returns true if the cache item is stale.
-func (i *CacheItem) IsStale() bool {
-	return time.Since(i.LastUpdated) > allowedAge
+// CacheTTL is how old cached items get before they are stale and refreshed from GitHub. A zero duration stands for
+// the default one, see DefaultCacheTTL.
+type CacheTTL struct {
+	// Providers is the age after which the versions of a provider are refreshed.
+	Providers time.Duration
+	// NotFound is the age after which the items recording that a provider does not exist are checked again.
+	NotFound time.Duration
+}
+
+// DefaultCacheTTL is the TTL of the items unless configured otherwise. The not found one is shorter, so that a
+// provider published after it was first requested is found soon enough.
+var DefaultCacheTTL = CacheTTL{
+	Providers: (1 * time.Hour) - (5 * time.Minute), //nolint:gomnd // 55 minutes
+	NotFound:  5 * time.Minute,                     //nolint:gomnd // Missing providers are checked again soon.
+}
+
+// maxAge returns the age after which the item is stale.
+func (t CacheTTL) maxAge(i *CacheItem) time.Duration {
+	if i.Metadata.NotFound {
+		if t.NotFound == 0 {
+			return DefaultCacheTTL.NotFound
+		}
+		return t.NotFound
+	}
+	if t.Providers == 0 {
+		return DefaultCacheTTL.Providers
+	}
+	return t.Providers
+}
+
+// IsStale returns true if the cache item is older than its TTL.
+func (i *CacheItem) IsStale(ttl CacheTTL) bool {
+	return time.Since(i.LastUpdated) > ttl.maxAge(i)
+}
+
+// KnownMissing returns true if the item recently recorded that the provider does not exist.
+func (i *CacheItem) KnownMissing(ttl CacheTTL) bool {
+	return i != nil && i.Metadata.NotFound && !i.IsStale(ttl)
 }
 
 type VersionList []CacheVersion
@@ -66,6 +203,39 @@ func (l VersionList) ToVersions() []Version {
 	return versionsToReturn
 }
 
+// WithoutPrereleases returns the versions which are not prereleases.
+func (l VersionList) WithoutPrereleases() VersionList {
+	filtered := make(VersionList, 0, len(l))
+	for i := range l {
+		if !l[i].IsPrerelease() {
+			filtered = append(filtered, l[i])
+		}
+	}
+	return filtered
+}
+
+// Prereleases returns the versions which are prereleases.
+func (l VersionList) Prereleases() VersionList {
+	filtered := make(VersionList, 0, len(l))
+	for i := range l {
+		if l[i].IsPrerelease() {
+			filtered = append(filtered, l[i])
+		}
+	}
+	return filtered
+}
+
+// WithoutYanked returns the versions which are not yanked.
+func (l VersionList) WithoutYanked() VersionList {
+	filtered := make(VersionList, 0, len(l))
+	for i := range l {
+		if l[i].Yanked == nil {
+			filtered = append(filtered, l[i])
+		}
+	}
+	return filtered
+}
+
 func (l VersionList) Deduplicate() VersionList {
 	if len(l) == 0 {
 		return l
@@ -85,6 +255,30 @@ func (l VersionList) Deduplicate() VersionList {
 	return versionsToReturn
 }
 
+// WithPlatforms returns the versions with only the download details of the platforms the allowlist allows. Versions
+// left without any platform are dropped.
+func (l VersionList) WithPlatforms(allowlist platform.Allowlist) VersionList {
+	if len(allowlist) == 0 {
+		return l
+	}
+
+	filtered := make(VersionList, 0, len(l))
+	for _, v := range l {
+		details := make([]CacheVersionDownloadDetails, 0, len(v.DownloadDetails))
+		for _, d := range v.DownloadDetails {
+			if allowlist.Allows(d.Platform) {
+				details = append(details, d)
+			}
+		}
+		if len(details) == 0 {
+			continue
+		}
+		v.DownloadDetails = details
+		filtered = append(filtered, v)
+	}
+	return filtered
+}
+
 func (i *CacheItem) GetVersionDetails(version string, os string, arch string) (*VersionDetails, bool) {
 	for _, v := range i.Versions {
 		if v.Version == version {
@@ -105,6 +299,62 @@ type CacheVersion struct {
 	Version         string                        `json:"version"` // The version number of the provider.
 	DownloadDetails []CacheVersionDownloadDetails `json:"download_details"`
 	Protocols       []string                      `json:"protocols"` // The protocol versions the provider supports.
+	// PublishedAt is when the release of the version was created, zero for versions cached before it was recorded
+	// and for OCI artifacts.
+	PublishedAt time.Time `json:"published_at"`
+	// ReleaseURL is the page of the GitHub release of the version, with its release notes. Empty for versions cached
+	// before it was recorded and for OCI artifacts.
+	ReleaseURL string `json:"release_url,omitempty"`
+	// UnavailablePlatforms are the platforms the release has files for which could not be ingested, with the reason.
+	// The platforms of the release without any file are not listed, they were never built.
+	UnavailablePlatforms []UnavailablePlatform `json:"unavailable_platforms,omitempty"`
+	// Prerelease is set if the release of the version is marked as a prerelease on GitHub, see IsPrerelease.
+	Prerelease bool `json:"prerelease,omitempty"`
+	// Yanked is set if the version was pulled from the registry through the admin API, e.g. for a security issue. A
+	// yanked version is left out of the listings unless they are asked for it, and cannot be downloaded.
+	Yanked *Yank `json:"yanked,omitempty"`
+}
+
+// Yank records why and when a version was yanked.
+type Yank struct {
+	Reason   string    `json:"reason"`
+	YankedAt time.Time `json:"yanked_at"`
+}
+
+// IsPrerelease returns true if the version is a prerelease, marked as such on GitHub or with a prerelease suffix. The
+// versions cached before the GitHub flag was recorded only have the suffix to go by.
+func (v *CacheVersion) IsPrerelease() bool {
+	return v.Prerelease || version.IsPrerelease(v.Version)
+}
+
+// Reasons a platform of a version is not available for download.
+const (
+	// PlatformNeverBuilt is the reason of the platforms the release has no file for.
+	PlatformNeverBuilt = "never_built"
+	// PlatformAssetMissing is the reason of the platforms listed in the SHA256SUMS without an archive in the release.
+	PlatformAssetMissing = "asset_missing"
+	// PlatformChecksumMissing is the reason of the platforms with an archive in the release missing from the SHA256SUMS.
+	PlatformChecksumMissing = "checksum_missing"
+	// PlatformExcluded is the reason of the platforms the registry is configured not to serve for the provider.
+	PlatformExcluded = "excluded"
+	// PlatformQuarantined is the reason of all the platforms of a quarantined version.
+	PlatformQuarantined = "quarantined"
+)
+
+// UnavailablePlatform is a platform of a version which cannot be downloaded.
+type UnavailablePlatform struct {
+	Platform platform.Platform `json:"platform"`
+	Reason   string            `json:"reason"`
+}
+
+// UnavailableReason returns why the platform is not available, for a platform the version has no download details for.
+func (v *CacheVersion) UnavailableReason(p platform.Platform) string {
+	for _, u := range v.UnavailablePlatforms {
+		if u.Platform == p {
+			return u.Reason
+		}
+	}
+	return PlatformNeverBuilt
 }
 
 // ToVersion converts a CacheVersion to a Version to be used in the provider version listing endpoint.
@@ -136,6 +386,7 @@ func (v *CacheVersion) GetVersionDetails(os, arch string) *VersionDetails {
 				SHASumsSignatureURL: d.SHASumsSignatureURL,
 				SHASum:              d.SHASum,
 				SigningKeys:         SigningKeys{},
+				Mirror:              d.Mirror,
 			}
 		}
 	}
@@ -151,4 +402,76 @@ type CacheVersionDownloadDetails struct {
 	SHASumsURL          string            `json:"shasums_url"`           // The URL to the SHA checksums file.
 	SHASumsSignatureURL string            `json:"shasums_signature_url"` // The URL to the GPG signature of the SHA checksums file.
 	SHASum              string            `json:"shasum"`                // The SHA checksum of the provider binary.
+	// Mirror is where the files are stored in the asset mirror bucket, nil if they were not mirrored by the registry.
+	Mirror *MirrorObjects `json:"mirror,omitempty"`
+}
+
+// MirrorObjects are the `s3://<bucket>/<key>` URLs of the copies of the files of a platform in the asset mirror
+// bucket. The objects are named after the SHA256 checksum of their contents, which was verified when they were copied.
+type MirrorObjects struct {
+	DownloadURL         string `json:"download_url"`
+	SHASumsURL          string `json:"shasums_url"`
+	SHASumsSignatureURL string `json:"shasums_signature_url"`
+}
+
+// VersionFilter narrows a version listing down to the versions a client can actually use.
+type VersionFilter struct {
+	// Protocols the version must support at least one of. A major version such as "5" matches any "5.x" protocol.
+	Protocols []string
+	// Platforms the version must have a build for, all of them.
+	Platforms []platform.Platform
+}
+
+// IsEmpty returns true if the filter matches every version.
+func (f VersionFilter) IsEmpty() bool {
+	return len(f.Protocols) == 0 && len(f.Platforms) == 0
+}
+
+// Matches returns true if the version passes the filter.
+func (f VersionFilter) Matches(v Version) bool {
+	return f.matchesProtocols(v.Protocols) && f.matchesPlatforms(v.Platforms)
+}
+
+// Apply returns the versions that pass the filter.
+func (f VersionFilter) Apply(versions []Version) []Version {
+	if f.IsEmpty() {
+		return versions
+	}
+	filtered := make([]Version, 0, len(versions))
+	for _, v := range versions {
+		if f.Matches(v) {
+			filtered = append(filtered, v)
+		}
+	}
+	return filtered
+}
+
+func (f VersionFilter) matchesProtocols(protocols []string) bool {
+	if len(f.Protocols) == 0 {
+		return true
+	}
+	for _, wanted := range f.Protocols {
+		for _, protocol := range protocols {
+			if protocol == wanted || strings.HasPrefix(protocol, wanted+".") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (f VersionFilter) matchesPlatforms(platforms []platform.Platform) bool {
+	for _, wanted := range f.Platforms {
+		found := false
+		for _, p := range platforms {
+			if p == wanted {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
 }
@@ -3,6 +3,9 @@ package types
 import (
 	"reflect"
 	"testing"
+	"time"
+
+	"github.com/opentofu/registry/internal/platform"
 )
 
 func TestDeduplicate(t *testing.T) {
@@ -50,3 +53,195 @@ func TestDeduplicate(t *testing.T) {
 		})
 	}
 }
+
+func TestVersionFilter(t *testing.T) {
+	versions := []Version{
+		{Version: "1.0.0", Protocols: []string{"4.0"}, Platforms: []platform.Platform{{OS: "linux", Arch: "amd64"}}},
+		{Version: "2.0.0", Protocols: []string{"5.0"}, Platforms: []platform.Platform{{OS: "linux", Arch: "amd64"}}},
+		{Version: "3.0.0", Protocols: []string{"5.1", "6.0"}, Platforms: []platform.Platform{{OS: "linux", Arch: "amd64"}, {OS: "darwin", Arch: "arm64"}}},
+	}
+
+	tests := []struct {
+		name     string
+		filter   VersionFilter
+		expected []string
+	}{
+		{
+			name:     "empty filter",
+			filter:   VersionFilter{},
+			expected: []string{"1.0.0", "2.0.0", "3.0.0"},
+		},
+		{
+			name:     "exact protocol",
+			filter:   VersionFilter{Protocols: []string{"5.0"}},
+			expected: []string{"2.0.0"},
+		},
+		{
+			name:     "major protocol",
+			filter:   VersionFilter{Protocols: []string{"5"}},
+			expected: []string{"2.0.0", "3.0.0"},
+		},
+		{
+			name:     "platform",
+			filter:   VersionFilter{Platforms: []platform.Platform{{OS: "darwin", Arch: "arm64"}}},
+			expected: []string{"3.0.0"},
+		},
+		{
+			name:     "protocol and platform",
+			filter:   VersionFilter{Protocols: []string{"4"}, Platforms: []platform.Platform{{OS: "darwin", Arch: "arm64"}}},
+			expected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			for _, v := range tt.filter.Apply(versions) {
+				got = append(got, v.Version)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Apply() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWithPlatforms(t *testing.T) {
+	linux := CacheVersionDownloadDetails{Platform: platform.Platform{OS: "linux", Arch: "amd64"}}
+	solaris := CacheVersionDownloadDetails{Platform: platform.Platform{OS: "solaris", Arch: "amd64"}}
+	versions := VersionList{
+		{Version: "1.0.0", DownloadDetails: []CacheVersionDownloadDetails{linux, solaris}},
+		{Version: "0.1.0", DownloadDetails: []CacheVersionDownloadDetails{solaris}},
+	}
+
+	got := versions.WithPlatforms(platform.Allowlist{"linux_*"})
+	want := VersionList{
+		{Version: "1.0.0", DownloadDetails: []CacheVersionDownloadDetails{linux}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("WithPlatforms() = %v, want %v", got, want)
+	}
+	if len(versions[0].DownloadDetails) != 2 {
+		t.Errorf("expected the original versions to be left untouched")
+	}
+
+	if got := versions.WithPlatforms(nil); !reflect.DeepEqual(got, versions) {
+		t.Errorf("expected an empty allowlist to keep every platform")
+	}
+}
+
+func TestVersionExtensions(t *testing.T) {
+	published := time.Date(2023, 9, 1, 12, 0, 0, 0, time.UTC)
+	versions := VersionList{
+		{Version: "1.1.0", PublishedAt: published, ReleaseURL: "https://github.com/example/terraform-provider-foo/releases/tag/v1.1.0"},
+		// cached before the extended metadata was recorded
+		{Version: "1.0.0"},
+	}
+
+	plain := VersionExtensions{}.ToVersions(versions)
+	if plain[0].PublishedAt != nil || plain[0].ChangelogURL != "" {
+		t.Errorf("expected no extended metadata without extensions, got %+v", plain[0])
+	}
+
+	extended := VersionExtensions{Timestamps: true, Changelog: true}.ToVersions(versions)
+	if extended[0].PublishedAt == nil || !extended[0].PublishedAt.Equal(published) || extended[0].ChangelogURL != versions[0].ReleaseURL {
+		t.Errorf("expected the extended metadata, got %+v", extended[0])
+	}
+	if extended[1].PublishedAt != nil || extended[1].ChangelogURL != "" {
+		t.Errorf("expected no extended metadata for a version without it, got %+v", extended[1])
+	}
+}
+
+func TestVersionExtensionsPrereleases(t *testing.T) {
+	versions := VersionList{
+		{Version: "1.1.0", Prerelease: true},
+		// cached before the GitHub flag was recorded
+		{Version: "1.0.0-rc1"},
+		{Version: "1.0.0+build.5"},
+		{Version: "0.9.0"},
+	}
+
+	for _, extensions := range []VersionExtensions{{}, {Prereleases: true}} {
+		got := versionNumbers(extensions.ToVersions(versions))
+		if !reflect.DeepEqual(got, []string{"1.0.0+build.5", "0.9.0"}) {
+			t.Errorf("%+v: expected the prereleases to be left out of the versions, got %v", extensions, got)
+		}
+	}
+	if got := (VersionExtensions{}).ToPrereleases(versions); got != nil {
+		t.Errorf("expected the prereleases to be left out, got %v", got)
+	}
+	got := versionNumbers(VersionExtensions{Prereleases: true}.ToPrereleases(versions))
+	if !reflect.DeepEqual(got, []string{"1.1.0", "1.0.0-rc1"}) {
+		t.Errorf("expected the prereleases to be listed apart on request, got %v", got)
+	}
+}
+
+func TestVersionExtensionsYanked(t *testing.T) {
+	versions := VersionList{
+		{Version: "1.1.0", Yanked: &Yank{Reason: "CVE-2023-1234"}},
+		{Version: "1.0.0"},
+	}
+
+	got := versionNumbers(VersionExtensions{}.ToVersions(versions))
+	if !reflect.DeepEqual(got, []string{"1.0.0"}) {
+		t.Errorf("expected the yanked version to be left out, got %v", got)
+	}
+	all := VersionExtensions{Yanked: true}.ToVersions(versions)
+	if len(all) != 2 || !all[0].Yanked || all[0].YankReason != "CVE-2023-1234" || all[1].Yanked {
+		t.Errorf("expected the yanked version to be listed and marked on request, got %+v", all)
+	}
+}
+
+func versionNumbers(versions []Version) []string {
+	numbers := make([]string, len(versions))
+	for i, v := range versions {
+		numbers[i] = v.Version
+	}
+	return numbers
+}
+
+func TestKnownMissing(t *testing.T) {
+	missing := &CacheItem{LastUpdated: time.Now().Add(-time.Minute), Metadata: ProviderMetadata{NotFound: true}}
+	if !missing.KnownMissing(CacheTTL{}) {
+		t.Error("expected a fresh missing provider to be known missing")
+	}
+
+	// missing providers are checked again much sooner than the versions of existing ones are refreshed
+	missing.LastUpdated = time.Now().Add(-10 * time.Minute)
+	if missing.KnownMissing(CacheTTL{}) || !missing.IsStale(CacheTTL{}) {
+		t.Error("expected an old missing provider to be stale")
+	}
+	if existing := (&CacheItem{LastUpdated: missing.LastUpdated}); existing.IsStale(CacheTTL{}) || existing.KnownMissing(CacheTTL{}) {
+		t.Error("expected an existing provider to be fresh and not missing")
+	}
+
+	var notCached *CacheItem
+	if notCached.KnownMissing(CacheTTL{}) {
+		t.Error("expected a provider which is not cached not to be known missing")
+	}
+
+	// the configured TTLs replace the defaults
+	ttl := CacheTTL{Providers: 5 * time.Minute, NotFound: 30 * time.Minute}
+	if !missing.KnownMissing(ttl) {
+		t.Error("expected a missing provider to be known missing for the configured TTL")
+	}
+	if existing := (&CacheItem{LastUpdated: missing.LastUpdated}); !existing.IsStale(ttl) {
+		t.Error("expected an existing provider to be stale after the configured TTL")
+	}
+}
+
+func TestValidKeys(t *testing.T) {
+	now := time.Now()
+	expired, later := now.Add(-time.Minute), now.Add(time.Hour)
+	keys := []GPGPublicKey{
+		{KeyID: "A"},
+		{KeyID: "B", ExpiresAt: &expired},
+		{KeyID: "C", ExpiresAt: &later},
+		{KeyID: "D", ExpiresAt: &now},
+	}
+
+	valid := ValidKeys(keys, now)
+	if len(valid) != 2 || valid[0].KeyID != "A" || valid[1].KeyID != "C" {
+		t.Errorf("ValidKeys() = %v", valid)
+	}
+}
@@ -2,16 +2,17 @@ package providers
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"io"
-	"strings"
 
 	"github.com/aws/aws-xray-sdk-go/xray"
 	"github.com/opentofu/registry/internal/github"
-	"github.com/opentofu/registry/internal/platform"
-	"golang.org/x/exp/slog"
 )
 
+// errShaSumMissing is returned when the file is not listed in the SHA256SUMS.
+var errShaSumMissing = errors.New("file is not listed in the shasums")
+
+// getShaSum returns the checksum of the file listed in the SHA256SUMS at downloadURL.
 func getShaSum(ctx context.Context, downloadURL string, filename string) (shaSum string, err error) {
 	err = xray.Capture(ctx, "filename.shasum", func(tracedCtx context.Context) error {
 		xray.AddAnnotation(tracedCtx, "filename", filename)
@@ -20,44 +21,19 @@ func getShaSum(ctx context.Context, downloadURL string, filename string) (shaSum
 		if assetErr != nil {
 			return fmt.Errorf("failed to download asset contents: %w", assetErr)
 		}
+		defer assetContents.Close()
 
-		contents, contentsErr := io.ReadAll(assetContents)
-		if err != nil {
-			return fmt.Errorf("failed to read asset contents: %w", contentsErr)
+		sums, parseErr := ParseShaSums(assetContents)
+		if parseErr != nil {
+			return parseErr
+		}
+		var ok bool
+		if shaSum, ok = sums[filename]; !ok {
+			// a download without its checksum would fail the verification of the client
+			return fmt.Errorf("%s: %w", filename, errShaSumMissing)
 		}
-
-		shaSum = findShaSum(contents, filename, shaSum)
-
 		return nil
 	})
 
 	return shaSum, err
 }
-
-func findShaSum(contents []byte, filename string, shaSum string) string {
-	lines := strings.Split(string(contents), "\n")
-
-	for _, line := range lines {
-		if strings.HasSuffix(line, filename) {
-			shaSum = strings.Split(line, " ")[0]
-			break
-		}
-	}
-	return shaSum
-}
-
-func getSupportedArchAndOS(assets []github.ReleaseAsset) []platform.Platform {
-	var platforms []platform.Platform
-	slog.Info("Finding supported platforms", "assets", len(assets))
-	for _, asset := range assets {
-		slog.Info("Extracting platform from asset", "asset", asset)
-		platform := platform.ExtractPlatformFromArtifact(asset.Name)
-		if platform == nil {
-			continue
-		}
-		slog.Info("Platform identified", "platform", platform)
-		platforms = append(platforms, *platform)
-	}
-	slog.Info("Supported platforms found", "platforms", len(platforms))
-	return platforms
-}
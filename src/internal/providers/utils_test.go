@@ -1,10 +1,15 @@
 package providers
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 )
 
-func TestFindShaSum(t *testing.T) {
+func TestParseShaSums(t *testing.T) {
 	contents := `0002dd4c79453da5bf1bb9c52172a25d042a571f6df131b7c9ced3d1f8f3eb44  terraform-provider-random_3.5.1_linux_386.zip
 49b0f8c2bd5632799aa6113e0e46acaa7d008f927665a41a1f8e8559fe6d8165  terraform-provider-random_3.5.1_darwin_amd64.zip
 56df70fca236caa06d0e636c41ab71dd1ced05375f4ddcb905b0ed2105737048  terraform-provider-random_3.5.1_windows_386.zip
@@ -17,9 +22,71 @@ df7f9eb93a832e66bc20cc41c57d38954f87671ec60be09fa866273adb8d9353  terraform-prov
 eb583d8f03b11f0b6c535375d8ed0d29e5f7f537b5c78943856d2e8ce76482d9  terraform-provider-random_3.5.1_windows_arm.zip
 `
 
+	sums, err := ParseShaSums(strings.NewReader(contents))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sums) != 10 {
+		t.Errorf("expected 10 checksums, got %d", len(sums))
+	}
+
 	filename := "terraform-provider-random_3.5.1_linux_amd64.zip"
-	shaSum := findShaSum([]byte(contents), filename, "")
-	if shaSum != "d8956cc5abcd5d1173b6cc25d5d8ed2c5cc456edab2fddb774a17d45e84820cb" {
+	if sums[filename] != "d8956cc5abcd5d1173b6cc25d5d8ed2c5cc456edab2fddb774a17d45e84820cb" {
 		t.Fatal("shaSum not found")
 	}
 }
+
+func TestGetShaSum(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("d8956cc5abcd5d1173b6cc25d5d8ed2c5cc456edab2fddb774a17d45e84820cb  terraform-provider-random_3.5.1_linux_amd64.zip\n"))
+	}))
+	defer server.Close()
+
+	shaSum, err := getShaSum(context.Background(), server.URL, "terraform-provider-random_3.5.1_linux_amd64.zip")
+	if err != nil || shaSum != "d8956cc5abcd5d1173b6cc25d5d8ed2c5cc456edab2fddb774a17d45e84820cb" {
+		t.Errorf("getShaSum() = %q, %v, want the listed checksum", shaSum, err)
+	}
+	if _, err := getShaSum(context.Background(), server.URL, "terraform-provider-random_3.5.1_darwin_arm64.zip"); !errors.Is(err, errShaSumMissing) {
+		t.Errorf("getShaSum() error = %v, want errShaSumMissing for a file which is not listed", err)
+	}
+}
+
+func TestParseShaSumsInvalidLines(t *testing.T) {
+	contents := `D8956CC5ABCD5D1173B6CC25D5D8ED2C5CC456EDAB2FDDB774A17D45E84820CB *upper.zip
+not-a-checksum  invalid.zip
+d8956cc5abcd5d1173b6cc25d5d8ed2c5cc456edab2fddb774a17d45e84820  short.zip
+d8956cc5abcd5d1173b6cc25d5d8ed2c5cc456edab2fddb774a17d45e84820cb  ../traversal.zip
+d8956cc5abcd5d1173b6cc25d5d8ed2c5cc456edab2fddb774a17d45e84820cb  two words.zip
+`
+	sums, err := ParseShaSums(strings.NewReader(contents))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sums) != 1 || sums["upper.zip"] != "d8956cc5abcd5d1173b6cc25d5d8ed2c5cc456edab2fddb774a17d45e84820cb" {
+		t.Errorf("expected only the lowercased checksum of upper.zip, got %v", sums)
+	}
+
+	if _, err := ParseShaSums(strings.NewReader(strings.Repeat("a", maxShaSumsSize+1))); err == nil {
+		t.Error("expected an error for a file over the size limit")
+	}
+}
+
+func FuzzParseShaSums(f *testing.F) {
+	f.Add("d8956cc5abcd5d1173b6cc25d5d8ed2c5cc456edab2fddb774a17d45e84820cb  terraform-provider-random_3.5.1_linux_amd64.zip\n")
+	f.Add("D8956CC5ABCD5D1173B6CC25D5D8ED2C5CC456EDAB2FDDB774A17D45E84820CB *a.zip\r\n\n  \n")
+	f.Add("")
+	f.Fuzz(func(t *testing.T, contents string) {
+		sums, err := ParseShaSums(strings.NewReader(contents))
+		if err != nil {
+			return
+		}
+		for filename, shaSum := range sums {
+			if !validShaSum(shaSum) || shaSum != strings.ToLower(shaSum) {
+				t.Errorf("invalid checksum %q for %q", shaSum, filename)
+			}
+			if !validAssetFilename(filename) {
+				t.Errorf("invalid filename %q", filename)
+			}
+		}
+	})
+}
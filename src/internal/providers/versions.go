@@ -1,9 +1,10 @@
 package providers
 
 import (
-	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -12,7 +13,10 @@ import (
 	"github.com/opentofu/registry/internal/github"
 	"github.com/opentofu/registry/internal/platform"
 	"github.com/opentofu/registry/internal/providers/types"
-	"github.com/shurcooL/githubv4"
+	"github.com/opentofu/registry/internal/quota"
+	"github.com/opentofu/registry/internal/snapshot"
+	"github.com/opentofu/registry/internal/vcs"
+	"github.com/opentofu/registry/internal/version"
 	"golang.org/x/exp/slog"
 )
 
@@ -21,28 +25,39 @@ type versionResult struct {
 	Err     error
 }
 
-// GetVersions fetches and returns a list of available versions of a given provider hosted on GitHub.
+// GetVersions fetches and returns a list of available versions of a given provider hosted on GitHub or GitLab.
 // The returned versions also include information about supported platforms and the Terraform protocol versions they are compatible with.
 //
 // Parameters:
 // - ctx: The context used to control cancellations and timeouts.
-// - ghClient: The GitHub GraphQL client to interact with the GitHub GraphQL API.
-// - namespace: The GitHub namespace (typically, the organization or user) under which the provider repository is hosted.
+// - host: The code host of the provider repository.
+// - namespace: The owner (typically, the organization or user) under which the provider repository is hosted.
 // - name: The name of the provider repository.
 // - since: The time after which to fetch versions. If nil, it fetches all versions.
+// - known: Versions we already have, whose releases are not processed again.
+// - patterns: The names of the release assets of the provider.
+//
+// If the context carries an ingestion budget, only as many new releases as it allows are processed, newest first.
 //
 // Returns a slice of Version structures detailing each available version. If an error occurs during fetching or processing, it returns an error.
-func GetVersions(ctx context.Context, ghClient *githubv4.Client, namespace string, name string, since *time.Time) (versions types.VersionList, err error) {
+func GetVersions(ctx context.Context, host vcs.Host, namespace string, name string, since *time.Time, known types.VersionList, patterns AssetPatterns) (versions types.VersionList, err error) {
 	err = xray.Capture(ctx, "provider.versions", func(tracedCtx context.Context) error {
 		xray.AddAnnotation(tracedCtx, "namespace", namespace)
 		xray.AddAnnotation(tracedCtx, "name", name)
 
 		slog.Info("Fetching versions")
 
-		releases, releasesErr := github.FetchReleases(tracedCtx, ghClient, namespace, name, since)
+		releases, releasesErr := host.Releases(tracedCtx, namespace, name, since)
 		if releasesErr != nil {
 			return fmt.Errorf("failed to fetch releases: %w", releasesErr)
 		}
+		snapshot.FromContext(tracedCtx).Record(releases, since == nil)
+
+		releases = withoutKnownReleases(releases, known)
+		if allowed := quota.FromContext(tracedCtx).Releases(len(releases)); allowed < len(releases) {
+			slog.Warn("Release quota exceeded, leaving older releases for the next run", "releases", len(releases), "allowed", allowed)
+			releases = releases[:allowed]
+		}
 
 		// if the releases slice is empty, we can't do anything
 		// so, we should just return an empty slice
@@ -51,55 +66,88 @@ func GetVersions(ctx context.Context, ghClient *githubv4.Client, namespace strin
 			return nil
 		}
 
-		versionCh := make(chan versionResult, len(releases))
+		var versionsErr error
+		versions, versionsErr = VersionsFromReleases(tracedCtx, releases, patterns)
+		return versionsErr
+	})
 
-		var wg sync.WaitGroup
+	slog.Info("Successfully found versions", "versions", len(versions))
+	return versions, nil
+}
 
-		for _, release := range releases {
-			wg.Add(1)
-			go func(r github.GHRelease) {
-				defer wg.Done()
-				getVersionFromGithubRelease(tracedCtx, r, versionCh)
-			}(release)
-		}
+// VersionsFromReleases builds the versions of a provider from its GitHub releases, downloading the manifest and
+// SHA256SUMS of each release. Releases without assets for any platform are skipped, as are the ones failing to
+// process.
+func VersionsFromReleases(ctx context.Context, releases []github.GHRelease, patterns AssetPatterns) (versions types.VersionList, err error) {
+	versionCh := make(chan versionResult, len(releases))
 
-		// Close the channel when all goroutines are done.
-		wg.Wait()
-		close(versionCh)
-
-		for vr := range versionCh {
-			if vr.Err != nil {
-				slog.Error("Failed to process some releases", "error", vr.Err)
-				// we should not fail the entire operation if we can't process a single release
-				// this is because some GitHub releases may not have the correct assets attached,
-				// and therefore we should just log and skip them
-				xrayErr := xray.AddError(tracedCtx, fmt.Errorf("failed to process some releases: %w", vr.Err))
-				if xrayErr != nil {
-					return fmt.Errorf("failed to add error to trace: %w", err)
-				}
-			} else if vr.Version.Version != "" && len(vr.Version.DownloadDetails) > 0 {
-				// only add the final list of versions if it's populated and has platforms attached
-				versions = append(versions, vr.Version)
+	var wg sync.WaitGroup
+
+	for _, release := range releases {
+		wg.Add(1)
+		go func(r github.GHRelease) {
+			defer wg.Done()
+			getVersionFromGithubRelease(ctx, r, patterns, versionCh)
+		}(release)
+	}
+
+	// Close the channel when all goroutines are done.
+	wg.Wait()
+	close(versionCh)
+
+	for vr := range versionCh {
+		if vr.Err != nil {
+			slog.Error("Failed to process some releases", "error", vr.Err)
+			// we should not fail the entire operation if we can't process a single release
+			// this is because some GitHub releases may not have the correct assets attached,
+			// and therefore we should just log and skip them
+			xrayErr := xray.AddError(ctx, fmt.Errorf("failed to process some releases: %w", vr.Err))
+			if xrayErr != nil {
+				return nil, fmt.Errorf("failed to add error to trace: %w", xrayErr)
 			}
+		} else if vr.Version.Version != "" && len(vr.Version.DownloadDetails) > 0 {
+			// only add the final list of versions if it's populated and has platforms attached
+			versions = append(versions, vr.Version)
 		}
-		return nil
-	})
-
-	slog.Info("Successfully found versions", "versions", len(versions))
+	}
 	return versions, nil
 }
 
+func withoutKnownReleases(releases []github.GHRelease, known types.VersionList) []github.GHRelease {
+	if len(known) == 0 {
+		return releases
+	}
+	knownVersions := make(map[string]bool, len(known))
+	for _, v := range known {
+		knownVersions[v.Version] = true
+	}
+
+	var unknown []github.GHRelease
+	for _, r := range releases {
+		if !knownVersions[strings.TrimPrefix(r.TagName, "v")] {
+			unknown = append(unknown, r)
+		}
+	}
+	return unknown
+}
+
 // getVersionFromGithubRelease fetches and returns detailed information about a specific version of a provider hosted on GitHub.
 // all results are passed back to the versionCh channel.
-func getVersionFromGithubRelease(ctx context.Context, r github.GHRelease, versionCh chan versionResult) {
+func getVersionFromGithubRelease(ctx context.Context, r github.GHRelease, patterns AssetPatterns, versionCh chan versionResult) {
 	result := versionResult{}
 
 	logger := slog.Default().With("version", r.TagName)
 
 	logger.Info("Processing release")
 
+	versionNumber, ok := version.FromTag(r.TagName)
+	if !ok {
+		logger.Warn("Skipping release, its tag is not a version")
+		return
+	}
+
 	assets := r.ReleaseAssets.Nodes
-	platforms := getSupportedArchAndOS(assets)
+	platforms := patterns.platformAssets(assets)
 
 	// if there are no platforms, we can't do anything with this release
 	// so, we should just skip
@@ -107,11 +155,9 @@ func getVersionFromGithubRelease(ctx context.Context, r github.GHRelease, versio
 		return
 	}
 
-	protocols := []string{"5.0"}
-
 	logger.Info("Fetching manifest")
 	// Read the manifest so that we can get the protocol versions.
-	manifest, manifestErr := findAndParseManifest(ctx, assets)
+	manifest, manifestErr := findAndParseManifest(ctx, patterns.manifestAsset(assets))
 	if manifestErr != nil {
 		logger.Error("Failed to find and parse manifest", "error", manifestErr)
 		result.Err = fmt.Errorf("failed to find and parse manifest: %w", manifestErr)
@@ -120,14 +166,13 @@ func getVersionFromGithubRelease(ctx context.Context, r github.GHRelease, versio
 	}
 
 	// attach the protocol versions to the version result
-	if manifest != nil {
-		slog.Info("Found manifest", "protocols", manifest.Metadata.ProtocolVersions)
-		protocols = manifest.Metadata.ProtocolVersions
-	}
+	protocols := manifest.Protocols()
+	slog.Info("Using protocol versions", "protocols", protocols, "manifest", manifest != nil)
 
 	slog.Info("Fetching shasums")
 	// download the shasums file so that we can get the checksum for each platform
-	shaSums, err := downloadShaSums(ctx, assets)
+	shaSumsURL := patterns.shaSumsAsset(assets)
+	shaSums, err := downloadShaSums(ctx, shaSumsURL)
 	if err != nil {
 		slog.Error("Failed to download shasums", "error", err)
 		result.Err = fmt.Errorf("failed to download shasums: %w", err)
@@ -137,8 +182,7 @@ func getVersionFromGithubRelease(ctx context.Context, r github.GHRelease, versio
 
 	slog.Info("Found shasums", "shasums", len(shaSums))
 
-	shaSumsURL := github.FindAssetBySuffix(assets, "_SHA256SUMS")
-	shaSumsSignatureURL := github.FindAssetBySuffix(assets, "_SHA256SUMS.sig")
+	shaSumsSignatureURL := patterns.shaSumsSignatureAsset(assets)
 
 	if shaSumsSignatureURL == nil {
 		// make an empty one
@@ -148,36 +192,105 @@ func getVersionFromGithubRelease(ctx context.Context, r github.GHRelease, versio
 	}
 
 	downloadDetails := make([]types.CacheVersionDownloadDetails, 0, len(platforms))
+	var unavailable []types.UnavailablePlatform
 	// for each of the supported platforms, we need to find the appropriate assets
 	// and add them to the version result
-	for _, platform := range platforms {
-		slog.Info("Fetching download details", "platform", fmt.Sprintf("%s_%s", platform.OS, platform.Arch))
-		details := getVersionDownloadDetails(platform, assets, shaSums)
+	for _, found := range platforms {
+		slog.Info("Fetching download details", "platform", fmt.Sprintf("%s_%s", found.Platform.OS, found.Platform.Arch))
+		details := getVersionDownloadDetails(found.Platform, found.Asset, shaSums)
 		if details != nil {
 			details.SHASumsURL = shaSumsURL.DownloadURL
 			details.SHASumsSignatureURL = shaSumsSignatureURL.DownloadURL
 			downloadDetails = append(downloadDetails, *details)
+		} else {
+			unavailable = append(unavailable, types.UnavailablePlatform{Platform: found.Platform, Reason: types.PlatformChecksumMissing})
 		}
 	}
+	unavailable = append(unavailable, missingPlatformAssets(patterns, platforms, shaSums)...)
 
 	// only populate the version if we have all download details
 	result.Version = types.CacheVersion{
-		Version:         strings.TrimPrefix(r.TagName, "v"),
+		Version:         versionNumber,
 		Protocols:       protocols,
 		DownloadDetails: downloadDetails,
+		PublishedAt:     r.CreatedAt,
+		ReleaseURL:      r.URL,
+		Prerelease:      r.IsPrerelease,
+
+		UnavailablePlatforms: unavailable,
 	}
 
 	versionCh <- result
 }
 
-func getVersionDownloadDetails(platform platform.Platform, assets []github.ReleaseAsset, shaSums map[string]string) *types.CacheVersionDownloadDetails {
-	// find the asset for the given platform
-	asset := github.FindAssetBySuffix(assets, fmt.Sprintf("_%s_%s.zip", platform.OS, platform.Arch))
-	if asset == nil {
-		slog.Warn("Could not find asset for platform", "platform", platform)
-		return nil
+// missingAssetError returns the error for a release without an archive for the platform, telling whether it was
+// never built or is listed in the SHA256SUMS without being attached to the release, and which platforms are available.
+func missingAssetError(ctx context.Context, patterns AssetPatterns, assets []github.ReleaseAsset, os, arch string) error {
+	fetchErr := &FetchError{Message: "failed to find asset to download", Code: ErrCodeAssetNotFound, PlatformReason: types.PlatformNeverBuilt}
+
+	// the reason is best effort, failing to read the SHA256SUMS leaves it at never built and lists every platform
+	// with an archive attached
+	if shaSumsAsset := patterns.shaSumsAsset(assets); shaSumsAsset != nil {
+		shaSums, err := DownloadChecksums(ctx, shaSumsAsset.DownloadURL)
+		if err != nil {
+			slog.Warn("Could not download shasums to explain the missing asset", "error", err)
+			for _, found := range patterns.platformAssets(assets) {
+				fetchErr.Available = append(fetchErr.Available, found.Platform)
+			}
+			return fetchErr
+		}
+		fetchErr.Available = checksummedPlatforms(patterns, assets, shaSums)
+		requested := platform.Normalize(platform.Platform{OS: os, Arch: arch})
+		for filename := range shaSums {
+			if pl, ok := patterns.platformOf(filename); ok && pl == requested {
+				fetchErr.PlatformReason = types.PlatformAssetMissing
+				break
+			}
+		}
+	}
+	return fetchErr
+}
+
+// checksummedPlatforms returns the platforms whose archive is both attached to the release and listed in its
+// SHA256SUMS, the only ones a download is served for: the URL of an archive which is not attached would not resolve,
+// and an archive without its checksum would fail the verification of the client.
+func checksummedPlatforms(patterns AssetPatterns, assets []github.ReleaseAsset, shaSums map[string]string) []platform.Platform {
+	var available []platform.Platform
+	for _, found := range patterns.platformAssets(assets) {
+		if _, ok := shaSums[found.Asset.Name]; ok {
+			available = append(available, found.Platform)
+		}
+	}
+	return available
+}
+
+// missingPlatformAssets returns the platforms whose archive is listed in the SHA256SUMS but not attached to the
+// release, sorted so that the cached versions do not change with the order of the checksums.
+func missingPlatformAssets(patterns AssetPatterns, platforms []platformAsset, shaSums map[string]string) []types.UnavailablePlatform {
+	attached := make(map[platform.Platform]bool, len(platforms))
+	for _, found := range platforms {
+		attached[found.Platform] = true
+	}
+
+	var missing []types.UnavailablePlatform
+	for filename := range shaSums {
+		pl, ok := patterns.platformOf(filename)
+		if !ok || attached[pl] {
+			continue
+		}
+		attached[pl] = true
+		missing = append(missing, types.UnavailablePlatform{Platform: pl, Reason: types.PlatformAssetMissing})
 	}
+	sort.Slice(missing, func(i, j int) bool {
+		if missing[i].Platform.OS != missing[j].Platform.OS {
+			return missing[i].Platform.OS < missing[j].Platform.OS
+		}
+		return missing[i].Platform.Arch < missing[j].Platform.Arch
+	})
+	return missing
+}
 
+func getVersionDownloadDetails(platform platform.Platform, asset github.ReleaseAsset, shaSums map[string]string) *types.CacheVersionDownloadDetails {
 	// get the shasum for the asset
 	shasum, ok := shaSums[asset.Name]
 	if !ok {
@@ -195,59 +308,45 @@ func getVersionDownloadDetails(platform platform.Platform, assets []github.Relea
 	}
 }
 
-func downloadShaSums(ctx context.Context, assets []github.ReleaseAsset) (map[string]string, error) {
-	asset := github.FindAssetBySuffix(assets, "_SHA256SUMS")
+func downloadShaSums(ctx context.Context, asset *github.ReleaseAsset) (map[string]string, error) {
 	if asset == nil {
 		return nil, fmt.Errorf("could not find shasums asset")
 	}
+	return DownloadChecksums(ctx, asset.DownloadURL)
+}
 
+// DownloadChecksums downloads a SHA256SUMS file and returns the checksum of each file it lists.
+func DownloadChecksums(ctx context.Context, shaSumsURL string) (map[string]string, error) {
 	// download the asset
-	sumsContent, assetErr := github.DownloadAssetContents(ctx, asset.DownloadURL)
+	sumsContent, assetErr := github.DownloadAssetContents(ctx, shaSumsURL)
 	if assetErr != nil {
 		return nil, fmt.Errorf("failed to download asset: %w", assetErr)
 	}
 	defer sumsContent.Close()
 
-	sums := make(map[string]string)
-
-	// read the contents of the shasums file
-	scanner := bufio.NewScanner(sumsContent)
-	for scanner.Scan() {
-		// read the line
-		parts := strings.Fields(scanner.Text())
-		if len(parts) != 2 { //nolint:gomnd // we expect 2 parts
-			continue
-		}
-
-		// the first part is the shasum, the second part is the filename
-		// we want to return a map of filename -> shasum
-		// so we can easily look up the shasum for a given filename
-		// when we are processing the release assets
-		if len(parts[1]) > 0 {
-			sums[parts[1]] = parts[0]
-		}
-	}
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("failed to read asset contents: %w", err)
-	}
-	return sums, nil
+	// we want to return a map of filename -> shasum
+	// so we can easily look up the shasum for a given filename
+	// when we are processing the release assets
+	return ParseShaSums(sumsContent)
 }
 
-// GetVersion fetches and returns detailed information about a specific version of a provider hosted on GitHub.
+// GetVersion fetches and returns detailed information about a specific version of a provider hosted on GitHub or GitLab.
 // The returned information includes the download URL, the filename, SHA sums, and more details pertinent to the specific version, OS, and architecture.
 //
 // Parameters:
 // - ctx: The context used to control cancellations and timeouts.
-// - ghClient: The GitHub GraphQL client to interact with the GitHub GraphQL API.
-// - namespace: The GitHub namespace (typically, the organization or user) under which the provider repository is hosted.
-// - name: The name of the provider without the "terraform-provider-" prefix.
+// - host: The code host of the provider repository.
+// - namespace: The namespace of the provider, whose keys sign its releases.
+// - owner: The owner (typically, the organization or user) under which the provider repository is hosted.
+// - name: The name of the provider repository.
 // - version: The specific version of the Terraform provider to fetch details for.
 // - os: The operating system for which the provider binary is intended.
 // - arch: The architecture for which the provider binary is intended.
+// - patterns: The names of the release assets of the provider.
 //
 // Returns a VersionDetails structure with detailed information about the specified version. If an error occurs during fetching or processing, it returns an error.
 
-func GetVersion(ctx context.Context, ghClient *githubv4.Client, namespace string, name string, version string, os string, arch string) (versionDetails *types.VersionDetails, err error) {
+func GetVersion(ctx context.Context, host vcs.Host, namespace string, owner string, name string, version string, os string, arch string, patterns AssetPatterns) (versionDetails *types.VersionDetails, err error) {
 	err = xray.Capture(ctx, "provider.versiondetails", func(tracedCtx context.Context) error {
 		xray.AddAnnotation(tracedCtx, "namespace", namespace)
 		xray.AddAnnotation(tracedCtx, "name", name)
@@ -259,7 +358,7 @@ func GetVersion(ctx context.Context, ghClient *githubv4.Client, namespace string
 
 		// TODO: Replace this with a GetRelease, iterating all the releases is not efficient at all!
 		// Fetch the specific release for the given version.
-		release, releaseErr := github.FindRelease(tracedCtx, ghClient, namespace, name, version)
+		release, releaseErr := vcs.FindVersionRelease(tracedCtx, host, owner, name, version)
 		if releaseErr != nil {
 			return fmt.Errorf("failed to find release: %w", releaseErr)
 		}
@@ -275,28 +374,24 @@ func GetVersion(ctx context.Context, ghClient *githubv4.Client, namespace string
 		}
 
 		// Find and parse the manifest from the release assets.
-		manifest, manifestErr := findAndParseManifest(tracedCtx, release.ReleaseAssets.Nodes)
+		manifest, manifestErr := findAndParseManifest(tracedCtx, patterns.manifestAsset(release.ReleaseAssets.Nodes))
 		if manifestErr != nil {
 			return newFetchError("failed to find and parse manifest", ErrCodeManifestNotFound, manifestErr)
 		}
 
-		if manifest != nil {
-			versionDetails.Protocols = manifest.Metadata.ProtocolVersions
-		} else {
-			versionDetails.Protocols = []string{"5.0"}
-		}
+		versionDetails.Protocols = manifest.Protocols()
 
 		// Identify the appropriate asset for download based on OS and architecture.
-		assetToDownload := github.FindAssetBySuffix(release.ReleaseAssets.Nodes, fmt.Sprintf("_%s_%s.zip", os, arch))
+		assetToDownload := patterns.platformAsset(release.ReleaseAssets.Nodes, os, arch)
 		if assetToDownload == nil {
-			return newFetchError("failed to find asset to download", ErrCodeAssetNotFound, nil)
+			return missingAssetError(tracedCtx, patterns, release.ReleaseAssets.Nodes, os, arch)
 		}
 		versionDetails.Filename = assetToDownload.Name
 		versionDetails.DownloadURL = assetToDownload.DownloadURL
 
 		// Locate the SHA256 checksums and its signature from the release assets.
-		shaSumsAsset := github.FindAssetBySuffix(release.ReleaseAssets.Nodes, "_SHA256SUMS")
-		shasumsSigAsset := github.FindAssetBySuffix(release.ReleaseAssets.Nodes, "_SHA256SUMS.sig")
+		shaSumsAsset := patterns.shaSumsAsset(release.ReleaseAssets.Nodes)
+		shasumsSigAsset := patterns.shaSumsSignatureAsset(release.ReleaseAssets.Nodes)
 
 		if shaSumsAsset == nil || shasumsSigAsset == nil {
 			slog.Error("Could not find shasums or its signature asset")
@@ -308,6 +403,18 @@ func GetVersion(ctx context.Context, ghClient *githubv4.Client, namespace string
 
 		// Extract the SHA256 checksum for the asset to download.
 		shaSum, shaSumErr := getShaSum(tracedCtx, shaSumsAsset.DownloadURL, versionDetails.Filename)
+		if errors.Is(shaSumErr, errShaSumMissing) {
+			slog.Error("Asset is not listed in the shasums", "asset", versionDetails.Filename)
+			fetchErr := &FetchError{Message: "asset is not listed in the shasums", Code: ErrCodeAssetNotFound, Inner: shaSumErr, PlatformReason: types.PlatformChecksumMissing}
+			// the other archives of the release may not be listed either, so they are checked the same way
+			shaSums, err := DownloadChecksums(tracedCtx, shaSumsAsset.DownloadURL)
+			if err != nil {
+				slog.Error("Could not download shasums to list the available platforms", "error", err)
+				return fmt.Errorf("failed to download shasums: %w", err)
+			}
+			fetchErr.Available = checksummedPlatforms(patterns, release.ReleaseAssets.Nodes, shaSums)
+			return fetchErr
+		}
 		if shaSumErr != nil {
 			slog.Error("Could not get shasum", "error", shaSumErr)
 			return newFetchError("failed to get shasum: %w", ErrCodeSHASumsNotFound, shaSumErr)
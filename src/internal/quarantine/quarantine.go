@@ -0,0 +1,143 @@
+// Package quarantine holds the provider versions we stopped serving because their release assets changed after we
+// cached them, typically an asset re-uploaded with different contents. A quarantined version is not downloadable until
+// an operator resolves it. It also holds the providers whose refreshes failed too many times in a row, which are not
+// refreshed until an operator resolves them, see AllVersions.
+package quarantine
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/opentofu/registry/internal/providers/types"
+	"github.com/opentofu/registry/internal/version"
+)
+
+// AllVersions is the version of the quarantine of the refreshes of a provider. Its cached versions are still served,
+// but they are no longer refreshed.
+const AllVersions = "*"
+
+// Quarantine records that a provider version is not served.
+type Quarantine struct {
+	Version    string     `json:"version" dynamodbav:"version"`
+	Reason     string     `json:"reason" dynamodbav:"reason"`
+	Mismatches []Mismatch `json:"mismatches,omitempty" dynamodbav:"mismatches"`
+	DetectedAt time.Time  `json:"detected_at" dynamodbav:"detected_at"`
+}
+
+// Mismatch is a file whose checksum in the published SHA256SUMS is not the one we cached.
+type Mismatch struct {
+	Filename string `json:"filename" dynamodbav:"filename"`
+	Cached   string `json:"cached" dynamodbav:"cached"`
+	// Published is empty if the file is no longer listed in the SHA256SUMS.
+	Published string `json:"published" dynamodbav:"published"`
+}
+
+// Address returns the key under which the quarantines of a provider are stored. Tenants have their own provider
+// caches, so their quarantines are kept apart from the main registry's.
+func Address(tenant, namespace, providerType string) string {
+	if tenant != "" {
+		return fmt.Sprintf("%s:%s/%s", tenant, namespace, providerType)
+	}
+	return fmt.Sprintf("%s/%s", namespace, providerType)
+}
+
+// Tenant returns the tenant of the provider whose quarantines are stored under address, empty for the main registry.
+func Tenant(address string) string {
+	if tenant, _, ok := strings.Cut(address, ":"); ok {
+		return tenant
+	}
+	return ""
+}
+
+// Validate checks that the quarantine can be stored.
+func (q Quarantine) Validate() error {
+	if q.Version == "" {
+		return fmt.Errorf("version is required")
+	}
+	if q.Reason == "" {
+		return fmt.Errorf("reason is required")
+	}
+	return nil
+}
+
+// Find returns the quarantine of the given version, nil if it is not quarantined.
+func Find(quarantines []Quarantine, version string) *Quarantine {
+	for i := range quarantines {
+		if quarantines[i].Version == version {
+			return &quarantines[i]
+		}
+	}
+	return nil
+}
+
+// Exclude returns the versions which are not quarantined, the ones whose archives and checksums can be served.
+func Exclude(versions types.VersionList, quarantines []Quarantine) types.VersionList {
+	if len(quarantines) == 0 {
+		return versions
+	}
+	kept := make(types.VersionList, 0, len(versions))
+	for _, v := range versions {
+		if Find(quarantines, v.Version) == nil {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
+
+// CompareChecksums returns the archives of the version whose cached checksum differs from the published one.
+func CompareChecksums(v types.CacheVersion, published map[string]string) []Mismatch {
+	var mismatches []Mismatch
+	for _, d := range v.DownloadDetails {
+		if published[d.Filename] != d.SHASum {
+			mismatches = append(mismatches, Mismatch{Filename: d.Filename, Cached: d.SHASum, Published: published[d.Filename]})
+		}
+	}
+	return mismatches
+}
+
+// Accept updates the cached checksums of the quarantined version to the published ones, for an operator who checked
+// that the new assets are legitimate. Files no longer published are dropped.
+func Accept(versions types.VersionList, q Quarantine) types.VersionList {
+	accepted := make(types.VersionList, 0, len(versions))
+	for _, v := range versions {
+		if v.Version != q.Version {
+			accepted = append(accepted, v)
+			continue
+		}
+
+		published := make(map[string]string, len(q.Mismatches))
+		for _, m := range q.Mismatches {
+			published[m.Filename] = m.Published
+		}
+		details := make([]types.CacheVersionDownloadDetails, 0, len(v.DownloadDetails))
+		for _, d := range v.DownloadDetails {
+			if sum, ok := published[d.Filename]; ok {
+				if sum == "" {
+					continue
+				}
+				d.SHASum = sum
+			}
+			details = append(details, d)
+		}
+		v.DownloadDetails = details
+		if len(details) > 0 {
+			accepted = append(accepted, v)
+		}
+	}
+	return accepted
+}
+
+// Newest returns up to n of the versions, newest first.
+func Newest(versions types.VersionList, n int) types.VersionList {
+	sorted := make(types.VersionList, len(versions))
+	copy(sorted, versions)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return version.Compare(sorted[i].Version, sorted[j].Version) > 0
+	})
+	if len(sorted) > n {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
@@ -0,0 +1,112 @@
+package quarantine
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/opentofu/registry/internal/providers/types"
+)
+
+func testVersion(version string, sums map[string]string) types.CacheVersion {
+	v := types.CacheVersion{Version: version}
+	for _, filename := range []string{"a.zip", "b.zip"} {
+		if sum, ok := sums[filename]; ok {
+			v.DownloadDetails = append(v.DownloadDetails, types.CacheVersionDownloadDetails{Filename: filename, SHASum: sum})
+		}
+	}
+	return v
+}
+
+func TestCompareChecksums(t *testing.T) {
+	v := testVersion("1.0.0", map[string]string{"a.zip": "aaa", "b.zip": "bbb"})
+
+	if got := CompareChecksums(v, map[string]string{"a.zip": "aaa", "b.zip": "bbb", "c.zip": "ccc"}); got != nil {
+		t.Errorf("CompareChecksums() = %v, want no mismatches", got)
+	}
+
+	got := CompareChecksums(v, map[string]string{"a.zip": "changed"})
+	want := []Mismatch{
+		{Filename: "a.zip", Cached: "aaa", Published: "changed"},
+		{Filename: "b.zip", Cached: "bbb", Published: ""},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CompareChecksums() = %v, want %v", got, want)
+	}
+}
+
+func TestAccept(t *testing.T) {
+	versions := types.VersionList{
+		testVersion("1.0.0", map[string]string{"a.zip": "aaa", "b.zip": "bbb"}),
+		testVersion("2.0.0", map[string]string{"a.zip": "aaa", "b.zip": "bbb"}),
+		testVersion("3.0.0", map[string]string{"a.zip": "aaa"}),
+	}
+
+	got := Accept(versions, Quarantine{Version: "2.0.0", Mismatches: []Mismatch{
+		{Filename: "a.zip", Cached: "aaa", Published: "changed"},
+		{Filename: "b.zip", Cached: "bbb", Published: ""},
+	}})
+	want := types.VersionList{
+		versions[0],
+		testVersion("2.0.0", map[string]string{"a.zip": "changed"}),
+		versions[2],
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Accept() = %v, want %v", got, want)
+	}
+
+	got = Accept(versions, Quarantine{Version: "3.0.0", Mismatches: []Mismatch{{Filename: "a.zip", Cached: "aaa"}}})
+	if len(got) != 2 || got[1].Version != "2.0.0" {
+		t.Errorf("Accept() = %v, want the version without any files dropped", got)
+	}
+}
+
+func TestNewest(t *testing.T) {
+	versions := types.VersionList{{Version: "1.10.0"}, {Version: "1.2.0"}, {Version: "2.0.0"}}
+
+	got := Newest(versions, 2)
+	if len(got) != 2 || got[0].Version != "2.0.0" || got[1].Version != "1.10.0" {
+		t.Errorf("Newest() = %v, want 2.0.0 and 1.10.0", got)
+	}
+	if versions[0].Version != "1.10.0" {
+		t.Errorf("Newest() reordered its input")
+	}
+	if got := Newest(versions, 5); len(got) != 3 {
+		t.Errorf("Newest() returned %d versions, want 3", len(got))
+	}
+}
+
+func TestFind(t *testing.T) {
+	quarantines := []Quarantine{{Version: "1.0.0", Reason: "a"}, {Version: "2.0.0", Reason: "b"}}
+	if got := Find(quarantines, "2.0.0"); got == nil || got.Reason != "b" {
+		t.Errorf("Find() = %v, want the quarantine of 2.0.0", got)
+	}
+	if got := Find(quarantines, "3.0.0"); got != nil {
+		t.Errorf("Find() = %v, want nil", got)
+	}
+}
+
+func TestExclude(t *testing.T) {
+	versions := types.VersionList{{Version: "2.0.0"}, {Version: "1.0.0"}}
+	if got := Exclude(versions, nil); !reflect.DeepEqual(got, versions) {
+		t.Errorf("Exclude() = %v, want all versions without quarantines", got)
+	}
+	got := Exclude(versions, []Quarantine{{Version: "2.0.0", Reason: "a"}})
+	if !reflect.DeepEqual(got, types.VersionList{{Version: "1.0.0"}}) {
+		t.Errorf("Exclude() = %v, want the quarantined version left out", got)
+	}
+}
+
+func TestAddress(t *testing.T) {
+	if got := Address("", "example", "foo"); got != "example/foo" {
+		t.Errorf("Address() = %q", got)
+	}
+	if got := Address("internal", "example", "foo"); got != "internal:example/foo" {
+		t.Errorf("Address() = %q", got)
+	}
+	if got := Tenant("internal:example/foo"); got != "internal" {
+		t.Errorf("Tenant() = %q", got)
+	}
+	if got := Tenant("example/foo"); got != "" {
+		t.Errorf("Tenant() = %q", got)
+	}
+}
@@ -0,0 +1,117 @@
+package quarantine
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"golang.org/x/exp/slog"
+)
+
+type Handler struct {
+	TableName *string
+	Client    *dynamodb.Client
+}
+
+func NewHandler(awsConfig aws.Config, tableName string) *Handler {
+	ddbClient := dynamodb.NewFromConfig(awsConfig)
+
+	return &Handler{
+		TableName: aws.String(tableName),
+		Client:    ddbClient,
+	}
+}
+
+// Item is the record stored in DynamoDB, holding all of the quarantined versions of a single provider.
+type Item struct {
+	Provider    string       `dynamodbav:"provider"`
+	Quarantines []Quarantine `dynamodbav:"quarantines"`
+}
+
+// Get returns the quarantined versions of the given provider, or nil if there are none.
+func (h *Handler) Get(ctx context.Context, provider string) ([]Quarantine, error) {
+	result, err := h.Client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: h.TableName,
+		Key: map[string]types.AttributeValue{
+			"provider": &types.AttributeValueMemberS{Value: provider},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quarantines: %w", err)
+	}
+
+	if len(result.Item) == 0 {
+		return nil, nil
+	}
+
+	var item Item
+	if err := attributevalue.UnmarshalMap(result.Item, &item); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal quarantines: %w", err)
+	}
+	return item.Quarantines, nil
+}
+
+// List returns the quarantines of every provider. The table only holds the providers with quarantines, so it is
+// scanned in full.
+func (h *Handler) List(ctx context.Context) ([]Item, error) {
+	var items []Item
+	input := &dynamodb.ScanInput{TableName: h.TableName}
+	for {
+		result, err := h.Client.Scan(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list quarantines: %w", err)
+		}
+		var page []Item
+		if err := attributevalue.UnmarshalListOfMaps(result.Items, &page); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal quarantines: %w", err)
+		}
+		items = append(items, page...)
+		if len(result.LastEvaluatedKey) == 0 {
+			break
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+
+	sort.Slice(items, func(i, j int) bool { return items[i].Provider < items[j].Provider })
+	return items, nil
+}
+
+// Put replaces the quarantined versions of the given provider. Storing none deletes the record.
+func (h *Handler) Put(ctx context.Context, provider string, quarantines []Quarantine) error {
+	if len(quarantines) == 0 {
+		return h.delete(ctx, provider)
+	}
+
+	marshalledItem, err := attributevalue.MarshalMap(Item{Provider: provider, Quarantines: quarantines})
+	if err != nil {
+		return fmt.Errorf("failed to marshal quarantines: %w", err)
+	}
+
+	slog.Info("Storing quarantines", "provider", provider, "quarantines", len(quarantines))
+	_, err = h.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		Item:      marshalledItem,
+		TableName: h.TableName,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store quarantines: %w", err)
+	}
+	return nil
+}
+
+func (h *Handler) delete(ctx context.Context, provider string) error {
+	slog.Info("Deleting quarantines", "provider", provider)
+	_, err := h.Client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: h.TableName,
+		Key: map[string]types.AttributeValue{
+			"provider": &types.AttributeValueMemberS{Value: provider},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete quarantines: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+// Package queue sends messages to an SQS queue. Like the bucket package for S3, it makes the few signed requests it
+// needs itself rather than pulling in the SQS client of the AWS SDK.
+package queue
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-xray-sdk-go/xray"
+)
+
+// maxBatchSize is the most messages SQS accepts in a single SendMessageBatch call.
+const maxBatchSize = 10
+
+// Queue sends messages to an SQS queue.
+type Queue struct {
+	URL         string
+	Region      string
+	Credentials aws.CredentialsProvider
+
+	// endpoint is the SQS API of the region, overridden in tests.
+	endpoint   string
+	httpClient *http.Client
+	signer     *v4.Signer
+}
+
+func New(awsConfig aws.Config, queueURL string) *Queue {
+	return &Queue{
+		URL:         queueURL,
+		Region:      awsConfig.Region,
+		Credentials: awsConfig.Credentials,
+		endpoint:    fmt.Sprintf("https://sqs.%s.amazonaws.com/", awsConfig.Region),
+		httpClient:  xray.Client(&http.Client{Timeout: 30 * time.Second}), //nolint:gomnd // Batches are small.
+		signer:      v4.NewSigner(),
+	}
+}
+
+type batchEntry struct {
+	ID          string `json:"Id"`
+	MessageBody string `json:"MessageBody"`
+}
+
+type batchResult struct {
+	Failed []struct {
+		ID      string `json:"Id"`
+		Code    string `json:"Code"`
+		Message string `json:"Message"`
+	} `json:"Failed"`
+}
+
+// SendAll sends the messages, in batches of up to ten. It stops at the first batch which fails, in full or in part,
+// and returns how many messages were sent before it.
+func (q *Queue) SendAll(ctx context.Context, messages []string) (int, error) {
+	sent := 0
+	for start := 0; start < len(messages); start += maxBatchSize {
+		end := start + maxBatchSize
+		if end > len(messages) {
+			end = len(messages)
+		}
+
+		entries := make([]batchEntry, 0, end-start)
+		for i, message := range messages[start:end] {
+			entries = append(entries, batchEntry{ID: strconv.Itoa(i), MessageBody: message})
+		}
+		if err := q.sendBatch(ctx, entries); err != nil {
+			return sent, err
+		}
+		sent += len(entries)
+	}
+	return sent, nil
+}
+
+func (q *Queue) sendBatch(ctx context.Context, entries []batchEntry) error {
+	body, err := json.Marshal(map[string]any{"QueueUrl": q.URL, "Entries": entries})
+	if err != nil {
+		return fmt.Errorf("could not encode messages: %w", err)
+	}
+
+	resp, err := q.do(ctx, "AmazonSQS.SendMessageBatch", body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024)) //nolint:gomnd // Enough for the error message.
+		return fmt.Errorf("unexpected status %d sending messages: %s", resp.StatusCode, message)
+	}
+
+	var result batchResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("could not decode the result of sending messages: %w", err)
+	}
+	if len(result.Failed) > 0 {
+		failed := result.Failed[0]
+		return fmt.Errorf("%d of %d messages were not sent, the first one with %s: %s", len(result.Failed), len(entries), failed.Code, failed.Message)
+	}
+	return nil
+}
+
+func (q *Queue) do(ctx context.Context, target string, body []byte) (*http.Response, error) {
+	credentials, err := q.Credentials.Retrieve(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve AWS credentials: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, q.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("could not create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.0")
+	req.Header.Set("X-Amz-Target", target)
+
+	payloadHash := sha256.Sum256(body)
+	if err := q.signer.SignHTTP(ctx, credentials, req, hex.EncodeToString(payloadHash[:]), "sqs", q.Region, time.Now()); err != nil {
+		return nil, fmt.Errorf("could not sign request: %w", err)
+	}
+
+	resp, err := q.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not send messages: %w", err)
+	}
+	return resp, nil
+}
@@ -0,0 +1,66 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/opentofu/registry/internal/awstest"
+)
+
+func TestSendAll(t *testing.T) {
+	var batches [][]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ") || r.Header.Get("X-Amz-Target") != "AmazonSQS.SendMessageBatch" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		var input struct {
+			QueueURL string `json:"QueueUrl"`
+			Entries  []batchEntry
+		}
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input.QueueURL != "https://sqs.eu-west-1.amazonaws.com/123/refresh" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var bodies []string
+		for _, entry := range input.Entries {
+			bodies = append(bodies, entry.MessageBody)
+		}
+		batches = append(batches, bodies)
+
+		// the third batch is refused in part
+		if len(batches) == 3 {
+			_, _ = w.Write([]byte(`{"Successful": [], "Failed": [{"Id": "0", "Code": "InternalError", "Message": "try again"}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"Successful": [], "Failed": []}`))
+	}))
+	defer server.Close()
+
+	q := New(awstest.Config(), "https://sqs.eu-west-1.amazonaws.com/123/refresh")
+	q.endpoint = server.URL
+	q.httpClient = server.Client()
+
+	var messages []string
+	for i := 0; i < 12; i++ {
+		messages = append(messages, strconv.Itoa(i))
+	}
+	sent, err := q.SendAll(context.Background(), messages)
+	if err != nil || sent != 12 {
+		t.Fatalf("SendAll() = %d, %v, want 12 messages sent", sent, err)
+	}
+	if len(batches) != 2 || len(batches[0]) != 10 || len(batches[1]) != 2 || batches[1][1] != "11" {
+		t.Errorf("batches = %v, want 10 then 2 messages", batches)
+	}
+
+	sent, err = q.SendAll(context.Background(), messages)
+	if err == nil || sent != 0 {
+		t.Errorf("SendAll() = %d, %v, want the partly failed batch to be reported", sent, err)
+	}
+}
@@ -0,0 +1,111 @@
+// Package quota limits how much work a single provider refresh may do, so that one namespace publishing thousands
+// of releases cannot starve the refreshes of everyone else.
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/opentofu/registry/internal/metrics"
+)
+
+// ErrExceeded is returned once a budget has been used up.
+var ErrExceeded = errors.New("ingestion quota exceeded")
+
+// Limits are the budgets of a single refresh. Zero means unlimited.
+type Limits struct {
+	GithubCalls    int   `json:"github_calls"`     // Requests to the GitHub REST and GraphQL APIs.
+	ReleasesPerRun int   `json:"releases_per_run"` // New releases processed.
+	AssetBytes     int64 `json:"asset_bytes"`      // Bytes of release assets downloaded.
+}
+
+// Budget tracks what a refresh has used of its limits. A nil budget is unlimited.
+type Budget struct {
+	namespace string
+	limits    Limits
+
+	mu          sync.Mutex
+	githubCalls int
+	assetBytes  int64
+	exhausted   bool
+}
+
+func NewBudget(namespace string, limits Limits) *Budget {
+	return &Budget{namespace: namespace, limits: limits}
+}
+
+// UseGithubCall records a request to the GitHub API, failing if the budget does not allow for it.
+func (b *Budget) UseGithubCall() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.limits.GithubCalls > 0 && b.githubCalls >= b.limits.GithubCalls {
+		return b.exceed("github_calls")
+	}
+	b.githubCalls++
+	return nil
+}
+
+// UseAssetBytes records n bytes of release assets downloaded, failing once the budget is used up.
+func (b *Budget) UseAssetBytes(n int64) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.assetBytes += n
+	if b.limits.AssetBytes > 0 && b.assetBytes > b.limits.AssetBytes {
+		return b.exceed("asset_bytes")
+	}
+	return nil
+}
+
+// Releases returns how many of n new releases may be processed in this run.
+func (b *Budget) Releases(n int) int {
+	if b == nil || b.limits.ReleasesPerRun <= 0 || n <= b.limits.ReleasesPerRun {
+		return n
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	_ = b.exceed("releases_per_run")
+	return b.limits.ReleasesPerRun
+}
+
+// Exhausted returns true if any of the budgets ran out, meaning the refresh did not get to everything.
+func (b *Budget) Exhausted() bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.exhausted
+}
+
+// exceed records that the given budget ran out. The caller holds the lock.
+func (b *Budget) exceed(budget string) error {
+	if !b.exhausted {
+		metrics.Inc(metrics.IngestionQuotaExceeded, metrics.Labels{"namespace": b.namespace, "budget": budget})
+	}
+	b.exhausted = true
+	return fmt.Errorf("%w: %s of namespace %s", ErrExceeded, budget, b.namespace)
+}
+
+type contextKey struct{}
+
+// WithBudget returns a context carrying the budget, which the GitHub clients and the ingestion charge their work to.
+func WithBudget(ctx context.Context, budget *Budget) context.Context {
+	return context.WithValue(ctx, contextKey{}, budget)
+}
+
+// FromContext returns the budget of the context, nil if there is none.
+func FromContext(ctx context.Context) *Budget {
+	budget, _ := ctx.Value(contextKey{}).(*Budget)
+	return budget
+}
@@ -0,0 +1,66 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestBudget(t *testing.T) {
+	budget := NewBudget("example", Limits{GithubCalls: 2, ReleasesPerRun: 3, AssetBytes: 100})
+
+	for i := 0; i < 2; i++ {
+		if err := budget.UseGithubCall(); err != nil {
+			t.Fatalf("call %d: unexpected error: %v", i, err)
+		}
+	}
+	if budget.Exhausted() {
+		t.Fatalf("expected the budget not to be exhausted yet")
+	}
+	if err := budget.UseGithubCall(); !errors.Is(err, ErrExceeded) {
+		t.Fatalf("expected ErrExceeded, got %v", err)
+	}
+	if !budget.Exhausted() {
+		t.Fatalf("expected the budget to be exhausted")
+	}
+
+	if got := budget.Releases(10); got != 3 {
+		t.Errorf("Releases(10) = %d, want 3", got)
+	}
+	if got := budget.Releases(2); got != 2 {
+		t.Errorf("Releases(2) = %d, want 2", got)
+	}
+
+	if err := budget.UseAssetBytes(100); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := budget.UseAssetBytes(1); !errors.Is(err, ErrExceeded) {
+		t.Errorf("expected ErrExceeded, got %v", err)
+	}
+}
+
+func TestUnlimitedBudget(t *testing.T) {
+	budget := FromContext(context.Background())
+	if budget != nil {
+		t.Fatalf("expected no budget in an empty context")
+	}
+
+	// a nil budget never runs out
+	if err := budget.UseGithubCall(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if got := budget.Releases(1000); got != 1000 {
+		t.Errorf("Releases(1000) = %d, want 1000", got)
+	}
+	if budget.Exhausted() {
+		t.Errorf("expected a nil budget never to be exhausted")
+	}
+
+	zero := NewBudget("example", Limits{})
+	if err := zero.UseAssetBytes(1 << 30); err != nil {
+		t.Errorf("expected zero limits to be unlimited, got %v", err)
+	}
+	if got := FromContext(WithBudget(context.Background(), zero)); got != zero {
+		t.Errorf("expected the budget from the context")
+	}
+}
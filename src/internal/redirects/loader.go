@@ -0,0 +1,67 @@
+package redirects
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/slog"
+)
+
+// DefaultRefreshInterval is how long the loaded redirects are used before they are loaded again, when not configured.
+const DefaultRefreshInterval = 5 * time.Minute
+
+// lister is the part of the Handler the Loader uses, replaced in tests.
+type lister interface {
+	List(ctx context.Context) ([]Redirect, error)
+}
+
+// Loader caches the redirects of the table, so that they are not scanned on every request. Changes made on other
+// instances are picked up once the cached redirects are older than the refresh interval.
+type Loader struct {
+	Store    *Handler
+	interval time.Duration
+	lister   lister
+	now      func() time.Time
+
+	mu        sync.Mutex
+	redirects map[string]string
+	loadedAt  time.Time
+}
+
+func NewLoader(store *Handler, interval time.Duration) *Loader {
+	if interval <= 0 {
+		interval = DefaultRefreshInterval
+	}
+	return &Loader{Store: store, interval: interval, lister: store, now: time.Now}
+}
+
+// Redirects returns the redirects of the table as a map from each namespace to the namespace it is redirected to,
+// loading them again if they are older than the refresh interval. If loading fails, the redirects loaded last keep
+// being used until the next attempt, one refresh interval later.
+func (l *Loader) Redirects(ctx context.Context) map[string]string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if !l.loadedAt.IsZero() && now.Sub(l.loadedAt) < l.interval {
+		return l.redirects
+	}
+
+	l.loadedAt = now
+	found, err := l.lister.List(ctx)
+	if err != nil {
+		slog.Error("Could not load provider namespace redirects, using the ones loaded last", "error", err)
+		return l.redirects
+	}
+	l.redirects = Map(found)
+	return l.redirects
+}
+
+// Invalidate makes the next call to Redirects load them again, so that the changes made through this instance are
+// applied right away.
+func (l *Loader) Invalidate() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.loadedAt = time.Time{}
+}
@@ -0,0 +1,70 @@
+package redirects
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type fakeLister struct {
+	redirects []Redirect
+	err       error
+	calls     int
+}
+
+func (f *fakeLister) List(context.Context) ([]Redirect, error) {
+	f.calls++
+	return f.redirects, f.err
+}
+
+func TestLoader(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := &fakeLister{redirects: []Redirect{{From: "acme", To: "acme-corp"}}}
+	loader := &Loader{interval: time.Minute, lister: store, now: func() time.Time { return now }}
+	ctx := context.Background()
+
+	if got := loader.Redirects(ctx); got["acme"] != "acme-corp" || store.calls != 1 {
+		t.Fatalf("Redirects() = %v after %d loads", got, store.calls)
+	}
+
+	// cached until the refresh interval has passed
+	store.redirects = append(store.redirects, Redirect{From: "old", To: "new"})
+	now = now.Add(30 * time.Second)
+	if got := loader.Redirects(ctx); got["old"] != "" || store.calls != 1 {
+		t.Errorf("Redirects() = %v after %d loads, expected the cached redirects", got, store.calls)
+	}
+
+	now = now.Add(time.Minute)
+	if got := loader.Redirects(ctx); got["old"] != "new" || store.calls != 2 {
+		t.Errorf("Redirects() = %v after %d loads, expected the redirects loaded again", got, store.calls)
+	}
+
+	// failures keep the redirects loaded last
+	store.err = errors.New("throttled")
+	now = now.Add(time.Minute)
+	if got := loader.Redirects(ctx); got["old"] != "new" || store.calls != 3 {
+		t.Errorf("Redirects() = %v after %d loads, expected the redirects loaded last", got, store.calls)
+	}
+	if loader.Redirects(ctx); store.calls != 3 {
+		t.Errorf("failed load retried before the refresh interval")
+	}
+
+	store.err = nil
+	store.redirects = nil
+	loader.Invalidate()
+	if got := loader.Redirects(ctx); len(got) != 0 || store.calls != 4 {
+		t.Errorf("Redirects() = %v after %d loads, expected the redirects loaded again after Invalidate", got, store.calls)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := New("Acme", "acme-corp").Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	for _, r := range []Redirect{New("", "acme"), New("acme", ""), New("acme", "acme"), New("acme", "a/b")} {
+		if err := r.Validate(); err == nil {
+			t.Errorf("Validate() of %+v succeeded", r)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+// Package redirects holds the provider namespace redirects managed through the admin API, so that a namespace can be
+// moved, e.g. when its company is renamed, without deploying the registry again. They apply on top of the redirects of
+// the PROVIDER_NAMESPACE_REDIRECTS environment variable, and are loaded from their table every few minutes.
+package redirects
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Redirect records that the providers of the From namespace are served from the To namespace.
+type Redirect struct {
+	From string `json:"from" dynamodbav:"from"`
+	To   string `json:"to" dynamodbav:"to"`
+}
+
+//nolint:gochecknoglobals // This should be treated as a constant.
+var namespacePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// New returns the redirect from one namespace to another, lowercased like the namespaces of provider addresses.
+func New(from, to string) Redirect {
+	return Redirect{From: strings.ToLower(from), To: strings.ToLower(to)}
+}
+
+// Validate checks that the redirect points from one valid namespace to another.
+func (r Redirect) Validate() error {
+	if !namespacePattern.MatchString(r.From) {
+		return fmt.Errorf("invalid from namespace %q", r.From)
+	}
+	if !namespacePattern.MatchString(r.To) {
+		return fmt.Errorf("invalid to namespace %q", r.To)
+	}
+	if r.From == r.To {
+		return fmt.Errorf("namespace %s cannot redirect to itself", r.From)
+	}
+	return nil
+}
+
+// Map returns the redirects as a map from each namespace to the namespace it is redirected to.
+func Map(redirects []Redirect) map[string]string {
+	m := make(map[string]string, len(redirects))
+	for _, r := range redirects {
+		m[r.From] = r.To
+	}
+	return m
+}
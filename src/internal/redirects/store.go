@@ -0,0 +1,103 @@
+package redirects
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"golang.org/x/exp/slog"
+)
+
+type Handler struct {
+	TableName *string
+	Client    *dynamodb.Client
+}
+
+func NewHandler(awsConfig aws.Config, tableName string) *Handler {
+	ddbClient := dynamodb.NewFromConfig(awsConfig)
+
+	return &Handler{
+		TableName: aws.String(tableName),
+		Client:    ddbClient,
+	}
+}
+
+// Get returns the redirect of the given namespace, or nil if it is not redirected.
+func (h *Handler) Get(ctx context.Context, from string) (*Redirect, error) {
+	result, err := h.Client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: h.TableName,
+		Key: map[string]types.AttributeValue{
+			"from": &types.AttributeValueMemberS{Value: from},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get redirect: %w", err)
+	}
+
+	if len(result.Item) == 0 {
+		return nil, nil //nolint:nilnil // This is not an error, it just means the namespace is not redirected.
+	}
+
+	var redirect Redirect
+	if err := attributevalue.UnmarshalMap(result.Item, &redirect); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal redirect: %w", err)
+	}
+	return &redirect, nil
+}
+
+// List returns all the recorded redirects.
+func (h *Handler) List(ctx context.Context) ([]Redirect, error) {
+	var redirects []Redirect
+
+	paginator := dynamodb.NewScanPaginator(h.Client, &dynamodb.ScanInput{TableName: h.TableName})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan redirects: %w", err)
+		}
+
+		var pageRedirects []Redirect
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &pageRedirects); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal redirects: %w", err)
+		}
+		redirects = append(redirects, pageRedirects...)
+	}
+
+	return redirects, nil
+}
+
+// Put creates or replaces the redirect of redirect.From.
+func (h *Handler) Put(ctx context.Context, redirect Redirect) error {
+	marshalledItem, err := attributevalue.MarshalMap(redirect)
+	if err != nil {
+		return fmt.Errorf("failed to marshal redirect: %w", err)
+	}
+
+	slog.Info("Storing redirect", "from", redirect.From, "to", redirect.To)
+	_, err = h.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		Item:      marshalledItem,
+		TableName: h.TableName,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store redirect: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the redirect of the given namespace.
+func (h *Handler) Delete(ctx context.Context, from string) error {
+	slog.Info("Deleting redirect", "from", from)
+	_, err := h.Client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: h.TableName,
+		Key: map[string]types.AttributeValue{
+			"from": &types.AttributeValueMemberS{Value: from},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete redirect: %w", err)
+	}
+	return nil
+}
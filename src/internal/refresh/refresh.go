@@ -0,0 +1,55 @@
+// Package refresh starts the refreshes of the cached providers and modules without waiting for them, for the API and
+// the release webhook.
+package refresh
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/logging"
+	"github.com/opentofu/registry/internal/modules"
+)
+
+// Event asks the populate_provider_versions Lambda to refresh a provider or a module, see populate.Event.
+type Event struct {
+	Namespace string              `json:"namespace,omitempty"`
+	Type      string              `json:"type,omitempty"`
+	Tenant    string              `json:"tenant"`
+	Module    *modules.Repository `json:"module,omitempty"`
+	Force     bool                `json:"force,omitempty"`
+}
+
+// Send starts a refresh without waiting for it: as a message of the refresh queue if there is one, so that SQS
+// retries it if it fails, otherwise by invoking the populate_provider_versions Lambda asynchronously.
+func Send(ctx context.Context, config config.Config, e Event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	if config.RefreshQueue != nil {
+		logging.FromContext(ctx).Info("Enqueueing refresh", "event", string(payload))
+		if _, err := config.RefreshQueue.SendAll(ctx, []string{string(payload)}); err != nil {
+			logging.FromContext(ctx).Error("Error enqueueing refresh", "error", err)
+			return err
+		}
+		return nil
+	}
+
+	logging.FromContext(ctx).Info("Invoking populate provider versions lambda asynchronously to update dynamodb document\n")
+	// invoke the async lambda to update the dynamodb document
+	_, err = config.LambdaClient.Invoke(ctx, &lambda.InvokeInput{
+		FunctionName:   aws.String(os.Getenv("POPULATE_PROVIDER_VERSIONS_FUNCTION_NAME")),
+		InvocationType: "Event", // Event == async
+		Payload:        payload,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Error invoking lambda", "error", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package refresh
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/opentofu/registry/internal/awstest"
+	"github.com/opentofu/registry/internal/config"
+)
+
+func TestSendInvokesLambda(t *testing.T) {
+	var invocationType string
+	var received Event
+	client := awstest.LambdaClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		invocationType = r.Header.Get("X-Amz-Invocation-Type")
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &received)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+
+	t.Setenv("POPULATE_PROVIDER_VERSIONS_FUNCTION_NAME", "populate")
+
+	event := Event{Namespace: "example", Type: "foo", Tenant: "internal", Force: true}
+	if err := Send(context.Background(), config.Config{LambdaClient: client}, event); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if invocationType != "Event" {
+		t.Errorf("invocation type = %q, want the lambda to be invoked asynchronously", invocationType)
+	}
+	if received != event {
+		t.Errorf("received %+v, want %+v", received, event)
+	}
+}
@@ -0,0 +1,85 @@
+// Package repomappings holds the repositories mapped by hand to the providers and modules whose releases are not in
+// the repository the naming convention points at, e.g. providers living in a monorepo of their maintainers. They are
+// managed through the admin API and consulted before the configured repository names and the convention.
+package repomappings
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Mapping records the repository the releases of the provider or module at Address are published in.
+type Mapping struct {
+	// Address is the address of the provider or module, see ProviderAddress and ModuleAddress.
+	Address    string `json:"address" dynamodbav:"address"`
+	Owner      string `json:"owner" dynamodbav:"owner"`
+	Repository string `json:"repository" dynamodbav:"repository"`
+	// TagPrefix, if set, is the prefix of the tags of the releases, e.g. `foo/` for releases tagged `foo/v1.2.3`. The
+	// releases of the repository whose tag does not have it are left out.
+	TagPrefix string `json:"tag_prefix,omitempty" dynamodbav:"tag_prefix"`
+	// Subdirectory is the directory of a module within the repository, empty if it is at the root. Providers have none.
+	Subdirectory string `json:"subdirectory,omitempty" dynamodbav:"subdirectory"`
+}
+
+//nolint:gochecknoglobals // These should be treated as constants.
+var (
+	namePattern      = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,100}$`)
+	ownerPattern     = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,100}(/[A-Za-z0-9_.-]{1,100})*$`)
+	tagPrefixPattern = regexp.MustCompile(`^[A-Za-z0-9_./-]{1,100}$`)
+)
+
+// ProviderAddress returns the address under which the mapping of a provider is stored,
+// `providers/<namespace>/<type>`.
+func ProviderAddress(namespace, providerType string) string {
+	return strings.ToLower(fmt.Sprintf("providers/%s/%s", namespace, providerType))
+}
+
+// ModuleAddress returns the address under which the mapping of a module is stored,
+// `modules/<namespace>/<name>/<system>`.
+func ModuleAddress(namespace, name, system string) string {
+	return strings.ToLower(fmt.Sprintf("modules/%s/%s/%s", namespace, name, system))
+}
+
+// IsProvider returns true if the mapping is the one of a provider rather than a module.
+func (m Mapping) IsProvider() bool {
+	return strings.HasPrefix(m.Address, "providers/")
+}
+
+// Provider returns the namespace and type of the provider of a provider mapping.
+func (m Mapping) Provider() (namespace, providerType string) {
+	namespace, providerType, _ = strings.Cut(strings.TrimPrefix(m.Address, "providers/"), "/")
+	return namespace, providerType
+}
+
+// Releases returns true if the release of the repository of the mapping tagged tag is a release of its provider or
+// module: the repositories of several providers or modules tell their releases apart by their tag prefix.
+func (m Mapping) Releases(owner, repository, tag string) bool {
+	return strings.EqualFold(m.Owner, owner) && strings.EqualFold(m.Repository, repository) && strings.HasPrefix(tag, m.TagPrefix)
+}
+
+// Validate checks the repository of the mapping, and cleans up its subdirectory. The owner may be the path of a
+// GitLab group.
+func (m *Mapping) Validate() error {
+	if !ownerPattern.MatchString(m.Owner) {
+		return fmt.Errorf("invalid owner %q", m.Owner)
+	}
+	if !namePattern.MatchString(m.Repository) {
+		return fmt.Errorf("invalid repository %q", m.Repository)
+	}
+	if m.TagPrefix != "" && !tagPrefixPattern.MatchString(m.TagPrefix) {
+		return fmt.Errorf("invalid tag prefix %q", m.TagPrefix)
+	}
+	if m.Subdirectory != "" {
+		if m.IsProvider() {
+			return fmt.Errorf("providers can not be in a subdirectory of their repository")
+		}
+		subdirectory := path.Clean(strings.Trim(m.Subdirectory, "/"))
+		if subdirectory == "." || subdirectory == ".." || strings.HasPrefix(subdirectory, "../") {
+			return fmt.Errorf("invalid subdirectory %q", m.Subdirectory)
+		}
+		m.Subdirectory = subdirectory
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package repomappings
+
+import "testing"
+
+func TestValidate(t *testing.T) {
+	module := Mapping{Address: ModuleAddress("Example", "vpc", "aws"), Owner: "example", Repository: "infrastructure", Subdirectory: "/modules/vpc/"}
+	if err := module.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if module.Address != "modules/example/vpc/aws" || module.Subdirectory != "modules/vpc" {
+		t.Errorf("mapping = %+v", module)
+	}
+
+	invalid := map[string]Mapping{
+		"no owner":               {Address: ProviderAddress("example", "foo"), Repository: "providers"},
+		"nested repository":      {Address: ProviderAddress("example", "foo"), Owner: "example", Repository: "a/b"},
+		"provider subdirectory":  {Address: ProviderAddress("example", "foo"), Owner: "example", Repository: "providers", Subdirectory: "foo"},
+		"escaping subdirectory":  {Address: ModuleAddress("example", "vpc", "aws"), Owner: "example", Repository: "infrastructure", Subdirectory: "../other"},
+		"tag prefix with spaces": {Address: ProviderAddress("example", "foo"), Owner: "example", Repository: "providers", TagPrefix: "foo v"},
+	}
+	for name, mapping := range invalid {
+		mapping := mapping
+		t.Run(name, func(t *testing.T) {
+			if err := mapping.Validate(); err == nil {
+				t.Errorf("Validate() of %+v succeeded, want an error", mapping)
+			}
+		})
+	}
+}
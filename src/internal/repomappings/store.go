@@ -0,0 +1,103 @@
+package repomappings
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"golang.org/x/exp/slog"
+)
+
+type Handler struct {
+	TableName *string
+	Client    *dynamodb.Client
+}
+
+func NewHandler(awsConfig aws.Config, tableName string) *Handler {
+	ddbClient := dynamodb.NewFromConfig(awsConfig)
+
+	return &Handler{
+		TableName: aws.String(tableName),
+		Client:    ddbClient,
+	}
+}
+
+// Get returns the mapping stored under the given address, or nil if there is none.
+func (h *Handler) Get(ctx context.Context, address string) (*Mapping, error) {
+	result, err := h.Client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: h.TableName,
+		Key: map[string]types.AttributeValue{
+			"address": &types.AttributeValueMemberS{Value: address},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get repository mapping: %w", err)
+	}
+
+	if len(result.Item) == 0 {
+		return nil, nil //nolint:nilnil // This is not an error, the repository follows the configuration or the convention.
+	}
+
+	var mapping Mapping
+	if err := attributevalue.UnmarshalMap(result.Item, &mapping); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal repository mapping: %w", err)
+	}
+	return &mapping, nil
+}
+
+// List returns all the recorded mappings.
+func (h *Handler) List(ctx context.Context) ([]Mapping, error) {
+	var mappings []Mapping
+
+	paginator := dynamodb.NewScanPaginator(h.Client, &dynamodb.ScanInput{TableName: h.TableName})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan repository mappings: %w", err)
+		}
+
+		var pageMappings []Mapping
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &pageMappings); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal repository mappings: %w", err)
+		}
+		mappings = append(mappings, pageMappings...)
+	}
+
+	return mappings, nil
+}
+
+// Put creates or replaces the mapping for mapping.Address.
+func (h *Handler) Put(ctx context.Context, mapping Mapping) error {
+	marshalledItem, err := attributevalue.MarshalMap(mapping)
+	if err != nil {
+		return fmt.Errorf("failed to marshal repository mapping: %w", err)
+	}
+
+	slog.Info("Storing repository mapping", "address", mapping.Address, "owner", mapping.Owner, "repository", mapping.Repository)
+	_, err = h.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		Item:      marshalledItem,
+		TableName: h.TableName,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store repository mapping: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the mapping stored under the given address.
+func (h *Handler) Delete(ctx context.Context, address string) error {
+	slog.Info("Deleting repository mapping", "address", address)
+	_, err := h.Client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: h.TableName,
+		Key: map[string]types.AttributeValue{
+			"address": &types.AttributeValueMemberS{Value: address},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete repository mapping: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/exp/slog"
+)
+
+// Job is a unit of work run on every tick of the scheduler.
+type Job struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// Scheduler runs jobs at a fixed interval, replacing the scheduled Lambda invocations in the self-hosted mode. Jobs
+// run one after the other so that they don't compete for the GitHub API quota.
+type Scheduler struct {
+	interval time.Duration
+	jobs     []Job
+}
+
+func New(interval time.Duration, jobs ...Job) *Scheduler {
+	return &Scheduler{interval: interval, jobs: jobs}
+}
+
+// Run runs all jobs immediately and then on every interval, until the context is cancelled. A failing job is logged
+// and retried on the next tick.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.runJobs(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) runJobs(ctx context.Context) {
+	for _, job := range s.jobs {
+		if ctx.Err() != nil {
+			return
+		}
+
+		start := time.Now()
+		if err := job.Run(ctx); err != nil {
+			slog.Error("Scheduled job failed", "job", job.Name, "error", err)
+			continue
+		}
+		slog.Info("Scheduled job finished", "job", job.Name, "duration", time.Since(start))
+	}
+}
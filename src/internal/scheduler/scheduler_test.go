@@ -0,0 +1,46 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSchedulerRunsJobsUntilCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var runs, failures int
+	s := New(time.Millisecond,
+		Job{Name: "failing", Run: func(ctx context.Context) error {
+			failures++
+			return errors.New("boom")
+		}},
+		Job{Name: "counting", Run: func(ctx context.Context) error {
+			runs++
+			if runs == 3 {
+				cancel()
+			}
+			return nil
+		}},
+	)
+
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("scheduler did not stop after the context was cancelled")
+	}
+
+	if runs != 3 {
+		t.Errorf("expected the job to run 3 times, got %d", runs)
+	}
+	if failures != 3 {
+		t.Errorf("expected a failing job not to stop the others, got %d failures", failures)
+	}
+}
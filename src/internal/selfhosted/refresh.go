@@ -0,0 +1,101 @@
+package selfhosted
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	gogithub "github.com/google/go-github/v54/github"
+	"github.com/opentofu/registry/internal/github"
+	"github.com/opentofu/registry/internal/providers"
+	"github.com/opentofu/registry/internal/providers/providercache"
+	"github.com/opentofu/registry/internal/providers/types"
+	"github.com/opentofu/registry/internal/scheduler"
+	"github.com/opentofu/registry/internal/vcs"
+	"github.com/shurcooL/githubv4"
+	"golang.org/x/exp/slog"
+)
+
+// Refresher keeps the cache up to date with the releases of the configured providers, doing the work of the
+// populate_provider_versions Lambda.
+type Refresher struct {
+	Cache               providercache.Cache
+	ManagedGithubClient *gogithub.Client
+	RawGithubv4Client   *githubv4.Client
+	// RepoNames are the names of the repositories not following the naming convention, none if nil.
+	RepoNames providers.RepoNames
+	// TTL is how old the cached items get before they are refreshed, the defaults if zero.
+	TTL types.CacheTTL
+}
+
+// Jobs returns a scheduler job per provider, in the form "namespace/type".
+func (r Refresher) Jobs(providerAddresses []string) []scheduler.Job {
+	jobs := make([]scheduler.Job, 0, len(providerAddresses))
+	for _, address := range providerAddresses {
+		address := address
+		jobs = append(jobs, scheduler.Job{
+			Name: address,
+			Run: func(ctx context.Context) error {
+				return r.Refresh(ctx, address)
+			},
+		})
+	}
+	return jobs
+}
+
+// Refresh fetches the releases of a provider published since it was last cached and stores the combined listing.
+func (r Refresher) Refresh(ctx context.Context, address string) error {
+	namespace, providerType, err := SplitAddress(address)
+	if err != nil {
+		return err
+	}
+	repoName := r.RepoNames.RepoName(namespace, providerType)
+	// the releases are listed with the REST API while the GraphQL API is failing
+	ctx = github.WithRESTFallback(ctx, func(string) *gogithub.Client { return r.ManagedGithubClient })
+	host := vcs.GitHub{REST: r.ManagedGithubClient, GraphQL: r.RawGithubv4Client}
+
+	document, err := r.Cache.GetItem(ctx, address)
+	if err != nil {
+		return fmt.Errorf("failed to get document from cache: %w", err)
+	}
+
+	var since *time.Time
+	if document != nil {
+		if !document.IsStale(r.TTL) {
+			slog.Info("Document is up to date, not updating", "provider", address)
+			return nil
+		}
+		since = &document.LastUpdated
+	} else {
+		exists, err := host.RepositoryExists(ctx, namespace, repoName)
+		if err != nil {
+			return fmt.Errorf("failed to check if repo exists: %w", err)
+		}
+		if !exists {
+			return fmt.Errorf("repo %s/%s does not exist", namespace, repoName)
+		}
+	}
+
+	versions, err := providers.GetVersions(ctx, host, namespace, repoName, since, nil, providers.AssetPatterns{})
+	if err != nil {
+		return fmt.Errorf("failed to get versions: %w", err)
+	}
+	if document == nil && len(versions) == 0 {
+		slog.Info("No versions found, skipping storage", "provider", address)
+		return nil
+	}
+
+	var metadata types.ProviderMetadata
+	if info, err := github.GetRepositoryInfo(ctx, r.ManagedGithubClient, namespace, repoName); err != nil || info == nil {
+		slog.Error("Failed to get repository details", "provider", address, "error", err)
+	} else {
+		metadata.Archived = info.Archived
+		metadata.License = info.License
+	}
+
+	// the versions stored since the document was read, e.g. by a refresh triggered meanwhile, are kept
+	if document != nil {
+		return r.Cache.AppendVersions(ctx, address, versions, metadata)
+	}
+	return r.Cache.Store(ctx, address, versions, metadata)
+}
@@ -0,0 +1,166 @@
+package selfhosted
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/opentofu/registry/internal/providers"
+	"github.com/opentofu/registry/internal/providers/providercache"
+	"github.com/opentofu/registry/internal/providers/types"
+	"golang.org/x/exp/slog"
+)
+
+// Server serves the provider registry protocol for the configured providers out of the cache. Providers which are
+// not configured, or not cached yet, are not found.
+type Server struct {
+	cache     providercache.Cache
+	providers map[string]bool
+}
+
+func NewServer(cache providercache.Cache, providerAddresses []string) *Server {
+	configured := make(map[string]bool, len(providerAddresses))
+	for _, address := range providerAddresses {
+		configured[address] = true
+	}
+	return &Server{cache: cache, providers: configured}
+}
+
+// listVersionsResponse has the shape of the listing of the registry API: the prereleases are only listed with
+// `include=prereleases`, apart from the versions.
+type listVersionsResponse struct {
+	Versions    []types.Version `json:"versions"`
+	Prereleases []types.Version `json:"prereleases,omitempty"`
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+
+	if r.URL.Path == "/.well-known/terraform.json" {
+		writeJSON(w, map[string]string{"providers.v1": "/v1/providers/"})
+		return
+	}
+
+	// `/v1/providers/{namespace}/{type}/versions` or `/v1/providers/{namespace}/{type}/{version}/download/{os}/{arch}`
+	rest, ok := strings.CutPrefix(r.URL.Path, "/v1/providers/")
+	if !ok {
+		writeError(w, http.StatusNotFound, "route_not_found", "not found")
+		return
+	}
+	parts := strings.Split(rest, "/")
+	switch {
+	case len(parts) == 3 && parts[2] == "versions": //nolint:gomnd // namespace, type and "versions"
+		s.listVersions(w, r, parts[0], parts[1])
+	case len(parts) == 6 && parts[3] == "download": //nolint:gomnd // namespace, type, version, "download", os and arch
+		s.download(w, r, parts[0], parts[1], parts[2], parts[4], parts[5])
+	default:
+		writeError(w, http.StatusNotFound, "route_not_found", "not found")
+	}
+}
+
+func (s *Server) listVersions(w http.ResponseWriter, r *http.Request, namespace, providerType string) {
+	document := s.document(w, r, namespace, providerType)
+	if document == nil {
+		return
+	}
+	extensions := types.VersionExtensions{Prereleases: includes(r, "prereleases")}
+	writeJSON(w, listVersionsResponse{
+		Versions:    extensions.ToVersions(document.Versions),
+		Prereleases: extensions.ToPrereleases(document.Versions),
+	})
+}
+
+// includes returns true if the `include` query parameter, repeated or a comma-separated list, has the value.
+func includes(r *http.Request, value string) bool {
+	for _, include := range r.URL.Query()["include"] {
+		for _, v := range strings.Split(include, ",") {
+			if strings.TrimSpace(v) == value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s *Server) download(w http.ResponseWriter, r *http.Request, namespace, providerType, version, os, arch string) {
+	document := s.document(w, r, namespace, providerType)
+	if document == nil {
+		return
+	}
+
+	versionDetails, ok := document.GetVersionDetails(version, os, arch)
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", "not found")
+		return
+	}
+
+	publicKeys, err := providers.KeysForNamespace(namespace)
+	if err != nil {
+		slog.Error("Could not get public keys", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal_server_error", "internal error")
+		return
+	}
+	versionDetails.SigningKeys = types.SigningKeys{GPGPublicKeys: publicKeys}
+
+	writeJSON(w, versionDetails)
+}
+
+// document returns the cached document of a configured provider. If there is none, the error response has been
+// written and nil is returned.
+func (s *Server) document(w http.ResponseWriter, r *http.Request, namespace, providerType string) *types.CacheItem {
+	address := fmt.Sprintf("%s/%s", namespace, providerType)
+	if !s.providers[address] {
+		writeError(w, http.StatusNotFound, "not_found", "not found")
+		return nil
+	}
+
+	document, err := s.cache.GetItem(r.Context(), address)
+	if err != nil {
+		slog.Error("Error getting document from cache", "provider", address, "error", err)
+		writeError(w, http.StatusInternalServerError, "internal_server_error", "internal error")
+		return nil
+	}
+	if document == nil {
+		slog.Info("Provider not cached yet", "provider", address)
+		writeError(w, http.StatusNotFound, "not_found", "not found")
+		return nil
+	}
+	return document
+}
+
+func writeJSON(w http.ResponseWriter, body any) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		slog.Error("Error marshalling response", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal_server_error", "internal error")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
+
+// errorObject is an error of the error documents, with the same codes as the registry API, see docs/errors.md.
+type errorObject struct {
+	Code   string `json:"code"`
+	Detail string `json:"detail"`
+}
+
+func writeError(w http.ResponseWriter, status int, code, detail string) {
+	data, _ := json.Marshal(map[string][]errorObject{"errors": {{Code: code, Detail: detail}}})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(data)
+}
+
+// SplitAddress splits a provider address in the form "namespace/type".
+func SplitAddress(address string) (string, string, error) {
+	namespace, providerType, ok := strings.Cut(address, "/")
+	if !ok || namespace == "" || providerType == "" || strings.Contains(providerType, "/") {
+		return "", "", fmt.Errorf("invalid provider address %q, expected namespace/type", address)
+	}
+	return namespace, providerType, nil
+}
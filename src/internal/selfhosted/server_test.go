@@ -0,0 +1,180 @@
+package selfhosted
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/opentofu/registry/internal/platform"
+	"github.com/opentofu/registry/internal/providers/providercache"
+	"github.com/opentofu/registry/internal/providers/types"
+)
+
+type memoryCache map[string]*types.CacheItem
+
+func (c memoryCache) GetItem(_ context.Context, key string) (*types.CacheItem, error) {
+	return c[key], nil
+}
+
+func (c memoryCache) GetItems(_ context.Context, keys []string) (map[string]*types.CacheItem, error) {
+	items := make(map[string]*types.CacheItem)
+	for _, key := range keys {
+		if item, ok := c[key]; ok {
+			items[key] = item
+		}
+	}
+	return items, nil
+}
+
+func (c memoryCache) ListNamespace(_ context.Context, namespace string) ([]string, error) {
+	var keys []string
+	for key := range c {
+		if strings.HasPrefix(key, namespace+"/") {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (c memoryCache) Sample(_ context.Context, n int) ([]string, error) {
+	var keys []string
+	for key := range c {
+		if len(keys) < n {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (c memoryCache) Search(_ context.Context, query string) ([]string, error) {
+	var keys []string
+	for key, item := range c {
+		if strings.Contains(key, query) && !item.Metadata.NotFound {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (c memoryCache) Keys(ctx context.Context) ([]string, error) {
+	return c.Search(ctx, "")
+}
+
+func (c memoryCache) Store(_ context.Context, key string, versions types.VersionList, metadata types.ProviderMetadata) error {
+	c[key] = &types.CacheItem{Provider: key, Versions: versions, LastUpdated: time.Now(), Metadata: metadata}
+	return nil
+}
+
+func (c memoryCache) AppendVersions(ctx context.Context, key string, versions types.VersionList, metadata types.ProviderMetadata) error {
+	if item, ok := c[key]; ok {
+		versions = append(item.Versions, versions...).Deduplicate()
+	}
+	return c.Store(ctx, key, versions, metadata)
+}
+
+func (c memoryCache) UpdateVersions(_ context.Context, key string, update func(item *types.CacheItem) error) error {
+	item, ok := c[key]
+	if !ok {
+		return providercache.ErrNotFound
+	}
+	updated := *item
+	if err := update(&updated); err != nil {
+		return err
+	}
+	c[key] = &updated
+	return nil
+}
+
+func (c memoryCache) StoreBatch(ctx context.Context, entries []providercache.Entry) error {
+	for _, entry := range entries {
+		_ = c.Store(ctx, entry.Key, entry.Versions, entry.Metadata)
+	}
+	return nil
+}
+
+func (c memoryCache) StoreMetadata(_ context.Context, key string, metadata types.ProviderMetadata) error {
+	if item, ok := c[key]; ok {
+		item.Metadata = metadata
+	}
+	return nil
+}
+
+func (c memoryCache) Delete(_ context.Context, key string) error {
+	delete(c, key)
+	return nil
+}
+
+func TestServer(t *testing.T) {
+	cache := memoryCache{}
+	_ = cache.Store(context.Background(), "example/cached", types.VersionList{{
+		Version:   "1.0.0",
+		Protocols: []string{"5.0"},
+		DownloadDetails: []types.CacheVersionDownloadDetails{{
+			Platform:    platform.Platform{OS: "linux", Arch: "amd64"},
+			Filename:    "terraform-provider-cached_1.0.0_linux_amd64.zip",
+			DownloadURL: "https://example.com/terraform-provider-cached_1.0.0_linux_amd64.zip",
+		}},
+	}}, types.ProviderMetadata{})
+	server := NewServer(cache, []string{"example/cached", "example/uncached"})
+
+	tests := []struct {
+		method string
+		path   string
+		want   int
+	}{
+		{http.MethodGet, "/.well-known/terraform.json", http.StatusOK},
+		{http.MethodGet, "/v1/providers/example/cached/versions", http.StatusOK},
+		{http.MethodGet, "/v1/providers/example/cached/1.0.0/download/linux/amd64", http.StatusOK},
+		{http.MethodGet, "/v1/providers/example/cached/1.0.0/download/darwin/arm64", http.StatusNotFound},
+		{http.MethodGet, "/v1/providers/example/uncached/versions", http.StatusNotFound},
+		{http.MethodGet, "/v1/providers/example/unknown/versions", http.StatusNotFound},
+		{http.MethodGet, "/v1/modules/example/name/aws/versions", http.StatusNotFound},
+		{http.MethodPost, "/v1/providers/example/cached/versions", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			server.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+			if rec.Code != tt.want {
+				t.Errorf("got status %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestServerPrereleases(t *testing.T) {
+	cache := memoryCache{}
+	_ = cache.Store(context.Background(), "example/cached", types.VersionList{{Version: "1.1.0-rc1"}, {Version: "1.0.0"}}, types.ProviderMetadata{})
+	server := NewServer(cache, []string{"example/cached"})
+
+	list := func(path string) (versions, prereleases []string) {
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		var response listVersionsResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		for _, v := range response.Versions {
+			versions = append(versions, v.Version)
+		}
+		for _, v := range response.Prereleases {
+			prereleases = append(prereleases, v.Version)
+		}
+		return versions, prereleases
+	}
+
+	if versions, prereleases := list("/v1/providers/example/cached/versions"); !reflect.DeepEqual(versions, []string{"1.0.0"}) || prereleases != nil {
+		t.Errorf("versions, prereleases = %v, %v, want the prerelease left out", versions, prereleases)
+	}
+	versions, prereleases := list("/v1/providers/example/cached/versions?include=prereleases")
+	if !reflect.DeepEqual(versions, []string{"1.0.0"}) || !reflect.DeepEqual(prereleases, []string{"1.1.0-rc1"}) {
+		t.Errorf("versions, prereleases = %v, %v, want the prerelease listed apart", versions, prereleases)
+	}
+}
@@ -0,0 +1,88 @@
+// Package snapshot records the release metadata fetched from GitHub while ingesting providers, so that their cached
+// versions can be rebuilt from it later, e.g. after a change of the cache schema, without querying the GitHub API
+// again.
+package snapshot
+
+import (
+	"context"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/opentofu/registry/internal/github"
+)
+
+// Snapshot holds the releases of a provider seen by its refreshes.
+type Snapshot struct {
+	Namespace string    `json:"namespace"`
+	Type      string    `json:"type"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// Complete is set once a refresh fetched all the releases of the provider, rather than only the ones published
+	// since the previous refresh. Until then, replaying the snapshot only rebuilds some of the versions.
+	Complete bool               `json:"complete"`
+	Releases []github.GHRelease `json:"releases"`
+}
+
+// Key returns the object key of the snapshot of a provider, `snapshots/providers/<namespace>/<type>.json`, under
+// `snapshots/tenants/<tenant>/` for the providers of a tenant registry.
+func Key(tenant, namespace, providerType string) string {
+	if tenant != "" {
+		return path.Join("snapshots", "tenants", tenant, "providers", namespace, providerType+".json")
+	}
+	return path.Join("snapshots", "providers", namespace, providerType+".json")
+}
+
+// Merge adds the releases to the snapshot, replacing the ones with the same tag, which may have had assets added
+// since.
+func (s *Snapshot) Merge(releases []github.GHRelease) {
+	index := make(map[string]int, len(s.Releases))
+	for i, r := range s.Releases {
+		index[r.TagName] = i
+	}
+	for _, r := range releases {
+		if i, ok := index[r.TagName]; ok {
+			s.Releases[i] = r
+			continue
+		}
+		index[r.TagName] = len(s.Releases)
+		s.Releases = append(s.Releases, r)
+	}
+}
+
+// Recorder collects the releases fetched during a refresh. A nil recorder ignores them.
+type Recorder struct {
+	mu       sync.Mutex
+	releases []github.GHRelease
+	complete bool
+}
+
+// Record adds releases fetched from GitHub, complete if they are all the releases of the provider.
+func (r *Recorder) Record(releases []github.GHRelease, complete bool) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.releases = append(r.releases, releases...)
+	r.complete = r.complete || complete
+}
+
+// Releases returns the recorded releases, and whether they are all the releases of the provider.
+func (r *Recorder) Releases() ([]github.GHRelease, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.releases, r.complete
+}
+
+type contextKey struct{}
+
+// WithRecorder returns a context carrying the recorder, which the ingestion records the releases it fetches to.
+func WithRecorder(ctx context.Context, recorder *Recorder) context.Context {
+	return context.WithValue(ctx, contextKey{}, recorder)
+}
+
+// FromContext returns the recorder of the context, nil if there is none.
+func FromContext(ctx context.Context) *Recorder {
+	recorder, _ := ctx.Value(contextKey{}).(*Recorder)
+	return recorder
+}
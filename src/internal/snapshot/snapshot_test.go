@@ -0,0 +1,103 @@
+package snapshot
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/opentofu/registry/internal/awstest"
+	"github.com/opentofu/registry/internal/github"
+)
+
+func TestKey(t *testing.T) {
+	if got := Key("", "hashicorp", "aws"); got != "snapshots/providers/hashicorp/aws.json" {
+		t.Errorf("Key() = %q", got)
+	}
+	if got := Key("acme", "hashicorp", "aws"); got != "snapshots/tenants/acme/providers/hashicorp/aws.json" {
+		t.Errorf("Key() = %q", got)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	s := &Snapshot{Releases: []github.GHRelease{{ID: "1", TagName: "v1.0.0"}, {ID: "2", TagName: "v1.1.0"}}}
+	s.Merge([]github.GHRelease{{ID: "3", TagName: "v1.1.0"}, {ID: "4", TagName: "v1.2.0"}})
+
+	var ids []string
+	for _, r := range s.Releases {
+		ids = append(ids, r.TagName+"="+r.ID)
+	}
+	if got := strings.Join(ids, ","); got != "v1.0.0=1,v1.1.0=3,v1.2.0=4" {
+		t.Errorf("releases = %s", got)
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	// a nil recorder ignores the releases
+	FromContext(context.Background()).Record([]github.GHRelease{{TagName: "v1.0.0"}}, true)
+
+	recorder := &Recorder{}
+	ctx := WithRecorder(context.Background(), recorder)
+	FromContext(ctx).Record([]github.GHRelease{{TagName: "v1.0.0"}}, false)
+	FromContext(ctx).Record([]github.GHRelease{{TagName: "v0.9.0"}}, true)
+
+	releases, complete := recorder.Releases()
+	if len(releases) != 2 || !complete {
+		t.Errorf("Releases() = %v, %v", releases, complete)
+	}
+}
+
+func TestStore(t *testing.T) {
+	var mu sync.Mutex
+	objects := make(map[string][]byte)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ") || r.Header.Get("X-Amz-Content-Sha256") == "" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			objects[r.URL.Path] = body
+		case http.MethodGet:
+			body, ok := objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write(body)
+		}
+	}))
+	defer server.Close()
+
+	store := NewStore(awstest.Config(), "registry-snapshots")
+	store.client.Endpoint = server.URL
+	store.client.HTTPClient = server.Client()
+
+	ctx := context.Background()
+	key := Key("", "example", "foo")
+
+	missing, err := store.Get(ctx, key)
+	if err != nil || missing != nil {
+		t.Fatalf("Get() of a missing snapshot = %v, %v", missing, err)
+	}
+
+	want := &Snapshot{Namespace: "example", Type: "foo", Complete: true, Releases: []github.GHRelease{{TagName: "v1.0.0"}}}
+	want.Releases[0].ReleaseAssets.Nodes = []github.ReleaseAsset{{Name: "SHA256SUMS", DownloadURL: "https://example.com/SHA256SUMS"}}
+	if err := store.Put(ctx, key, want); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	got, err := store.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got == nil || !got.Complete || len(got.Releases) != 1 || got.Releases[0].ReleaseAssets.Nodes[0].Name != "SHA256SUMS" {
+		t.Errorf("Get() = %+v", got)
+	}
+}
@@ -0,0 +1,64 @@
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/opentofu/registry/internal/bucket"
+)
+
+// Store reads and writes snapshots as JSON objects of an S3 bucket.
+type Store struct {
+	client *bucket.Client
+}
+
+func NewStore(awsConfig aws.Config, name string) *Store {
+	return &Store{client: bucket.New(awsConfig, name, 30*time.Second)} //nolint:gomnd // Snapshots are small objects.
+}
+
+// Get returns the snapshot stored under the key, nil if there is none.
+//
+//nolint:nilnil // A missing snapshot is not an error.
+func (s *Store) Get(ctx context.Context, key string) (*Snapshot, error) {
+	resp, err := s.client.Do(ctx, http.MethodGet, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d getting snapshot %s", resp.StatusCode, key)
+	}
+
+	var snapshot Snapshot
+	if err := json.NewDecoder(resp.Body).Decode(&snapshot); err != nil {
+		return nil, fmt.Errorf("could not decode snapshot %s: %w", key, err)
+	}
+	return &snapshot, nil
+}
+
+// Put stores the snapshot under the key.
+func (s *Store) Put(ctx context.Context, key string, snapshot *Snapshot) error {
+	body, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("could not encode snapshot %s: %w", key, err)
+	}
+
+	resp, err := s.client.Do(ctx, http.MethodPut, key, http.Header{"Content-Type": {"application/json"}}, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d putting snapshot %s", resp.StatusCode, key)
+	}
+	return nil
+}
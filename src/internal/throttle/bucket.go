@@ -0,0 +1,47 @@
+// Package throttle limits the rate of the requests of each client of the registry, so that a single client, such as
+// a CI farm behind a single IP, cannot use up the GitHub quota of the registry for everyone else. Each client has a
+// token bucket stored in DynamoDB, shared by all the instances of the API.
+package throttle
+
+import (
+	"math"
+	"time"
+)
+
+// Limits are the rate and burst each client is allowed.
+type Limits struct {
+	// RequestsPerMinute is the sustained rate of requests, at which the bucket refills.
+	RequestsPerMinute float64
+	// Burst is the size of the bucket, the number of requests a client which was idle can make at once.
+	Burst int
+}
+
+// bucket is the token bucket of a client: its tokens at the time it was last updated, refilled since then.
+type bucket struct {
+	Client    string  `dynamodbav:"client"`
+	Tokens    float64 `dynamodbav:"tokens"`
+	UpdatedAt int64   `dynamodbav:"updated_at"` // Unix nanoseconds.
+	// ExpiresAt is when the bucket is full again, in Unix seconds, after which DynamoDB can delete it: a missing
+	// bucket is a full one.
+	ExpiresAt int64 `dynamodbav:"expires_at"`
+}
+
+// take takes a token from the bucket at now, returning the updated bucket, or how long to wait for a token if there
+// is none left.
+func (b bucket) take(limits Limits, now time.Time) (bucket, time.Duration, bool) {
+	perToken := float64(time.Minute) / limits.RequestsPerMinute
+
+	tokens := float64(limits.Burst)
+	if b.UpdatedAt != 0 {
+		elapsed := math.Max(0, float64(now.UnixNano()-b.UpdatedAt))
+		tokens = math.Min(float64(limits.Burst), b.Tokens+elapsed/perToken)
+	}
+
+	if tokens < 1 {
+		return b, time.Duration(math.Ceil((1 - tokens) * perToken)), false
+	}
+
+	tokens--
+	full := now.Add(time.Duration((float64(limits.Burst) - tokens) * perToken))
+	return bucket{Client: b.Client, Tokens: tokens, UpdatedAt: now.UnixNano(), ExpiresAt: full.Unix() + 1}, 0, true
+}
@@ -0,0 +1,49 @@
+package throttle
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTake(t *testing.T) {
+	limits := Limits{RequestsPerMinute: 60, Burst: 2}
+	now := time.Unix(1700000000, 0)
+
+	b := bucket{Client: "ip:192.0.2.1"}
+	for i := 0; i < limits.Burst; i++ {
+		var ok bool
+		if b, _, ok = b.take(limits, now); !ok {
+			t.Fatalf("request %d of the burst was rejected", i)
+		}
+	}
+
+	_, retryAfter, ok := b.take(limits, now)
+	if ok {
+		t.Fatalf("the request after the burst was allowed")
+	}
+	if retryAfter != time.Second {
+		t.Errorf("retry after %s, want 1s for a refill of one token per second", retryAfter)
+	}
+
+	// half a token later there is still none
+	if _, _, ok := b.take(limits, now.Add(500*time.Millisecond)); ok {
+		t.Errorf("the request half a token later was allowed")
+	}
+
+	refilled, _, ok := b.take(limits, now.Add(time.Second))
+	if !ok {
+		t.Fatalf("the request a token later was rejected")
+	}
+	if refilled.Tokens != 0 {
+		t.Errorf("tokens = %f, want 0", refilled.Tokens)
+	}
+	if want := now.Add(3*time.Second).Unix() + 1; refilled.ExpiresAt != want {
+		t.Errorf("expires at %d, want %d, once the bucket is full again", refilled.ExpiresAt, want)
+	}
+
+	// an idle client gets its burst back, no more
+	idle, _, _ := refilled.take(limits, now.Add(time.Hour))
+	if idle.Tokens != float64(limits.Burst-1) {
+		t.Errorf("tokens after an hour = %f, want %d", idle.Tokens, limits.Burst-1)
+	}
+}
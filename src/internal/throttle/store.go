@@ -0,0 +1,108 @@
+package throttle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/opentofu/registry/internal/logging"
+)
+
+// maxAttempts is how many times a token is taken from a bucket updated concurrently by other requests of the client
+// before giving up, and letting the request through.
+const maxAttempts = 3
+
+type Handler struct {
+	TableName *string
+	Client    *dynamodb.Client
+	Limits    Limits
+}
+
+func NewHandler(awsConfig aws.Config, tableName string, limits Limits) *Handler {
+	ddbClient := dynamodb.NewFromConfig(awsConfig)
+
+	return &Handler{
+		TableName: aws.String(tableName),
+		Client:    ddbClient,
+		Limits:    limits,
+	}
+}
+
+// Allow takes a token from the bucket of the client. If there is none left, the request must be rejected, and the
+// returned duration is how long the client should wait before retrying.
+func (h *Handler) Allow(ctx context.Context, client string) (time.Duration, bool, error) {
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		previous, err := h.get(ctx, client)
+		if err != nil {
+			return 0, false, err
+		}
+
+		updated, retryAfter, ok := previous.take(h.Limits, time.Now())
+		if !ok {
+			return retryAfter, false, nil
+		}
+
+		err = h.put(ctx, updated, previous.UpdatedAt)
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			// another request of the client took a token in the meantime, take one from the bucket it left
+			continue
+		}
+		if err != nil {
+			return 0, false, err
+		}
+		return 0, true, nil
+	}
+
+	logging.FromContext(ctx).Warn("Could not take a token from the bucket of a busy client, letting the request through", "client", client)
+	return 0, true, nil
+}
+
+func (h *Handler) get(ctx context.Context, client string) (bucket, error) {
+	result, err := h.Client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: h.TableName,
+		Key: map[string]types.AttributeValue{
+			"client": &types.AttributeValueMemberS{Value: client},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return bucket{}, fmt.Errorf("failed to get rate limit bucket: %w", err)
+	}
+
+	b := bucket{Client: client}
+	if len(result.Item) == 0 {
+		return b, nil
+	}
+	if err := attributevalue.UnmarshalMap(result.Item, &b); err != nil {
+		return bucket{}, fmt.Errorf("failed to unmarshal rate limit bucket: %w", err)
+	}
+	return b, nil
+}
+
+// put stores the bucket, if it was not updated since it was read at previousUpdatedAt, zero if there was none.
+func (h *Handler) put(ctx context.Context, b bucket, previousUpdatedAt int64) error {
+	marshalledItem, err := attributevalue.MarshalMap(b)
+	if err != nil {
+		return fmt.Errorf("failed to marshal rate limit bucket: %w", err)
+	}
+
+	_, err = h.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		Item:                marshalledItem,
+		TableName:           h.TableName,
+		ConditionExpression: aws.String("attribute_not_exists(client) OR updated_at = :previous"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":previous": &types.AttributeValueMemberN{Value: strconv.FormatInt(previousUpdatedAt, 10)},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store rate limit bucket: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package vcs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/opentofu/registry/internal/github"
+	"github.com/opentofu/registry/internal/version"
+)
+
+// DefaultGitLabURL is the GitLab instance the repositories are on when not configured.
+const DefaultGitLabURL = "https://gitlab.com"
+
+// gitlabPerPage is the number of releases per page of the GitLab API, its maximum.
+const gitlabPerPage = 100
+
+// sincePadding is how long before the given time the releases are still listed, like on GitHub, so that releases
+// created while the previous listing ran are not missed.
+const sincePadding = 2 * time.Minute
+
+// GitLab is the Host of the repositories of a GitLab instance. The owner of a repository is the full path of its
+// group, e.g. `example/terraform`.
+type GitLab struct {
+	// BaseURL is the URL of the instance, without a trailing slash.
+	BaseURL string
+
+	token      string
+	httpClient *http.Client
+}
+
+// NewGitLab returns the host of the GitLab instance at baseURL, called with the token if it is not empty. Without a
+// token, only the public projects can be read.
+func NewGitLab(baseURL, token string) *GitLab {
+	return &GitLab{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		token:      token,
+		httpClient: xray.Client(&http.Client{Timeout: 30 * time.Second}), //nolint:gomnd // Pages of releases are small.
+	}
+}
+
+// gitlabRelease is a release of the GitLab releases API.
+type gitlabRelease struct {
+	TagName         string    `json:"tag_name"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpcomingRelease bool      `json:"upcoming_release"`
+	Links           struct {
+		Self string `json:"self"`
+	} `json:"_links"`
+	Assets struct {
+		Links []struct {
+			ID             int64  `json:"id"`
+			Name           string `json:"name"`
+			URL            string `json:"url"`
+			DirectAssetURL string `json:"direct_asset_url"`
+		} `json:"links"`
+	} `json:"assets"`
+}
+
+func (g *GitLab) RepositoryExists(ctx context.Context, owner, name string) (bool, error) {
+	resp, err := g.get(ctx, g.projectPath(owner, name), nil)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("unexpected status %d getting project %s/%s", resp.StatusCode, owner, name)
+	}
+}
+
+// Releases lists the releases of the project page by page. The upcoming releases, whose release date is still to
+// come, are not published yet and are left out, like the drafts on GitHub. GitLab has no prerelease flag, so the
+// releases tagged with a prerelease version are the prereleases.
+func (g *GitLab) Releases(ctx context.Context, owner, name string, since *time.Time) ([]github.GHRelease, error) {
+	var releases []github.GHRelease
+	page := "1"
+	for page != "" {
+		query := url.Values{
+			"per_page": {fmt.Sprint(gitlabPerPage)},
+			"page":     {page},
+			"order_by": {"created_at"},
+			"sort":     {"desc"},
+		}
+		resp, err := g.get(ctx, g.projectPath(owner, name)+"/releases", query)
+		if err != nil {
+			return nil, err
+		}
+
+		var batch []gitlabRelease
+		err = decodeResponse(resp, &batch)
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list releases of %s/%s: %w", owner, name, err)
+		}
+
+		for _, r := range batch {
+			if r.UpcomingRelease {
+				continue
+			}
+			// the releases are ordered by creation date, so the following ones are older still
+			if since != nil && r.CreatedAt.Before(since.Add(-sincePadding)) {
+				return releases, nil
+			}
+			releases = append(releases, g.release(owner, name, r))
+		}
+		page = resp.Header.Get("X-Next-Page")
+	}
+	return releases, nil
+}
+
+// AssetURL returns the permanent link of the asset, which GitLab serves for the release links with a file path.
+func (g *GitLab) AssetURL(owner, name, tag, asset string) string {
+	return fmt.Sprintf("%s/%s/%s/-/releases/%s/downloads/%s", g.BaseURL, owner, name, url.PathEscape(tag), asset)
+}
+
+// release converts a GitLab release to a GitHub one. The assets of a GitLab release are links, downloaded from their
+// direct URL.
+func (g *GitLab) release(owner, name string, r gitlabRelease) github.GHRelease {
+	release := github.GHRelease{
+		ID:        r.TagName,
+		TagName:   r.TagName,
+		URL:       r.Links.Self,
+		CreatedAt: r.CreatedAt,
+	}
+	if v, ok := version.FromTag(r.TagName); ok {
+		release.IsPrerelease = version.IsPrerelease(v)
+	}
+	for _, link := range r.Assets.Links {
+		downloadURL := link.DirectAssetURL
+		if downloadURL == "" {
+			downloadURL = g.AssetURL(owner, name, r.TagName, link.Name)
+		}
+		release.ReleaseAssets.Nodes = append(release.ReleaseAssets.Nodes, github.ReleaseAsset{
+			ID:          fmt.Sprint(link.ID),
+			DownloadURL: downloadURL,
+			Name:        link.Name,
+		})
+	}
+	return release
+}
+
+// projectPath returns the API path of the project, whose ID is its full path, escaped.
+func (g *GitLab) projectPath(owner, name string) string {
+	return "/api/v4/projects/" + url.PathEscape(owner+"/"+name)
+}
+
+func (g *GitLab) get(ctx context.Context, path string, query url.Values) (*http.Response, error) {
+	target := g.BaseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not create request for %s: %w", path, err)
+	}
+	if g.token != "" {
+		req.Header.Set("PRIVATE-TOKEN", g.token)
+	}
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not get %s: %w", path, err)
+	}
+	return resp, nil
+}
+
+// decodeResponse decodes the JSON body of a successful response into v, and closes the body.
+func decodeResponse(resp *http.Response, v interface{}) error {
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,92 @@
+package vcs
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestGitLabReleases(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.URL.EscapedPath() == "/api/v4/projects/example%2Fterraform%2Fterraform-provider-foo":
+			fmt.Fprint(w, `{"id":1}`)
+		case r.URL.EscapedPath() == "/api/v4/projects/example%2Fterraform%2Fterraform-provider-foo/releases" && r.URL.Query().Get("page") == "1":
+			w.Header().Set("X-Next-Page", "2")
+			fmt.Fprint(w, `[{"tag_name":"v1.2.0","created_at":"2023-09-03T00:00:00Z","upcoming_release":true},
+				{"tag_name":"v1.1.0","created_at":"2023-09-02T00:00:00Z","_links":{"self":"https://gitlab.example.com/r/v1.1.0"},
+				 "assets":{"links":[{"id":7,"name":"terraform-provider-foo_1.1.0_SHA256SUMS","direct_asset_url":"https://gitlab.example.com/SHA256SUMS"},
+				                    {"id":8,"name":"terraform-provider-foo_1.1.0_SHA256SUMS.sig"}]}}]`)
+		case r.URL.EscapedPath() == "/api/v4/projects/example%2Fterraform%2Fterraform-provider-foo/releases" && r.URL.Query().Get("page") == "2":
+			fmt.Fprint(w, `[{"tag_name":"v1.0.0-rc1","created_at":"2023-09-01T00:00:00Z"}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	gitlab := NewGitLab(server.URL+"/", "secret")
+	gitlab.httpClient = server.Client()
+	ctx := context.Background()
+
+	exists, err := gitlab.RepositoryExists(ctx, "example/terraform", "terraform-provider-foo")
+	if err != nil || !exists {
+		t.Fatalf("RepositoryExists() = %v, %v", exists, err)
+	}
+	exists, err = gitlab.RepositoryExists(ctx, "example/terraform", "terraform-provider-bar")
+	if err != nil || exists {
+		t.Fatalf("RepositoryExists() of a missing project = %v, %v", exists, err)
+	}
+
+	releases, err := gitlab.Releases(ctx, "example/terraform", "terraform-provider-foo", nil)
+	if err != nil {
+		t.Fatalf("Releases() error = %v", err)
+	}
+	if len(releases) != 2 || releases[0].TagName != "v1.1.0" || releases[0].IsPrerelease || releases[1].TagName != "v1.0.0-rc1" || !releases[1].IsPrerelease {
+		t.Fatalf("Releases() = %+v, want v1.1.0 and the v1.0.0-rc1 prerelease", releases)
+	}
+	assets := releases[0].ReleaseAssets.Nodes
+	if len(assets) != 2 || assets[0].DownloadURL != "https://gitlab.example.com/SHA256SUMS" {
+		t.Fatalf("assets of v1.1.0 = %+v", assets)
+	}
+	if want := server.URL + "/example/terraform/terraform-provider-foo/-/releases/v1.1.0/downloads/terraform-provider-foo_1.1.0_SHA256SUMS.sig"; assets[1].DownloadURL != want {
+		t.Errorf("asset without a direct URL downloaded from %q, want %q", assets[1].DownloadURL, want)
+	}
+
+	since := time.Date(2023, 9, 2, 12, 0, 0, 0, time.UTC)
+	releases, err = gitlab.Releases(ctx, "example/terraform", "terraform-provider-foo", &since)
+	if err != nil || len(releases) != 0 {
+		t.Errorf("Releases() since %s = %+v, %v, want none", since, releases, err)
+	}
+
+	releases, err = gitlab.Releases(ctx, "example/terraform", "terraform-provider-bar", nil)
+	if err != nil || len(releases) != 0 {
+		t.Errorf("Releases() of a missing project = %+v, %v", releases, err)
+	}
+}
+
+func TestFindRelease(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"tag_name":"1.1.0","created_at":"2023-09-02T00:00:00Z"},{"tag_name":"v1.0.0","created_at":"2023-09-01T00:00:00Z"}]`)
+	}))
+	defer server.Close()
+
+	gitlab := NewGitLab(server.URL, "")
+	gitlab.httpClient = server.Client()
+
+	release, err := FindRelease(context.Background(), gitlab, "example", "terraform-aws-vpc", "v1.0.0", "1.0.0")
+	if err != nil || release == nil || release.TagName != "v1.0.0" {
+		t.Errorf("FindRelease() = %+v, %v, want v1.0.0", release, err)
+	}
+	release, err = FindRelease(context.Background(), gitlab, "example", "terraform-aws-vpc", "v2.0.0")
+	if err != nil || release != nil {
+		t.Errorf("FindRelease() of a missing tag = %+v, %v", release, err)
+	}
+}
@@ -0,0 +1,58 @@
+package vcs
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/opentofu/registry/internal/github"
+)
+
+// WithTagPrefix returns the host of the releases of a repository whose tags start with prefix, e.g. the releases of
+// one of the providers of a monorepo, tagged `foo/v1.2.3`. The other releases of the repository are left out, and
+// the prefix is trimmed from the tags of the releases returned, so that they are handled like the releases of a
+// repository of their own. The tags given to FindRelease and AssetURL are the trimmed ones.
+func WithTagPrefix(host Host, prefix string) Host {
+	if prefix == "" {
+		return host
+	}
+	return tagPrefixHost{Host: host, prefix: prefix}
+}
+
+type tagPrefixHost struct {
+	Host
+	prefix string
+}
+
+func (h tagPrefixHost) Releases(ctx context.Context, owner, name string, since *time.Time) ([]github.GHRelease, error) {
+	releases, err := h.Host.Releases(ctx, owner, name, since)
+	if err != nil {
+		return nil, err
+	}
+
+	var matching []github.GHRelease
+	for _, release := range releases {
+		if tag, ok := strings.CutPrefix(release.TagName, h.prefix); ok {
+			release.TagName = tag
+			matching = append(matching, release)
+		}
+	}
+	return matching, nil
+}
+
+func (h tagPrefixHost) AssetURL(owner, name, tag, asset string) string {
+	return h.Host.AssetURL(owner, name, h.prefix+tag, asset)
+}
+
+func (h tagPrefixHost) findRelease(ctx context.Context, owner, name string, tags ...string) (*github.GHRelease, error) {
+	prefixed := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		prefixed = append(prefixed, h.prefix+tag)
+	}
+	release, err := FindRelease(ctx, h.Host, owner, name, prefixed...)
+	if err != nil || release == nil {
+		return release, err
+	}
+	release.TagName = strings.TrimPrefix(release.TagName, h.prefix)
+	return release, nil
+}
@@ -0,0 +1,69 @@
+package vcs
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWithTagPrefix(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"tag_name":"bar/v2.0.0","created_at":"2023-09-03T00:00:00Z"},
+			{"tag_name":"foo/v1.1.0","created_at":"2023-09-02T00:00:00Z"},
+			{"tag_name":"v1.0.0","created_at":"2023-09-01T00:00:00Z"}]`)
+	}))
+	defer server.Close()
+
+	gitlab := NewGitLab(server.URL, "")
+	gitlab.httpClient = server.Client()
+	host := WithTagPrefix(gitlab, "foo/")
+	ctx := context.Background()
+
+	releases, err := host.Releases(ctx, "example", "providers", nil)
+	if err != nil {
+		t.Fatalf("Releases() error = %v", err)
+	}
+	if len(releases) != 1 || releases[0].TagName != "v1.1.0" {
+		t.Fatalf("Releases() = %+v, want the foo/v1.1.0 release as v1.1.0", releases)
+	}
+
+	release, err := FindRelease(ctx, host, "example", "providers", "v1.1.0")
+	if err != nil || release == nil || release.TagName != "v1.1.0" {
+		t.Errorf("FindRelease() = %+v, %v, want v1.1.0", release, err)
+	}
+	release, err = FindRelease(ctx, host, "example", "providers", "v1.0.0")
+	if err != nil || release != nil {
+		t.Errorf("FindRelease() of a release without the prefix = %+v, %v, want none", release, err)
+	}
+
+	if got, want := host.AssetURL("example", "providers", "v1.1.0", "SHA256SUMS"), server.URL+"/example/providers/-/releases/foo%2Fv1.1.0/downloads/SHA256SUMS"; got != want {
+		t.Errorf("AssetURL() = %q, want %q", got, want)
+	}
+
+	if WithTagPrefix(gitlab, "") != Host(gitlab) {
+		t.Errorf("WithTagPrefix() with an empty prefix wraps the host")
+	}
+}
+
+func TestFindVersionRelease(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"tag_name":"release/1.2.3","created_at":"2023-09-02T00:00:00Z"},
+			{"tag_name":"1.1.0","created_at":"2023-09-01T00:00:00Z"}]`)
+	}))
+	defer server.Close()
+
+	gitlab := NewGitLab(server.URL, "")
+	gitlab.httpClient = server.Client()
+	ctx := context.Background()
+
+	release, err := FindVersionRelease(ctx, gitlab, "example", "providers", "1.1.0")
+	if err != nil || release == nil || release.TagName != "1.1.0" {
+		t.Errorf("FindVersionRelease() of a tag without a v = %+v, %v, want 1.1.0", release, err)
+	}
+	release, err = FindVersionRelease(ctx, WithTagPrefix(gitlab, "release/"), "example", "providers", "1.2.3")
+	if err != nil || release == nil || release.TagName != "1.2.3" {
+		t.Errorf("FindVersionRelease() with a tag prefix = %+v, %v, want release/1.2.3 as 1.2.3", release, err)
+	}
+}
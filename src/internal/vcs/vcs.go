@@ -0,0 +1,80 @@
+// Package vcs abstracts the code hosts the providers and modules are released on: GitHub, and a GitLab instance for
+// the namespaces mapped to GitLab groups. The releases of every host are handled as GitHub releases, the shape the
+// rest of the registry already works with, so the versions are built from them the same way wherever they come from.
+package vcs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	gogithub "github.com/google/go-github/v54/github"
+	"github.com/opentofu/registry/internal/github"
+	"github.com/opentofu/registry/internal/version"
+	"github.com/shurcooL/githubv4"
+)
+
+// Host is a code host the providers and modules are released on.
+type Host interface {
+	// RepositoryExists returns whether the repository exists.
+	RepositoryExists(ctx context.Context, owner, name string) (bool, error)
+	// Releases returns the published releases of the repository, newest first. If since is not nil, the releases
+	// created before it are left out. A repository which does not exist has no releases.
+	Releases(ctx context.Context, owner, name string, since *time.Time) ([]github.GHRelease, error)
+	// AssetURL returns the URL the asset of the release tagged tag is downloaded from.
+	AssetURL(owner, name, tag, asset string) string
+}
+
+// releaseFinder is implemented by the hosts which can find a release without listing the releases first.
+type releaseFinder interface {
+	findRelease(ctx context.Context, owner, name string, tags ...string) (*github.GHRelease, error)
+}
+
+// FindRelease returns the first published release of the repository, newest first, tagged with one of the given tags,
+// or nil if there is none.
+func FindRelease(ctx context.Context, host Host, owner, name string, tags ...string) (*github.GHRelease, error) {
+	if finder, ok := host.(releaseFinder); ok {
+		return finder.findRelease(ctx, owner, name, tags...)
+	}
+
+	releases, err := host.Releases(ctx, owner, name, nil)
+	if err != nil {
+		return nil, err
+	}
+	for i := range releases {
+		for _, tag := range tags {
+			if releases[i].TagName == tag {
+				return &releases[i], nil
+			}
+		}
+	}
+	return nil, nil //nolint:nilnil // The repository has no such release.
+}
+
+// FindVersionRelease returns the published release of the version, tagged with one of version.Tags, or nil if there is
+// none. Repositories tag their releases `v1.2.3` or `1.2.3`, and the version does not tell which.
+func FindVersionRelease(ctx context.Context, host Host, owner, name, versionNumber string) (*github.GHRelease, error) {
+	return FindRelease(ctx, host, owner, name, version.Tags(versionNumber)...)
+}
+
+// GitHub is the Host of the repositories on GitHub, called with the clients of their namespace.
+type GitHub struct {
+	REST    *gogithub.Client
+	GraphQL *githubv4.Client
+}
+
+func (g GitHub) RepositoryExists(ctx context.Context, owner, name string) (bool, error) {
+	return github.RepositoryExists(ctx, g.REST, owner, name)
+}
+
+func (g GitHub) Releases(ctx context.Context, owner, name string, since *time.Time) ([]github.GHRelease, error) {
+	return github.FetchReleases(ctx, g.GraphQL, owner, name, since)
+}
+
+func (g GitHub) AssetURL(owner, name, tag, asset string) string {
+	return fmt.Sprintf("https://github.com/%s/%s/releases/download/%s/%s", owner, name, tag, asset)
+}
+
+func (g GitHub) findRelease(ctx context.Context, owner, name string, tags ...string) (*github.GHRelease, error) {
+	return github.FindReleaseByTag(ctx, g.GraphQL, owner, name, tags...)
+}
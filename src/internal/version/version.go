@@ -0,0 +1,192 @@
+// Package version parses provider and module versions and evaluates version constraints against them.
+package version
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxTagLength caps the length of the release tags accepted as versions.
+const maxTagLength = 128
+
+// semverPattern matches a semantic version, without the leading "v" of tags.
+//
+//nolint:gochecknoglobals // This should be treated as a constant.
+var semverPattern = regexp.MustCompile(`^[0-9]+\.[0-9]+\.[0-9]+(-[0-9A-Za-z-]+(\.[0-9A-Za-z-]+)*)?(\+[0-9A-Za-z-]+(\.[0-9A-Za-z-]+)*)?$`)
+
+// FromTag returns the version of a release tag, `v1.2.3` or `1.2.3`. Tags are chosen by whoever publishes the
+// release and the version ends up in cache keys, paths and URLs, so tags which are not a semantic version are
+// rejected.
+func FromTag(tag string) (string, bool) {
+	if len(tag) > maxTagLength {
+		return "", false
+	}
+	version := strings.TrimPrefix(tag, "v")
+	if !semverPattern.MatchString(version) {
+		return "", false
+	}
+	return version, true
+}
+
+// Tags returns the tags a release of the version may have, the inverse of FromTag: `v1.2.3` as most repositories tag
+// their releases, then `1.2.3`. The tag prefixes of repositories releasing several providers or modules are added by
+// vcs.WithTagPrefix.
+func Tags(version string) []string {
+	version = strings.TrimPrefix(version, "v")
+	return []string{"v" + version, version}
+}
+
+// IsPrerelease returns true if the version has a prerelease suffix, e.g. `1.2.3-rc1`.
+func IsPrerelease(version string) bool {
+	core, _, _ := strings.Cut(version, "+")
+	return strings.Contains(core, "-")
+}
+
+// Constraints is a list of version constraints that must all hold, e.g. ">= 1.0.0, < 2.0.0".
+type Constraints []constraint
+
+type constraint struct {
+	op      string
+	version []int
+}
+
+// ParseConstraints parses a comma-separated list of version constraints. Supported operators are `=`, `!=`, `>`,
+// `>=`, `<`, `<=` and the pessimistic `~>`. A version without an operator must match exactly.
+// An empty string parses to no constraints, which every version satisfies.
+func ParseConstraints(value string) (Constraints, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+
+	var constraints Constraints
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		op := "="
+		for _, candidate := range []string{"~>", ">=", "<=", "!=", ">", "<", "="} {
+			if strings.HasPrefix(part, candidate) {
+				op = candidate
+				part = strings.TrimSpace(strings.TrimPrefix(part, candidate))
+				break
+			}
+		}
+
+		v, err := parse(part)
+		if err != nil {
+			return nil, err
+		}
+		constraints = append(constraints, constraint{op: op, version: v})
+	}
+	return constraints, nil
+}
+
+// Check returns true if the version satisfies all constraints. Versions that cannot be parsed never do.
+func (c Constraints) Check(version string) bool {
+	parsed, err := parse(version)
+	if err != nil {
+		return false
+	}
+	for _, constraint := range c {
+		if !constraint.check(parsed) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c constraint) check(v []int) bool {
+	cmp := compare(v, c.version)
+	switch c.op {
+	case "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case "~>":
+		return cmp >= 0 && compare(v, pessimisticUpperBound(c.version)) < 0
+	}
+	return false
+}
+
+// pessimisticUpperBound returns the exclusive upper bound of `~> version`: only the rightmost component may increase,
+// so `~> 1.2.3` allows up to (but excluding) 1.3.0 and `~> 1.2` up to 2.0.
+func pessimisticUpperBound(v []int) []int {
+	if len(v) < 2 { //nolint:gomnd // a single component has no upper bound
+		return []int{int(^uint(0) >> 1)}
+	}
+	upper := make([]int, len(v)-1)
+	copy(upper, v)
+	upper[len(upper)-1]++
+	return upper
+}
+
+// Compare compares two versions numerically, returning -1, 0 or 1. Versions that cannot be parsed sort first.
+func Compare(a, b string) int {
+	parsedA, errA := parse(a)
+	parsedB, errB := parse(b)
+	switch {
+	case errA != nil && errB != nil:
+		return 0
+	case errA != nil:
+		return -1
+	case errB != nil:
+		return 1
+	}
+	return compare(parsedA, parsedB)
+}
+
+// Valid returns true if the value can be parsed as a version.
+func Valid(value string) bool {
+	_, err := parse(value)
+	return err == nil
+}
+
+// parse parses the numeric part of a version such as "1.2.3" or "v1.2.3-beta1".
+// Any prerelease or build metadata suffix is ignored.
+func parse(version string) ([]int, error) {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if idx := strings.IndexAny(version, "-+"); idx >= 0 {
+		version = version[:idx]
+	}
+	if version == "" {
+		return nil, fmt.Errorf("empty version")
+	}
+
+	parts := strings.Split(version, ".")
+	parsed := make([]int, len(parts))
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version %q: %w", version, err)
+		}
+		parsed[i] = n
+	}
+	return parsed, nil
+}
+
+func compare(a, b []int) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var x, y int
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
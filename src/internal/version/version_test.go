@@ -0,0 +1,136 @@
+package version
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConstraintsCheck(t *testing.T) {
+	tests := []struct {
+		constraints string
+		version     string
+		want        bool
+	}{
+		{constraints: "", version: "1.0.0", want: true},
+		{constraints: "1.2.3", version: "1.2.3", want: true},
+		{constraints: "1.2.3", version: "v1.2.3", want: true},
+		{constraints: ">= 1.0.0, < 2.0.0", version: "1.5.0", want: true},
+		{constraints: ">= 1.0.0, < 2.0.0", version: "2.0.0", want: false},
+		{constraints: "!= 1.0.0", version: "1.0.0", want: false},
+		{constraints: "~> 1.2.3", version: "1.2.9", want: true},
+		{constraints: "~> 1.2.3", version: "1.3.0", want: false},
+		{constraints: "~> 1.2", version: "1.9.0", want: true},
+		{constraints: "~> 1.2", version: "2.0.0", want: false},
+		{constraints: "~> 1", version: "5.0.0", want: true},
+		{constraints: ">= 1.0.0", version: "not-a-version", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.constraints+" "+tt.version, func(t *testing.T) {
+			constraints, err := ParseConstraints(tt.constraints)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := constraints.Check(tt.version); got != tt.want {
+				t.Errorf("Check(%q) = %v, want %v", tt.version, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseConstraintsInvalid(t *testing.T) {
+	if _, err := ParseConstraints(">= one"); err == nil {
+		t.Error("expected an error for an invalid version")
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "1.0.0", b: "1.0.0", want: 0},
+		{a: "1.0", b: "1.0.0", want: 0},
+		{a: "1.10.0", b: "1.9.0", want: 1},
+		{a: "v0.1.0", b: "0.2.0", want: -1},
+		{a: "invalid", b: "0.0.1", want: -1},
+	}
+
+	for _, tt := range tests {
+		if got := Compare(tt.a, tt.b); got != tt.want {
+			t.Errorf("Compare(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestFromTag(t *testing.T) {
+	tests := []struct {
+		tag    string
+		want   string
+		wantOK bool
+	}{
+		{tag: "v1.2.3", want: "1.2.3", wantOK: true},
+		{tag: "1.2.3", want: "1.2.3", wantOK: true},
+		{tag: "v1.0.0-beta.1+build.5", want: "1.0.0-beta.1+build.5", wantOK: true},
+		{tag: "latest", wantOK: false},
+		{tag: "v1.2", wantOK: false},
+		{tag: "v1.2.3/../../x", wantOK: false},
+		{tag: "v1.2.3\n", wantOK: false},
+		{tag: "v1.2.3-" + strings.Repeat("a", maxTagLength), wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			got, ok := FromTag(tt.tag)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("FromTag(%q) = %q, %v, want %q, %v", tt.tag, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestTags(t *testing.T) {
+	for _, v := range []string{"1.2.3", "v1.2.3"} {
+		tags := Tags(v)
+		if !reflect.DeepEqual(tags, []string{"v1.2.3", "1.2.3"}) {
+			t.Errorf("Tags(%q) = %v, want [v1.2.3 1.2.3]", v, tags)
+		}
+		for _, tag := range tags {
+			if got, ok := FromTag(tag); !ok || got != "1.2.3" {
+				t.Errorf("FromTag(%q) = %q, %v, want 1.2.3", tag, got, ok)
+			}
+		}
+	}
+}
+
+func FuzzFromTag(f *testing.F) {
+	for _, seed := range []string{"v1.2.3", "1.0.0-rc.1", "latest", "v", ""} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, tag string) {
+		version, ok := FromTag(tag)
+		if !ok {
+			return
+		}
+		if strings.ContainsAny(version, "/\\ \n?#%") {
+			t.Errorf("FromTag(%q) accepted unsafe version %q", tag, version)
+		}
+		if again, ok := FromTag(version); !ok || again != version {
+			t.Errorf("FromTag(%q) is not stable: %q, %v", version, again, ok)
+		}
+	})
+}
+
+func TestIsPrerelease(t *testing.T) {
+	for version, want := range map[string]bool{
+		"1.2.3":                false,
+		"1.2.3-rc1":            true,
+		"1.0.0-beta.1+build.5": true,
+		"1.0.0+build-5":        false,
+	} {
+		if got := IsPrerelease(version); got != want {
+			t.Errorf("IsPrerelease(%q) = %v, want %v", version, got, want)
+		}
+	}
+}
@@ -1,6 +1,20 @@
 // Package warnings defines the warnings associated with the provider
 package warnings
 
+import "fmt"
+
+// ArchivedRepository is the warning for providers whose source repository has been archived.
+const ArchivedRepository = "The source repository of this provider is archived, it is not expected to receive new versions."
+
+// UnsignedChecksums is the warning for provider versions that cannot be verified, because their SHA256SUMS file is not
+// signed or there is no public key registered for their namespace.
+const UnsignedChecksums = "This provider version cannot be verified: its checksums are not signed with a key registered for this namespace."
+
+// Quarantined is the warning for a provider version that is listed but cannot be downloaded until it is resolved.
+func Quarantined(version, reason string) string {
+	return fmt.Sprintf("Version %s is quarantined and cannot be downloaded: %s", version, reason)
+}
+
 // ProviderWarnings return the list of warnings for a given provider identified by its namespace and type
 //
 // Example: registry.terraform.io/hashicorp/terraform
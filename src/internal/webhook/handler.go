@@ -0,0 +1,184 @@
+// Package webhook refreshes the cache of the registry when GitHub notifies it of a release, so that new releases are
+// served within seconds rather than once the cached versions go stale.
+package webhook
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	gogithub "github.com/google/go-github/v54/github"
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/modules"
+	"github.com/opentofu/registry/internal/modules/modulecache"
+	"github.com/opentofu/registry/internal/refresh"
+	"golang.org/x/exp/slog"
+)
+
+// LambdaFunc handles a GitHub webhook delivered to the URL of the lambda.
+type LambdaFunc func(context.Context, events.LambdaFunctionURLRequest) (events.LambdaFunctionURLResponse, error)
+
+// target is what a release of a repository refreshes.
+type target struct {
+	// providers are the `<namespace>/<type>` addresses of the providers released from the repository.
+	providers []string
+	// module is set if the repository holds modules.
+	module *modules.Repository
+}
+
+func setupLogging() {
+	slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stdout, nil)))
+}
+
+// HandleRequest handles the GitHub release webhooks. Webhooks are authenticated by their HMAC signature, and the
+// events other than releases are ignored. A release of a provider refreshes its cached versions through the
+// populate_provider_versions lambda, and a release of a module refreshes the cached versions of its repository. The
+// providers are refreshed in the registry of the tenant named by the `tenant` query parameter of the webhook URL, the
+// main registry without one.
+func HandleRequest(baseConfig *config.Config) LambdaFunc {
+	return func(ctx context.Context, req events.LambdaFunctionURLRequest) (events.LambdaFunctionURLResponse, error) {
+		setupLogging()
+
+		config := baseConfig
+		if tenant := req.QueryStringParameters["tenant"]; tenant != "" {
+			scoped := baseConfig.ForTenant(tenant)
+			if scoped.TenantName != tenant {
+				return textResponse(http.StatusNotFound, "unknown tenant"), nil
+			}
+			config = &scoped
+		}
+
+		if config.ReleaseWebhookSecret == "" {
+			// an empty secret would accept the webhooks signed with an empty secret too
+			slog.Error("The release webhook secret is not set")
+			return textResponse(http.StatusInternalServerError, "webhook secret not configured"), nil
+		}
+
+		body := []byte(req.Body)
+		if req.IsBase64Encoded {
+			decoded, err := base64.StdEncoding.DecodeString(req.Body)
+			if err != nil {
+				return textResponse(http.StatusBadRequest, "invalid body"), nil
+			}
+			body = decoded
+		}
+		if err := gogithub.ValidateSignature(req.Headers["x-hub-signature-256"], body, []byte(config.ReleaseWebhookSecret)); err != nil {
+			slog.Warn("Rejecting webhook with an invalid signature", "error", err)
+			return textResponse(http.StatusUnauthorized, "invalid signature"), nil
+		}
+
+		// GitHub sends a ping when the webhook is created, and any other event it is subscribed to
+		if eventType := req.Headers["x-github-event"]; eventType != "release" {
+			slog.Info("Ignoring webhook", "event", eventType)
+			return textResponse(http.StatusOK, "ignored"), nil
+		}
+
+		var event gogithub.ReleaseEvent
+		if err := json.Unmarshal(body, &event); err != nil {
+			return textResponse(http.StatusBadRequest, "invalid release event"), nil
+		}
+		owner, repoName := event.GetRepo().GetOwner().GetLogin(), event.GetRepo().GetName()
+		slog.SetDefault(slog.Default().With("repository", fmt.Sprintf("%s/%s", owner, repoName)).With("action", event.GetAction()))
+
+		// the cache must not change while the registry is in maintenance, the delivery can be redelivered afterwards
+		if config.MaintenanceMode {
+			slog.Warn("Registry is in maintenance mode, not refreshing")
+			return textResponse(http.StatusServiceUnavailable, "maintenance"), nil
+		}
+
+		t, err := resolveTarget(ctx, config, owner, repoName, event.GetRelease().GetTagName())
+		if err != nil {
+			slog.Error("Error resolving the providers and modules of the repository", "error", err)
+			return textResponse(http.StatusInternalServerError, "refresh failed"), nil
+		}
+		if len(t.providers) == 0 && t.module == nil {
+			slog.Info("Repository holds no provider or module, ignoring release")
+			return textResponse(http.StatusOK, "ignored"), nil
+		}
+		for _, provider := range t.providers {
+			namespace, providerType, _ := strings.Cut(provider, "/")
+			if err := refreshProvider(ctx, config, namespace, providerType); err != nil {
+				slog.Error("Error triggering provider refresh", "provider", provider, "error", err)
+				return textResponse(http.StatusInternalServerError, "refresh failed"), nil
+			}
+		}
+		if t.module != nil {
+			if err := refreshModule(ctx, config, *t.module); err != nil {
+				slog.Error("Error refreshing module versions", "error", err)
+				return textResponse(http.StatusInternalServerError, "refresh failed"), nil
+			}
+		}
+		return textResponse(http.StatusAccepted, "refreshing"), nil
+	}
+}
+
+// resolveTarget returns what a release of the repository, tagged tag, refreshes. The providers mapped to the repository
+// in the repository mappings table are the ones released from it; without any, providers are served under the
+// namespace of the owner of their repository, see config.ProviderRepoNames. Modules are only refreshed if their
+// versions are cached.
+func resolveTarget(ctx context.Context, config *config.Config, owner, repoName, tag string) (target, error) {
+	var t target
+	if config.RepositoryMappings != nil {
+		// the mappings are few and managed by hand, and releases are rare, so they are listed rather than indexed
+		mappings, err := config.RepositoryMappings.List(ctx)
+		if err != nil {
+			return t, err
+		}
+		for _, m := range mappings {
+			if m.IsProvider() && m.Releases(owner, repoName, tag) {
+				namespace, providerType := m.Provider()
+				t.providers = append(t.providers, namespace+"/"+providerType)
+			}
+		}
+	}
+	if len(t.providers) == 0 {
+		if providerType, ok := config.ProviderRepoNames.ProviderType(owner, repoName); ok {
+			t.providers = []string{strings.ToLower(owner) + "/" + providerType}
+		}
+	}
+	if config.ModuleVersionCache == nil {
+		return t, nil
+	}
+
+	isModule := modules.IsModuleRepo(repoName)
+	for _, r := range config.ModuleRepositories {
+		isModule = isModule || (strings.EqualFold(r.Owner, owner) && strings.EqualFold(r.Name, repoName))
+	}
+	if isModule {
+		t.module = &modules.Repository{Owner: owner, Name: repoName}
+	}
+	return t, nil
+}
+
+// refreshProvider starts fetching the releases published since the provider was last refreshed, stale or not, in the
+// registry of the tenant of the configuration. Like the refreshes the API starts, it goes through the refresh queue if
+// there is one, so that failed refreshes are retried.
+func refreshProvider(ctx context.Context, config *config.Config, namespace, providerType string) error {
+	if err := refresh.Send(ctx, *config, refresh.Event{Namespace: namespace, Type: providerType, Tenant: config.TenantName, Force: true}); err != nil {
+		return fmt.Errorf("failed to start refresh: %w", err)
+	}
+	return nil
+}
+
+// refreshModule fetches the versions of the modules of the repository and caches them. Module repositories have few
+// releases, so this is done before answering the webhook.
+func refreshModule(ctx context.Context, config *config.Config, repo modules.Repository) error {
+	versions, err := modules.GetVersions(ctx, config.RepositoryHost(repo), repo.Owner, repo.Name, nil)
+	if err != nil {
+		return fmt.Errorf("failed to fetch module versions: %w", err)
+	}
+	return config.ModuleVersionCache.Store(ctx, modulecache.Key(repo), versions)
+}
+
+func textResponse(statusCode int, body string) events.LambdaFunctionURLResponse {
+	return events.LambdaFunctionURLResponse{
+		StatusCode: statusCode,
+		Headers:    map[string]string{"Content-Type": "text/plain"},
+		Body:       body,
+	}
+}
@@ -0,0 +1,131 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/opentofu/registry/internal/awstest"
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/modules"
+	"github.com/opentofu/registry/internal/modules/modulecache"
+	"github.com/opentofu/registry/internal/providers"
+	"github.com/opentofu/registry/internal/repomappings"
+)
+
+func signedRequest(secret, eventType, body string) events.LambdaFunctionURLRequest {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return events.LambdaFunctionURLRequest{
+		Headers: map[string]string{
+			"x-github-event":      eventType,
+			"x-hub-signature-256": "sha256=" + hex.EncodeToString(mac.Sum(nil)),
+		},
+		Body: body,
+	}
+}
+
+func withTenant(req events.LambdaFunctionURLRequest, tenant string) events.LambdaFunctionURLRequest {
+	req.QueryStringParameters = map[string]string{"tenant": tenant}
+	return req
+}
+
+func TestHandleRequest(t *testing.T) {
+	cfg := &config.Config{ReleaseWebhookSecret: "secret"}
+	release := `{"action": "published", "repository": {"name": "docs", "owner": {"login": "example"}}}`
+
+	tests := []struct {
+		name     string
+		req      events.LambdaFunctionURLRequest
+		wantCode int
+	}{
+		{name: "invalid signature", req: signedRequest("other", "release", release), wantCode: http.StatusUnauthorized},
+		{name: "ping", req: signedRequest("secret", "ping", `{"zen": "Keep it logically awesome."}`), wantCode: http.StatusOK},
+		{name: "not a provider or module", req: signedRequest("secret", "release", release), wantCode: http.StatusOK},
+		{name: "invalid event", req: signedRequest("secret", "release", `{`), wantCode: http.StatusBadRequest},
+		{name: "unknown tenant", req: withTenant(signedRequest("secret", "release", release), "unknown"), wantCode: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := HandleRequest(cfg)(context.Background(), tt.req)
+			if err != nil {
+				t.Fatalf("HandleRequest() error = %v", err)
+			}
+			if response.StatusCode != tt.wantCode {
+				t.Errorf("status = %d, want %d", response.StatusCode, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestResolveTarget(t *testing.T) {
+	repoNames, err := providers.ParseRepoNames(`{"example": "opentofu-provider-{type}"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{
+		ProviderRepoNames:  repoNames,
+		ModuleVersionCache: &modulecache.Handler{},
+		ModuleRepositories: map[string]modules.Repository{"example/vpc/aws": {Owner: "example", Name: "infrastructure"}},
+	}
+
+	tests := []struct {
+		owner, repoName string
+		wantProviders   []string
+		wantModule      bool
+	}{
+		{owner: "Example", repoName: "opentofu-provider-foo", wantProviders: []string{"example/foo"}},
+		{owner: "other", repoName: "terraform-provider-bar", wantProviders: []string{"other/bar"}},
+		{owner: "other", repoName: "terraform-aws-vpc", wantModule: true},
+		{owner: "example", repoName: "Infrastructure", wantModule: true},
+		{owner: "other", repoName: "infrastructure"},
+	}
+	for _, tt := range tests {
+		got, err := resolveTarget(context.Background(), cfg, tt.owner, tt.repoName, "v1.0.0")
+		if err != nil || !reflect.DeepEqual(got.providers, tt.wantProviders) || (got.module != nil) != tt.wantModule {
+			t.Errorf("resolveTarget(%s/%s) = %+v, %v, want providers %v and module %v", tt.owner, tt.repoName, got, err, tt.wantProviders, tt.wantModule)
+		}
+	}
+
+	// the versions of modules are only refreshed where they are cached
+	cfg.ModuleVersionCache = nil
+	if got, _ := resolveTarget(context.Background(), cfg, "other", "terraform-aws-vpc", "v1.0.0"); got.module != nil {
+		t.Errorf("resolveTarget() = %+v without a module cache, want no module", got)
+	}
+}
+
+func TestResolveTargetMappings(t *testing.T) {
+	client := awstest.DynamoDBClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+		_, _ = w.Write([]byte(`{"Items":[
+			{"address":{"S":"providers/acme/foo"},"owner":{"S":"Acme"},"repository":{"S":"monorepo"},"tag_prefix":{"S":"foo/"}},
+			{"address":{"S":"providers/acme/bar"},"owner":{"S":"acme"},"repository":{"S":"monorepo"},"tag_prefix":{"S":"bar/"}},
+			{"address":{"S":"providers/acme/baz"},"owner":{"S":"acme"},"repository":{"S":"terraform-provider-other"}}
+		]}`))
+	}))
+	cfg := &config.Config{RepositoryMappings: &repomappings.Handler{TableName: aws.String("mappings"), Client: client}}
+
+	tests := []struct {
+		repoName, tag string
+		want          []string
+	}{
+		{repoName: "monorepo", tag: "foo/v1.0.0", want: []string{"acme/foo"}},
+		{repoName: "monorepo", tag: "bar/v2.0.0", want: []string{"acme/bar"}},
+		{repoName: "monorepo", tag: "v1.0.0"},
+		// the mapped repository is released as the mapped provider, not the one of the convention
+		{repoName: "terraform-provider-other", tag: "v1.0.0", want: []string{"acme/baz"}},
+		{repoName: "terraform-provider-qux", tag: "v1.0.0", want: []string{"acme/qux"}},
+	}
+	for _, tt := range tests {
+		got, err := resolveTarget(context.Background(), cfg, "ACME", tt.repoName, tt.tag)
+		if err != nil || !reflect.DeepEqual(got.providers, tt.want) {
+			t.Errorf("resolveTarget(%s, %s) = %v, %v, want %v", tt.repoName, tt.tag, got.providers, err, tt.want)
+		}
+	}
+}
@@ -2,21 +2,21 @@ package main
 
 import (
 	"context"
+	"os"
 
-	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
-	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/api"
+	"github.com/opentofu/registry/internal/metrics"
+	"golang.org/x/exp/slog"
 )
 
-type LambdaFunc func(context.Context, events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error)
-
 func main() {
-	configBuilder := config.NewBuilder(config.WithProviderRedirects())
+	slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stdout, nil)))
 
-	config, err := configBuilder.BuildConfig(context.Background(), "registry.buildconfig")
+	config, err := api.NewConfigBuilder().BuildConfig(context.Background(), "registry.buildconfig")
 	if err != nil {
 		panic(err)
 	}
 
-	lambda.Start(Router(*config))
+	lambda.Start(metrics.WithEMF(api.Router(*config)))
 }
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/metrics"
+	"github.com/opentofu/registry/internal/populate"
+)
+
+func main() {
+	configBuilder := config.NewBuilder(config.WithOCIProviders(), config.WithGitLab(), config.WithQuarantine(), config.WithGitHubAppAuth())
+	config, err := configBuilder.BuildConfig(context.Background(), "populate_provider_versions_worker.buildconfig")
+	if err != nil {
+		panic(fmt.Errorf("could not build config: %w", err))
+	}
+
+	lambda.Start(metrics.WithEMF(populate.HandleQueue(config)))
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/opentofu/registry/internal/config"
+	"github.com/opentofu/registry/internal/metrics"
+	"github.com/opentofu/registry/internal/webhook"
+)
+
+func main() {
+	configBuilder := config.NewBuilder(config.WithReleaseWebhook(), config.WithGitHubAppAuth())
+	config, err := configBuilder.BuildConfig(context.Background(), "release_webhook.buildconfig")
+	if err != nil {
+		panic(fmt.Errorf("could not build config: %w", err))
+	}
+
+	lambda.Start(metrics.WithEMF(webhook.HandleRequest(config)))
+}
@@ -15,9 +15,451 @@ variable "domain_name" {
   type = string
 }
 
+// provider namespaces whose releases are fetched from another namespace. Chains are followed, loops are rejected
 variable "provider_namespace_redirects" {
   type = map(any)
   default = {
     "hashicorp" : "opentofu"
   }
 }
+
+// how often the api lambda loads again the provider namespace redirects managed through the admin API, as a Go
+// duration. Empty for the default of 5m
+variable "provider_redirects_refresh_interval" {
+  type    = string
+  default = ""
+}
+
+variable "admin_api_token" {
+  type      = string
+  sensitive = true
+}
+
+// additional hostnames the registry is served on, each needs its own custom domain mapping to the API stage
+variable "additional_hostnames" {
+  type    = list(string)
+  default = []
+}
+
+// advertise the /v2/providers/ endpoints as providers.v2 in /.well-known/terraform.json
+variable "service_discovery_providers_v2" {
+  type    = bool
+  default = false
+}
+
+// login.v1 service advertised in /.well-known/terraform.json, the OAuth client `tofu login` gets tokens with. null to
+// not advertise it
+variable "service_discovery_login" {
+  type = object({
+    client      = string
+    grant_types = optional(list(string))
+    authz       = optional(string)
+    token       = string
+    ports       = optional(list(number))
+  })
+  default = null
+}
+
+// further services advertised in /.well-known/terraform.json by service id, e.g. a provider mirror
+variable "service_discovery_services" {
+  type    = any
+  default = {}
+}
+
+// origins of the browser-based frontends, e.g. a registry UI, allowed to call the API directly. ["*"] allows any origin
+variable "cors_allowed_origins" {
+  type    = list(string)
+  default = []
+}
+
+// requests per minute each client of the API is allowed, identified by its API key, private providers read token or
+// source IP. 0 disables the rate limit
+variable "rate_limit_requests_per_minute" {
+  type    = number
+  default = 0
+}
+
+// requests a client which was idle may make at once. 0 defaults to a minute of requests
+variable "rate_limit_burst" {
+  type    = number
+  default = 0
+}
+
+// tenant registries served from this deployment, keyed by hostname. each tenant gets its own provider versions table
+variable "tenants" {
+  type = map(object({
+    name                         = string
+    provider_namespace_redirects = optional(map(string), {})
+  }))
+  default = {}
+}
+
+// rewrite rules applied, in order, to module download sources. the first rule whose regex matches wins
+variable "module_source_rewrites" {
+  type = list(object({
+    match   = string
+    replace = string
+  }))
+  default = []
+}
+
+// makes module downloads point at the tarball of the GitHub release instead of the git repository
+variable "module_archive_sources" {
+  type    = bool
+  default = false
+}
+
+// makes module downloads point at byte-stable archives: the tarball attached to the release, or else an archive of the
+// tree of the tag built by the populate lambda and stored in the asset mirror bucket
+variable "module_stable_archives" {
+  type    = bool
+  default = false
+}
+
+// repositories of the modules not following the terraform-<system>-<name> naming convention, mapping
+// "namespace/name/system" to the GitHub owner, repository and, for monorepos, the subdirectory of the module
+variable "module_repositories" {
+  type = map(object({
+    owner        = string
+    repository   = string
+    subdirectory = optional(string)
+  }))
+  default = {}
+}
+
+// providers published as OCI artifacts, mapping "namespace/type" to the repository, e.g. "ghcr.io/example/terraform-provider-foo"
+variable "oci_providers" {
+  type    = map(string)
+  default = {}
+}
+
+// optional "username:password" credentials for pulling provider artifacts from the OCI registry
+variable "oci_registry_credentials" {
+  type      = string
+  sensitive = true
+  default   = ""
+}
+
+// namespaces whose providers and modules are released on GitLab, mapping the namespace to the full path of its group, e.g. "example/terraform"
+variable "gitlab_namespaces" {
+  type    = map(string)
+  default = {}
+}
+
+// the GitLab instance the namespaces of gitlab_namespaces are on
+variable "gitlab_url" {
+  type    = string
+  default = "https://gitlab.com"
+}
+
+// optional access token for reading the projects of gitlab_namespaces, without which only public projects are served
+variable "gitlab_token" {
+  type      = string
+  sensitive = true
+  default   = ""
+}
+
+// the GitHub App installation the registry authenticates as instead of github_api_token, if github_app_id is set
+variable "github_app_id" {
+  type    = string
+  default = ""
+}
+
+variable "github_app_installation_id" {
+  type    = string
+  default = ""
+}
+
+// the PEM encoded private key of the GitHub App
+variable "github_app_private_key" {
+  type      = string
+  sensitive = true
+  default   = ""
+}
+
+// the secret the GitHub release webhooks are signed with, deploys the release webhook lambda if set
+variable "release_webhook_secret" {
+  type      = string
+  sensitive = true
+  default   = ""
+}
+
+// additional GitHub tokens the registry's own GitHub calls are spread over, along with github_api_token
+variable "github_token_pool" {
+  type      = list(string)
+  sensitive = true
+  default   = []
+}
+
+// optional GitHub tokens used instead of github_api_token for the repositories of a namespace, keyed by namespace
+variable "namespace_github_tokens" {
+  type      = map(string)
+  sensitive = true
+  default   = {}
+}
+
+// providers only served to the requests carrying one of their tokens, keyed by namespace or namespace/type
+variable "private_providers" {
+  type      = map(list(string))
+  sensitive = true
+  default   = {}
+}
+
+// names of the repositories of providers not named terraform-provider-<type>, keyed by namespace or namespace/type,
+// with {type} replaced by the provider type, e.g. { example = "opentofu-provider-{type}" }
+variable "provider_repositories" {
+  type    = map(string)
+  default = {}
+}
+
+// regular expressions matching the release assets of providers not named the standard way, keyed by namespace or
+// namespace/type. Patterns left out match the standard names
+variable "asset_name_patterns" {
+  type = map(object({
+    platform          = optional(string, "")
+    shasums           = optional(string, "")
+    shasums_signature = optional(string, "")
+    manifest          = optional(string, "")
+  }))
+  default = {}
+}
+
+// platforms ingested and served, as <os>_<arch> with * wildcards, keyed by namespace or namespace/type, with "*"
+// applying to the providers not listed. Providers without an allowlist serve every platform
+variable "platform_allowlist" {
+  type    = map(list(string))
+  default = {}
+}
+
+// limits of a single provider refresh, keyed by namespace, with "*" applying to the namespaces not listed. 0 is unlimited
+variable "ingestion_quotas" {
+  type = map(object({
+    github_calls     = optional(number, 0)
+    releases_per_run = optional(number, 0)
+    asset_bytes      = optional(number, 0)
+  }))
+  default = {}
+}
+
+// private S3 bucket, in the region of the lambdas, mirroring the release assets under
+// providers/<namespace>/<type>/<version>/<filename>. Downloads are served from it through pre-signed URLs if set
+variable "asset_mirror_bucket" {
+  type    = string
+  default = ""
+}
+
+// how long the pre-signed URLs to the asset mirror are valid for, as a Go duration
+variable "asset_mirror_url_ttl" {
+  type    = string
+  default = "15m"
+}
+
+// copy the release assets of the provider versions into asset_mirror_bucket when they are first cached
+variable "asset_mirror_populate" {
+  type    = bool
+  default = false
+}
+
+// the schedule expression of the checks repairing the missing and corrupted objects of asset_mirror_bucket, e.g.
+// "rate(1 day)", disabled if empty. Requires asset_mirror_populate
+variable "asset_mirror_reconcile_schedule" {
+  type    = string
+  default = ""
+}
+
+// the number of cached providers picked at random by each asset mirror check
+variable "asset_mirror_reconcile_sample" {
+  type    = number
+  default = 10
+}
+
+// base URL of a CloudFront distribution in front of asset_mirror_bucket. Downloads are served from it through signed
+// URLs instead of pre-signed S3 URLs if set
+variable "asset_mirror_cloudfront_url" {
+  type    = string
+  default = ""
+}
+
+// the ID of the public key of the CloudFront distribution the download URLs are signed for
+variable "asset_mirror_cloudfront_key_pair_id" {
+  type    = string
+  default = ""
+}
+
+// the PEM encoded private key of the public key of the CloudFront distribution
+variable "asset_mirror_cloudfront_private_key" {
+  type      = string
+  sensitive = true
+  default   = ""
+}
+
+// public mirrors of the release assets with the layout of the asset mirror, listed as alternative download locations
+// of cached provider versions. Lower priorities are preferred, the asset mirror has 10 and GitHub 100
+variable "regional_mirrors" {
+  type = list(object({
+    name     = string
+    url      = string
+    priority = optional(number)
+  }))
+  default = []
+}
+
+// S3 bucket, in the region of the lambdas, keeping the GitHub releases seen by the provider refreshes under
+// snapshots/, from which the cached versions can be rebuilt without querying GitHub
+variable "ingestion_snapshot_bucket" {
+  type    = string
+  default = ""
+}
+
+// S3 bucket, in the region of the lambdas, keeping under listings/ the v1 versions listings larger than
+// large_listing_threshold bytes, and under cache/ the cached versions too large for a DynamoDB item
+variable "large_listings_bucket" {
+  type    = string
+  default = ""
+}
+
+// size in bytes above which the v1 versions listing of a provider is kept in large_listings_bucket
+variable "large_listing_threshold" {
+  type    = number
+  default = 1048576
+}
+
+// base URL of the CloudFront distribution in front of large_listings_bucket, which clients are redirected to for the
+// large listings. If empty, the API reads the listings from the bucket and returns them itself
+variable "large_listings_url" {
+  type    = string
+  default = ""
+}
+
+// how old cached provider versions get before they are refreshed, as a Go duration. Empty for the default of 55m
+variable "provider_cache_ttl" {
+  type    = string
+  default = ""
+}
+
+// how long providers are remembered as not found before they are looked up again, as a Go duration. Empty for the
+// default of 5m
+variable "not_found_cache_ttl" {
+  type    = string
+  default = ""
+}
+
+// how old cached module versions get before they are refreshed, as a Go duration. Empty for the default of 15m
+variable "module_cache_ttl" {
+  type    = string
+  default = ""
+}
+
+// how long clients and CDNs may reuse the versions listings and downloads of cached providers, as a Go duration. Empty
+// for the default of 5m
+variable "response_max_age" {
+  type    = string
+  default = ""
+}
+
+// CloudWatch namespace of the metrics the lambdas log in the Embedded Metric Format. Empty for the default of
+// OpenTofuRegistry
+variable "metrics_namespace" {
+  type    = string
+  default = ""
+}
+
+// number of the newest cached versions of a provider whose SHA256SUMS are checked again on each refresh. 0 disables it
+variable "checksum_reconcile_versions" {
+  type    = number
+  default = 0
+}
+
+// the number of refreshes of a provider which have to fail in a row for its refreshes to be quarantined, disabled if 0
+variable "refresh_quarantine_failures" {
+  type    = number
+  default = 5
+}
+
+// schema version the provider cache items are written in, empty for the current one. Set it to the previous schema
+// version while rolling out a schema change, until both lambdas run the new release
+variable "cache_schema_write_version" {
+  type    = string
+  default = ""
+}
+
+// faults injected into the calls to GitHub and the provider cache, to test how the registry copes with them. Staging only
+variable "fault_injection" {
+  type = list(object({
+    target      = string
+    operation   = optional(string)
+    probability = optional(number)
+    latency     = optional(string)
+    error       = optional(string)
+    rate_limit  = optional(bool)
+  }))
+  default = []
+}
+
+// caps the number of versions in v1 versions listings, with a continuation URL to the rest. 0 disables the cap
+variable "v1_versions_page_size" {
+  type    = number
+  default = 0
+}
+
+// makes the requests carrying a bearer token call GitHub with it instead of the registry's token, to serve private repositories to their users
+variable "github_token_passthrough" {
+  type    = bool
+  default = false
+}
+
+// serves the stale cached module versions straight away and refreshes them in the background, through the refresh
+// queue if refresh_schedule is set, instead of fetching them from GitHub during the request
+variable "serve_stale" {
+  type    = bool
+  default = false
+}
+
+// makes the registry read-only and serves providers from the cache only, for data migrations and GitHub incidents
+variable "maintenance_mode" {
+  type    = bool
+  default = false
+}
+
+// the schedule expression of the drift checks comparing a sample of the cached providers with GitHub, e.g. "rate(1 hour)", disabled if empty
+variable "drift_check_schedule" {
+  type    = string
+  default = ""
+}
+
+// the number of cached providers picked at random by each drift check
+variable "drift_check_sample" {
+  type    = number
+  default = 10
+}
+
+// makes the drift checks add the missing versions to the cache and remove the versions whose release was deleted
+variable "drift_auto_heal" {
+  type    = bool
+  default = false
+}
+
+// the schedule expression of the refreshes of all the cached providers through the refresh queue, e.g. "rate(6 hours)", disabled if empty
+variable "refresh_schedule" {
+  type    = string
+  default = ""
+}
+
+// the number of worker lambdas refreshing providers from the refresh queue at the same time, at least 2
+variable "refresh_concurrency" {
+  type    = number
+  default = 5
+}
+
+// the number of providers a worker lambda refreshes in parallel, taken from the refresh queue together, at most 10.
+// Drift checks of a sample check as many providers at a time
+variable "refresh_workers" {
+  type    = number
+  default = 4
+}
+
+// the number of times a provider refresh is attempted before its message is moved to the dead-letter queue
+variable "refresh_max_receive_count" {
+  type    = number
+  default = 3
+}